@@ -0,0 +1,102 @@
+package valr
+
+import (
+	"context"
+	"time"
+)
+
+// FetchTiming records when a request was made, before any wait for the
+// rate limiter, and when its response was received. The data returned
+// reflects the account at some moment in between.
+type FetchTiming struct {
+	SentAt     time.Time
+	ReceivedAt time.Time
+}
+
+// AccountState is a view of an account's balances, open orders and open
+// futures positions fetched together by Client.AccountState, so that risk
+// checks can reason about them as one.
+type AccountState struct {
+	Balances   []AccountBalance
+	OpenOrders []OpenOrder
+	Positions  []OpenPosition
+
+	BalancesTiming   FetchTiming
+	OpenOrdersTiming FetchTiming
+	PositionsTiming  FetchTiming
+}
+
+// Window returns the time between the first request being sent and the last
+// response being received. Changes to the account within the window, such
+// as a fill, may be reflected in some parts of the state and not others.
+func (s *AccountState) Window() time.Duration {
+	return s.ReceivedAt().Sub(s.SentAt())
+}
+
+// SentAt returns when the first request was sent.
+func (s *AccountState) SentAt() time.Time {
+	t := s.BalancesTiming.SentAt
+	for _, timing := range []FetchTiming{s.OpenOrdersTiming, s.PositionsTiming} {
+		if timing.SentAt.Before(t) {
+			t = timing.SentAt
+		}
+	}
+	return t
+}
+
+// ReceivedAt returns when the last response was received.
+func (s *AccountState) ReceivedAt() time.Time {
+	t := s.BalancesTiming.ReceivedAt
+	for _, timing := range []FetchTiming{s.OpenOrdersTiming, s.PositionsTiming} {
+		if timing.ReceivedAt.After(t) {
+			t = timing.ReceivedAt
+		}
+	}
+	return t
+}
+
+// Age returns how old the state may be at now: the time since the first
+// request was sent.
+func (s *AccountState) Age(now time.Time) time.Duration {
+	return now.Sub(s.SentAt())
+}
+
+// Stale returns true if the state may be older than maxAge.
+func (s *AccountState) Stale(maxAge time.Duration) bool {
+	return s.Age(time.Now()) > maxAge
+}
+
+// AccountState fetches the account's balances, open orders and open futures
+// positions concurrently, so the responses reflect the account at as close
+// to the same moment as the rate limiter allows. Check Window to see how
+// far apart they were read. If any request fails the remaining requests are
+// cancelled and the first error is returned.
+func (cl *Client) AccountState(ctx context.Context) (*AccountState, error) {
+	g, ctx := newFetchGroup(ctx)
+	defer g.cancel()
+
+	state := new(AccountState)
+	g.fetch(func() (err error) {
+		state.BalancesTiming.SentAt = time.Now()
+		state.Balances, err = cl.GetAccountBalancesRequest(ctx, &GetAccountBalancesRequest{})
+		state.BalancesTiming.ReceivedAt = time.Now()
+		return err
+	})
+	g.fetch(func() (err error) {
+		state.OpenOrdersTiming.SentAt = time.Now()
+		state.OpenOrders, err = cl.GetAllOpenOrdersRequest(ctx, &GetAllOpenOrdersRequest{})
+		state.OpenOrdersTiming.ReceivedAt = time.Now()
+		return err
+	})
+	g.fetch(func() (err error) {
+		state.PositionsTiming.SentAt = time.Now()
+		state.Positions, err = cl.GetOpenPositionsRequest(ctx, &GetOpenPositionsRequest{})
+		state.PositionsTiming.ReceivedAt = time.Now()
+		return err
+	})
+
+	if err := g.wait(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
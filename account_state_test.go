@@ -0,0 +1,78 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestAccountState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/account/balances":
+			w.Write([]byte(`[{"currency":"ZAR","available":"90","reserved":"10","total":"100"}]`))
+		case "/orders/open":
+			w.Write([]byte(`[{"orderId":"o1","currencyPair":"BTCZAR"}]`))
+		case "/positions/open":
+			w.Write([]byte(`[{"pair":"BTCUSDTPERP","quantity":"0.5"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+
+	before := time.Now()
+	state, err := cl.AccountState(context.Background())
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(state.Balances) != 1 || state.Balances[0].Total.String() != "100" {
+		t.Errorf("Expected ZAR balance, got %v", state.Balances)
+	}
+	if len(state.OpenOrders) != 1 || state.OpenOrders[0].OrderID != "o1" {
+		t.Errorf("Expected open order o1, got %v", state.OpenOrders)
+	}
+	if len(state.Positions) != 1 || state.Positions[0].Pair != "BTCUSDTPERP" {
+		t.Errorf("Expected BTCUSDTPERP position, got %v", state.Positions)
+	}
+	if state.SentAt().Before(before) || state.ReceivedAt().Before(state.SentAt()) {
+		t.Errorf("Expected timings after %s, got %s to %s", before, state.SentAt(), state.ReceivedAt())
+	}
+	if state.Window() < 0 || state.Window() > state.Age(time.Now()) {
+		t.Errorf("Expected window within age, got %s", state.Window())
+	}
+	if state.Stale(time.Minute) {
+		t.Error("Expected fresh state")
+	}
+	if !state.Stale(-time.Second) {
+		t.Error("Expected stale state")
+	}
+}
+
+func TestAccountStateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/positions/open" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+
+	_, err := cl.AccountState(context.Background())
+	if !valr.IsAuthError(err) {
+		t.Errorf("Expected auth error, got %v", err)
+	}
+}
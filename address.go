@@ -0,0 +1,312 @@
+package valr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// maxPaymentReferenceLength is the longest payment reference VALR accepts.
+const maxPaymentReferenceLength = 256
+
+// Address formats checked by ValidateAddress.
+const (
+	addressEVM     = "evm"
+	addressBitcoin = "bitcoin"
+	addressLite    = "litecoin"
+	addressTron    = "tron"
+	addressRipple  = "ripple"
+	addressSolana  = "solana"
+)
+
+var (
+	// networkAddressFormats maps withdrawal networks to address formats.
+	networkAddressFormats = map[string]string{
+		"ethereum":          addressEVM,
+		"polygon":           addressEVM,
+		"arbitrum":          addressEVM,
+		"optimism":          addressEVM,
+		"base":              addressEVM,
+		"bsc":               addressEVM,
+		"binancesmartchain": addressEVM,
+		"avalanche":         addressEVM,
+		"bitcoin":           addressBitcoin,
+		"litecoin":          addressLite,
+		"tron":              addressTron,
+		"ripple":            addressRipple,
+		"xrp":               addressRipple,
+		"solana":            addressSolana,
+	}
+	// currencyAddressFormats maps currencies to the address format of their
+	// default network.
+	currencyAddressFormats = map[string]string{
+		"ETH":  addressEVM,
+		"BTC":  addressBitcoin,
+		"LTC":  addressLite,
+		"TRX":  addressTron,
+		"XRP":  addressRipple,
+		"SOL":  addressSolana,
+		"USDC": addressEVM,
+		"LINK": addressEVM,
+	}
+	// paymentReferenceCurrencies accept a payment reference (memo or tag).
+	paymentReferenceCurrencies = map[string]bool{
+		"XRP": true,
+		"XMR": true,
+		"XEM": true,
+		"XLM": true,
+	}
+)
+
+// Validate checks the request for missing fields, a malformed address, an
+// unsupported payment reference and incomplete beneficiary details.
+func (r *PostNewCryptoWithdrawRequest) Validate() error {
+	if r.Asset == "" {
+		return &ValidationError{Field: "currencyCode", Reason: "required"}
+	}
+	if !r.Amount.IsPositive() {
+		return &ValidationError{Field: "amount", Reason: "must be positive"}
+	}
+	if err := ValidateAddress(r.Asset, r.Network, r.Address); err != nil {
+		return err
+	}
+	if err := validatePaymentReference(r.Asset, r.PaymentReference); err != nil {
+		return err
+	}
+	if r.Beneficiary != nil {
+		return r.Beneficiary.Validate()
+	}
+	return nil
+}
+
+// ValidateAddress checks that address is well formed for currency on
+// network, verifying checksums where the format has one: EIP-55 for mixed
+// case Ethereum style addresses, bech32 and bech32m for segwit addresses and
+// base58check otherwise. network may be empty for the currency's default
+// network. Addresses in formats that aren't known are accepted.
+func ValidateAddress(currency, network, address string) error {
+	if address == "" {
+		return &ValidationError{Field: "address", Reason: "required"}
+	}
+	format, ok := networkAddressFormats[strings.ToLower(network)]
+	if !ok {
+		format = currencyAddressFormats[strings.ToUpper(currency)]
+	}
+
+	var err error
+	switch format {
+	case addressEVM:
+		err = validateEVMAddress(address)
+	case addressBitcoin:
+		err = validateSegwitOrBase58(address, "bc", 0x00, 0x05)
+	case addressLite:
+		err = validateSegwitOrBase58(address, "ltc", 0x30, 0x32, 0x05)
+	case addressTron:
+		err = validateBase58Check(address, bitcoinAlphabet, 0x41)
+	case addressRipple:
+		err = validateBase58Check(address, rippleAlphabet, 0x00)
+	case addressSolana:
+		var b []byte
+		if b, err = base58Decode(address, bitcoinAlphabet); err == nil && len(b) != 32 {
+			err = fmt.Errorf("decodes to %d bytes, expected 32", len(b))
+		}
+	}
+	if err != nil {
+		return &ValidationError{Field: "address", Reason: fmt.Sprintf("not a valid %s address: %v", currency, err)}
+	}
+	return nil
+}
+
+func validatePaymentReference(currency, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	currency = strings.ToUpper(currency)
+	if !paymentReferenceCurrencies[currency] {
+		return &ValidationError{Field: "paymentReference", Reason: fmt.Sprintf("not supported for %s", currency)}
+	}
+	if len(ref) > maxPaymentReferenceLength {
+		return &ValidationError{Field: "paymentReference", Reason: fmt.Sprintf("longer than %d characters", maxPaymentReferenceLength)}
+	}
+	if currency == "XRP" {
+		if _, err := strconv.ParseUint(ref, 10, 32); err != nil {
+			return &ValidationError{Field: "paymentReference", Reason: "XRP destination tags must be a number up to 4294967295"}
+		}
+	}
+	return nil
+}
+
+func validateEVMAddress(address string) error {
+	hexPart, ok := strings.CutPrefix(address, "0x")
+	if !ok || len(hexPart) != 40 {
+		return fmt.Errorf("expected 0x followed by 40 hex digits")
+	}
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return fmt.Errorf("expected 0x followed by 40 hex digits")
+	}
+	lower := strings.ToLower(hexPart)
+	if hexPart == lower || hexPart == strings.ToUpper(hexPart) {
+		// All one case carries no checksum.
+		return nil
+	}
+	// EIP-55 checksums use the original Keccak-256, not SHA3-256.
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+	for i, c := range hexPart {
+		if c < 'A' {
+			continue
+		}
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if (nibble >= 8) != (c <= 'F') {
+			return fmt.Errorf("EIP-55 checksum mismatch")
+		}
+	}
+	return nil
+}
+
+func validateSegwitOrBase58(address, hrp string, versions ...byte) error {
+	if strings.HasPrefix(strings.ToLower(address), hrp+"1") {
+		return validateSegwit(address, hrp)
+	}
+	return validateBase58Check(address, bitcoinAlphabet, versions...)
+}
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+func base58Decode(s, alphabet string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	// Leading zero digits encode leading zero bytes.
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// validateBase58Check checks a base58check encoded 21 byte payload whose
+// first byte is one of versions.
+func validateBase58Check(address, alphabet string, versions ...byte) error {
+	b, err := base58Decode(address, alphabet)
+	if err != nil {
+		return err
+	}
+	if len(b) != 25 {
+		return fmt.Errorf("decodes to %d bytes, expected 25", len(b))
+	}
+	payload, sum := b[:21], b[21:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], sum) {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if bytes.IndexByte(versions, payload[0]) < 0 {
+		return fmt.Errorf("unexpected version byte 0x%02x", payload[0])
+	}
+	return nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// validateSegwit checks a bech32 (witness version 0) or bech32m (later
+// versions) segwit address with the given human readable part.
+func validateSegwit(address, hrp string) error {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return fmt.Errorf("mixed case")
+	}
+	address = strings.ToLower(address)
+	sep := strings.LastIndexByte(address, '1')
+	if address[:sep] != hrp || len(address)-sep-1 < 7 || len(address) > 90 {
+		return fmt.Errorf("malformed bech32")
+	}
+	var data []byte
+	for _, c := range address[sep+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return fmt.Errorf("invalid character %q", c)
+		}
+		data = append(data, byte(i))
+	}
+	values := make([]byte, 0, 2*len(hrp)+1+len(data))
+	for _, c := range []byte(hrp) {
+		values = append(values, c>>5)
+	}
+	values = append(values, 0)
+	for _, c := range []byte(hrp) {
+		values = append(values, c&31)
+	}
+	values = append(values, data...)
+
+	version := data[0]
+	want := uint32(1)
+	if version > 0 {
+		want = 0x2bc830a3
+	}
+	if bech32Polymod(values) != want {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if version > 16 {
+		return fmt.Errorf("unknown witness version %d", version)
+	}
+
+	// Regroup the program from 5 to 8 bit groups.
+	var (
+		program []byte
+		acc     uint32
+		nbits   uint
+	)
+	for _, v := range data[1 : len(data)-6] {
+		acc = acc<<5 | uint32(v)
+		nbits += 5
+		if nbits >= 8 {
+			nbits -= 8
+			program = append(program, byte(acc>>nbits))
+		}
+	}
+	if nbits >= 5 || acc&(1<<nbits-1) != 0 {
+		return fmt.Errorf("invalid padding")
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	return nil
+}
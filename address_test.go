@@ -0,0 +1,61 @@
+package valr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestValidateAddress(t *testing.T) {
+	for _, tc := range []struct {
+		currency, network, address string
+		valid                      bool
+	}{
+		{"ETH", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"ETH", "", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"ETH", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"USDT", "Ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", false},
+		{"BTC", "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true},
+		{"BTC", "", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", true},
+		{"BTC", "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdx", false},
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", true},
+		{"BTC", "", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true},
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", false},
+		{"USDT", "Tron", "TAFH65xLeW1gcNMQdt24jBhtaedmJRsEeF", true},
+		{"USDT", "Tron", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false},
+		{"XRP", "", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", true},
+		{"SOL", "", "11111111111111111111111111111111", true},
+		{"DOGE", "", "anything", true},
+	} {
+		err := valr.ValidateAddress(tc.currency, tc.network, tc.address)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s %s %s: expected valid %v, got %v", tc.currency, tc.network, tc.address, tc.valid, err)
+		}
+		if err != nil && !valr.IsValidationError(err) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	}
+}
+
+func TestCryptoWithdrawPaymentReference(t *testing.T) {
+	req := &valr.PostNewCryptoWithdrawRequest{
+		Asset:            "XRP",
+		Amount:           decimal.RequireFromString("10"),
+		Address:          "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+		PaymentReference: "12345",
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	req.PaymentReference = "memo"
+	var verr *valr.ValidationError
+	if err := req.Validate(); !errors.As(err, &verr) || verr.Field != "paymentReference" {
+		t.Errorf("Expected invalid paymentReference, got %v", err)
+	}
+	req.Asset, req.Address, req.PaymentReference = "BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "1"
+	if err := req.Validate(); !errors.As(err, &verr) || verr.Field != "paymentReference" {
+		t.Errorf("Expected invalid paymentReference, got %v", err)
+	}
+}
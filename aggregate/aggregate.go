@@ -0,0 +1,155 @@
+// Package aggregate fans queries out across several VALR accounts and merges
+// the results, labelled by account, for back-office views.
+package aggregate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// Account is a labelled account to query. Accounts may use separate clients,
+// e.g. for different users' API keys, or share a client and act on behalf of
+// a subaccount.
+type Account struct {
+	Label        string
+	Client       *valr.Client
+	SubaccountID string
+}
+
+func (a Account) context(ctx context.Context) context.Context {
+	if a.SubaccountID == "" {
+		return ctx
+	}
+	return valr.WithSubaccount(ctx, a.SubaccountID)
+}
+
+// Labelled is an item returned by an account.
+type Labelled[T any] struct {
+	Account string
+	Item    T
+}
+
+// Result holds the merged items of all accounts that were queried
+// successfully, and the errors of those that weren't, keyed by label.
+type Result[T any] struct {
+	Items  []Labelled[T]
+	Errors map[string]error
+}
+
+// ByAccount groups the items by account label.
+func (r *Result[T]) ByAccount() map[string][]T {
+	res := make(map[string][]T)
+	for _, it := range r.Items {
+		res[it.Account] = append(res[it.Account], it.Item)
+	}
+	return res
+}
+
+// Aggregator queries a fixed set of accounts concurrently.
+type Aggregator struct {
+	accounts []Account
+}
+
+// New returns an Aggregator for the given accounts. Labels must be unique.
+func New(accounts ...Account) (*Aggregator, error) {
+	seen := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		if a.Client == nil {
+			return nil, fmt.Errorf("aggregate: account %q has no client", a.Label)
+		}
+		if seen[a.Label] {
+			return nil, fmt.Errorf("aggregate: duplicate account label %q", a.Label)
+		}
+		seen[a.Label] = true
+	}
+	return &Aggregator{accounts: accounts}, nil
+}
+
+// Accounts returns the configured accounts.
+func (a *Aggregator) Accounts() []Account {
+	return append([]Account(nil), a.accounts...)
+}
+
+// Balances returns the balances of every account.
+func (a *Aggregator) Balances(ctx context.Context) (*Result[valr.AccountBalance], error) {
+	return fanOut(ctx, a.accounts, func(ctx context.Context, cl *valr.Client) ([]valr.AccountBalance, error) {
+		return cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	})
+}
+
+// OpenOrders returns the open orders of every account.
+func (a *Aggregator) OpenOrders(ctx context.Context) (*Result[valr.OpenOrder], error) {
+	return fanOut(ctx, a.accounts, func(ctx context.Context, cl *valr.Client) ([]valr.OpenOrder, error) {
+		return cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	})
+}
+
+// Positions returns the open futures positions of every account.
+func (a *Aggregator) Positions(ctx context.Context) (*Result[valr.OpenPosition], error) {
+	return fanOut(ctx, a.accounts, func(ctx context.Context, cl *valr.Client) ([]valr.OpenPosition, error) {
+		return cl.GetOpenPositionsRequest(ctx, &valr.GetOpenPositionsRequest{})
+	})
+}
+
+// fanOut runs query against every account concurrently. Results are ordered
+// by account, in the order accounts were configured. The returned error
+// joins the errors of all failed accounts; the results of the others are
+// still returned.
+func fanOut[T any](ctx context.Context, accounts []Account,
+	query func(context.Context, *valr.Client) ([]T, error)) (*Result[T], error) {
+
+	items := make([][]T, len(accounts))
+	errs := make([]error, len(accounts))
+	var wg sync.WaitGroup
+	for i, acc := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items[i], errs[i] = query(acc.context(ctx), acc.Client)
+		}()
+	}
+	wg.Wait()
+
+	res := &Result[T]{Errors: make(map[string]error)}
+	var joined []error
+	for i, acc := range accounts {
+		if errs[i] != nil {
+			res.Errors[acc.Label] = errs[i]
+			joined = append(joined, fmt.Errorf("aggregate: %s: %w", acc.Label, errs[i]))
+			continue
+		}
+		for _, it := range items[i] {
+			res.Items = append(res.Items, Labelled[T]{Account: acc.Label, Item: it})
+		}
+	}
+	return res, errors.Join(joined...)
+}
+
+// TotalBalances sums balances across accounts per currency, sorted by
+// currency.
+func TotalBalances(balances []Labelled[valr.AccountBalance]) []valr.AccountBalance {
+	totals := make(map[string]*valr.AccountBalance)
+	for _, b := range balances {
+		t, ok := totals[b.Item.Currency]
+		if !ok {
+			t = &valr.AccountBalance{Currency: b.Item.Currency}
+			totals[b.Item.Currency] = t
+		}
+		t.Available = t.Available.Add(b.Item.Available)
+		t.Reserved = t.Reserved.Add(b.Item.Reserved)
+		t.Total = t.Total.Add(b.Item.Total)
+	}
+	res := make([]valr.AccountBalance, 0, len(totals))
+	for _, t := range totals {
+		res = append(res, *t)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Currency < res[j].Currency
+	})
+	return res
+}
@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Carry entry kinds.
+const (
+	CarryFunding  = "funding"
+	CarryInterest = "interest"
+)
+
+// CarryEntry is a single funding payment or interest charge. Amount is
+// positive for costs and negative for income, such as funding received.
+type CarryEntry struct {
+	Kind string
+	// Pair is empty for interest not attributed to a pair.
+	Pair     string
+	Currency string
+	Amount   decimal.Decimal
+	At       time.Time
+}
+
+// CarryCost is the total carrying cost of a position, keyed by currency.
+type CarryCost struct {
+	Pair     string
+	Funding  map[string]decimal.Decimal
+	Interest map[string]decimal.Decimal
+}
+
+// CarryLedger holds the funding and interest entries of an account.
+type CarryLedger struct {
+	entries []CarryEntry
+}
+
+// NewCarryLedger extracts funding payments and interest charges from
+// transaction history. Transactions are classified by type, so both paid
+// and received funding are included, with debits recorded as costs and
+// credits as income.
+func NewCarryLedger(txs []valr.TransactionInfo) *CarryLedger {
+	l := new(CarryLedger)
+	for _, tx := range txs {
+		kind := tx.TransactionType.Type
+		var e CarryEntry
+		switch {
+		case strings.Contains(kind, "FUNDING"):
+			e.Kind = CarryFunding
+		case strings.Contains(kind, "INTEREST"):
+			e.Kind = CarryInterest
+		default:
+			continue
+		}
+		e.Pair = tx.AdditionalInfo.CurrencyPairSymbol
+		e.At = tx.EventAt.Time
+		if !tx.DebitValue.IsZero() {
+			e.Currency, e.Amount = tx.DebitCurrency, tx.DebitValue
+		} else {
+			e.Currency, e.Amount = tx.CreditCurrency, tx.CreditValue.Neg()
+		}
+		l.entries = append(l.entries, e)
+	}
+	sort.SliceStable(l.entries, func(i, j int) bool {
+		return l.entries[i].At.Before(l.entries[j].At)
+	})
+	return l
+}
+
+// Entries returns the ledger's entries, oldest first.
+func (l *CarryLedger) Entries() []CarryEntry {
+	return append([]CarryEntry(nil), l.entries...)
+}
+
+// ByPair returns the total carrying cost per pair, sorted by pair. Interest
+// not attributed to a pair is reported under an empty pair.
+func (l *CarryLedger) ByPair() []*CarryCost {
+	costs := make(map[string]*CarryCost)
+	for _, e := range l.entries {
+		addCarry(costs, e)
+	}
+	res := make([]*CarryCost, 0, len(costs))
+	for _, pair := range sortedKeys(costs) {
+		res = append(res, costs[pair])
+	}
+	return res
+}
+
+// PositionCost returns the carrying cost of pair accrued in [from, to), such
+// as over the life of a round trip. A zero to includes everything after
+// from.
+func (l *CarryLedger) PositionCost(pair string, from, to time.Time) *CarryCost {
+	costs := make(map[string]*CarryCost)
+	for _, e := range l.entries {
+		if e.Pair != pair || e.At.Before(from) || (!to.IsZero() && !e.At.Before(to)) {
+			continue
+		}
+		addCarry(costs, e)
+	}
+	if c, ok := costs[pair]; ok {
+		return c
+	}
+	return newCarryCost(pair)
+}
+
+// Total returns the sum of funding and interest in currency.
+func (c *CarryCost) Total(currency string) decimal.Decimal {
+	return c.Funding[currency].Add(c.Interest[currency])
+}
+
+func newCarryCost(pair string) *CarryCost {
+	return &CarryCost{
+		Pair:     pair,
+		Funding:  make(map[string]decimal.Decimal),
+		Interest: make(map[string]decimal.Decimal),
+	}
+}
+
+func addCarry(costs map[string]*CarryCost, e CarryEntry) {
+	c, ok := costs[e.Pair]
+	if !ok {
+		c = newCarryCost(e.Pair)
+		costs[e.Pair] = c
+	}
+	if e.Kind == CarryFunding {
+		c.Funding[e.Currency] = c.Funding[e.Currency].Add(e.Amount)
+	} else {
+		c.Interest[e.Currency] = c.Interest[e.Currency].Add(e.Amount)
+	}
+}
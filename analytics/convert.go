@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+// ConvertTrades returns trades with their prices and fees converted to
+// base, such as ZAR, USDT or BTC, at conv's current rates. Reports built
+// from the result, such as BuildVolumeReport or MatchRoundTrips, are then
+// in base, so pairs quoted in different currencies can be summed.
+// Quantities remain in each pair's base asset.
+func ConvertTrades(ctx context.Context, conv *fx.Converter, base string, trades []Trade) ([]Trade, error) {
+	rates := make(map[string]decimal.Decimal)
+	rate := func(currency string) (decimal.Decimal, error) {
+		if r, ok := rates[currency]; ok {
+			return r, nil
+		}
+		r, err := conv.Rate(ctx, currency, base)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		rates[currency] = r
+		return r, nil
+	}
+
+	out := make([]Trade, len(trades))
+	for i, t := range trades {
+		quote := money.QuoteCurrency(t.Pair)
+		if quote == "" {
+			return nil, fmt.Errorf("analytics: unknown quote currency of %s", t.Pair)
+		}
+		r, err := rate(quote)
+		if err != nil {
+			return nil, err
+		}
+		t.Price = t.Price.Mul(r)
+		if !t.Fee.IsZero() {
+			r, err := rate(t.FeeCurrency)
+			if err != nil {
+				return nil, err
+			}
+			t.Fee = t.Fee.Mul(r)
+			t.FeeCurrency = base
+		}
+		out[i] = t
+	}
+	return out, nil
+}
+
+// StatsIn is like Stats but values volume, P&L and fees in base at conv's
+// current rates, so strategies trading pairs with different quote
+// currencies can be compared and totalled.
+func (s *Session) StatsIn(ctx context.Context, conv *fx.Converter, base string) (*SessionStats, error) {
+	s.mu.Lock()
+	trades := make(map[string][]Trade, len(s.trades))
+	for name, ts := range s.trades {
+		trades[name] = append([]Trade(nil), ts...)
+	}
+	s.mu.Unlock()
+
+	for name, ts := range trades {
+		converted, err := ConvertTrades(ctx, conv, base, ts)
+		if err != nil {
+			return nil, err
+		}
+		trades[name] = converted
+	}
+	return sessionStats(s.started, trades, s.rounding, base), nil
+}
@@ -0,0 +1,205 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+// Period is the length of the buckets a report is grouped into.
+type Period string
+
+const (
+	Day  Period = "day"
+	Week Period = "week"
+)
+
+// Start returns the start of the period containing t, in UTC. Weeks start
+// on Monday.
+func (p Period) Start(t time.Time) time.Time {
+	return p.StartIn(t, time.UTC)
+}
+
+// StartIn returns the start of the period containing t, with days starting
+// at midnight in loc.
+func (p Period) StartIn(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	if p == Week {
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// PairVolume is the maker and taker volume of a pair, in its quote currency,
+// along with the fees paid.
+type PairVolume struct {
+	Pair        string
+	MakerVolume decimal.Decimal
+	TakerVolume decimal.Decimal
+	MakerTrades int
+	TakerTrades int
+	// Fees holds the fees paid keyed by currency. Rebates are negative.
+	Fees map[string]decimal.Decimal
+}
+
+// VolumeBucket aggregates the trades in a single period.
+type VolumeBucket struct {
+	Start time.Time
+	// Pairs is sorted by pair.
+	Pairs []*PairVolume
+	// Fees holds the fees paid across all pairs, keyed by currency.
+	Fees map[string]decimal.Decimal
+}
+
+// VolumeReport is a breakdown of maker and taker volume and fees over time.
+type VolumeReport struct {
+	Period Period
+	// Location is the time zone of the period boundaries.
+	Location *time.Location
+	// Formatter rounds and formats the volumes and fees written by
+	// WriteCSV to the decimal places of their currency. They are written
+	// exactly if nil.
+	Formatter *money.Formatter
+	// Buckets is sorted oldest first.
+	Buckets []*VolumeBucket
+	// Totals aggregates the whole report per pair, sorted by pair.
+	Totals []*PairVolume
+}
+
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	location  *time.Location
+	formatter *money.Formatter
+}
+
+// WithLocation starts the days of a report at midnight in loc, such as
+// Africa/Johannesburg, rather than in UTC.
+func WithLocation(loc *time.Location) ReportOption {
+	return func(o *reportOptions) {
+		o.location = loc
+	}
+}
+
+// WithFormatter rounds the amounts written by the report with f.
+func WithFormatter(f *money.Formatter) ReportOption {
+	return func(o *reportOptions) {
+		o.formatter = f
+	}
+}
+
+// BuildVolumeReport aggregates trades into maker/taker volume and fees per
+// pair and per period.
+func BuildVolumeReport(trades []Trade, period Period, opts ...ReportOption) *VolumeReport {
+	o := reportOptions{location: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	buckets := make(map[time.Time]map[string]*PairVolume)
+	totals := make(map[string]*PairVolume)
+
+	for _, t := range trades {
+		start := period.StartIn(t.TradedAt, o.location)
+		pairs, ok := buckets[start]
+		if !ok {
+			pairs = make(map[string]*PairVolume)
+			buckets[start] = pairs
+		}
+		addTrade(pairs, t)
+		addTrade(totals, t)
+	}
+
+	r := &VolumeReport{Period: period, Location: o.location, Formatter: o.formatter, Totals: sortedPairs(totals)}
+	for start, pairs := range buckets {
+		b := &VolumeBucket{
+			Start: start,
+			Pairs: sortedPairs(pairs),
+			Fees:  make(map[string]decimal.Decimal),
+		}
+		for _, pv := range b.Pairs {
+			for cur, fee := range pv.Fees {
+				b.Fees[cur] = b.Fees[cur].Add(fee)
+			}
+		}
+		r.Buckets = append(r.Buckets, b)
+	}
+	sort.Slice(r.Buckets, func(i, j int) bool {
+		return r.Buckets[i].Start.Before(r.Buckets[j].Start)
+	})
+	return r
+}
+
+func addTrade(pairs map[string]*PairVolume, t Trade) {
+	pv, ok := pairs[t.Pair]
+	if !ok {
+		pv = &PairVolume{Pair: t.Pair, Fees: make(map[string]decimal.Decimal)}
+		pairs[t.Pair] = pv
+	}
+	if t.Maker {
+		pv.MakerVolume = pv.MakerVolume.Add(t.Notional())
+		pv.MakerTrades++
+	} else {
+		pv.TakerVolume = pv.TakerVolume.Add(t.Notional())
+		pv.TakerTrades++
+	}
+	if !t.Fee.IsZero() {
+		pv.Fees[t.FeeCurrency] = pv.Fees[t.FeeCurrency].Add(t.Fee)
+	}
+}
+
+func sortedPairs(pairs map[string]*PairVolume) []*PairVolume {
+	res := make([]*PairVolume, 0, len(pairs))
+	for _, pv := range pairs {
+		res = append(res, pv)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Pair < res[j].Pair
+	})
+	return res
+}
+
+// WriteCSV writes one row per period, pair and fee currency. Pairs without
+// fees are written with an empty fee currency.
+func (r *VolumeReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"period_start", "pair", "maker_volume", "taker_volume",
+		"maker_trades", "taker_trades", "fee_currency", "fee"})
+	if err != nil {
+		return err
+	}
+	for _, b := range r.Buckets {
+		start := b.Start.Format("2006-01-02")
+		for _, pv := range b.Pairs {
+			quote := money.QuoteCurrency(pv.Pair)
+			row := []string{start, pv.Pair, r.formatAmount(quote, pv.MakerVolume), r.formatAmount(quote, pv.TakerVolume),
+				strconv.Itoa(pv.MakerTrades), strconv.Itoa(pv.TakerTrades)}
+			if len(pv.Fees) == 0 {
+				if err := cw.Write(append(row, "", "")); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, cur := range sortedKeys(pv.Fees) {
+				if err := cw.Write(append(row[:6:6], cur, r.formatAmount(cur, pv.Fees[cur]))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r *VolumeReport) formatAmount(currency string, d decimal.Decimal) string {
+	if r.Formatter == nil || currency == "" {
+		return d.String()
+	}
+	return r.Formatter.Format(currency, d)
+}
@@ -0,0 +1,58 @@
+package analytics_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/analytics"
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+func TestVolumeReportLocation(t *testing.T) {
+	sast := time.FixedZone("SAST", 2*60*60)
+	trades := []analytics.Trade{
+		{Pair: "BTCZAR", Price: decimal.New(100, 0), Quantity: decimal.New(1, 0), TradedAt: time.Date(2024, 1, 1, 21, 0, 0, 0, time.UTC)},
+		{Pair: "BTCZAR", Price: decimal.New(100, 0), Quantity: decimal.New(2, 0), TradedAt: time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)},
+	}
+
+	r := analytics.BuildVolumeReport(trades, analytics.Day)
+	if len(r.Buckets) != 1 {
+		t.Errorf("Expected 1 UTC day, got %d", len(r.Buckets))
+	}
+
+	r = analytics.BuildVolumeReport(trades, analytics.Day, analytics.WithLocation(sast))
+	if len(r.Buckets) != 2 {
+		t.Fatalf("Expected 2 local days, got %d", len(r.Buckets))
+	}
+	if want := time.Date(2024, 1, 2, 0, 0, 0, 0, sast); !r.Buckets[1].Start.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, r.Buckets[1].Start)
+	}
+	if got := r.Buckets[1].Pairs[0].TakerVolume.String(); got != "200" {
+		t.Errorf("Expected %q, got %q", "200", got)
+	}
+
+	// 2024-01-07 23:00 UTC is Monday in Johannesburg.
+	start := analytics.Week.StartIn(time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC), sast)
+	if want := time.Date(2024, 1, 8, 0, 0, 0, 0, sast); !start.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, start)
+	}
+}
+
+func TestVolumeReportFormatter(t *testing.T) {
+	trades := []analytics.Trade{{
+		Pair: "BTCZAR", Price: decimal.RequireFromString("100.125"), Quantity: decimal.New(1, 0),
+		Fee: decimal.RequireFromString("0.000012345"), FeeCurrency: "BTC",
+		TradedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}}
+	r := analytics.BuildVolumeReport(trades, analytics.Day, analytics.WithFormatter(money.NewFormatter(money.WithRounding(money.HalfEven))))
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if want := "2024-01-01,BTCZAR,0.00,100.12,0,1,BTC,0.00001234\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
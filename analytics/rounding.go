@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+// RoundingPolicy is how P&L figures are rounded. All arithmetic is done in
+// decimal; sums and products are exact and only divisions and reported
+// amounts are rounded, as set out here, so the same trades give the same
+// figures, to the cent, in every run.
+type RoundingPolicy struct {
+	// DivisionPlaces is the decimal places of divisions, such as the share
+	// of a trade's fee allocated to part of its quantity, 16 by default.
+	DivisionPlaces int32 `json:"divisionPlaces"`
+	// Mode rounds divisions, ratios and, with Amounts, reported amounts.
+	Mode money.Rounding `json:"mode"`
+	// RatioPlaces is the decimal places of ratios such as the win rate, 4
+	// by default.
+	RatioPlaces int32 `json:"ratioPlaces"`
+	// Amounts, if set, rounds reported amounts to the decimal places of
+	// their currency. Its own rounding is ignored in favour of Mode.
+	// Amounts are only rounded once totalled, never before, and are left
+	// exact when their currency is ambiguous, such as P&L summed over pairs
+	// with different quote currencies.
+	Amounts *money.Formatter `json:"-"`
+}
+
+// DefaultRounding rounds divisions half up to 16 places and ratios to 4,
+// and reports exact amounts.
+var DefaultRounding = RoundingPolicy{DivisionPlaces: 16, Mode: money.HalfUp, RatioPlaces: 4}
+
+// div divides d by d2 as the policy rounds divisions.
+func (p RoundingPolicy) div(d, d2 decimal.Decimal) decimal.Decimal {
+	return money.Div(d, d2, p.DivisionPlaces, p.Mode)
+}
+
+// ratio divides n by of as the policy rounds ratios.
+func (p RoundingPolicy) ratio(n, of int) decimal.Decimal {
+	return money.Div(decimal.New(int64(n), 0), decimal.New(int64(of), 0), p.RatioPlaces, p.Mode)
+}
+
+// amount rounds an amount in currency, if the policy rounds amounts and the
+// currency is known.
+func (p RoundingPolicy) amount(currency string, d decimal.Decimal) decimal.Decimal {
+	if p.Amounts == nil || currency == "" {
+		return d
+	}
+	return money.Round(d, p.Amounts.Places(currency), p.Mode)
+}
@@ -0,0 +1,146 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Lot is an open quantity that has not yet been matched by an opposing trade.
+type Lot struct {
+	Pair     string
+	Side     valr.ResponseSide
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	OpenedAt time.Time
+	// Fees holds the share of the opening trade's fees that remains
+	// unallocated, keyed by currency.
+	Fees map[string]decimal.Decimal
+}
+
+// RoundTrip is a quantity that was opened and later closed by an opposing
+// trade.
+type RoundTrip struct {
+	Pair string
+	// Side is the side of the opening trade: buy for longs and sell for
+	// shorts.
+	Side       valr.ResponseSide
+	Quantity   decimal.Decimal
+	EntryPrice decimal.Decimal
+	ExitPrice  decimal.Decimal
+	OpenedAt   time.Time
+	ClosedAt   time.Time
+	// RealisedPnL is in the pair's quote currency, before fees.
+	RealisedPnL decimal.Decimal
+	// Fees holds the entry and exit fees allocated pro rata to the matched
+	// quantity, keyed by currency.
+	Fees map[string]decimal.Decimal
+}
+
+// HoldingTime returns how long the position was held.
+func (r RoundTrip) HoldingTime() time.Duration {
+	return r.ClosedAt.Sub(r.OpenedAt)
+}
+
+// MatchRoundTrips pairs buys and sells per pair, first in first out, into
+// closed round trips. Lots that remain unmatched are returned as open. Fees
+// are allocated with DefaultRounding.
+func MatchRoundTrips(trades []Trade) ([]RoundTrip, []Lot) {
+	return MatchRoundTripsWith(trades, DefaultRounding)
+}
+
+// MatchRoundTripsWith is like MatchRoundTrips but allocates fees to partly
+// matched quantities with the divisions of p.
+func MatchRoundTripsWith(trades []Trade, p RoundingPolicy) ([]RoundTrip, []Lot) {
+	sorted := append([]Trade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TradedAt.Before(sorted[j].TradedAt)
+	})
+
+	var (
+		trips []RoundTrip
+		books = make(map[string][]*Lot)
+		pairs []string
+	)
+	for _, t := range sorted {
+		if !t.Quantity.IsPositive() {
+			continue
+		}
+		lots, ok := books[t.Pair]
+		if !ok {
+			pairs = append(pairs, t.Pair)
+		}
+
+		remaining := t.Quantity
+		for len(lots) > 0 && lots[0].Side != t.Side && remaining.IsPositive() {
+			lot := lots[0]
+			qty := decimal.Min(lot.Quantity, remaining)
+
+			pnl := t.Price.Sub(lot.Price).Mul(qty)
+			if lot.Side == valr.ResponseSideSell {
+				pnl = pnl.Neg()
+			}
+			fees := make(map[string]decimal.Decimal)
+			takeFees(p, fees, lot.Fees, qty, lot.Quantity)
+			if !t.Fee.IsZero() {
+				fees[t.FeeCurrency] = fees[t.FeeCurrency].Add(p.div(t.Fee.Mul(qty), t.Quantity))
+			}
+
+			trips = append(trips, RoundTrip{
+				Pair:        t.Pair,
+				Side:        lot.Side,
+				Quantity:    qty,
+				EntryPrice:  lot.Price,
+				ExitPrice:   t.Price,
+				OpenedAt:    lot.OpenedAt,
+				ClosedAt:    t.TradedAt,
+				RealisedPnL: pnl,
+				Fees:        fees,
+			})
+
+			lot.Quantity = lot.Quantity.Sub(qty)
+			remaining = remaining.Sub(qty)
+			if lot.Quantity.IsZero() {
+				lots = lots[1:]
+			}
+		}
+
+		if remaining.IsPositive() {
+			lot := &Lot{
+				Pair:     t.Pair,
+				Side:     t.Side,
+				Price:    t.Price,
+				Quantity: remaining,
+				OpenedAt: t.TradedAt,
+				Fees:     make(map[string]decimal.Decimal),
+			}
+			if !t.Fee.IsZero() {
+				lot.Fees[t.FeeCurrency] = p.div(t.Fee.Mul(remaining), t.Quantity)
+			}
+			lots = append(lots, lot)
+		}
+		books[t.Pair] = lots
+	}
+
+	var open []Lot
+	for _, pair := range pairs {
+		for _, lot := range books[pair] {
+			open = append(open, *lot)
+		}
+	}
+	return trips, open
+}
+
+// takeFees moves the share qty/of of each fee in from into to.
+func takeFees(p RoundingPolicy, to, from map[string]decimal.Decimal, qty, of decimal.Decimal) {
+	for cur, fee := range from {
+		share := fee
+		if qty.LessThan(of) {
+			share = p.div(fee.Mul(qty), of)
+		}
+		to[cur] = to[cur].Add(share)
+		from[cur] = fee.Sub(share)
+	}
+}
@@ -0,0 +1,52 @@
+package analytics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/analytics"
+	"github.com/shopspring/decimal"
+)
+
+func TestMatchRoundTrips(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := decimal.RequireFromString
+	trades := []analytics.Trade{
+		{Pair: "BTCZAR", Side: valr.ResponseSideBuy, Price: d("100"), Quantity: d("1"), TradedAt: t0},
+		{Pair: "BTCZAR", Side: valr.ResponseSideBuy, Price: d("110"), Quantity: d("1"), TradedAt: t0.Add(time.Hour)},
+		// Closes the first lot and half of the second, FIFO.
+		{Pair: "BTCZAR", Side: valr.ResponseSideSell, Price: d("120"), Quantity: d("1.5"), TradedAt: t0.Add(2 * time.Hour)},
+		// Closes the rest of the second lot and opens a short.
+		{Pair: "BTCZAR", Side: valr.ResponseSideSell, Price: d("90"), Quantity: d("1"), TradedAt: t0.Add(3 * time.Hour)},
+	}
+
+	trips, open := analytics.MatchRoundTrips(trades)
+
+	exp := []struct {
+		qty, pnl string
+		held     time.Duration
+	}{
+		{"1", "20", 2 * time.Hour},
+		{"0.5", "5", time.Hour},
+		{"0.5", "-10", 2 * time.Hour},
+	}
+	if len(trips) != len(exp) {
+		t.Fatalf("Expected %d round trips, got %d", len(exp), len(trips))
+	}
+	for i, e := range exp {
+		if trips[i].Quantity.String() != e.qty {
+			t.Errorf("Expected %q, got %q", e.qty, trips[i].Quantity)
+		}
+		if trips[i].RealisedPnL.String() != e.pnl {
+			t.Errorf("Expected %q, got %q", e.pnl, trips[i].RealisedPnL)
+		}
+		if trips[i].HoldingTime() != e.held {
+			t.Errorf("Expected %v, got %v", e.held, trips[i].HoldingTime())
+		}
+	}
+
+	if len(open) != 1 || open[0].Side != valr.ResponseSideSell || open[0].Quantity.String() != "0.5" {
+		t.Errorf("Expected one open short of 0.5, got %+v", open)
+	}
+}
@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+// Session accumulates the fills of one or more strategies during a trading
+// session. It is safe for concurrent use.
+type Session struct {
+	started  time.Time
+	rounding RoundingPolicy
+
+	mu     sync.Mutex
+	trades map[string][]Trade
+}
+
+type SessionOption func(*Session)
+
+// WithRounding sets how the session's figures are rounded,
+// DefaultRounding by default.
+func WithRounding(p RoundingPolicy) SessionOption {
+	return func(s *Session) {
+		s.rounding = p
+	}
+}
+
+// NewSession starts a session.
+func NewSession(opts ...SessionOption) *Session {
+	s := &Session{
+		started:  time.Now(),
+		rounding: DefaultRounding,
+		trades:   make(map[string][]Trade),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Rounding returns how the session's figures are rounded.
+func (s *Session) Rounding() RoundingPolicy {
+	return s.rounding
+}
+
+// Record adds a fill made by strategy.
+func (s *Session) Record(strategy string, t Trade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trades[strategy] = append(s.trades[strategy], t)
+}
+
+// StrategyStats are the statistics of a strategy's fills. P&L figures are
+// realised from round trips matched first in first out, in the quote
+// currency and before fees, so they are only meaningful when summed across
+// pairs sharing a quote currency, unless converted to a base currency by
+// Session.StatsIn.
+type StrategyStats struct {
+	Strategy string `json:"strategy"`
+	Fills    int    `json:"fills"`
+	// Volume is the traded value in the quote currency.
+	Volume     decimal.Decimal `json:"volume"`
+	RoundTrips int             `json:"roundTrips"`
+	Wins       int             `json:"wins"`
+	// WinRate is the fraction of round trips with positive P&L.
+	WinRate     decimal.Decimal `json:"winRate"`
+	RealisedPnL decimal.Decimal `json:"realisedPnl"`
+	// MaxDrawdown is the largest fall in cumulative realised P&L from a
+	// previous peak.
+	MaxDrawdown decimal.Decimal `json:"maxDrawdown"`
+	// Fees holds the fees paid keyed by currency. Rebates are negative.
+	Fees map[string]decimal.Decimal `json:"fees"`
+}
+
+// SessionStats is a point in time summary of a session.
+type SessionStats struct {
+	Started time.Time `json:"started"`
+	AsOf    time.Time `json:"asOf"`
+	// Base is the currency of the amounts if they were converted with
+	// StatsIn, and empty if they are in each pair's quote currency.
+	Base string `json:"base,omitempty"`
+	// Strategies is sorted by strategy.
+	Strategies []StrategyStats `json:"strategies"`
+	// Total combines all strategies. Its drawdown is over the combined P&L.
+	Total StrategyStats `json:"total"`
+	// Rounding is how the figures were rounded.
+	Rounding RoundingPolicy `json:"rounding"`
+}
+
+// Stats summarises the session so far.
+func (s *Session) Stats() *SessionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sessionStats(s.started, s.trades, s.rounding, "")
+}
+
+// sessionStats summarises the trades of each strategy, with amounts in
+// base, or in each pair's quote currency if base is empty.
+func sessionStats(started time.Time, byStrategy map[string][]Trade, p RoundingPolicy, base string) *SessionStats {
+	st := &SessionStats{Started: started, AsOf: time.Now(), Base: base, Rounding: p}
+	var all []RoundTrip
+	total := StrategyStats{Strategy: "total", Fees: make(map[string]decimal.Decimal)}
+	for _, name := range sortedKeys(byStrategy) {
+		trades := byStrategy[name]
+		trips, _ := MatchRoundTripsWith(trades, p)
+		ss := strategyStats(name, trades, trips, p)
+		all = append(all, trips...)
+
+		total.Fills += ss.Fills
+		total.Volume = total.Volume.Add(ss.Volume)
+		for cur, fee := range ss.Fees {
+			total.Fees[cur] = total.Fees[cur].Add(fee)
+		}
+		roundStats(&ss, p, amountCurrency(base, trades))
+		st.Strategies = append(st.Strategies, ss)
+	}
+	addTrips(&total, all, p)
+	var trades []Trade
+	for _, ts := range byStrategy {
+		trades = append(trades, ts...)
+	}
+	roundStats(&total, p, amountCurrency(base, trades))
+	st.Total = total
+	return st
+}
+
+// amountCurrency returns the currency of the volume and P&L of trades:
+// base if set, or the quote currency of their pairs if they share one.
+func amountCurrency(base string, trades []Trade) string {
+	if base != "" {
+		return base
+	}
+	var currency string
+	for _, t := range trades {
+		switch q := money.QuoteCurrency(t.Pair); {
+		case q == "":
+			return ""
+		case currency == "":
+			currency = q
+		case q != currency:
+			return ""
+		}
+	}
+	return currency
+}
+
+// roundStats rounds the amounts of ss, once totalled, with p.
+func roundStats(ss *StrategyStats, p RoundingPolicy, currency string) {
+	ss.Volume = p.amount(currency, ss.Volume)
+	ss.RealisedPnL = p.amount(currency, ss.RealisedPnL)
+	ss.MaxDrawdown = p.amount(currency, ss.MaxDrawdown)
+	for cur, fee := range ss.Fees {
+		ss.Fees[cur] = p.amount(cur, fee)
+	}
+}
+
+// WriteJSON writes the session's current stats to w as JSON.
+func (s *Session) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Stats())
+}
+
+func strategyStats(name string, trades []Trade, trips []RoundTrip, p RoundingPolicy) StrategyStats {
+	ss := StrategyStats{Strategy: name, Fills: len(trades), Fees: make(map[string]decimal.Decimal)}
+	for _, t := range trades {
+		ss.Volume = ss.Volume.Add(t.Notional())
+		if !t.Fee.IsZero() {
+			ss.Fees[t.FeeCurrency] = ss.Fees[t.FeeCurrency].Add(t.Fee)
+		}
+	}
+	addTrips(&ss, trips, p)
+	return ss
+}
+
+// addTrips sets the round trip statistics of ss from trips.
+func addTrips(ss *StrategyStats, trips []RoundTrip, p RoundingPolicy) {
+	sorted := append([]RoundTrip(nil), trips...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ClosedAt.Before(sorted[j].ClosedAt)
+	})
+
+	var pnl, peak decimal.Decimal
+	for _, trip := range sorted {
+		ss.RoundTrips++
+		if trip.RealisedPnL.IsPositive() {
+			ss.Wins++
+		}
+		pnl = pnl.Add(trip.RealisedPnL)
+		peak = decimal.Max(peak, pnl)
+		ss.MaxDrawdown = decimal.Max(ss.MaxDrawdown, peak.Sub(pnl))
+	}
+	ss.RealisedPnL = pnl
+	if ss.RoundTrips > 0 {
+		ss.WinRate = p.ratio(ss.Wins, ss.RoundTrips)
+	}
+}
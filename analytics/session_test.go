@@ -0,0 +1,154 @@
+package analytics_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/analytics"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+func TestSessionStats(t *testing.T) {
+	s := analytics.NewSession()
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(minute int, side valr.ResponseSide, price string) analytics.Trade {
+		return analytics.Trade{
+			Pair: "BTCZAR", Side: side,
+			Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString("1"),
+			TradedAt: at.Add(time.Duration(minute) * time.Minute),
+		}
+	}
+	s.Record("a", trade(0, valr.ResponseSideBuy, "100"))
+	s.Record("a", trade(1, valr.ResponseSideSell, "110"))
+	s.Record("a", trade(2, valr.ResponseSideBuy, "100"))
+	s.Record("a", trade(3, valr.ResponseSideSell, "85"))
+	s.Record("b", trade(4, valr.ResponseSideBuy, "100"))
+
+	st := s.Stats()
+	if len(st.Strategies) != 2 {
+		t.Fatalf("Expected 2 strategies, got %d", len(st.Strategies))
+	}
+	a := st.Strategies[0]
+	if a.RoundTrips != 2 || a.Wins != 1 {
+		t.Errorf("Expected 2 round trips and 1 win, got %d and %d", a.RoundTrips, a.Wins)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want decimal.Decimal
+	}{
+		{"win rate", a.WinRate, decimal.RequireFromString("0.5")},
+		{"pnl", a.RealisedPnL, decimal.RequireFromString("-5")},
+		{"drawdown", a.MaxDrawdown, decimal.RequireFromString("15")},
+		{"volume", st.Total.Volume, decimal.RequireFromString("495")},
+	} {
+		if !c.got.Equal(c.want) {
+			t.Errorf("Expected %s %s, got %s", c.name, c.want, c.got)
+		}
+	}
+	if st.Total.Fills != 5 {
+		t.Errorf("Expected 5 fills, got %d", st.Total.Fills)
+	}
+}
+
+func TestSessionStatsIn(t *testing.T) {
+	tickers := marketdata.NewTickerStore()
+	p := decimal.New(20, 0)
+	tickers.Update(marketdata.Ticker{Pair: "USDTZAR", Bid: p, Ask: p, Last: p})
+
+	s := analytics.NewSession()
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(pair string, side valr.ResponseSide, price string) analytics.Trade {
+		at = at.Add(time.Minute)
+		return analytics.Trade{
+			Pair: pair, Side: side,
+			Price: decimal.RequireFromString(price), Quantity: decimal.New(1, 0),
+			TradedAt: at,
+		}
+	}
+	buy := trade("BTCZAR", valr.ResponseSideBuy, "2000")
+	buy.Fee, buy.FeeCurrency = decimal.New(20, 0), "ZAR"
+	s.Record("a", buy)
+	s.Record("a", trade("BTCZAR", valr.ResponseSideSell, "2200"))
+	s.Record("a", trade("BTCUSDT", valr.ResponseSideBuy, "100"))
+	s.Record("a", trade("BTCUSDT", valr.ResponseSideSell, "105"))
+
+	st, err := s.StatsIn(context.Background(), fx.New(tickers), "USDT")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// 100 to 110 USDT on BTCZAR and 100 to 105 on BTCUSDT.
+	total := st.Total
+	if st.Base != "USDT" || total.RealisedPnL.String() != "15" || total.Volume.String() != "415" {
+		t.Errorf("Expected P&L of 15 on 415 USDT, got %s on %s %s", total.RealisedPnL, total.Volume, st.Base)
+	}
+	if fees := total.Fees; len(fees) != 1 || fees["USDT"].String() != "1" {
+		t.Errorf("Expected 1 USDT of fees, got %v", fees)
+	}
+
+	if _, err := s.StatsIn(context.Background(), fx.New(tickers), "ETH"); !errors.Is(err, fx.ErrNoRoute) {
+		t.Errorf("Expected ErrNoRoute, got %v", err)
+	}
+}
+
+func TestSessionRounding(t *testing.T) {
+	policy := analytics.RoundingPolicy{
+		DivisionPlaces: 8,
+		Mode:           money.HalfEven,
+		RatioPlaces:    2,
+		Amounts:        money.Default,
+	}
+	s := analytics.NewSession(analytics.WithRounding(policy))
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(minute int, side valr.ResponseSide, price, qty string) analytics.Trade {
+		return analytics.Trade{
+			Pair: "BTCZAR", Side: side,
+			Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString(qty),
+			TradedAt: at.Add(time.Duration(minute) * time.Minute),
+		}
+	}
+	buy := trade(0, valr.ResponseSideBuy, "100.01", "3")
+	buy.Fee, buy.FeeCurrency = decimal.RequireFromString("0.1"), "ZAR"
+	s.Record("a", buy)
+	s.Record("a", trade(1, valr.ResponseSideSell, "100.125", "1"))
+	s.Record("a", trade(2, valr.ResponseSideSell, "99.99", "1"))
+	s.Record("a", trade(3, valr.ResponseSideSell, "99", "1"))
+
+	st := s.Stats()
+	a := st.Strategies[0]
+	for _, c := range []struct {
+		name      string
+		got, want decimal.Decimal
+	}{
+		// 1 win of 3.
+		{"win rate", a.WinRate, decimal.RequireFromString("0.33")},
+		// 0.115 - 0.02 - 1.01 = -0.915.
+		{"pnl", a.RealisedPnL, decimal.RequireFromString("-0.92")},
+		// 599.145 in total, rounded to even.
+		{"volume", a.Volume, decimal.RequireFromString("599.14")},
+		{"fees", a.Fees["ZAR"], decimal.RequireFromString("0.1")},
+	} {
+		if !c.got.Equal(c.want) {
+			t.Errorf("Expected %s %s, got %s", c.name, c.want, c.got)
+		}
+	}
+	if s.Rounding().Mode != money.HalfEven {
+		t.Errorf("Expected %s, got %s", money.HalfEven, s.Rounding().Mode)
+	}
+
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := `"rounding":{"divisionPlaces":8,"mode":"half_even","ratioPlaces":2}`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("Expected %s in %s", want, b)
+	}
+}
@@ -0,0 +1,74 @@
+// Package analytics aggregates account history into reports on trading
+// volume, fees and performance.
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Trade is a single fill on the account.
+type Trade struct {
+	OrderID     string
+	Pair        string
+	Side        valr.ResponseSide
+	Price       decimal.Decimal
+	Quantity    decimal.Decimal
+	Fee         decimal.Decimal
+	FeeCurrency string
+	Maker       bool
+	TradedAt    time.Time
+}
+
+// Notional returns the trade's value in the quote currency.
+func (t Trade) Notional() decimal.Decimal {
+	return t.Price.Mul(t.Quantity)
+}
+
+// TradesFromTransactions extracts trades from account transaction history,
+// ignoring other transaction types. The result is sorted oldest first.
+//
+// Transaction history doesn't record liquidity, so market and simple orders
+// are treated as taker, and limit orders as maker unless they paid a
+// positive fee; VALR pays makers a rebate. Override Maker where the
+// classification is known to differ.
+func TradesFromTransactions(txs []valr.TransactionInfo) []Trade {
+	var trades []Trade
+	for _, tx := range txs {
+		kind := tx.TransactionType.Type
+		var side valr.ResponseSide
+		switch {
+		case strings.HasSuffix(kind, "_BUY"):
+			side = valr.ResponseSideBuy
+		case strings.HasSuffix(kind, "_SELL"):
+			side = valr.ResponseSideSell
+		default:
+			continue
+		}
+
+		t := Trade{
+			OrderID:     tx.AdditionalInfo.OrderID,
+			Pair:        tx.AdditionalInfo.CurrencyPairSymbol,
+			Side:        side,
+			Price:       tx.AdditionalInfo.CostPerCoin,
+			Fee:         tx.FeeValue,
+			FeeCurrency: tx.FeeCurrency,
+			Maker:       strings.HasPrefix(kind, "LIMIT_") && !tx.FeeValue.IsPositive(),
+			TradedAt:    tx.EventAt.Time,
+		}
+		if side == valr.ResponseSideBuy {
+			t.Quantity = tx.CreditValue
+		} else {
+			t.Quantity = tx.DebitValue
+		}
+		trades = append(trades, t)
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].TradedAt.Before(trades[j].TradedAt)
+	})
+	return trades
+}
@@ -0,0 +1,423 @@
+// Package anomaly watches the mutating calls made with an API key and
+// raises alerts when they depart from the key's normal pattern, as a safety
+// layer against a leaked or misused key. A Monitor is fed the client's
+// audit records and learns, during a learning period, how fast orders are
+// placed, how often withdrawals are made, at which hours the key is used
+// and which endpoints it calls:
+//
+//	mon := anomaly.New(anomaly.WithNotifier(sink))
+//	cl.SetAuditHook(mon.Observe)
+//
+// Afterwards it alerts on order bursts, withdrawals from a key that never
+// made any or at an unusual rate, calls at hours the key is normally idle
+// and calls to endpoints it has never used.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/notify"
+)
+
+const (
+	defaultLearningPeriod = 7 * 24 * time.Hour
+	defaultSensitivity    = 4
+	defaultMinBurst       = 20
+	defaultCooldown       = 10 * time.Minute
+	notifyTimeout         = 10 * time.Second
+	// maxGap bounds the idle buckets folded into a rate after a gap in
+	// activity.
+	maxGap = 7 * 24 * 60
+)
+
+// Kind is the kind of an anomaly.
+type Kind string
+
+const (
+	// KindOrderBurst is more orders placed in a minute than usual.
+	KindOrderBurst Kind = "order_burst"
+	// KindUnexpectedWithdrawal is a withdrawal by a key that made none
+	// while learning.
+	KindUnexpectedWithdrawal Kind = "unexpected_withdrawal"
+	// KindWithdrawalRate is more withdrawals made in a day than usual.
+	KindWithdrawalRate Kind = "withdrawal_rate"
+	// KindUnusualHour is a call at an hour of the day the key was never
+	// used at while learning.
+	KindUnusualHour Kind = "unusual_hour"
+	// KindNewEndpoint is a call to an endpoint not called while learning.
+	KindNewEndpoint Kind = "new_endpoint"
+)
+
+// Alert describes an anomaly.
+type Alert struct {
+	Kind Kind
+	Time time.Time
+	// Endpoint is the method and path template of the call that raised
+	// the alert, e.g. "POST /wallet/crypto/{currencyCode}/withdraw".
+	Endpoint string
+	// Observed and Expected are the rate seen and the most expected, for
+	// rate anomalies.
+	Observed float64
+	Expected float64
+	Text     string
+}
+
+// Level returns the severity the alert is notified with. Withdrawal
+// anomalies are critical, since they can move funds out of the account.
+func (a Alert) Level() notify.Level {
+	switch a.Kind {
+	case KindUnexpectedWithdrawal, KindWithdrawalRate:
+		return notify.Critical
+	}
+	return notify.Warning
+}
+
+// Stats summarises a series of counts per bucket.
+type Stats struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	// M2 is the sum of squared differences from the mean.
+	M2 float64 `json:"m2"`
+	// Max is the highest count seen.
+	Max float64 `json:"max"`
+}
+
+func (s *Stats) add(x float64) {
+	s.N++
+	d := x - s.Mean
+	s.Mean += d / float64(s.N)
+	s.M2 += d * (x - s.Mean)
+	s.Max = max(s.Max, x)
+}
+
+// StdDev returns the standard deviation of the counts.
+func (s Stats) StdDev() float64 {
+	if s.N < 2 {
+		return 0
+	}
+	return math.Sqrt(s.M2 / float64(s.N-1))
+}
+
+// Baseline is what a Monitor has learned about the normal use of the key.
+// It can be saved, e.g. as JSON, and passed to WithBaseline after a
+// restart so the learning period needn't be repeated.
+type Baseline struct {
+	// Start is the time of the first call observed.
+	Start time.Time `json:"start"`
+	// Orders counts the orders placed per minute.
+	Orders Stats `json:"orders"`
+	// Withdrawals counts the withdrawals made per day.
+	Withdrawals Stats `json:"withdrawals"`
+	// Hours counts the calls made in each hour of the day while learning.
+	Hours [24]int `json:"hours"`
+	// Endpoints holds the endpoints called while learning.
+	Endpoints []string `json:"endpoints"`
+}
+
+type Option func(*Monitor)
+
+// WithLearningPeriod sets how long after the first call the monitor only
+// learns, 7 days by default. Hours and endpoints are only learned in this
+// period; rates keep being learned from normal activity afterwards.
+func WithLearningPeriod(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.learningPeriod = d
+	}
+}
+
+// WithSensitivity sets how many standard deviations above the mean a rate
+// must be to be anomalous, 4 by default.
+func WithSensitivity(k float64) Option {
+	return func(m *Monitor) {
+		m.sensitivity = k
+	}
+}
+
+// WithMinBurst sets the fewest orders in a minute that are anomalous,
+// whatever the learned rate, 20 by default. It stops keys that rarely
+// trade from alerting on a handful of orders.
+func WithMinBurst(n int) Option {
+	return func(m *Monitor) {
+		m.minBurst = n
+	}
+}
+
+// WithCooldown sets how long an alert is suppressed after it is raised,
+// per kind and endpoint, 10 minutes by default.
+func WithCooldown(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.cooldown = d
+	}
+}
+
+// WithLocation sets the location of the hours of the day, the local time
+// zone by default.
+func WithLocation(loc *time.Location) Option {
+	return func(m *Monitor) {
+		m.location = loc
+	}
+}
+
+// WithBaseline starts the monitor from a saved baseline.
+func WithBaseline(b Baseline) Option {
+	return func(m *Monitor) {
+		m.baseline = b
+		m.endpoints = make(map[string]bool, len(b.Endpoints))
+		for _, e := range b.Endpoints {
+			m.endpoints[e] = true
+		}
+	}
+}
+
+// WithAlertHandler calls fn with every alert raised. It is called
+// synchronously from Observe, so should not block.
+func WithAlertHandler(fn func(Alert)) Option {
+	return func(m *Monitor) {
+		m.onAlert = fn
+	}
+}
+
+// WithNotifier delivers every alert raised to s.
+func WithNotifier(s notify.Sink) Option {
+	return func(m *Monitor) {
+		m.notifier = s
+	}
+}
+
+// Monitor learns the normal use of an API key from its audit records and
+// raises alerts on anomalies. Its methods are safe for concurrent use.
+type Monitor struct {
+	learningPeriod time.Duration
+	sensitivity    float64
+	minBurst       int
+	cooldown       time.Duration
+	location       *time.Location
+	onAlert        func(Alert)
+	notifier       notify.Sink
+
+	mu        sync.Mutex
+	baseline  Baseline
+	endpoints map[string]bool
+	orders    bucket
+	withdraws bucket
+	// raised holds when each alert was last raised, by kind and endpoint.
+	raised map[string]time.Time
+}
+
+// bucket counts the events of the current interval of a rate.
+type bucket struct {
+	start time.Time
+	count int
+	// anomalous is true if the bucket raised an alert, which keeps it out
+	// of the learned rate.
+	anomalous bool
+}
+
+// New returns a monitor with no baseline.
+func New(opts ...Option) *Monitor {
+	m := &Monitor{
+		learningPeriod: defaultLearningPeriod,
+		sensitivity:    defaultSensitivity,
+		minBurst:       defaultMinBurst,
+		cooldown:       defaultCooldown,
+		location:       time.Local,
+		endpoints:      make(map[string]bool),
+		raised:         make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Baseline returns what the monitor has learned.
+func (m *Monitor) Baseline() Baseline {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.baseline
+	b.Endpoints = make([]string, 0, len(m.endpoints))
+	for e := range m.endpoints {
+		b.Endpoints = append(b.Endpoints, e)
+	}
+	sort.Strings(b.Endpoints)
+	return b
+}
+
+// Observe learns from or checks a call. Its signature matches
+// valr.AuditHook. Failed calls are observed too, since attempts matter as
+// much as successes for a misused key.
+func (m *Monitor) Observe(rec valr.AuditRecord) {
+	m.mu.Lock()
+	alerts := m.observe(rec)
+	m.mu.Unlock()
+
+	for _, a := range alerts {
+		if m.onAlert != nil {
+			m.onAlert(a)
+		}
+		if m.notifier != nil {
+			notify.Send(m.notifier, message(a), notifyTimeout)
+		}
+	}
+}
+
+func (m *Monitor) observe(rec valr.AuditRecord) []Alert {
+	t := rec.Time
+	if m.baseline.Start.IsZero() {
+		m.baseline.Start = t
+	}
+	learning := t.Before(m.baseline.Start.Add(m.learningPeriod))
+	endpoint := endpointOf(rec.Method, rec.Path)
+	hour := t.In(m.location).Hour()
+
+	var alerts []Alert
+	raise := func(a Alert) {
+		a.Time, a.Endpoint = t, endpoint
+		key := string(a.Kind) + " " + a.Endpoint
+		if last, ok := m.raised[key]; ok && t.Sub(last) < m.cooldown {
+			return
+		}
+		m.raised[key] = t
+		alerts = append(alerts, a)
+	}
+
+	if learning {
+		m.baseline.Hours[hour]++
+		m.endpoints[endpoint] = true
+	} else {
+		if m.baseline.Hours[hour] == 0 {
+			raise(Alert{Kind: KindUnusualHour, Text: fmt.Sprintf("call at %02d:00, an hour the key was not used at while learning", hour)})
+		}
+		if !m.endpoints[endpoint] {
+			raise(Alert{Kind: KindNewEndpoint, Text: "call to an endpoint not used while learning"})
+		}
+	}
+
+	switch {
+	case isOrder(rec.Method, rec.Path):
+		n := m.count(&m.orders, &m.baseline.Orders, t, time.Minute)
+		if limit := m.limit(m.baseline.Orders, float64(m.minBurst)); !learning && float64(n) > limit {
+			m.orders.anomalous = true
+			raise(Alert{Kind: KindOrderBurst, Observed: float64(n), Expected: limit,
+				Text: fmt.Sprintf("%d orders in a minute, expected at most %.1f", n, limit)})
+		}
+	case isWithdrawal(rec.Method, rec.Path):
+		n := m.count(&m.withdraws, &m.baseline.Withdrawals, t, 24*time.Hour)
+		if learning {
+			break
+		}
+		if m.baseline.Withdrawals.Max == 0 {
+			m.withdraws.anomalous = true
+			raise(Alert{Kind: KindUnexpectedWithdrawal, Observed: float64(n),
+				Text: "withdrawal by a key that made none while learning"})
+		} else if limit := m.limit(m.baseline.Withdrawals, m.baseline.Withdrawals.Max); float64(n) > limit {
+			m.withdraws.anomalous = true
+			raise(Alert{Kind: KindWithdrawalRate, Observed: float64(n), Expected: limit,
+				Text: fmt.Sprintf("%d withdrawals in a day, expected at most %.1f", n, limit)})
+		}
+	}
+	return alerts
+}
+
+// count adds an event at t to b, folding the buckets completed since into
+// s, and returns the count of the current bucket.
+func (m *Monitor) count(b *bucket, s *Stats, t time.Time, width time.Duration) int {
+	start := t.Truncate(width)
+	if b.start.IsZero() {
+		b.start = start
+	}
+	if start.After(b.start) {
+		if !b.anomalous {
+			s.add(float64(b.count))
+		}
+		idle := int(start.Sub(b.start)/width) - 1
+		for i := 0; i < min(idle, maxGap); i++ {
+			s.add(0)
+		}
+		*b = bucket{start: start}
+	}
+	b.count++
+	return b.count
+}
+
+// limit returns the highest normal count of a rate: sensitivity standard
+// deviations above its mean, but at least floor.
+func (m *Monitor) limit(s Stats, floor float64) float64 {
+	return max(s.Mean+m.sensitivity*s.StdDev(), floor)
+}
+
+func isOrder(method, path string) bool {
+	return method == "POST" && (strings.Contains(path, "/orders/") || strings.Contains(path, "/batch/orders"))
+}
+
+func isWithdrawal(method, path string) bool {
+	return method == "POST" && strings.Contains(path, "/withdraw")
+}
+
+// versionPrefix matches the API version a request path starts with.
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// endpointOf returns the method and path template of a request, or its
+// method and path if no endpoint of the client matches.
+func endpointOf(method, path string) string {
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+	path = versionPrefix.ReplaceAllString(path, "/")
+	segs := strings.Split(path, "/")
+	best, tags := path, -1
+	for _, e := range valr.Endpoints() {
+		if e.Method != method {
+			continue
+		}
+		// The most specific template wins, e.g. /orders/open over
+		// /orders/{currencyPair}.
+		if n, ok := matches(strings.Split(e.Path, "/"), segs); ok && (tags < 0 || n < tags) {
+			best, tags = e.Path, n
+		}
+	}
+	return method + " " + best
+}
+
+// matches returns true if the segments of a path match those of a
+// template, where {tags} match any segment, and the number of tags.
+func matches(template, segs []string) (int, bool) {
+	if len(template) != len(segs) {
+		return 0, false
+	}
+	tags := 0
+	for i, s := range template {
+		switch {
+		case strings.HasPrefix(s, "{"):
+			tags++
+		case s != segs[i]:
+			return 0, false
+		}
+	}
+	return tags, true
+}
+
+func message(a Alert) notify.Message {
+	m := notify.Message{
+		Level:  a.Level(),
+		Source: "anomaly",
+		Title:  fmt.Sprintf("Account activity anomaly: %s", a.Kind),
+		Text:   a.Text,
+		Fields: []notify.Field{{Name: "endpoint", Value: a.Endpoint}},
+		Time:   a.Time,
+	}
+	if a.Expected > 0 {
+		m.Fields = append(m.Fields,
+			notify.Field{Name: "observed", Value: fmt.Sprintf("%g", a.Observed)},
+			notify.Field{Name: "expected", Value: fmt.Sprintf("%.1f", a.Expected)})
+	}
+	return m
+}
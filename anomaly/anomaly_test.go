@@ -0,0 +1,77 @@
+package anomaly_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/anomaly"
+)
+
+func TestMonitor(t *testing.T) {
+	var alerts []anomaly.Alert
+	opts := []anomaly.Option{
+		anomaly.WithLearningPeriod(time.Hour),
+		anomaly.WithLocation(time.UTC),
+		anomaly.WithAlertHandler(func(a anomaly.Alert) { alerts = append(alerts, a) }),
+	}
+	m := anomaly.New(opts...)
+	call := func(at time.Time, method, path string) {
+		m.Observe(valr.AuditRecord{Time: at, Method: method, Path: path, StatusCode: 202})
+	}
+
+	// Two orders a minute from 10:00 to 11:00.
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		call(start.Add(time.Duration(i)*30*time.Second), "POST", "/v1/orders/limit")
+	}
+	call(start.Add(59*time.Minute), "DELETE", "/v1/orders/order")
+	if len(alerts) != 0 {
+		t.Fatalf("Expected no alerts while learning, got %+v", alerts)
+	}
+
+	expect := func(kind anomaly.Kind, endpoint string) {
+		t.Helper()
+		if len(alerts) != 1 || alerts[0].Kind != kind || alerts[0].Endpoint != endpoint {
+			t.Errorf("Expected a %s alert for %q, got %+v", kind, endpoint, alerts)
+		}
+		alerts = nil
+	}
+
+	// The next day, normal trading raises nothing but a burst does, once.
+	day := start.Add(24 * time.Hour)
+	for i := 0; i < 4; i++ {
+		call(day.Add(time.Duration(i)*30*time.Second), "POST", "/v1/orders/limit")
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("Expected no alerts, got %+v", alerts)
+	}
+	for i := 0; i < 30; i++ {
+		call(day.Add(5*time.Minute+time.Duration(i)*time.Second), "POST", "/v1/orders/limit")
+	}
+	expect(anomaly.KindOrderBurst, "POST /orders/limit")
+
+	// A withdrawal is both unexpected and to a new endpoint.
+	call(day.Add(30*time.Minute), "POST", "/v1/wallet/crypto/BTC/withdraw")
+	if len(alerts) != 2 || alerts[0].Kind != anomaly.KindNewEndpoint || alerts[1].Kind != anomaly.KindUnexpectedWithdrawal {
+		t.Errorf("Expected new endpoint and unexpected withdrawal alerts, got %+v", alerts)
+	}
+	if len(alerts) == 2 && alerts[1].Endpoint != "POST /wallet/crypto/{currencyCode}/withdraw" {
+		t.Errorf("Expected %q, got %q", "POST /wallet/crypto/{currencyCode}/withdraw", alerts[1].Endpoint)
+	}
+	alerts = nil
+
+	call(day.Add(17*time.Hour), "DELETE", "/v1/orders/order")
+	expect(anomaly.KindUnusualHour, "DELETE /orders/order")
+
+	// A restarted monitor carries on from the saved baseline.
+	b := m.Baseline()
+	if len(b.Endpoints) != 2 || b.Hours[10] != 121 {
+		t.Errorf("Expected 2 endpoints and 121 calls at 10:00, got %+v", b)
+	}
+	m = anomaly.New(append(opts, anomaly.WithBaseline(b))...)
+	call(day.Add(time.Hour), "POST", "/v1/wallet/fiat/ZAR/withdraw")
+	if len(alerts) != 3 {
+		t.Errorf("Expected unusual hour, new endpoint and unexpected withdrawal alerts, got %+v", alerts)
+	}
+}
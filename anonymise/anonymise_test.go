@@ -0,0 +1,97 @@
+package anonymise_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/anonymise"
+)
+
+func TestJSON(t *testing.T) {
+	a, err := anonymise.New([]byte("secret"), anonymise.WithField("pair", anonymise.Hash), anonymise.WithField("address", anonymise.Keep))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	in := `{"orderId":"o1","quantity":1.50000000000000000001,"pair":"BTCZAR","address":"bc1q",
+		"frame":{"data":{"OrderID":"o1","customer_order_id":7}},"beneficiary":{"firstName":"Ann"},
+		"fills":[{"orderId":"o2"},{"orderId":""}]}`
+	out, err := a.JSON([]byte(in))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var got struct {
+		OrderID     string          `json:"orderId"`
+		Quantity    json.RawMessage `json:"quantity"`
+		Pair        string          `json:"pair"`
+		Address     string          `json:"address"`
+		Beneficiary any             `json:"beneficiary"`
+		Frame       struct {
+			Data map[string]string `json:"data"`
+		} `json:"frame"`
+		Fills []map[string]string `json:"fills"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if got.OrderID != a.Value("o1") || !strings.HasPrefix(got.OrderID, "anon_") {
+		t.Errorf("Expected hashed order ID, got %q", got.OrderID)
+	}
+	if got.Frame.Data["OrderID"] != got.OrderID {
+		t.Errorf("Expected nested order ID to join, got %q", got.Frame.Data["OrderID"])
+	}
+	if got.Frame.Data["customer_order_id"] != a.Value("7") {
+		t.Errorf("Expected hashed customer order ID, got %q", got.Frame.Data["customer_order_id"])
+	}
+	if string(got.Quantity) != "1.50000000000000000001" {
+		t.Errorf("Expected quantity to be preserved, got %s", got.Quantity)
+	}
+	if got.Pair != a.Value("BTCZAR") || got.Address != "bc1q" {
+		t.Errorf("Expected overrides to apply, got %q and %q", got.Pair, got.Address)
+	}
+	if got.Beneficiary != nil {
+		t.Errorf("Expected beneficiary to be stripped, got %v", got.Beneficiary)
+	}
+	if got.Fills[0]["orderId"] != a.Value("o2") || got.Fills[1]["orderId"] != "" {
+		t.Errorf("Unexpected fills %v", got.Fills)
+	}
+
+	other, _ := anonymise.New([]byte("other"))
+	if other.Value("o1") == a.Value("o1") {
+		t.Errorf("Expected hashes to depend on the key")
+	}
+	if _, err := anonymise.New(nil); err == nil {
+		t.Errorf("Expected error for empty key")
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	a, _ := anonymise.New([]byte("secret"))
+	var buf bytes.Buffer
+	err := a.JSONLines(strings.NewReader("{\"orderId\":\"o1\"}\n\n{\"orderId\":\"o1\"}\n"), &buf)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := `{"orderId":"` + a.Value("o1") + `"}` + "\n"
+	if buf.String() != want+want {
+		t.Errorf("Expected %q, got %q", want+want, buf.String())
+	}
+	if err := a.JSONLines(strings.NewReader("{}\nnot json\n"), &buf); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error on line 2, got %v", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	a, _ := anonymise.New([]byte("secret"))
+	var buf bytes.Buffer
+	in := "ref,recipient_email,order_id,amount\na,ann@example.com,o1,10\n"
+	if err := a.CSV(strings.NewReader(in), &buf); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := "ref,recipient_email,order_id,amount\na,," + a.Value("o1") + ",10\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
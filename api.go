@@ -2,7 +2,6 @@ package valr
 
 import (
 	"context"
-	"net/http"
 )
 
 /*
@@ -15,48 +14,28 @@ PUBLIC API GET REQUESTS
 // Ask orders are sorted by price ascending. Bid orders are sorted by price descending.
 // Orders of the same price are aggregated.
 func (cl *Client) GetOrderBook(ctx context.Context, req *GetOrderBookRequest) (*OrderBook, error) {
-	var res *OrderBook
-	err := cl.do(ctx, http.MethodGet, "/public/{currencyPair}/orderbook", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*OrderBook](ctx, cl, "/public/{currencyPair}/orderbook", req)
 }
 
 // GetCurrencies
 //
 // Get a list of currencies supported by VALR.
 func (cl *Client) GetCurrencies(ctx context.Context, req *GetCurrenciesRequest) ([]CurrencyInfo, error) {
-	var res []CurrencyInfo
-	err := cl.do(ctx, http.MethodGet, "/public/currencies", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]CurrencyInfo](ctx, cl, "/public/currencies", req)
 }
 
 // GetCurrencyPairs
 //
 // Get a list of all the currency pairs supported by VALR.
 func (cl *Client) GetCurrencyPairs(ctx context.Context, req *GetCurrencyPairsRequest) ([]PairInfo, error) {
-	var res []PairInfo
-	err := cl.do(ctx, http.MethodGet, "/public/pairs", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]PairInfo](ctx, cl, "/public/pairs", req)
 }
 
 // GetCurrencyPairs
 //
 // Get a list of all the currency pairs supported by VALR.
 func (cl *Client) GetCurrencyPairsByType(ctx context.Context, req *GetCurrencyPairsByTypeRequest) ([]PairInfo, error) {
-	var res []PairInfo
-	err := cl.do(ctx, http.MethodGet, "/public/pairs/{pairType}", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]PairInfo](ctx, cl, "/public/pairs/{pairType}", req)
 }
 
 // GetOrderTypesRequest
@@ -71,12 +50,7 @@ func (cl *Client) GetCurrencyPairsByType(ctx context.Context, req *GetCurrencyPa
 // market : Place a market order on the Exchange (only crypto-to-ZAR pairs).
 // simple : Similar to a market order, but allows for crypto-to-crypto pairs.
 func (cl *Client) GetOrderTypesRequest(ctx context.Context, req *GetOrderTypesRequest) ([]OrderTypes, error) {
-	var res []OrderTypes
-	err := cl.do(ctx, http.MethodGet, "/public/ordertypes", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]OrderTypes](ctx, cl, "/public/ordertypes", req)
 }
 
 // GetOrderTypesForPairRequest
@@ -91,76 +65,64 @@ func (cl *Client) GetOrderTypesRequest(ctx context.Context, req *GetOrderTypesRe
 // market : Place a market order on the Exchange (only crypto-to-ZAR pairs).
 // simple : Similar to a market order, but allows for crypto-to-crypto pairs.
 func (cl *Client) GetOrderTypesForPairRequest(ctx context.Context, req *GetOrderTypesForPairRequest) ([]string, error) {
-	var res []string
-	err := cl.do(ctx, http.MethodGet, "/public/{currencyPair}/ordertypes", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]string](ctx, cl, "/public/{currencyPair}/ordertypes", req)
 }
 
 // GetMarketSummaryRequest
 //
 // Get the market summary for all supported currency pairs.
 func (cl *Client) GetMarketSummaryRequest(ctx context.Context, req *GetMarketSummaryRequest) ([]MarketSummary, error) {
-	var res []MarketSummary
-	err := cl.do(ctx, http.MethodGet, "/public/marketsummary", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]MarketSummary](ctx, cl, "/public/marketsummary", req)
 }
 
 // GetMarketSummaryForPairRequest
 //
 // Get the market summary for a given currency pair.
 func (cl *Client) GetMarketSummaryForPairRequest(ctx context.Context, req *GetMarketSummaryForPairRequest) (*MarketSummary, error) {
-	var res *MarketSummary
-	err := cl.do(ctx, http.MethodGet, "/public/{currencyPair}/marketsummary", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*MarketSummary](ctx, cl, "/public/{currencyPair}/marketsummary", req)
+}
+
+// GetTradeHistoryRequest
+//
+// Get the recent trades of a currency pair, newest first. Page through
+// older trades with BeforeID, or poll for newer ones with AfterID.
+func (cl *Client) GetTradeHistoryRequest(ctx context.Context, req *GetTradeHistoryRequest) ([]TradeHistoryInfo, error) {
+	return Get[[]TradeHistoryInfo](ctx, cl, "/public/{currencyPair}/trades", req)
 }
 
 // GetServerTimeRequest
 //
 // Get the server time.
 func (cl *Client) GetServerTimeRequest(ctx context.Context, req *GetServerTimeRequest) (*GetServerTimeResponse, error) {
-	var res GetServerTimeResponse
-	err := cl.do(ctx, http.MethodGet, "/public/time", req, &res, false)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetServerTimeResponse](ctx, cl, "/public/time", req)
 }
 
 /*
 PRIVATE API GET REQUESTS
 */
 
+// GetCurrentAPIKeyRequest
+//
+// Returns information about the API key used to sign the request, including
+// its permissions.
+func (cl *Client) GetCurrentAPIKeyRequest(ctx context.Context, req *GetCurrentAPIKeyRequest) (*GetCurrentAPIKeyResponse, error) {
+	return Get[*GetCurrentAPIKeyResponse](ctx, cl, "/account/api-keys/current", req)
+}
+
 // GetAccountBalancesRequest
 //
 // Returns the list of all wallets with their respective balances.
 func (cl *Client) GetAccountBalancesRequest(ctx context.Context, req *GetAccountBalancesRequest) ([]AccountBalance, error) {
-	var res []AccountBalance
-	err := cl.do(ctx, http.MethodGet, "/account/balances", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]AccountBalance](ctx, cl, "/account/balances", req)
 }
 
 // GetTransactionHistoryRequest
 //
-// Transaction history for your account. Note: This API supports pagination.
+// Transaction history for your account, optionally filtered by transaction
+// types, currency, time range or a transaction ID to list from. Note: This
+// API supports pagination.
 func (cl *Client) GetTransactionHistoryRequest(ctx context.Context, req *GetTransactionHistoryRequest) ([]TransactionInfo, error) {
-	var res []TransactionInfo
-	err := cl.do(ctx, http.MethodGet, "/account/transactionhistory", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]TransactionInfo](ctx, cl, "/account/transactionhistory", req)
 }
 
 // GetTradeHistoryForPairRequest
@@ -168,24 +130,14 @@ func (cl *Client) GetTransactionHistoryRequest(ctx context.Context, req *GetTran
 // Get the last 100 recent trades for a given currency pair for your account.
 // You can limit the number of trades returned by specifying the limit parameter.
 func (cl *Client) GetTradeHistoryForPairRequest(ctx context.Context, req *GetTradeHistoryForPairRequest) ([]TradeInfo, error) {
-	var res []TradeInfo
-	err := cl.do(ctx, http.MethodGet, "/account/{currencyPair}/tradehistory", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]TradeInfo](ctx, cl, "/account/{currencyPair}/tradehistory", req)
 }
 
 // GetDepositAddressRequest
 //
 // Returns the default deposit address associated with currency specified in the path variable {currencyCode}.
 func (cl *Client) GetDepositAddressRequest(ctx context.Context, req *GetDepositAddressRequest) (*GetDepositAddressResponse, error) {
-	var res GetDepositAddressResponse
-	err := cl.do(ctx, http.MethodGet, "/wallet/crypto/{currencyCode}/deposit/address", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetDepositAddressResponse](ctx, cl, "/wallet/crypto/{currencyCode}/deposit/address", req)
 }
 
 // GetWithdrawInfoRequest
@@ -193,60 +145,35 @@ func (cl *Client) GetDepositAddressRequest(ctx context.Context, req *GetDepositA
 // Get all the information about withdrawing a given currency from your VALR account.
 // That will include withdrawal costs, minimum withdrawal amount etc.
 func (cl *Client) GetWithdrawInfoRequest(ctx context.Context, req *GetWithdrawInfoRequest) (*GetWithdrawInfoResponse, error) {
-	var res GetWithdrawInfoResponse
-	err := cl.do(ctx, http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetWithdrawInfoResponse](ctx, cl, "/wallet/crypto/{currencyCode}/withdraw", req)
 }
 
 // GetWithdrawStatusRequest
 //
 // Check the status of a withdrawal.
 func (cl *Client) GetWithdrawStatusRequest(ctx context.Context, req *GetWithdrawStatusRequest) (*WithdrawInfo, error) {
-	var res *WithdrawInfo
-	err := cl.do(ctx, http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw/{withdrawId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*WithdrawInfo](ctx, cl, "/wallet/crypto/{currencyCode}/withdraw/{withdrawId}", req)
 }
 
 // GetDepositHistoryForAssetRequest
 //
 // Get the Deposit History records for a given currency.
 func (cl *Client) GetDepositHistoryForAssetRequest(ctx context.Context, req *GetDepositHistoryForAssetRequest) ([]DepositInfo, error) {
-	var res []DepositInfo
-	err := cl.do(ctx, http.MethodGet, "/wallet/crypto/{currencyCode}/deposit/history", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]DepositInfo](ctx, cl, "/wallet/crypto/{currencyCode}/deposit/history", req)
 }
 
 // GetWithdrawHistoryForAssetRequest
 //
 // Get Withdrawal History records for a given currency.
 func (cl *Client) GetWithdrawHistoryForAssetRequest(ctx context.Context, req *GetWithdrawHistoryForAssetRequest) ([]WithdrawInfo, error) {
-	var res []WithdrawInfo
-	err := cl.do(ctx, http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw/history", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]WithdrawInfo](ctx, cl, "/wallet/crypto/{currencyCode}/withdraw/history", req)
 }
 
 // GetBankAccountForAssetRequest
 // Get a list of bank accounts that are linked to your VALR account.
 // Bank accounts can be linked by signing in to your account on www.VALR.com.
 func (cl *Client) GetBankAccountForAssetRequest(ctx context.Context, req *GetBankAccountForAssetRequest) ([]BankInfo, error) {
-	var res []BankInfo
-	err := cl.do(ctx, http.MethodGet, "/wallet/fiat/{currencyCode}/accounts", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]BankInfo](ctx, cl, "/wallet/fiat/{currencyCode}/accounts", req)
 }
 
 // GetAuthOrderBookRequest
@@ -255,12 +182,7 @@ func (cl *Client) GetBankAccountForAssetRequest(ctx context.Context, req *GetBan
 // Ask orders are sorted by price ascending. Bid orders are sorted by price descending.
 // Orders of the same price are aggregated.
 func (cl *Client) GetAuthOrderBookRequest(ctx context.Context, req *GetAuthOrderBookRequest) (*OrderBook, error) {
-	var res *OrderBook
-	err := cl.do(ctx, http.MethodGet, "/marketdata/{currencyPair}/orderbook", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*OrderBook](ctx, cl, "/marketdata/{currencyPair}/orderbook", req)
 }
 
 // GetAuthFullOrderBookRequest
@@ -270,37 +192,23 @@ func (cl *Client) GetAuthOrderBookRequest(ctx context.Context, req *GetAuthOrder
 // Bid orders are sorted by price descending.
 // Orders of the same price are aggregated.
 func (cl *Client) GetAuthFullOrderBookRequest(ctx context.Context, req *GetAuthFullOrderBookRequest) (*OrderBook, error) {
-	var res *OrderBook
-	err := cl.do(ctx, http.MethodGet, "/marketdata/{currencyPair}/orderbook/full", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*OrderBook](ctx, cl, "/marketdata/{currencyPair}/orderbook/full", req)
 }
 
 // GetAuthTradeHistoryForPairRequest
 //
 // Get the last 100 recent trades for a given currency pair.
-// You can limit the number of trades returned by specifying the limit parameter.
+// You can limit the number of trades returned by specifying the limit parameter,
+// and page through older or newer trades with BeforeID or AfterID.
 func (cl *Client) GetAuthTradeHistoryForPairRequest(ctx context.Context, req *GetAuthTradeHistoryForPairRequest) ([]TradeHistoryInfo, error) {
-	var res []TradeHistoryInfo
-	err := cl.do(ctx, http.MethodGet, "/marketdata/{currencyPair}/tradehistory", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]TradeHistoryInfo](ctx, cl, "/marketdata/{currencyPair}/tradehistory", req)
 }
 
 // GetSimpleBuyOrSellOrderStatusRequest
 //
 // Get the status of a Simple Buy/Sell order.
 func (cl *Client) GetSimpleBuyOrSellOrderStatusRequest(ctx context.Context, req *GetSimpleBuyOrSellOrderStatusRequest) (*GetSimpleBuyOrSellOrderStatusResponse, error) {
-	var res GetSimpleBuyOrSellOrderStatusResponse
-	err := cl.do(ctx, http.MethodGet, "/simple/{currencyPair}/order/{orderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetSimpleBuyOrSellOrderStatusResponse](ctx, cl, "/simple/{currencyPair}/order/{orderId}", req)
 }
 
 // GetOrderStatusByOrderIDRequest
@@ -311,12 +219,7 @@ func (cl *Client) GetSimpleBuyOrSellOrderStatusRequest(ctx context.Context, req
 //
 // Note: If a customerOrderId was also specified while placing the order, that customerOrderId will be returned as part of the response.
 func (cl *Client) GetOrderStatusByOrderIDRequest(ctx context.Context, req *GetOrderStatusByOrderIDRequest) (*GetOrderStatusByOrderIDResponse, error) {
-	var res GetOrderStatusByOrderIDResponse
-	err := cl.do(ctx, http.MethodGet, "/orders/{currencyPair}/orderid/{orderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetOrderStatusByOrderIDResponse](ctx, cl, "/orders/{currencyPair}/orderid/{orderId}", req)
 }
 
 // GetOrderStatusByCustomerOrderIDRequest
@@ -325,12 +228,7 @@ func (cl *Client) GetOrderStatusByOrderIDRequest(ctx context.Context, req *GetOr
 // The customer can specify a customerOrderId while placing an order on the Exchange.
 // Use this API to query the order status using that customerOrderId.
 func (cl *Client) GetOrderStatusByCustomerOrderIDRequest(ctx context.Context, req *GetOrderStatusByCustomerOrderIDRequest) (*GetOrderStatusByOrderIDResponse, error) {
-	var res *GetOrderStatusByOrderIDResponse
-	err := cl.do(ctx, http.MethodGet, "/orders/{currencyPair}/customerorderid/{customerOrderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[*GetOrderStatusByOrderIDResponse](ctx, cl, "/orders/{currencyPair}/customerorderid/{customerOrderId}", req)
 }
 
 // GetAllOpenOrdersRequest
@@ -338,24 +236,14 @@ func (cl *Client) GetOrderStatusByCustomerOrderIDRequest(ctx context.Context, re
 // Get all open orders for your account.
 // A customerOrderId field will be returned in the response for all those orders that were created with a customerOrderId field.
 func (cl *Client) GetAllOpenOrdersRequest(ctx context.Context, req *GetAllOpenOrdersRequest) ([]OpenOrder, error) {
-	var res []OpenOrder
-	err := cl.do(ctx, http.MethodGet, "/orders/open", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]OpenOrder](ctx, cl, "/orders/open", req)
 }
 
 // GetOrderHistoryRequest
 //
 // Get historical orders placed by you.
 func (cl *Client) GetOrderHistoryRequest(ctx context.Context, req *GetOrderHistoryRequest) ([]OrderReceipt, error) {
-	var res []OrderReceipt
-	err := cl.do(ctx, http.MethodGet, "/orders/history", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]OrderReceipt](ctx, cl, "/orders/history", req)
 }
 
 // GetOrderHistorySummaryByOrderIDRequest
@@ -364,12 +252,7 @@ func (cl *Client) GetOrderHistoryRequest(ctx context.Context, req *GetOrderHisto
 // When this happens, you can get a more detailed summary about this order using this call.
 // Orders that are not completed are invalid for this request.
 func (cl *Client) GetOrderHistorySummaryByOrderIDRequest(ctx context.Context, req *GetOrderHistorySummaryByOrderIDRequest) (*GetOrderHistorySummaryByOrderIDResponse, error) {
-	var res GetOrderHistorySummaryByOrderIDResponse
-	err := cl.do(ctx, http.MethodGet, "/orders/history/summary/orderid/{orderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetOrderHistorySummaryByOrderIDResponse](ctx, cl, "/orders/history/summary/orderid/{orderId}", req)
 }
 
 // GetOrderHistorySummaryByCustomerOrderIDRequest
@@ -378,12 +261,7 @@ func (cl *Client) GetOrderHistorySummaryByOrderIDRequest(ctx context.Context, re
 // When this happens, you can get a more detailed summary about this order using this call.
 // Orders that are not completed are invalid for this request.
 func (cl *Client) GetOrderHistorySummaryByCustomerOrderIDRequest(ctx context.Context, req *GetOrderHistorySummaryByCustomerOrderIDRequest) (*GetOrderHistorySummaryByCustomerOrderIDResponse, error) {
-	var res GetOrderHistorySummaryByCustomerOrderIDResponse
-	err := cl.do(ctx, http.MethodGet, "/orders/history/summary/customerorderid/{customerOrderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Get[*GetOrderHistorySummaryByCustomerOrderIDResponse](ctx, cl, "/orders/history/summary/customerorderid/{customerOrderId}", req)
 }
 
 // GetOrderHistoryDetailsByOrderIDRequest
@@ -391,12 +269,7 @@ func (cl *Client) GetOrderHistorySummaryByCustomerOrderIDRequest(ctx context.Con
 // Get a detailed history of an order's statuses. This call returns an array of "Order Status" objects.
 // The latest and most up-to-date status of this order is the zeroth element in the array.
 func (cl *Client) GetOrderHistoryDetailsByOrderIDRequest(ctx context.Context, req *GetOrderHistoryDetailsByOrderIDRequest) ([]OrderStatus, error) {
-	var res []OrderStatus
-	err := cl.do(ctx, http.MethodGet, "/orders/history/detail/orderid/{orderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]OrderStatus](ctx, cl, "/orders/history/detail/orderid/{orderId}", req)
 }
 
 // GetOrderHistoryDetailsByCustomerOrderIDRequest
@@ -404,12 +277,37 @@ func (cl *Client) GetOrderHistoryDetailsByOrderIDRequest(ctx context.Context, re
 // Get a detailed history of an order's statuses. This call returns an array of "Order Status" objects.
 // The latest and most up-to-date status of this order is the zeroth element in the array.
 func (cl *Client) GetOrderHistoryDetailsByCustomerOrderIDRequest(ctx context.Context, req *GetOrderHistoryDetailsByCustomerOrderIDRequest) ([]OrderStatus, error) {
-	var res []OrderStatus
-	err := cl.do(ctx, http.MethodGet, "/orders/history/detail/customerorderid/{customerOrderId}", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return Get[[]OrderStatus](ctx, cl, "/orders/history/detail/customerorderid/{customerOrderId}", req)
+}
+
+// GetOpenPositionsRequest
+//
+// Get all open futures positions, optionally filtered by currency pair.
+func (cl *Client) GetOpenPositionsRequest(ctx context.Context, req *GetOpenPositionsRequest) ([]OpenPosition, error) {
+	return Get[[]OpenPosition](ctx, cl, "/positions/open", req)
+}
+
+// GetPayLimitsRequest
+//
+// Get the VALR Pay limits for a currency, including the amount that can
+// still be paid today.
+func (cl *Client) GetPayLimitsRequest(ctx context.Context, req *GetPayLimitsRequest) (*GetPayLimitsResponse, error) {
+	return Get[*GetPayLimitsResponse](ctx, cl, "/pay/limits", req)
+}
+
+// GetPayStatusRequest
+//
+// Get the status of a VALR Pay payment by the identifier returned when it
+// was made.
+func (cl *Client) GetPayStatusRequest(ctx context.Context, req *GetPayStatusRequest) (*GetPayStatusResponse, error) {
+	return Get[*GetPayStatusResponse](ctx, cl, "/pay/identifier/{identifier}", req)
+}
+
+// GetSubaccountsRequest
+//
+// List the subaccounts of the primary account.
+func (cl *Client) GetSubaccountsRequest(ctx context.Context, req *GetSubaccountsRequest) ([]Subaccount, error) {
+	return Get[[]Subaccount](ctx, cl, "/account/subaccounts", req)
 }
 
 /*
@@ -422,24 +320,22 @@ PRIVATE API POST REQUESTS
 // The request body for XRP, XMR, XEM, XLM will accept an optional field called "paymentReference".
 // Max length for paymentReference is 256.
 func (cl *Client) PostNewCryptoWithdrawRequest(ctx context.Context, req *PostNewCryptoWithdrawRequest) (*PostNewCryptoWithdrawResponse, error) {
-	var res PostNewCryptoWithdrawResponse
-	err := cl.do(ctx, http.MethodPost, "/wallet/crypto/{currencyCode}/withdraw", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Post[*PostNewCryptoWithdrawResponse](ctx, cl, "/wallet/crypto/{currencyCode}/withdraw", req)
 }
 
 // PostNewFiatWithdrawRequest
 //
 // Withdraw your ZAR funds into one of your linked bank accounts.
 func (cl *Client) PostNewFiatWithdrawRequest(ctx context.Context, req *PostNewFiatWithdrawRequest) (*PostNewFiatWithdrawResponse, error) {
-	var res PostNewFiatWithdrawResponse
-	err := cl.do(ctx, http.MethodPost, "/wallet/fiat/{currencyCode}/withdraw", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Post[*PostNewFiatWithdrawResponse](ctx, cl, "/wallet/fiat/{currencyCode}/withdraw", req)
+}
+
+// PostPayRequest
+//
+// Pay another VALR user by email, cell number or Pay ID. Payments are
+// subject to the client's withdrawal policy and approval gate.
+func (cl *Client) PostPayRequest(ctx context.Context, req *PostPayRequest) (*PostPayResponse, error) {
+	return Post[*PostPayResponse](ctx, cl, "/pay", req)
 }
 
 // PostSimpleBuyOrSellQuoteRequest
@@ -454,12 +350,7 @@ func (cl *Client) PostNewFiatWithdrawRequest(ctx context.Context, req *PostNewFi
 //	   "side": "SELL"
 //	}
 func (cl *Client) PostSimpleBuyOrSellQuoteRequest(ctx context.Context, req *PostSimpleBuyOrSellQuoteRequest) (*PostSimpleBuyOrSellQuoteResponse, error) {
-	var res PostSimpleBuyOrSellQuoteResponse
-	err := cl.do(ctx, http.MethodPost, "/simple/{currencyPair}/quote", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Post[*PostSimpleBuyOrSellQuoteResponse](ctx, cl, "/simple/{currencyPair}/quote", req)
 }
 
 // PostSimpleBuyOrSellOrderRequest
@@ -474,12 +365,7 @@ func (cl *Client) PostSimpleBuyOrSellQuoteRequest(ctx context.Context, req *Post
 //	   "side": "SELL"
 //	}
 func (cl *Client) PostSimpleBuyOrSellOrderRequest(ctx context.Context, req *PostSimpleBuyOrSellOrderRequest) (*PostSimpleBuyOrSellOrderResponse, error) {
-	var res PostSimpleBuyOrSellOrderResponse
-	err := cl.do(ctx, http.MethodPost, "/simple/{currencyPair}/order", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Post[*PostSimpleBuyOrSellOrderResponse](ctx, cl, "/simple/{currencyPair}/order", req)
 }
 
 // PostLimitOrderRequest
@@ -497,12 +383,13 @@ func (cl *Client) PostSimpleBuyOrSellOrderRequest(ctx context.Context, req *Post
 //	   "customerOrderId": "1234"
 //	}
 func (cl *Client) PostLimitOrderRequest(ctx context.Context, req *PostLimitOrderRequest) (*PostLimitOrderResponse, error) {
-	var res PostLimitOrderResponse
-	err := cl.do(ctx, http.MethodPost, "/orders/limit", req, &res, true)
-	if err != nil {
-		return nil, err
+	res, err := placeWithinBudget(ctx, cl, req.Pair, req.CustomerOrderID, func(ctx context.Context) (*PostLimitOrderResponse, error) {
+		return Post[*PostLimitOrderResponse](ctx, cl, "/orders/limit", req)
+	})
+	if err == nil {
+		cl.trackImmediate(req, res)
 	}
-	return &res, nil
+	return res, err
 }
 
 // PostMarketBuyRequest
@@ -519,12 +406,9 @@ func (cl *Client) PostLimitOrderRequest(ctx context.Context, req *PostLimitOrder
 //	   "customerOrderId": "1234"
 //	}
 func (cl *Client) PostMarketBuyRequest(ctx context.Context, req *PostMarketOrderBuyRequest) (*PostMarketOrderResponse, error) {
-	var res PostMarketOrderResponse
-	err := cl.do(ctx, http.MethodPost, "/orders/market", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return placeWithinBudget(ctx, cl, req.Pair, req.CustomerOrderID, func(ctx context.Context) (*PostMarketOrderResponse, error) {
+		return Post[*PostMarketOrderResponse](ctx, cl, "/orders/market", req)
+	})
 }
 
 // PostMarketBuyRequest
@@ -541,12 +425,111 @@ func (cl *Client) PostMarketBuyRequest(ctx context.Context, req *PostMarketOrder
 //	   "customerOrderId": "1234"
 //	}
 func (cl *Client) PostMarketSellRequest(ctx context.Context, req *PostMarketOrderSellRequest) (*PostMarketOrderResponse, error) {
-	var res PostMarketOrderResponse
-	err := cl.do(ctx, http.MethodPost, "/orders/market", req, &res, true)
+	return placeWithinBudget(ctx, cl, req.Pair, req.CustomerOrderID, func(ctx context.Context) (*PostMarketOrderResponse, error) {
+		return Post[*PostMarketOrderResponse](ctx, cl, "/orders/market", req)
+	})
+}
+
+// PostMarketBaseAmountRequest
+//
+// Create a new market order on either side, sized in the base currency.
+// Set ReduceOnly on futures pairs to only ever reduce an open position.
+//
+// Example request body:
+//
+//	{
+//	   "side": "BUY",
+//	   "baseAmount": "0.100000",
+//	   "pair": "BTCUSDTPERP",
+//	   "reduceOnly": true
+//	}
+func (cl *Client) PostMarketBaseAmountRequest(ctx context.Context, req *PostMarketOrderBaseAmountRequest) (*PostMarketOrderResponse, error) {
+	return placeWithinBudget(ctx, cl, req.Pair, req.CustomerOrderID, func(ctx context.Context) (*PostMarketOrderResponse, error) {
+		return Post[*PostMarketOrderResponse](ctx, cl, "/orders/market", req)
+	})
+}
+
+// PostStopLimitOrderRequest
+//
+// Create a new stop-limit order, placed as a limit order at price once the
+// market reaches stopPrice.
+//
+// Example request body:
+//
+//	{
+//	   "side": "SELL",
+//	   "quantity": "0.100000",
+//	   "price": "9000",
+//	   "stopPrice": "9100",
+//	   "pair": "BTCZAR",
+//	   "type": "STOP_LOSS_LIMIT",
+//	   "customerOrderId": "1234"
+//	}
+func (cl *Client) PostStopLimitOrderRequest(ctx context.Context, req *PostStopLimitOrderRequest) (*PostStopLimitOrderResponse, error) {
+	return placeWithinBudget(ctx, cl, req.Pair, req.CustomerOrderID, func(ctx context.Context) (*PostStopLimitOrderResponse, error) {
+		return Post[*PostStopLimitOrderResponse](ctx, cl, "/orders/stop/limit", req)
+	})
+}
+
+// PostBatchOrdersRequest
+//
+// Place and cancel up to 20 orders in a single request. Each request in the
+// batch succeeds or fails on its own; see the outcomes of the response.
+// Batches count as placements, so they are refused while the client is
+// draining.
+//
+// Example request body:
+//
+//	{
+//	   "requests": [
+//	      {"type": "PLACE_LIMIT", "data": {"side": "BUY", "quantity": "0.1", "price": "9000", "pair": "BTCZAR"}},
+//	      {"type": "CANCEL_ORDER", "data": {"orderId": "e5886f2d", "pair": "BTCZAR"}}
+//	   ]
+//	}
+func (cl *Client) PostBatchOrdersRequest(ctx context.Context, req *PostBatchOrdersRequest) (*PostBatchOrdersResponse, error) {
+	res, err := Post[*PostBatchOrdersResponse](ctx, cl, "/batch/orders", req)
 	if err != nil {
 		return nil, err
 	}
-	return &res, nil
+	for i, o := range res.Outcomes {
+		if i >= len(req.Requests) || !o.Accepted {
+			continue
+		}
+		if limit, ok := req.Requests[i].Data.(*PostLimitOrderRequest); ok {
+			cl.trackImmediate(limit, &PostLimitOrderResponse{ID: o.OrderID})
+		}
+	}
+	return res, nil
+}
+
+// PostSubaccountRequest
+//
+// Create a subaccount with the given label.
+//
+// Example request body:
+//
+//	{
+//	   "label": "Market Making"
+//	}
+func (cl *Client) PostSubaccountRequest(ctx context.Context, req *PostSubaccountRequest) (*PostSubaccountResponse, error) {
+	return Post[*PostSubaccountResponse](ctx, cl, "/account/subaccount", req)
+}
+
+// PostSubaccountTransferRequest
+//
+// Transfer funds between the primary account and its subaccounts. Transfers
+// above the threshold of the client's approval gate wait for approval.
+//
+// Example request body:
+//
+//	{
+//	   "fromId": "0",
+//	   "toId": "1234",
+//	   "currencyCode": "ZAR",
+//	   "amount": "100"
+//	}
+func (cl *Client) PostSubaccountTransferRequest(ctx context.Context, req *PostSubaccountTransferRequest) (*PostSubaccountTransferResponse, error) {
+	return Post[*PostSubaccountTransferResponse](ctx, cl, "/account/subaccounts/transfer", req)
 }
 
 /*
@@ -566,12 +549,7 @@ PRIVATE API DEL REQUESTS
 //	 "pair": "BTCZAR"
 //	}
 func (cl *Client) DelOrderRequest(ctx context.Context, req *DelOrderRequest) (*DelOrderResponse, error) {
-	var res DelOrderResponse
-	err := cl.do(ctx, http.MethodDelete, "/orders/order", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Delete[*DelOrderResponse](ctx, cl, "/orders/order", req)
 }
 
 // DelOrderByCustomerOrderIDRequest
@@ -587,10 +565,5 @@ func (cl *Client) DelOrderRequest(ctx context.Context, req *DelOrderRequest) (*D
 //	 "pair": "BTCZAR"
 //	}
 func (cl *Client) DelOrderByCustomerOrderIDRequest(ctx context.Context, req *DelOrderByCustomerOrderIDRequest) (*DelOrderByCustomerOrderIDResponse, error) {
-	var res DelOrderByCustomerOrderIDResponse
-	err := cl.do(ctx, http.MethodDelete, "/orders/order", req, &res, true)
-	if err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return Delete[*DelOrderByCustomerOrderIDResponse](ctx, cl, "/orders/order", req)
 }
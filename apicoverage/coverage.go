@@ -0,0 +1,191 @@
+// Package apicoverage compares the endpoints wrapped by valr.Client with a
+// manifest of the VALR REST API, reporting the endpoints implemented,
+// missing and deprecated. A manifest of the API as of this version of the
+// library is bundled; checking a pinned version against the manifest of a
+// newer one shows what upgrading would add, and flags deprecated endpoints
+// still in use.
+package apicoverage
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+//go:embed manifest.json
+var bundled []byte
+
+// Manifest lists the endpoints of the VALR REST API.
+type Manifest struct {
+	// Version identifies the state of the API described, such as the date
+	// it was taken.
+	Version   string     `json:"version"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Endpoint is an endpoint of the API.
+type Endpoint struct {
+	Method string `json:"method"`
+	// Path is relative to the base URL, with path parameters as {tags}.
+	Path       string `json:"path"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	// Note explains a deprecation, e.g. naming the replacement.
+	Note string `json:"note,omitempty"`
+}
+
+func (e Endpoint) String() string {
+	return e.Method + " " + e.Path
+}
+
+// Bundled returns the manifest bundled with the library.
+func Bundled() (*Manifest, error) {
+	return ParseManifest(bytes.NewReader(bundled))
+}
+
+// LoadManifest reads a manifest from a JSON file.
+func LoadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := ParseManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("apicoverage: decoding %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ParseManifest decodes a JSON manifest from r. Unknown fields are
+// rejected, and methods are normalised to upper case.
+func ParseManifest(r io.Reader) (*Manifest, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	m := new(Manifest)
+	if err := dec.Decode(m); err != nil {
+		return nil, err
+	}
+	for i, e := range m.Endpoints {
+		if e.Method == "" || !strings.HasPrefix(e.Path, "/") {
+			return nil, fmt.Errorf("apicoverage: endpoint %d: expected a method and an absolute path, got %q", i, e.String())
+		}
+		m.Endpoints[i].Method = strings.ToUpper(e.Method)
+	}
+	return m, nil
+}
+
+// Report is the coverage of a manifest by a set of implemented endpoints.
+type Report struct {
+	ManifestVersion string `json:"manifestVersion"`
+	// Implemented holds the manifest endpoints that are implemented,
+	// including deprecated ones.
+	Implemented []Endpoint `json:"implemented"`
+	// Missing holds the manifest endpoints that are not implemented,
+	// other than deprecated ones.
+	Missing []Endpoint `json:"missing"`
+	// Deprecated holds the implemented endpoints that the manifest marks
+	// deprecated.
+	Deprecated []Endpoint `json:"deprecated"`
+	// Unknown holds the implemented endpoints absent from the manifest,
+	// which may have been removed from the API.
+	Unknown []Endpoint `json:"unknown"`
+	// Coverage is the fraction of the manifest's current endpoints that
+	// are implemented.
+	Coverage float64 `json:"coverage"`
+}
+
+// Compare reports the coverage of m by implemented, usually
+// valr.Endpoints().
+func Compare(m *Manifest, implemented []valr.Endpoint) *Report {
+	have := make(map[valr.Endpoint]bool, len(implemented))
+	for _, e := range implemented {
+		have[valr.Endpoint{Method: strings.ToUpper(e.Method), Path: e.Path}] = true
+	}
+
+	r := &Report{
+		ManifestVersion: m.Version,
+		Implemented:     []Endpoint{},
+		Missing:         []Endpoint{},
+		Deprecated:      []Endpoint{},
+		Unknown:         []Endpoint{},
+	}
+	listed := make(map[valr.Endpoint]bool, len(m.Endpoints))
+	current := 0
+	for _, e := range m.Endpoints {
+		key := valr.Endpoint{Method: e.Method, Path: e.Path}
+		listed[key] = true
+		if !e.Deprecated {
+			current++
+		}
+		switch {
+		case have[key]:
+			r.Implemented = append(r.Implemented, e)
+			if e.Deprecated {
+				r.Deprecated = append(r.Deprecated, e)
+			}
+		case !e.Deprecated:
+			r.Missing = append(r.Missing, e)
+		}
+	}
+	for e := range have {
+		if !listed[e] {
+			r.Unknown = append(r.Unknown, Endpoint{Method: e.Method, Path: e.Path})
+		}
+	}
+	if current > 0 {
+		r.Coverage = float64(len(r.Implemented)-len(r.Deprecated)) / float64(current)
+	}
+	for _, es := range [][]Endpoint{r.Implemented, r.Missing, r.Deprecated, r.Unknown} {
+		sortEndpoints(es)
+	}
+	return r
+}
+
+func sortEndpoints(es []Endpoint) {
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].Path != es[j].Path {
+			return es[i].Path < es[j].Path
+		}
+		return es[i].Method < es[j].Method
+	})
+}
+
+// WriteText writes a summary of r for people, listing the missing,
+// deprecated and unknown endpoints.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Manifest %s: %d implemented, %d missing, %d deprecated, %d unknown (%.1f%% coverage)\n",
+		r.ManifestVersion, len(r.Implemented), len(r.Missing), len(r.Deprecated), len(r.Unknown), r.Coverage*100)
+	section := func(title string, es []Endpoint) {
+		if len(es) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, e := range es {
+			if e.Note != "" {
+				fmt.Fprintf(&b, "  %s (%s)\n", e, e.Note)
+			} else {
+				fmt.Fprintf(&b, "  %s\n", e)
+			}
+		}
+	}
+	section("Missing", r.Missing)
+	section("Deprecated", r.Deprecated)
+	section("Unknown", r.Unknown)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes r as indented JSON, for tools.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,91 @@
+package apicoverage_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/apicoverage"
+)
+
+func TestBundledManifestCoversEndpoints(t *testing.T) {
+	m, err := apicoverage.Bundled()
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	r := apicoverage.Compare(m, valr.Endpoints())
+	if len(r.Unknown) > 0 {
+		t.Errorf("Expected every implemented endpoint in the manifest, got %v", r.Unknown)
+	}
+	if len(r.Implemented) != len(valr.Endpoints()) {
+		t.Errorf("Expected %d, got %d", len(valr.Endpoints()), len(r.Implemented))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	m, err := apicoverage.ParseManifest(strings.NewReader(`{
+		"version": "test",
+		"endpoints": [
+			{"method": "get", "path": "/account/balances"},
+			{"method": "GET", "path": "/public/time", "deprecated": true, "note": "use /public/status"},
+			{"method": "GET", "path": "/public/status"},
+			{"method": "GET", "path": "/old", "deprecated": true}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	r := apicoverage.Compare(m, []valr.Endpoint{
+		{Method: "GET", Path: "/account/balances"},
+		{Method: "GET", Path: "/public/time"},
+		{Method: "POST", Path: "/removed"},
+	})
+
+	names := func(es []apicoverage.Endpoint) []string {
+		out := []string{}
+		for _, e := range es {
+			out = append(out, e.String())
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		got  []apicoverage.Endpoint
+		want []string
+	}{
+		{"implemented", r.Implemented, []string{"GET /account/balances", "GET /public/time"}},
+		{"missing", r.Missing, []string{"GET /public/status"}},
+		{"deprecated", r.Deprecated, []string{"GET /public/time"}},
+		{"unknown", r.Unknown, []string{"POST /removed"}},
+	}
+	for _, test := range tests {
+		if got := names(test.got); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Expected %v, got %v", test.name, test.want, got)
+		}
+	}
+	if r.Coverage != 0.5 {
+		t.Errorf("Expected 0.5, got %v", r.Coverage)
+	}
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !strings.Contains(b.String(), "GET /public/time (use /public/status)") {
+		t.Errorf("Expected deprecation note in %q", b.String())
+	}
+}
+
+func TestParseManifestInvalid(t *testing.T) {
+	invalid := []string{
+		`{"endpoints": [{"method": "GET", "path": "public/time"}]}`,
+		`{"endpoints": [{"path": "/public/time"}]}`,
+		`{"endpoints": [{"method": "GET", "path": "/public/time", "deprecatd": true}]}`,
+	}
+	for _, s := range invalid {
+		if _, err := apicoverage.ParseManifest(strings.NewReader(s)); err == nil {
+			t.Errorf("Expected error for %s", s)
+		}
+	}
+}
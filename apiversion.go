@@ -0,0 +1,62 @@
+package valr
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// versionSuffix matches the version at the end of a base URL.
+var versionSuffix = regexp.MustCompile(`/v[0-9]+$`)
+
+// SetAPIVersions routes endpoints to API versions other than the base
+// URL's, so endpoints VALR moves to a new version can be adopted one at a
+// time. Keys are path templates such as "/orders/limit", or prefixes ending
+// in "*" such as "/futures/*", with the longest match winning; values are
+// versions such as "v2". It replaces any routes set before. Calls can also
+// override the version with WithAPIVersion.
+func (cl *Client) SetAPIVersions(routes map[string]string) {
+	cl.apiVersions = make(map[string]string, len(routes))
+	for path, version := range routes {
+		cl.apiVersions["/"+strings.TrimLeft(path, "/")] = version
+	}
+}
+
+type apiVersionKey struct{}
+
+// WithAPIVersion returns a context whose calls use the given API version,
+// such as "v2", whatever the client's routes.
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// apiVersion returns the version path is called with, or "" for the base
+// URL's.
+func (cl *Client) apiVersion(ctx context.Context, path string) string {
+	if v, _ := ctx.Value(apiVersionKey{}).(string); v != "" {
+		return v
+	}
+	path = "/" + strings.TrimLeft(path, "/")
+	if v, ok := cl.apiVersions[path]; ok {
+		return v
+	}
+	var version string
+	longest := -1
+	for route, v := range cl.apiVersions {
+		prefix, ok := strings.CutSuffix(route, "*")
+		if ok && strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			version, longest = v, len(prefix)
+		}
+	}
+	return version
+}
+
+// endpointBaseURL returns the base URL path is called at, with the
+// version of the base URL replaced by the version routed to.
+func (cl *Client) endpointBaseURL(ctx context.Context, path string) string {
+	version := cl.apiVersion(ctx, path)
+	if version == "" {
+		return cl.baseURL
+	}
+	return versionSuffix.ReplaceAllString(cl.baseURL, "") + "/" + strings.Trim(version, "/")
+}
@@ -0,0 +1,49 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestAPIVersions(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Path
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL + "/v1")
+	cl.SetAuth("key", "secret")
+	cl.SetAPIVersions(map[string]string{
+		"/futures/*":        "v2",
+		"/futures/legacy/*": "v1",
+		"positions/open":    "v3",
+	})
+
+	ctx := context.Background()
+	tests := []struct {
+		ctx  context.Context
+		path string
+		exp  string
+	}{
+		{ctx, "/account/balances", "/v1/account/balances"},
+		{ctx, "/futures/positions", "/v2/futures/positions"},
+		{ctx, "/futures/legacy/positions", "/v1/futures/legacy/positions"},
+		{ctx, "/positions/open", "/v3/positions/open"},
+		{valr.WithAPIVersion(ctx, "v2"), "/account/balances", "/v2/account/balances"},
+	}
+	for _, test := range tests {
+		if err := cl.Call(test.ctx, http.MethodGet, test.path, nil, nil, true); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if got != test.exp {
+			t.Errorf("Expected %q, got %q", test.exp, got)
+		}
+	}
+}
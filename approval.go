@@ -0,0 +1,271 @@
+package valr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const defaultApprovalExpiry = 15 * time.Minute
+
+var (
+	// ErrApprovalRejected is returned for calls whose intent was rejected.
+	ErrApprovalRejected = errors.New("valr: approval rejected")
+	// ErrApprovalExpired is returned for calls whose intent was not approved
+	// before it expired.
+	ErrApprovalExpired = errors.New("valr: approval expired")
+	// ErrUnknownIntent is returned when approving or rejecting an intent that
+	// is not pending.
+	ErrUnknownIntent = errors.New("valr: unknown or decided intent")
+)
+
+// Intent kinds.
+const (
+	IntentWithdrawal = "withdrawal"
+	IntentTransfer   = "transfer"
+)
+
+// Intent is a call parked until it is approved.
+type Intent struct {
+	ID          string
+	Kind        string
+	Currency    string
+	Destination string
+	Amount      decimal.Decimal
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Approval decisions.
+const (
+	DecisionApproved  = "approved"
+	DecisionRejected  = "rejected"
+	DecisionExpired   = "expired"
+	DecisionCancelled = "cancelled"
+)
+
+// ApprovalRecord is the audit record of a decided intent.
+type ApprovalRecord struct {
+	Intent    Intent
+	Decision  string
+	Approver  string
+	Reason    string
+	DecidedAt time.Time
+}
+
+// ApprovalRequester notifies a second person that an intent awaits their
+// approval, e.g. by posting to a chat channel or calling a webhook. The
+// approval is given by calling ApprovalGate.Approve or Reject with the
+// intent's ID.
+type ApprovalRequester func(ctx context.Context, intent Intent) error
+
+// ApprovalGate parks withdrawals, and transfers above a threshold, as
+// pending intents until they are approved by a second person. The call that
+// created an intent blocks until it is decided, it expires or its context
+// is done. Calls are gated however they are made, including with Call, Post
+// and Delete.
+type ApprovalGate struct {
+	request    ApprovalRequester
+	expiry     time.Duration
+	thresholds map[string]decimal.Decimal
+	audit      func(ApprovalRecord)
+
+	mu      sync.Mutex
+	pending map[string]*pendingIntent
+}
+
+type pendingIntent struct {
+	intent   Intent
+	decision chan ApprovalRecord
+}
+
+type ApprovalOption func(*ApprovalGate)
+
+// WithApprovalExpiry sets how long intents wait for approval, 15 minutes by
+// default.
+func WithApprovalExpiry(d time.Duration) ApprovalOption {
+	return func(g *ApprovalGate) {
+		g.expiry = d
+	}
+}
+
+// WithTransferThreshold requires approval for transfers of currency of at
+// least amount. Transfers of currencies without a threshold are not parked.
+func WithTransferThreshold(currency string, amount decimal.Decimal) ApprovalOption {
+	return func(g *ApprovalGate) {
+		g.thresholds[strings.ToUpper(currency)] = amount
+	}
+}
+
+// WithApprovalAudit sets a function receiving the record of every decided
+// intent.
+func WithApprovalAudit(fn func(ApprovalRecord)) ApprovalOption {
+	return func(g *ApprovalGate) {
+		g.audit = fn
+	}
+}
+
+// NewApprovalGate returns a gate that notifies approvers using request.
+func NewApprovalGate(request ApprovalRequester, opts ...ApprovalOption) *ApprovalGate {
+	g := &ApprovalGate{
+		request:    request,
+		expiry:     defaultApprovalExpiry,
+		thresholds: make(map[string]decimal.Decimal),
+		pending:    make(map[string]*pendingIntent),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// SetApprovalGate requires approval through g for the client's withdrawals
+// and large transfers. Pass nil to remove the gate.
+func (cl *Client) SetApprovalGate(g *ApprovalGate) {
+	cl.approvals = g
+}
+
+// Pending returns the intents awaiting approval.
+func (g *ApprovalGate) Pending() []Intent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	intents := make([]Intent, 0, len(g.pending))
+	for _, p := range g.pending {
+		intents = append(intents, p.intent)
+	}
+	return intents
+}
+
+// Approve approves the pending intent with the given ID, releasing its call.
+func (g *ApprovalGate) Approve(id, approver string) error {
+	return g.decide(id, DecisionApproved, approver, "")
+}
+
+// Reject rejects the pending intent with the given ID. Its call fails with
+// ErrApprovalRejected.
+func (g *ApprovalGate) Reject(id, approver, reason string) error {
+	return g.decide(id, DecisionRejected, approver, reason)
+}
+
+func (g *ApprovalGate) decide(id, decision, approver, reason string) error {
+	g.mu.Lock()
+	p, ok := g.pending[id]
+	if ok {
+		delete(g.pending, id)
+	}
+	g.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownIntent, id)
+	}
+	p.decision <- ApprovalRecord{
+		Intent:    p.intent,
+		Decision:  decision,
+		Approver:  approver,
+		Reason:    reason,
+		DecidedAt: time.Now(),
+	}
+	return nil
+}
+
+// requires returns true if a call of the given kind needs approval.
+func (g *ApprovalGate) requires(kind, currency string, amount decimal.Decimal) bool {
+	if kind != IntentTransfer {
+		return true
+	}
+	threshold, ok := g.thresholds[strings.ToUpper(currency)]
+	return ok && amount.GreaterThanOrEqual(threshold)
+}
+
+// await parks an intent and blocks until it is decided.
+func (g *ApprovalGate) await(ctx context.Context, kind, currency, destination string, amount decimal.Decimal) error {
+	if !g.requires(kind, currency, amount) {
+		return nil
+	}
+
+	now := time.Now()
+	p := &pendingIntent{
+		intent: Intent{
+			ID:          newIntentID(),
+			Kind:        kind,
+			Currency:    strings.ToUpper(currency),
+			Destination: destination,
+			Amount:      amount,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(g.expiry),
+		},
+		// Buffered so deciding never blocks on a call that gave up.
+		decision: make(chan ApprovalRecord, 1),
+	}
+	g.mu.Lock()
+	g.pending[p.intent.ID] = p
+	g.mu.Unlock()
+
+	if err := g.request(ctx, p.intent); err != nil {
+		g.abandon(p, DecisionCancelled, err.Error())
+		return fmt.Errorf("valr: requesting approval: %w", err)
+	}
+
+	timer := time.NewTimer(g.expiry)
+	defer timer.Stop()
+
+	select {
+	case rec := <-p.decision:
+		g.record(rec)
+		if rec.Decision != DecisionApproved {
+			return fmt.Errorf("%w by %s: %s", ErrApprovalRejected, rec.Approver, rec.Reason)
+		}
+		return nil
+	case <-timer.C:
+		if g.abandon(p, DecisionExpired, "") {
+			return ErrApprovalExpired
+		}
+	case <-ctx.Done():
+		if g.abandon(p, DecisionCancelled, ctx.Err().Error()) {
+			return ctx.Err()
+		}
+	}
+	// The intent was decided while giving up; honour the decision.
+	rec := <-p.decision
+	g.record(rec)
+	if rec.Decision != DecisionApproved {
+		return fmt.Errorf("%w by %s: %s", ErrApprovalRejected, rec.Approver, rec.Reason)
+	}
+	return nil
+}
+
+// abandon removes an undecided intent, returning false if it had already
+// been decided.
+func (g *ApprovalGate) abandon(p *pendingIntent, decision, reason string) bool {
+	g.mu.Lock()
+	_, ok := g.pending[p.intent.ID]
+	delete(g.pending, p.intent.ID)
+	g.mu.Unlock()
+	if ok {
+		g.record(ApprovalRecord{
+			Intent:    p.intent,
+			Decision:  decision,
+			Reason:    reason,
+			DecidedAt: time.Now(),
+		})
+	}
+	return ok
+}
+
+func (g *ApprovalGate) record(rec ApprovalRecord) {
+	if g.audit != nil {
+		g.audit(rec)
+	}
+}
+
+func newIntentID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
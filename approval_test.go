@@ -0,0 +1,291 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// approvalClient returns a client of a server counting the requests sent
+// to it, gated by a gate built with requester.
+func approvalClient(t *testing.T, requester func(*valr.ApprovalGate, context.Context, valr.Intent) error, opts ...valr.ApprovalOption) (*valr.Client, *valr.ApprovalGate, *atomic.Int64, func() []valr.ApprovalRecord) {
+	t.Helper()
+	sent := new(atomic.Int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+		_, _ = w.Write([]byte(`{"id":"w1"}`))
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		records []valr.ApprovalRecord
+	)
+	var gate *valr.ApprovalGate
+	gate = valr.NewApprovalGate(func(ctx context.Context, intent valr.Intent) error {
+		return requester(gate, ctx, intent)
+	}, append(opts, valr.WithApprovalAudit(func(rec valr.ApprovalRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, rec)
+	}))...)
+	cl.SetApprovalGate(gate)
+	return cl, gate, sent, func() []valr.ApprovalRecord {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]valr.ApprovalRecord(nil), records...)
+	}
+}
+
+func withdraw(ctx context.Context, cl *valr.Client) error {
+	_, err := cl.PostNewCryptoWithdrawRequest(ctx, &valr.PostNewCryptoWithdrawRequest{
+		Asset:   "BTC",
+		Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		Amount:  decimal.RequireFromString("0.1"),
+	})
+	return err
+}
+
+func TestApprovalGateApprove(t *testing.T) {
+	intents := make(chan valr.Intent, 1)
+	cl, gate, sent, records := approvalClient(t, func(_ *valr.ApprovalGate, _ context.Context, intent valr.Intent) error {
+		intents <- intent
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- withdraw(context.Background(), cl) }()
+	intent := <-intents
+	if intent.Kind != valr.IntentWithdrawal || intent.Currency != "BTC" || !intent.Amount.Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("Unexpected intent %+v", intent)
+	}
+	if p := gate.Pending(); len(p) != 1 || p[0].ID != intent.ID {
+		t.Errorf("Expected the intent pending, got %+v", p)
+	}
+
+	// The withdrawal blocks until it is decided.
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the withdrawal to wait for approval, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := sent.Load(); n != 0 {
+		t.Fatalf("Expected nothing sent before approval, got %d requests", n)
+	}
+
+	if err := gate.Approve(intent.ID, "ops"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n := sent.Load(); n != 1 {
+		t.Errorf("Expected the withdrawal sent, got %d requests", n)
+	}
+	if err := gate.Approve(intent.ID, "ops"); !errors.Is(err, valr.ErrUnknownIntent) {
+		t.Errorf("Expected ErrUnknownIntent, got %v", err)
+	}
+	recs := records()
+	if len(recs) != 1 || recs[0].Decision != valr.DecisionApproved || recs[0].Approver != "ops" || recs[0].Intent.ID != intent.ID {
+		t.Errorf("Expected an approval record, got %+v", recs)
+	}
+}
+
+func TestApprovalGateExpiry(t *testing.T) {
+	cl, gate, sent, records := approvalClient(t, func(*valr.ApprovalGate, context.Context, valr.Intent) error {
+		return nil
+	}, valr.WithApprovalExpiry(10*time.Millisecond))
+
+	if err := withdraw(context.Background(), cl); !errors.Is(err, valr.ErrApprovalExpired) {
+		t.Fatalf("Expected ErrApprovalExpired, got %v", err)
+	}
+	if n := sent.Load(); n != 0 {
+		t.Errorf("Expected nothing sent, got %d requests", n)
+	}
+	if p := gate.Pending(); len(p) != 0 {
+		t.Errorf("Expected no pending intents, got %+v", p)
+	}
+	if recs := records(); len(recs) != 1 || recs[0].Decision != valr.DecisionExpired {
+		t.Errorf("Expected an expiry record, got %+v", recs)
+	}
+}
+
+func TestApprovalGateCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cl, gate, sent, records := approvalClient(t, func(*valr.ApprovalGate, context.Context, valr.Intent) error {
+		cancel()
+		return nil
+	})
+
+	if err := withdraw(ctx, cl); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+	if n := sent.Load(); n != 0 {
+		t.Errorf("Expected nothing sent, got %d requests", n)
+	}
+	if p := gate.Pending(); len(p) != 0 {
+		t.Errorf("Expected no pending intents, got %+v", p)
+	}
+	if recs := records(); len(recs) != 1 || recs[0].Decision != valr.DecisionCancelled {
+		t.Errorf("Expected a cancellation record, got %+v", recs)
+	}
+}
+
+func TestApprovalGateRequestFailure(t *testing.T) {
+	cl, _, sent, records := approvalClient(t, func(*valr.ApprovalGate, context.Context, valr.Intent) error {
+		return errors.New("webhook down")
+	})
+
+	if err := withdraw(context.Background(), cl); err == nil || errors.Is(err, valr.ErrApprovalRejected) {
+		t.Fatalf("Expected the requester's error, got %v", err)
+	}
+	if n := sent.Load(); n != 0 {
+		t.Errorf("Expected nothing sent, got %d requests", n)
+	}
+	if recs := records(); len(recs) != 1 || recs[0].Decision != valr.DecisionCancelled || recs[0].Reason != "webhook down" {
+		t.Errorf("Expected a cancellation record, got %+v", recs)
+	}
+}
+
+// A decision made as the call gives up is honoured, whichever is seen
+// first.
+func TestApprovalGateDecisionAtCancel(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cl, _, sent, records := approvalClient(t, func(gate *valr.ApprovalGate, _ context.Context, intent valr.Intent) error {
+			if err := gate.Approve(intent.ID, "ops"); err != nil {
+				t.Errorf("Expected success, got %v", err)
+			}
+			cancel()
+			return nil
+		})
+		err := withdraw(ctx, cl)
+		if recs := records(); len(recs) != 1 || recs[0].Decision != valr.DecisionApproved {
+			t.Fatalf("Expected one approval record, got %+v", recs)
+		}
+		// The approved withdrawal is only sent if the request's context
+		// allows it.
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected approval, got %v", err)
+		}
+		if err == nil && sent.Load() != 1 {
+			t.Errorf("Expected the withdrawal sent, got %d requests", sent.Load())
+		}
+	}
+}
+
+func TestApprovalGateReject(t *testing.T) {
+	cl, _, sent, records := approvalClient(t, func(gate *valr.ApprovalGate, _ context.Context, intent valr.Intent) error {
+		return gate.Reject(intent.ID, "ops", "unknown address")
+	})
+
+	if err := withdraw(context.Background(), cl); !errors.Is(err, valr.ErrApprovalRejected) {
+		t.Fatalf("Expected ErrApprovalRejected, got %v", err)
+	}
+	if n := sent.Load(); n != 0 {
+		t.Errorf("Expected nothing sent, got %d requests", n)
+	}
+	if recs := records(); len(recs) != 1 || recs[0].Decision != valr.DecisionRejected || recs[0].Reason != "unknown address" {
+		t.Errorf("Expected a rejection record, got %+v", recs)
+	}
+}
+
+func TestApprovalGateGenericCalls(t *testing.T) {
+	var intents []valr.Intent
+	cl, _, sent, _ := approvalClient(t, func(gate *valr.ApprovalGate, _ context.Context, intent valr.Intent) error {
+		intents = append(intents, intent)
+		return gate.Reject(intent.ID, "ops", "not expected")
+	}, valr.WithTransferThreshold("ZAR", decimal.RequireFromString("1000")))
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		call     func() error
+		kind     string
+		currency string
+	}{
+		{
+			name: "crypto withdrawal",
+			call: func() error {
+				return cl.Call(ctx, http.MethodPost, "/wallet/crypto/BTC/withdraw", map[string]string{
+					"address": "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+					"amount":  "0.1",
+				}, nil, true)
+			},
+			kind: valr.IntentWithdrawal, currency: "BTC",
+		},
+		{
+			name: "fiat withdrawal",
+			call: func() error {
+				_, err := valr.Post[map[string]any](ctx, cl, "/wallet/fiat/ZAR/withdraw", map[string]string{
+					"linkedBankAccountId": "bank-1",
+					"amount":              "100",
+				})
+				return err
+			},
+			kind: valr.IntentWithdrawal, currency: "ZAR",
+		},
+		{
+			name: "pay",
+			call: func() error {
+				_, err := valr.Post[map[string]any](ctx, cl, "/pay", map[string]string{
+					"currency":       "ZAR",
+					"amount":         "50",
+					"recipientPayId": "someone",
+				})
+				return err
+			},
+			kind: valr.IntentWithdrawal, currency: "ZAR",
+		},
+		{
+			name: "subaccount transfer",
+			call: func() error {
+				return cl.Call(ctx, http.MethodPost, "/account/subaccounts/transfer", map[string]string{
+					"fromId":       "0",
+					"toId":         "1234",
+					"currencyCode": "ZAR",
+					"amount":       "5000",
+				}, nil, true)
+			},
+			kind: valr.IntentTransfer, currency: "ZAR",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			intents = nil
+			if err := tc.call(); !errors.Is(err, valr.ErrApprovalRejected) {
+				t.Fatalf("Expected ErrApprovalRejected, got %v", err)
+			}
+			if len(intents) != 1 || intents[0].Kind != tc.kind || intents[0].Currency != tc.currency {
+				t.Errorf("Expected a %s intent for %s, got %+v", tc.kind, tc.currency, intents)
+			}
+			if n := sent.Load(); n != 0 {
+				t.Errorf("Expected nothing sent, got %d requests", n)
+			}
+		})
+	}
+
+	// Transfers below the threshold are not parked.
+	err := cl.Call(ctx, http.MethodPost, "/account/subaccounts/transfer", map[string]string{
+		"fromId":       "0",
+		"toId":         "1234",
+		"currencyCode": "ZAR",
+		"amount":       "10",
+	}, nil, true)
+	if err != nil || sent.Load() != 1 {
+		t.Errorf("Expected the small transfer sent, got %v after %d requests", err, sent.Load())
+	}
+}
@@ -0,0 +1,91 @@
+package valr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AuditRecord is a canonical record of a mutating call, such as placing or
+// cancelling an order, a withdrawal or a transfer.
+type AuditRecord struct {
+	Time time.Time
+	// KeyID is the public part of the API key the call was signed with.
+	KeyID string
+	// SubaccountID is empty for calls on the primary account.
+	SubaccountID string
+	Method       string
+	// Path is the request path, including the API version and query.
+	Path string
+	// ParamsHash is the hex encoded SHA-256 of the path and request body,
+	// identifying the parameters without storing them.
+	ParamsHash string
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	// ResponseID is the ID returned by the server, e.g. of the order or
+	// withdrawal, if the response contained one.
+	ResponseID string
+	Duration   time.Duration
+	Err        error
+}
+
+// AuditHook is invoked for every mutating call the client sends, whether or
+// not it succeeds. It is called synchronously, so should not block.
+type AuditHook func(AuditRecord)
+
+// SetAuditHook sets a hook invoked for every POST, PUT, PATCH and DELETE
+// call, independently of debug logging. Pass nil to remove it.
+func (cl *Client) SetAuditHook(fn AuditHook) {
+	cl.auditHook = fn
+}
+
+// audit reports a call sent at start to the audit hook, if the call is
+// audited.
+func (cl *Client) audit(ctx context.Context, start time.Time, method, url string,
+	reqBody []byte, statusCode int, resBody []byte, err error) {
+
+	if cl.auditHook == nil || (!isMutating(method) && method != http.MethodDelete) {
+		return
+	}
+	path, _ := signingPath(url)
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{'\n'})
+	h.Write(reqBody)
+
+	cl.auditHook(AuditRecord{
+		Time:         start,
+		KeyID:        cl.apiKeyPub,
+		SubaccountID: subaccountFromContext(ctx),
+		Method:       method,
+		Path:         path,
+		ParamsHash:   hex.EncodeToString(h.Sum(nil)),
+		StatusCode:   statusCode,
+		ResponseID:   responseID(resBody),
+		Duration:     time.Since(start),
+		Err:          err,
+	})
+}
+
+// responseID extracts the ID from a response body, if it has one.
+func responseID(body []byte) string {
+	var res struct {
+		ID      json.RawMessage `json:"id"`
+		OrderID string          `json:"orderId"`
+	}
+	if json.Unmarshal(body, &res) != nil {
+		return ""
+	}
+	// IDs may be strings or numbers; keep numbers verbatim.
+	var id string
+	if err := json.Unmarshal(res.ID, &id); err != nil {
+		id = string(res.ID)
+	}
+	if id != "" && id != "null" {
+		return id
+	}
+	return res.OrderID
+}
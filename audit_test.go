@@ -0,0 +1,100 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestAuditHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":"o1"}`))
+		case "DELETE /orders/order":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1,"message":"Order not found"}`))
+		case "GET /account/balances":
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var records []valr.AuditRecord
+	cl.SetAuditHook(func(r valr.AuditRecord) { records = append(records, r) })
+
+	ctx := valr.WithSubaccount(context.Background(), "sub-1")
+	order := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+	}
+	if _, err := cl.PostLimitOrderRequest(ctx, order); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := cl.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: "BTCZAR", ID: "o0"}); err == nil {
+		t.Fatal("Expected the cancellation to fail")
+	}
+	// Reads are not audited.
+	if _, err := cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %+v", records)
+	}
+	place, cancel := records[0], records[1]
+	if place.Method != http.MethodPost || place.Path != "/orders/limit" || place.StatusCode != http.StatusAccepted ||
+		place.ResponseID != "o1" || place.Err != nil {
+		t.Errorf("Unexpected placement record %+v", place)
+	}
+	if cancel.Method != http.MethodDelete || cancel.StatusCode != http.StatusBadRequest || cancel.ResponseID != "" {
+		t.Errorf("Unexpected cancellation record %+v", cancel)
+	}
+	for _, r := range records {
+		if r.KeyID != "key" || r.SubaccountID != "sub-1" || len(r.ParamsHash) != 64 || r.Time.IsZero() {
+			t.Errorf("Unexpected record %+v", r)
+		}
+	}
+	if place.ParamsHash == cancel.ParamsHash {
+		t.Error("Expected the parameter hashes to differ")
+	}
+}
+
+func TestAuditHookTransportError(t *testing.T) {
+	// The server is closed, so no response is received.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var records []valr.AuditRecord
+	cl.SetAuditHook(func(r valr.AuditRecord) { records = append(records, r) })
+
+	order := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+	}
+	if _, err := cl.PostLimitOrderRequest(context.Background(), order); err == nil {
+		t.Fatal("Expected an error")
+	}
+	// The failed attempt is recorded with its error and no status.
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %+v", records)
+	}
+	if r := records[0]; r.StatusCode != 0 || r.ResponseID != "" || r.Err == nil {
+		t.Errorf("Expected the record marked failed, got %+v", r)
+	}
+}
@@ -0,0 +1,59 @@
+package valr
+
+// maxBatchOrders is the most requests VALR accepts in a single batch.
+const maxBatchOrders = 20
+
+// OrderTypeCancel is the type of batch requests cancelling an order.
+const OrderTypeCancel = "CANCEL_ORDER"
+
+// BatchOrder is a single placement or cancellation in a batch of orders.
+// Build them with BatchLimitOrder, BatchMarketOrder, BatchStopLimitOrder and
+// BatchCancelOrder.
+type BatchOrder struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// BatchOrderError is the reason a request in a batch was rejected.
+type BatchOrderError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// BatchOrderOutcome is the result of a single request in a batch.
+type BatchOrderOutcome struct {
+	Accepted        bool             `json:"accepted"`
+	OrderID         string           `json:"orderId"`
+	CustomerOrderID string           `json:"customerOrderId"`
+	Error           *BatchOrderError `json:"error,omitempty"`
+}
+
+// marketOrderRequest is implemented by the market order requests.
+type marketOrderRequest interface {
+	validator
+	marketOrder()
+}
+
+func (*PostMarketOrderBuyRequest) marketOrder()        {}
+func (*PostMarketOrderSellRequest) marketOrder()       {}
+func (*PostMarketOrderBaseAmountRequest) marketOrder() {}
+
+// BatchLimitOrder places a limit order as part of a batch.
+func BatchLimitOrder(req *PostLimitOrderRequest) BatchOrder {
+	return BatchOrder{Type: OrderTypeLimit, Data: req}
+}
+
+// BatchMarketOrder places a market order as part of a batch.
+func BatchMarketOrder(req marketOrderRequest) BatchOrder {
+	return BatchOrder{Type: OrderTypeMarket, Data: req}
+}
+
+// BatchStopLimitOrder places a stop-limit order as part of a batch.
+func BatchStopLimitOrder(req *PostStopLimitOrderRequest) BatchOrder {
+	return BatchOrder{Type: OrderTypeStopLimit, Data: req}
+}
+
+// BatchCancelOrder cancels an order as part of a batch.
+func BatchCancelOrder(req *DelOrderRequest) BatchOrder {
+	return BatchOrder{Type: OrderTypeCancel, Data: req}
+}
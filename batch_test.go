@@ -0,0 +1,196 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// recordingServer replies to every call with reply, recording the last
+// request's path, query and body.
+func recordingServer(t *testing.T, reply string) (*valr.Client, *http.Request, *string) {
+	t.Helper()
+	last := new(http.Request)
+	body := new(string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*last, *body = *r, string(b)
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl, last, body
+}
+
+func TestPostBatchOrders(t *testing.T) {
+	cl, last, body := recordingServer(t, `{"outcomes":[{"accepted":true,"orderId":"o1"},{"accepted":false,"error":{"code":-1,"message":"Order not found"}}]}`)
+	ctx := context.Background()
+
+	req := &valr.PostBatchOrdersRequest{Requests: []valr.BatchOrder{
+		valr.BatchLimitOrder(&valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.RequireFromString("0.1"), Price: decimal.New(9000, 0),
+		}),
+		valr.BatchCancelOrder(&valr.DelOrderRequest{Pair: "BTCZAR", ID: "o0"}),
+	}}
+	res, err := cl.PostBatchOrdersRequest(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if last.URL.Path != "/batch/orders" {
+		t.Errorf("Expected %q, got %q", "/batch/orders", last.URL.Path)
+	}
+	var sent struct {
+		Requests []struct {
+			Type string         `json:"type"`
+			Data map[string]any `json:"data"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal([]byte(*body), &sent); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(sent.Requests) != 2 || sent.Requests[0].Type != "PLACE_LIMIT" || sent.Requests[1].Type != "CANCEL_ORDER" || sent.Requests[1].Data["orderId"] != "o0" {
+		t.Errorf("Unexpected body %s", *body)
+	}
+	if len(res.Outcomes) != 2 || !res.Outcomes[0].Accepted || res.Outcomes[1].Error == nil || res.Outcomes[1].Error.Message != "Order not found" {
+		t.Errorf("Unexpected outcomes %+v", res.Outcomes)
+	}
+
+	// Requests in the batch are validated before sending.
+	req.Requests[0].Data.(*valr.PostLimitOrderRequest).Price = decimal.Zero
+	_, err = cl.PostBatchOrdersRequest(ctx, req)
+	var verr *valr.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "requests[0].price" {
+		t.Errorf("Expected price validation error, got %v", err)
+	}
+	if _, err := cl.PostBatchOrdersRequest(ctx, &valr.PostBatchOrdersRequest{}); !errors.As(err, &verr) {
+		t.Errorf("Expected validation error for an empty batch, got %v", err)
+	}
+}
+
+func TestPostStopLimitOrder(t *testing.T) {
+	cl, last, body := recordingServer(t, `{"id":"s1"}`)
+	req := &valr.PostStopLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.SELL, Quantity: decimal.RequireFromString("0.1"),
+		Price: decimal.New(9000, 0), StopPrice: decimal.New(9100, 0), Type: valr.StopLossLimit,
+	}
+	res, err := cl.PostStopLimitOrderRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := `{"pair":"BTCZAR","quantity":"0.1","price":"9000","stopPrice":"9100","side":"SELL","type":"STOP_LOSS_LIMIT"}`
+	if last.URL.Path != "/orders/stop/limit" || *body != want || res.ID != "s1" {
+		t.Errorf("Expected %s, got %s %s", want, last.URL.Path, *body)
+	}
+
+	req.Type = ""
+	var verr *valr.ValidationError
+	if _, err := cl.PostStopLimitOrderRequest(context.Background(), req); !errors.As(err, &verr) || verr.Field != "type" {
+		t.Errorf("Expected type validation error, got %v", err)
+	}
+}
+
+func TestSubaccounts(t *testing.T) {
+	cl, last, body := recordingServer(t, `[{"label":"Market Making","id":"1234"}]`)
+	ctx := context.Background()
+
+	subs, err := cl.GetSubaccountsRequest(ctx, &valr.GetSubaccountsRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(subs) != 1 || subs[0].ID != "1234" || last.URL.Path != "/account/subaccounts" {
+		t.Errorf("Unexpected subaccounts %+v from %s", subs, last.URL.Path)
+	}
+
+	cl, last, body = recordingServer(t, `{"id":"5678"}`)
+	res, err := cl.PostSubaccountRequest(ctx, &valr.PostSubaccountRequest{Label: "Arbitrage"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.ID != "5678" || last.URL.Path != "/account/subaccount" || *body != `{"label":"Arbitrage"}` {
+		t.Errorf("Unexpected request %s %s", last.URL.Path, *body)
+	}
+}
+
+func TestSubaccountTransferApproval(t *testing.T) {
+	cl, last, body := recordingServer(t, `{"id":"t1"}`)
+	gate := valr.NewApprovalGate(func(ctx context.Context, intent valr.Intent) error {
+		if intent.Kind != valr.IntentTransfer || intent.Destination != "1234" {
+			t.Errorf("Unexpected intent %+v", intent)
+		}
+		return nil
+	}, valr.WithTransferThreshold("ZAR", decimal.New(1000, 0)))
+	cl.SetApprovalGate(gate)
+
+	transfer := func(amount int64) error {
+		_, err := cl.PostSubaccountTransferRequest(context.Background(), &valr.PostSubaccountTransferRequest{
+			FromID: "0", ToID: "1234", Currency: "ZAR", Amount: decimal.New(amount, 0),
+		})
+		return err
+	}
+
+	// Small transfers are not parked.
+	if err := transfer(10); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := `{"fromId":"0","toId":"1234","currencyCode":"ZAR","amount":"10"}`
+	if last.URL.Path != "/account/subaccounts/transfer" || *body != want {
+		t.Errorf("Expected %s, got %s %s", want, last.URL.Path, *body)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- transfer(5000) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(gate.Pending()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a pending transfer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := gate.Reject(gate.Pending()[0].ID, "ops", "too large"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := <-done; !errors.Is(err, valr.ErrApprovalRejected) {
+		t.Errorf("Expected ErrApprovalRejected, got %v", err)
+	}
+}
+
+func TestTransactionHistoryFilters(t *testing.T) {
+	cl, last, _ := recordingServer(t, `[]`)
+	_, err := cl.GetTransactionHistoryRequest(context.Background(), &valr.GetTransactionHistoryRequest{
+		Limit:            100,
+		TransactionTypes: valr.TransactionTypes{"LIMIT_BUY", "LIMIT_SELL"},
+		Currency:         "BTC",
+		StartTime:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	q := last.URL.Query()
+	for key, want := range map[string]string{
+		"transactionTypes": "LIMIT_BUY,LIMIT_SELL",
+		"currency":         "BTC",
+		"startTime":        "2024-01-01T00:00:00Z",
+		"limit":            "100",
+	} {
+		if got := q.Get(key); got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+	if q.Has("endTime") || q.Has("beforeId") {
+		t.Errorf("Expected unset filters to be omitted, got %s", last.URL.RawQuery)
+	}
+}
@@ -0,0 +1,89 @@
+package valr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Withdrawal destination types for travel rule reporting.
+const (
+	// DestinationVASP is a wallet hosted by an exchange or other virtual
+	// asset service provider.
+	DestinationVASP = "VASP"
+	// DestinationSelfHosted is a wallet controlled directly by its owner.
+	DestinationSelfHosted = "SELF_HOSTED"
+)
+
+// Beneficiary types for travel rule reporting.
+const (
+	BeneficiaryIndividual = "INDIVIDUAL"
+	BeneficiaryEntity     = "ENTITY"
+)
+
+// countryCodePattern matches ISO 3166-1 alpha-2 country codes.
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// WithdrawalBeneficiary describes the recipient of a crypto withdrawal, as
+// required by the travel rule.
+type WithdrawalBeneficiary struct {
+	DestinationType string `json:"destinationType"`
+	// VASPName is the name of the receiving service provider, required for
+	// DestinationVASP.
+	VASPName string `json:"vaspName,omitempty"`
+	// IsSelf is set when the beneficiary is the account holder. The
+	// beneficiary's details are then not required.
+	IsSelf          bool   `json:"isSelf"`
+	BeneficiaryType string `json:"beneficiaryType,omitempty"`
+	// FirstName and LastName are required for individuals, EntityName for
+	// entities.
+	FirstName  string `json:"firstName,omitempty"`
+	LastName   string `json:"lastName,omitempty"`
+	EntityName string `json:"entityName,omitempty"`
+	// CountryOfResidence is an ISO 3166-1 alpha-2 code.
+	CountryOfResidence string `json:"countryOfResidence,omitempty"`
+}
+
+// Validate checks that the fields required for the destination and
+// beneficiary types are present.
+func (b *WithdrawalBeneficiary) Validate() error {
+	field := func(name string) string { return "beneficiary." + name }
+
+	switch b.DestinationType {
+	case DestinationVASP:
+		if b.VASPName == "" {
+			return &ValidationError{Field: field("vaspName"), Reason: "required for VASP destinations"}
+		}
+	case DestinationSelfHosted:
+		if b.VASPName != "" {
+			return &ValidationError{Field: field("vaspName"), Reason: "not allowed for self-hosted destinations"}
+		}
+	default:
+		return &ValidationError{Field: field("destinationType"), Reason: fmt.Sprintf("must be %s or %s, got %q", DestinationVASP, DestinationSelfHosted, b.DestinationType)}
+	}
+
+	if b.CountryOfResidence != "" && !countryCodePattern.MatchString(b.CountryOfResidence) {
+		return &ValidationError{Field: field("countryOfResidence"), Reason: "must be an ISO 3166-1 alpha-2 code"}
+	}
+	if b.IsSelf {
+		return nil
+	}
+	switch b.BeneficiaryType {
+	case BeneficiaryIndividual:
+		if b.FirstName == "" || b.LastName == "" {
+			return &ValidationError{Field: field("firstName"), Reason: "first and last name required for individuals"}
+		}
+		if b.EntityName != "" {
+			return &ValidationError{Field: field("entityName"), Reason: "not allowed for individuals"}
+		}
+	case BeneficiaryEntity:
+		if b.EntityName == "" {
+			return &ValidationError{Field: field("entityName"), Reason: "required for entities"}
+		}
+		if b.FirstName != "" || b.LastName != "" {
+			return &ValidationError{Field: field("firstName"), Reason: "not allowed for entities"}
+		}
+	default:
+		return &ValidationError{Field: field("beneficiaryType"), Reason: fmt.Sprintf("must be %s or %s for third parties, got %q", BeneficiaryIndividual, BeneficiaryEntity, b.BeneficiaryType)}
+	}
+	return nil
+}
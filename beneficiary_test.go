@@ -0,0 +1,36 @@
+package valr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestWithdrawalBeneficiary(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		b     valr.WithdrawalBeneficiary
+		field string
+	}{
+		{"self hosted own wallet", valr.WithdrawalBeneficiary{DestinationType: valr.DestinationSelfHosted, IsSelf: true}, ""},
+		{"vasp individual", valr.WithdrawalBeneficiary{
+			DestinationType: valr.DestinationVASP, VASPName: "Exchange",
+			BeneficiaryType: valr.BeneficiaryIndividual, FirstName: "A", LastName: "B", CountryOfResidence: "ZA",
+		}, ""},
+		{"vasp without name", valr.WithdrawalBeneficiary{DestinationType: valr.DestinationVASP, IsSelf: true}, "beneficiary.vaspName"},
+		{"unknown destination", valr.WithdrawalBeneficiary{IsSelf: true}, "beneficiary.destinationType"},
+		{"third party without type", valr.WithdrawalBeneficiary{DestinationType: valr.DestinationSelfHosted}, "beneficiary.beneficiaryType"},
+		{"entity without name", valr.WithdrawalBeneficiary{DestinationType: valr.DestinationSelfHosted, BeneficiaryType: valr.BeneficiaryEntity}, "beneficiary.entityName"},
+		{"bad country", valr.WithdrawalBeneficiary{DestinationType: valr.DestinationSelfHosted, IsSelf: true, CountryOfResidence: "ZAF"}, "beneficiary.countryOfResidence"},
+	} {
+		err := tc.b.Validate()
+		var verr *valr.ValidationError
+		switch {
+		case tc.field == "" && err != nil:
+			t.Errorf("%s: expected success, got %v", tc.name, err)
+		case tc.field != "" && (!errors.As(err, &verr) || verr.Field != tc.field):
+			t.Errorf("%s: expected invalid %s, got %v", tc.name, tc.field, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package calendar gates trading activity to configured time windows, such
+// as trading sessions per pair and quiet hours with little liquidity.
+// Strategies can check Open or block in Wait before acting, and the order
+// manager can reject orders placed outside a pair's calendar.
+package calendar
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+)
+
+const (
+	day = 24 * time.Hour
+	// horizon is how far ahead NextOpen searches.
+	horizon = 15
+	// dateFormat is the format of holidays.
+	dateFormat = "2006-01-02"
+)
+
+// Window is a daily time window from Start until End, both offsets from
+// local midnight. A window whose End is before its Start spans midnight,
+// e.g. 22:00 to 02:00. Days restricts the window to the days it starts on;
+// it applies every day if empty.
+type Window struct {
+	Days  []time.Weekday
+	Start time.Duration
+	End   time.Duration
+}
+
+// Daily returns a window from start until end every day.
+func Daily(start, end time.Duration) Window {
+	return Window{Start: start, End: end}
+}
+
+// Weekdays returns a window from start until end, Monday to Friday.
+func Weekdays(start, end time.Duration) Window {
+	return Window{
+		Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start: start,
+		End:   end,
+	}
+}
+
+// contains returns true if the window started on the date of midnight
+// contains t.
+func (w Window) contains(midnight, t time.Time) bool {
+	if len(w.Days) > 0 && !containsDay(w.Days, midnight.Weekday()) {
+		return false
+	}
+	start := at(midnight, w.Start)
+	end := at(midnight, w.End)
+	if w.End <= w.Start {
+		end = at(midnight.AddDate(0, 0, 1), w.End)
+	}
+	return !t.Before(start) && t.Before(end)
+}
+
+func containsDay(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// at returns the time offset after midnight, in wall clock terms so that
+// windows keep their local times across daylight saving changes.
+func at(midnight time.Time, offset time.Duration) time.Time {
+	y, m, d := midnight.Date()
+	return time.Date(y, m, d, 0, 0, 0, int(offset), midnight.Location())
+}
+
+type Option func(*Calendar)
+
+// WithLocation sets the time zone of the windows and holidays, UTC by
+// default.
+func WithLocation(loc *time.Location) Option {
+	return func(c *Calendar) {
+		c.location = loc
+	}
+}
+
+// WithClock sets the clock Wait waits on, clock.Real by default.
+func WithClock(cl clock.Clock) Option {
+	return func(c *Calendar) {
+		c.clock = cl
+	}
+}
+
+// WithSessions restricts trading to the given windows. Without sessions
+// the calendar is open at all times other than quiet hours and holidays.
+func WithSessions(windows ...Window) Option {
+	return func(c *Calendar) {
+		c.sessions = append(c.sessions, windows...)
+	}
+}
+
+// WithQuietHours closes the calendar during the given windows, e.g. the
+// low-liquidity early morning hours.
+func WithQuietHours(windows ...Window) Option {
+	return func(c *Calendar) {
+		c.quiet = append(c.quiet, windows...)
+	}
+}
+
+// WithHolidays closes the calendar for whole local days, given as dates
+// such as "2024-12-25".
+func WithHolidays(dates ...string) Option {
+	return func(c *Calendar) {
+		for _, d := range dates {
+			c.holidays[d] = true
+		}
+	}
+}
+
+// Calendar decides when trading is allowed.
+type Calendar struct {
+	location *time.Location
+	clock    clock.Clock
+	sessions []Window
+	quiet    []Window
+	holidays map[string]bool
+}
+
+// New returns a calendar. Without options it is always open.
+func New(opts ...Option) *Calendar {
+	c := &Calendar{location: time.UTC, clock: clock.Real, holidays: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Open returns true if trading is allowed at t. A nil calendar is always
+// open.
+func (c *Calendar) Open(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	t = t.In(c.location)
+	if c.holidays[t.Format(dateFormat)] {
+		return false
+	}
+	// Windows spanning midnight may have started the day before.
+	today := at(t, 0)
+	yesterday := today.AddDate(0, 0, -1)
+	in := func(windows []Window) bool {
+		for _, w := range windows {
+			if w.contains(today, t) || w.contains(yesterday, t) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(c.sessions) > 0 && !in(c.sessions) {
+		return false
+	}
+	return !in(c.quiet)
+}
+
+// NextOpen returns t if the calendar is open at t, and otherwise when it
+// next opens. It returns the zero time if the calendar doesn't open in the
+// next two weeks.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	if c.Open(t) {
+		return t
+	}
+	for _, b := range c.boundaries(t) {
+		if b.After(t) && c.Open(b) {
+			return b
+		}
+	}
+	return time.Time{}
+}
+
+// boundaries returns the times the calendar may open or close over the
+// horizon after t, sorted.
+func (c *Calendar) boundaries(t time.Time) []time.Time {
+	t = t.In(c.location)
+	var out []time.Time
+	for i := -1; i <= horizon; i++ {
+		midnight := at(t, 0).AddDate(0, 0, i)
+		out = append(out, midnight)
+		for _, windows := range [][]Window{c.sessions, c.quiet} {
+			for _, w := range windows {
+				out = append(out, at(midnight, w.Start))
+				if w.End <= w.Start {
+					out = append(out, at(midnight.AddDate(0, 0, 1), w.End))
+				} else {
+					out = append(out, at(midnight, w.End))
+				}
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// Wait blocks until the calendar is open or ctx is done, returning ctx's
+// error in that case. It returns at once if the calendar is open.
+func (c *Calendar) Wait(ctx context.Context) error {
+	for {
+		now := c.clock.Now()
+		next := c.NextOpen(now)
+		if next.Equal(now) {
+			return nil
+		}
+		// Check again after a day if the calendar doesn't open soon.
+		d := day
+		if !next.IsZero() {
+			d = next.Sub(now)
+		}
+		timer := c.clock.NewTimer(d)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Schedule holds the calendars of pairs, with a default for pairs without
+// their own.
+type Schedule struct {
+	def *Calendar
+
+	mu    sync.RWMutex
+	pairs map[string]*Calendar
+}
+
+// NewSchedule returns a schedule using def for pairs without their own
+// calendar. A nil default is always open.
+func NewSchedule(def *Calendar) *Schedule {
+	return &Schedule{def: def, pairs: make(map[string]*Calendar)}
+}
+
+// Set sets the calendar of pair.
+func (s *Schedule) Set(pair string, c *Calendar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pairs[pair] = c
+}
+
+// For returns the calendar of pair.
+func (s *Schedule) For(pair string) *Calendar {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c, ok := s.pairs[pair]; ok {
+		return c
+	}
+	return s.def
+}
+
+// Open returns true if trading pair is allowed at t.
+func (s *Schedule) Open(pair string, t time.Time) bool {
+	return s.For(pair).Open(t)
+}
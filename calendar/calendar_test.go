@@ -0,0 +1,72 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/calendar"
+)
+
+func TestCalendarOpen(t *testing.T) {
+	loc := time.FixedZone("SAST", 2*60*60)
+	cal := calendar.New(
+		calendar.WithLocation(loc),
+		calendar.WithSessions(calendar.Weekdays(8*time.Hour, 17*time.Hour)),
+		calendar.WithQuietHours(calendar.Daily(12*time.Hour, 13*time.Hour)),
+		calendar.WithHolidays("2024-12-25"),
+	)
+
+	// 2024-12-23 is a Monday.
+	tests := []struct {
+		time string
+		open bool
+	}{
+		{"2024-12-23T07:59:00+02:00", false},
+		{"2024-12-23T08:00:00+02:00", true},
+		{"2024-12-23T12:30:00+02:00", false},
+		{"2024-12-23T13:00:00+02:00", true},
+		{"2024-12-23T15:30:00Z", false},
+		{"2024-12-25T10:00:00+02:00", false},
+		{"2024-12-28T10:00:00+02:00", false},
+	}
+	for _, test := range tests {
+		tm, _ := time.Parse(time.RFC3339, test.time)
+		if got := cal.Open(tm); got != test.open {
+			t.Errorf("Expected open %v at %s, got %v", test.open, test.time, got)
+		}
+	}
+
+	tests2 := []struct {
+		time, next string
+	}{
+		{"2024-12-23T10:00:00+02:00", "2024-12-23T10:00:00+02:00"},
+		{"2024-12-23T12:15:00+02:00", "2024-12-23T13:00:00+02:00"},
+		{"2024-12-24T18:00:00+02:00", "2024-12-26T08:00:00+02:00"},
+		{"2024-12-27T17:00:00+02:00", "2024-12-30T08:00:00+02:00"},
+	}
+	for _, test := range tests2 {
+		tm, _ := time.Parse(time.RFC3339, test.time)
+		want, _ := time.Parse(time.RFC3339, test.next)
+		if got := cal.NextOpen(tm); !got.Equal(want) {
+			t.Errorf("Expected next open %s after %s, got %s", want, test.time, got)
+		}
+	}
+}
+
+func TestQuietHoursSpanningMidnight(t *testing.T) {
+	cal := calendar.New(calendar.WithQuietHours(calendar.Daily(22*time.Hour, 2*time.Hour)))
+	s := calendar.NewSchedule(nil)
+	s.Set("BTCZAR", cal)
+
+	at := time.Date(2024, 1, 10, 1, 0, 0, 0, time.UTC)
+	if s.Open("BTCZAR", at) {
+		t.Errorf("Expected BTCZAR closed at %s", at)
+	}
+	if !s.Open("ETHZAR", at) {
+		t.Errorf("Expected ETHZAR open at %s", at)
+	}
+	want := time.Date(2024, 1, 10, 2, 0, 0, 0, time.UTC)
+	if got := cal.NextOpen(at); !got.Equal(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,45 @@
+package valr
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+)
+
+// Call makes a request to an arbitrary VALR endpoint, for endpoints this
+// library doesn't wrap yet. Requests are rate limited, signed when auth is
+// true and checked for error responses in the same way as the typed
+// methods; the response body is decoded as JSON into res unless res is
+// nil.
+//
+// path is relative to the base URL, e.g. "/account/balances", and may
+// contain {tags} filled from req's url tagged fields. req may be nil, a
+// pointer to a struct with url and json tags, a url.Values holding query
+// parameters, or any other value to send as the JSON body.
+func (cl *Client) Call(ctx context.Context, method, path string, req, res any, auth bool) error {
+	return cl.do(ctx, method, path, req, res, auth)
+}
+
+// requestValues returns the url values of req, used for path tags and the
+// query string.
+func requestValues(req any) (url.Values, error) {
+	if v, ok := req.(url.Values); ok {
+		values := make(url.Values, len(v))
+		for k, vs := range v {
+			values[k] = append([]string(nil), vs...)
+		}
+		return values, nil
+	}
+	rv := reflect.ValueOf(req)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return make(url.Values), nil
+	}
+	return MakeURLValues(req)
+}
+
+// isQueryValues returns true if req only carries query parameters, so no
+// request body is sent.
+func isQueryValues(req any) bool {
+	_, ok := req.(url.Values)
+	return ok
+}
@@ -0,0 +1,96 @@
+package valr
+
+import (
+	"reflect"
+	"strings"
+)
+
+// CallError wraps an error returned by a call with the context it was made
+// in, so that errors surfacing from deep call stacks remain actionable. Use
+// errors.As to access the fields; the wrapped error is still matched by
+// errors.Is and errors.As.
+type CallError struct {
+	Method string
+	// Endpoint is the path template of the endpoint, e.g.
+	// "/orders/{currencyPair}/orderid/{orderId}".
+	Endpoint        string
+	Pair            string
+	OrderID         string
+	CustomerOrderID string
+	SubaccountID    string
+	Err             error
+}
+
+func (e *CallError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	b.WriteString(" [")
+	for i, kv := range e.fields() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kv[0])
+		b.WriteByte('=')
+		b.WriteString(kv[1])
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// Fields returns the non-empty context of the error as key-value pairs,
+// e.g. for structured loggers.
+func (e *CallError) Fields() map[string]string {
+	fields := make(map[string]string)
+	for _, kv := range e.fields() {
+		fields[kv[0]] = kv[1]
+	}
+	return fields
+}
+
+func (e *CallError) fields() [][2]string {
+	var fields [][2]string
+	add := func(k, v string) {
+		if v != "" {
+			fields = append(fields, [2]string{k, v})
+		}
+	}
+	add("endpoint", e.Method+" "+e.Endpoint)
+	add("pair", e.Pair)
+	add("orderId", e.OrderID)
+	add("customerOrderId", e.CustomerOrderID)
+	add("subaccountId", e.SubaccountID)
+	return fields
+}
+
+// newCallError wraps err with the context of a call, taking the pair and
+// order IDs from the request's fields.
+func newCallError(method, path, subaccountID string, req any, err error) *CallError {
+	e := &CallError{
+		Method:       method,
+		Endpoint:     path,
+		SubaccountID: subaccountID,
+		Err:          err,
+	}
+	rv := reflect.ValueOf(req)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return e
+	}
+	rv = rv.Elem()
+	field := func(names ...string) string {
+		for _, name := range names {
+			f := rv.FieldByName(name)
+			if f.IsValid() && f.Kind() == reflect.String {
+				return f.String()
+			}
+		}
+		return ""
+	}
+	e.Pair = field("Pair", "CurrencyPair")
+	e.OrderID = field("OrderID", "ID")
+	e.CustomerOrderID = field("CustomerOrderID")
+	return e
+}
@@ -0,0 +1,173 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimeRange is the half-open time interval [Start, End).
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitTimeRange splits [start, end) into consecutive ranges of at most
+// chunk in length, in chronological order.
+func SplitTimeRange(start, end time.Time, chunk time.Duration) []TimeRange {
+	if chunk <= 0 || !start.Before(end) {
+		return nil
+	}
+	var ranges []TimeRange
+	for s := start; s.Before(end); s = s.Add(chunk) {
+		e := s.Add(chunk)
+		if e.After(end) {
+			e = end
+		}
+		ranges = append(ranges, TimeRange{Start: s, End: e})
+	}
+	return ranges
+}
+
+type chunkConfig struct {
+	concurrency int
+}
+
+type ChunkOption func(*chunkConfig)
+
+// WithChunkConcurrency sets how many chunks are fetched at the same time.
+// The default of 1 fetches chunks sequentially.
+func WithChunkConcurrency(n int) ChunkOption {
+	return func(c *chunkConfig) {
+		c.concurrency = n
+	}
+}
+
+// FetchChunked splits [start, end) into chunks, calls fetch for each chunk
+// and returns the concatenated results in chronological chunk order. Items
+// within a chunk are kept in the order fetch returned them. On error the
+// remaining chunks are cancelled and the first error is returned.
+//
+// Neighbouring chunks share a boundary, which endpoints treating their end
+// time as inclusive return in both chunks, so callers should drop
+// duplicates.
+func FetchChunked[T any](ctx context.Context, start, end time.Time, chunk time.Duration,
+	fetch func(ctx context.Context, r TimeRange) ([]T, error), opts ...ChunkOption) ([]T, error) {
+
+	cfg := chunkConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	ranges := SplitTimeRange(start, end, chunk)
+	if len(ranges) == 0 {
+		return nil, errors.New("valr: empty time range")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]T, len(ranges))
+	sem := make(chan struct{}, cfg.concurrency)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i, r := range ranges {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, r TimeRange) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := fetch(ctx, r)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			results[i] = res
+		}(i, r)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var merged []T
+	for _, res := range results {
+		merged = append(merged, res...)
+	}
+	return merged, nil
+}
+
+// GetAuthTradeHistoryForPairRange fetches all trades for pair between start
+// and end by splitting the range into chunks and paging through each chunk.
+// Trades are returned oldest first, once each, including those on a chunk
+// boundary.
+func (cl *Client) GetAuthTradeHistoryForPairRange(ctx context.Context, pair string,
+	start, end time.Time, chunk time.Duration, opts ...ChunkOption) ([]TradeHistoryInfo, error) {
+
+	trades, err := FetchChunked(ctx, start, end, chunk,
+		func(ctx context.Context, r TimeRange) ([]TradeHistoryInfo, error) {
+			req := &GetAuthTradeHistoryForPairRequest{
+				Pair:      pair,
+				Limit:     defaultHistoryLimit,
+				StartTime: r.Start,
+				EndTime:   r.End,
+			}
+			var res []TradeHistoryInfo
+			for {
+				page, err := cl.GetAuthTradeHistoryForPairPage(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, page.Items...)
+				if !page.HasMore {
+					return res, nil
+				}
+				req.Skip = page.NextSkip
+			}
+		}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(trades))
+	unique := trades[:0]
+	for _, t := range trades {
+		if t.ID != "" {
+			if seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+		}
+		unique = append(unique, t)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].SequenceID < unique[j].SequenceID
+	})
+	return unique, nil
+}
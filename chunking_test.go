@@ -0,0 +1,96 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestFetchChunked(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(150 * time.Minute)
+
+	ranges := valr.SplitTimeRange(start, end, time.Hour)
+	if len(ranges) != 3 || !ranges[1].Start.Equal(ranges[0].End) || !ranges[2].End.Equal(end) {
+		t.Fatalf("Expected 3 contiguous chunks ending at %s, got %+v", end, ranges)
+	}
+
+	// Chunks fetched concurrently are returned in chronological order.
+	got, err := valr.FetchChunked(context.Background(), start, end, time.Hour,
+		func(_ context.Context, r valr.TimeRange) ([]string, error) {
+			time.Sleep(time.Duration(3-r.Start.Hour()) * 5 * time.Millisecond)
+			return []string{r.Start.Format("15:04")}, nil
+		}, valr.WithChunkConcurrency(3))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if strings.Join(got, " ") != "00:00 01:00 02:00" {
+		t.Errorf("Expected chunks in order, got %q", got)
+	}
+
+	failed := errors.New("failed")
+	_, err = valr.FetchChunked(context.Background(), start, end, time.Hour,
+		func(_ context.Context, r valr.TimeRange) ([]string, error) {
+			if r.Start.Hour() == 1 {
+				return nil, failed
+			}
+			return nil, nil
+		})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected %v, got %v", failed, err)
+	}
+}
+
+func TestTradeHistoryRangeBoundary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	type trade struct {
+		ID         string    `json:"id"`
+		SequenceID int       `json:"sequenceId"`
+		TradedAt   time.Time `json:"tradedAt"`
+	}
+	// Newest first, as VALR returns them; t2 is on the boundary of the
+	// first and second hour.
+	trades := []trade{
+		{ID: "t3", SequenceID: 3, TradedAt: start.Add(90 * time.Minute)},
+		{ID: "t2", SequenceID: 2, TradedAt: start.Add(time.Hour)},
+		{ID: "t1", SequenceID: 1, TradedAt: start.Add(30 * time.Minute)},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, _ := time.Parse(time.RFC3339, r.URL.Query().Get("startTime"))
+		to, _ := time.Parse(time.RFC3339, r.URL.Query().Get("endTime"))
+		// The end time is treated as inclusive.
+		res := []trade{}
+		for _, tr := range trades {
+			if !tr.TradedAt.Before(from) && !tr.TradedAt.After(to) {
+				res = append(res, tr)
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	got, err := cl.GetAuthTradeHistoryForPairRange(context.Background(), "BTCZAR", start, start.Add(2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var ids []string
+	for _, tr := range got {
+		ids = append(ids, tr.ID)
+	}
+	if strings.Join(ids, " ") != "t1 t2 t3" {
+		t.Errorf("Expected each trade once, oldest first, got %q", ids)
+	}
+}
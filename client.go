@@ -3,18 +3,17 @@ package valr
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,16 +24,36 @@ const (
 	defaultTimeout = 10 * time.Second
 )
 
+// ErrTooManyRequests matches the APIError returned for rate limited calls.
 var ErrTooManyRequests = errors.New("too many requests")
 
 // Client is a Valr API client.
 type Client struct {
-	httpClient   *http.Client
-	rateLimiter  Limiter
-	baseURL      string
-	apiKeyPub    string
-	apiKeySecret string
-	debug        bool
+	httpClient     *http.Client
+	rateLimiter    Limiter
+	baseURL        string
+	env            Environment
+	cache          *responseCache
+	hedgeDelays    map[EndpointClass]time.Duration
+	cancelPriority bool
+	apiKeyPub      string
+	signer         Signer
+	debug          bool
+	halted         atomic.Bool
+	draining       atomic.Bool
+	inflight       inflightCalls
+	mutating       inflightCalls
+	immediateMu    sync.Mutex
+	immediate      map[string]string
+	auditHook      AuditHook
+	scopes         *ScopeAdvisor
+	schema         *SchemaMonitor
+	decimals       *DecimalFormat
+	retryPolicy    *RetryPolicy
+	apiVersions    map[string]string
+
+	withdrawalPolicy *WithdrawalPolicy
+	approvals        *ApprovalGate
 }
 
 // NewClient creates a new Valr API client with the default base URL.
@@ -43,6 +62,7 @@ func NewClient() *Client {
 		httpClient:  &http.Client{Timeout: defaultTimeout},
 		rateLimiter: NewRateLimiter(),
 		baseURL:     defaultBaseURL,
+		env:         Production,
 	}
 }
 
@@ -51,8 +71,24 @@ func (cl *Client) SetAuth(apiKeyID, apiKeySecret string) error {
 	if apiKeyID == "" || apiKeySecret == "" {
 		return errors.New("valr: no credentials provided")
 	}
+	signer, err := NewHMACSigner(apiKeySecret)
+	if err != nil {
+		return err
+	}
 	cl.apiKeyPub = apiKeyID
-	cl.apiKeySecret = apiKeySecret
+	cl.signer = signer
+	return nil
+}
+
+// SetSigner provides the client with an API key and a Signer, for callers
+// that don't want the API secret held by the client, e.g. when signing is
+// done by a remote service.
+func (cl *Client) SetSigner(apiKeyID string, signer Signer) error {
+	if apiKeyID == "" || signer == nil {
+		return errors.New("valr: no credentials provided")
+	}
+	cl.apiKeyPub = apiKeyID
+	cl.signer = signer
 	return nil
 }
 
@@ -75,6 +111,17 @@ func (cl *Client) SetTimeout(timeout *time.Duration) {
 // SetBaseURL overrides the default base URL. For internal use.
 func (cl *Client) SetBaseURL(baseURL string) {
 	cl.baseURL = strings.TrimRight(baseURL, "/")
+	cl.env.BaseURL = cl.baseURL
+}
+
+// Close releases resources held by the client, such as the background
+// goroutine of the default rate limiter. The client must not be used after
+// Close has been called.
+func (cl *Client) Close() error {
+	if c, ok := cl.rateLimiter.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
 }
 
 // SetDebug enables or disables debug mode. In debug mode, HTTP requests and
@@ -83,48 +130,128 @@ func (cl *Client) SetDebug(debug bool) {
 	cl.debug = debug
 }
 
+// do sends a request, wrapping any error in a CallError.
 func (cl *Client) do(ctx context.Context, method, path string,
 	req, res interface{}, auth bool) error {
 
-	url := cl.baseURL + "/" + strings.TrimLeft(path, "/")
+	if cl.scopes != nil && auth {
+		cl.scopes.observe(method, path)
+	}
+	if err := cl.send(ctx, method, path, req, res, auth); err != nil {
+		return newCallError(method, path, subaccountFromContext(ctx), req, err)
+	}
+	return nil
+}
 
-	if cl.debug {
-		log.Printf("valr: Call: %s %s", method, path)
-		log.Printf("valr: Request: %#v", req)
+func (cl *Client) send(ctx context.Context, method, path string,
+	req, res interface{}, auth bool) (err error) {
+
+	if cl.halted.Load() && isMutating(method) {
+		return ErrTradingHalted
+	}
+	if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
 	}
 
+	baseURL := cl.endpointBaseURL(ctx, path)
+	expanded := path
 	var reqBody []byte
+	var query string
 	if req != nil {
-		values, err := MakeURLValues(req)
+		values, err := requestValues(req)
 		if err != nil {
 			return err
 		}
-		tags := findTags(path)
-		for _, tag := range tags {
-			url = strings.Replace(url, "{"+tag+"}", values.Get(tag), -1)
-			values.Del(tag)
+		if expanded, err = expandPath(path, values); err != nil {
+			return err
 		}
 		for key := range values {
 			if values.Get(key) == "" {
 				values.Del(key)
 			}
 		}
-		if method == http.MethodGet {
-			if values.Encode() != "" {
-				url = url + "?" + values.Encode()
-			}
+		if method == http.MethodGet || isQueryValues(req) {
+			query = values.Encode()
 		} else {
 			reqBody, err = json.Marshal(req)
 			if err != nil {
 				return err
 			}
+			if cl.decimals != nil {
+				if reqBody, err = cl.decimals.format(reqBody); err != nil {
+					return err
+				}
+			}
 		}
 	}
+	url := baseURL + "/" + strings.TrimLeft(expanded, "/")
+	if query != "" {
+		url = url + "?" + query
+	}
+
+	// Withdrawals are checked however they are made, before they are
+	// signed.
+	done, err := cl.guard(ctx, method, expanded, reqBody)
+	if err != nil {
+		return err
+	}
+	b := newBudget(ctx, method, path)
+	defer func() { done(b.attempts > 0, err) }()
+
+	// Register the call before checking for draining, so Drain either sees
+	// it in progress or it sees Drain.
+	cl.inflight.add()
+	defer cl.inflight.done()
+	if cl.draining.Load() && isPlacement(method, path) {
+		return ErrDraining
+	}
+
 	if cl.debug {
+		log.Printf("valr: Call: %s %s", method, path)
+		log.Printf("valr: Request: %#v", req)
 		log.Printf("Request URL: %s", url)
 		log.Printf("Request body: %s", string(reqBody))
 	}
 
+	cacheable := cl.cache != nil && method == http.MethodGet && !auth
+	if cacheable {
+		if body, ok := cl.cache.get(url); ok {
+			return decodeResponse(body, res)
+		}
+	}
+
+	if cl.cancelPriority && isCancel(method, path) {
+		ctx = WithPriority(ctx)
+	}
+	hedgeDelay := cl.hedgeDelay(method, path)
+	for {
+		var err error
+		if hedgeDelay > 0 {
+			err = cl.hedgedAttempt(ctx, b, hedgeDelay, method, url, reqBody, res, auth, cacheable)
+		} else {
+			err = cl.attempt(ctx, b, method, url, reqBody, res, auth, cacheable)
+		}
+		wait, retry := cl.retryPolicy.backoff(method, req, b.attempts, err)
+		if !retry {
+			return err
+		}
+		if cl.debug {
+			log.Printf("valr: Retrying %s %s in %s: %v", method, path, wait, err)
+		}
+		if err := b.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt signs and sends a request once, decoding the response into res.
+// The HTTP request is built afresh from reqBody and signed with a new
+// timestamp every time, so attempts can be repeated safely.
+func (cl *Client) attempt(ctx context.Context, b *budget, method, url string,
+	reqBody []byte, res interface{}, auth, cacheable bool) error {
+
 	httpReq, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
 	if err != nil {
 		return err
@@ -135,13 +262,39 @@ func (cl *Client) do(ctx context.Context, method, path string,
 		httpReq.Header.Set("Content-Type", "application/json")
 	}
 
-	cl.rateLimiter.Wait(ctx)
+	if err := b.wait(ctx, cl.rateLimiter); err != nil {
+		return err
+	}
+	if isMutating(method) {
+		// Register the attempt before checking for the kill switch, so
+		// KillSwitch either waits for it or it sees the halt, however long
+		// it waited for the limiter or retries.
+		cl.mutating.add()
+		defer cl.mutating.done()
+		if cl.halted.Load() {
+			return ErrTradingHalted
+		}
+	}
 	if auth {
+		if cl.signer == nil {
+			return errors.New("valr: no credentials provided")
+		}
 		httpReq.Header.Set("X-VALR-API-KEY", cl.apiKeyPub)
-		now := time.Now()
-		timestampString := strconv.FormatInt(now.UnixNano()/1000000, 10)
-		path := strings.Replace(url, "https://api.valr.com", "", -1)
-		signature := SignRequest(cl.apiKeySecret, timestampString, method, path, reqBody)
+		timestampString := NextTimestamp()
+		path, err := signingPath(url)
+		if err != nil {
+			return err
+		}
+		signBody := reqBody
+		if id := subaccountFromContext(ctx); id != "" {
+			// The subaccount ID is appended to the signed payload.
+			signBody = append(append([]byte(nil), reqBody...), id...)
+			httpReq.Header.Set("X-VALR-SUB-ACCOUNT-ID", id)
+		}
+		signature, err := cl.signer.Sign(ctx, timestampString, method, path, signBody)
+		if err != nil {
+			return err
+		}
 		httpReq.Header.Set("X-VALR-SIGNATURE", signature)
 		httpReq.Header.Set("X-VALR-TIMESTAMP", timestampString)
 		if cl.debug {
@@ -151,13 +304,17 @@ func (cl *Client) do(ctx context.Context, method, path string,
 		}
 	}
 
+	b.attempts++
+	start := time.Now()
 	httpRes, err := cl.httpClient.Do(httpReq)
 	if err != nil {
+		cl.audit(ctx, start, method, url, reqBody, 0, nil, err)
 		return err
 	}
 	defer httpRes.Body.Close()
 
 	resBody, err := ioutil.ReadAll(httpRes.Body)
+	cl.audit(ctx, start, method, url, reqBody, httpRes.StatusCode, resBody, err)
 	if err != nil {
 		return err
 	}
@@ -165,89 +322,117 @@ func (cl *Client) do(ctx context.Context, method, path string,
 		log.Printf("Response: %s", string(resBody))
 	}
 
-	if httpRes.StatusCode == 429 {
-		return ErrTooManyRequests
+	if httpRes.StatusCode/100 != 2 {
+		apiErr := newAPIError(httpRes.StatusCode, httpRes.Header, resBody)
+		if httpRes.StatusCode != http.StatusTooManyRequests {
+			log.Printf("valr: Call: %s %s\nvalr: Request: %s\nvalr: Response: %s\n", method, b.path, string(reqBody), string(resBody))
+		} else if p, ok := cl.rateLimiter.(pauser); ok && apiErr.RetryAfter > 0 {
+			// Hold back every call, not just this one, until VALR is ready.
+			p.Pause(apiErr.RetryAfter)
+		}
+		return apiErr
+	}
+
+	if cacheable {
+		cl.cache.put(b.path, url, resBody)
 	}
+	return cl.decode(b, resBody, res)
+}
 
-	if httpRes.StatusCode/100 != 2 {
-		log.Printf("valr: Call: %s %s\nvalr: Request: %s\nvalr: Response: %s\n", method, path, string(reqBody), string(resBody))
-		return fmt.Errorf("valr: error response (%d %s)",
-			httpRes.StatusCode, http.StatusText(httpRes.StatusCode))
+// decode decodes the response to a call, checking it with the schema
+// monitor if set.
+func (cl *Client) decode(b *budget, body []byte, res interface{}) error {
+	if err := decodeResponse(body, res); err != nil {
+		return err
 	}
+	if cl.schema != nil && res != nil {
+		cl.schema.observe(b.method, b.path, body, res)
+	}
+	return nil
+}
 
-	return json.Unmarshal(resBody, res)
+func decodeResponse(body []byte, res interface{}) error {
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(body, res)
 }
 
-// getProtocol takes a URL string and returns its protocol (scheme).
-func getProtocol(rawurl string) (string, error) {
+// signingPath returns the part of rawurl that is signed: the path and query
+// string. It is derived from the parsed URL so that requests to any host,
+// such as a mock server or proxy, are signed correctly.
+func signingPath(rawurl string) (string, error) {
 	parsedURL, err := url.Parse(rawurl)
 	if err != nil {
-		return "", errors.Join(err, errors.New("failed to parse url for protocol"))
+		return "", errors.Join(err, errors.New("failed to parse url for signing"))
+	}
+	switch parsedURL.Scheme {
+	case "https", "http", "wss", "ws":
+	default:
+		return "", errors.New("unsupported protocol")
 	}
 
-	return parsedURL.Scheme, nil
+	return parsedURL.RequestURI(), nil
 }
 
 func GetAuthHeaders(rawurl string, method string, apiKeyPub, apiKeySecret string, reqBody []byte) (http.Header, error) {
+	signer, err := NewHMACSigner(apiKeySecret)
+	if err != nil {
+		return nil, err
+	}
+	return GetSignedHeaders(context.Background(), rawurl, method, apiKeyPub, signer, reqBody)
+}
+
+// GetSignedHeaders returns the VALR authentication headers for a request,
+// using signer to produce the signature.
+func GetSignedHeaders(ctx context.Context, rawurl string, method string, apiKeyPub string, signer Signer, reqBody []byte) (http.Header, error) {
+	path, err := signingPath(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := http.Header{}
 
 	headers.Set("X-VALR-API-KEY", apiKeyPub)
-	now := time.Now()
-	timestampString := strconv.FormatInt(now.UnixNano()/1000000, 10)
-	scheme, err := getProtocol(rawurl)
+	timestampString := NextTimestamp()
+	signature, err := signer.Sign(ctx, timestampString, method, path, reqBody)
 	if err != nil {
 		return nil, err
 	}
-	var path string
-	if scheme == "wss" {
-		path = strings.Replace(rawurl, "wss://api.valr.com", "", -1)
-	} else if scheme == "https" {
-		path = strings.Replace(rawurl, "https://api.valr.com", "", -1)
-	} else {
-		return nil, errors.New("unsupported protocol")
-	}
-	signature := SignRequest(apiKeySecret, timestampString, method, path, reqBody)
 	headers.Set("X-VALR-SIGNATURE", signature)
 	headers.Set("X-VALR-TIMESTAMP", timestampString)
 
 	return headers, nil
 }
 
-func findTags(str string) []string {
-	tags := make([]string, 0)
-	firstTag, remainder := findTag(str)
-	if firstTag != "" {
-		tags = append(tags, firstTag)
-	}
-	for remainder != "" {
-		var nextTag string
-		nextTag, remainder = findTag(remainder)
-		if nextTag != "" {
-			tags = append(tags, nextTag)
+// expandPath replaces the {tags} of path with their values, removing them
+// from values. Values are escaped, so braces, slashes and query characters
+// in them can't change the rest of the path.
+func expandPath(path string, values url.Values) (string, error) {
+	var b strings.Builder
+	for {
+		left := strings.Index(path, "{")
+		if left < 0 {
+			break
 		}
-	}
-	return tags
-}
-
-func findTag(str string) (string, string) {
-	leftIndex := strings.Index(str, "{")
-	if leftIndex > 0 {
-		rightIndex := strings.Index(str[leftIndex:], "}")
-		if rightIndex >= 0 {
-			return str[leftIndex+1 : rightIndex+leftIndex], str[rightIndex+leftIndex:]
+		right := strings.Index(path[left:], "}")
+		if right < 0 {
+			break
+		}
+		tag := path[left+1 : left+right]
+		value := values.Get(tag)
+		switch value {
+		case "":
+			return "", fmt.Errorf("valr: missing path parameter %q", tag)
+		case ".", "..":
+			// Servers resolve dot segments, reaching another endpoint.
+			return "", fmt.Errorf("valr: invalid path parameter %s=%q", tag, value)
 		}
+		values.Del(tag)
+		b.WriteString(path[:left])
+		b.WriteString(url.PathEscape(value))
+		path = path[left+right+1:]
 	}
-	return "", ""
-}
-
-func SignRequest(apiSecret string, timestampString, verb, path string, body []byte) string {
-	// Create a new Keyed-Hash Message Authentication Code (HMAC) using SHA512 and API Secret
-	mac := hmac.New(sha512.New, []byte(apiSecret))
-
-	mac.Write([]byte(timestampString))
-	mac.Write([]byte(strings.ToUpper(verb)))
-	mac.Write([]byte(path))
-	mac.Write(body)
-	// Gets the byte hash from HMAC and converts it into a hex string
-	return hex.EncodeToString(mac.Sum(nil))
+	b.WriteString(path)
+	return b.String(), nil
 }
@@ -0,0 +1,36 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestGetSignedHeadersPath(t *testing.T) {
+	tests := []struct {
+		url string
+		exp string
+	}{
+		{"wss://api.valr.com/ws/trade", "/ws/trade"},
+		{"https://api.valr.com/v1/orders/history?limit=10", "/v1/orders/history?limit=10"},
+		{"ws://127.0.0.1:8080/ws/account", "/ws/account"},
+		{"http://proxy.internal/v1/account/balances", "/v1/account/balances"},
+	}
+	for _, test := range tests {
+		var act string
+		signer := valr.SignerFunc(func(_ context.Context, _, _, path string, _ []byte) (string, error) {
+			act = path
+			return "sig", nil
+		})
+		_, err := valr.GetSignedHeaders(context.Background(), test.url, http.MethodGet, "key", signer, nil)
+		if err != nil {
+			t.Errorf("Expected success, got %v", err)
+			continue
+		}
+		if act != test.exp {
+			t.Errorf("Expected %q, got %q", test.exp, act)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package clock abstracts the current time, timers and random numbers, so
+// that a bot's subsystems can share one source of each and a whole run can
+// be replayed deterministically in tests: given the same market input, a
+// Manual clock and a seeded Rand, every timestamp, timeout and jittered
+// backoff comes out the same.
+package clock
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a timer that fires once, after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event, like time.Timer.
+type Timer interface {
+	// C delivers the time the timer fired.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if it already
+	// fired or was stopped.
+	Stop() bool
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+// Sleep waits for d on c, returning false if done is closed first.
+func Sleep(c Clock, d time.Duration, done <-chan struct{}) bool {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// Manual is a clock that only moves when told to, for tests and replays.
+// Timers fire, in order, as Advance or Set moves the time past them. It is
+// safe for concurrent use.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+	// added is signalled whenever a timer is created.
+	added chan struct{}
+}
+
+// NewManual returns a clock set to t.
+func NewManual(t time.Time) *Manual {
+	return &Manual{now: t, added: make(chan struct{}, 1)}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) NewTimer(d time.Duration) Timer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := &manualTimer{m: m, at: m.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- m.now
+		t.fired = true
+	} else {
+		m.timers = append(m.timers, t)
+	}
+	select {
+	case m.added <- struct{}{}:
+	default:
+	}
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers due by then.
+func (m *Manual) Advance(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set moves the clock to t, firing the timers due by then. The clock never
+// moves backwards; earlier times are ignored.
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t.Before(m.now) {
+		return
+	}
+	sort.SliceStable(m.timers, func(i, j int) bool { return m.timers[i].at.Before(m.timers[j].at) })
+	var pending []*manualTimer
+	for _, tm := range m.timers {
+		if tm.at.After(t) {
+			pending = append(pending, tm)
+			continue
+		}
+		tm.fired = true
+		tm.c <- tm.at
+	}
+	m.timers = pending
+	m.now = t
+}
+
+// BlockUntil waits until at least n timers are pending, e.g. so a test
+// advances the clock only once the code under test is waiting on it.
+func (m *Manual) BlockUntil(n int) {
+	for {
+		m.mu.Lock()
+		pending := len(m.timers)
+		m.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		<-m.added
+	}
+}
+
+type manualTimer struct {
+	m     *Manual
+	at    time.Time
+	c     chan time.Time
+	fired bool
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.c }
+
+func (t *manualTimer) Stop() bool {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	if t.fired {
+		return false
+	}
+	t.fired = true
+	for i, tm := range t.m.timers {
+		if tm == t {
+			t.m.timers = append(t.m.timers[:i], t.m.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// NewRand returns a random number generator seeded with seed that, unlike
+// rand.New, is safe for concurrent use, so one seeded source can be shared
+// by every subsystem of a run.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+}
+
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
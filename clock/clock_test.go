@@ -0,0 +1,70 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewManual(start)
+
+	late := c.NewTimer(2 * time.Second)
+	early := c.NewTimer(time.Second)
+	stopped := c.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Expected a pending timer to stop")
+	}
+
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-early.C():
+		t.Fatal("Expected the timer not to fire yet")
+	default:
+	}
+
+	c.Advance(2 * time.Second)
+	if got := <-early.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected %v, got %v", start.Add(time.Second), got)
+	}
+	if got := <-late.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Expected %v, got %v", start.Add(2*time.Second), got)
+	}
+	select {
+	case <-stopped.C():
+		t.Error("Expected a stopped timer not to fire")
+	default:
+	}
+	if late.Stop() {
+		t.Error("Expected a fired timer not to stop")
+	}
+
+	c.Set(start)
+	if exp := start.Add(2500 * time.Millisecond); !c.Now().Equal(exp) {
+		t.Errorf("Expected the clock not to move back to %v, got %v", start, c.Now())
+	}
+}
+
+func TestManualBlockUntil(t *testing.T) {
+	c := clock.NewManual(time.Unix(0, 0))
+	done := make(chan bool)
+	go func() {
+		done <- clock.Sleep(c, time.Hour, nil)
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	if !<-done {
+		t.Error("Expected the sleep to complete")
+	}
+}
+
+func TestNewRand(t *testing.T) {
+	a, b := clock.NewRand(42), clock.NewRand(42)
+	for i := 0; i < 10; i++ {
+		if x, y := a.Int63(), b.Int63(); x != y {
+			t.Fatalf("Expected equally seeded sources to agree, got %d and %d", x, y)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Command valr-coverage reports which endpoints of the VALR REST API this
+// version of the library implements, compared with an endpoint manifest.
+// By default the manifest bundled with the library is used; pass the
+// manifest of a newer release to see what upgrading would add and which
+// endpoints in use have been deprecated.
+//
+// Usage:
+//
+//	valr-coverage [-manifest manifest.json] [-format text|json] [-fail-on missing,deprecated,unknown]
+//
+// With -fail-on, it exits with status 1 if any endpoints fall in the given
+// categories, for use in CI.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/apicoverage"
+)
+
+func main() {
+	var (
+		manifest = flag.String("manifest", "", "endpoint manifest to compare against; the bundled manifest if empty")
+		format   = flag.String("format", "text", "report format, text or json")
+		failOn   = flag.String("fail-on", "", "comma separated categories that fail the check: missing, deprecated, unknown")
+	)
+	flag.Parse()
+
+	var (
+		m   *apicoverage.Manifest
+		err error
+	)
+	if *manifest == "" {
+		m, err = apicoverage.Bundled()
+	} else {
+		m, err = apicoverage.LoadManifest(*manifest)
+	}
+	if err != nil {
+		log.Fatalf("valr-coverage: %v", err)
+	}
+	r := apicoverage.Compare(m, valr.Endpoints())
+
+	switch *format {
+	case "text":
+		err = r.WriteText(os.Stdout)
+	case "json":
+		err = r.WriteJSON(os.Stdout)
+	default:
+		log.Fatalf("valr-coverage: unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatalf("valr-coverage: %v", err)
+	}
+
+	failed := false
+	for _, category := range strings.Split(*failOn, ",") {
+		switch strings.TrimSpace(category) {
+		case "":
+		case "missing":
+			failed = failed || len(r.Missing) > 0
+		case "deprecated":
+			failed = failed || len(r.Deprecated) > 0
+		case "unknown":
+			failed = failed || len(r.Unknown) > 0
+		default:
+			log.Fatalf("valr-coverage: unknown category %q", category)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
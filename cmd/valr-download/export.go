@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+const day = 24 * time.Hour
+
+// exporter downloads the trades of pairs over a range of days, writing one
+// CSV file per pair and day under dir. All workers share the client, and so
+// its rate limiter.
+type exporter struct {
+	cl       *valr.Client
+	dir      string
+	workers  int
+	pageSize int
+	progress *progress
+}
+
+// job is a single pair and day to export.
+type job struct {
+	pair string
+	day  time.Time
+}
+
+// path returns the partitioned output path of j: dir/PAIR/YYYY-MM-DD.csv.
+func (e *exporter) path(j job) string {
+	return filepath.Join(e.dir, j.pair, j.day.Format(time.DateOnly)+".csv")
+}
+
+// days returns the UTC days from from to to, inclusive.
+func days(from, to time.Time) []time.Time {
+	var out []time.Time
+	for d := from.UTC().Truncate(day); !d.After(to); d = d.Add(day) {
+		out = append(out, d)
+	}
+	return out
+}
+
+// run exports every pair for every day, skipping days already exported, so
+// an interrupted export can be resumed by running it again. Days that fail
+// are reported in the returned error and left for the next run.
+func (e *exporter) run(ctx context.Context, pairs []string, from, to time.Time) error {
+	var jobs []job
+	for _, pair := range pairs {
+		for _, d := range days(from, to) {
+			jobs = append(jobs, job{pair: pair, day: d})
+		}
+	}
+	e.progress.start(len(jobs))
+
+	ch := make(chan job)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+	for i := 0; i < max(e.workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range ch {
+				n, err := e.export(ctx, j)
+				if err != nil {
+					mu.Lock()
+					failed = append(failed, fmt.Sprintf("%s %s: %v", j.pair, j.day.Format(time.DateOnly), err))
+					mu.Unlock()
+				}
+				e.progress.done(n)
+			}
+		}()
+	}
+feed:
+	for _, j := range jobs {
+		select {
+		case ch <- j:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ch)
+	wg.Wait()
+	e.progress.finish()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("valr-download: %d of %d days failed, rerun to retry:\n  %s",
+			len(failed), len(jobs), strings.Join(failed, "\n  "))
+	}
+	return nil
+}
+
+// export writes a day of a pair's trades, unless already written, and
+// returns the number of trades written. Days not yet over are written again
+// on each run.
+func (e *exporter) export(ctx context.Context, j job) (int, error) {
+	path := e.path(j)
+	if _, err := os.Stat(path); err == nil && time.Now().After(j.day.Add(day)) {
+		return 0, nil
+	}
+	trades, err := e.fetch(ctx, j)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeCSV(path, trades); err != nil {
+		return 0, err
+	}
+	return len(trades), nil
+}
+
+// fetch returns a day of a pair's trades, oldest first. Times are sent with
+// second precision and the end is inclusive, so trades outside the day are
+// filtered out.
+func (e *exporter) fetch(ctx context.Context, j job) ([]valr.TradeHistoryInfo, error) {
+	end := j.day.Add(day)
+	req := &valr.GetAuthTradeHistoryForPairRequest{
+		Pair:      j.pair,
+		Limit:     e.pageSize,
+		StartTime: j.day,
+		EndTime:   end,
+	}
+	var trades []valr.TradeHistoryInfo
+	for {
+		page, err := e.cl.GetAuthTradeHistoryForPairPage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Items {
+			if !t.TradedAt.Before(j.day) && t.TradedAt.Before(end) {
+				trades = append(trades, t)
+			}
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Skip = page.NextSkip
+	}
+	sort.SliceStable(trades, func(a, b int) bool {
+		if !trades[a].TradedAt.Equal(trades[b].TradedAt.Time) {
+			return trades[a].TradedAt.Before(trades[b].TradedAt.Time)
+		}
+		return trades[a].SequenceID < trades[b].SequenceID
+	})
+	return trades, nil
+}
+
+var csvHeader = []string{"id", "sequenceId", "tradedAt", "pair", "takerSide", "price", "quantity"}
+
+// writeCSV writes trades to a temporary file and renames it into place, so
+// a partial file is never mistaken for a completed day.
+func writeCSV(path string, trades []valr.TradeHistoryInfo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := csv.NewWriter(tmp)
+	w.Write(csvHeader)
+	for _, t := range trades {
+		w.Write([]string{
+			t.ID,
+			strconv.Itoa(t.SequenceID),
+			t.TradedAt.UTC().Format(time.RFC3339Nano),
+			t.Pair,
+			string(t.TakerSide),
+			t.Price.String(),
+			t.Quantity.String(),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// progress renders a progress bar with an ETA.
+type progress struct {
+	out   *os.File
+	width int
+
+	total   int
+	started time.Time
+	jobs    atomic.Int64
+	trades  atomic.Int64
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newProgress(out *os.File) *progress {
+	return &progress{out: out, width: 30}
+}
+
+func (p *progress) start(total int) {
+	if p == nil {
+		return
+	}
+	p.total, p.started = total, time.Now()
+	p.stop, p.stopped = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(p.stopped)
+		t := time.NewTicker(250 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-t.C:
+				p.render()
+			}
+		}
+	}()
+}
+
+func (p *progress) done(trades int) {
+	if p == nil {
+		return
+	}
+	p.jobs.Add(1)
+	p.trades.Add(int64(trades))
+}
+
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	p.render()
+	fmt.Fprintln(p.out)
+}
+
+func (p *progress) render() {
+	fmt.Fprint(p.out, "\r", p.line(time.Since(p.started)))
+}
+
+// line formats the bar for the given elapsed time.
+func (p *progress) line(elapsed time.Duration) string {
+	done := int(p.jobs.Load())
+	frac := 1.0
+	if p.total > 0 {
+		frac = float64(done) / float64(p.total)
+	}
+	filled := int(frac * float64(p.width))
+	bar := make([]byte, p.width)
+	for i := range bar {
+		switch {
+		case i < filled:
+			bar[i] = '='
+		case i == filled:
+			bar[i] = '>'
+		default:
+			bar[i] = ' '
+		}
+	}
+	eta := "--"
+	if done > 0 && done < p.total {
+		remaining := time.Duration(float64(elapsed) / float64(done) * float64(p.total-done))
+		eta = remaining.Round(time.Second).String()
+	} else if done == p.total {
+		eta = "0s"
+	}
+	return fmt.Sprintf("[%s] %d/%d days %3.0f%% %d trades ETA %s ",
+		bar, done, p.total, frac*100, p.trades.Load(), eta)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestExporter(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var trades []valr.TradeHistoryInfo
+	for _, pair := range []string{"BTCZAR", "ETHZAR"} {
+		for i := 0; i < 5; i++ {
+			// Trades 12 hours apart, the last just before the end of day 3.
+			at := day1.Add(time.Duration(i)*12*time.Hour + 500*time.Millisecond)
+			if i == 4 {
+				at = day1.Add(3*day - 500*time.Millisecond)
+			}
+			trades = append(trades, valr.TradeHistoryInfo{
+				ID: fmt.Sprintf("%s-%d", pair, i), SequenceID: i, Pair: pair, TakerSide: "buy",
+				Price: decimal.New(int64(100+i), 0), Quantity: decimal.New(1, 0), TradedAt: valr.NewTimestamp(at),
+			})
+		}
+	}
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		q := r.URL.Query()
+		pair := strings.Split(strings.TrimPrefix(r.URL.Path, "/marketdata/"), "/")[0]
+		start, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+		end, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+		skip, _ := strconv.Atoi(q.Get("skip"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var out []valr.TradeHistoryInfo
+		for i := len(trades) - 1; i >= 0; i-- {
+			tr := trades[i]
+			if tr.Pair == pair && !tr.TradedAt.Before(start) && !tr.TradedAt.After(end) {
+				out = append(out, tr)
+			}
+		}
+		out = out[min(skip, len(out)):]
+		out = out[:min(limit, len(out))]
+		if out == nil {
+			out = []valr.TradeHistoryInfo{}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	dir := t.TempDir()
+	e := &exporter{cl: cl, dir: dir, workers: 3, pageSize: 1}
+	pairs := []string{"BTCZAR", "ETHZAR"}
+	if err := e.run(context.Background(), pairs, day1, day1.Add(2*day)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	for _, pair := range pairs {
+		for d, want := range []int{2, 2, 1} {
+			path := filepath.Join(dir, pair, day1.Add(time.Duration(d)*day).Format(time.DateOnly)+".csv")
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			if len(lines) != want+1 || lines[0] != "id,sequenceId,tradedAt,pair,takerSide,price,quantity" {
+				t.Errorf("Unexpected %s:\n%s", path, b)
+			}
+		}
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "BTCZAR", "2024-01-01.csv"))
+	if want := "BTCZAR-0,0,2024-01-01T00:00:00.5Z,BTCZAR,buy,100,1\nBTCZAR-1,1,2024-01-01T12:00:00.5Z,BTCZAR,buy,101,1\n"; !strings.HasSuffix(string(b), want) {
+		t.Errorf("Expected trades oldest first, got:\n%s", b)
+	}
+
+	// Exported days are skipped when rerun.
+	calls.Store(0)
+	if err := e.run(context.Background(), pairs, day1, day1.Add(2*day)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("Expected no requests on rerun, got %d", n)
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	p := &progress{width: 10, total: 4}
+	p.jobs.Store(1)
+	p.trades.Store(250)
+	want := "[==>       ] 1/4 days  25% 250 trades ETA 30s "
+	if got := p.line(10 * time.Second); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,97 @@
+// Command valr-download exports trade history to CSV, one file per pair and
+// UTC day under the output directory (out/PAIR/YYYY-MM-DD.csv). Several pairs
+// are exported concurrently, sharing one rate limiter. Days already exported
+// are skipped, so an interrupted export resumes where it stopped.
+//
+// Usage:
+//
+//	valr-download -pairs BTCZAR,ETHZAR -from 2024-01-01 [-to 2024-01-31] [-out trades] [-workers 4]
+//	valr-download -all -from 2024-01-01
+//
+// Credentials are read from VA_KEY_ID and VA_SECRET, in a .env file or the
+// environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/credentials"
+)
+
+func main() {
+	var (
+		pairs    = flag.String("pairs", "", "comma separated pairs to export")
+		all      = flag.Bool("all", false, "export every active pair")
+		from     = flag.String("from", "", "first day to export, as YYYY-MM-DD")
+		to       = flag.String("to", "", "last day to export, as YYYY-MM-DD; yesterday if empty")
+		out      = flag.String("out", "trades", "output directory")
+		workers  = flag.Int("workers", 4, "number of days exported concurrently")
+		pageSize = flag.Int("page-size", 100, "trades requested per page")
+		quiet    = flag.Bool("quiet", false, "don't show progress")
+		envFile  = flag.String("env-file", ".env", "dotenv file with VA_KEY_ID and VA_SECRET")
+	)
+	flag.Parse()
+
+	start, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		log.Fatalf("valr-download: invalid -from: %v", err)
+	}
+	end := time.Now().UTC().Truncate(day).Add(-day)
+	if *to != "" {
+		if end, err = time.Parse(time.DateOnly, *to); err != nil {
+			log.Fatalf("valr-download: invalid -to: %v", err)
+		}
+	}
+	if (*pairs == "") == !*all {
+		log.Fatal("valr-download: set one of -pairs or -all")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	creds, err := credentials.Chain(
+		credentials.FromEnvFile(*envFile),
+		credentials.FromEnv(),
+	).Retrieve(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cl := valr.NewClient()
+	defer cl.Close()
+	if err := cl.SetAuth(creds.KeyID, creds.Secret); err != nil {
+		log.Fatal(err)
+	}
+
+	var pairList []string
+	if *all {
+		info, err := cl.GetCurrencyPairs(ctx, &valr.GetCurrencyPairsRequest{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range info {
+			if p.Active {
+				pairList = append(pairList, p.Symbol)
+			}
+		}
+	} else {
+		for _, p := range strings.Split(*pairs, ",") {
+			pairList = append(pairList, strings.ToUpper(strings.TrimSpace(p)))
+		}
+	}
+
+	e := &exporter{cl: cl, dir: *out, workers: *workers, pageSize: *pageSize}
+	if !*quiet {
+		e.progress = newProgress(os.Stderr)
+	}
+	if err := e.run(ctx, pairList, start, end); err != nil {
+		log.Fatal(err)
+	}
+}
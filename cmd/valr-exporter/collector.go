@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+// collector scrapes account and market metrics from the REST API on an
+// interval and serves the latest scrape, so Prometheus scrapes never count
+// against the API rate limit.
+type collector struct {
+	cl    *valr.Client
+	pairs map[string]bool
+
+	mu       sync.Mutex
+	last     *metricSet
+	scrapes  int
+	failures map[string]int
+
+	stream *streamHealth
+}
+
+func newCollector(cl *valr.Client, pairs []string, stream *streamHealth) *collector {
+	c := &collector{
+		cl:       cl,
+		pairs:    make(map[string]bool),
+		failures: make(map[string]int),
+		stream:   stream,
+	}
+	for _, p := range pairs {
+		c.pairs[strings.ToUpper(p)] = true
+	}
+	return c
+}
+
+func float(d decimal.Decimal) float64 {
+	f, _ := d.Float64()
+	return f
+}
+
+// scrape fetches every endpoint, recording the success of each, and returns
+// the metrics of those that succeeded.
+func (c *collector) scrape(ctx context.Context) *metricSet {
+	s := newMetricSet()
+	start := time.Now()
+
+	endpoint := func(name string, err error) bool {
+		ok := 0.0
+		if err == nil {
+			ok = 1
+		} else {
+			log.Printf("valr/exporter: scraping %s: %v", name, err)
+			c.mu.Lock()
+			c.failures[name]++
+			c.mu.Unlock()
+		}
+		s.gauge("valr_scrape_success", "Whether the last scrape of the endpoint succeeded.", ok, "endpoint", name)
+		return err == nil
+	}
+
+	balances, err := c.cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if endpoint("balances", err) {
+		for _, b := range balances {
+			s.gauge("valr_balance_total", "Total balance of the currency.", float(b.Total), "currency", b.Currency)
+			s.gauge("valr_balance_available", "Available balance of the currency.", float(b.Available), "currency", b.Currency)
+			s.gauge("valr_balance_reserved", "Balance of the currency reserved by open orders.", float(b.Reserved), "currency", b.Currency)
+		}
+	}
+
+	orders, err := c.cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if endpoint("open_orders", err) {
+		type key struct{ pair, side string }
+		counts := make(map[key]int)
+		for p := range c.pairs {
+			counts[key{p, "buy"}] = 0
+			counts[key{p, "sell"}] = 0
+		}
+		for _, o := range orders {
+			counts[key{o.Pair, strings.ToLower(string(o.Side))}]++
+		}
+		for k, n := range counts {
+			s.gauge("valr_open_orders", "Number of open orders.", float64(n), "pair", k.pair, "side", k.side)
+		}
+	}
+
+	positions, err := c.cl.GetOpenPositionsRequest(ctx, &valr.GetOpenPositionsRequest{})
+	if endpoint("positions", err) {
+		for _, p := range positions {
+			side := strings.ToLower(string(p.Side))
+			s.gauge("valr_position_quantity", "Quantity of the open position.", float(p.Quantity), "pair", p.Pair, "side", side)
+			s.gauge("valr_position_unrealised_pnl", "Unrealised profit and loss of the open position.", float(p.UnrealisedPnl), "pair", p.Pair, "side", side)
+		}
+	}
+
+	summaries, err := c.cl.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{})
+	if endpoint("market_summary", err) {
+		for _, m := range summaries {
+			if len(c.pairs) > 0 && !c.pairs[m.Pair] {
+				continue
+			}
+			s.gauge("valr_last_price", "Last traded price of the pair.", float(m.LastPrice), "pair", m.Pair)
+			if m.BidPrice.IsPositive() && m.AskPrice.IsPositive() {
+				spread := m.AskPrice.Sub(m.BidPrice)
+				mid := m.AskPrice.Add(m.BidPrice).Div(decimal.New(2, 0))
+				s.gauge("valr_spread", "Difference between the best ask and best bid.", float(spread), "pair", m.Pair)
+				s.gauge("valr_spread_bps", "Spread in basis points of the mid price.", float(spread.Div(mid).Mul(decimal.New(10000, 0))), "pair", m.Pair)
+			}
+		}
+	}
+
+	s.gauge("valr_scrape_duration_seconds", "Duration of the last scrape.", time.Since(start).Seconds())
+	s.gauge("valr_scrape_timestamp_seconds", "Unix time of the last scrape.", float64(start.Unix()))
+	return s
+}
+
+// run scrapes immediately and then every interval until ctx is done.
+func (c *collector) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s := c.scrape(ctx)
+		c.mu.Lock()
+		c.last = s
+		c.scrapes++
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// ServeHTTP serves the latest scrape and the stream health.
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := c.metrics()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.writeTo(w); err != nil {
+		log.Printf("valr/exporter: writing metrics: %v", err)
+	}
+}
+
+// metrics returns the latest scrape, the scrape counts and the stream
+// health.
+func (c *collector) metrics() *metricSet {
+	s := newMetricSet()
+	c.mu.Lock()
+	if c.last != nil {
+		s.merge(c.last)
+	}
+	s.counter("valr_scrapes_total", "Number of scrapes of the API.", float64(c.scrapes))
+	for name, n := range c.failures {
+		s.counter("valr_scrape_failures_total", "Number of failed scrapes of the endpoint.", float64(n), "endpoint", name)
+	}
+	c.mu.Unlock()
+	if c.stream != nil {
+		s.merge(c.stream.metrics())
+	}
+	return s
+}
+
+// streamHealth tracks the health of the trade stream.
+type streamHealth struct {
+	staleAfter time.Duration
+
+	conn     atomic.Pointer[streaming.Conn]
+	connects atomic.Uint64
+	trades   atomic.Uint64
+	errors   atomic.Uint64
+}
+
+// options returns the dial options recording the stream's health.
+func (h *streamHealth) options() []streaming.DialOption {
+	return []streaming.DialOption{
+		streaming.WithConnectCallback(func(c *streaming.Conn) {
+			h.conn.Store(c)
+			h.connects.Add(1)
+		}),
+		streaming.WithUpdateCallback(func(streaming.MessageTradeUpdate) {
+			h.trades.Add(1)
+		}),
+		streaming.WithErrorCallback(func(error) {
+			h.errors.Add(1)
+		}),
+	}
+}
+
+func (h *streamHealth) metrics() *metricSet {
+	s := newMetricSet()
+	up := 0.0
+	if c := h.conn.Load(); c != nil {
+		if last := c.LastMessage(); !last.IsZero() {
+			age := time.Since(last)
+			s.gauge("valr_stream_last_message_age_seconds", "Time since the last message on the stream.", age.Seconds())
+			if age < h.staleAfter {
+				up = 1
+			}
+		}
+	}
+	s.gauge("valr_stream_up", "Whether the stream has delivered a message recently.", up)
+	s.counter("valr_stream_connects_total", "Number of stream connections.", float64(h.connects.Load()))
+	s.counter("valr_stream_trades_total", "Number of trades received on the stream.", float64(h.trades.Load()))
+	s.counter("valr_stream_errors_total", "Number of stream errors.", float64(h.errors.Load()))
+	return s
+}
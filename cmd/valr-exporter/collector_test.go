@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/account/balances"):
+			w.Write([]byte(`[{"currency":"ZAR","available":"100","reserved":"50","total":"150"}]`))
+		case strings.HasSuffix(r.URL.Path, "/orders/open"):
+			w.Write([]byte(`[
+				{"orderId":"1","side":"buy","currencyPair":"BTCZAR"},
+				{"orderId":"2","side":"buy","currencyPair":"BTCZAR"}
+			]`))
+		case strings.HasSuffix(r.URL.Path, "/positions/open"):
+			http.Error(w, `{"code":-1,"message":"Margin not enabled"}`, http.StatusBadRequest)
+		case strings.HasSuffix(r.URL.Path, "/marketsummary"):
+			w.Write([]byte(`[
+				{"currencyPair":"BTCZAR","bidPrice":"999","askPrice":"1001","lastTradedPrice":"1000"},
+				{"currencyPair":"ETHZAR","bidPrice":"99","askPrice":"101","lastTradedPrice":"100"}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	c := newCollector(cl, []string{"btczar"}, &streamHealth{})
+	c.last = c.scrape(context.Background())
+	c.scrapes = 1
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE valr_balance_total gauge\n",
+		`valr_balance_total{currency="ZAR"} 150` + "\n",
+		`valr_balance_reserved{currency="ZAR"} 50` + "\n",
+		`valr_open_orders{pair="BTCZAR",side="buy"} 2` + "\n",
+		`valr_open_orders{pair="BTCZAR",side="sell"} 0` + "\n",
+		`valr_spread{pair="BTCZAR"} 2` + "\n",
+		`valr_spread_bps{pair="BTCZAR"} 20` + "\n",
+		`valr_scrape_success{endpoint="positions"} 0` + "\n",
+		`valr_scrape_success{endpoint="balances"} 1` + "\n",
+		`valr_scrape_failures_total{endpoint="positions"} 1` + "\n",
+		"valr_scrapes_total 1\n",
+		"valr_stream_up 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "ETHZAR") {
+		t.Errorf("Expected only the configured pairs, got:\n%s", body)
+	}
+}
+
+func TestMetricSetEscapes(t *testing.T) {
+	s := newMetricSet()
+	s.gauge("m", "help\nwith newline", 1.5, "l", `a"b\c`)
+	var b strings.Builder
+	if err := s.writeTo(&b); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := "# HELP m help\\nwith newline\n# TYPE m gauge\nm{l=\"a\\\"b\\\\c\"} 1.5\n"
+	if b.String() != want {
+		t.Errorf("Expected %q, got %q", want, b.String())
+	}
+}
@@ -0,0 +1,127 @@
+// Command valr-exporter exposes VALR account and market metrics to
+// Prometheus: balances, open order counts, position sizes, spreads and the
+// health of the trade stream. It also serves /healthz and /readyz probes.
+// With -statsd, the same metrics are also pushed to a StatsD or DogStatsD
+// server every interval, with labels sent as Datadog tags or, with
+// -statsd-format statsd, appended to the metric names.
+//
+// Usage:
+//
+//	valr-exporter [-listen :9876] [-interval 30s] [-pairs BTCZAR,ETHZAR] [-stream]
+//	valr-exporter -statsd localhost:8125 [-statsd-prefix valr.] [-statsd-tags env:prod,team:trading]
+//
+// Credentials are read from VA_KEY_ID and VA_SECRET, in a .env file or the
+// environment.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/credentials"
+	"github.com/donohutcheon/valr-go/health"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func main() {
+	var (
+		listen     = flag.String("listen", ":9876", "address to serve /metrics on")
+		interval   = flag.Duration("interval", 30*time.Second, "interval between scrapes of the API")
+		pairs      = flag.String("pairs", "", "comma separated pairs to report market metrics and stream trades for; all pairs if empty")
+		stream     = flag.Bool("stream", false, "stream trades of -pairs and report the stream's health")
+		staleAfter = flag.Duration("stale-after", time.Minute, "time without messages after which the stream is reported down")
+		envFile    = flag.String("env-file", ".env", "dotenv file with VA_KEY_ID and VA_SECRET")
+
+		statsdAddr   = flag.String("statsd", "", "address of a StatsD or DogStatsD server to also push metrics to")
+		statsdFormat = flag.String("statsd-format", string(formatDatadog), "StatsD dialect: datadog or statsd")
+		statsdPrefix = flag.String("statsd-prefix", "", "prefix of pushed metric names")
+		statsdTags   = flag.String("statsd-tags", "", "comma separated tags added to every pushed metric, e.g. env:prod")
+	)
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	creds, err := credentials.Chain(
+		credentials.FromEnvFile(*envFile),
+		credentials.FromEnv(),
+	).Retrieve(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	if err := cl.SetAuth(creds.KeyID, creds.Secret); err != nil {
+		log.Fatal(err)
+	}
+
+	var pairList []string
+	if *pairs != "" {
+		pairList = strings.Split(*pairs, ",")
+	}
+
+	var (
+		stats     *streamHealth
+		probeOpts []health.Option
+	)
+	if *stream {
+		if len(pairList) == 0 {
+			log.Fatal("valr-exporter: -stream requires -pairs")
+		}
+		stats = &streamHealth{staleAfter: *staleAfter}
+		conn, err := streaming.Dial(creds.KeyID, creds.Secret, stats.options()...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SubscribeToMarkets(pairList)
+		probeOpts = append(probeOpts, health.WithStream("trades", conn, *staleAfter))
+	}
+
+	c := newCollector(cl, pairList, stats)
+	go c.run(ctx, *interval)
+
+	if *statsdAddr != "" {
+		format, err := parseStatsDFormat(*statsdFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var tags []string
+		if *statsdTags != "" {
+			tags = strings.Split(*statsdTags, ",")
+		}
+		p, err := newStatsDPusher(*statsdAddr, format, *statsdPrefix, tags)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer p.Close()
+		go p.run(ctx, *interval, c.metrics)
+		log.Printf("valr-exporter: pushing metrics to StatsD at %s", *statsdAddr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c)
+	health.New(cl, probeOpts...).Register(mux)
+	srv := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("valr-exporter: serving metrics on %s/metrics", *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
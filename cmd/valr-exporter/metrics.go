@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sample is a single value of a metric.
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// metric is a gauge or counter in the Prometheus text exposition format.
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// metricSet collects metrics for a scrape.
+type metricSet struct {
+	metrics map[string]*metric
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{metrics: make(map[string]*metric)}
+}
+
+func (s *metricSet) add(kind, name, help string, value float64, labels ...string) {
+	m, ok := s.metrics[name]
+	if !ok {
+		m = &metric{name: name, help: help, kind: kind}
+		s.metrics[name] = m
+	}
+	var smp sample
+	for i := 0; i+1 < len(labels); i += 2 {
+		smp.labels = append(smp.labels, [2]string{labels[i], labels[i+1]})
+	}
+	smp.value = value
+	m.samples = append(m.samples, smp)
+}
+
+// gauge adds a sample of a gauge, with labels given as name, value pairs.
+func (s *metricSet) gauge(name, help string, value float64, labels ...string) {
+	s.add("gauge", name, help, value, labels...)
+}
+
+// counter adds a sample of a counter, with labels given as name, value
+// pairs.
+func (s *metricSet) counter(name, help string, value float64, labels ...string) {
+	s.add("counter", name, help, value, labels...)
+}
+
+// merge adds the metrics of o to s.
+func (s *metricSet) merge(o *metricSet) {
+	for name, m := range o.metrics {
+		if existing, ok := s.metrics[name]; ok {
+			existing.samples = append(existing.samples, m.samples...)
+			continue
+		}
+		cp := *m
+		cp.samples = append([]sample(nil), m.samples...)
+		s.metrics[name] = &cp
+	}
+}
+
+// writeTo writes the metrics in the text exposition format, sorted by name
+// and labels so scrapes are stable.
+func (s *metricSet) writeTo(w io.Writer) error {
+	names := make([]string, 0, len(s.metrics))
+	for name := range s.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := s.metrics[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.kind)
+		lines := make([]string, 0, len(m.samples))
+		for _, smp := range m.samples {
+			lines = append(lines, m.name+formatLabels(smp.labels)+" "+formatValue(smp.value))
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, l[0]+`="`+escapeLabel(l[1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
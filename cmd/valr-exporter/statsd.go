@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxPacketSize keeps StatsD datagrams within a typical MTU.
+const maxPacketSize = 1432
+
+// statsdFormat is the dialect metrics are pushed in.
+type statsdFormat string
+
+const (
+	// formatDatadog sends labels as DogStatsD tags.
+	formatDatadog statsdFormat = "datadog"
+	// formatStatsD appends label values to the metric name, for servers
+	// without tag support.
+	formatStatsD statsdFormat = "statsd"
+)
+
+func parseStatsDFormat(s string) (statsdFormat, error) {
+	switch f := statsdFormat(s); f {
+	case formatDatadog, formatStatsD:
+		return f, nil
+	default:
+		return "", fmt.Errorf("valr-exporter: unknown StatsD format %q", s)
+	}
+}
+
+// statsdPusher pushes metric sets to a StatsD or DogStatsD server over UDP,
+// for shops standardised on Datadog rather than Prometheus. Gauges are sent
+// as gauges and counters as the increase since the previous push.
+type statsdPusher struct {
+	conn   net.Conn
+	format statsdFormat
+	prefix string
+	// tags are added to every metric in the Datadog format.
+	tags []string
+
+	// last holds the value of each counter series at the previous push.
+	last map[string]float64
+}
+
+// newStatsDPusher returns a pusher sending to addr, e.g. "localhost:8125".
+func newStatsDPusher(addr string, format statsdFormat, prefix string, tags []string) (*statsdPusher, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("valr-exporter: dialling StatsD: %w", err)
+	}
+	return &statsdPusher{
+		conn:   conn,
+		format: format,
+		prefix: prefix,
+		tags:   tags,
+		last:   make(map[string]float64),
+	}, nil
+}
+
+// run pushes the metrics returned by metrics every interval until ctx is
+// done.
+func (p *statsdPusher) run(ctx context.Context, interval time.Duration, metrics func() *metricSet) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := p.push(metrics()); err != nil {
+			log.Printf("valr/exporter: pushing to StatsD: %v", err)
+		}
+	}
+}
+
+// push sends every sample of s, batching lines into datagrams.
+func (p *statsdPusher) push(s *metricSet) error {
+	var (
+		errs []error
+		buf  []byte
+	)
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		if _, err := p.conn.Write(buf); err != nil {
+			errs = append(errs, err)
+		}
+		buf = buf[:0]
+	}
+	for _, line := range p.lines(s) {
+		if len(buf) > 0 && len(buf)+1+len(line) > maxPacketSize {
+			flush()
+		}
+		if len(buf) > 0 {
+			buf = append(buf, '\n')
+		}
+		buf = append(buf, line...)
+	}
+	flush()
+	return errors.Join(errs...)
+}
+
+// lines formats the samples of s, sorted for stable output.
+func (p *statsdPusher) lines(s *metricSet) []string {
+	var lines []string
+	for _, m := range s.metrics {
+		for _, smp := range m.samples {
+			name, tags := p.prefix+m.name, p.tags
+			switch p.format {
+			case formatDatadog:
+				tags = append([]string(nil), tags...)
+				for _, l := range smp.labels {
+					tags = append(tags, sanitizeTag(l[0])+":"+sanitizeTag(l[1]))
+				}
+			default:
+				for _, l := range smp.labels {
+					name += "." + sanitizeName(l[1])
+				}
+			}
+
+			value, kind := smp.value, "g"
+			if m.kind == "counter" {
+				key := m.name + formatLabels(smp.labels)
+				prev, ok := p.last[key]
+				p.last[key] = value
+				if ok && value >= prev {
+					value -= prev
+				}
+				kind = "c"
+			}
+
+			line := name + ":" + formatValue(value) + "|" + kind
+			if len(tags) > 0 {
+				line += "|#" + strings.Join(tags, ",")
+			}
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func (p *statsdPusher) Close() error {
+	return p.conn.Close()
+}
+
+var (
+	tagReplacer  = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+	nameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+)
+
+func sanitizeTag(s string) string  { return tagReplacer.Replace(s) }
+func sanitizeName(s string) string { return nameReplacer.Replace(s) }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsDPusher(t *testing.T) {
+	srv, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer srv.Close()
+
+	receive := func() []string {
+		t.Helper()
+		var lines []string
+		buf := make([]byte, 64<<10)
+		srv.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		for {
+			n, _, err := srv.ReadFrom(buf)
+			if err != nil {
+				return lines
+			}
+			if n > maxPacketSize {
+				t.Errorf("Expected datagrams of at most %d bytes, got %d", maxPacketSize, n)
+			}
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+	}
+
+	set := func(trades float64) *metricSet {
+		s := newMetricSet()
+		s.gauge("valr_balance_total", "", 150, "currency", "ZAR")
+		s.counter("valr_stream_trades_total", "", trades)
+		return s
+	}
+
+	for _, tc := range []struct {
+		format statsdFormat
+		want   []string
+	}{
+		{formatDatadog, []string{
+			"valr.valr_balance_total:150|g|#env:test,currency:ZAR",
+			"valr.valr_stream_trades_total:10|c|#env:test",
+			"valr.valr_stream_trades_total:4|c|#env:test",
+		}},
+		{formatStatsD, []string{
+			"valr.valr_balance_total.ZAR:150|g|#env:test",
+			"valr.valr_stream_trades_total:10|c|#env:test",
+			"valr.valr_stream_trades_total:4|c|#env:test",
+		}},
+	} {
+		p, err := newStatsDPusher(srv.LocalAddr().String(), tc.format, "valr.", []string{"env:test"})
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if err := p.push(set(10)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		first := receive()
+		if err := p.push(set(14)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		second := receive()
+		p.Close()
+
+		if len(first) != 2 || first[0] != tc.want[0] || first[1] != tc.want[1] {
+			t.Errorf("Expected %q, got %q", tc.want[:2], first)
+		}
+		// Counters are sent as the increase since the previous push.
+		if len(second) != 2 || second[1] != tc.want[2] {
+			t.Errorf("Expected %q, got %q", tc.want[2], second)
+		}
+	}
+}
+
+func TestStatsDPusherBatching(t *testing.T) {
+	srv, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer srv.Close()
+
+	p, err := newStatsDPusher(srv.LocalAddr().String(), formatDatadog, "", nil)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer p.Close()
+
+	s := newMetricSet()
+	for i := 0; i < 200; i++ {
+		s.gauge("valr_open_orders", "", float64(i), "pair", strings.Repeat("X", i%7)+"ZAR", "side", "buy")
+	}
+	if err := p.push(s); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var lines, packets int
+	buf := make([]byte, 64<<10)
+	srv.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	for {
+		n, _, err := srv.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		if n > maxPacketSize {
+			t.Errorf("Expected datagrams of at most %d bytes, got %d", maxPacketSize, n)
+		}
+		packets++
+		lines += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	if lines != 200 || packets < 2 {
+		t.Errorf("Expected 200 lines in several datagrams, got %d in %d", lines, packets)
+	}
+}
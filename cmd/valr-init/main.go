@@ -0,0 +1,63 @@
+// Command valr-init generates a new trading bot project wiring together the
+// client, the market and account streams, the order manager with risk
+// limits, reloadable configuration, health probes and trading statistics,
+// with graceful shutdown, so a new bot starts from a correct skeleton and
+// only needs its strategy filled in.
+//
+// Usage:
+//
+//	valr-init -module github.com/me/mybot [-dir mybot] [-pairs BTCZAR,ETHZAR] [-replace ../valr-go] [-force]
+//
+// Then, in the new directory, run "go mod tidy" and fill in strategy.go.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func main() {
+	var (
+		module  = flag.String("module", "", "module path of the new project, e.g. github.com/me/mybot")
+		dir     = flag.String("dir", "", "directory to generate the project in; the last element of -module if empty")
+		pairs   = flag.String("pairs", "BTCZAR", "comma separated pairs traded")
+		replace = flag.String("replace", "", "local checkout of valr-go to build against")
+		force   = flag.Bool("force", false, "overwrite existing files")
+	)
+	flag.Parse()
+
+	if *module == "" || strings.ContainsAny(*module, " \t\\") {
+		log.Fatal("valr-init: set -module to a valid module path")
+	}
+	p := project{
+		Module:      *module,
+		Name:        path.Base(*module),
+		ValrVersion: valr.Version,
+		Replace:     *replace,
+	}
+	for _, pair := range strings.Split(*pairs, ",") {
+		if pair = strings.ToUpper(strings.TrimSpace(pair)); pair != "" {
+			p.Pairs = append(p.Pairs, pair)
+		}
+	}
+	if len(p.Pairs) == 0 {
+		log.Fatal("valr-init: set -pairs")
+	}
+	if *dir == "" {
+		*dir = p.Name
+	}
+
+	names, err := generate(*dir, p, *force)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range names {
+		fmt.Printf("created %s\n", path.Join(*dir, name))
+	}
+	fmt.Printf("\nNext:\n  cd %s\n  go mod tidy\n  cp .env.example .env\n", *dir)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// files maps each generated file to its template.
+var files = map[string]string{
+	"go.mod":       "go.mod.tmpl",
+	"main.go":      "main.go.tmpl",
+	"config.go":    "config.go.tmpl",
+	"strategy.go":  "strategy.go.tmpl",
+	"config.json":  "config.json.tmpl",
+	".env.example": "env.example.tmpl",
+	".gitignore":   "gitignore.tmpl",
+	"README.md":    "README.md.tmpl",
+}
+
+// project describes the project to generate.
+type project struct {
+	// Module is the module path, e.g. github.com/me/mybot.
+	Module string
+	// Name is the name of the command, the last element of Module by
+	// default.
+	Name  string
+	Pairs []string
+	// ValrVersion is the version of valr-go required.
+	ValrVersion string
+	// Replace, if set, is a local checkout of valr-go to build against.
+	Replace string
+}
+
+// generate writes the project's files to dir, returning their names. No
+// file is written if any would overwrite an existing file, unless force is
+// set. Go sources are formatted.
+func generate(dir string, p project, force bool) ([]string, error) {
+	tmpl, err := template.ParseFS(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]byte, len(files))
+	var names []string
+	for name, src := range files {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, src, p); err != nil {
+			return nil, fmt.Errorf("valr-init: rendering %s: %w", name, err)
+		}
+		b := buf.Bytes()
+		if strings.HasSuffix(name, ".go") {
+			if b, err = format.Source(b); err != nil {
+				return nil, fmt.Errorf("valr-init: formatting %s: %w", name, err)
+			}
+		}
+		out[name] = b
+		names = append(names, name)
+
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil && !force {
+			return nil, fmt.Errorf("valr-init: %s already exists, use -force to overwrite", filepath.Join(dir, name))
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	for name, b := range out {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
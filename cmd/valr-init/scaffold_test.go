@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mybot")
+	p := project{
+		Module:      "example.com/me/mybot",
+		Name:        "mybot",
+		Pairs:       []string{"BTCZAR", "ETHZAR"},
+		ValrVersion: "1.2.3",
+	}
+	names, err := generate(dir, p, false)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(names) != len(files) {
+		t.Errorf("Expected %d files, got %q", len(files), names)
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			t.Fatalf("Expected %s to parse, got %v", name, err)
+		}
+		if f.Name.Name != "main" {
+			t.Errorf("Expected package main in %s, got %s", name, f.Name.Name)
+		}
+	}
+
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, want := range []string{"module example.com/me/mybot\n", "require github.com/donohutcheon/valr-go v1.2.3\n"} {
+		if !strings.Contains(string(mod), want) {
+			t.Errorf("Expected %q in go.mod, got %q", want, mod)
+		}
+	}
+	if strings.Contains(string(mod), "replace") {
+		t.Errorf("Expected no replace directive, got %q", mod)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var cfg struct {
+		Pairs       []string          `json:"pairs"`
+		MaxPosition map[string]string `json:"maxPosition"`
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, b)
+	}
+	if strings.Join(cfg.Pairs, ",") != "BTCZAR,ETHZAR" || len(cfg.MaxPosition) != 2 {
+		t.Errorf("Expected both pairs configured, got %+v", cfg)
+	}
+
+	// Existing files are kept unless forced.
+	if err := os.WriteFile(filepath.Join(dir, "strategy.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	p.Replace = "../valr-go"
+	if _, err := generate(dir, p, false); err == nil {
+		t.Fatal("Expected an error for existing files")
+	}
+	if mod, _ := os.ReadFile(filepath.Join(dir, "go.mod")); strings.Contains(string(mod), "replace") {
+		t.Error("Expected go.mod to be left unchanged")
+	}
+	if _, err := generate(dir, p, true); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	mod, _ = os.ReadFile(filepath.Join(dir, "go.mod"))
+	if !strings.Contains(string(mod), "replace github.com/donohutcheon/valr-go => ../valr-go\n") {
+		t.Errorf("Expected a replace directive, got %q", mod)
+	}
+}
@@ -0,0 +1,132 @@
+// Command valr-orders is a terminal UI listing the account's open orders,
+// kept up to date by the account stream, for cancelling or moving orders by
+// hand, e.g. during an incident. Orders are cancelled and replaced through
+// the order manager, so its throttles and checks apply.
+//
+// Keys:
+//
+//	j/k or arrows  select an order
+//	c              cancel the selected order
+//	m              replace the selected order with a new price and quantity
+//	x              cancel every listed order
+//	q              quit
+//
+// Usage:
+//
+//	valr-orders [-pairs BTCZAR,ETHZAR] [-timeout 10s]
+//
+// Credentials are read from VA_KEY_ID and VA_SECRET, in a .env file or the
+// environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/credentials"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func main() {
+	var (
+		pairs   = flag.String("pairs", "", "comma separated pairs to list; all pairs if empty")
+		timeout = flag.Duration("timeout", 10*time.Second, "timeout of each cancel or replace")
+		envFile = flag.String("env-file", ".env", "dotenv file with VA_KEY_ID and VA_SECRET")
+	)
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	creds, err := credentials.Chain(
+		credentials.FromEnvFile(*envFile),
+		credentials.FromEnv(),
+	).Retrieve(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cl := valr.NewClient()
+	defer cl.Close()
+	if err := cl.SetAuth(creds.KeyID, creds.Secret); err != nil {
+		log.Fatal(err)
+	}
+
+	var pairList []string
+	if *pairs != "" {
+		for _, p := range strings.Split(*pairs, ",") {
+			pairList = append(pairList, strings.ToUpper(strings.TrimSpace(p)))
+		}
+	}
+	m := ordermanager.New(cl)
+	u := newUI(m, pairList, *timeout)
+
+	restore, err := rawMode()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer restore()
+	os.Stdout.WriteString(hideCursor)
+	defer os.Stdout.WriteString(showCursor + clearScreen)
+	// Log to the status line rather than over the screen.
+	log.SetOutput(statusWriter{u})
+
+	conn, err := streaming.DialAccount(creds.KeyID, creds.Secret,
+		streaming.WithAccountSnapshot(cl),
+		streaming.WithOpenOrdersUpdateCallback(func(msg streaming.MessageOpenOrdersUpdate) {
+			u.setOrders(msg.Data, time.Now())
+		}),
+	)
+	if err != nil {
+		restore()
+		log.SetOutput(os.Stderr)
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	keys := make(chan []string)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				cancel()
+				return
+			}
+			keys <- decodeKeys(buf[:n])
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		u.render(os.Stdout, time.Now())
+		select {
+		case ks := <-keys:
+			for _, k := range ks {
+				if u.handleKey(ctx, k) {
+					return
+				}
+			}
+		case <-u.redraw:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// statusWriter shows log output in the status line of the UI.
+type statusWriter struct{ u *ui }
+
+func (w statusWriter) Write(b []byte) (int, error) {
+	w.u.setStatus("%s", strings.TrimSpace(string(b)))
+	return len(b), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ANSI escape sequences used to draw the screen.
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	reverse     = "\x1b[7m"
+	bold        = "\x1b[1m"
+	reset       = "\x1b[0m"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// rawMode switches the terminal on stdin to deliver keystrokes as they are
+// typed, without echoing them, and returns a function restoring its
+// previous mode. It relies on stty, so works on Unix terminals only.
+func rawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("valr-orders: stdin is not a terminal: %w", err)
+	}
+	if _, err := stty("cbreak", "-echo"); err != nil {
+		return nil, fmt.Errorf("valr-orders: setting terminal mode: %w", err)
+	}
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Keys that aren't printable characters.
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyEnter     = "enter"
+	keyEsc       = "esc"
+	keyBackspace = "backspace"
+)
+
+// decodeKeys splits input read from the terminal into keys: the names
+// above for special keys and the character for everything else.
+// Unrecognised escape sequences are dropped.
+func decodeKeys(b []byte) []string {
+	var keys []string
+	for s := string(b); s != ""; {
+		switch {
+		case strings.HasPrefix(s, "\x1b[A"), strings.HasPrefix(s, "\x1bOA"):
+			keys, s = append(keys, keyUp), s[3:]
+		case strings.HasPrefix(s, "\x1b[B"), strings.HasPrefix(s, "\x1bOB"):
+			keys, s = append(keys, keyDown), s[3:]
+		case strings.HasPrefix(s, "\x1b["):
+			// Skip to the final byte of the sequence.
+			i := strings.IndexFunc(s[2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+			if i < 0 {
+				return keys
+			}
+			s = s[2+i+1:]
+		case s[0] == 0x1b:
+			keys, s = append(keys, keyEsc), s[1:]
+		case s[0] == '\r' || s[0] == '\n':
+			keys, s = append(keys, keyEnter), s[1:]
+		case s[0] == 0x7f || s[0] == '\b':
+			keys, s = append(keys, keyBackspace), s[1:]
+		default:
+			r := []rune(s)[0]
+			keys, s = append(keys, string(r)), s[len(string(r)):]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+// actions are the order mutations made from the UI, implemented by
+// ordermanager.Manager.
+type actions interface {
+	Cancel(ctx context.Context, pair, orderID string) error
+	Replace(ctx context.Context, orderID string, req *valr.PostLimitOrderRequest) (*ordermanager.Execution, error)
+}
+
+// mode is what keystrokes currently do.
+type mode int
+
+const (
+	modeList mode = iota
+	modeConfirmCancel
+	modeConfirmCancelAll
+	modeEditPrice
+	modeEditQuantity
+)
+
+// ui is the state of the order screen. Orders are replaced as the account
+// stream reports them, and actions run in the background, reporting their
+// outcome in the status line.
+type ui struct {
+	act     actions
+	pairs   map[string]bool // nil for all pairs
+	timeout time.Duration
+	// redraw is signalled whenever the screen needs to be drawn again.
+	redraw chan struct{}
+	// pending tracks actions in progress.
+	pending sync.WaitGroup
+
+	mu       sync.Mutex
+	orders   []streaming.OpenOrder
+	selected int
+	mode     mode
+	input    string
+	price    decimal.Decimal
+	status   string
+	updated  time.Time
+}
+
+func newUI(act actions, pairs []string, timeout time.Duration) *ui {
+	u := &ui{act: act, timeout: timeout, redraw: make(chan struct{}, 1)}
+	if len(pairs) > 0 {
+		u.pairs = make(map[string]bool)
+		for _, p := range pairs {
+			u.pairs[p] = true
+		}
+	}
+	return u
+}
+
+func (u *ui) changed() {
+	select {
+	case u.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// setOrders replaces the listed orders, keeping the selected order
+// selected if it is still open.
+func (u *ui) setOrders(orders []streaming.OpenOrder, at time.Time) {
+	var shown []streaming.OpenOrder
+	for _, o := range orders {
+		if u.pairs == nil || u.pairs[o.CurrencyPair] {
+			shown = append(shown, o)
+		}
+	}
+	sort.Slice(shown, func(i, j int) bool {
+		a, b := shown[i], shown[j]
+		if a.CurrencyPair != b.CurrencyPair {
+			return a.CurrencyPair < b.CurrencyPair
+		}
+		if a.Side != b.Side {
+			return a.Side < b.Side
+		}
+		if !a.Price.Equal(b.Price) {
+			return a.Price.GreaterThan(b.Price)
+		}
+		return a.OrderID < b.OrderID
+	})
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if sel, ok := u.current(); ok {
+		u.selected = 0
+		for i, o := range shown {
+			if o.OrderID == sel.OrderID {
+				u.selected = i
+			}
+		}
+	}
+	u.orders = shown
+	u.selected = min(u.selected, max(len(shown)-1, 0))
+	if _, ok := u.current(); !ok && u.mode != modeList && u.mode != modeConfirmCancelAll {
+		// The order being acted on has closed.
+		u.mode, u.input = modeList, ""
+	}
+	u.updated = at
+	u.changed()
+}
+
+func (u *ui) setStatus(format string, args ...any) {
+	u.mu.Lock()
+	u.status = fmt.Sprintf(format, args...)
+	u.mu.Unlock()
+	u.changed()
+}
+
+// current returns the selected order.
+func (u *ui) current() (streaming.OpenOrder, bool) {
+	if u.selected < 0 || u.selected >= len(u.orders) {
+		return streaming.OpenOrder{}, false
+	}
+	return u.orders[u.selected], true
+}
+
+// handleKey acts on a keystroke, returning true to quit.
+func (u *ui) handleKey(ctx context.Context, key string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	defer u.changed()
+
+	switch u.mode {
+	case modeConfirmCancel:
+		u.mode = modeList
+		if o, ok := u.current(); ok && key == "y" {
+			u.cancel(ctx, []streaming.OpenOrder{o})
+		}
+	case modeConfirmCancelAll:
+		u.mode = modeList
+		if key == "y" {
+			u.cancel(ctx, append([]streaming.OpenOrder(nil), u.orders...))
+		}
+	case modeEditPrice, modeEditQuantity:
+		u.edit(ctx, key)
+	default:
+		switch key {
+		case "q":
+			return true
+		case "k", keyUp:
+			u.selected = max(u.selected-1, 0)
+		case "j", keyDown:
+			u.selected = min(u.selected+1, max(len(u.orders)-1, 0))
+		case "c":
+			if _, ok := u.current(); ok {
+				u.mode = modeConfirmCancel
+			}
+		case "x":
+			if len(u.orders) > 0 {
+				u.mode = modeConfirmCancelAll
+			}
+		case "m":
+			if o, ok := u.current(); ok {
+				u.mode, u.input = modeEditPrice, o.Price.String()
+			}
+		}
+	}
+	return false
+}
+
+// edit handles a keystroke while entering the new price or quantity of
+// the selected order.
+func (u *ui) edit(ctx context.Context, key string) {
+	o, ok := u.current()
+	switch {
+	case !ok || key == keyEsc:
+		u.mode, u.input = modeList, ""
+	case key == keyBackspace:
+		if u.input != "" {
+			u.input = u.input[:len(u.input)-1]
+		}
+	case key == keyEnter:
+		v, err := decimal.NewFromString(u.input)
+		if err != nil || !v.IsPositive() {
+			u.status = fmt.Sprintf("Invalid amount %q", u.input)
+			return
+		}
+		if u.mode == modeEditPrice {
+			u.price = v
+			u.mode, u.input = modeEditQuantity, o.RemainingQuantity.String()
+			return
+		}
+		u.mode, u.input = modeList, ""
+		u.replace(ctx, o, u.price, v)
+	case len(key) == 1 && strings.ContainsAny(key, "0123456789."):
+		u.input += key
+	}
+}
+
+// cancel cancels orders in the background.
+func (u *ui) cancel(ctx context.Context, orders []streaming.OpenOrder) {
+	u.status = fmt.Sprintf("Cancelling %d order(s)...", len(orders))
+	u.pending.Add(1)
+	go func() {
+		defer u.pending.Done()
+		ctx, cancel := context.WithTimeout(ctx, u.timeout)
+		defer cancel()
+		var failed []string
+		for _, o := range orders {
+			if err := u.act.Cancel(ctx, o.CurrencyPair, o.OrderID); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", o.OrderID, err))
+			}
+		}
+		if len(failed) > 0 {
+			u.setStatus("Failed to cancel %d of %d order(s): %s", len(failed), len(orders), strings.Join(failed, "; "))
+			return
+		}
+		u.setStatus("Requested cancellation of %d order(s)", len(orders))
+	}()
+}
+
+// replace moves o to a new price and quantity in the background.
+func (u *ui) replace(ctx context.Context, o streaming.OpenOrder, price, qty decimal.Decimal) {
+	side := valr.BUY
+	if o.Side == valr.ResponseSideSell {
+		side = valr.SELL
+	}
+	req := &valr.PostLimitOrderRequest{
+		Pair:     o.CurrencyPair,
+		Side:     side,
+		Price:    price,
+		Quantity: qty,
+	}
+	u.status = fmt.Sprintf("Replacing %s...", o.OrderID)
+	u.pending.Add(1)
+	go func() {
+		defer u.pending.Done()
+		ctx, cancel := context.WithTimeout(ctx, u.timeout)
+		defer cancel()
+		exec, err := u.act.Replace(ctx, o.OrderID, req)
+		if err != nil {
+			u.setStatus("Failed to replace %s: %v", o.OrderID, err)
+			return
+		}
+		u.setStatus("Replaced %s with %s: %s %s @ %s", o.OrderID, exec.OrderID, side, qty, price)
+	}()
+}
+
+// render draws the screen as of now.
+func (u *ui) render(w io.Writer, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	fmt.Fprintf(&b, "%svalr-orders%s  %d open order(s)", bold, reset, len(u.orders))
+	if !u.updated.IsZero() {
+		fmt.Fprintf(&b, "  updated %s", u.updated.Local().Format(time.TimeOnly))
+	}
+	b.WriteString("\r\n\r\n")
+	row := "%-2s%-10s %-4s %16s %16s %16s %7s %9s  %s\r\n"
+	fmt.Fprintf(&b, row, "", "PAIR", "SIDE", "PRICE", "REMAINING", "ORIGINAL", "FILLED", "AGE", "ORDER ID")
+	for i, o := range u.orders {
+		marker := ""
+		if i == u.selected {
+			marker = ">"
+			b.WriteString(reverse)
+		}
+		fmt.Fprintf(&b, "%-2s%-10s %-4s %16s %16s %16s %6s%% %9s  %s", marker,
+			o.CurrencyPair, o.Side, o.Price, o.RemainingQuantity, o.OriginalQuantity,
+			o.FilledPercentage.StringFixed(1), age(now, o.CreatedAt.Time), o.OrderID)
+		if i == u.selected {
+			b.WriteString(reset)
+		}
+		b.WriteString("\r\n")
+	}
+	if len(u.orders) == 0 {
+		b.WriteString("  No open orders\r\n")
+	}
+	b.WriteString("\r\n")
+
+	o, _ := u.current()
+	switch u.mode {
+	case modeConfirmCancel:
+		fmt.Fprintf(&b, "Cancel %s %s %s @ %s? (y/n)", o.Side, o.RemainingQuantity, o.CurrencyPair, o.Price)
+	case modeConfirmCancelAll:
+		fmt.Fprintf(&b, "Cancel all %d listed orders? (y/n)", len(u.orders))
+	case modeEditPrice:
+		fmt.Fprintf(&b, "New price of %s: %s_  (enter to continue, esc to abort)", o.OrderID, u.input)
+	case modeEditQuantity:
+		fmt.Fprintf(&b, "New quantity at %s: %s_  (enter to replace, esc to abort)", u.price, u.input)
+	default:
+		b.WriteString(u.status)
+	}
+	b.WriteString("\r\n\r\n")
+	b.WriteString("j/k select  c cancel  m modify  x cancel all  q quit\r\n")
+	io.WriteString(w, b.String())
+}
+
+// age formats the time since t to the second.
+func age(now, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return now.Sub(t).Truncate(time.Second).String()
+}
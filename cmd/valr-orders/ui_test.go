@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+type fakeActions struct {
+	mu        sync.Mutex
+	cancelled []string
+	replaced  []*valr.PostLimitOrderRequest
+	err       error
+}
+
+func (a *fakeActions) Cancel(_ context.Context, pair, orderID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cancelled = append(a.cancelled, pair+"/"+orderID)
+	return a.err
+}
+
+func (a *fakeActions) Replace(_ context.Context, orderID string, req *valr.PostLimitOrderRequest) (*ordermanager.Execution, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.replaced = append(a.replaced, req)
+	return &ordermanager.Execution{OrderID: "new-" + orderID}, a.err
+}
+
+func openOrders() []streaming.OpenOrder {
+	order := func(id, pair string, side valr.ResponseSide, price string) streaming.OpenOrder {
+		return streaming.OpenOrder{
+			OrderID: id, CurrencyPair: pair, Side: side,
+			Price:             decimal.RequireFromString(price),
+			RemainingQuantity: decimal.RequireFromString("0.5"),
+			OriginalQuantity:  decimal.RequireFromString("1"),
+		}
+	}
+	return []streaming.OpenOrder{
+		order("o3", "ETHZAR", valr.ResponseSideBuy, "50"),
+		order("o1", "BTCZAR", valr.ResponseSideSell, "110"),
+		order("o2", "BTCZAR", valr.ResponseSideBuy, "100"),
+		order("o4", "XRPZAR", valr.ResponseSideBuy, "10"),
+	}
+}
+
+func press(u *ui, keys ...string) {
+	for _, k := range keys {
+		u.handleKey(context.Background(), k)
+	}
+	u.pending.Wait()
+}
+
+func TestUICancel(t *testing.T) {
+	act := new(fakeActions)
+	u := newUI(act, []string{"BTCZAR", "ETHZAR"}, time.Second)
+	u.setOrders(openOrders(), time.Now())
+
+	// Sorted by pair then side: o2, o1, o3.
+	press(u, "j", "c", "y")
+	if strings.Join(act.cancelled, ",") != "BTCZAR/o1" {
+		t.Errorf("Expected o1 to be cancelled, got %q", act.cancelled)
+	}
+
+	// The selection follows the order as the list changes.
+	orders := openOrders()
+	u.setOrders(orders[:1], time.Now())
+	u.setOrders(orders, time.Now())
+	press(u, "c", "n")
+	if len(act.cancelled) != 1 {
+		t.Errorf("Expected no cancellation, got %q", act.cancelled)
+	}
+
+	act.err = errors.New("boom")
+	press(u, "x", "y")
+	if len(act.cancelled) != 4 {
+		t.Errorf("Expected the 3 listed orders to be cancelled, got %q", act.cancelled)
+	}
+	var b strings.Builder
+	u.render(&b, time.Now())
+	if !strings.Contains(b.String(), "Failed to cancel 3 of 3 order(s)") {
+		t.Errorf("Expected the failure in the status line, got %q", b.String())
+	}
+	if strings.Contains(b.String(), "XRPZAR") {
+		t.Errorf("Expected XRPZAR to be filtered out, got %q", b.String())
+	}
+}
+
+func TestUIReplace(t *testing.T) {
+	act := new(fakeActions)
+	u := newUI(act, nil, time.Second)
+	u.setOrders(openOrders(), time.Now())
+
+	press(u, keyDown, "m", keyBackspace, keyBackspace, keyBackspace, "1", "2", "a", keyEnter)
+	press(u, keyBackspace, keyBackspace, keyBackspace, keyEnter)
+	if len(act.replaced) != 0 {
+		t.Fatalf("Expected no replacement without a quantity, got %v", act.replaced)
+	}
+	press(u, "2", keyEnter)
+	if len(act.replaced) != 1 {
+		t.Fatalf("Expected a replacement, got %v", act.replaced)
+	}
+	req := act.replaced[0]
+	if req.Pair != "BTCZAR" || req.Side != valr.SELL || req.Price.String() != "12" || req.Quantity.String() != "2" {
+		t.Errorf("Expected SELL 2 BTCZAR @ 12, got %+v", req)
+	}
+
+	var b strings.Builder
+	u.render(&b, time.Now())
+	if !strings.Contains(b.String(), "Replaced o1 with new-o1") {
+		t.Errorf("Expected the replacement in the status line, got %q", b.String())
+	}
+
+	press(u, "m", keyEsc, "q")
+	if u.mode != modeList {
+		t.Errorf("Expected editing to be aborted, got mode %d", u.mode)
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	got := decodeKeys([]byte("j\x1b[A\x1b[B\x1b[5~\r\x7f\x1bq"))
+	want := []string{"j", keyUp, keyDown, keyEnter, keyBackspace, keyEsc, "q"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,131 @@
+// Command valr-sim serves a simulation of the VALR REST and websocket APIs,
+// so integration environments can run fully offline. Markets trade and
+// their books move every interval according to a scenario: trending,
+// choppy or halted. Point clients at it with valr.MockEnvironment.
+//
+// Usage:
+//
+//	valr-sim [-listen :8080] [-pairs BTCZAR=1000000,ETHZAR=50000] [-scenario choppy] [-interval 1s] [-script events.json]
+//
+// A script plays timed events, such as fills, disconnects and injected
+// errors, from the time the server starts; see sim.Script for the format.
+//
+// Any API key is accepted unless -key and -secret are given, in which case
+// requests must be signed with them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/shopspring/decimal"
+)
+
+func main() {
+	var (
+		listen   = flag.String("listen", ":8080", "address to serve the API on")
+		pairs    = flag.String("pairs", "BTCZAR=1000000,ETHZAR=50000", "comma separated pairs to simulate, each with its starting price")
+		scenario = flag.String("scenario", "choppy", "market scenario: trending, choppy or halted")
+		drift    = flag.Float64("drift", 0, "fractional price change per tick of trending markets; negative for a downtrend")
+		interval = flag.Duration("interval", time.Second, "interval between market ticks")
+		seed     = flag.Int64("seed", time.Now().UnixNano(), "random seed, for repeatable runs")
+		balances = flag.String("balances", "ZAR=100000,BTC=1", "comma separated starting balances of the account")
+		key      = flag.String("key", "", "API key that requests must be signed with")
+		secret   = flag.String("secret", "", "API secret that requests must be signed with")
+		script   = flag.String("script", "", "JSON file of scripted events to play")
+	)
+	flag.Parse()
+
+	sc, err := sim.ParseScenario(*scenario)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []sim.Option{sim.WithSeed(*seed)}
+	markets, err := parseAmounts(*pairs)
+	if err != nil {
+		log.Fatalf("valr-sim: invalid -pairs: %v", err)
+	}
+	for _, m := range markets {
+		opts = append(opts, sim.WithMarket(sim.Market{Pair: m.name, Price: m.amount, Scenario: sc, Drift: *drift}))
+	}
+	funds, err := parseAmounts(*balances)
+	if err != nil {
+		log.Fatalf("valr-sim: invalid -balances: %v", err)
+	}
+	for _, b := range funds {
+		opts = append(opts, sim.WithBalance(b.name, b.amount))
+	}
+	if *key != "" || *secret != "" {
+		opts = append(opts, sim.WithCredentials(*key, *secret))
+	}
+
+	var events *sim.Script
+	if *script != "" {
+		if events, err = sim.LoadScript(*script); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	s := sim.New(opts...)
+	go s.Run(ctx.Done(), *interval)
+	if events != nil {
+		go func() {
+			if err := s.Play(ctx.Done(), events); err != nil {
+				log.Printf("valr-sim: script stopped: %v", err)
+				return
+			}
+			log.Printf("valr-sim: script done")
+		}()
+	}
+
+	srv := &http.Server{Addr: *listen, Handler: s}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("valr-sim: simulating %s (%s) on %s", strings.Join(s.Pairs(), ","), sc, *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+type namedAmount struct {
+	name   string
+	amount decimal.Decimal
+}
+
+// parseAmounts parses a list like "BTCZAR=1000000,ETHZAR=50000".
+func parseAmounts(s string) ([]namedAmount, error) {
+	var out []namedAmount
+	for _, item := range strings.Split(s, ",") {
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not NAME=AMOUNT", item)
+		}
+		amount, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", item, err)
+		}
+		out = append(out, namedAmount{name: strings.ToUpper(name), amount: amount})
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+// Command valr-stream records market data from the VALR trade stream to
+// files, one directory per event under the output directory, as newline
+// delimited JSON or CSV. Files are rotated every -rotate period and when
+// they reach -max-size, and named by the UTC start of their period, e.g.
+// out/trades/2024-01-01T10-00-00Z.jsonl.
+//
+// Events are trades, summaries (market summaries) and books (the best
+// -depth levels of each side of the aggregated order book, recorded when
+// they change).
+//
+// Usage:
+//
+//	valr-stream -pairs BTCZAR,ETHZAR [-events trades,books] [-format jsonl|csv] [-out stream] [-rotate 1h] [-max-size 104857600]
+//
+// Credentials are read from VA_KEY_ID and VA_SECRET, in a .env file or the
+// environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/donohutcheon/valr-go/credentials"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func main() {
+	var (
+		pairs   = flag.String("pairs", "", "comma separated pairs to record")
+		events  = flag.String("events", eventTrades, "comma separated events to record: trades, summaries, books")
+		depth   = flag.Int("depth", 10, "levels of each side of the book recorded")
+		fmtName = flag.String("format", string(formatJSON), "file format: jsonl or csv")
+		out     = flag.String("out", "stream", "output directory")
+		rotate  = flag.Duration("rotate", time.Hour, "period after which a new file is started; 0 to never rotate by time")
+		maxSize = flag.Int64("max-size", 100<<20, "size in bytes after which a new file is started; 0 for no limit")
+		envFile = flag.String("env-file", ".env", "dotenv file with VA_KEY_ID and VA_SECRET")
+	)
+	flag.Parse()
+
+	if *pairs == "" {
+		log.Fatal("valr-stream: -pairs is required")
+	}
+	var pairList []string
+	for _, p := range strings.Split(*pairs, ",") {
+		pairList = append(pairList, strings.ToUpper(strings.TrimSpace(p)))
+	}
+	f, err := parseFormat(*fmtName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sub := streaming.NewSubscription()
+	recordEvents := make(map[string]bool)
+	for _, ev := range strings.Split(*events, ",") {
+		switch ev = strings.TrimSpace(ev); ev {
+		case eventTrades:
+			sub.Trades(pairList...)
+		case eventSummaries:
+			sub.MarketSummary(pairList...)
+		case eventBooks:
+			sub.AggregatedBook(pairList...)
+		default:
+			log.Fatalf("valr-stream: unknown event %q", ev)
+		}
+		recordEvents[ev] = true
+	}
+	if err := sub.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	creds, err := credentials.Chain(
+		credentials.FromEnvFile(*envFile),
+		credentials.FromEnv(),
+	).Retrieve(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rec := newRecorder(*out, f, *rotate, *maxSize)
+	defer func() {
+		if err := rec.Close(); err != nil {
+			log.Printf("valr-stream: %v", err)
+		}
+	}()
+	write := func(r record) {
+		if err := rec.write(r); err != nil {
+			log.Print(err)
+			cancel()
+		}
+	}
+
+	var opts []streaming.DialOption
+	if recordEvents[eventTrades] {
+		opts = append(opts, streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+			write(newTradeRecord(time.Now(), m))
+		}))
+	}
+	if recordEvents[eventSummaries] {
+		opts = append(opts, streaming.WithMarketSummaryCallback(func(m streaming.MessageMarketSummaryUpdate) {
+			write(newSummaryRecord(time.Now(), m))
+		}))
+	}
+	conn, err := streaming.Dial(creds.KeyID, creds.Secret, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	if recordEvents[eventBooks] {
+		for _, pair := range pairList {
+			s := conn.Books().SubscribeTopN(pair, *depth)
+			defer s.Close()
+			go func() {
+				for {
+					select {
+					case top := <-s.C:
+						write(newBookRecord(time.Now(), top))
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+	}
+
+	if err := conn.Subscribe(sub).Wait(ctx); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+	log.Printf("valr-stream: recording %s of %s to %s", *events, *pairs, *out)
+
+	flush := time.NewTicker(time.Second)
+	defer flush.Stop()
+	for {
+		select {
+		case <-flush.C:
+			if err := rec.Flush(); err != nil {
+				log.Printf("valr-stream: %v", err)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
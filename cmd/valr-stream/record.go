@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+// Events that can be recorded, each to its own directory.
+const (
+	eventTrades    = "trades"
+	eventSummaries = "summaries"
+	eventBooks     = "books"
+)
+
+// record is a single recorded message.
+type record interface {
+	event() string
+	// header names the CSV columns.
+	header() []string
+	// rows returns the CSV rows of the record, one or more.
+	rows() [][]string
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type tradeRecord struct {
+	ReceivedAt time.Time       `json:"receivedAt"`
+	ID         string          `json:"id"`
+	Pair       string          `json:"pair"`
+	TradedAt   time.Time       `json:"tradedAt"`
+	TakerSide  string          `json:"takerSide"`
+	Price      decimal.Decimal `json:"price"`
+	Quantity   decimal.Decimal `json:"quantity"`
+}
+
+func newTradeRecord(at time.Time, m streaming.MessageTradeUpdate) tradeRecord {
+	return tradeRecord{
+		ReceivedAt: at,
+		ID:         m.Data.ID,
+		Pair:       m.CurrencyPairSymbol,
+		TradedAt:   m.Data.TradedAt.Time,
+		TakerSide:  m.Data.TakerSide,
+		Price:      m.Data.Price,
+		Quantity:   m.Data.Quantity,
+	}
+}
+
+func (tradeRecord) event() string { return eventTrades }
+
+func (tradeRecord) header() []string {
+	return []string{"receivedAt", "id", "pair", "tradedAt", "takerSide", "price", "quantity"}
+}
+
+func (r tradeRecord) rows() [][]string {
+	return [][]string{{
+		formatTime(r.ReceivedAt), r.ID, r.Pair, formatTime(r.TradedAt),
+		r.TakerSide, r.Price.String(), r.Quantity.String(),
+	}}
+}
+
+type summaryRecord struct {
+	ReceivedAt time.Time       `json:"receivedAt"`
+	Pair       string          `json:"pair"`
+	Bid        decimal.Decimal `json:"bid"`
+	Ask        decimal.Decimal `json:"ask"`
+	Last       decimal.Decimal `json:"last"`
+	High       decimal.Decimal `json:"high"`
+	Low        decimal.Decimal `json:"low"`
+	BaseVolume decimal.Decimal `json:"baseVolume"`
+	Change     decimal.Decimal `json:"change"`
+}
+
+func newSummaryRecord(at time.Time, m streaming.MessageMarketSummaryUpdate) summaryRecord {
+	return summaryRecord{
+		ReceivedAt: at,
+		Pair:       m.CurrencyPairSymbol,
+		Bid:        m.Data.BidPrice,
+		Ask:        m.Data.AskPrice,
+		Last:       m.Data.LastPrice,
+		High:       m.Data.HighPrice,
+		Low:        m.Data.LowPrice,
+		BaseVolume: m.Data.BaseVolume,
+		Change:     m.Data.ChangeFromPrevious,
+	}
+}
+
+func (summaryRecord) event() string { return eventSummaries }
+
+func (summaryRecord) header() []string {
+	return []string{"receivedAt", "pair", "bid", "ask", "last", "high", "low", "baseVolume", "change"}
+}
+
+func (r summaryRecord) rows() [][]string {
+	return [][]string{{
+		formatTime(r.ReceivedAt), r.Pair, r.Bid.String(), r.Ask.String(), r.Last.String(),
+		r.High.String(), r.Low.String(), r.BaseVolume.String(), r.Change.String(),
+	}}
+}
+
+type bookLevel struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Orders   int             `json:"orders"`
+}
+
+type bookRecord struct {
+	ReceivedAt time.Time   `json:"receivedAt"`
+	Pair       string      `json:"pair"`
+	Sequence   int64       `json:"sequence"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+	Bids       []bookLevel `json:"bids"`
+	Asks       []bookLevel `json:"asks"`
+}
+
+func newBookRecord(at time.Time, top streaming.TopN) bookRecord {
+	levels := func(in []streaming.Level) []bookLevel {
+		out := make([]bookLevel, 0, len(in))
+		for _, l := range in {
+			out = append(out, bookLevel{Price: l.Price, Quantity: l.Quantity, Orders: l.OrderCount})
+		}
+		return out
+	}
+	return bookRecord{
+		ReceivedAt: at,
+		Pair:       top.Pair,
+		Sequence:   top.Sequence,
+		UpdatedAt:  top.UpdatedAt,
+		Bids:       levels(top.Bids),
+		Asks:       levels(top.Asks),
+	}
+}
+
+func (bookRecord) event() string { return eventBooks }
+
+func (bookRecord) header() []string {
+	return []string{"receivedAt", "pair", "sequence", "updatedAt", "side", "level", "price", "quantity", "orders"}
+}
+
+// rows returns a row per level, bids then asks, best first.
+func (r bookRecord) rows() [][]string {
+	var rows [][]string
+	add := func(side string, levels []bookLevel) {
+		for i, l := range levels {
+			rows = append(rows, []string{
+				formatTime(r.ReceivedAt), r.Pair, strconv.FormatInt(r.Sequence, 10), formatTime(r.UpdatedAt),
+				side, strconv.Itoa(i), l.Price.String(), l.Quantity.String(), strconv.Itoa(l.Orders),
+			})
+		}
+	}
+	add("bid", r.Bids)
+	add("ask", r.Asks)
+	return rows
+}
+
+// format is the encoding of recorded files.
+type format string
+
+const (
+	formatJSON format = "jsonl"
+	formatCSV  format = "csv"
+)
+
+func parseFormat(s string) (format, error) {
+	switch f := format(s); f {
+	case formatJSON, formatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("valr-stream: unknown format %q", s)
+	}
+}
+
+// recorder writes records to files under dir, one directory per event. A
+// new file is started at the start of each rotation period, e.g. every hour
+// on the hour, and whenever a file would grow beyond maxSize. Files are
+// named by the UTC start of their period and never overwritten, so a
+// restarted recorder adds a new part rather than replacing earlier data.
+type recorder struct {
+	dir     string
+	format  format
+	every   time.Duration
+	maxSize int64
+	now     func() time.Time
+
+	mu    sync.Mutex
+	files map[string]*rotatingFile
+	err   error
+}
+
+func newRecorder(dir string, f format, every time.Duration, maxSize int64) *recorder {
+	return &recorder{
+		dir:     dir,
+		format:  f,
+		every:   every,
+		maxSize: maxSize,
+		now:     time.Now,
+		files:   make(map[string]*rotatingFile),
+	}
+}
+
+// write appends rec to the current file of its event. After an error
+// writing, all further records are dropped and the error is returned.
+func (r *recorder) write(rec record) error {
+	b, err := r.encode(rec)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	f, ok := r.files[rec.event()]
+	if !ok {
+		f = &rotatingFile{dir: filepath.Join(r.dir, rec.event()), ext: "." + string(r.format)}
+		if r.format == formatCSV {
+			f.header, _ = r.encodeRows([][]string{rec.header()})
+		}
+		r.files[rec.event()] = f
+	}
+	if err := f.write(b, r.window(), r.maxSize); err != nil {
+		r.err = fmt.Errorf("valr-stream: writing %s: %w", rec.event(), err)
+	}
+	return r.err
+}
+
+// window returns the start of the current rotation period.
+func (r *recorder) window() time.Time {
+	now := r.now().UTC()
+	if r.every <= 0 {
+		return time.Time{}
+	}
+	return now.Truncate(r.every)
+}
+
+func (r *recorder) encode(rec record) ([]byte, error) {
+	if r.format == formatCSV {
+		return r.encodeRows(rec.rows())
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func (r *recorder) encodeRows(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Flush writes buffered records to their files.
+func (r *recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, f := range r.files {
+		errs = append(errs, f.flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes every file.
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, f := range r.files {
+		errs = append(errs, f.close())
+	}
+	return errors.Join(errs...)
+}
+
+// rotatingFile is the current file of an event.
+type rotatingFile struct {
+	dir    string
+	ext    string
+	header []byte
+
+	f      *os.File
+	w      *bufio.Writer
+	window time.Time
+	part   int
+	size   int64
+}
+
+// write appends b, first starting a new file if the period has changed or
+// b would take the file beyond maxSize. A record larger than maxSize is
+// still written, alone in its file.
+func (f *rotatingFile) write(b []byte, window time.Time, maxSize int64) error {
+	switch {
+	case f.f == nil || !window.Equal(f.window):
+		if err := f.open(window, 0); err != nil {
+			return err
+		}
+	case maxSize > 0 && f.size > int64(len(f.header)) && f.size+int64(len(b)) > maxSize:
+		if err := f.open(window, f.part+1); err != nil {
+			return err
+		}
+	}
+	n, err := f.w.Write(b)
+	f.size += int64(n)
+	return err
+}
+
+// open closes the current file and creates the file of window, using the
+// first part from part on that doesn't exist yet.
+func (f *rotatingFile) open(window time.Time, part int) error {
+	if err := f.close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+	name := "stream"
+	if !window.IsZero() {
+		name = window.Format("2006-01-02T15-04-05Z")
+	}
+	for ; ; part++ {
+		path := filepath.Join(f.dir, name+f.ext)
+		if part > 0 {
+			path = filepath.Join(f.dir, fmt.Sprintf("%s.%d%s", name, part, f.ext))
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		f.f, f.w = file, bufio.NewWriter(file)
+		f.window, f.part, f.size = window, part, 0
+		break
+	}
+	n, err := f.w.Write(f.header)
+	f.size += int64(n)
+	return err
+}
+
+func (f *rotatingFile) flush() error {
+	if f.w == nil {
+		return nil
+	}
+	return f.w.Flush()
+}
+
+func (f *rotatingFile) close() error {
+	if f.f == nil {
+		return nil
+	}
+	err := errors.Join(f.w.Flush(), f.f.Close())
+	f.f, f.w = nil, nil
+	return err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func trade(id string) tradeRecord {
+	var m streaming.MessageTradeUpdate
+	m.CurrencyPairSymbol = "BTCZAR"
+	m.Data.ID = id
+	m.Data.Price = decimal.New(100, 0)
+	m.Data.Quantity = decimal.New(1, -2)
+	m.Data.TakerSide = "buy"
+	m.Data.TradedAt = valr.NewTimestamp(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	return newTradeRecord(m.Data.TradedAt.Time, m)
+}
+
+func TestRecorderRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 10, 59, 0, 0, time.UTC)
+	r := newRecorder(dir, formatJSON, time.Hour, 0)
+	r.now = func() time.Time { return now }
+
+	for _, id := range []string{"1", "2"} {
+		if err := r.write(trade(id)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	if err := r.write(trade("3")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	for name, want := range map[string][]string{
+		"2024-01-01T10-00-00Z.jsonl": {"1", "2"},
+		"2024-01-01T11-00-00Z.jsonl": {"3"},
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, eventTrades, name))
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("Expected %d records in %s, got %d", len(want), name, len(lines))
+		}
+		for i, line := range lines {
+			var got tradeRecord
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			if got.ID != want[i] || !got.Price.Equal(decimal.New(100, 0)) {
+				t.Errorf("Expected trade %s at 100, got %s at %s", want[i], got.ID, got.Price)
+			}
+		}
+	}
+
+	// A restart in the same hour adds a part rather than overwriting.
+	r = newRecorder(dir, formatJSON, time.Hour, 0)
+	r.now = func() time.Time { return now }
+	if err := r.write(trade("4")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	r.Close()
+	if _, err := os.Stat(filepath.Join(dir, eventTrades, "2024-01-01T11-00-00Z.1.jsonl")); err != nil {
+		t.Errorf("Expected a second part, got %v", err)
+	}
+}
+
+func TestRecorderCSVMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	r := newRecorder(dir, formatCSV, 0, 150)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := r.write(trade(id)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	top := streaming.TopN{
+		Pair: "BTCZAR", Sequence: 7,
+		Bids: []streaming.Level{{Price: decimal.New(99, 0), Quantity: decimal.New(1, 0), OrderCount: 2}},
+		Asks: []streaming.Level{{Price: decimal.New(101, 0), Quantity: decimal.New(3, 0), OrderCount: 1}},
+	}
+	if err := r.write(newBookRecord(time.Now(), top)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, eventTrades, "*.csv"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected a file per trade, got %v", files)
+	}
+	for _, name := range files {
+		rows := readCSV(t, name)
+		if len(rows) != 2 || rows[0][1] != "id" {
+			t.Errorf("Expected a header and one trade in %s, got %q", name, rows)
+		}
+	}
+
+	rows := readCSV(t, filepath.Join(dir, eventBooks, "stream.csv"))
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 levels, got %q", rows)
+	}
+	if want := []string{"bid", "0", "99", "1", "2"}; strings.Join(rows[1][4:], ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %q, got %q", want, rows[1][4:])
+	}
+}
+
+func readCSV(t *testing.T, name string) [][]string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return rows
+}
@@ -0,0 +1,102 @@
+// Package codec defines the schemas of the market data messages published
+// to downstream data platforms, such as message queues, and pluggable
+// codecs for them. JSON and Protobuf codecs are provided; others, such as
+// Avro, can be added with Register.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownCodec is returned by Lookup for unregistered codecs.
+var ErrUnknownCodec = errors.New("codec: unknown codec")
+
+// Codec encodes and decodes messages.
+type Codec interface {
+	// Name identifies the codec, e.g. in configuration.
+	Name() string
+	// ContentType is the MIME type of encoded messages, e.g. for message
+	// headers.
+	ContentType() string
+	Marshal(m Message) ([]byte, error)
+	// Unmarshal decodes data into m, which must be of the kind encoded.
+	Unmarshal(data []byte, m Message) error
+}
+
+var (
+	// JSON encodes messages as JSON objects, with decimals as strings.
+	JSON Codec = jsonCodec{}
+	// Protobuf encodes messages in the protobuf wire format, per
+	// schema.proto, with decimals as strings and times as Unix
+	// nanoseconds.
+	Protobuf Codec = protobufCodec{}
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{
+		JSON.Name():     JSON,
+		Protobuf.Name(): Protobuf,
+	}
+)
+
+// Register makes c available to Lookup by its name, replacing any codec of
+// the same name.
+func Register(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Lookup returns the codec registered with name.
+func Lookup(name string) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
+
+// Names returns the names of the registered codecs, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string        { return "json" }
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Marshal(m Message) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (jsonCodec) Unmarshal(data []byte, m Message) error {
+	return json.Unmarshal(data, m)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string        { return "protobuf" }
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (protobufCodec) Marshal(m Message) ([]byte, error) {
+	return m.appendProto(nil), nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, m Message) error {
+	return parseFields(data, m.parseProto)
+}
@@ -0,0 +1,148 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/codec"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func messages() []codec.Message {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 123, time.UTC)
+	return []codec.Message{
+		codec.TradeFrom(valr.TradeHistoryInfo{
+			Pair: "BTCZAR", ID: "t1", Price: d("1200000.5"), Quantity: d("0.0001"),
+			TakerSide: "buy", TradedAt: valr.NewTimestamp(at),
+		}),
+		codec.TickerFrom(marketdata.Ticker{Pair: "BTCZAR", Bid: d("1199999"), Ask: d("1200001"), Last: d("1200000"), Time: at}),
+		codec.CandleFrom(marketdata.Candle{
+			Pair: "ETHZAR", Start: at.Truncate(time.Minute), Interval: time.Minute,
+			Open: d("1"), High: d("3"), Low: d("0.5"), Close: d("2"),
+			Volume: d("10"), QuoteVolume: d("20"), VWAP: d("2"), Trades: 4,
+		}),
+		codec.BookFrom(streaming.TopN{
+			Pair: "BTCZAR", Sequence: 42, UpdatedAt: at,
+			Bids: []streaming.Level{{Price: d("100"), Quantity: d("1")}, {Price: d("99"), Quantity: d("2")}},
+			Asks: []streaming.Level{{Price: d("101"), Quantity: d("0.5")}},
+		}),
+	}
+}
+
+func newOfKind(t *testing.T, kind string) codec.Message {
+	switch kind {
+	case codec.KindTrade:
+		return new(codec.Trade)
+	case codec.KindTicker:
+		return new(codec.Ticker)
+	case codec.KindCandle:
+		return new(codec.Candle)
+	case codec.KindBook:
+		return new(codec.Book)
+	}
+	t.Fatalf("Unexpected kind %q", kind)
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []codec.Codec{codec.JSON, codec.Protobuf} {
+		for _, m := range messages() {
+			t.Run(c.Name()+"/"+m.Kind(), func(t *testing.T) {
+				b, err := c.Marshal(m)
+				if err != nil {
+					t.Fatalf("Expected success, got %v", err)
+				}
+				got := newOfKind(t, m.Kind())
+				if err := c.Unmarshal(b, got); err != nil {
+					t.Fatalf("Expected success, got %v", err)
+				}
+				again, err := c.Marshal(got)
+				if err != nil {
+					t.Fatalf("Expected success, got %v", err)
+				}
+				if !bytes.Equal(b, again) {
+					t.Errorf("Expected %q, got %q", b, again)
+				}
+			})
+		}
+	}
+}
+
+func TestProtobufWireFormat(t *testing.T) {
+	m := &codec.Trade{Pair: "BTCZAR", Price: d("1.5"), TradedAt: time.Unix(0, 300)}
+	b, err := codec.Protobuf.Marshal(m)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// pair = 1 (string), price = 3 (string), traded_at = 6 (varint 300).
+	want := []byte{0x0a, 6, 'B', 'T', 'C', 'Z', 'A', 'R', 0x1a, 3, '1', '.', '5', 0x30, 0xac, 0x02}
+	if !bytes.Equal(b, want) {
+		t.Errorf("Expected %x, got %x", want, b)
+	}
+
+	var got codec.Trade
+	if err := codec.Protobuf.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if got.Pair != "BTCZAR" || !got.Price.Equal(d("1.5")) || got.TradedAt.UnixNano() != 300 {
+		t.Errorf("Unexpected trade %+v", got)
+	}
+}
+
+func TestProtobufSkipsUnknownFields(t *testing.T) {
+	b, _ := codec.Protobuf.Marshal(&codec.Ticker{Pair: "BTCZAR"})
+	b = append(b, 0x78, 0x01) // field 15, varint 1
+	var got codec.Ticker
+	if err := codec.Protobuf.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if got.Pair != "BTCZAR" {
+		t.Errorf("Expected %q, got %q", "BTCZAR", got.Pair)
+	}
+}
+
+func TestProtobufTruncated(t *testing.T) {
+	b, _ := codec.Protobuf.Marshal(&codec.Ticker{Pair: "BTCZAR"})
+	var got codec.Ticker
+	if err := codec.Protobuf.Unmarshal(b[:len(b)-1], &got); err == nil {
+		t.Errorf("Expected error for truncated message")
+	}
+}
+
+type avro struct{}
+
+func (avro) Name() string                          { return "avro" }
+func (avro) ContentType() string                   { return "avro/binary" }
+func (avro) Marshal(codec.Message) ([]byte, error) { return nil, nil }
+func (avro) Unmarshal([]byte, codec.Message) error { return nil }
+
+func TestRegistry(t *testing.T) {
+	c, err := codec.Lookup("protobuf")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if c.ContentType() != "application/x-protobuf" {
+		t.Errorf("Expected %q, got %q", "application/x-protobuf", c.ContentType())
+	}
+	if _, err := codec.Lookup("avro"); !errors.Is(err, codec.ErrUnknownCodec) {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+	codec.Register(avro{})
+	t.Cleanup(func() { codec.Unregister("avro") })
+	if _, err := codec.Lookup("avro"); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if want, got := []string{"avro", "json", "protobuf"}, codec.Names(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
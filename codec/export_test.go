@@ -0,0 +1,9 @@
+package codec
+
+// Unregister removes the codec registered with name, so tests can restore
+// the registry.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
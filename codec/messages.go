@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+// Message kinds, e.g. for use in topic or subject names.
+const (
+	KindTrade  = "trade"
+	KindTicker = "ticker"
+	KindCandle = "candle"
+	KindBook   = "book"
+)
+
+// Message is a market data message with a fixed schema. The protobuf
+// schema is in schema.proto.
+type Message interface {
+	Kind() string
+	appendProto(b []byte) []byte
+	parseProto(f field) error
+}
+
+// Trade is a public trade.
+type Trade struct {
+	Pair      string          `json:"pair"`
+	ID        string          `json:"id"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	TakerSide string          `json:"takerSide"`
+	TradedAt  time.Time       `json:"tradedAt"`
+}
+
+// TradeFrom converts trade history.
+func TradeFrom(t valr.TradeHistoryInfo) *Trade {
+	return &Trade{
+		Pair:      t.Pair,
+		ID:        t.ID,
+		Price:     t.Price,
+		Quantity:  t.Quantity,
+		TakerSide: string(t.TakerSide),
+		TradedAt:  t.TradedAt.Time,
+	}
+}
+
+func (*Trade) Kind() string { return KindTrade }
+
+func (m *Trade) appendProto(b []byte) []byte {
+	b = appendString(b, 1, m.Pair)
+	b = appendString(b, 2, m.ID)
+	b = appendDecimal(b, 3, m.Price)
+	b = appendDecimal(b, 4, m.Quantity)
+	b = appendString(b, 5, m.TakerSide)
+	return appendTime(b, 6, m.TradedAt)
+}
+
+func (m *Trade) parseProto(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Pair = f.string()
+	case 2:
+		m.ID = f.string()
+	case 3:
+		m.Price, err = f.decimal()
+	case 4:
+		m.Quantity, err = f.decimal()
+	case 5:
+		m.TakerSide = f.string()
+	case 6:
+		m.TradedAt = f.time()
+	}
+	return err
+}
+
+// Ticker is the best bid, best ask and last price of a pair.
+type Ticker struct {
+	Pair string          `json:"pair"`
+	Bid  decimal.Decimal `json:"bid"`
+	Ask  decimal.Decimal `json:"ask"`
+	Last decimal.Decimal `json:"last"`
+	Time time.Time       `json:"time"`
+}
+
+// TickerFrom converts a ticker.
+func TickerFrom(t marketdata.Ticker) *Ticker {
+	return &Ticker{Pair: t.Pair, Bid: t.Bid, Ask: t.Ask, Last: t.Last, Time: t.Time}
+}
+
+func (*Ticker) Kind() string { return KindTicker }
+
+func (m *Ticker) appendProto(b []byte) []byte {
+	b = appendString(b, 1, m.Pair)
+	b = appendDecimal(b, 2, m.Bid)
+	b = appendDecimal(b, 3, m.Ask)
+	b = appendDecimal(b, 4, m.Last)
+	return appendTime(b, 5, m.Time)
+}
+
+func (m *Ticker) parseProto(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Pair = f.string()
+	case 2:
+		m.Bid, err = f.decimal()
+	case 3:
+		m.Ask, err = f.decimal()
+	case 4:
+		m.Last, err = f.decimal()
+	case 5:
+		m.Time = f.time()
+	}
+	return err
+}
+
+// Candle is the OHLCV summary of an interval.
+type Candle struct {
+	Pair        string          `json:"pair"`
+	Start       time.Time       `json:"start"`
+	Interval    time.Duration   `json:"interval"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	QuoteVolume decimal.Decimal `json:"quoteVolume"`
+	VWAP        decimal.Decimal `json:"vwap"`
+	Trades      int             `json:"trades"`
+}
+
+// CandleFrom converts a candle.
+func CandleFrom(c marketdata.Candle) *Candle {
+	return &Candle{
+		Pair:        c.Pair,
+		Start:       c.Start,
+		Interval:    c.Interval,
+		Open:        c.Open,
+		High:        c.High,
+		Low:         c.Low,
+		Close:       c.Close,
+		Volume:      c.Volume,
+		QuoteVolume: c.QuoteVolume,
+		VWAP:        c.VWAP,
+		Trades:      c.Trades,
+	}
+}
+
+func (*Candle) Kind() string { return KindCandle }
+
+func (m *Candle) appendProto(b []byte) []byte {
+	b = appendString(b, 1, m.Pair)
+	b = appendTime(b, 2, m.Start)
+	b = appendInt64(b, 3, int64(m.Interval))
+	b = appendDecimal(b, 4, m.Open)
+	b = appendDecimal(b, 5, m.High)
+	b = appendDecimal(b, 6, m.Low)
+	b = appendDecimal(b, 7, m.Close)
+	b = appendDecimal(b, 8, m.Volume)
+	b = appendDecimal(b, 9, m.QuoteVolume)
+	b = appendDecimal(b, 10, m.VWAP)
+	return appendInt64(b, 11, int64(m.Trades))
+}
+
+func (m *Candle) parseProto(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Pair = f.string()
+	case 2:
+		m.Start = f.time()
+	case 3:
+		m.Interval = time.Duration(f.int64())
+	case 4:
+		m.Open, err = f.decimal()
+	case 5:
+		m.High, err = f.decimal()
+	case 6:
+		m.Low, err = f.decimal()
+	case 7:
+		m.Close, err = f.decimal()
+	case 8:
+		m.Volume, err = f.decimal()
+	case 9:
+		m.QuoteVolume, err = f.decimal()
+	case 10:
+		m.VWAP, err = f.decimal()
+	case 11:
+		m.Trades = int(f.int64())
+	}
+	return err
+}
+
+// Level is a price level of a book.
+type Level struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// Book is the top levels of a pair's order book, best first.
+type Book struct {
+	Pair      string    `json:"pair"`
+	Sequence  int64     `json:"sequence"`
+	Bids      []Level   `json:"bids"`
+	Asks      []Level   `json:"asks"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BookFrom converts the top of a book.
+func BookFrom(t streaming.TopN) *Book {
+	m := &Book{Pair: t.Pair, Sequence: t.Sequence, UpdatedAt: t.UpdatedAt}
+	for _, l := range t.Bids {
+		m.Bids = append(m.Bids, Level{Price: l.Price, Quantity: l.Quantity})
+	}
+	for _, l := range t.Asks {
+		m.Asks = append(m.Asks, Level{Price: l.Price, Quantity: l.Quantity})
+	}
+	return m
+}
+
+func (*Book) Kind() string { return KindBook }
+
+func (m *Book) appendProto(b []byte) []byte {
+	b = appendString(b, 1, m.Pair)
+	b = appendInt64(b, 2, m.Sequence)
+	for _, l := range m.Bids {
+		b = appendBytes(b, 3, l.appendProto(nil))
+	}
+	for _, l := range m.Asks {
+		b = appendBytes(b, 4, l.appendProto(nil))
+	}
+	return appendTime(b, 5, m.UpdatedAt)
+}
+
+func (m *Book) parseProto(f field) error {
+	switch f.num {
+	case 1:
+		m.Pair = f.string()
+	case 2:
+		m.Sequence = f.int64()
+	case 3, 4:
+		var l Level
+		if err := parseFields(f.bytes, l.parseProto); err != nil {
+			return err
+		}
+		if f.num == 3 {
+			m.Bids = append(m.Bids, l)
+		} else {
+			m.Asks = append(m.Asks, l)
+		}
+	case 5:
+		m.UpdatedAt = f.time()
+	}
+	return nil
+}
+
+func (l Level) appendProto(b []byte) []byte {
+	b = appendDecimal(b, 1, l.Price)
+	return appendDecimal(b, 2, l.Quantity)
+}
+
+func (l *Level) parseProto(f field) (err error) {
+	switch f.num {
+	case 1:
+		l.Price, err = f.decimal()
+	case 2:
+		l.Quantity, err = f.decimal()
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+var errTruncated = errors.New("codec: truncated protobuf message")
+
+func appendTag(b []byte, field int, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// appendInt64 appends a non-zero int64 field; zero values are omitted as in
+// proto3.
+func appendInt64(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, uint64(v))
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendString(b []byte, field int, v string) []byte {
+	if v == "" {
+		return b
+	}
+	return appendBytes(b, field, []byte(v))
+}
+
+// appendDecimal encodes decimals as strings, so no precision is lost.
+func appendDecimal(b []byte, field int, v decimal.Decimal) []byte {
+	if v.IsZero() {
+		return b
+	}
+	return appendString(b, field, v.String())
+}
+
+// appendTime encodes times as Unix nanoseconds.
+func appendTime(b []byte, field int, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendInt64(b, field, t.UnixNano())
+}
+
+// field is a single decoded protobuf field.
+type field struct {
+	num   int
+	wire  int
+	value uint64
+	bytes []byte
+}
+
+func (f field) int64() int64 {
+	return int64(f.value)
+}
+
+func (f field) string() string {
+	return string(f.bytes)
+}
+
+func (f field) decimal() (decimal.Decimal, error) {
+	return decimal.NewFromString(f.string())
+}
+
+func (f field) time() time.Time {
+	return time.Unix(0, f.int64()).UTC()
+}
+
+// parseFields decodes a protobuf message into its fields, calling fn for
+// each. Fields of unknown wire types are rejected.
+func parseFields(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		f := field{num: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return errTruncated
+			}
+			f.bytes = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			return fmt.Errorf("codec: unsupported protobuf wire type %d", f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
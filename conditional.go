@@ -0,0 +1,165 @@
+package valr
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ConditionalTransport is an http.RoundTripper that revalidates GET
+// responses carrying an ETag or Last-Modified validator. Repeat requests
+// are sent with If-None-Match or If-Modified-Since, and a 304 Not Modified
+// reply is transparently replaced with the cached response.
+//
+// Responses are keyed by URL, API key and subaccount, so authenticated
+// responses are never shared between accounts. At most maxCacheEntries
+// responses are kept, discarding the least recently used.
+type ConditionalTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru orders the entries from most to least recently used.
+	lru *list.List
+}
+
+type validatedResponse struct {
+	key          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// NewConditionalTransport wraps base, or http.DefaultTransport if nil.
+func NewConditionalTransport(base http.RoundTripper) *ConditionalTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &ConditionalTransport{
+		base:    base,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// lookup returns the response stored under key, if any, marking it used.
+func (t *ConditionalTransport) lookup(key string) *validatedResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	t.lru.MoveToFront(e)
+	return e.Value.(*validatedResponse)
+}
+
+// store stores v, discarding the least recently used responses beyond the
+// limit.
+func (t *ConditionalTransport) store(v *validatedResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.entries[v.key]; ok {
+		e.Value = v
+		t.lru.MoveToFront(e)
+		return
+	}
+	t.entries[v.key] = t.lru.PushFront(v)
+	for t.lru.Len() > maxCacheEntries {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*validatedResponse).key)
+	}
+}
+
+func conditionalKey(req *http.Request) string {
+	return req.URL.String() + "\x00" + req.Header.Get("X-VALR-API-KEY") +
+		"\x00" + req.Header.Get("X-VALR-SUB-ACCOUNT-ID")
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := conditionalKey(req)
+	cached := t.lookup(key)
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		header := cached.header.Clone()
+		for k, v := range res.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         res.Proto,
+			ProtoMajor:    res.ProtoMajor,
+			ProtoMinor:    res.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	if res.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(&validatedResponse{
+		key:          key,
+		etag:         etag,
+		lastModified: lastModified,
+		header:       res.Header.Clone(),
+		body:         body,
+	})
+	return res, nil
+}
+
+// Purge discards all stored responses.
+func (t *ConditionalTransport) Purge() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = make(map[string]*list.Element)
+	t.lru.Init()
+}
+
+// EnableConditionalRequests wraps the transport of the client's HTTP client
+// in a ConditionalTransport. Call it after SetHTTPClient, which replaces the
+// transport.
+func (cl *Client) EnableConditionalRequests() *ConditionalTransport {
+	t := NewConditionalTransport(cl.httpClient.Transport)
+	hc := *cl.httpClient
+	hc.Transport = t
+	cl.httpClient = &hc
+	return t
+}
@@ -0,0 +1,70 @@
+package valr_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestConditionalTransport(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		ifNoneMatch []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		mu.Unlock()
+		etag := `"` + r.URL.Path + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: valr.NewConditionalTransport(nil)}
+	get := func(path string) (int, string, string) {
+		t.Helper()
+		res, err := hc.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return res.StatusCode, string(body), ifNoneMatch[len(ifNoneMatch)-1]
+	}
+
+	if status, body, sent := get("/a"); status != http.StatusOK || body != `{"path":"/a"}` || sent != "" {
+		t.Errorf("Expected a fresh response, got %d %q sending %q", status, body, sent)
+	}
+	// The repeat request is revalidated, and the 304 answered with the
+	// stored body.
+	if status, body, sent := get("/a"); status != http.StatusOK || body != `{"path":"/a"}` || sent != `"/a"` {
+		t.Errorf("Expected the stored response, got %d %q sending %q", status, body, sent)
+	}
+
+	// The least recently used responses are discarded beyond the limit.
+	for i := 0; i < 1024; i++ {
+		get(fmt.Sprintf("/page/%d", i))
+	}
+	if _, _, sent := get("/a"); sent != "" {
+		t.Errorf("Expected the response discarded, got If-None-Match %q", sent)
+	}
+	if _, _, sent := get("/page/1023"); sent != `"/page/1023"` {
+		t.Errorf("Expected the recent response revalidated, got If-None-Match %q", sent)
+	}
+}
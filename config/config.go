@@ -0,0 +1,224 @@
+// Package config reloads configuration, such as risk limits, subscribed
+// pairs and execution parameters, at runtime, so live bots don't need
+// restarting to change them. Each new configuration is validated before it
+// replaces the current one, and changes are reported to callbacks.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/ordermanager"
+)
+
+// Source loads a configuration.
+type Source[T any] func(ctx context.Context) (T, error)
+
+// File returns a Source decoding the JSON file at path. Unknown fields are
+// rejected, so typos don't silently leave a parameter unchanged.
+func File[T any](path string) Source[T] {
+	return func(context.Context) (T, error) {
+		var v T
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return v, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			return v, fmt.Errorf("config: decoding %s: %w", path, err)
+		}
+		return v, nil
+	}
+}
+
+// Validator is implemented by configurations that can check themselves.
+type Validator interface {
+	Validate() error
+}
+
+// Change reports a new configuration replacing Old.
+type Change[T any] struct {
+	Old T
+	New T
+	// Initial is true for the first configuration loaded, when Old is the
+	// zero value.
+	Initial bool
+	Time    time.Time
+}
+
+type Option[T any] func(*Watcher[T])
+
+// WithValidator sets a check of new configurations, in addition to their
+// Validate method if they implement Validator.
+func WithValidator[T any](fn func(T) error) Option[T] {
+	return func(w *Watcher[T]) {
+		w.validators = append(w.validators, fn)
+	}
+}
+
+// WithChangeCallback adds a callback for configuration changes. Callbacks
+// are called in order, one change at a time. If one returns an error the
+// change is rolled back: the old configuration is restored and the callbacks
+// already called are called again with the reverse change.
+func WithChangeCallback[T any](fn func(Change[T]) error) Option[T] {
+	return func(w *Watcher[T]) {
+		w.onChange = append(w.onChange, fn)
+	}
+}
+
+// WithErrorCallback sets a callback for configurations rejected by Run. Each
+// distinct error is reported once.
+func WithErrorCallback[T any](fn func(error)) Option[T] {
+	return func(w *Watcher[T]) {
+		w.onError = fn
+	}
+}
+
+// Watcher holds the current configuration and replaces it as the source
+// changes.
+type Watcher[T any] struct {
+	src        Source[T]
+	validators []func(T) error
+	onChange   []func(Change[T]) error
+	onError    func(error)
+
+	// applyMu serialises changes, so callbacks see them in order.
+	applyMu sync.Mutex
+	mu      sync.RWMutex
+	current T
+	loaded  bool
+	lastErr string
+}
+
+// NewWatcher returns a Watcher of src. Call Load before Current.
+func NewWatcher[T any](src Source[T], opts ...Option[T]) *Watcher[T] {
+	w := &Watcher[T]{src: src}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Current returns the current configuration.
+func (w *Watcher[T]) Current() T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Load reads the source and, if the configuration is valid and differs from
+// the current one, applies it. It can be called on demand, e.g. on SIGHUP or
+// from an admin endpoint.
+func (w *Watcher[T]) Load(ctx context.Context) error {
+	v, err := w.src(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Set(v)
+}
+
+// Set validates v and, if it differs from the current configuration,
+// applies it.
+func (w *Watcher[T]) Set(v T) error {
+	if err := w.validate(v); err != nil {
+		return err
+	}
+
+	w.applyMu.Lock()
+	defer w.applyMu.Unlock()
+	w.mu.RLock()
+	old, loaded := w.current, w.loaded
+	w.mu.RUnlock()
+	if loaded && reflect.DeepEqual(old, v) {
+		return nil
+	}
+
+	w.store(v, true)
+	c := Change[T]{Old: old, New: v, Initial: !loaded, Time: time.Now()}
+	for i, fn := range w.onChange {
+		if err := fn(c); err != nil {
+			w.store(old, loaded)
+			undo := Change[T]{Old: v, New: old, Initial: c.Initial, Time: time.Now()}
+			for j := i - 1; j >= 0; j-- {
+				w.onChange[j](undo)
+			}
+			return fmt.Errorf("config: change rejected: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher[T]) store(v T, loaded bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current, w.loaded = v, loaded
+}
+
+func (w *Watcher[T]) validate(v T) error {
+	if val, ok := any(v).(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return fmt.Errorf("config: invalid configuration: %w", err)
+		}
+	}
+	for _, fn := range w.validators {
+		if err := fn(v); err != nil {
+			return fmt.Errorf("config: invalid configuration: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run calls Load every interval until ctx is done, keeping the current
+// configuration when the source fails or is invalid.
+func (w *Watcher[T]) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		err := w.Load(ctx)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != w.lastErr && err != nil && w.onError != nil {
+			w.onError(err)
+		}
+		w.lastErr = msg
+	}
+}
+
+// Diff returns the items of next not in prev and of prev not in next, e.g.
+// the pairs to subscribe to and unsubscribe from.
+func Diff[E comparable](prev, next []E) (added, removed []E) {
+	for _, e := range next {
+		if !slices.Contains(prev, e) {
+			added = append(added, e)
+		}
+	}
+	for _, e := range prev {
+		if !slices.Contains(next, e) {
+			removed = append(removed, e)
+		}
+	}
+	return added, removed
+}
+
+// ApplyRisk returns a change callback setting r's limits to those selected
+// from each new configuration.
+func ApplyRisk[T any](r *ordermanager.Risk, limits func(T) ordermanager.RiskLimits) func(Change[T]) error {
+	return func(c Change[T]) error {
+		return r.SetLimits(limits(c.New))
+	}
+}
@@ -0,0 +1,152 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/config"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+type botConfig struct {
+	Pairs       []string        `json:"pairs"`
+	MaxPosition decimal.Decimal `json:"maxPosition"`
+	Spread      decimal.Decimal `json:"spread"`
+}
+
+func (c botConfig) Validate() error {
+	if len(c.Pairs) == 0 {
+		return errors.New("no pairs")
+	}
+	return nil
+}
+
+func (c botConfig) limits() ordermanager.RiskLimits {
+	max := make(map[string]decimal.Decimal)
+	for _, p := range c.Pairs {
+		max[p] = c.MaxPosition
+	}
+	return ordermanager.RiskLimits{MaxPosition: max}
+}
+
+func TestWatcherFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.json")
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	write(`{"pairs":["BTCZAR"],"maxPosition":"1","spread":"0.001"}`)
+
+	risk := ordermanager.NewRisk(ordermanager.RiskLimits{})
+	var changes []config.Change[botConfig]
+	errs := make(chan error, 10)
+	w := config.NewWatcher(config.File[botConfig](path),
+		config.WithChangeCallback(config.ApplyRisk(risk, botConfig.limits)),
+		config.WithChangeCallback(func(c config.Change[botConfig]) error {
+			changes = append(changes, c)
+			return nil
+		}),
+		config.WithErrorCallback[botConfig](func(err error) { errs <- err }),
+	)
+	if err := w.Load(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(changes) != 1 || !changes[0].Initial {
+		t.Fatalf("Expected initial change, got %+v", changes)
+	}
+	if got := risk.Limits().MaxPosition["BTCZAR"]; !got.Equal(decimal.New(1, 0)) {
+		t.Errorf("Expected max position 1, got %s", got)
+	}
+
+	// Reloading an unchanged file is not a change.
+	if err := w.Load(context.Background()); err != nil || len(changes) != 1 {
+		t.Errorf("Expected no change, got %v %d", err, len(changes))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, 5*time.Millisecond)
+
+	write(`{"pairs":[],"maxPosition":"1"}`)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "no pairs") {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected validation error")
+	}
+	if got := w.Current().Pairs; !reflect.DeepEqual(got, []string{"BTCZAR"}) {
+		t.Errorf("Expected invalid configuration to be rejected, got %v", got)
+	}
+
+	write(`{"pairs":["BTCZAR","ETHZAR"],"maxPosition":"2","spread":"0.002"}`)
+	deadline := time.Now().Add(time.Second)
+	for len(w.Current().Pairs) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected reload")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if got := risk.Limits().MaxPosition["ETHZAR"]; !got.Equal(decimal.New(2, 0)) {
+		t.Errorf("Expected max position 2, got %s", got)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("Expected the error reported once, got %v", err)
+	default:
+	}
+}
+
+func TestWatcherRollback(t *testing.T) {
+	var applied []string
+	w := config.NewWatcher[botConfig](nil,
+		config.WithChangeCallback(func(c config.Change[botConfig]) error {
+			applied = append(applied, c.New.Pairs[0])
+			return nil
+		}),
+		config.WithChangeCallback(func(c config.Change[botConfig]) error {
+			if c.New.Pairs[0] == "XRPZAR" {
+				return errors.New("unsupported pair")
+			}
+			return nil
+		}),
+		config.WithValidator(func(c botConfig) error {
+			if c.Spread.IsNegative() {
+				return errors.New("negative spread")
+			}
+			return nil
+		}),
+	)
+	if err := w.Set(botConfig{Pairs: []string{"BTCZAR"}}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := w.Set(botConfig{Pairs: []string{"BTCZAR"}, Spread: decimal.New(-1, 0)}); err == nil {
+		t.Errorf("Expected validation error")
+	}
+	if err := w.Set(botConfig{Pairs: []string{"XRPZAR"}}); err == nil {
+		t.Errorf("Expected rejected change")
+	}
+	if got := w.Current().Pairs[0]; got != "BTCZAR" {
+		t.Errorf("Expected %q, got %q", "BTCZAR", got)
+	}
+	if want := []string{"BTCZAR", "XRPZAR", "BTCZAR"}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("Expected %v, got %v", want, applied)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	added, removed := config.Diff([]string{"BTCZAR", "ETHZAR"}, []string{"ETHZAR", "SOLZAR"})
+	if !reflect.DeepEqual(added, []string{"SOLZAR"}) || !reflect.DeepEqual(removed, []string{"BTCZAR"}) {
+		t.Errorf("Unexpected diff %v %v", added, removed)
+	}
+}
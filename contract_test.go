@@ -0,0 +1,303 @@
+package valr_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/anonymise"
+	"github.com/donohutcheon/valr-go/credentials"
+	"github.com/shopspring/decimal"
+)
+
+// The contract tests check the response structs against a corpus of
+// recorded VALR payloads in testdata/contract. Payloads with fields the
+// structs don't have, or missing fields they do, fail, so changes to the API
+// are caught before they silently drop data. Re-record the corpus against
+// the live API, with identifying fields redacted, with:
+//
+//	VA_KEY_ID=... VA_SECRET=... go test -run TestContracts -record-contracts
+var recordContracts = flag.Bool("record-contracts", false, "record the contract corpus from the live API using VA_KEY_ID and VA_SECRET")
+
+const contractDir = "testdata/contract"
+
+type contract struct {
+	name string
+	// call fetches the payload when recording.
+	call     func(ctx context.Context, cl *valr.Client) error
+	newValue func() any
+	// optional lists the fields, as paths such as "[].additionalInfo", that
+	// VALR omits from some payloads. A path ending in ".*" makes every field
+	// of an object optional.
+	optional []string
+}
+
+func newContract[T any](name string, call func(context.Context, *valr.Client) (T, error), optional ...string) contract {
+	return contract{
+		name: name,
+		call: func(ctx context.Context, cl *valr.Client) error {
+			_, err := call(ctx, cl)
+			return err
+		},
+		newValue: func() any { return new(T) },
+		optional: optional,
+	}
+}
+
+var contracts = []contract{
+	newContract("server_time", func(ctx context.Context, cl *valr.Client) (*valr.GetServerTimeResponse, error) {
+		return cl.GetServerTimeRequest(ctx, &valr.GetServerTimeRequest{})
+	}),
+	newContract("currencies", func(ctx context.Context, cl *valr.Client) ([]valr.CurrencyInfo, error) {
+		return cl.GetCurrencies(ctx, &valr.GetCurrenciesRequest{})
+	}),
+	newContract("pairs", func(ctx context.Context, cl *valr.Client) ([]valr.PairInfo, error) {
+		return cl.GetCurrencyPairs(ctx, &valr.GetCurrencyPairsRequest{})
+	}),
+	newContract("order_types", func(ctx context.Context, cl *valr.Client) ([]valr.OrderTypes, error) {
+		return cl.GetOrderTypesRequest(ctx, &valr.GetOrderTypesRequest{})
+	}),
+	newContract("market_summary", func(ctx context.Context, cl *valr.Client) ([]valr.MarketSummary, error) {
+		return cl.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{})
+	}),
+	newContract("market_summary_pair", func(ctx context.Context, cl *valr.Client) (*valr.MarketSummary, error) {
+		return cl.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: "BTCZAR"})
+	}),
+	newContract("orderbook", func(ctx context.Context, cl *valr.Client) (*valr.OrderBook, error) {
+		return cl.GetOrderBook(ctx, &valr.GetOrderBookRequest{Pair: "BTCZAR"})
+	}),
+	newContract("api_key", func(ctx context.Context, cl *valr.Client) (*valr.GetCurrentAPIKeyResponse, error) {
+		return cl.GetCurrentAPIKeyRequest(ctx, &valr.GetCurrentAPIKeyRequest{})
+	}),
+	newContract("balances", func(ctx context.Context, cl *valr.Client) ([]valr.AccountBalance, error) {
+		return cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	}),
+	newContract("transaction_history", func(ctx context.Context, cl *valr.Client) ([]valr.TransactionInfo, error) {
+		return cl.GetTransactionHistoryRequest(ctx, &valr.GetTransactionHistoryRequest{Limit: 100})
+	},
+		// Only trades carry fees, and the additional info depends on the
+		// transaction type.
+		"[].feeCurrency", "[].feeValue", "[].debitCurrency", "[].debitValue",
+		"[].creditCurrency", "[].creditValue", "[].additionalInfo", "[].additionalInfo.*"),
+	newContract("account_trade_history", func(ctx context.Context, cl *valr.Client) ([]valr.TradeInfo, error) {
+		return cl.GetTradeHistoryForPairRequest(ctx, &valr.GetTradeHistoryForPairRequest{Pair: "BTCZAR", Limit: 10})
+	}),
+	newContract("market_trade_history", func(ctx context.Context, cl *valr.Client) ([]valr.TradeHistoryInfo, error) {
+		return cl.GetAuthTradeHistoryForPairRequest(ctx, &valr.GetAuthTradeHistoryForPairRequest{Pair: "BTCZAR", Limit: 10})
+	}),
+	newContract("open_orders", func(ctx context.Context, cl *valr.Client) ([]valr.OpenOrder, error) {
+		return cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	},
+		// Only orders placed with a customer order ID have one.
+		"[].customerOrderId"),
+	newContract("order_history", func(ctx context.Context, cl *valr.Client) ([]valr.OrderReceipt, error) {
+		return cl.GetOrderHistoryRequest(ctx, &valr.GetOrderHistoryRequest{Limit: 10})
+	},
+		// quantity isn't sent, only the original and remaining quantities.
+		"[].customerOrderId", "[].quantity"),
+	newContract("open_positions", func(ctx context.Context, cl *valr.Client) ([]valr.OpenPosition, error) {
+		return cl.GetOpenPositionsRequest(ctx, &valr.GetOpenPositionsRequest{})
+	}),
+}
+
+func TestContracts(t *testing.T) {
+	if *recordContracts {
+		recordContractCorpus(t)
+	}
+	for _, c := range contracts {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(contractDir, c.name+".json"))
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			for _, problem := range checkContract(data, c.newValue(), c.optional) {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// checkContract decodes data into v, reporting fields of the payload that v
+// doesn't have and fields of v missing from the payload.
+func checkContract(data []byte, v any, optional []string) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return []string{fmt.Sprintf("Payload doesn't match the struct: %v", err)}
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []string{err.Error()}
+	}
+	skip := make(map[string]bool, len(optional))
+	for _, p := range optional {
+		skip[p] = true
+	}
+	var problems []string
+	missingFields(reflect.TypeOf(v).Elem(), raw, "", skip, &problems)
+	return problems
+}
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+func missingFields(t reflect.Type, raw any, path string, optional map[string]bool, problems *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		items, _ := raw.([]any)
+		if len(items) == 0 && path == "" {
+			*problems = append(*problems, fmt.Sprintf("%s: Expected a non-empty array to check its elements", path+"[]"))
+		}
+		for _, item := range items {
+			missingFields(t.Elem(), item, path+"[]", optional, problems)
+		}
+	case reflect.Struct:
+		if t == decimalType || t == timeType {
+			return
+		}
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" || strings.Contains(opts, "omitempty") {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if optional[fieldPath] || optional[path+".*"] {
+				continue
+			}
+			fv, ok := obj[name]
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: Expected field in payload; was it renamed or removed?", fieldPath))
+				continue
+			}
+			missingFields(f.Type, fv, fieldPath, optional, problems)
+		}
+	}
+}
+
+// recordingTransport keeps the body of the last response.
+type recordingTransport struct {
+	last []byte
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rt.last = body
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// recordContractCorpus fetches every contract payload from the live API and
+// writes it to the corpus with identifying fields replaced by hashes under a
+// random, discarded key. Empty arrays are not written, so they don't
+// replace payloads that have elements to check.
+func recordContractCorpus(t *testing.T) {
+	ctx := context.Background()
+	creds, err := credentials.FromEnv().Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("Recording requires credentials: %v", err)
+	}
+	rt := new(recordingTransport)
+	cl := valr.NewClient()
+	cl.SetHTTPClient(&http.Client{Transport: rt, Timeout: 30 * time.Second})
+	if err := cl.SetAuth(creds.KeyID, creds.Secret); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	opts := []anonymise.Option{
+		anonymise.WithField("id", anonymise.Hash),
+		anonymise.WithField("positionId", anonymise.Hash),
+		anonymise.WithField("label", anonymise.Hash),
+		anonymise.WithField("allowedIpAddressCidr", anonymise.Hash),
+	}
+	// Hash rather than strip the default fields, so the payloads keep their
+	// shape.
+	for name, mode := range anonymise.DefaultFields {
+		if mode == anonymise.Strip {
+			opts = append(opts, anonymise.WithField(name, anonymise.Hash))
+		}
+	}
+	anon, err := anonymise.New(key, opts...)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	for _, c := range contracts {
+		rt.last = nil
+		if err := c.call(ctx, cl); err != nil {
+			t.Errorf("%s: Failed to record: %v", c.name, err)
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(rt.last), []byte("[]")) {
+			t.Logf("%s: Not recorded, the payload is empty", c.name)
+			continue
+		}
+		redacted, err := anon.JSON(rt.last)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		var out bytes.Buffer
+		json.Indent(&out, redacted, "", "  ")
+		out.WriteByte('\n')
+		if err := os.WriteFile(filepath.Join(contractDir, c.name+".json"), out.Bytes(), 0o644); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+}
+
+func TestCheckContract(t *testing.T) {
+	problems := checkContract([]byte(`{"currency":"ZAR","available":"1","reserved":"0","total":"1","staked":"0"}`), new(valr.AccountBalance), nil)
+	if len(problems) != 1 || !strings.Contains(problems[0], `unknown field "staked"`) {
+		t.Errorf("Expected an unknown field, got %q", problems)
+	}
+
+	problems = checkContract([]byte(`[{"currency":"ZAR","available":"1","total":"1"}]`), new([]valr.AccountBalance), nil)
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "[].reserved:") {
+		t.Errorf("Expected a missing field, got %q", problems)
+	}
+
+	problems = checkContract([]byte(`[{"currency":"ZAR","available":"1","total":"1"}]`), new([]valr.AccountBalance), []string{"[].reserved"})
+	if len(problems) != 0 {
+		t.Errorf("Expected no problems, got %q", problems)
+	}
+}
@@ -0,0 +1,106 @@
+package credentials
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+)
+
+// defaultCacheTTL is how long cached credentials are reused before the
+// underlying provider is consulted again.
+const defaultCacheTTL = 5 * time.Minute
+
+// RotationHook is called when a refresh returns credentials that differ from
+// the previously cached ones.
+type RotationHook func(old, new Credentials)
+
+type CacheOption func(*Cache)
+
+// WithTTL sets how long credentials are cached before being refreshed.
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithClock sets the clock used to expire credentials, clock.Real by
+// default.
+func WithClock(c clock.Clock) CacheOption {
+	return func(cc *Cache) {
+		cc.clock = c
+	}
+}
+
+// WithRotationHook registers a hook that is called whenever the cached
+// credentials change, e.g. to call SetAuth on a long-lived client. Hooks
+// are called without the cache locked, so they may call Retrieve.
+func WithRotationHook(fn RotationHook) CacheOption {
+	return func(c *Cache) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
+
+// Cache is a Provider that caches the result of another Provider, so that
+// slow or rate-limited sources such as cloud secret managers aren't queried
+// on every use.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	hooks    []RotationHook
+	clock    clock.Clock
+
+	mu        sync.Mutex
+	creds     Credentials
+	fetchedAt time.Time
+}
+
+// NewCache wraps p with a cache.
+func NewCache(p Provider, opts ...CacheOption) *Cache {
+	c := &Cache{
+		provider: p,
+		ttl:      defaultCacheTTL,
+		clock:    clock.Real,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Retrieve returns the cached credentials, refreshing them from the
+// underlying provider if they have expired.
+func (c *Cache) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	if c.creds.Valid() && c.clock.Now().Sub(c.fetchedAt) < c.ttl {
+		creds := c.creds
+		c.mu.Unlock()
+		return creds, nil
+	}
+
+	creds, err := c.provider.Retrieve(ctx)
+	if err != nil {
+		c.mu.Unlock()
+		return Credentials{}, err
+	}
+	old := c.creds
+	c.creds = creds
+	c.fetchedAt = c.clock.Now()
+	c.mu.Unlock()
+
+	if old.Valid() && old != creds {
+		for _, hook := range c.hooks {
+			hook(old, creds)
+		}
+	}
+	return creds, nil
+}
+
+// Invalidate forces the next call to Retrieve to refresh the credentials,
+// e.g. after the API rejects the current key.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchedAt = time.Time{}
+}
@@ -0,0 +1,72 @@
+package credentials_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/credentials"
+)
+
+func TestCache(t *testing.T) {
+	var calls int
+	current := credentials.Credentials{KeyID: "key1", Secret: "secret1"}
+	provider := credentials.ProviderFunc(func(context.Context) (credentials.Credentials, error) {
+		calls++
+		return current, nil
+	})
+
+	clk := clock.NewManual(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	var (
+		cache   *credentials.Cache
+		rotated []string
+	)
+	cache = credentials.NewCache(provider,
+		credentials.WithClock(clk),
+		credentials.WithTTL(time.Minute),
+		credentials.WithRotationHook(func(old, new credentials.Credentials) {
+			// Hooks may use the cache, e.g. to apply the new credentials.
+			got, err := cache.Retrieve(context.Background())
+			if err != nil || got != new {
+				t.Errorf("Expected %+v from the hook, got %+v, %v", new, got, err)
+			}
+			rotated = append(rotated, old.KeyID+"->"+new.KeyID)
+		}))
+
+	ctx := context.Background()
+	retrieve := func(wantKey string, wantCalls int) {
+		t.Helper()
+		got, err := cache.Retrieve(ctx)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if got.KeyID != wantKey || calls != wantCalls {
+			t.Errorf("Expected %s after %d calls, got %s after %d", wantKey, wantCalls, got.KeyID, calls)
+		}
+	}
+
+	retrieve("key1", 1)
+	current = credentials.Credentials{KeyID: "key2", Secret: "secret2"}
+	clk.Advance(30 * time.Second)
+	retrieve("key1", 1)
+
+	// The credentials are refreshed once the TTL has passed.
+	clk.Advance(30 * time.Second)
+	retrieve("key2", 2)
+	retrieve("key2", 2)
+
+	// Invalidate refreshes them at once.
+	current = credentials.Credentials{KeyID: "key3", Secret: "secret3"}
+	cache.Invalidate()
+	retrieve("key3", 3)
+
+	// Unchanged credentials don't call the hooks.
+	cache.Invalidate()
+	retrieve("key3", 4)
+
+	want := []string{"key1->key2", "key2->key3"}
+	if len(rotated) != len(want) || rotated[0] != want[0] || rotated[1] != want[1] {
+		t.Errorf("Expected rotations %q, got %q", want, rotated)
+	}
+}
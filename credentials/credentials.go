@@ -0,0 +1,152 @@
+// Package credentials resolves VALR API keys from the environment, files or
+// external secret managers.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/joho/godotenv"
+)
+
+const (
+	// DefaultKeyIDVar is the environment variable holding the API key ID.
+	DefaultKeyIDVar = "VA_KEY_ID"
+	// DefaultSecretVar is the environment variable holding the API secret.
+	DefaultSecretVar = "VA_SECRET"
+)
+
+// ErrNotFound is returned by a Provider that has no credentials to offer.
+var ErrNotFound = errors.New("credentials: not found")
+
+// Credentials is a VALR API key pair.
+type Credentials struct {
+	KeyID  string
+	Secret string
+}
+
+// Valid returns true if both the key ID and secret are set.
+func (c Credentials) Valid() bool {
+	return c.KeyID != "" && c.Secret != ""
+}
+
+// Provider resolves credentials from some source.
+type Provider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// ProviderFunc adapts an ordinary function to the Provider interface.
+type ProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Retrieve calls fn(ctx).
+func (fn ProviderFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return fn(ctx)
+}
+
+// FromEnv returns a Provider reading VA_KEY_ID and VA_SECRET from the
+// environment.
+func FromEnv() Provider {
+	return FromEnvVars(DefaultKeyIDVar, DefaultSecretVar)
+}
+
+// FromEnvVars returns a Provider reading the given environment variables.
+func FromEnvVars(keyIDVar, secretVar string) Provider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		c := Credentials{
+			KeyID:  os.Getenv(keyIDVar),
+			Secret: os.Getenv(secretVar),
+		}
+		if !c.Valid() {
+			return Credentials{}, fmt.Errorf("%w: %s/%s not set", ErrNotFound, keyIDVar, secretVar)
+		}
+		return c, nil
+	})
+}
+
+// FromEnvFile returns a Provider reading VA_KEY_ID and VA_SECRET from a
+// dotenv formatted file. Unlike godotenv.Load, the process environment is
+// left untouched.
+func FromEnvFile(path string) Provider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		vars, err := godotenv.Read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return Credentials{}, fmt.Errorf("%w: %s does not exist", ErrNotFound, path)
+		} else if err != nil {
+			return Credentials{}, err
+		}
+		c := Credentials{
+			KeyID:  vars[DefaultKeyIDVar],
+			Secret: vars[DefaultSecretVar],
+		}
+		if !c.Valid() {
+			return Credentials{}, fmt.Errorf("%w: %s is missing %s or %s",
+				ErrNotFound, path, DefaultKeyIDVar, DefaultSecretVar)
+		}
+		return c, nil
+	})
+}
+
+// FromFiles returns a Provider reading the key ID and secret from two files,
+// as is common with mounted Kubernetes or Docker secrets. Surrounding
+// whitespace is trimmed.
+func FromFiles(keyIDPath, secretPath string) Provider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		keyID, err := os.ReadFile(keyIDPath)
+		if err != nil {
+			return Credentials{}, err
+		}
+		secret, err := os.ReadFile(secretPath)
+		if err != nil {
+			return Credentials{}, err
+		}
+		c := Credentials{
+			KeyID:  strings.TrimSpace(string(keyID)),
+			Secret: strings.TrimSpace(string(secret)),
+		}
+		if !c.Valid() {
+			return Credentials{}, fmt.Errorf("%w: empty key files", ErrNotFound)
+		}
+		return c, nil
+	})
+}
+
+// Static returns a Provider that always returns the given credentials.
+func Static(keyID, secret string) Provider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		c := Credentials{KeyID: keyID, Secret: secret}
+		if !c.Valid() {
+			return Credentials{}, ErrNotFound
+		}
+		return c, nil
+	})
+}
+
+// Chain returns a Provider that tries each provider in turn and returns the
+// first credentials found. Errors other than ErrNotFound stop the chain.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		for _, p := range providers {
+			c, err := p.Retrieve(ctx)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			} else if err != nil {
+				return Credentials{}, err
+			}
+			return c, nil
+		}
+		return Credentials{}, ErrNotFound
+	})
+}
+
+// Apply retrieves credentials from p and sets them on the client.
+func Apply(ctx context.Context, cl *valr.Client, p Provider) error {
+	c, err := p.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.SetAuth(c.KeyID, c.Secret)
+}
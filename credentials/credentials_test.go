@@ -0,0 +1,58 @@
+package credentials_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/credentials"
+)
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	failing := credentials.ProviderFunc(func(context.Context) (credentials.Credentials, error) {
+		return credentials.Credentials{}, errors.New("unavailable")
+	})
+
+	// Providers without credentials are skipped.
+	c, err := credentials.Chain(credentials.Static("", ""), credentials.Static("key", "secret"), failing).Retrieve(ctx)
+	if err != nil || c.KeyID != "key" {
+		t.Errorf("Expected the second provider's key, got %+v, %v", c, err)
+	}
+
+	// Other errors stop the chain.
+	_, err = credentials.Chain(failing, credentials.Static("key", "secret")).Retrieve(ctx)
+	if err == nil || errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("Expected the provider's error, got %v", err)
+	}
+
+	if _, err := credentials.Chain(credentials.Static("", "")).Retrieve(ctx); !errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFromSecretManager(t *testing.T) {
+	secrets := map[string]string{
+		"valid":      `{"keyId":"key","secret":"secret"}`,
+		"malformed":  `{"keyId":"key",`,
+		"incomplete": `{"keyId":"key"}`,
+	}
+	fetcher := credentials.SecretFetcherFunc(func(_ context.Context, name string) (string, error) {
+		return secrets[name], nil
+	})
+	ctx := context.Background()
+
+	c, err := credentials.FromSecretManager(fetcher, "valid").Retrieve(ctx)
+	if err != nil || c != (credentials.Credentials{KeyID: "key", Secret: "secret"}) {
+		t.Errorf("Expected the stored key pair, got %+v, %v", c, err)
+	}
+	_, err = credentials.FromSecretManager(fetcher, "malformed").Retrieve(ctx)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) || errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+	if _, err := credentials.FromSecretManager(fetcher, "incomplete").Retrieve(ctx); !errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SecretFetcher returns the payload of a named secret. It is implemented by
+// thin adapters over cloud SDKs, for example:
+//
+//	// AWS Secrets Manager
+//	credentials.SecretFetcherFunc(func(ctx context.Context, name string) (string, error) {
+//		out, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &name})
+//		if err != nil {
+//			return "", err
+//		}
+//		return *out.SecretString, nil
+//	})
+//
+//	// GCP Secret Manager
+//	credentials.SecretFetcherFunc(func(ctx context.Context, name string) (string, error) {
+//		res, err := sm.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+//		if err != nil {
+//			return "", err
+//		}
+//		return string(res.Payload.Data), nil
+//	})
+type SecretFetcher interface {
+	FetchSecret(ctx context.Context, name string) (string, error)
+}
+
+// SecretFetcherFunc adapts an ordinary function to the SecretFetcher
+// interface.
+type SecretFetcherFunc func(ctx context.Context, name string) (string, error)
+
+// FetchSecret calls fn(ctx, name).
+func (fn SecretFetcherFunc) FetchSecret(ctx context.Context, name string) (string, error) {
+	return fn(ctx, name)
+}
+
+// FromSecretManager returns a Provider reading a single secret holding both
+// halves of the key pair as a JSON object:
+//
+//	{"keyId": "...", "secret": "..."}
+func FromSecretManager(f SecretFetcher, name string) Provider {
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		payload, err := f.FetchSecret(ctx, name)
+		if err != nil {
+			return Credentials{}, err
+		}
+		var v struct {
+			KeyID  string `json:"keyId"`
+			Secret string `json:"secret"`
+		}
+		if err := json.Unmarshal([]byte(payload), &v); err != nil {
+			return Credentials{}, fmt.Errorf("credentials: malformed secret %q: %w", name, err)
+		}
+		c := Credentials{KeyID: v.KeyID, Secret: v.Secret}
+		if !c.Valid() {
+			return Credentials{}, fmt.Errorf("%w: secret %q is incomplete", ErrNotFound, name)
+		}
+		return c, nil
+	})
+}
+
+// FromSecretPair returns a Provider reading the key ID and secret from two
+// separately stored secrets.
+func FromSecretPair(f SecretFetcher, keyIDName, secretName string) Provider {
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		keyID, err := f.FetchSecret(ctx, keyIDName)
+		if err != nil {
+			return Credentials{}, err
+		}
+		secret, err := f.FetchSecret(ctx, secretName)
+		if err != nil {
+			return Credentials{}, err
+		}
+		c := Credentials{KeyID: keyID, Secret: secret}
+		if !c.Valid() {
+			return Credentials{}, fmt.Errorf("%w: secrets %q/%q are empty", ErrNotFound, keyIDName, secretName)
+		}
+		return c, nil
+	})
+}
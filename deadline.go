@@ -0,0 +1,91 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadlineBudgetExceeded is matched (via errors.Is) by errors returned
+// when a call cannot complete within its context deadline.
+var ErrDeadlineBudgetExceeded = errors.New("valr: deadline budget exceeded")
+
+// DeadlineBudgetError describes a call that was abandoned because waiting
+// any longer (for the rate limiter or a retry) would overrun the caller's
+// context deadline.
+type DeadlineBudgetError struct {
+	Method string
+	Path   string
+	// Attempts is the number of HTTP requests that were sent.
+	Attempts int
+	// Waited is the time spent waiting before giving up.
+	Waited time.Duration
+	// Needed is the additional wait that would have been required.
+	Needed time.Duration
+	// Remaining is the time left until the deadline when the call gave up.
+	Remaining time.Duration
+}
+
+func (e *DeadlineBudgetError) Error() string {
+	return fmt.Sprintf("valr: deadline budget exceeded for %s %s after %d attempt(s): "+
+		"waited %s, needed %s more but only %s remaining",
+		e.Method, e.Path, e.Attempts, e.Waited, e.Needed, e.Remaining)
+}
+
+func (e *DeadlineBudgetError) Unwrap() error {
+	return ErrDeadlineBudgetExceeded
+}
+
+// budget tracks time spent within a single call to Client.do against the
+// deadline of the caller's context.
+type budget struct {
+	method, path string
+	deadline     time.Time
+	hasDeadline  bool
+	attempts     int
+	waited       time.Duration
+}
+
+func newBudget(ctx context.Context, method, path string) *budget {
+	deadline, ok := ctx.Deadline()
+	return &budget{
+		method:      method,
+		path:        path,
+		deadline:    deadline,
+		hasDeadline: ok,
+	}
+}
+
+// remaining returns the time left until the deadline, or -1 if the context
+// has no deadline.
+func (b *budget) remaining() time.Duration {
+	if !b.hasDeadline {
+		return -1
+	}
+	return time.Until(b.deadline)
+}
+
+// wait blocks on the limiter, accounting the time spent against the budget.
+func (b *budget) wait(ctx context.Context, l Limiter) error {
+	start := time.Now()
+	err := l.Wait(ctx)
+	b.waited += time.Since(start)
+
+	var dbe *DeadlineBudgetError
+	if errors.As(err, &dbe) {
+		return b.exceeded(dbe.Needed)
+	}
+	return err
+}
+
+func (b *budget) exceeded(needed time.Duration) *DeadlineBudgetError {
+	return &DeadlineBudgetError{
+		Method:    b.method,
+		Path:      b.path,
+		Attempts:  b.attempts,
+		Waited:    b.waited,
+		Needed:    needed,
+		Remaining: max(b.remaining(), 0),
+	}
+}
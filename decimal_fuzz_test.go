@@ -0,0 +1,53 @@
+package valr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func FuzzDecimalRoundTrip(f *testing.F) {
+	for _, s := range []string{
+		"0",
+		"0.00000001",
+		"123456789012345678901234567890.123456789012345678901234567890",
+		"-0.1",
+		"1e-30",
+		"9007199254740993",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return
+		}
+
+		// Amounts may arrive quoted or as bare JSON numbers.
+		for _, raw := range []string{`"` + d.String() + `"`, d.String()} {
+			var ms valr.MarketSummary
+			body := `{"askPrice":` + raw + `,"changeFromPrevious":` + raw + `}`
+			if err := json.Unmarshal([]byte(body), &ms); err != nil {
+				t.Fatalf("Expected success decoding %s, got %v", body, err)
+			}
+			if !ms.AskPrice.Equal(d) || !ms.ChangeFromPrevious.Equal(d) {
+				t.Fatalf("Expected %s, got %s and %s", d, ms.AskPrice, ms.ChangeFromPrevious)
+			}
+
+			b, err := json.Marshal(ms)
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			var again valr.MarketSummary
+			if err := json.Unmarshal(b, &again); err != nil {
+				t.Fatalf("Expected success decoding %s, got %v", b, err)
+			}
+			if !again.AskPrice.Equal(d) {
+				t.Fatalf("Expected %s, got %s", d, again.AskPrice)
+			}
+		}
+	})
+}
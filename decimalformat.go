@@ -0,0 +1,212 @@
+package valr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// Decimal fields of order payloads formatted by a DecimalFormat, by the
+// precision they take.
+var (
+	priceFields    = map[string]bool{"price": true, "stopPrice": true}
+	quantityFields = map[string]bool{"quantity": true, "baseAmount": true}
+	// amountFields are formatted without exponents but take no precision
+	// from the pair.
+	amountFields = map[string]bool{"quoteAmount": true, "payAmount": true, "amount": true}
+)
+
+// PairPrecision is the number of decimal places of a pair's prices and base
+// quantities.
+type PairPrecision struct {
+	Price    int32 `json:"price"`
+	Quantity int32 `json:"quantity"`
+}
+
+// PrecisionOf returns the precision of a pair from its reference data: the
+// decimal places of its tick size and its base decimal places.
+func PrecisionOf(p PairInfo) (PairPrecision, error) {
+	quantity, err := strconv.ParseInt(p.BaseDecimalPlaces, 10, 32)
+	if err != nil {
+		return PairPrecision{}, fmt.Errorf("valr: invalid base decimal places %q for %s: %w",
+			p.BaseDecimalPlaces, p.Symbol, err)
+	}
+	var price int32
+	if p.TickSize.IsPositive() && p.TickSize.Exponent() < 0 {
+		price = -p.TickSize.Exponent()
+	}
+	return PairPrecision{Price: price, Quantity: int32(quantity)}, nil
+}
+
+// DecimalFormat controls how decimals are written in the JSON bodies of
+// order requests. Every price and quantity is written in plain notation,
+// never with an exponent, and, for pairs with a known precision, with
+// exactly the pair's number of decimal places. Values with more significant
+// decimal places than the pair allows are rejected with a ValidationError
+// rather than rounded, so an order is never silently changed.
+//
+// Payloads are matched to pairs by their "pair" field, including the
+// orders of a batch.
+type DecimalFormat struct {
+	mu    sync.RWMutex
+	pairs map[string]PairPrecision
+}
+
+// NewDecimalFormat returns a format that knows no pair precisions.
+func NewDecimalFormat() *DecimalFormat {
+	return &DecimalFormat{pairs: make(map[string]PairPrecision)}
+}
+
+// SetPrecision sets the precision of pair.
+func (f *DecimalFormat) SetPrecision(pair string, p PairPrecision) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pairs[pair] = p
+}
+
+// SetPairs sets the precision of each pair from its reference data.
+func (f *DecimalFormat) SetPairs(pairs []PairInfo) error {
+	precisions := make(map[string]PairPrecision, len(pairs))
+	for _, p := range pairs {
+		prec, err := PrecisionOf(p)
+		if err != nil {
+			return err
+		}
+		precisions[p.Symbol] = prec
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for pair, prec := range precisions {
+		f.pairs[pair] = prec
+	}
+	return nil
+}
+
+// Load sets the precision of every pair listed by cl.
+func (f *DecimalFormat) Load(ctx context.Context, cl *Client) error {
+	pairs, err := cl.GetCurrencyPairs(ctx, &GetCurrencyPairsRequest{})
+	if err != nil {
+		return err
+	}
+	return f.SetPairs(pairs)
+}
+
+// Precision returns the precision of pair, if known.
+func (f *DecimalFormat) Precision(pair string) (PairPrecision, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	p, ok := f.pairs[pair]
+	return p, ok
+}
+
+// SetDecimalFormat formats the decimals of every request body the client
+// sends with f. Pass nil to send decimals as they are.
+func (cl *Client) SetDecimalFormat(f *DecimalFormat) {
+	cl.decimals = f
+}
+
+// format rewrites the decimal fields of a JSON request body. Bodies without
+// such fields are returned unchanged.
+func (f *DecimalFormat) format(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body, nil
+	}
+	changed, err := f.walk(v)
+	if err != nil || !changed {
+		return body, err
+	}
+	return json.Marshal(v)
+}
+
+// walk formats the decimal fields of the objects in v, returning true if
+// any were rewritten.
+func (f *DecimalFormat) walk(v any) (bool, error) {
+	var changed bool
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			c, err := f.walk(e)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	case map[string]any:
+		pair, _ := v["pair"].(string)
+		prec, known := f.Precision(pair)
+		for key, val := range v {
+			places := int32(-1)
+			switch {
+			case priceFields[key] && known:
+				places = prec.Price
+			case quantityFields[key] && known:
+				places = prec.Quantity
+			case !priceFields[key] && !quantityFields[key] && !amountFields[key]:
+				c, err := f.walk(val)
+				if err != nil {
+					return false, err
+				}
+				changed = changed || c
+				continue
+			}
+			formatted, err := formatDecimal(key, pair, val, places)
+			if err != nil {
+				return false, err
+			}
+			if formatted != val {
+				v[key] = formatted
+				changed = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+// formatDecimal writes the decimal val of field key in plain notation with
+// places decimal places, or as many as it has if places is negative.
+func formatDecimal(key, pair string, val any, places int32) (any, error) {
+	var s string
+	switch val := val.(type) {
+	case string:
+		s = val
+	case json.Number:
+		s = val.String()
+	default:
+		return val, nil
+	}
+	if places < 0 && !strings.ContainsAny(s, "eE") {
+		return val, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return val, nil
+	}
+	var out string
+	if places < 0 {
+		out = d.String()
+	} else {
+		if !d.Truncate(places).Equal(d) {
+			return nil, &ValidationError{
+				Field:  key,
+				Reason: fmt.Sprintf("%s has more than %d decimal places for %s", s, places, pair),
+			}
+		}
+		out = d.StringFixed(places)
+	}
+	if out == s {
+		return val, nil
+	}
+	if _, ok := val.(json.Number); ok {
+		return json.Number(out), nil
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestDecimalFormat(t *testing.T) {
+	cl, _, body := recordingServer(t, `{"id":"o1"}`)
+	f := valr.NewDecimalFormat()
+	err := f.SetPairs([]valr.PairInfo{{Symbol: "BTCZAR", TickSize: decimal.RequireFromString("0.01"), BaseDecimalPlaces: "8"}})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	cl.SetDecimalFormat(f)
+	ctx := context.Background()
+
+	_, err = cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Price:    decimal.New(1, 6),
+		Quantity: decimal.New(5, -5),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, want := range []string{`"price":"1000000.00"`, `"quantity":"0.00005000"`} {
+		if !strings.Contains(*body, want) {
+			t.Errorf("Expected %s in %s", want, *body)
+		}
+	}
+
+	// Pairs of unknown precision are sent as they are.
+	_, err = cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Pair: "ETHZAR", Side: valr.BUY,
+		Price:    decimal.RequireFromString("50000.5"),
+		Quantity: decimal.New(1, -3),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !strings.Contains(*body, `"price":"50000.5"`) || !strings.Contains(*body, `"quantity":"0.001"`) {
+		t.Errorf("Expected unformatted decimals, got %s", *body)
+	}
+
+	_, err = cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Price:    decimal.RequireFromString("1000000.001"),
+		Quantity: decimal.New(1, -3),
+	})
+	var verr *valr.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "price" {
+		t.Errorf("Expected price validation error, got %v", err)
+	}
+}
+
+func TestPrecisionOf(t *testing.T) {
+	p, err := valr.PrecisionOf(valr.PairInfo{Symbol: "XRPZAR", TickSize: decimal.RequireFromString("0.0001"), BaseDecimalPlaces: "2"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if p != (valr.PairPrecision{Price: 4, Quantity: 2}) {
+		t.Errorf("Expected {4 2}, got %+v", p)
+	}
+	if _, err := valr.PrecisionOf(valr.PairInfo{Symbol: "XRPZAR", BaseDecimalPlaces: "x"}); err == nil {
+		t.Errorf("Expected error for invalid base decimal places")
+	}
+}
@@ -0,0 +1,305 @@
+// Package deposits attributes incoming fiat deposits to the payment
+// references an application expects, such as invoice numbers, by watching
+// the account's transaction history.
+package deposits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// Event types.
+const (
+	// EventMatched deposits carry an expected reference.
+	EventMatched = "MATCHED"
+	// EventUnmatched deposits carry no expected reference.
+	EventUnmatched = "UNMATCHED"
+)
+
+const (
+	// fiatDeposit is the transaction type of fiat deposits.
+	fiatDeposit = "FIAT_DEPOSIT"
+
+	// expectedNamespace holds the registered references, keyed by
+	// normalised reference, and seenNamespace the keys of the deposits
+	// already attributed.
+	expectedNamespace = "deposits.expected"
+	seenNamespace     = "deposits.seen"
+
+	pageSize = 100
+)
+
+// ErrInvalidReference is returned when registering a reference with no
+// letters or digits.
+var ErrInvalidReference = errors.New("deposits: invalid reference")
+
+// Expected is a payment the application is waiting for.
+type Expected struct {
+	Reference string `json:"reference"`
+	// Currency and Amount are optional. A deposit in another currency
+	// doesn't match; one of another amount does, and the difference is
+	// reported in the event.
+	Currency string          `json:"currency,omitempty"`
+	Amount   decimal.Decimal `json:"amount"`
+	// Label is free form data for the application, e.g. an invoice ID.
+	Label string `json:"label,omitempty"`
+}
+
+// Event reports the attribution of a deposit.
+type Event struct {
+	Type    string
+	Deposit valr.TransactionInfo
+	// Expected is the matched payment, nil if unmatched.
+	Expected *Expected
+	// Difference is the amount deposited less the amount expected, zero if
+	// unmatched or no amount was expected.
+	Difference decimal.Decimal
+}
+
+type Option func(*Matcher)
+
+// WithStore keeps the expected references and attributed deposits in s, so
+// they survive restarts. By default an in-memory store is used.
+func WithStore(s store.Store) Option {
+	return func(m *Matcher) {
+		m.store = s
+	}
+}
+
+// WithSince sets how far back deposits are attributed. By default only
+// deposits made after the Matcher was created are.
+func WithSince(t time.Time) Option {
+	return func(m *Matcher) {
+		m.since = t
+	}
+}
+
+// WithNotifier delivers a notification of each attributed deposit to s.
+// Unmatched deposits, and matched deposits of an unexpected amount, are
+// warnings since they need a human to reconcile them.
+func WithNotifier(s notify.Sink) Option {
+	return func(m *Matcher) {
+		m.notifier = s
+	}
+}
+
+// Matcher attributes fiat deposits to expected payment references. Each
+// reference is matched at most once and each deposit is reported once.
+type Matcher struct {
+	client   *valr.Client
+	store    store.Store
+	since    time.Time
+	notifier notify.Sink
+}
+
+// NewMatcher returns a Matcher for deposits into cl's account.
+func NewMatcher(cl *valr.Client, opts ...Option) *Matcher {
+	m := &Matcher{client: cl, store: store.NewMemory(), since: time.Now()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Expect registers a payment reference to match, replacing any registered
+// with the same normalised reference.
+func (m *Matcher) Expect(ctx context.Context, e Expected) error {
+	key := normalise(e.Reference)
+	if key == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidReference, e.Reference)
+	}
+	return store.PutJSON(ctx, m.store, expectedNamespace, key, e)
+}
+
+// Forget stops matching a reference.
+func (m *Matcher) Forget(ctx context.Context, reference string) error {
+	return m.store.Delete(ctx, expectedNamespace, normalise(reference))
+}
+
+// Pending returns the references registered and not yet matched.
+func (m *Matcher) Pending(ctx context.Context) ([]Expected, error) {
+	keys, err := m.store.List(ctx, expectedNamespace)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Expected, 0, len(keys))
+	for _, key := range keys {
+		var e Expected
+		if err := store.GetJSON(ctx, m.store, expectedNamespace, key, &e); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		pending = append(pending, e)
+	}
+	return pending, nil
+}
+
+// Poll reads the fiat deposits made since the last poll and attributes them,
+// oldest first. Matched references are forgotten.
+func (m *Matcher) Poll(ctx context.Context) ([]Event, error) {
+	deposits, err := m.newDeposits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for i := len(deposits) - 1; i >= 0; i-- {
+		d := deposits[i]
+		ev := Event{Type: EventUnmatched, Deposit: d}
+		if j := match(pending, d); j >= 0 {
+			e := pending[j]
+			pending = append(pending[:j], pending[j+1:]...)
+			ev.Type, ev.Expected = EventMatched, &e
+			if e.Amount.IsPositive() {
+				ev.Difference = d.CreditValue.Sub(e.Amount)
+			}
+			if err := m.store.Delete(ctx, expectedNamespace, normalise(e.Reference)); err != nil {
+				return events, err
+			}
+		}
+		if err := m.store.Put(ctx, seenNamespace, depositKey(d), nil); err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+		m.notify(ctx, ev)
+	}
+	return events, nil
+}
+
+// notify delivers ev to the notifier, if any. Failures are logged rather
+// than failing the poll, since the deposit has been attributed.
+func (m *Matcher) notify(ctx context.Context, ev Event) {
+	if m.notifier == nil {
+		return
+	}
+	d := ev.Deposit
+	msg := notify.Message{
+		Level:  notify.Info,
+		Source: "deposits",
+		Title:  fmt.Sprintf("Deposit of %s %s matched", d.CreditValue, d.CreditCurrency),
+		Fields: []notify.Field{{Name: "reference", Value: d.AdditionalInfo.Reference}},
+		Time:   d.EventAt.Time,
+	}
+	switch {
+	case ev.Expected == nil:
+		msg.Level = notify.Warning
+		msg.Title = fmt.Sprintf("Unmatched deposit of %s %s", d.CreditValue, d.CreditCurrency)
+	case !ev.Difference.IsZero():
+		msg.Level = notify.Warning
+		msg.Fields = append(msg.Fields,
+			notify.Field{Name: "expected", Value: ev.Expected.Amount.String()},
+			notify.Field{Name: "difference", Value: ev.Difference.String()})
+	}
+	if ev.Expected != nil && ev.Expected.Label != "" {
+		msg.Fields = append(msg.Fields, notify.Field{Name: "label", Value: ev.Expected.Label})
+	}
+	if err := m.notifier.Notify(ctx, msg); err != nil {
+		log.Printf("valr/deposits: Failed to notify deposit %s: %v", depositKey(d), err)
+	}
+}
+
+// Run polls every interval until ctx is done, passing each event to handle.
+// Failed polls are logged and retried at the next interval.
+func (m *Matcher) Run(ctx context.Context, interval time.Duration, handle func(Event)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		events, err := m.Poll(ctx)
+		for _, ev := range events {
+			handle(ev)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("valr/deposits: Failed to poll deposits: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// newDeposits pages through the transaction history, newest first, until
+// it reaches transactions made before since, and returns the fiat deposits
+// not yet attributed.
+func (m *Matcher) newDeposits(ctx context.Context) ([]valr.TransactionInfo, error) {
+	var deposits []valr.TransactionInfo
+	req := &valr.GetTransactionHistoryRequest{Limit: pageSize}
+	for {
+		page, err := m.client.GetTransactionHistoryPage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Items {
+			if t.EventAt.Before(m.since) {
+				return deposits, nil
+			}
+			if t.TransactionType.Type != fiatDeposit {
+				continue
+			}
+			_, err := m.store.Get(ctx, seenNamespace, depositKey(t))
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				deposits = append(deposits, t)
+			case err != nil:
+				return nil, err
+			}
+		}
+		if !page.HasMore {
+			return deposits, nil
+		}
+		req.Skip = page.NextSkip
+	}
+}
+
+// match returns the index of the expected payment whose reference appears in
+// the deposit's reference, preferring the longest, or -1.
+func match(pending []Expected, d valr.TransactionInfo) int {
+	ref := normalise(d.AdditionalInfo.Reference)
+	best := -1
+	for i, e := range pending {
+		if e.Currency != "" && !strings.EqualFold(e.Currency, d.CreditCurrency) {
+			continue
+		}
+		key := normalise(e.Reference)
+		if strings.Contains(ref, key) && (best < 0 || len(key) > len(normalise(pending[best].Reference))) {
+			best = i
+		}
+	}
+	return best
+}
+
+// normalise upper cases a reference and strips everything but letters and
+// digits, since banks often reformat references.
+func normalise(ref string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(ref) {
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// depositKey identifies a deposit, by ID if VALR reports one.
+func depositKey(t valr.TransactionInfo) string {
+	if t.ID != "" {
+		return t.ID
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", t.EventAt.UTC().Format(time.RFC3339Nano), t.CreditCurrency, t.CreditValue, normalise(t.AdditionalInfo.Reference))
+}
@@ -0,0 +1,99 @@
+package deposits_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/deposits"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/shopspring/decimal"
+)
+
+func TestMatcher(t *testing.T) {
+	history := `[
+		{"id":"4","transactionType":{"type":"FIAT_DEPOSIT"},"creditCurrency":"ZAR","creditValue":"90","eventAt":"2024-01-01T10:04:00Z","additionalInfo":{"reference":"inv 1002"}},
+		{"id":"3","transactionType":{"type":"LIMIT_BUY"},"creditCurrency":"BTC","creditValue":"1","eventAt":"2024-01-01T10:03:00Z"},
+		{"id":"2","transactionType":{"type":"FIAT_DEPOSIT"},"creditCurrency":"ZAR","creditValue":"50","eventAt":"2024-01-01T10:02:00Z","additionalInfo":{"reference":"random"}},
+		{"id":"1","transactionType":{"type":"FIAT_DEPOSIT"},"creditCurrency":"ZAR","creditValue":"100","eventAt":"2024-01-01T10:01:00Z","additionalInfo":{"reference":"ACME INV-1001"}},
+		{"id":"0","transactionType":{"type":"FIAT_DEPOSIT"},"creditCurrency":"ZAR","creditValue":"100","eventAt":"2023-12-31T10:00:00Z","additionalInfo":{"reference":"INV-1000"}}
+	]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(history))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	ctx := context.Background()
+	var notified []string
+	notifier := notify.SinkFunc(func(ctx context.Context, msg notify.Message) error {
+		notified = append(notified, msg.Level.String()+" "+msg.Title)
+		return nil
+	})
+	m := deposits.NewMatcher(cl, deposits.WithSince(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), deposits.WithNotifier(notifier))
+	for _, e := range []deposits.Expected{
+		{Reference: "INV-1000", Label: "old"},
+		{Reference: "INV-1001", Currency: "ZAR", Amount: decimal.New(100, 0), Label: "a"},
+		{Reference: "INV-1002", Amount: decimal.New(100, 0), Label: "b"},
+		{Reference: "INV-100", Label: "prefix"},
+	} {
+		if err := m.Expect(ctx, e); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	if err := m.Expect(ctx, deposits.Expected{Reference: " - "}); err == nil {
+		t.Errorf("Expected error for empty reference")
+	}
+
+	events, err := m.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if ev := events[0]; ev.Type != deposits.EventMatched || ev.Expected.Label != "a" || !ev.Difference.IsZero() {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+	if ev := events[1]; ev.Type != deposits.EventUnmatched || ev.Deposit.ID != "2" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+	if ev := events[2]; ev.Type != deposits.EventMatched || ev.Expected.Label != "b" || !ev.Difference.Equal(decimal.New(-10, 0)) {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+
+	want := []string{
+		"info Deposit of 100 ZAR matched",
+		"warning Unmatched deposit of 50 ZAR",
+		"warning Deposit of 90 ZAR matched",
+	}
+	if strings.Join(notified, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, notified)
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(pending) != 2 || pending[0].Label != "prefix" || pending[1].Label != "old" {
+		t.Errorf("Unexpected pending references %+v", pending)
+	}
+
+	events, err = m.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected deposits to be reported once, got %+v", events)
+	}
+}
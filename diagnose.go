@@ -0,0 +1,186 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultMaxClockSkew is the largest difference between the local and server
+// clocks that Diagnose accepts by default.
+const DefaultMaxClockSkew = 5 * time.Second
+
+// Names of the checks run by Diagnose.
+const (
+	CheckConnectivity = "connectivity"
+	CheckClockSkew    = "clock_skew"
+	CheckCredentials  = "credentials"
+	CheckPermissions  = "permissions"
+	CheckRateLimit    = "rate_limit"
+)
+
+// DiagnosticCheck is the outcome of a single check run by Diagnose.
+type DiagnosticCheck struct {
+	Name string
+	OK   bool
+	// Skipped is true if the check could not be run, e.g. because the client
+	// has no credentials. Skipped checks are not failures.
+	Skipped bool
+	Detail  string
+	Latency time.Duration
+	Err     error
+}
+
+// DiagnosticReport describes the health of a client's connection to VALR.
+type DiagnosticReport struct {
+	CheckedAt time.Time
+	Checks    []DiagnosticCheck
+	// ClockSkew is the server's clock minus the local clock, estimated from
+	// the midpoint of the server time request.
+	ClockSkew time.Duration
+	// Permissions are those of the client's API key, if it could be checked.
+	Permissions []string
+	// RateLimitRemaining is the number of requests left in the current rate
+	// limit interval, or -1 if the client's limiter doesn't report it.
+	RateLimitRemaining int
+}
+
+// OK returns true if no check failed.
+func (r *DiagnosticReport) OK() bool {
+	for _, c := range r.Checks {
+		if !c.OK && !c.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+type diagnoseConfig struct {
+	maxSkew     time.Duration
+	permissions []string
+	minHeadroom int
+}
+
+type DiagnoseOption func(*diagnoseConfig)
+
+// WithMaxClockSkew sets the largest acceptable clock skew.
+func WithMaxClockSkew(d time.Duration) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.maxSkew = d
+	}
+}
+
+// WithRequiredPermissions fails the permissions check unless the API key has
+// all of the given permissions, e.g. "View access" and "Trade".
+func WithRequiredPermissions(perms ...string) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.permissions = append(c.permissions, perms...)
+	}
+}
+
+// WithMinRateLimitHeadroom fails the rate limit check if fewer than n
+// requests remain in the current interval.
+func WithMinRateLimitHeadroom(n int) DiagnoseOption {
+	return func(c *diagnoseConfig) {
+		c.minHeadroom = n
+	}
+}
+
+// Diagnose checks connectivity to the API, clock skew against the server,
+// the validity and permissions of the client's credentials and the rate
+// limit headroom, e.g. as a readiness probe. It always returns a report;
+// the error is non-nil if any check failed.
+func (cl *Client) Diagnose(ctx context.Context, opts ...DiagnoseOption) (*DiagnosticReport, error) {
+	cfg := diagnoseConfig{maxSkew: DefaultMaxClockSkew, minHeadroom: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	report := &DiagnosticReport{CheckedAt: time.Now(), RateLimitRemaining: -1}
+	report.Checks = append(report.Checks, cl.diagnoseRateLimit(report, cfg))
+
+	start := time.Now()
+	serverTime, err := cl.GetServerTimeRequest(ctx, &GetServerTimeRequest{})
+	latency := time.Since(start)
+	report.Checks = append(report.Checks, DiagnosticCheck{
+		Name:    CheckConnectivity,
+		OK:      err == nil,
+		Latency: latency,
+		Err:     err,
+	})
+
+	skew := DiagnosticCheck{Name: CheckClockSkew}
+	if err != nil {
+		skew.Skipped, skew.Detail = true, "server unreachable"
+	} else {
+		report.ClockSkew = serverTime.Time.Sub(start.Add(latency / 2))
+		skew.OK = report.ClockSkew.Abs() <= cfg.maxSkew
+		skew.Detail = fmt.Sprintf("skew %s, max %s", report.ClockSkew, cfg.maxSkew)
+	}
+	report.Checks = append(report.Checks, skew)
+
+	report.Checks = append(report.Checks, cl.diagnoseCredentials(ctx, report, cfg)...)
+
+	var errs []error
+	for _, c := range report.Checks {
+		if c.OK || c.Skipped {
+			continue
+		}
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("valr: %s check failed: %w", c.Name, c.Err))
+		} else {
+			errs = append(errs, fmt.Errorf("valr: %s check failed: %s", c.Name, c.Detail))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+func (cl *Client) diagnoseRateLimit(report *DiagnosticReport, cfg diagnoseConfig) DiagnosticCheck {
+	check := DiagnosticCheck{Name: CheckRateLimit}
+	remaining, ok := cl.RateLimitRemaining()
+	if !ok {
+		check.Skipped, check.Detail = true, "limiter does not report headroom"
+		return check
+	}
+	report.RateLimitRemaining = remaining
+	check.OK = report.RateLimitRemaining >= cfg.minHeadroom
+	check.Detail = fmt.Sprintf("%d requests remaining", report.RateLimitRemaining)
+	return check
+}
+
+func (cl *Client) diagnoseCredentials(ctx context.Context, report *DiagnosticReport, cfg diagnoseConfig) []DiagnosticCheck {
+	creds := DiagnosticCheck{Name: CheckCredentials}
+	perms := DiagnosticCheck{Name: CheckPermissions}
+	if cl.apiKeyPub == "" || cl.signer == nil {
+		creds.Skipped, creds.Detail = true, "no credentials configured"
+		perms.Skipped, perms.Detail = true, "no credentials configured"
+		return []DiagnosticCheck{creds, perms}
+	}
+
+	start := time.Now()
+	key, err := cl.GetCurrentAPIKeyRequest(ctx, &GetCurrentAPIKeyRequest{})
+	creds.Latency = time.Since(start)
+	if err != nil {
+		creds.Err = err
+		perms.Skipped, perms.Detail = true, "credentials invalid"
+		return []DiagnosticCheck{creds, perms}
+	}
+	creds.OK, creds.Detail = true, key.Label
+
+	report.Permissions = key.Permissions
+	var missing []string
+	for _, p := range cfg.permissions {
+		if !slices.Contains(key.Permissions, p) {
+			missing = append(missing, p)
+		}
+	}
+	perms.OK = len(missing) == 0
+	if perms.OK {
+		perms.Detail = fmt.Sprintf("%v", key.Permissions)
+	} else {
+		perms.Detail = fmt.Sprintf("missing %v", missing)
+	}
+	return []DiagnosticCheck{creds, perms}
+}
@@ -0,0 +1,170 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDraining is returned for order placements made while the client is
+// draining.
+var ErrDraining = errors.New("valr: client is draining")
+
+const drainPollInterval = 250 * time.Millisecond
+
+// inflightCalls counts REST calls in progress.
+type inflightCalls struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (f *inflightCalls) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+}
+
+func (f *inflightCalls) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// wait blocks until no calls are in progress or ctx is done.
+func (f *inflightCalls) wait(ctx context.Context) error {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isPlacement returns true for calls that place new orders.
+func isPlacement(method, path string) bool {
+	if method != http.MethodPost {
+		return false
+	}
+	path = strings.TrimLeft(path, "/")
+	return strings.HasPrefix(path, "orders/") || strings.HasPrefix(path, "simple/") || path == "batch/orders"
+}
+
+// trackImmediate records an IOC or FOK order placed by the client, so Drain
+// can wait for it to resolve.
+func (cl *Client) trackImmediate(req *PostLimitOrderRequest, res *PostLimitOrderResponse) {
+	if res == nil || (req.TimeInForce != ImmediateOrCancel && req.TimeInForce != FillOrKill) {
+		return
+	}
+	cl.immediateMu.Lock()
+	defer cl.immediateMu.Unlock()
+	if cl.immediate == nil {
+		cl.immediate = make(map[string]string)
+	}
+	cl.immediate[res.ID] = req.Pair
+}
+
+type drainConfig struct {
+	waitImmediate bool
+}
+
+type DrainOption func(*drainConfig)
+
+// WithDrainImmediateOrders makes Drain also wait for the IOC and FOK limit
+// orders placed by the client to be filled, cancelled or failed.
+func WithDrainImmediateOrders() DrainOption {
+	return func(c *drainConfig) {
+		c.waitImmediate = true
+	}
+}
+
+// Drain stops the client accepting new order placements, which then fail
+// with ErrDraining, and waits for REST calls in progress to complete.
+// Cancellations and other calls are still permitted. Drain returns once the
+// client is idle, or with ctx's error if that takes too long, e.g. so a
+// trading service can shut down cleanly during a rollout.
+func (cl *Client) Drain(ctx context.Context, opts ...DrainOption) error {
+	var cfg drainConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cl.draining.Store(true)
+	if err := cl.inflight.wait(ctx); err != nil {
+		return err
+	}
+	if cfg.waitImmediate {
+		return cl.awaitImmediate(ctx)
+	}
+	return nil
+}
+
+// Draining returns true if Drain has been called.
+func (cl *Client) Draining() bool {
+	return cl.draining.Load()
+}
+
+// Undrain accepts order placements again after Drain.
+func (cl *Client) Undrain() {
+	cl.draining.Store(false)
+}
+
+// awaitImmediate polls the status of tracked IOC and FOK orders until all of
+// them have resolved.
+func (cl *Client) awaitImmediate(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		cl.immediateMu.Lock()
+		orders := make(map[string]string, len(cl.immediate))
+		for id, pair := range cl.immediate {
+			orders[id] = pair
+		}
+		cl.immediateMu.Unlock()
+
+		for id, pair := range orders {
+			res, err := cl.GetOrderStatusByOrderIDRequest(ctx, &GetOrderStatusByOrderIDRequest{Pair: pair, ID: id})
+			if err != nil {
+				return err
+			}
+			switch res.OrderStatusType {
+			case "Filled", "Cancelled", "Failed":
+				cl.immediateMu.Lock()
+				delete(cl.immediate, id)
+				cl.immediateMu.Unlock()
+			}
+		}
+
+		cl.immediateMu.Lock()
+		remaining := len(cl.immediate)
+		cl.immediateMu.Unlock()
+		if remaining == 0 {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
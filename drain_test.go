@@ -0,0 +1,186 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func drainOrder(tif valr.TimeInForce) *valr.PostLimitOrderRequest {
+	return &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+		TimeInForce: tif,
+	}
+}
+
+func TestDrain(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var placed, cancelled atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			if placed.Add(1) == 1 {
+				received <- struct{}{}
+				<-release
+			}
+			w.Write([]byte(`{"id":"o1"}`))
+		case "DELETE /orders/order":
+			cancelled.Add(1)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+
+	placement := make(chan error, 1)
+	go func() {
+		_, err := cl.PostLimitOrderRequest(ctx, drainOrder(valr.GoodTillCancelled))
+		placement <- err
+	}()
+	<-received
+
+	drained := make(chan error, 1)
+	go func() { drained <- cl.Drain(ctx) }()
+
+	// Drain waits for the placement in progress.
+	select {
+	case err := <-drained:
+		t.Fatalf("Expected Drain to wait for the call in progress, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if !cl.Draining() {
+		t.Error("Expected the client draining")
+	}
+
+	// New placements fail, but orders can still be cancelled.
+	if _, err := cl.PostLimitOrderRequest(ctx, drainOrder(valr.GoodTillCancelled)); !errors.Is(err, valr.ErrDraining) {
+		t.Errorf("Expected ErrDraining, got %v", err)
+	}
+	if _, err := cl.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: "BTCZAR", ID: "o0"}); err != nil {
+		t.Errorf("Expected the cancellation to succeed, got %v", err)
+	}
+
+	close(release)
+	if err := <-placement; err != nil {
+		t.Errorf("Expected the placement in progress to succeed, got %v", err)
+	}
+	if err := <-drained; err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if n := placed.Load(); n != 1 {
+		t.Errorf("Expected 1 order placed, got %d", n)
+	}
+	if n := cancelled.Load(); n != 1 {
+		t.Errorf("Expected 1 order cancelled, got %d", n)
+	}
+
+	cl.Undrain()
+	if cl.Draining() {
+		t.Error("Expected the client not draining")
+	}
+	if _, err := cl.PostLimitOrderRequest(ctx, drainOrder(valr.GoodTillCancelled)); err != nil {
+		t.Errorf("Expected placements accepted after Undrain, got %v", err)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	defer close(release)
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	go cl.GetAllOpenOrdersRequest(context.Background(), &valr.GetAllOpenOrdersRequest{})
+	<-received
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := cl.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDrainImmediateOrders(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		placed int
+		polls  = make(map[string]int)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			// The IOC order is placed first.
+			placed++
+			if placed == 1 {
+				w.Write([]byte(`{"id":"ioc"}`))
+			} else {
+				w.Write([]byte(`{"id":"gtc"}`))
+			}
+		case "GET /orders/BTCZAR/orderid/ioc":
+			polls["ioc"]++
+			status := "Placed"
+			if polls["ioc"] >= 2 {
+				status = "Cancelled"
+			}
+			w.Write([]byte(`{"orderId":"ioc","orderStatusType":"` + status + `","currencyPair":"BTCZAR"}`))
+		case "GET /orders/BTCZAR/orderid/gtc":
+			polls["gtc"]++
+			w.Write([]byte(`{"orderId":"gtc","orderStatusType":"Placed","currencyPair":"BTCZAR"}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := cl.PostLimitOrderRequest(ctx, drainOrder(valr.ImmediateOrCancel)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := cl.PostLimitOrderRequest(ctx, drainOrder(valr.GoodTillCancelled)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	// Only the IOC order is polled, until it is cancelled.
+	if err := cl.Drain(ctx, valr.WithDrainImmediateOrders()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if polls["ioc"] != 2 || polls["gtc"] != 0 {
+		t.Errorf("Expected the IOC order polled twice and the GTC order never, got %v", polls)
+	}
+}
@@ -0,0 +1,42 @@
+package valr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Get calls a GET endpoint and decodes the response as T. Endpoints under
+// /public are called unauthenticated, all others are signed. See Call for
+// the accepted path and query formats.
+//
+//	res, err := valr.Get[[]valr.AccountBalance](ctx, cl, "/account/balances", nil)
+func Get[T any](ctx context.Context, cl *Client, path string, query any) (T, error) {
+	return callAs[T](ctx, cl, http.MethodGet, path, query)
+}
+
+// Post calls a POST endpoint with a JSON body and decodes the response as T.
+func Post[T any](ctx context.Context, cl *Client, path string, body any) (T, error) {
+	return callAs[T](ctx, cl, http.MethodPost, path, body)
+}
+
+// Delete calls a DELETE endpoint with a JSON body and decodes the response
+// as T.
+func Delete[T any](ctx context.Context, cl *Client, path string, body any) (T, error) {
+	return callAs[T](ctx, cl, http.MethodDelete, path, body)
+}
+
+func callAs[T any](ctx context.Context, cl *Client, method, path string, req any) (T, error) {
+	var res T
+	if err := cl.do(ctx, method, path, req, &res, !isPublicPath(path)); err != nil {
+		var zero T
+		return zero, err
+	}
+	return res, nil
+}
+
+// isPublicPath returns true for endpoints that don't require
+// authentication.
+func isPublicPath(path string) bool {
+	return strings.HasPrefix(strings.TrimLeft(path, "/"), "public/")
+}
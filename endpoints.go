@@ -0,0 +1,70 @@
+package valr
+
+import "net/http"
+
+// Endpoint is a REST endpoint, identified by its method and its path
+// relative to the base URL with path parameters as {tags}.
+type Endpoint struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// endpoints holds the endpoints wrapped by typed Client methods, sorted by
+// path and method.
+var endpoints = []Endpoint{
+	{http.MethodGet, "/account/api-keys/current"},
+	{http.MethodGet, "/account/balances"},
+	{http.MethodPost, "/account/subaccount"},
+	{http.MethodGet, "/account/subaccounts"},
+	{http.MethodPost, "/account/subaccounts/transfer"},
+	{http.MethodGet, "/account/transactionhistory"},
+	{http.MethodGet, "/account/{currencyPair}/tradehistory"},
+	{http.MethodPost, "/batch/orders"},
+	{http.MethodGet, "/marketdata/{currencyPair}/orderbook"},
+	{http.MethodGet, "/marketdata/{currencyPair}/orderbook/full"},
+	{http.MethodGet, "/marketdata/{currencyPair}/tradehistory"},
+	{http.MethodGet, "/orders/history"},
+	{http.MethodGet, "/orders/history/detail/customerorderid/{customerOrderId}"},
+	{http.MethodGet, "/orders/history/detail/orderid/{orderId}"},
+	{http.MethodGet, "/orders/history/summary/customerorderid/{customerOrderId}"},
+	{http.MethodGet, "/orders/history/summary/orderid/{orderId}"},
+	{http.MethodPost, "/orders/limit"},
+	{http.MethodPost, "/orders/market"},
+	{http.MethodGet, "/orders/open"},
+	{http.MethodDelete, "/orders/order"},
+	{http.MethodPost, "/orders/stop/limit"},
+	{http.MethodGet, "/orders/{currencyPair}/customerorderid/{customerOrderId}"},
+	{http.MethodGet, "/orders/{currencyPair}/orderid/{orderId}"},
+	{http.MethodPost, "/pay"},
+	{http.MethodGet, "/pay/identifier/{identifier}"},
+	{http.MethodGet, "/pay/limits"},
+	{http.MethodGet, "/positions/open"},
+	{http.MethodGet, "/public/currencies"},
+	{http.MethodGet, "/public/marketsummary"},
+	{http.MethodGet, "/public/ordertypes"},
+	{http.MethodGet, "/public/pairs"},
+	{http.MethodGet, "/public/pairs/{pairType}"},
+	{http.MethodGet, "/public/time"},
+	{http.MethodGet, "/public/{currencyPair}/marketsummary"},
+	{http.MethodGet, "/public/{currencyPair}/orderbook"},
+	{http.MethodGet, "/public/{currencyPair}/ordertypes"},
+	{http.MethodGet, "/public/{currencyPair}/trades"},
+	{http.MethodPost, "/simple/{currencyPair}/order"},
+	{http.MethodGet, "/simple/{currencyPair}/order/{orderId}"},
+	{http.MethodPost, "/simple/{currencyPair}/quote"},
+	{http.MethodGet, "/wallet/crypto/{currencyCode}/deposit/address"},
+	{http.MethodGet, "/wallet/crypto/{currencyCode}/deposit/history"},
+	{http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw"},
+	{http.MethodPost, "/wallet/crypto/{currencyCode}/withdraw"},
+	{http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw/history"},
+	{http.MethodGet, "/wallet/crypto/{currencyCode}/withdraw/{withdrawId}"},
+	{http.MethodGet, "/wallet/fiat/{currencyCode}/accounts"},
+	{http.MethodPost, "/wallet/fiat/{currencyCode}/withdraw"},
+}
+
+// Endpoints returns the REST endpoints wrapped by typed Client methods,
+// sorted by path and method. Endpoints only reachable through Call are not
+// included.
+func Endpoints() []Endpoint {
+	return append([]Endpoint(nil), endpoints...)
+}
@@ -0,0 +1,58 @@
+package valr_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// TestEndpoints checks that Endpoints lists exactly the endpoints called
+// by the typed methods in api.go.
+func TestEndpoints(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "api.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	seen := make(map[valr.Endpoint]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 3 {
+			return true
+		}
+		index, ok := call.Fun.(*ast.IndexExpr)
+		if !ok {
+			return true
+		}
+		fn, ok := index.X.(*ast.Ident)
+		lit, isLit := call.Args[2].(*ast.BasicLit)
+		if !ok || !isLit {
+			return true
+		}
+		path, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		seen[valr.Endpoint{Method: strings.ToUpper(fn.Name), Path: path}] = true
+		return true
+	})
+	var want []valr.Endpoint
+	for e := range seen {
+		want = append(want, e)
+	}
+	sort.Slice(want, func(i, j int) bool {
+		if want[i].Path != want[j].Path {
+			return want[i].Path < want[j].Path
+		}
+		return want[i].Method < want[j].Method
+	})
+	if got := valr.Endpoints(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,80 @@
+package valr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Environment describes a VALR API deployment, such as production or a mock
+// stack used in tests.
+type Environment struct {
+	Name string
+	// BaseURL is the REST base URL, including the API version.
+	BaseURL string
+	// WebSocketURL is the base URL of the streaming API, without a path.
+	WebSocketURL string
+}
+
+// Production is the live VALR environment and the default for new clients.
+var Production = Environment{
+	Name:         "production",
+	BaseURL:      defaultBaseURL,
+	WebSocketURL: "wss://api.valr.com",
+}
+
+var (
+	environmentsMu sync.RWMutex
+	environments   = map[string]Environment{Production.Name: Production}
+)
+
+// RegisterEnvironment makes env available to LookupEnvironment by name,
+// replacing any environment with the same name.
+func RegisterEnvironment(env Environment) {
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	environments[env.Name] = env
+}
+
+// LookupEnvironment returns the registered environment with the given name.
+func LookupEnvironment(name string) (Environment, error) {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	env, ok := environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("valr: unknown environment %q", name)
+	}
+	return env, nil
+}
+
+// MockEnvironment returns an environment for a mock server listening at
+// rawurl, e.g. an httptest.Server. The REST API is expected under /v1 and
+// the streaming API on the same host.
+func MockEnvironment(rawurl string) (Environment, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return Environment{}, err
+	}
+	ws := *u
+	ws.Scheme = "ws"
+	if u.Scheme == "https" {
+		ws.Scheme = "wss"
+	}
+	return Environment{
+		Name:         "mock",
+		BaseURL:      strings.TrimRight(rawurl, "/") + "/v1",
+		WebSocketURL: strings.TrimRight(ws.String(), "/"),
+	}, nil
+}
+
+// SetEnvironment points the client at env.
+func (cl *Client) SetEnvironment(env Environment) {
+	cl.baseURL = strings.TrimRight(env.BaseURL, "/")
+	cl.env = env
+}
+
+// Environment returns the environment the client is configured for.
+func (cl *Client) Environment() Environment {
+	return cl.env
+}
@@ -0,0 +1,118 @@
+package valr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// APIError is returned for calls that VALR answered with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	// Code and Message are parsed from VALR's JSON error body, if present.
+	Code    int
+	Message string
+	// RetryAfter is how long VALR asked the client to wait before retrying,
+	// from the Retry-After header of the response, or 0 if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("valr: error response (%d %s)", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+// Is makes 429 responses match ErrTooManyRequests.
+func (e *APIError) Is(target error) bool {
+	return target == ErrTooManyRequests && e.StatusCode == http.StatusTooManyRequests
+}
+
+// Retryable returns true for rate limited calls and server errors.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// Unauthorized returns true if the API key was rejected or lacks permission.
+func (e *APIError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Invalid returns true if VALR rejected the request's parameters.
+func (e *APIError) Invalid() bool {
+	return e.StatusCode == http.StatusBadRequest
+}
+
+// newAPIError builds an APIError from a response.
+func newAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		RetryAfter: parseRetryAfter(header.Get("Retry-After"), time.Now()),
+	}
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &res) == nil {
+		e.Code, e.Message = res.Code, res.Message
+	}
+	return e
+}
+
+// Invalid returns true; local validation failures are never retryable.
+func (e *ValidationError) Invalid() bool {
+	return true
+}
+
+// IsRetryable returns true if err is transient, so the call may succeed if
+// repeated: rate limiting, server errors and network timeouts. Errors from
+// other packages are classified by a Retryable() bool method.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrTooManyRequests) {
+		return true
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// IsRejected returns true if err shows that a request was definitely not
+// carried out: it failed local validation or VALR answered with a client
+// error. After other errors, such as timeouts, cancellations and server
+// errors, a request with side effects may or may not have taken effect.
+func IsRejected(err error) bool {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// IsAuthError returns true if err reports invalid credentials or missing
+// permissions. Errors from other packages are classified by an
+// Unauthorized() bool method.
+func IsAuthError(err error) bool {
+	var a interface{ Unauthorized() bool }
+	return errors.As(err, &a) && a.Unauthorized()
+}
+
+// IsValidationError returns true if err reports an invalid request, whether
+// rejected locally or by VALR. Errors from other packages are classified by
+// an Invalid() bool method.
+func IsValidationError(err error) bool {
+	var v interface{ Invalid() bool }
+	return errors.As(err, &v) && v.Invalid()
+}
@@ -0,0 +1,69 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestErrorClassification(t *testing.T) {
+	status := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code":-93,"message":"nope"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	ctx := context.Background()
+
+	tests := []struct {
+		status    int
+		retryable bool
+		auth      bool
+		invalid   bool
+	}{
+		{http.StatusTooManyRequests, true, false, false},
+		{http.StatusBadGateway, true, false, false},
+		{http.StatusUnauthorized, false, true, false},
+		{http.StatusBadRequest, false, false, true},
+	}
+	for _, test := range tests {
+		status = test.status
+		_, err := cl.GetServerTimeRequest(ctx, &valr.GetServerTimeRequest{})
+
+		var apiErr *valr.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != -93 || apiErr.Message != "nope" {
+			t.Errorf("Expected APIError with code -93, got %v", err)
+		}
+		if valr.IsRetryable(err) != test.retryable {
+			t.Errorf("%d: Expected retryable %v, got %v", test.status, test.retryable, !test.retryable)
+		}
+		if valr.IsAuthError(err) != test.auth {
+			t.Errorf("%d: Expected auth %v, got %v", test.status, test.auth, !test.auth)
+		}
+		if valr.IsValidationError(err) != test.invalid {
+			t.Errorf("%d: Expected invalid %v, got %v", test.status, test.invalid, !test.invalid)
+		}
+	}
+
+	_, err := cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: "BTCZAR", ID: "abc"})
+	var callErr *valr.CallError
+	if !errors.As(err, &callErr) {
+		t.Errorf("Expected CallError, got %v", err)
+	} else if callErr.Pair != "BTCZAR" || callErr.OrderID != "abc" ||
+		callErr.Endpoint != "/orders/{currencyPair}/orderid/{orderId}" {
+		t.Errorf("Expected call context, got %+v", callErr)
+	}
+
+	wrapped := fmt.Errorf("placing: %w", &valr.ValidationError{Field: "pair", Reason: "required"})
+	if !valr.IsValidationError(wrapped) || valr.IsRetryable(wrapped) {
+		t.Errorf("Expected non-retryable validation error, got %v", wrapped)
+	}
+}
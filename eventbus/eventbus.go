@@ -0,0 +1,135 @@
+// Package eventbus decouples the modules producing account and market events
+// from those consuming them. Producers, such as streaming connections and
+// market data feeds, publish typed events to a Bus whether they learnt of
+// them from the websocket streams or the REST API. Consumers, such as order
+// managers, loggers or bridges to other systems, subscribe to the event
+// types they need without knowing where the events came from.
+package eventbus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const defaultBuffer = 256
+
+// Bus delivers published events to subscribers. Each subscriber has its own
+// buffer: when it is full, events for that subscriber are dropped rather
+// than holding up the publisher or other subscribers.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+// New returns an empty bus.
+func New() *Bus {
+	return &Bus{subs: make(map[*subscriber]struct{})}
+}
+
+// subscriber is the untyped side of a Subscription.
+type subscriber struct {
+	accept  func(Event) bool
+	deliver func(Event) bool
+	close   func()
+	dropped atomic.Uint64
+}
+
+// Subscription receives the events of type T published to a Bus.
+type Subscription[T Event] struct {
+	// C delivers events. It is closed by Unsubscribe and Bus.Close.
+	C <-chan T
+
+	bus *Bus
+	sub *subscriber
+}
+
+// Subscribe registers a subscriber for events of type T, such as Fill, with
+// a buffer of the given size, or a default size if buffer is not positive.
+// Subscribe to Event to receive every event.
+func Subscribe[T Event](b *Bus, buffer int) *Subscription[T] {
+	return SubscribeFunc[T](b, buffer, nil)
+}
+
+// SubscribeFunc is like Subscribe but only delivers the events for which
+// filter returns true. A nil filter accepts every event of type T.
+func SubscribeFunc[T Event](b *Bus, buffer int, filter func(T) bool) *Subscription[T] {
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+	ch := make(chan T, buffer)
+	sub := &subscriber{
+		accept: func(ev Event) bool {
+			t, ok := ev.(T)
+			return ok && (filter == nil || filter(t))
+		},
+		deliver: func(ev Event) bool {
+			select {
+			case ch <- ev.(T):
+				return true
+			default:
+				return false
+			}
+		},
+		close: func() { close(ch) },
+	}
+	s := &Subscription[T]{C: ch, bus: b, sub: sub}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return s
+	}
+	b.subs[sub] = struct{}{}
+	return s
+}
+
+// Unsubscribe stops delivery to the subscriber and closes its channel.
+func (s *Subscription[T]) Unsubscribe() {
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s.sub]; !ok {
+		return
+	}
+	delete(b.subs, s.sub)
+	s.sub.close()
+}
+
+// Dropped returns the number of events dropped because the subscriber's
+// buffer was full.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.sub.dropped.Load()
+}
+
+// Publish delivers ev to every subscriber of its type without blocking.
+// Publishing to a nil or closed bus does nothing, so producers can publish
+// unconditionally.
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.accept(ev) && !s.deliver(ev) {
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Close unsubscribes all subscribers. Later subscribers are closed
+// immediately and later events are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for s := range b.subs {
+		s.close()
+	}
+	b.subs = make(map[*subscriber]struct{})
+}
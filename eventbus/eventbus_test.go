@@ -0,0 +1,89 @@
+package eventbus_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/shopspring/decimal"
+)
+
+func TestSubscribeByType(t *testing.T) {
+	b := eventbus.New()
+	defer b.Close()
+	fills := eventbus.Subscribe[eventbus.Fill](b, 0)
+	all := eventbus.Subscribe[eventbus.Event](b, 0)
+	btc := eventbus.SubscribeFunc(b, 0, func(ev eventbus.Tick) bool { return ev.Pair == "BTCZAR" })
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b.Publish(eventbus.Tick{Header: eventbus.Header{Source: eventbus.SourceREST, Time: now}, Pair: "ETHZAR"})
+	b.Publish(eventbus.Tick{Header: eventbus.Header{Source: eventbus.SourceStream, Time: now}, Pair: "BTCZAR"})
+	b.Publish(eventbus.Fill{TradeID: "t1", Quantity: decimal.New(1, 0)})
+
+	select {
+	case f := <-fills.C:
+		if f.TradeID != "t1" {
+			t.Errorf("Expected %q, got %q", "t1", f.TradeID)
+		}
+	default:
+		t.Error("Expected a fill")
+	}
+	if len(fills.C) != 0 {
+		t.Errorf("Expected only fills, got %d more", len(fills.C))
+	}
+
+	if len(all.C) != 3 {
+		t.Errorf("Expected %d events, got %d", 3, len(all.C))
+	}
+	first := <-all.C
+	if first.EventSource() != eventbus.SourceREST || !first.EventTime().Equal(now) {
+		t.Errorf("Expected REST event at %s, got %s at %s", now, first.EventSource(), first.EventTime())
+	}
+
+	if len(btc.C) != 1 {
+		t.Fatalf("Expected %d tick, got %d", 1, len(btc.C))
+	}
+	if tick := <-btc.C; tick.Pair != "BTCZAR" {
+		t.Errorf("Expected %q, got %q", "BTCZAR", tick.Pair)
+	}
+}
+
+func TestDropWhenFull(t *testing.T) {
+	b := eventbus.New()
+	defer b.Close()
+	s := eventbus.Subscribe[eventbus.Balance](b, 1)
+
+	b.Publish(eventbus.Balance{Currency: "ZAR"})
+	b.Publish(eventbus.Balance{Currency: "BTC"})
+	if s.Dropped() != 1 {
+		t.Errorf("Expected %d dropped, got %d", 1, s.Dropped())
+	}
+	if ev := <-s.C; ev.Currency != "ZAR" {
+		t.Errorf("Expected %q, got %q", "ZAR", ev.Currency)
+	}
+}
+
+func TestUnsubscribeAndClose(t *testing.T) {
+	b := eventbus.New()
+	s := eventbus.Subscribe[eventbus.Order](b, 0)
+	s.Unsubscribe()
+	s.Unsubscribe()
+	if _, ok := <-s.C; ok {
+		t.Error("Expected channel to be closed")
+	}
+	b.Publish(eventbus.Order{OrderID: "o1"})
+
+	other := eventbus.Subscribe[eventbus.Order](b, 0)
+	b.Close()
+	if _, ok := <-other.C; ok {
+		t.Error("Expected channel to be closed")
+	}
+	late := eventbus.Subscribe[eventbus.Order](b, 0)
+	if _, ok := <-late.C; ok {
+		t.Error("Expected channel to be closed")
+	}
+	b.Publish(eventbus.Order{OrderID: "o2"})
+
+	var nilBus *eventbus.Bus
+	nilBus.Publish(eventbus.Order{OrderID: "o3"})
+}
@@ -0,0 +1,99 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Sources of events.
+const (
+	SourceStream = "stream"
+	SourceREST   = "rest"
+)
+
+// Event is an event published to a Bus: an Order, Fill, Balance, Tick,
+// Trade or PairStatus.
+type Event interface {
+	// EventSource returns SourceStream or SourceREST.
+	EventSource() string
+	// EventTime returns when the event happened, as reported by VALR where
+	// available.
+	EventTime() time.Time
+}
+
+// Header holds the fields common to all events.
+type Header struct {
+	Source string
+	Time   time.Time
+}
+
+func (h Header) EventSource() string  { return h.Source }
+func (h Header) EventTime() time.Time { return h.Time }
+
+// Order is a change in the status of one of the account's orders.
+type Order struct {
+	Header
+	OrderID           string
+	CustomerOrderID   string
+	Pair              string
+	Side              valr.ResponseSide
+	Type              string
+	Status            string
+	FailedReason      string
+	Price             decimal.Decimal
+	OriginalQuantity  decimal.Decimal
+	RemainingQuantity decimal.Decimal
+}
+
+// Fill is a trade against one of the account's orders.
+type Fill struct {
+	Header
+	TradeID  string
+	OrderID  string
+	Pair     string
+	Side     valr.ResponseSide
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	// Fee and FeeCurrency are zero if the source doesn't report fees.
+	Fee         decimal.Decimal
+	FeeCurrency string
+}
+
+// Balance is the new balance of one of the account's currencies.
+type Balance struct {
+	Header
+	Currency  string
+	Available decimal.Decimal
+	Reserved  decimal.Decimal
+	Total     decimal.Decimal
+}
+
+// Tick is the best bid, best ask and last traded price of a pair.
+type Tick struct {
+	Header
+	Pair string
+	Bid  decimal.Decimal
+	Ask  decimal.Decimal
+	Last decimal.Decimal
+}
+
+// Trade is a trade on the market of a pair, made by any account.
+type Trade struct {
+	Header
+	TradeID   string
+	Pair      string
+	Price     decimal.Decimal
+	Quantity  decimal.Decimal
+	TakerSide string
+}
+
+// PairStatus is a change in whether a pair can be traded, such as the pair
+// becoming inactive or being delisted. Status is one of the refdata pair
+// statuses.
+type PairStatus struct {
+	Header
+	Pair   string
+	Status string
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/donohutcheon/valr-go"
-	"github.com/joho/godotenv"
+	"github.com/donohutcheon/valr-go/credentials"
 	"log"
 	"os"
 	"os/signal"
@@ -14,29 +14,14 @@ import (
 	"time"
 )
 
-// Function to check if file exists and return a bool.
-func fileExists(filename string) bool {
-	info, err := os.Stat(filename)
-	if os.IsNotExist(err) {
-		return false
-	}
-
-	return !info.IsDir()
-}
-
-func maybeLoadEnvFile() {
-	if !fileExists(".env") {
-		return
-	}
-
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Printf("Error loading .env file: %s", err.Error())
-	}
-}
+// envProvider resolves credentials from a local .env file, falling back to the
+// process environment.
+var envProvider = credentials.Chain(
+	credentials.FromEnvFile(".env"),
+	credentials.FromEnv(),
+)
 
 func main() {
-	maybeLoadEnvFile()
 	// Create a channel to receive OS signals.
 	sigs := make(chan os.Signal, 1)
 
@@ -65,7 +50,12 @@ func main() {
 
 func pollMarketsForever(ctx context.Context) {
 	client := valr.NewClient()
-	client.SetAuth(os.Getenv("VA_KEY_ID"), os.Getenv("VA_SECRET"))
+	defer client.Close()
+	// Ride out rate limit bursts rather than giving up.
+	client.SetRetryPolicy(valr.DefaultRetryPolicy())
+	if err := credentials.Apply(ctx, client, envProvider); err != nil {
+		log.Fatal(err)
+	}
 	endTime := time.Now()
 	startTime := endTime.Add(-6 * time.Minute)
 	req := &valr.GetAuthTradeHistoryForPairRequest{
@@ -80,7 +70,7 @@ func pollMarketsForever(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		default:
-			resp, err := client.GetAuthTradeHistoryForPairRequest(ctx, req)
+			page, err := client.GetAuthTradeHistoryForPairPage(ctx, req)
 			if errors.Is(err, valr.ErrTooManyRequests) {
 				log.Fatal(err)
 			}
@@ -89,25 +79,26 @@ func pollMarketsForever(ctx context.Context) {
 				return
 			}
 
-			if len(resp) == 0 {
-				continue
-			}
-			fmt.Printf("got trades, %d, %d, %d\n", resp[0].SequenceID, resp[len(resp)-1].SequenceID, len(resp))
-			if len(resp) < 100 {
-			} else {
-				req.Skip += 100
+			resp := page.Items
+			if len(resp) > 0 {
+				fmt.Printf("got trades, %d, %d, %d\n", resp[0].SequenceID, resp[len(resp)-1].SequenceID, len(resp))
 			}
 
 			for _, trade := range resp {
 				fmt.Printf("Trade:\n\tPair: %s\n\tTaker's Side: %s\n\tPrice: %s\n\tQuantity: %s\n\tTimestamp: %s\n\tSequence: %d\n\tTrade ID: %s\n", trade.Pair, trade.TakerSide, trade.Price, trade.Quantity, trade.TradedAt, trade.SequenceID, trade.ID)
 			}
+
+			if !page.HasMore {
+				return
+			}
+			req.Skip = page.NextSkip
 		}
-		return
 	}
 }
 
 func listSupportedPairs(ctx context.Context) {
 	client := valr.NewClient()
+	defer client.Close()
 	req := &valr.GetCurrencyPairsByTypeRequest{
 		PairType: valr.PairTypeSpot,
 	}
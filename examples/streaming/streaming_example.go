@@ -3,37 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/donohutcheon/valr-go/credentials"
 	streaming2 "github.com/donohutcheon/valr-go/streaming"
-	"github.com/joho/godotenv"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
-// Function to check if file exists and return a bool.
-func fileExists(filename string) bool {
-	info, err := os.Stat(filename)
-	if os.IsNotExist(err) {
-		return false
-	}
-
-	return !info.IsDir()
-}
-
-func maybeLoadEnvFile() {
-	if !fileExists(".env") {
-		return
-	}
-
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Printf("Error loading .env file: %s", err.Error())
-	}
-}
+// envProvider resolves credentials from a local .env file, falling back to the
+// process environment.
+var envProvider = credentials.Chain(
+	credentials.FromEnvFile(".env"),
+	credentials.FromEnv(),
+)
 
 func main() {
-	maybeLoadEnvFile()
 	// Create a channel to receive OS signals.
 	sigs := make(chan os.Signal, 1)
 
@@ -59,9 +44,13 @@ func main() {
 }
 
 func streamMarketsForever(ctx context.Context) {
+	creds, err := envProvider.Retrieve(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
 	c, err := streaming2.Dial(
-		os.Getenv("VA_KEY_ID"),
-		os.Getenv("VA_SECRET"),
+		creds.KeyID,
+		creds.Secret,
 		streaming2.WithUpdateCallback(tradeUpdateCallback(ctx)),
 	)
 	if err != nil {
@@ -69,7 +58,10 @@ func streamMarketsForever(ctx context.Context) {
 	}
 	defer c.Close()
 
-	c.SubscribeToMarkets([]string{"BTCZAR", "ETHZAR", "SOLZAR"})
+	ack := c.SubscribeToMarkets([]string{"BTCZAR", "ETHZAR", "SOLZAR"})
+	if err := ack.Wait(ctx); err != nil {
+		log.Fatal(err)
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,169 @@
+// Package exchange defines exchange-agnostic interfaces for market data,
+// trading and account streams, so multi-exchange systems can be written
+// against the abstraction. VALR is the reference implementation; adapters
+// for other venues implement the same interfaces with the same semantics.
+package exchange
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnsupported is returned for requests an exchange cannot serve, e.g. an
+// order type it does not offer.
+var ErrUnsupported = errors.New("exchange: unsupported")
+
+// Side is the side of an order or trade.
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// OrderType is the type of an order.
+type OrderType string
+
+const (
+	// Limit orders rest on the book at their price.
+	Limit OrderType = "limit"
+	// Market orders take liquidity at any price.
+	Market OrderType = "market"
+)
+
+// OrderStatus is the state of an order.
+type OrderStatus string
+
+const (
+	StatusOpen            OrderStatus = "open"
+	StatusPartiallyFilled OrderStatus = "partiallyFilled"
+	StatusFilled          OrderStatus = "filled"
+	StatusCancelled       OrderStatus = "cancelled"
+	StatusFailed          OrderStatus = "failed"
+)
+
+// Done returns true for the statuses of orders that will not change again.
+func (s OrderStatus) Done() bool {
+	return s == StatusFilled || s == StatusCancelled || s == StatusFailed
+}
+
+// Quote is the best prices of a pair.
+type Quote struct {
+	Pair string
+	Bid  decimal.Decimal
+	Ask  decimal.Decimal
+	// Last is the price of the last trade.
+	Last decimal.Decimal
+	Time time.Time
+}
+
+// Level is a price level of an order book.
+type Level struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+}
+
+// Book is an order book, best prices first.
+type Book struct {
+	Pair string
+	Bids []Level
+	Asks []Level
+	Time time.Time
+}
+
+// OrderRequest is an order to place. Quantities are in the base currency.
+type OrderRequest struct {
+	Pair     string
+	Side     Side
+	Type     OrderType
+	Quantity decimal.Decimal
+	// Price is the limit price, ignored for market orders.
+	Price decimal.Decimal
+	// PostOnly rejects limit orders that would take liquidity.
+	PostOnly bool
+	// ClientOrderID, if set, identifies the order to the caller.
+	ClientOrderID string
+}
+
+// Order is the state of an order.
+type Order struct {
+	ID            string
+	ClientOrderID string
+	Pair          string
+	Side          Side
+	Type          OrderType
+	Status        OrderStatus
+	Price         decimal.Decimal
+	Quantity      decimal.Decimal
+	Filled        decimal.Decimal
+	// Reason explains why the order failed.
+	Reason string
+	Time   time.Time
+}
+
+// Fill is a trade of one of the account's orders.
+type Fill struct {
+	TradeID  string
+	OrderID  string
+	Pair     string
+	Side     Side
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	Time     time.Time
+}
+
+// Balance is the balance of a currency.
+type Balance struct {
+	Currency  string
+	Available decimal.Decimal
+	Reserved  decimal.Decimal
+	Total     decimal.Decimal
+}
+
+// AccountEvent is a change to the account. Exactly one field is set.
+type AccountEvent struct {
+	Order   *Order
+	Fill    *Fill
+	Balance *Balance
+}
+
+// Ticker fetches the best prices of pairs.
+type Ticker interface {
+	Ticker(ctx context.Context, pair string) (Quote, error)
+}
+
+// OrderBook fetches order books.
+type OrderBook interface {
+	// OrderBook returns up to depth levels of each side of the pair's
+	// book, or every level if depth is not positive.
+	OrderBook(ctx context.Context, pair string, depth int) (Book, error)
+}
+
+// Trader places and cancels orders and reports the account's state.
+type Trader interface {
+	// PlaceOrder places req, returning the exchange's ID of the order.
+	PlaceOrder(ctx context.Context, req OrderRequest) (string, error)
+	CancelOrder(ctx context.Context, pair, orderID string) error
+	OpenOrders(ctx context.Context) ([]Order, error)
+	Balances(ctx context.Context) ([]Balance, error)
+}
+
+// AccountStream streams changes to the account.
+type AccountStream interface {
+	// SubscribeAccount delivers order, fill and balance changes until ctx
+	// is done and then closes the channel.
+	SubscribeAccount(ctx context.Context) (<-chan AccountEvent, error)
+}
+
+// Exchange is a venue implementing every interface.
+type Exchange interface {
+	// Name identifies the exchange, e.g. "valr".
+	Name() string
+	Ticker
+	OrderBook
+	Trader
+	AccountStream
+}
@@ -0,0 +1,278 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+var _ Exchange = (*VALR)(nil)
+
+const accountBuffer = 256
+
+type VALROption func(*VALR)
+
+// WithAccountCredentials sets the API key SubscribeAccount authenticates
+// the account stream with.
+func WithAccountCredentials(keyID, secret string) VALROption {
+	return func(v *VALR) {
+		v.keyID, v.secret = keyID, secret
+	}
+}
+
+// WithDialOptions adds options for dialling the account stream, which
+// otherwise connects to the environment of the client.
+func WithDialOptions(opts ...streaming.DialOption) VALROption {
+	return func(v *VALR) {
+		v.dialOpts = append(v.dialOpts, opts...)
+	}
+}
+
+// VALR is the Exchange implementation for VALR.
+type VALR struct {
+	client   *valr.Client
+	keyID    string
+	secret   string
+	dialOpts []streaming.DialOption
+}
+
+// NewVALR returns VALR trading through cl.
+func NewVALR(cl *valr.Client, opts ...VALROption) *VALR {
+	v := &VALR{client: cl}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Name implements Exchange.
+func (v *VALR) Name() string { return "valr" }
+
+// Ticker implements Ticker from the pair's market summary.
+func (v *VALR) Ticker(ctx context.Context, pair string) (Quote, error) {
+	s, err := v.client.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: pair})
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{Pair: pair, Bid: s.BidPrice, Ask: s.AskPrice, Last: s.LastPrice, Time: s.Created.Time}, nil
+}
+
+// OrderBook implements OrderBook.
+func (v *VALR) OrderBook(ctx context.Context, pair string, depth int) (Book, error) {
+	ob, err := v.client.GetOrderBook(ctx, &valr.GetOrderBookRequest{Pair: pair})
+	if err != nil {
+		return Book{}, err
+	}
+	return Book{Pair: pair, Bids: levels(ob.Bids, depth), Asks: levels(ob.Asks, depth), Time: ob.LastChange.Time}, nil
+}
+
+func levels(entries []valr.OrderBookEntry, depth int) []Level {
+	if depth > 0 && len(entries) > depth {
+		entries = entries[:depth]
+	}
+	out := make([]Level, len(entries))
+	for i, e := range entries {
+		out[i] = Level{Price: e.Price, Quantity: e.Quantity}
+	}
+	return out
+}
+
+// PlaceOrder implements Trader. Limit orders are good till cancelled and
+// market orders are sized in the base currency on either side.
+func (v *VALR) PlaceOrder(ctx context.Context, req OrderRequest) (string, error) {
+	side, err := requestSide(req.Side)
+	if err != nil {
+		return "", err
+	}
+	switch req.Type {
+	case Limit:
+		res, err := v.client.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+			Pair:            req.Pair,
+			Quantity:        req.Quantity,
+			Price:           req.Price,
+			Side:            side,
+			PostOnly:        req.PostOnly,
+			CustomerOrderID: req.ClientOrderID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return res.ID, nil
+	case Market:
+		res, err := v.client.PostMarketBaseAmountRequest(ctx, &valr.PostMarketOrderBaseAmountRequest{
+			Side:            side,
+			Quantity:        req.Quantity,
+			Pair:            req.Pair,
+			CustomerOrderID: req.ClientOrderID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return res.ID, nil
+	default:
+		return "", fmt.Errorf("%w: order type %q", ErrUnsupported, req.Type)
+	}
+}
+
+// CancelOrder implements Trader.
+func (v *VALR) CancelOrder(ctx context.Context, pair, orderID string) error {
+	_, err := v.client.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: pair, ID: orderID})
+	return err
+}
+
+// OpenOrders implements Trader.
+func (v *VALR) OpenOrders(ctx context.Context) ([]Order, error) {
+	res, err := v.client.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]Order, len(res))
+	for i, o := range res {
+		filled := o.OriginalQuantity.Sub(o.RemainingQuantity)
+		status := StatusOpen
+		if filled.IsPositive() {
+			status = StatusPartiallyFilled
+		}
+		orders[i] = Order{
+			ID:            o.OrderID,
+			ClientOrderID: o.CustomerOrderID,
+			Pair:          o.Pair,
+			Side:          Side(o.Side),
+			Type:          Limit,
+			Status:        status,
+			Price:         o.Price,
+			Quantity:      o.OriginalQuantity,
+			Filled:        filled,
+			Time:          o.CreatedAt.Time,
+		}
+	}
+	return orders, nil
+}
+
+// Balances implements Trader.
+func (v *VALR) Balances(ctx context.Context) ([]Balance, error) {
+	res, err := v.client.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, len(res))
+	for i, b := range res {
+		balances[i] = Balance{Currency: b.Currency, Available: b.Available, Reserved: b.Reserved, Total: b.Total}
+	}
+	return balances, nil
+}
+
+// SubscribeAccount implements AccountStream over the account websocket,
+// which requires WithAccountCredentials. Events are not dropped: the
+// stream blocks while the channel is full.
+func (v *VALR) SubscribeAccount(ctx context.Context) (<-chan AccountEvent, error) {
+	out := make(chan AccountEvent, accountBuffer)
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	send := func(ev AccountEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case out <- ev:
+		case <-ctx.Done():
+		}
+	}
+
+	opts := append([]streaming.DialOption{
+		streaming.WithEnvironment(v.client.Environment()),
+		streaming.WithOrderStatusUpdateCallback(func(m streaming.MessageOrderStatusUpdate) {
+			o := orderFromStatus(m.Data)
+			send(AccountEvent{Order: &o})
+		}),
+		streaming.WithAccountTradeCallback(func(m streaming.MessageAccountTrade) {
+			t := m.Data
+			send(AccountEvent{Fill: &Fill{
+				TradeID:  t.ID,
+				OrderID:  t.OrderID,
+				Pair:     t.CurrencyPair,
+				Side:     Side(t.Side),
+				Price:    t.Price,
+				Quantity: t.Quantity,
+				Time:     t.TradedAt.Time,
+			}})
+		}),
+		streaming.WithBalanceUpdateCallback(func(m streaming.MessageBalanceUpdate) {
+			b := m.Data
+			send(AccountEvent{Balance: &Balance{
+				Currency:  b.Currency.Symbol,
+				Available: b.Available,
+				Reserved:  b.Reserved,
+				Total:     b.Total,
+			}})
+		}),
+	}, v.dialOpts...)
+	conn, err := streaming.DialAccount(v.keyID, v.secret, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+		mu.Lock()
+		closed = true
+		close(out)
+		mu.Unlock()
+	}()
+	return out, nil
+}
+
+func requestSide(s Side) (valr.RequestSide, error) {
+	switch s {
+	case Buy:
+		return valr.BUY, nil
+	case Sell:
+		return valr.SELL, nil
+	default:
+		return "", fmt.Errorf("%w: side %q", ErrUnsupported, s)
+	}
+}
+
+// orderFromStatus converts a VALR order status update.
+func orderFromStatus(s valr.OrderStatus) Order {
+	return Order{
+		ID:            s.OrderID,
+		ClientOrderID: s.CustomerOrderID,
+		Pair:          s.Pair,
+		Side:          Side(s.OrderSide),
+		Type:          OrderType(strings.ToLower(s.OrderType)),
+		Status:        orderStatus(s.OrderStatusType),
+		Price:         s.OriginalPrice,
+		Quantity:      s.OriginalQuantity,
+		Filled:        s.OriginalQuantity.Sub(s.RemainingQuantity),
+		Reason:        s.FailedReason,
+		Time:          s.OrderUpdatedAt.Time,
+	}
+}
+
+// orderStatus maps VALR's order statuses, such as "Partially Filled".
+func orderStatus(s string) OrderStatus {
+	switch s {
+	case "Placed":
+		return StatusOpen
+	case "Partially Filled":
+		return StatusPartiallyFilled
+	case "Filled":
+		return StatusFilled
+	case "Cancelled":
+		return StatusCancelled
+	case "Failed":
+		return StatusFailed
+	default:
+		return OrderStatus(strings.ToLower(s))
+	}
+}
@@ -0,0 +1,105 @@
+package exchange_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/exchange"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/shopspring/decimal"
+)
+
+func TestVALR(t *testing.T) {
+	srv := httptest.NewServer(sim.New(
+		sim.WithMarket(sim.Market{Pair: "BTCZAR", Price: decimal.New(1000000, 0)}),
+		sim.WithBalance("ZAR", decimal.New(100000, 0)),
+	))
+	defer srv.Close()
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetEnvironment(env)
+	cl.SetAuth("key", "secret")
+
+	var ex exchange.Exchange = exchange.NewVALR(cl, exchange.WithAccountCredentials("key", "secret"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q, err := ex.Ticker(ctx, "BTCZAR")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !q.Bid.LessThan(q.Ask) {
+		t.Errorf("Expected bid below ask, got %+v", q)
+	}
+	book, err := ex.OrderBook(ctx, "BTCZAR", 1)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(book.Bids) != 1 || len(book.Asks) != 1 {
+		t.Errorf("Expected one level a side, got %+v", book)
+	}
+
+	events, err := ex.SubscribeAccount(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	id, err := ex.PlaceOrder(ctx, exchange.OrderRequest{
+		Pair:          "BTCZAR",
+		Side:          exchange.Buy,
+		Type:          exchange.Limit,
+		Quantity:      decimal.New(1, -2),
+		Price:         decimal.New(500000, 0),
+		ClientOrderID: "resting",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	orders, err := ex.OpenOrders(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(orders) != 1 || orders[0].ID != id || orders[0].ClientOrderID != "resting" ||
+		orders[0].Side != exchange.Buy || orders[0].Status != exchange.StatusOpen {
+		t.Errorf("Expected the resting order, got %+v", orders)
+	}
+	if err := ex.CancelOrder(ctx, "BTCZAR", id); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var cancelled bool
+	for !cancelled {
+		select {
+		case ev := <-events:
+			if ev.Order != nil && ev.Order.ID == id && ev.Order.Status == exchange.StatusCancelled {
+				cancelled = true
+				if !ev.Order.Status.Done() || ev.Order.Type != exchange.Limit {
+					t.Errorf("Expected a done limit order, got %+v", ev.Order)
+				}
+			}
+		case <-ctx.Done():
+			t.Fatal("Expected the order to be cancelled")
+		}
+	}
+
+	balances, err := ex.Balances(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, b := range balances {
+		if b.Currency == "ZAR" && (!b.Total.Equal(decimal.New(100000, 0)) || !b.Reserved.IsZero()) {
+			t.Errorf("Expected ZAR to be released, got %+v", b)
+		}
+	}
+
+	if _, err := ex.PlaceOrder(ctx, exchange.OrderRequest{Pair: "BTCZAR", Side: exchange.Buy, Type: "stop"}); err == nil {
+		t.Error("Expected an unsupported order type error")
+	}
+}
@@ -0,0 +1,80 @@
+package valr
+
+import (
+	"context"
+	"errors"
+)
+
+// FlattenOptions controls which positions FlattenPositions closes.
+type FlattenOptions struct {
+	// Pairs restricts flattening to the given pairs. All open positions are
+	// flattened if empty.
+	Pairs []string
+	// CustomerOrderIDPrefix, if set, is combined with the position ID to
+	// form the customerOrderId of each closing order.
+	CustomerOrderIDPrefix string
+}
+
+// FlattenResult is the outcome of closing a single position.
+type FlattenResult struct {
+	Position OpenPosition
+	OrderID  string
+	Err      error
+}
+
+// FlattenPositions closes open futures positions by submitting a reduce-only
+// market order opposite to each position. Every position is attempted even
+// if earlier ones fail; the returned results describe each one.
+func (cl *Client) FlattenPositions(ctx context.Context, opts FlattenOptions) ([]FlattenResult, error) {
+	positions, err := cl.GetOpenPositionsRequest(ctx, &GetOpenPositionsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	include := make(map[string]bool, len(opts.Pairs))
+	for _, pair := range opts.Pairs {
+		include[pair] = true
+	}
+
+	var (
+		results []FlattenResult
+		failed  bool
+	)
+	for _, p := range positions {
+		if len(include) > 0 && !include[p.Pair] {
+			continue
+		}
+		if p.Quantity.IsZero() {
+			continue
+		}
+
+		side := SELL
+		if p.Side == ResponseSideSell {
+			side = BUY
+		}
+		req := &PostMarketOrderBaseAmountRequest{
+			Side:       side,
+			Quantity:   p.Quantity.Abs(),
+			Pair:       p.Pair,
+			ReduceOnly: true,
+		}
+		if opts.CustomerOrderIDPrefix != "" {
+			req.CustomerOrderID = opts.CustomerOrderIDPrefix + p.PositionID
+		}
+
+		res := FlattenResult{Position: p}
+		resp, err := cl.PostMarketBaseAmountRequest(ctx, req)
+		if err != nil {
+			res.Err = err
+			failed = true
+		} else {
+			res.OrderID = resp.ID
+		}
+		results = append(results, res)
+	}
+
+	if failed {
+		return results, errors.New("valr: failed to flatten all positions")
+	}
+	return results, nil
+}
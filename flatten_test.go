@@ -0,0 +1,127 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// flattenServer serves open positions and records the closing orders
+// placed, failing those for failPair.
+func flattenServer(t *testing.T, failPair string) (*valr.Client, func() []map[string]any) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		orders []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /positions/open":
+			w.Write([]byte(`[
+				{"pair":"BTCUSDTPERP","side":"buy","quantity":"0.5","positionId":"p1"},
+				{"pair":"ETHUSDTPERP","side":"sell","quantity":"2","positionId":"p2"},
+				{"pair":"SOLUSDTPERP","side":"buy","quantity":"0","positionId":"p3"}
+			]`))
+		case "POST /orders/market":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Expected a JSON order, got %v", err)
+			}
+			mu.Lock()
+			orders = append(orders, body)
+			mu.Unlock()
+			if body["pair"] == failPair {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-1,"message":"Insufficient margin"}`))
+				return
+			}
+			w.Write([]byte(`{"id":"close-` + body["pair"].(string) + `"}`))
+		default:
+			t.Errorf("Unexpected call %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]any(nil), orders...)
+	}
+}
+
+func TestFlattenPositions(t *testing.T) {
+	cl, orders := flattenServer(t, "")
+	results, err := cl.FlattenPositions(context.Background(), valr.FlattenOptions{CustomerOrderIDPrefix: "flat-"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	// Each open position is closed in full on the opposite side, and the
+	// empty position is skipped.
+	want := []map[string]any{
+		{"pair": "BTCUSDTPERP", "side": "SELL", "baseAmount": "0.5", "reduceOnly": true, "customerOrderId": "flat-p1"},
+		{"pair": "ETHUSDTPERP", "side": "BUY", "baseAmount": "2", "reduceOnly": true, "customerOrderId": "flat-p2"},
+	}
+	got := orders()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d orders, got %v", len(want), got)
+	}
+	for i := range want {
+		for k, v := range want[i] {
+			if got[i][k] != v {
+				t.Errorf("Expected order %d %s %v, got %v", i, k, v, got[i][k])
+			}
+		}
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+	for i, id := range []string{"close-BTCUSDTPERP", "close-ETHUSDTPERP"} {
+		if results[i].OrderID != id || results[i].Err != nil || results[i].Position.Pair != want[i]["pair"] {
+			t.Errorf("Expected result %d for order %s, got %+v", i, id, results[i])
+		}
+	}
+}
+
+func TestFlattenPositionsPairs(t *testing.T) {
+	cl, orders := flattenServer(t, "")
+	results, err := cl.FlattenPositions(context.Background(), valr.FlattenOptions{Pairs: []string{"ETHUSDTPERP"}})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	got := orders()
+	if len(got) != 1 || got[0]["pair"] != "ETHUSDTPERP" || len(results) != 1 {
+		t.Fatalf("Expected only ETHUSDTPERP flattened, got %v", got)
+	}
+	if id := got[0]["customerOrderId"]; id != "" {
+		t.Errorf("Expected no customer order ID, got %v", id)
+	}
+}
+
+func TestFlattenPositionsFailure(t *testing.T) {
+	cl, orders := flattenServer(t, "BTCUSDTPERP")
+	results, err := cl.FlattenPositions(context.Background(), valr.FlattenOptions{})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	// The later position is still closed.
+	if n := len(orders()); n != 2 {
+		t.Errorf("Expected 2 orders attempted, got %d", n)
+	}
+	if len(results) != 2 || results[0].Err == nil || results[1].Err != nil || results[1].OrderID != "close-ETHUSDTPERP" {
+		t.Errorf("Expected the first position failed and the second closed, got %+v", results)
+	}
+}
@@ -0,0 +1,173 @@
+// Package fx converts amounts between currencies using VALR prices, routing
+// through an intermediate currency when no direct pair is listed.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoRoute is returned when no listed pair, or pair of pairs, connects two
+// currencies.
+var ErrNoRoute = errors.New("fx: no conversion route")
+
+// precision is the number of decimal places kept in inverted rates.
+const precision = 16
+
+// DefaultVia is the currencies conversions are routed through, in order of
+// preference, when there is no direct pair.
+var DefaultVia = []string{"ZAR", "USDT"}
+
+type Option func(*Converter)
+
+// WithVia sets the intermediate currencies tried, in order, when there is no
+// direct pair.
+func WithVia(currencies ...string) Option {
+	return func(c *Converter) {
+		c.via = currencies
+	}
+}
+
+// WithCacheTTL caches rates for d, reducing requests to a live source such
+// as marketdata.RESTTickers.
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *Converter) {
+		c.ttl = d
+	}
+}
+
+type cachedRate struct {
+	rate decimal.Decimal
+	at   time.Time
+}
+
+// Converter converts between currencies at the mid price of VALR pairs.
+type Converter struct {
+	source marketdata.TickerSource
+	via    []string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedRate
+}
+
+// New returns a Converter pricing pairs from source.
+func New(source marketdata.TickerSource, opts ...Option) *Converter {
+	c := &Converter{
+		source: source,
+		via:    DefaultVia,
+		cache:  make(map[string]cachedRate),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Convert returns amount of from expressed in to.
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	rate, err := c.Rate(ctx, from, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate), nil
+}
+
+// Rate returns the number of units of to per unit of from. It uses the pair
+// from+to, or the inverse of to+from, or failing both, a route through the
+// first intermediate currency that connects them. Only pairs that aren't
+// listed are routed around; any other error pricing a pair is returned.
+func (c *Converter) Rate(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.New(1, 0), nil
+	}
+	rate, err := c.direct(ctx, from, to)
+	if !unlisted(err) {
+		return rate, err
+	}
+	for _, via := range c.via {
+		if via == from || via == to {
+			continue
+		}
+		first, err := c.direct(ctx, from, via)
+		if unlisted(err) {
+			continue
+		} else if err != nil {
+			return decimal.Zero, err
+		}
+		second, err := c.direct(ctx, via, to)
+		if unlisted(err) {
+			continue
+		} else if err != nil {
+			return decimal.Zero, err
+		}
+		return first.Mul(second), nil
+	}
+	return decimal.Zero, fmt.Errorf("%w: %s to %s", ErrNoRoute, from, to)
+}
+
+// direct returns the rate from a single pair in either direction.
+func (c *Converter) direct(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	rate, err := c.price(ctx, from+to)
+	if !unlisted(err) {
+		return rate, err
+	}
+	rate, err = c.price(ctx, to+from)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.New(1, 0).DivRound(rate, precision), nil
+}
+
+// unlisted returns true if err reports that a pair isn't listed, as opposed
+// to a failure to price it.
+func unlisted(err error) bool {
+	if errors.Is(err, marketdata.ErrUnknownPair) {
+		return true
+	}
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.Invalid()
+}
+
+// price returns the mid price of pair, falling back to the last traded
+// price for one-sided markets.
+func (c *Converter) price(ctx context.Context, pair string) (decimal.Decimal, error) {
+	if c.ttl > 0 {
+		c.mu.Lock()
+		cached, ok := c.cache[pair]
+		c.mu.Unlock()
+		if ok && time.Since(cached.at) < c.ttl {
+			return cached.rate, nil
+		}
+	}
+
+	t, err := c.source.Ticker(ctx, pair)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	rate := t.Last
+	if t.Bid.IsPositive() && t.Ask.IsPositive() {
+		rate = t.Mid()
+	}
+	if !rate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: %s", marketdata.ErrEmptyTicker, pair)
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.cache[pair] = cachedRate{rate: rate, at: time.Now()}
+		c.mu.Unlock()
+	}
+	return rate, nil
+}
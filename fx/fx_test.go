@@ -0,0 +1,127 @@
+package fx_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+func TestConvert(t *testing.T) {
+	tickers := marketdata.NewTickerStore()
+	for pair, mid := range map[string]string{"BTCZAR": "1000000", "USDTZAR": "20", "SOLUSDT": "150"} {
+		p := decimal.RequireFromString(mid)
+		tickers.Update(marketdata.Ticker{Pair: pair, Bid: p, Ask: p, Last: p})
+	}
+	c := fx.New(tickers)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		amount, from, to, want string
+	}{
+		{"2", "BTC", "ZAR", "2000000"},
+		{"100", "ZAR", "USDT", "5"},
+		{"1", "BTC", "USDT", "50000"},
+		{"1", "SOL", "ZAR", "3000"},
+	} {
+		got, err := c.Convert(ctx, decimal.RequireFromString(tc.amount), tc.from, tc.to)
+		if err != nil {
+			t.Errorf("Expected success converting %s to %s, got %v", tc.from, tc.to, err)
+			continue
+		}
+		if !got.Equal(decimal.RequireFromString(tc.want)) {
+			t.Errorf("Expected %s %s in %s to be %s, got %s", tc.amount, tc.from, tc.to, tc.want, got)
+		}
+	}
+
+	if _, err := c.Convert(ctx, decimal.New(1, 0), "BTC", "ETH"); !errors.Is(err, fx.ErrNoRoute) {
+		t.Errorf("Expected %v, got %v", fx.ErrNoRoute, err)
+	}
+}
+
+// failingTickers fails to price the pairs in errs, pricing the rest from
+// the store.
+type failingTickers struct {
+	*marketdata.TickerStore
+	errs map[string]error
+}
+
+func (f failingTickers) Ticker(ctx context.Context, pair string) (marketdata.Ticker, error) {
+	if err, ok := f.errs[pair]; ok {
+		return marketdata.Ticker{}, err
+	}
+	return f.TickerStore.Ticker(ctx, pair)
+}
+
+func TestRateFallback(t *testing.T) {
+	store := marketdata.NewTickerStore()
+	for pair, mid := range map[string]string{"BTCZAR": "1000000", "ETHZAR": "50000", "ZARUSDT": "0.05"} {
+		p := decimal.RequireFromString(mid)
+		store.Update(marketdata.Ticker{Pair: pair, Bid: p, Ask: p, Last: p})
+	}
+	rateLimited := &valr.APIError{StatusCode: http.StatusTooManyRequests}
+	serverErr := &valr.APIError{StatusCode: http.StatusBadGateway}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		errs     map[string]error
+		from, to string
+		want     string
+		wantErr  error
+	}{
+		{name: "unlisted pair", from: "ETH", to: "BTC", want: "0.05"},
+		{
+			name: "unlisted API pair",
+			errs: map[string]error{
+				"USDTZAR": &valr.APIError{StatusCode: http.StatusBadRequest, Message: "Invalid currency pair"},
+			},
+			from: "ZAR", to: "USDT", want: "0.05",
+		},
+		{
+			name: "direct pair rate limited",
+			errs: map[string]error{"ZARUSDT": rateLimited},
+			from: "ZAR", to: "USDT", wantErr: valr.ErrTooManyRequests,
+		},
+		{
+			name: "direct pair server error",
+			errs: map[string]error{"ZARBTC": serverErr},
+			from: "ZAR", to: "BTC", wantErr: serverErr,
+		},
+		{
+			name: "route leg server error",
+			errs: map[string]error{"BTCZAR": serverErr},
+			from: "ETH", to: "BTC", wantErr: serverErr,
+		},
+		{
+			name: "network error",
+			errs: map[string]error{"BTCZAR": errors.New("connection reset")},
+			from: "BTC", to: "ZAR", wantErr: errors.New("connection reset"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fx.New(failingTickers{TickerStore: store, errs: tc.errs})
+			got, err := c.Rate(ctx, tc.from, tc.to)
+			if tc.wantErr != nil {
+				if err == nil || (!errors.Is(err, tc.wantErr) && err.Error() != tc.wantErr.Error()) {
+					t.Fatalf("Expected %v, got rate %s, error %v", tc.wantErr, got, err)
+				}
+				if errors.Is(err, fx.ErrNoRoute) {
+					t.Errorf("Expected the source error, not %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			if !got.Equal(decimal.RequireFromString(tc.want)) {
+				t.Errorf("Expected rate %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
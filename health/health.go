@@ -0,0 +1,251 @@
+// Package health serves liveness and readiness endpoints for services
+// embedding the client, e.g. for Kubernetes probes. Readiness reflects REST
+// connectivity and clock skew, as checked by Client.Diagnose, the state of
+// any websocket connections and custom checks. A SkewMonitor tracks clock
+// skew continuously, alerting when it exceeds a threshold.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+const (
+	defaultInterval   = 30 * time.Second
+	defaultStaleAfter = time.Minute
+	defaultTimeout    = 5 * time.Second
+)
+
+// Stream is a websocket connection, such as a *streaming.Conn.
+type Stream interface {
+	IsClosed() bool
+	LastMessage() time.Time
+}
+
+var _ Stream = (*streaming.Conn)(nil)
+
+// CheckFunc is a custom check, failing if it returns an error.
+type CheckFunc func(ctx context.Context) error
+
+type stream struct {
+	name       string
+	conn       Stream
+	staleAfter time.Duration
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type Option func(*Checker)
+
+// WithStream makes readiness require that conn is open and has received a
+// message within staleAfter, or one minute if zero. The check is named
+// "stream_" followed by name.
+func WithStream(name string, conn Stream, staleAfter time.Duration) Option {
+	return func(c *Checker) {
+		if staleAfter <= 0 {
+			staleAfter = defaultStaleAfter
+		}
+		c.streams = append(c.streams, stream{name: name, conn: conn, staleAfter: staleAfter})
+	}
+}
+
+// WithReadinessCheck adds a custom readiness check.
+func WithReadinessCheck(name string, fn CheckFunc) Option {
+	return func(c *Checker) {
+		c.ready = append(c.ready, check{name: name, fn: fn})
+	}
+}
+
+// WithLivenessCheck adds a custom liveness check. Liveness checks should
+// only fail if restarting the service would help, so they should not depend
+// on VALR being reachable.
+func WithLivenessCheck(name string, fn CheckFunc) Option {
+	return func(c *Checker) {
+		c.live = append(c.live, check{name: name, fn: fn})
+	}
+}
+
+// WithDiagnoseOptions sets the options of the readiness Diagnose call, e.g.
+// valr.WithMaxClockSkew.
+func WithDiagnoseOptions(opts ...valr.DiagnoseOption) Option {
+	return func(c *Checker) {
+		c.diagnoseOpts = append(c.diagnoseOpts, opts...)
+	}
+}
+
+// WithInterval sets how long a Diagnose result is reused, so frequent probes
+// don't consume the API rate limit. The default is 30 seconds.
+func WithInterval(d time.Duration) Option {
+	return func(c *Checker) {
+		c.interval = d
+	}
+}
+
+// WithTimeout bounds each check. The default is 5 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = d
+	}
+}
+
+// Checker serves /healthz and /readyz.
+type Checker struct {
+	cl           *valr.Client
+	diagnoseOpts []valr.DiagnoseOption
+	interval     time.Duration
+	timeout      time.Duration
+	streams      []stream
+	ready        []check
+	live         []check
+
+	mu       sync.Mutex
+	report   *valr.DiagnosticReport
+	reportAt time.Time
+}
+
+// New returns a Checker of cl's connectivity, which may be nil to check only
+// streams and custom checks.
+func New(cl *valr.Client, opts ...Option) *Checker {
+	c := &Checker{cl: cl, interval: defaultInterval, timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Status is the body of a probe response.
+type Status struct {
+	OK     bool     `json:"ok"`
+	Checks []Result `json:"checks"`
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) Status {
+	return c.run(ctx, c.live)
+}
+
+// Ready runs the readiness checks. The rate limit check of Diagnose is
+// ignored, since an exhausted rate limit recovers on its own.
+func (c *Checker) Ready(ctx context.Context) Status {
+	s := c.run(ctx, c.ready)
+	if c.cl != nil {
+		for _, dc := range c.diagnose(ctx).Checks {
+			if dc.Skipped || dc.Name == valr.CheckRateLimit {
+				continue
+			}
+			r := Result{Name: dc.Name, OK: dc.OK, Detail: dc.Detail}
+			if dc.Err != nil {
+				r.Error = dc.Err.Error()
+			}
+			s.add(r)
+		}
+	}
+	now := time.Now()
+	for _, st := range c.streams {
+		r := Result{Name: "stream_" + st.name}
+		switch last := st.conn.LastMessage(); {
+		case st.conn.IsClosed():
+			r.Detail = "closed"
+		case last.IsZero():
+			r.Detail = "no messages received"
+		case now.Sub(last) > st.staleAfter:
+			r.Detail = fmt.Sprintf("no messages for %s", now.Sub(last).Round(time.Second))
+		default:
+			r.OK = true
+		}
+		s.add(r)
+	}
+	sort.SliceStable(s.Checks, func(i, j int) bool {
+		return s.Checks[i].Name < s.Checks[j].Name
+	})
+	return s
+}
+
+func (s *Status) add(r Result) {
+	s.Checks = append(s.Checks, r)
+	s.OK = s.OK && r.OK
+}
+
+func (c *Checker) run(ctx context.Context, checks []check) Status {
+	s := Status{OK: true, Checks: []Result{}}
+	for _, ch := range checks {
+		cctx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := ch.fn(cctx)
+		cancel()
+		r := Result{Name: ch.name, OK: err == nil}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		s.add(r)
+	}
+	return s
+}
+
+// diagnose returns the latest Diagnose report, refreshing it if it is older
+// than the interval. Concurrent probes share a refresh, which isn't cut
+// short by a probe giving up.
+func (c *Checker) diagnose(ctx context.Context) *valr.DiagnosticReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.report != nil && time.Since(c.reportAt) < c.interval {
+		return c.report
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+	c.report, _ = c.cl.Diagnose(ctx, c.diagnoseOpts...)
+	c.reportAt = time.Now()
+	return c.report
+}
+
+// Healthz serves the liveness checks, with status 503 if any failed.
+func (c *Checker) Healthz(w http.ResponseWriter, r *http.Request) {
+	serve(w, c.Live(r.Context()))
+}
+
+// Readyz serves the readiness checks, with status 503 if any failed.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	serve(w, c.Ready(r.Context()))
+}
+
+// Register adds /healthz and /readyz to mux.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.Healthz)
+	mux.HandleFunc("/readyz", c.Readyz)
+}
+
+// Handler returns a handler serving /healthz and /readyz.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	c.Register(mux)
+	return mux
+}
+
+func serve(w http.ResponseWriter, s Status) {
+	w.Header().Set("Content-Type", "application/json")
+	if !s.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		log.Printf("valr/health: writing status: %v", err)
+	}
+}
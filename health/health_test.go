@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/health"
+)
+
+type fakeStream struct {
+	closed bool
+	last   time.Time
+}
+
+func (s *fakeStream) IsClosed() bool         { return s.closed }
+func (s *fakeStream) LastMessage() time.Time { return s.last }
+
+func timeServer(t *testing.T, offset time.Duration, calls *atomic.Int32) *valr.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		now := time.Now().Add(offset)
+		fmt.Fprintf(w, `{"epochTime":%d,"time":%q}`, now.Unix(), now.Format(time.RFC3339Nano))
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	return cl
+}
+
+func probe(t *testing.T, h http.Handler, path string) (int, health.Status) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var s health.Status
+	if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return rec.Code, s
+}
+
+func TestReady(t *testing.T) {
+	var calls atomic.Int32
+	cl := timeServer(t, 0, &calls)
+	st := &fakeStream{last: time.Now()}
+	h := health.New(cl, health.WithStream("trades", st, time.Minute)).Handler()
+
+	code, s := probe(t, h, "/readyz")
+	if code != http.StatusOK || !s.OK {
+		t.Fatalf("Expected ready, got %d %+v", code, s)
+	}
+	names := []string{}
+	for _, r := range s.Checks {
+		names = append(names, r.Name)
+	}
+	if fmt.Sprint(names) != "[clock_skew connectivity stream_trades]" {
+		t.Errorf("Unexpected checks %v", names)
+	}
+
+	st.last = time.Now().Add(-2 * time.Minute)
+	if code, s := probe(t, h, "/readyz"); code != http.StatusServiceUnavailable || s.OK {
+		t.Errorf("Expected not ready with a stale stream, got %d %+v", code, s)
+	}
+	st.last, st.closed = time.Now(), true
+	if code, _ := probe(t, h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready with a closed stream, got %d", code)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected the diagnosis to be reused, got %d calls", n)
+	}
+}
+
+func TestReadyClockSkew(t *testing.T) {
+	var calls atomic.Int32
+	cl := timeServer(t, time.Hour, &calls)
+	c := health.New(cl, health.WithDiagnoseOptions(valr.WithMaxClockSkew(time.Second)))
+	s := c.Ready(context.Background())
+	if s.OK {
+		t.Fatalf("Expected not ready, got %+v", s)
+	}
+	for _, r := range s.Checks {
+		if r.Name == valr.CheckClockSkew && r.OK {
+			t.Errorf("Expected clock skew check to fail, got %+v", r)
+		}
+	}
+}
+
+func TestLive(t *testing.T) {
+	var calls atomic.Int32
+	cl := timeServer(t, 0, &calls)
+	healthy := true
+	h := health.New(cl, health.WithLivenessCheck("worker", func(context.Context) error {
+		if !healthy {
+			return errors.New("stuck")
+		}
+		return nil
+	})).Handler()
+
+	if code, s := probe(t, h, "/healthz"); code != http.StatusOK || len(s.Checks) != 1 {
+		t.Errorf("Expected live, got %d %+v", code, s)
+	}
+	healthy = false
+	code, s := probe(t, h, "/healthz")
+	if code != http.StatusServiceUnavailable || s.Checks[0].Error != "stuck" {
+		t.Errorf("Expected not live, got %d %+v", code, s)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("Expected liveness not to call the API, got %d calls", n)
+	}
+}
@@ -0,0 +1,271 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+const (
+	// SkewServer is the skew measured against the server time endpoint.
+	SkewServer = "server"
+	// SkewStream is the skew estimated from streamed event timestamps.
+	SkewStream = "stream"
+
+	defaultSkewWindow = time.Minute
+	skewNotifyTimeout = 10 * time.Second
+)
+
+// SkewAlert describes a skew crossing the monitor's threshold.
+type SkewAlert struct {
+	// Source is SkewServer or SkewStream.
+	Source    string
+	Skew      time.Duration
+	Threshold time.Duration
+	// Resolved is true once the skew is back within the threshold.
+	Resolved bool
+	Time     time.Time
+}
+
+// SkewCallback is notified when a skew crosses the threshold, in either
+// direction.
+type SkewCallback func(SkewAlert)
+
+type SkewOption func(*SkewMonitor)
+
+// WithSkewThreshold sets the largest acceptable skew, by default
+// valr.DefaultMaxClockSkew.
+func WithSkewThreshold(d time.Duration) SkewOption {
+	return func(m *SkewMonitor) {
+		m.threshold = d
+	}
+}
+
+// WithSkewWindow sets the window over which streamed event timestamps are
+// compared, one minute by default.
+func WithSkewWindow(d time.Duration) SkewOption {
+	return func(m *SkewMonitor) {
+		m.window = d
+	}
+}
+
+// WithSkewCallback sets a callback notified of alerts.
+func WithSkewCallback(fn SkewCallback) SkewOption {
+	return func(m *SkewMonitor) {
+		m.callback = fn
+	}
+}
+
+// WithSkewNotifier delivers a notification of each alert to s: a warning
+// when the skew exceeds the threshold and an info message once it is
+// resolved.
+func WithSkewNotifier(s notify.Sink) SkewOption {
+	return func(m *SkewMonitor) {
+		m.notifier = s
+	}
+}
+
+// Skew is the latest estimate of the server's clock minus the local clock
+// from a source.
+type Skew struct {
+	Source string
+	Skew   time.Duration
+	// At is when the estimate was made.
+	At time.Time
+}
+
+type streamSample struct {
+	received time.Time
+	offset   time.Duration
+}
+
+// SkewMonitor continuously compares the local clock with VALR's, using the
+// server time endpoint and the timestamps of streamed events, and alerts
+// when the skew exceeds a threshold. Skew silently breaks request signing,
+// as VALR rejects requests with timestamps too far from its own, and
+// queries over time windows.
+//
+// Streamed events arrive some time after VALR stamped them, so their skew
+// is estimated from the event with the least apparent delay within the
+// window. Pass ObserveEvent to streaming.WithEventTimeCallback to feed it.
+type SkewMonitor struct {
+	cl        *valr.Client
+	threshold time.Duration
+	window    time.Duration
+	callback  SkewCallback
+	notifier  notify.Sink
+
+	mu       sync.Mutex
+	server   Skew
+	stream   Skew
+	samples  []streamSample
+	alerting map[string]bool
+}
+
+var _ streaming.EventTimeCallback = (*SkewMonitor)(nil).ObserveEvent
+
+// NewSkewMonitor returns a monitor measuring skew against cl's server
+// time, or only from streamed events if cl is nil.
+func NewSkewMonitor(cl *valr.Client, opts ...SkewOption) *SkewMonitor {
+	m := &SkewMonitor{
+		cl:        cl,
+		threshold: valr.DefaultMaxClockSkew,
+		window:    defaultSkewWindow,
+		alerting:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Run measures the skew against the server time every interval until ctx
+// is done. Failed measurements are logged and retried at the next interval.
+func (m *SkewMonitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Measure(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/health: Failed to measure clock skew: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Measure measures the skew against the server time once, assuming the
+// server read its clock halfway through the request.
+func (m *SkewMonitor) Measure(ctx context.Context) (time.Duration, error) {
+	if m.cl == nil {
+		return 0, errors.New("health: no client to measure skew with")
+	}
+	start := time.Now()
+	res, err := m.cl.GetServerTimeRequest(ctx, &valr.GetServerTimeRequest{})
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	skew := res.Time.Sub(start.Add(latency / 2))
+	m.record(Skew{Source: SkewServer, Skew: skew, At: time.Now()})
+	return skew, nil
+}
+
+// ObserveEvent records the timestamp VALR stamped a streamed event with
+// and when it was received.
+func (m *SkewMonitor) ObserveEvent(stamped, received time.Time) {
+	offset := stamped.Sub(received)
+	m.mu.Lock()
+	// The event delayed least is the one whose stamp is furthest ahead of
+	// its arrival. Samples are kept in decreasing order of offset, dropping
+	// those that can no longer be the largest in the window.
+	for n := len(m.samples); n > 0 && m.samples[n-1].offset <= offset; n-- {
+		m.samples = m.samples[:n-1]
+	}
+	m.samples = append(m.samples, streamSample{received: received, offset: offset})
+	cutoff := received.Add(-m.window)
+	for len(m.samples) > 1 && m.samples[0].received.Before(cutoff) {
+		m.samples = m.samples[1:]
+	}
+	best := m.samples[0].offset
+	m.mu.Unlock()
+
+	m.record(Skew{Source: SkewStream, Skew: best, At: received})
+}
+
+func (m *SkewMonitor) record(s Skew) {
+	m.mu.Lock()
+	if s.Source == SkewServer {
+		m.server = s
+	} else {
+		m.stream = s
+	}
+	exceeded := s.Skew.Abs() > m.threshold
+	changed := exceeded != m.alerting[s.Source]
+	m.alerting[s.Source] = exceeded
+	m.mu.Unlock()
+
+	if changed {
+		m.alert(SkewAlert{Source: s.Source, Skew: s.Skew, Threshold: m.threshold, Resolved: !exceeded, Time: s.At})
+	}
+}
+
+func (m *SkewMonitor) alert(a SkewAlert) {
+	if a.Resolved {
+		log.Printf("valr/health: Clock skew from %s back within %s: %s", a.Source, a.Threshold, a.Skew)
+	} else {
+		log.Printf("valr/health: Clock skew from %s exceeds %s: %s", a.Source, a.Threshold, a.Skew)
+	}
+	if m.callback != nil {
+		m.callback(a)
+	}
+	if m.notifier != nil {
+		notify.Send(m.notifier, skewMessage(a), skewNotifyTimeout)
+	}
+}
+
+func skewMessage(a SkewAlert) notify.Message {
+	m := notify.Message{
+		Level:  notify.Warning,
+		Source: "clock",
+		Title:  fmt.Sprintf("Clock skew from %s exceeds %s", a.Source, a.Threshold),
+		Text:   "Requests may be rejected as expired and time window queries may miss data.",
+		Fields: []notify.Field{
+			{Name: "skew", Value: a.Skew.String()},
+		},
+		Time: a.Time,
+	}
+	if a.Resolved {
+		m.Level = notify.Info
+		m.Title = fmt.Sprintf("Clock skew from %s back within %s", a.Source, a.Threshold)
+		m.Text = ""
+	}
+	return m
+}
+
+// Skews returns the latest estimates of each source that has been
+// measured, server first.
+func (m *SkewMonitor) Skews() []Skew {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Skew
+	for _, s := range []Skew{m.server, m.stream} {
+		if !s.At.IsZero() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Check fails if the latest estimate of any source exceeds the threshold,
+// for use with WithReadinessCheck.
+func (m *SkewMonitor) Check(ctx context.Context) error {
+	for _, s := range m.Skews() {
+		if s.Skew.Abs() > m.threshold {
+			return fmt.Errorf("health: clock skew from %s is %s, max %s", s.Source, s.Skew, m.threshold)
+		}
+	}
+	return nil
+}
+
+// ServeHTTP writes the latest estimates as Prometheus gauges in the text
+// exposition format.
+func (m *SkewMonitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP valr_clock_skew_seconds Server clock minus local clock.\n# TYPE valr_clock_skew_seconds gauge\n")
+	for _, s := range m.Skews() {
+		fmt.Fprintf(w, "valr_clock_skew_seconds{source=%q} %g\n", s.Source, s.Skew.Seconds())
+	}
+	fmt.Fprintf(w, "# HELP valr_clock_skew_threshold_seconds Largest acceptable clock skew.\n# TYPE valr_clock_skew_threshold_seconds gauge\n")
+	fmt.Fprintf(w, "valr_clock_skew_threshold_seconds %g\n", m.threshold.Seconds())
+}
@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/health"
+)
+
+func TestSkewMonitorServer(t *testing.T) {
+	var calls atomic.Int32
+	cl := timeServer(t, 10*time.Second, &calls)
+	var alerts []health.SkewAlert
+	m := health.NewSkewMonitor(cl, health.WithSkewCallback(func(a health.SkewAlert) {
+		alerts = append(alerts, a)
+	}))
+
+	for i := 0; i < 2; i++ {
+		skew, err := m.Measure(context.Background())
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if skew < 9*time.Second || skew > 11*time.Second {
+			t.Errorf("Expected skew of about 10s, got %v", skew)
+		}
+	}
+	if len(alerts) != 1 || alerts[0].Source != health.SkewServer || alerts[0].Resolved {
+		t.Errorf("Expected a single unresolved server alert, got %+v", alerts)
+	}
+	if err := m.Check(context.Background()); err == nil {
+		t.Error("Expected the check to fail")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !regexp.MustCompile(`valr_clock_skew_seconds\{source="server"\} (9\.9|10)`).MatchString(rec.Body.String()) {
+		t.Errorf("Expected a server skew gauge, got %s", rec.Body)
+	}
+}
+
+func TestSkewMonitorStream(t *testing.T) {
+	var alerts []health.SkewAlert
+	m := health.NewSkewMonitor(nil,
+		health.WithSkewThreshold(time.Second),
+		health.WithSkewWindow(time.Minute),
+		health.WithSkewCallback(func(a health.SkewAlert) {
+			alerts = append(alerts, a)
+		}))
+
+	// The local clock is 3s ahead, so events arrive 3s plus their delay
+	// after they were stamped. The least delayed event gives the skew.
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m.ObserveEvent(base, base.Add(3500*time.Millisecond))
+	m.ObserveEvent(base.Add(time.Second), base.Add(4*time.Second+100*time.Millisecond))
+	m.ObserveEvent(base.Add(2*time.Second), base.Add(5800*time.Millisecond))
+
+	skews := m.Skews()
+	if len(skews) != 1 || skews[0].Source != health.SkewStream {
+		t.Fatalf("Expected a stream skew, got %+v", skews)
+	}
+	if want := -3100 * time.Millisecond; skews[0].Skew != want {
+		t.Errorf("Expected %v, got %v", want, skews[0].Skew)
+	}
+	if len(alerts) != 1 || alerts[0].Resolved {
+		t.Errorf("Expected a single unresolved alert, got %+v", alerts)
+	}
+
+	// Once the clock is corrected and the skewed events leave the window,
+	// the alert is resolved.
+	later := base.Add(2 * time.Minute)
+	m.ObserveEvent(later, later.Add(50*time.Millisecond))
+	if want := -50 * time.Millisecond; m.Skews()[0].Skew != want {
+		t.Errorf("Expected %v, got %v", want, m.Skews()[0].Skew)
+	}
+	if len(alerts) != 2 || !alerts[1].Resolved {
+		t.Errorf("Expected the alert to be resolved, got %+v", alerts)
+	}
+	if err := m.Check(context.Background()); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+}
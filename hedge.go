@@ -0,0 +1,124 @@
+package valr
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetHedging enables hedged requests for latency-critical market data reads:
+// if a GET request of a class in delays hasn't returned after its delay, an
+// identical second request is sent and the first response to succeed is
+// used, cancelling the other. This trades extra requests, each counted
+// against the rate limit, for lower tail latency. Classes without a
+// positive delay are not hedged. ClassOrderBook covers both the public and
+// the authenticated order books. Pass nil to disable hedging, the default.
+func (cl *Client) SetHedging(delays map[EndpointClass]time.Duration) {
+	if delays == nil {
+		cl.hedgeDelays = nil
+		return
+	}
+	cl.hedgeDelays = make(map[EndpointClass]time.Duration, len(delays))
+	for class, d := range delays {
+		cl.hedgeDelays[class] = d
+	}
+}
+
+// hedgeDelay returns how long to wait before hedging a call to the path
+// template, or zero if it isn't hedged.
+func (cl *Client) hedgeDelay(method, path string) time.Duration {
+	if cl.hedgeDelays == nil || method != http.MethodGet {
+		return 0
+	}
+	return cl.hedgeDelays[hedgeClass(path)]
+}
+
+// hedgeClass classifies a path template for hedging. Unlike caching, it
+// includes the authenticated order books.
+func hedgeClass(path string) EndpointClass {
+	if class := endpointClass(path); class != "" {
+		return class
+	}
+	path = strings.TrimLeft(path, "/")
+	if strings.HasPrefix(path, "marketdata/") && strings.Contains(path, "/orderbook") {
+		return ClassOrderBook
+	}
+	return ""
+}
+
+// hedgedAttempt sends a request like attempt, sending a second one if the
+// first hasn't returned after delay. It returns once a request succeeds or
+// every request sent has failed, after the other request has been cancelled
+// and has returned, so both are accounted against b.
+func (cl *Client) hedgedAttempt(ctx context.Context, b *budget, delay time.Duration, method, url string,
+	reqBody []byte, res interface{}, auth, cacheable bool) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		body *json.RawMessage
+		err  error
+	}
+	results := make(chan result, 2)
+	send := func(b *budget) {
+		// Each request decodes into its own buffer, so the loser can't
+		// write to res.
+		var r result
+		var dst interface{}
+		if res != nil {
+			r.body = new(json.RawMessage)
+			dst = r.body
+		}
+		r.err = cl.attempt(ctx, b, method, url, reqBody, dst, auth, cacheable)
+		results <- r
+	}
+
+	hedge := newBudget(ctx, b.method, b.path)
+	defer func() {
+		b.attempts += hedge.attempts
+	}()
+
+	go send(b)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedged := false
+
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if cl.debug {
+				log.Printf("valr: Hedging %s %s after %s", method, b.path, delay)
+			}
+			hedged = true
+			pending++
+			go send(hedge)
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				cancel()
+				for ; pending > 0; pending-- {
+					<-results
+				}
+				if r.body == nil {
+					return nil
+				}
+				return cl.decode(b, *r.body, res)
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if !hedged {
+				// The request failed before the hedge was due, so there is
+				// nothing to gain by sending it.
+				return firstErr
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,78 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// slowFirstServer stalls the first request until it is cancelled and
+// answers later ones at once, counting the requests received.
+func slowFirstServer(t *testing.T) (*valr.Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.Write([]byte(`{"Asks":[],"Bids":[],"SequenceNumber":7}`))
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+	return cl, &calls
+}
+
+func TestHedgingTakesFastest(t *testing.T) {
+	for name, get := range map[string]func(*valr.Client) (*valr.OrderBook, error){
+		"public": func(cl *valr.Client) (*valr.OrderBook, error) {
+			return cl.GetOrderBook(context.Background(), &valr.GetOrderBookRequest{Pair: "BTCZAR"})
+		},
+		"authenticated": func(cl *valr.Client) (*valr.OrderBook, error) {
+			return cl.GetAuthOrderBookRequest(context.Background(), &valr.GetAuthOrderBookRequest{Pair: "BTCZAR"})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cl, calls := slowFirstServer(t)
+			cl.SetHedging(map[valr.EndpointClass]time.Duration{valr.ClassOrderBook: 20 * time.Millisecond})
+			start := time.Now()
+			book, err := get(cl)
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			if book.SequenceNumber != 7 {
+				t.Errorf("Expected %d, got %d", 7, book.SequenceNumber)
+			}
+			if calls.Load() != 2 {
+				t.Errorf("Expected %d calls, got %d", 2, calls.Load())
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("Expected the hedge to return quickly, took %v", d)
+			}
+		})
+	}
+}
+
+func TestHedgingOnlyConfiguredClasses(t *testing.T) {
+	cl, calls := slowFirstServer(t)
+	cl.SetHedging(map[valr.EndpointClass]time.Duration{valr.ClassMarketSummary: 20 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := cl.GetOrderBook(ctx, &valr.GetOrderBookRequest{Pair: "BTCZAR"}); err == nil {
+		t.Error("Expected the unhedged call to time out")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected %d calls, got %d", 1, calls.Load())
+	}
+}
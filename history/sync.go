@@ -0,0 +1,258 @@
+// Package history syncs trade and transaction history incrementally. A
+// Syncer remembers, in a store.Store, the cursor of the last record
+// processed for each pair or account, and each run fetches only the records
+// after it, so scheduled ETL jobs are cheap and can be rerun safely.
+package history
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/store"
+)
+
+const (
+	defaultNamespace = "history.cursors"
+	defaultPageSize  = 100
+	// maxPageSize is the largest page VALR returns for history endpoints.
+	maxPageSize = 100
+)
+
+// Cursor is the position of the last record processed. IDs holds the IDs of
+// the records at Time, which is inclusive in VALR's time filters, so they
+// aren't processed again.
+type Cursor struct {
+	Time time.Time `json:"time"`
+	IDs  []string  `json:"ids,omitempty"`
+	// Records is the total number of records processed.
+	Records int `json:"records"`
+	// SyncedAt is when the cursor was last advanced.
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// after returns true if a record at t with id is after the cursor.
+func (c Cursor) after(t time.Time, id string) bool {
+	if t.Equal(c.Time) {
+		return !slices.Contains(c.IDs, id)
+	}
+	return t.After(c.Time)
+}
+
+// advance moves the cursor past records, which must be in time order.
+func (c *Cursor) advance(n int, last time.Time, ids []string) {
+	if last.Equal(c.Time) {
+		ids = append(c.IDs, ids...)
+	}
+	c.Time, c.IDs = last, ids
+	c.Records += n
+	c.SyncedAt = time.Now()
+}
+
+type Option func(*Syncer)
+
+// WithNamespace sets the store namespace of the cursors. The default is
+// "history.cursors".
+func WithNamespace(ns string) Option {
+	return func(s *Syncer) {
+		s.namespace = ns
+	}
+}
+
+// WithStart sets where the first sync of each key starts. By default all
+// available history is synced.
+func WithStart(t time.Time) Option {
+	return func(s *Syncer) {
+		s.start = t
+	}
+}
+
+// WithPageSize sets the page size of requests, at most 100.
+func WithPageSize(n int) Option {
+	return func(s *Syncer) {
+		s.pageSize = min(n, maxPageSize)
+	}
+}
+
+// WithBatchSize sets the largest number of records passed to each call of a
+// handler. The cursor is saved after each batch, so a failed run resumes
+// after the last batch handled. By default all new records are handled in
+// one batch.
+func WithBatchSize(n int) Option {
+	return func(s *Syncer) {
+		s.batchSize = n
+	}
+}
+
+// Syncer fetches history incrementally.
+type Syncer struct {
+	cl        *valr.Client
+	st        store.Store
+	namespace string
+	start     time.Time
+	pageSize  int
+	batchSize int
+}
+
+// NewSyncer returns a Syncer keeping its cursors in st.
+func NewSyncer(cl *valr.Client, st store.Store, opts ...Option) *Syncer {
+	s := &Syncer{
+		cl:        cl,
+		st:        st,
+		namespace: defaultNamespace,
+		pageSize:  defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// TradesKey is the cursor key of a pair's trades.
+func TradesKey(pair string) string {
+	return "trades/" + pair
+}
+
+// TransactionsKey is the cursor key of the account's transactions.
+const TransactionsKey = "transactions"
+
+// Cursor returns the cursor of key. A key never synced has a zero cursor
+// starting at the WithStart time.
+func (s *Syncer) Cursor(ctx context.Context, key string) (Cursor, error) {
+	var c Cursor
+	err := store.GetJSON(ctx, s.st, s.namespace, key, &c)
+	if errors.Is(err, store.ErrNotFound) {
+		return Cursor{Time: s.start}, nil
+	}
+	return c, err
+}
+
+// Reset forgets the cursor of key, so the next sync starts again.
+func (s *Syncer) Reset(ctx context.Context, key string) error {
+	return s.st.Delete(ctx, s.namespace, key)
+}
+
+// SyncTrades fetches the pair's trades after its cursor and passes them to
+// handle, oldest first, advancing the cursor after each batch handled. It
+// returns the number of trades handled. If handle fails, the cursor is left
+// after the last batch handled and the error is returned.
+func (s *Syncer) SyncTrades(ctx context.Context, pair string, handle func([]valr.TradeHistoryInfo) error) (int, error) {
+	key := TradesKey(pair)
+	cur, err := s.Cursor(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	// Pin the end of the range, so trades arriving during the sync don't
+	// shift the pages.
+	req := &valr.GetAuthTradeHistoryForPairRequest{
+		Pair:      pair,
+		Limit:     s.pageSize,
+		StartTime: cur.Time,
+		EndTime:   time.Now(),
+	}
+	var trades []valr.TradeHistoryInfo
+	for {
+		page, err := s.cl.GetAuthTradeHistoryForPairPage(ctx, req)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range page.Items {
+			if cur.after(t.TradedAt.Time, t.ID) {
+				trades = append(trades, t)
+			}
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Skip = page.NextSkip
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		if !trades[i].TradedAt.Equal(trades[j].TradedAt.Time) {
+			return trades[i].TradedAt.Before(trades[j].TradedAt.Time)
+		}
+		return trades[i].SequenceID < trades[j].SequenceID
+	})
+	return handleBatches(ctx, s, key, cur, trades, handle, func(t valr.TradeHistoryInfo) (time.Time, string) {
+		return t.TradedAt.Time, t.ID
+	})
+}
+
+// SyncTransactions fetches the account's transactions after its cursor and
+// passes them to handle, oldest first, like SyncTrades.
+func (s *Syncer) SyncTransactions(ctx context.Context, handle func([]valr.TransactionInfo) error) (int, error) {
+	cur, err := s.Cursor(ctx, TransactionsKey)
+	if err != nil {
+		return 0, err
+	}
+
+	// Transactions are listed newest first with no time filter, so page back
+	// until reaching the cursor. New transactions shift the pages, so some
+	// may be listed twice.
+	req := &valr.GetTransactionHistoryRequest{Limit: s.pageSize}
+	var (
+		txs  []valr.TransactionInfo
+		seen = make(map[string]bool)
+	)
+	for {
+		page, err := s.cl.GetTransactionHistoryPage(ctx, req)
+		if err != nil {
+			return 0, err
+		}
+		reached := false
+		for _, t := range page.Items {
+			if t.EventAt.Before(cur.Time) {
+				reached = true
+				continue
+			}
+			if cur.after(t.EventAt.Time, t.ID) && (t.ID == "" || !seen[t.ID]) {
+				seen[t.ID] = true
+				txs = append(txs, t)
+			}
+		}
+		if reached || !page.HasMore {
+			break
+		}
+		req.Skip = page.NextSkip
+	}
+	slices.Reverse(txs)
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].EventAt.Before(txs[j].EventAt.Time)
+	})
+	return handleBatches(ctx, s, TransactionsKey, cur, txs, handle, func(t valr.TransactionInfo) (time.Time, string) {
+		return t.EventAt.Time, t.ID
+	})
+}
+
+// handleBatches passes records to handle in batches, saving the cursor after
+// each.
+func handleBatches[T any](ctx context.Context, s *Syncer, key string, cur Cursor, records []T, handle func([]T) error, pos func(T) (time.Time, string)) (int, error) {
+	size := s.batchSize
+	if size <= 0 {
+		size = len(records)
+	}
+	n := 0
+	for len(records) > 0 {
+		batch := records[:min(size, len(records))]
+		records = records[len(batch):]
+		if err := handle(batch); err != nil {
+			return n, err
+		}
+		last, _ := pos(batch[len(batch)-1])
+		var ids []string
+		for _, r := range batch {
+			if t, id := pos(r); t.Equal(last) {
+				ids = append(ids, id)
+			}
+		}
+		cur.advance(len(batch), last, ids)
+		if err := store.PutJSON(ctx, s.st, s.namespace, key, cur); err != nil {
+			return n, err
+		}
+		n += len(batch)
+	}
+	return n, nil
+}
@@ -0,0 +1,206 @@
+package history_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/history"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// exchange serves trade and transaction history, newest first, from
+// in-memory lists.
+type exchange struct {
+	mu     sync.Mutex
+	trades []valr.TradeHistoryInfo
+	txs    []valr.TransactionInfo
+	calls  int
+}
+
+func (e *exchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	q := r.URL.Query()
+	skip, _ := strconv.Atoi(q.Get("skip"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	var items []any
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/tradehistory"):
+		start, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+		end, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+		for i := len(e.trades) - 1; i >= 0; i-- {
+			t := e.trades[i]
+			if t.TradedAt.Before(start) || (!end.IsZero() && t.TradedAt.After(end)) {
+				continue
+			}
+			items = append(items, t)
+		}
+	case strings.HasSuffix(r.URL.Path, "/transactionhistory"):
+		for i := len(e.txs) - 1; i >= 0; i-- {
+			items = append(items, e.txs[i])
+		}
+	}
+	items = items[min(skip, len(items)):]
+	items = items[:min(limit, len(items))]
+	if items == nil {
+		items = []any{}
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+func (e *exchange) addTrades(at time.Time, n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := 0; i < n; i++ {
+		id := len(e.trades) + 1
+		e.trades = append(e.trades, valr.TradeHistoryInfo{
+			ID: fmt.Sprintf("t%d", id), SequenceID: id, Pair: "BTCZAR",
+			Price: decimal.New(int64(id), 0), Quantity: decimal.New(1, 0),
+			TradedAt: valr.NewTimestamp(at),
+		})
+	}
+}
+
+func newClient(t *testing.T, e *exchange) *valr.Client {
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl
+}
+
+func TestSyncTrades(t *testing.T) {
+	e := new(exchange)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	e.addTrades(base, 3)
+	e.addTrades(base.Add(time.Minute), 4)
+
+	st := store.NewMemory()
+	s := history.NewSyncer(newClient(t, e), st, history.WithPageSize(2))
+	var got []string
+	handle := func(trades []valr.TradeHistoryInfo) error {
+		for _, tr := range trades {
+			got = append(got, tr.ID)
+		}
+		return nil
+	}
+
+	n, err := s.SyncTrades(context.Background(), "BTCZAR", handle)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n != 7 || strings.Join(got, ",") != "t1,t2,t3,t4,t5,t6,t7" {
+		t.Fatalf("Expected t1 to t7 in order, got %d %v", n, got)
+	}
+
+	// Trades at the cursor's time, and after it, are picked up once.
+	e.addTrades(base.Add(time.Minute), 1)
+	e.addTrades(base.Add(2*time.Minute), 2)
+	got = nil
+	if n, err = s.SyncTrades(context.Background(), "BTCZAR", handle); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n != 3 || strings.Join(got, ",") != "t8,t9,t10" {
+		t.Errorf("Expected t8 to t10, got %d %v", n, got)
+	}
+
+	got = nil
+	if n, err = s.SyncTrades(context.Background(), "BTCZAR", handle); err != nil || n != 0 {
+		t.Errorf("Expected no new trades, got %d %v %v", n, got, err)
+	}
+
+	cur, err := s.Cursor(context.Background(), history.TradesKey("BTCZAR"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if cur.Records != 10 || !cur.Time.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("Unexpected cursor %+v", cur)
+	}
+}
+
+func TestSyncTradesResumesAfterFailure(t *testing.T) {
+	e := new(exchange)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		e.addTrades(base.Add(time.Duration(i)*time.Second), 1)
+	}
+	s := history.NewSyncer(newClient(t, e), store.NewMemory(), history.WithBatchSize(2))
+
+	errFail := errors.New("warehouse down")
+	var got []string
+	calls := 0
+	handle := func(trades []valr.TradeHistoryInfo) error {
+		calls++
+		if calls == 2 {
+			return errFail
+		}
+		for _, tr := range trades {
+			got = append(got, tr.ID)
+		}
+		return nil
+	}
+	n, err := s.SyncTrades(context.Background(), "BTCZAR", handle)
+	if !errors.Is(err, errFail) || n != 2 {
+		t.Fatalf("Expected failure after 2 trades, got %d %v", n, err)
+	}
+	if n, err = s.SyncTrades(context.Background(), "BTCZAR", handle); err != nil || n != 3 {
+		t.Fatalf("Expected 3 trades, got %d %v", n, err)
+	}
+	if strings.Join(got, ",") != "t1,t2,t3,t4,t5" {
+		t.Errorf("Expected each trade once, got %v", got)
+	}
+}
+
+func TestSyncTransactions(t *testing.T) {
+	e := new(exchange)
+	base := time.Now().Add(-time.Hour)
+	add := func(n int) {
+		for i := 0; i < n; i++ {
+			id := len(e.txs) + 1
+			e.txs = append(e.txs, valr.TransactionInfo{
+				ID: fmt.Sprintf("x%d", id), EventAt: valr.NewTimestamp(base.Add(time.Duration(id) * time.Second)),
+			})
+		}
+	}
+	add(5)
+
+	s := history.NewSyncer(newClient(t, e), store.NewMemory(), history.WithPageSize(2))
+	var got []string
+	handle := func(txs []valr.TransactionInfo) error {
+		for _, tx := range txs {
+			got = append(got, tx.ID)
+		}
+		return nil
+	}
+	if n, err := s.SyncTransactions(context.Background(), handle); err != nil || n != 5 {
+		t.Fatalf("Expected 5 transactions, got %d %v", n, err)
+	}
+
+	add(3)
+	e.calls = 0
+	if n, err := s.SyncTransactions(context.Background(), handle); err != nil || n != 3 {
+		t.Fatalf("Expected 3 transactions, got %d %v", n, err)
+	}
+	if strings.Join(got, ",") != "x1,x2,x3,x4,x5,x6,x7,x8" {
+		t.Errorf("Expected each transaction once in order, got %v", got)
+	}
+	if e.calls != 3 {
+		t.Errorf("Expected paging to stop at the cursor after 3 pages, got %d", e.calls)
+	}
+}
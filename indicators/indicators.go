@@ -0,0 +1,239 @@
+// Package indicators computes technical indicators incrementally, one candle
+// at a time, so that signals can be updated as candles arrive.
+package indicators
+
+import (
+	"context"
+	"math"
+
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+// precision is the number of decimal places kept in divisions.
+const precision = 16
+
+var (
+	two     = decimal.New(2, 0)
+	hundred = decimal.New(100, 0)
+)
+
+// Indicator is updated with each completed candle.
+type Indicator interface {
+	Update(c marketdata.Candle)
+	// Ready returns true once enough candles have been seen for the value
+	// to be meaningful.
+	Ready() bool
+}
+
+// Feed updates inds with every candle received from candles until the
+// channel is closed or ctx is done.
+func Feed(ctx context.Context, candles <-chan marketdata.Candle, inds ...Indicator) error {
+	for {
+		select {
+		case c, ok := <-candles:
+			if !ok {
+				return nil
+			}
+			for _, ind := range inds {
+				ind.Update(c)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// EMA is an exponential moving average of closing prices, seeded with the
+// simple average of the first period values.
+type EMA struct {
+	period int
+	k      decimal.Decimal
+	n      int
+	value  decimal.Decimal
+}
+
+// NewEMA returns an EMA over period candles.
+func NewEMA(period int) *EMA {
+	period = max(period, 1)
+	return &EMA{
+		period: period,
+		k:      two.DivRound(decimal.New(int64(period)+1, 0), precision),
+	}
+}
+
+func (e *EMA) Update(c marketdata.Candle) {
+	e.UpdateValue(c.Close)
+}
+
+// UpdateValue adds a value other than a candle's close.
+func (e *EMA) UpdateValue(v decimal.Decimal) {
+	e.n++
+	if e.n <= e.period {
+		// Running simple average until the seed period is complete.
+		e.value = e.value.Add(v.Sub(e.value).DivRound(decimal.New(int64(e.n), 0), precision))
+		return
+	}
+	e.value = e.value.Add(v.Sub(e.value).Mul(e.k))
+}
+
+func (e *EMA) Ready() bool {
+	return e.n >= e.period
+}
+
+func (e *EMA) Value() decimal.Decimal {
+	return e.value
+}
+
+// wilder is Wilder's smoothed average, used by RSI and ATR.
+type wilder struct {
+	period int
+	n      int
+	value  decimal.Decimal
+}
+
+func (w *wilder) update(v decimal.Decimal) {
+	w.n++
+	if w.n <= w.period {
+		w.value = w.value.Add(v.Sub(w.value).DivRound(decimal.New(int64(w.n), 0), precision))
+		return
+	}
+	w.value = w.value.Add(v.Sub(w.value).DivRound(decimal.New(int64(w.period), 0), precision))
+}
+
+// RSI is Wilder's relative strength index of closing prices, from 0 to 100.
+type RSI struct {
+	gain, loss wilder
+	prev       decimal.Decimal
+	started    bool
+}
+
+// NewRSI returns an RSI over period candles.
+func NewRSI(period int) *RSI {
+	period = max(period, 1)
+	return &RSI{gain: wilder{period: period}, loss: wilder{period: period}}
+}
+
+func (r *RSI) Update(c marketdata.Candle) {
+	if !r.started {
+		r.prev, r.started = c.Close, true
+		return
+	}
+	change := c.Close.Sub(r.prev)
+	r.prev = c.Close
+	r.gain.update(decimal.Max(change, decimal.Zero))
+	r.loss.update(decimal.Max(change.Neg(), decimal.Zero))
+}
+
+func (r *RSI) Ready() bool {
+	return r.gain.n >= r.gain.period
+}
+
+func (r *RSI) Value() decimal.Decimal {
+	if r.loss.value.IsZero() {
+		if r.gain.value.IsZero() {
+			return hundred.Div(two)
+		}
+		return hundred
+	}
+	rs := r.gain.value.DivRound(r.loss.value, precision)
+	return hundred.Sub(hundred.DivRound(rs.Add(decimal.New(1, 0)), precision))
+}
+
+// ATR is Wilder's average true range.
+type ATR struct {
+	avg       wilder
+	prevClose decimal.Decimal
+	started   bool
+}
+
+// NewATR returns an ATR over period candles.
+func NewATR(period int) *ATR {
+	return &ATR{avg: wilder{period: max(period, 1)}}
+}
+
+func (a *ATR) Update(c marketdata.Candle) {
+	tr := c.High.Sub(c.Low)
+	if a.started {
+		tr = decimal.Max(tr, c.High.Sub(a.prevClose).Abs(), c.Low.Sub(a.prevClose).Abs())
+	}
+	a.prevClose, a.started = c.Close, true
+	a.avg.update(tr)
+}
+
+func (a *ATR) Ready() bool {
+	return a.avg.n >= a.avg.period
+}
+
+func (a *ATR) Value() decimal.Decimal {
+	return a.avg.value
+}
+
+// Bollinger is a simple moving average of closing prices with bands a
+// number of population standard deviations above and below it.
+type Bollinger struct {
+	width  decimal.Decimal
+	window []decimal.Decimal
+	next   int
+	full   bool
+}
+
+// NewBollinger returns Bollinger bands over period candles, width standard
+// deviations either side of the average; 20 and 2 are customary.
+func NewBollinger(period int, width decimal.Decimal) *Bollinger {
+	return &Bollinger{width: width, window: make([]decimal.Decimal, max(period, 1))}
+}
+
+func (b *Bollinger) Update(c marketdata.Candle) {
+	b.window[b.next] = c.Close
+	b.next = (b.next + 1) % len(b.window)
+	b.full = b.full || b.next == 0
+}
+
+func (b *Bollinger) Ready() bool {
+	return b.full
+}
+
+// Value returns the middle, upper and lower bands.
+func (b *Bollinger) Value() (middle, upper, lower decimal.Decimal) {
+	values := b.window
+	if !b.full {
+		values = b.window[:b.next]
+	}
+	if len(values) == 0 {
+		return
+	}
+	n := decimal.New(int64(len(values)), 0)
+	var sum decimal.Decimal
+	for _, v := range values {
+		sum = sum.Add(v)
+	}
+	middle = sum.DivRound(n, precision)
+	var squares decimal.Decimal
+	for _, v := range values {
+		d := v.Sub(middle)
+		squares = squares.Add(d.Mul(d))
+	}
+	offset := sqrt(squares.DivRound(n, precision)).Mul(b.width)
+	return middle, middle.Add(offset), middle.Sub(offset)
+}
+
+// sqrt returns the square root of a non-negative v by Newton's method.
+func sqrt(v decimal.Decimal) decimal.Decimal {
+	if !v.IsPositive() {
+		return decimal.Zero
+	}
+	x := v
+	if f, _ := v.Float64(); f > 0 {
+		// Start from the float estimate; a few iterations refine it.
+		x = decimal.NewFromFloat(math.Sqrt(f))
+	}
+	for i := 0; i < 8; i++ {
+		next := x.Add(v.DivRound(x, precision)).DivRound(two, precision)
+		if next.Equal(x) {
+			break
+		}
+		x = next
+	}
+	return x
+}
@@ -0,0 +1,57 @@
+package indicators_test
+
+import (
+	"testing"
+
+	"github.com/donohutcheon/valr-go/indicators"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+func candle(h, l, c string) marketdata.Candle {
+	return marketdata.Candle{
+		High:  decimal.RequireFromString(h),
+		Low:   decimal.RequireFromString(l),
+		Close: decimal.RequireFromString(c),
+	}
+}
+
+func TestIndicators(t *testing.T) {
+	ema := indicators.NewEMA(3)
+	rsi := indicators.NewRSI(2)
+	atr := indicators.NewATR(2)
+	bb := indicators.NewBollinger(4, decimal.New(2, 0))
+	for _, c := range []marketdata.Candle{
+		candle("11", "9", "10"),
+		candle("13", "10", "12"),
+		candle("12", "9", "11"),
+		candle("16", "12", "15"),
+	} {
+		for _, ind := range []indicators.Indicator{ema, rsi, atr, bb} {
+			ind.Update(c)
+		}
+	}
+
+	check := func(name string, got decimal.Decimal, want string) {
+		t.Helper()
+		if !got.Round(6).Equal(decimal.RequireFromString(want)) {
+			t.Errorf("Expected %s %s, got %s", name, want, got)
+		}
+	}
+	// Seeded with (10+12+11)/3 = 11, then 11 + (15-11)*0.5.
+	check("ema", ema.Value(), "13")
+	// Gains 2, 0, 4 and losses 0, 1, 0 smoothed over 2: 2.5 and 0.25.
+	check("rsi", rsi.Value(), "90.909091")
+	// True ranges 2, 3, 3, 5 smoothed over 2.
+	check("atr", atr.Value(), "3.875")
+	middle, upper, lower := bb.Value()
+	check("bollinger middle", middle, "12")
+	// Population standard deviation of 10, 12, 11, 15 is sqrt(3.5).
+	check("bollinger upper", upper, "15.741657")
+	check("bollinger lower", lower, "8.258343")
+	for _, ind := range []indicators.Indicator{ema, rsi, atr, bb} {
+		if !ind.Ready() {
+			t.Errorf("Expected %T to be ready", ind)
+		}
+	}
+}
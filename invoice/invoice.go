@@ -0,0 +1,289 @@
+// Package invoice issues invoices payable by fiat deposit into a VALR
+// account and tracks them until they are paid or expire. Each invoice has a
+// unique payment reference, which is attributed to deposits by a
+// deposits.Matcher.
+package invoice
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/deposits"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// Invoice statuses.
+const (
+	StatusOpen = "OPEN"
+	// StatusPaid invoices received at least the amount due.
+	StatusPaid = "PAID"
+	// StatusUnderpaid invoices received less than the amount due. The
+	// reference is not matched again.
+	StatusUnderpaid = "UNDERPAID"
+	StatusExpired   = "EXPIRED"
+)
+
+const (
+	// invoicesNamespace holds invoices keyed by ID.
+	invoicesNamespace = "invoice.invoices"
+
+	// referenceAlphabet omits characters that are easily confused.
+	referenceAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referenceLength   = 8
+)
+
+// ErrNotFound is returned for unknown invoice IDs.
+var ErrNotFound = errors.New("invoice: not found")
+
+// Invoice is a request for payment.
+type Invoice struct {
+	ID       string          `json:"id"`
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+	// Reference is the payment reference the payer must use.
+	Reference string    `json:"reference"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt is zero for invoices that don't expire.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Received, PaidAt and DepositID describe the matched deposit.
+	Received  decimal.Decimal `json:"received"`
+	PaidAt    time.Time       `json:"paidAt"`
+	DepositID string          `json:"depositId,omitempty"`
+}
+
+// Callback is called with an invoice whose status changed.
+type Callback func(Invoice)
+
+type Option func(*Book)
+
+// WithStore keeps invoices in s, so they survive restarts. The matcher
+// should use a persistent store too. By default an in-memory store is used.
+func WithStore(s store.Store) Option {
+	return func(b *Book) {
+		b.store = s
+	}
+}
+
+// WithPaidCallback sets a callback for invoices that are paid or
+// underpaid.
+func WithPaidCallback(fn Callback) Option {
+	return func(b *Book) {
+		b.onPaid = fn
+	}
+}
+
+// WithExpiredCallback sets a callback for invoices that expire unpaid.
+func WithExpiredCallback(fn Callback) Option {
+	return func(b *Book) {
+		b.onExpired = fn
+	}
+}
+
+// WithReferencePrefix sets the prefix of generated references, "INV" by
+// default.
+func WithReferencePrefix(prefix string) Option {
+	return func(b *Book) {
+		b.prefix = prefix
+	}
+}
+
+// Book issues invoices and settles them from the deposits attributed by its
+// matcher. Callbacks are called from Poll or Run, without locks held.
+type Book struct {
+	matcher   *deposits.Matcher
+	store     store.Store
+	onPaid    Callback
+	onExpired Callback
+	prefix    string
+	now       func() time.Time
+
+	// mu serialises status changes.
+	mu sync.Mutex
+}
+
+// New returns a Book settling invoices with m. m must not be polled by
+// anything else, or deposits will be missed.
+func New(m *deposits.Matcher, opts ...Option) *Book {
+	b := &Book{matcher: m, store: store.NewMemory(), prefix: "INV", now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Create issues an invoice for amount of currency, expiring after ttl, or
+// never if ttl is zero.
+func (b *Book) Create(ctx context.Context, amount decimal.Decimal, currency string, ttl time.Duration) (*Invoice, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("invoice: amount must be positive, got %s", amount)
+	}
+	ref, err := b.reference()
+	if err != nil {
+		return nil, err
+	}
+	now := b.now().UTC()
+	inv := &Invoice{
+		ID:        ref,
+		Amount:    amount,
+		Currency:  strings.ToUpper(currency),
+		Reference: ref,
+		Status:    StatusOpen,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		inv.ExpiresAt = now.Add(ttl)
+	}
+	if err := store.PutJSON(ctx, b.store, invoicesNamespace, inv.ID, inv); err != nil {
+		return nil, err
+	}
+	err = b.matcher.Expect(ctx, deposits.Expected{
+		Reference: inv.Reference,
+		Currency:  inv.Currency,
+		Amount:    inv.Amount,
+		Label:     inv.ID,
+	})
+	if err != nil {
+		b.store.Delete(context.WithoutCancel(ctx), invoicesNamespace, inv.ID)
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (b *Book) reference() (string, error) {
+	buf := make([]byte, referenceLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, c := range buf {
+		buf[i] = referenceAlphabet[int(c)%len(referenceAlphabet)]
+	}
+	return b.prefix + string(buf), nil
+}
+
+// Get returns the invoice with id.
+func (b *Book) Get(ctx context.Context, id string) (*Invoice, error) {
+	inv := new(Invoice)
+	err := store.GetJSON(ctx, b.store, invoicesNamespace, id, inv)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return inv, err
+}
+
+// Open returns the invoices that are neither settled nor expired.
+func (b *Book) Open(ctx context.Context) ([]Invoice, error) {
+	keys, err := b.store.List(ctx, invoicesNamespace)
+	if err != nil {
+		return nil, err
+	}
+	var open []Invoice
+	for _, key := range keys {
+		var inv Invoice
+		if err := store.GetJSON(ctx, b.store, invoicesNamespace, key, &inv); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if inv.Status == StatusOpen {
+			open = append(open, inv)
+		}
+	}
+	return open, nil
+}
+
+// Poll settles invoices from new deposits and expires those past their
+// expiry. It can be called when an account stream reports a balance change,
+// to settle without waiting for the next interval of Run.
+func (b *Book) Poll(ctx context.Context) error {
+	var paid, expired []Invoice
+	err := b.poll(ctx, &paid, &expired)
+	for _, inv := range paid {
+		if b.onPaid != nil {
+			b.onPaid(inv)
+		}
+	}
+	for _, inv := range expired {
+		if b.onExpired != nil {
+			b.onExpired(inv)
+		}
+	}
+	return err
+}
+
+func (b *Book) poll(ctx context.Context, paid, expired *[]Invoice) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events, err := b.matcher.Poll(ctx)
+	for _, ev := range events {
+		if ev.Type != deposits.EventMatched {
+			continue
+		}
+		inv, gerr := b.Get(ctx, ev.Expected.Label)
+		if gerr != nil {
+			// Not one of ours.
+			continue
+		}
+		if inv.Status != StatusOpen {
+			continue
+		}
+		inv.Status = StatusPaid
+		if ev.Difference.IsNegative() {
+			inv.Status = StatusUnderpaid
+		}
+		inv.Received, inv.PaidAt, inv.DepositID = ev.Deposit.CreditValue, ev.Deposit.EventAt.Time, ev.Deposit.ID
+		if perr := store.PutJSON(ctx, b.store, invoicesNamespace, inv.ID, inv); perr != nil {
+			return perr
+		}
+		*paid = append(*paid, *inv)
+	}
+	if err != nil {
+		return err
+	}
+
+	open, err := b.Open(ctx)
+	if err != nil {
+		return err
+	}
+	now := b.now()
+	for _, inv := range open {
+		if inv.ExpiresAt.IsZero() || now.Before(inv.ExpiresAt) {
+			continue
+		}
+		if err := b.matcher.Forget(ctx, inv.Reference); err != nil {
+			return err
+		}
+		inv.Status = StatusExpired
+		if err := store.PutJSON(ctx, b.store, invoicesNamespace, inv.ID, inv); err != nil {
+			return err
+		}
+		*expired = append(*expired, inv)
+	}
+	return nil
+}
+
+// Run polls every interval until ctx is done. Failed polls are logged and
+// retried at the next interval.
+func (b *Book) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := b.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/invoice: Failed to poll invoices: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
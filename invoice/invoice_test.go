@@ -0,0 +1,99 @@
+package invoice_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/deposits"
+	"github.com/donohutcheon/valr-go/invoice"
+	"github.com/shopspring/decimal"
+)
+
+func TestBook(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		history []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "[%s]", strings.Join(history, ","))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var paid, expired []invoice.Invoice
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+	book := invoice.New(deposits.NewMatcher(cl, deposits.WithSince(start)),
+		invoice.WithPaidCallback(func(inv invoice.Invoice) { paid = append(paid, inv) }),
+		invoice.WithExpiredCallback(func(inv invoice.Invoice) { expired = append(expired, inv) }),
+	)
+
+	a, err := book.Create(ctx, decimal.New(100, 0), "zar", 0)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	b, err := book.Create(ctx, decimal.New(100, 0), "ZAR", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !strings.HasPrefix(a.Reference, "INV") || a.Reference == b.Reference || a.Currency != "ZAR" {
+		t.Errorf("Unexpected invoices %+v and %+v", a, b)
+	}
+	if _, err := book.Create(ctx, decimal.Zero, "ZAR", 0); err == nil {
+		t.Errorf("Expected error for zero amount")
+	}
+
+	mu.Lock()
+	history = append(history, fmt.Sprintf(
+		`{"id":"d1","transactionType":{"type":"FIAT_DEPOSIT"},"creditCurrency":"ZAR","creditValue":"100","eventAt":%q,"additionalInfo":{"reference":"payment %s"}}`,
+		time.Now().UTC().Format(time.RFC3339Nano), strings.ToLower(a.Reference)))
+	mu.Unlock()
+	time.Sleep(2 * time.Millisecond)
+
+	if err := book.Poll(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(paid) != 1 || paid[0].ID != a.ID || paid[0].Status != invoice.StatusPaid || paid[0].DepositID != "d1" {
+		t.Errorf("Expected %s to be paid, got %+v", a.ID, paid)
+	}
+	if len(expired) != 1 || expired[0].ID != b.ID || expired[0].Status != invoice.StatusExpired {
+		t.Errorf("Expected %s to expire, got %+v", b.ID, expired)
+	}
+
+	got, err := book.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if got.Status != invoice.StatusPaid || !got.Received.Equal(decimal.New(100, 0)) {
+		t.Errorf("Unexpected invoice %+v", got)
+	}
+	if open, _ := book.Open(ctx); len(open) != 0 {
+		t.Errorf("Expected no open invoices, got %+v", open)
+	}
+	if _, err := book.Get(ctx, "nope"); !errors.Is(err, invoice.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := book.Poll(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(paid) != 1 || len(expired) != 1 {
+		t.Errorf("Expected callbacks to fire once, got %d and %d", len(paid), len(expired))
+	}
+}
@@ -0,0 +1,157 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrTradingHalted is returned for mutating calls made after the kill switch
+// has been engaged. Cancellations are still permitted.
+var ErrTradingHalted = errors.New("valr: trading halted by kill switch")
+
+// isMutating returns true for methods that create state on the exchange,
+// such as placing orders or withdrawing. DELETE is excluded since it is used
+// to cancel orders, which must remain possible while halted.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultKillConcurrency is the number of orders the kill switch cancels at
+// once by default.
+const defaultKillConcurrency = 8
+
+type killSwitchConfig struct {
+	subaccountIDs []string
+	concurrency   int
+}
+
+type KillSwitchOption func(*killSwitchConfig)
+
+// WithKillSubaccounts also cancels the open orders of the given subaccounts.
+func WithKillSubaccounts(ids ...string) KillSwitchOption {
+	return func(c *killSwitchConfig) {
+		c.subaccountIDs = append(c.subaccountIDs, ids...)
+	}
+}
+
+// WithKillConcurrency sets the number of orders cancelled at once, 8 by
+// default. Cancellations still share the client's rate limiter.
+func WithKillConcurrency(n int) KillSwitchOption {
+	return func(c *killSwitchConfig) {
+		c.concurrency = n
+	}
+}
+
+// CancelResult is the outcome of cancelling a single open order.
+type CancelResult struct {
+	// SubaccountID is empty for the primary account.
+	SubaccountID string
+	Order        OpenOrder
+	Err          error
+}
+
+// KillSwitchReport describes the orders cancelled by Client.KillSwitch.
+type KillSwitchReport struct {
+	HaltedAt  time.Time
+	Cancelled []CancelResult
+	Failed    []CancelResult
+	// ListErrors holds errors listing the open orders of an account, keyed
+	// by subaccount ID (empty for the primary account).
+	ListErrors map[string]error
+}
+
+// KillSwitch halts trading on the client and cancels every open order across
+// all pairs, optionally including subaccounts. Orders are cancelled
+// concurrently; see WithKillConcurrency. Once engaged, all further
+// mutating calls on the client fail with ErrTradingHalted until Resume is
+// called, including calls waiting for the rate limiter or to retry. Open
+// orders are listed once the mutating requests already sent have completed,
+// so the orders they placed are cancelled too. The report lists every order
+// that was, or failed to be, cancelled.
+func (cl *Client) KillSwitch(ctx context.Context, opts ...KillSwitchOption) (*KillSwitchReport, error) {
+	cfg := killSwitchConfig{concurrency: defaultKillConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	cl.halted.Store(true)
+	report := &KillSwitchReport{
+		HaltedAt:   time.Now(),
+		ListErrors: make(map[string]error),
+	}
+	// Orders placed by requests already sent may still be listed, so wait
+	// for them before listing. Should they take too long, cancel what can
+	// be cancelled anyway.
+	waitErr := cl.mutating.wait(ctx)
+
+	accounts := append([]string{""}, cfg.subaccountIDs...)
+	for _, id := range accounts {
+		actx := ctx
+		if id != "" {
+			actx = WithSubaccount(ctx, id)
+		}
+
+		orders, err := cl.GetAllOpenOrdersRequest(actx, &GetAllOpenOrdersRequest{})
+		if err != nil {
+			report.ListErrors[id] = err
+			continue
+		}
+		for _, res := range cl.cancelOrders(actx, id, orders, cfg.concurrency) {
+			if res.Err != nil {
+				report.Failed = append(report.Failed, res)
+			} else {
+				report.Cancelled = append(report.Cancelled, res)
+			}
+		}
+	}
+
+	if len(report.Failed) > 0 || len(report.ListErrors) > 0 {
+		return report, errors.New("valr: kill switch could not cancel all orders")
+	}
+	if waitErr != nil {
+		return report, fmt.Errorf("valr: kill switch did not wait for calls in progress: %w", waitErr)
+	}
+	return report, nil
+}
+
+// cancelOrders cancels orders of an account, concurrency at a time,
+// returning the results in the order of orders.
+func (cl *Client) cancelOrders(ctx context.Context, subaccountID string, orders []OpenOrder, concurrency int) []CancelResult {
+	results := make([]CancelResult, len(orders))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, o := range orders {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, o OpenOrder) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, err := cl.DelOrderRequest(ctx, &DelOrderRequest{Pair: o.Pair, ID: o.OrderID})
+			results[i] = CancelResult{SubaccountID: subaccountID, Order: o, Err: err}
+		}(i, o)
+	}
+	wg.Wait()
+	return results
+}
+
+// Halted returns true if the kill switch is engaged.
+func (cl *Client) Halted() bool {
+	return cl.halted.Load()
+}
+
+// Resume disengages the kill switch, allowing mutating calls again.
+func (cl *Client) Resume() {
+	cl.halted.Store(false)
+}
@@ -0,0 +1,260 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestKillSwitch(t *testing.T) {
+	var (
+		mu                sync.Mutex
+		posted            int
+		inFlight, maxSeen int
+		cancelled         []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account := r.Header.Get("X-VALR-SUB-ACCOUNT-ID")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /orders/open":
+			switch account {
+			case "":
+				w.Write([]byte(`[{"orderId":"o1","currencyPair":"BTCZAR"},{"orderId":"o2","currencyPair":"BTCZAR"},
+					{"orderId":"o3","currencyPair":"ETHZAR"},{"orderId":"o4","currencyPair":"ETHZAR"}]`))
+			case "sub1":
+				w.Write([]byte(`[{"orderId":"gone","currencyPair":"BTCZAR"}]`))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"code":-1,"message":"unavailable"}`))
+			}
+		case "DELETE /orders/order":
+			var req valr.DelOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			inFlight++
+			maxSeen = max(maxSeen, inFlight)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			if req.ID == "gone" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-1,"message":"Order not found"}`))
+				return
+			}
+			mu.Lock()
+			cancelled = append(cancelled, req.ID)
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{}`))
+		default:
+			mu.Lock()
+			posted++
+			mu.Unlock()
+			w.Write([]byte(`{"id":"order"}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	ctx := context.Background()
+	report, err := cl.KillSwitch(ctx, valr.WithKillSubaccounts("sub1", "sub2"))
+	if err == nil {
+		t.Errorf("Expected an error for the orders not cancelled")
+	}
+	if !cl.Halted() {
+		t.Errorf("Expected trading halted")
+	}
+
+	var ids []string
+	for _, res := range report.Cancelled {
+		if res.SubaccountID != "" || res.Err != nil {
+			t.Errorf("Unexpected cancellation %+v", res)
+		}
+		ids = append(ids, res.Order.OrderID)
+	}
+	if want := []string{"o1", "o2", "o3", "o4"}; len(ids) != len(want) || ids[0] != "o1" || ids[3] != "o4" {
+		t.Errorf("Expected %q cancelled in order, got %q", want, ids)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].SubaccountID != "sub1" || report.Failed[0].Order.OrderID != "gone" ||
+		report.Failed[0].Err == nil {
+		t.Errorf("Expected the subaccount order to fail, got %+v", report.Failed)
+	}
+	if _, ok := report.ListErrors["sub2"]; !ok || len(report.ListErrors) != 1 {
+		t.Errorf("Expected a listing error for sub2, got %v", report.ListErrors)
+	}
+	mu.Lock()
+	if len(cancelled) != 4 {
+		t.Errorf("Expected 4 orders cancelled, got %q", cancelled)
+	}
+	if maxSeen < 2 {
+		t.Errorf("Expected orders cancelled concurrently, got at most %d at once", maxSeen)
+	}
+	mu.Unlock()
+
+	// Orders can't be placed while halted.
+	order := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+	}
+	if _, err := cl.PostLimitOrderRequest(ctx, order); !errors.Is(err, valr.ErrTradingHalted) {
+		t.Errorf("Expected ErrTradingHalted, got %v", err)
+	}
+	cl.Resume()
+	if _, err := cl.PostLimitOrderRequest(ctx, order); err != nil {
+		t.Errorf("Expected success after resuming, got %v", err)
+	}
+	mu.Lock()
+	if posted != 1 {
+		t.Errorf("Expected 1 order placed, got %d", posted)
+	}
+	mu.Unlock()
+}
+
+func TestKillSwitchPlacementInProgress(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	var (
+		mu     sync.Mutex
+		placed bool
+		listed bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			close(received)
+			<-release
+			mu.Lock()
+			placed = true
+			mu.Unlock()
+			w.Write([]byte(`{"id":"o1"}`))
+		case "GET /orders/open":
+			mu.Lock()
+			listed = true
+			open := placed
+			mu.Unlock()
+			if open {
+				w.Write([]byte(`[{"orderId":"o1","currencyPair":"BTCZAR"}]`))
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case "DELETE /orders/order":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+	order := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+	}
+
+	placement := make(chan error, 1)
+	go func() {
+		_, err := cl.PostLimitOrderRequest(ctx, order)
+		placement <- err
+	}()
+	<-received
+
+	reports := make(chan *valr.KillSwitchReport, 1)
+	go func() {
+		report, err := cl.KillSwitch(ctx)
+		if err != nil {
+			t.Errorf("Expected success, got %v", err)
+		}
+		reports <- report
+	}()
+
+	// Open orders aren't listed until the placement completes.
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	if listed {
+		t.Error("Expected open orders listed after the placement completed")
+	}
+	mu.Unlock()
+	close(release)
+
+	if err := <-placement; err != nil {
+		t.Fatalf("Expected the placement to succeed, got %v", err)
+	}
+	report := <-reports
+	if len(report.Cancelled) != 1 || report.Cancelled[0].Order.OrderID != "o1" {
+		t.Errorf("Expected the placed order cancelled, got %+v", report.Cancelled)
+	}
+}
+
+func TestKillSwitchPlacementRetrying(t *testing.T) {
+	limited := make(chan struct{}, 1)
+	var (
+		mu     sync.Mutex
+		posted int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			mu.Lock()
+			posted++
+			mu.Unlock()
+			select {
+			case limited <- struct{}{}:
+			default:
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":-1,"message":"Rate limit exceeded"}`))
+		case "GET /orders/open":
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(&valr.RetryPolicy{MaxRetries: 5, MinBackoff: 50 * time.Millisecond})
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+
+	placement := make(chan error, 1)
+	go func() {
+		_, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+		})
+		placement <- err
+	}()
+	<-limited
+
+	// The retry is never sent.
+	if _, err := cl.KillSwitch(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := <-placement; !errors.Is(err, valr.ErrTradingHalted) {
+		t.Errorf("Expected ErrTradingHalted, got %v", err)
+	}
+	mu.Lock()
+	if posted != 1 {
+		t.Errorf("Expected 1 attempt sent, got %d", posted)
+	}
+	mu.Unlock()
+}
@@ -0,0 +1,123 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLatencyBudgetExceeded is matched (via errors.Is) by errors returned
+// when an order placement takes longer than its latency budget.
+var ErrLatencyBudgetExceeded = errors.New("valr: latency budget exceeded")
+
+// lateCancelTimeout bounds the cancellation of an order placed too late.
+const lateCancelTimeout = 10 * time.Second
+
+type latencyBudgetKey struct{}
+
+type latencyBudget struct {
+	max    time.Duration
+	cancel bool
+}
+
+// WithLatencyBudget returns a context for order placements that gives up on
+// the placement if no response arrives within d, or fails it if the
+// response arrives later than d. A late acknowledgement often means the
+// price has moved since the order was priced, so it is better treated as a
+// failure. The order may still have been placed; see
+// WithLatencyBudgetCancel.
+func WithLatencyBudget(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, latencyBudgetKey{}, latencyBudget{max: d})
+}
+
+// WithLatencyBudgetCancel is like WithLatencyBudget, but also cancels an
+// order placed over budget by its customerOrderId, so it doesn't stand.
+// Orders placed without a customerOrderId can't be identified after a
+// timeout and are not cancelled.
+func WithLatencyBudgetCancel(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, latencyBudgetKey{}, latencyBudget{max: d, cancel: true})
+}
+
+// LatencyBudgetError describes an order placement that took longer than its
+// latency budget.
+type LatencyBudgetError struct {
+	Pair            string
+	CustomerOrderID string
+	Budget          time.Duration
+	// Elapsed is the time taken until the response, or until the placement
+	// was given up on.
+	Elapsed time.Duration
+	// TimedOut is true if no response arrived within the budget, so whether
+	// the order was placed is unknown.
+	TimedOut bool
+	// CancelAttempted is true if the order was cancelled by its
+	// customerOrderId, with CancelErr holding any error doing so.
+	CancelAttempted bool
+	CancelErr       error
+}
+
+func (e *LatencyBudgetError) Error() string {
+	msg := fmt.Sprintf("valr: latency budget of %s exceeded placing %s order %q: took %s",
+		e.Budget, e.Pair, e.CustomerOrderID, e.Elapsed)
+	if e.TimedOut {
+		msg += " without a response"
+	}
+	switch {
+	case e.CancelAttempted && e.CancelErr != nil:
+		msg += fmt.Sprintf("; cancelling failed: %v", e.CancelErr)
+	case e.CancelAttempted:
+		msg += "; cancelled"
+	}
+	return msg
+}
+
+func (e *LatencyBudgetError) Unwrap() error {
+	return ErrLatencyBudgetExceeded
+}
+
+// placeWithinBudget calls place, enforcing the latency budget of ctx if it
+// has one. An order acknowledged late is returned along with the error, so
+// callers can see what was placed.
+func placeWithinBudget[T any](ctx context.Context, cl *Client, pair, customerOrderID string,
+	place func(ctx context.Context) (T, error),
+) (T, error) {
+	lb, ok := ctx.Value(latencyBudgetKey{}).(latencyBudget)
+	if !ok || lb.max <= 0 {
+		return place(ctx)
+	}
+
+	start := time.Now()
+	placeCtx, cancel := context.WithTimeout(ctx, lb.max)
+	defer cancel()
+	res, err := place(placeCtx)
+	elapsed := time.Since(start)
+
+	timedOut := err != nil && ctx.Err() == nil && placeCtx.Err() != nil
+	if err != nil && !timedOut {
+		return res, err
+	}
+	if !timedOut && elapsed <= lb.max {
+		return res, nil
+	}
+
+	lbe := &LatencyBudgetError{
+		Pair:            pair,
+		CustomerOrderID: customerOrderID,
+		Budget:          lb.max,
+		Elapsed:         elapsed,
+		TimedOut:        timedOut,
+	}
+	if lb.cancel && customerOrderID != "" {
+		// The caller's context may be about to end, but the order must not
+		// be left standing.
+		delCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lateCancelTimeout)
+		defer cancel()
+		_, lbe.CancelErr = cl.DelOrderByCustomerOrderIDRequest(delCtx, &DelOrderByCustomerOrderIDRequest{
+			Pair: pair,
+			ID:   customerOrderID,
+		})
+		lbe.CancelAttempted = true
+	}
+	return res, lbe
+}
@@ -0,0 +1,92 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestLatencyBudget(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		delay     time.Duration
+		cancelled []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			mu.Lock()
+			d := delay
+			mu.Unlock()
+			time.Sleep(d)
+			w.Write([]byte(`{"id":"order-1"}`))
+		case http.MethodDelete:
+			var req valr.DelOrderByCustomerOrderIDRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			cancelled = append(cancelled, req.ID)
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+	cl.SetRetryPolicy(nil)
+
+	order := func(id string) *valr.PostLimitOrderRequest {
+		return &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY, CustomerOrderID: id,
+			Quantity: decimal.New(1, -3), Price: decimal.New(1000000, 0),
+		}
+	}
+
+	ctx := valr.WithLatencyBudgetCancel(context.Background(), time.Second)
+	res, err := cl.PostLimitOrderRequest(ctx, order("fast"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.ID != "order-1" {
+		t.Errorf("Expected %q, got %q", "order-1", res.ID)
+	}
+
+	mu.Lock()
+	delay = 200 * time.Millisecond
+	mu.Unlock()
+	ctx = valr.WithLatencyBudgetCancel(context.Background(), 50*time.Millisecond)
+	_, err = cl.PostLimitOrderRequest(ctx, order("slow"))
+	var lbe *valr.LatencyBudgetError
+	if !errors.As(err, &lbe) || !errors.Is(err, valr.ErrLatencyBudgetExceeded) {
+		t.Fatalf("Expected a latency budget error, got %v", err)
+	}
+	if !lbe.TimedOut || !lbe.CancelAttempted || lbe.CancelErr != nil {
+		t.Errorf("Expected a timed out, cancelled order, got %+v", lbe)
+	}
+	mu.Lock()
+	if len(cancelled) != 1 || cancelled[0] != "slow" {
+		t.Errorf("Expected order %q cancelled, got %q", "slow", cancelled)
+	}
+	mu.Unlock()
+
+	// Without cancelling, the late order is left alone.
+	ctx = valr.WithLatencyBudget(context.Background(), 50*time.Millisecond)
+	if _, err := cl.PostLimitOrderRequest(ctx, order("left")); !errors.Is(err, valr.ErrLatencyBudgetExceeded) {
+		t.Fatalf("Expected a latency budget error, got %v", err)
+	}
+	mu.Lock()
+	if len(cancelled) != 1 {
+		t.Errorf("Expected no more cancellations, got %q", cancelled)
+	}
+	mu.Unlock()
+}
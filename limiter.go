@@ -2,6 +2,7 @@ package valr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -14,15 +15,34 @@ const (
 	defaultMaxPerInterval = 1000
 )
 
+// ErrLimiterClosed is returned by RateLimiter.Wait once the limiter has been
+// closed.
+var ErrLimiterClosed = errors.New("valr: rate limiter closed")
+
 type Limiter interface {
 	Wait(context.Context) error
 }
 
+// pauser is implemented by limiters that can hold back calls when VALR asks
+// the client to slow down.
+type pauser interface {
+	Pause(d time.Duration)
+}
+
 type RateLimiter struct {
 	cond           *sync.Cond
 	requestCount   int
 	rate           time.Duration
 	maxPerInterval int
+	reserved       int
+	closed         bool
+	pausedUntil    time.Time
+	// priorityWaiting counts priority calls blocked in Wait, which other
+	// calls give way to.
+	priorityWaiting int
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type RateLimiterOption func(limiter *RateLimiter)
@@ -39,6 +59,33 @@ func WithMaxPerInterval(maxPerInterval int) RateLimiterOption {
 	}
 }
 
+// WithReserved reserves n requests of every interval for priority calls, so
+// they get through even when other traffic has used up the rest.
+func WithReserved(n int) RateLimiterOption {
+	return func(limiter *RateLimiter) {
+		limiter.reserved = n
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context for calls that take priority over others
+// in the rate limiter: they may use the requests reserved with WithReserved
+// and are let through ahead of other waiting calls when the limit resets.
+// Pauses requested by VALR still apply to them.
+func WithPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// IsPriority reports whether ctx was returned by WithPriority, for custom
+// limiters.
+func IsPriority(ctx context.Context) bool {
+	p, _ := ctx.Value(priorityKey{}).(bool)
+	return p
+}
+
+// NewRateLimiter creates a limiter and starts its reset goroutine. Call Close
+// to stop the goroutine once the limiter is no longer needed.
 func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
 	mu := new(sync.Mutex)
 	cond := sync.NewCond(mu)
@@ -47,39 +94,164 @@ func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
 		requestCount:   0,
 		rate:           defaultRate,
 		maxPerInterval: defaultMaxPerInterval,
+		done:           make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(rl)
 	}
 
-	go func() {
-		for {
-			rl.resetCount()
-		}
-	}()
+	go rl.resetForever()
 
 	return rl
 }
 
 func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := l.waitPause(ctx); err != nil {
+		return err
+	}
+
+	l.cond.L.Lock()
+	defer l.cond.L.Unlock()
+
+	priority := IsPriority(ctx)
+	blocked := func() bool {
+		if priority {
+			return l.requestCount >= l.maxPerInterval
+		}
+		return l.requestCount >= l.maxPerInterval-l.reserved || l.priorityWaiting > 0
+	}
+
+	if !l.closed && blocked() {
+		// Fail fast rather than waiting for a reset that lands after the
+		// caller's deadline.
+		reset := nextReset(l.rate)
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(reset) {
+			return &DeadlineBudgetError{
+				Needed:    time.Until(reset),
+				Remaining: max(time.Until(deadline), 0),
+			}
+		}
+
+		stop := context.AfterFunc(ctx, func() {
+			l.cond.L.Lock()
+			defer l.cond.L.Unlock()
+			l.cond.Broadcast()
+		})
+		defer stop()
+
+		if priority {
+			l.priorityWaiting++
+			defer func() {
+				l.priorityWaiting--
+				l.cond.Broadcast()
+			}()
+		}
+	}
+
+	for !l.closed && blocked() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fmt.Printf("Rate limit exceeded. Waiting for reset\n")
+		l.cond.Wait()
+	}
+	if l.closed {
+		return ErrLimiterClosed
+	}
+
+	l.requestCount++
+	return nil
+}
+
+// Pause holds back calls to Wait for d, e.g. when VALR responded with a
+// Retry-After header. Pauses never shorten one already in effect.
+func (l *RateLimiter) Pause(d time.Duration) {
 	l.cond.L.Lock()
 	defer l.cond.L.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
 
-	if l.requestCount < l.maxPerInterval {
-		l.requestCount++
-		return nil
+// waitPause blocks until any pause has elapsed.
+func (l *RateLimiter) waitPause(ctx context.Context) error {
+	for {
+		l.cond.L.Lock()
+		d := time.Until(l.pausedUntil)
+		closed := l.closed
+		l.cond.L.Unlock()
+		if closed || d <= 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+			return &DeadlineBudgetError{
+				Needed:    d,
+				Remaining: max(time.Until(deadline), 0),
+			}
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-l.done:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		t.Stop()
 	}
+}
 
-	fmt.Printf("Rate limit exceeded. Waiting for reset\n")
-	l.cond.Wait()
+// Remaining returns the number of requests still allowed in the current
+// interval.
+func (l *RateLimiter) Remaining() int {
+	l.cond.L.Lock()
+	defer l.cond.L.Unlock()
+	return max(l.maxPerInterval-l.requestCount, 0)
+}
+
+// RateLimitRemaining returns the number of requests the client's rate
+// limiter still allows in the current interval, and false if the limiter
+// doesn't report it.
+func (cl *Client) RateLimitRemaining() (int, bool) {
+	rl, ok := cl.rateLimiter.(interface{ Remaining() int })
+	if !ok {
+		return 0, false
+	}
+	return rl.Remaining(), true
+}
 
+// Close stops the reset goroutine and releases any callers blocked in Wait.
+// It is safe to call Close more than once.
+func (l *RateLimiter) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+
+		l.cond.L.Lock()
+		l.closed = true
+		l.cond.Broadcast()
+		l.cond.L.Unlock()
+	})
 	return nil
 }
 
+func (l *RateLimiter) resetForever() {
+	timer := time.NewTimer(time.Until(nextReset(l.rate)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-timer.C:
+			l.resetCount()
+			timer.Reset(time.Until(nextReset(l.rate)))
+		}
+	}
+}
+
 func (l *RateLimiter) resetCount() {
-	until := time.Until(nextReset(l.rate))
-	time.Sleep(until)
 	l.cond.L.Lock()
 	defer l.cond.L.Unlock()
 	l.requestCount = 0
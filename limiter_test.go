@@ -0,0 +1,118 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestRateLimiterDeadlineBudget(t *testing.T) {
+	l := valr.NewRateLimiter(valr.WithRate(time.Hour), valr.WithMaxPerInterval(1))
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err != nil {
+		t.Errorf("Expected success, got %v", err)
+		return
+	}
+
+	start := time.Now()
+	err := l.Wait(ctx)
+	if !errors.Is(err, valr.ErrDeadlineBudgetExceeded) {
+		t.Errorf("Expected ErrDeadlineBudgetExceeded, got %v", err)
+		return
+	}
+	if time.Since(start) > 40*time.Millisecond {
+		t.Errorf("Expected Wait to fail fast, took %s", time.Since(start))
+	}
+}
+
+func TestRateLimiterClose(t *testing.T) {
+	l := valr.NewRateLimiter(valr.WithRate(time.Hour), valr.WithMaxPerInterval(1))
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Expected success, got %v", err)
+		return
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- l.Wait(context.Background())
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	l.Close()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, valr.ErrLimiterClosed) {
+			t.Errorf("Expected ErrLimiterClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected Close to release waiters")
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	l := valr.NewRateLimiter()
+	defer l.Close()
+
+	l.Pause(50 * time.Millisecond)
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("Expected Wait to be paused, took %s", d)
+	}
+}
+
+func TestRateLimiterReserved(t *testing.T) {
+	l := valr.NewRateLimiter(valr.WithRate(time.Hour), valr.WithMaxPerInterval(2), valr.WithReserved(1))
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := l.Wait(ctx); !errors.Is(err, valr.ErrDeadlineBudgetExceeded) {
+		t.Errorf("Expected the reserved request to be held back, got %v", err)
+	}
+	if err := l.Wait(valr.WithPriority(ctx)); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+}
+
+func TestRateLimiterPriorityGoesFirst(t *testing.T) {
+	l := valr.NewRateLimiter(valr.WithRate(200*time.Millisecond), valr.WithMaxPerInterval(1))
+	defer l.Close()
+
+	// Start early in a fresh interval, after the limiter has reset, so the
+	// waiters below see a single reset.
+	time.Sleep(time.Until(time.Now().Truncate(200 * time.Millisecond).Add(250 * time.Millisecond)))
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	order := make(chan string, 2)
+	go func() {
+		l.Wait(context.Background())
+		order <- "normal"
+	}()
+	time.Sleep(20 * time.Millisecond)
+	go func() {
+		l.Wait(valr.WithPriority(context.Background()))
+		order <- "priority"
+	}()
+
+	if first := <-order; first != "priority" {
+		t.Errorf("Expected %q, got %q", "priority", first)
+	}
+	<-order
+}
@@ -0,0 +1,286 @@
+package marketdata
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+const (
+	defaultTradeCacheSize  = 100000
+	defaultCandleCacheSize = 100000
+	defaultHistoryChunk    = time.Hour
+	// settleDelay is how long after a range ends before it is cached, since
+	// trades may still be reported in it.
+	settleDelay = time.Minute
+)
+
+// CacheStats counts the lookups of a HistoryCache and what it holds.
+type CacheStats struct {
+	TradeHits    uint64
+	TradeMisses  uint64
+	CandleHits   uint64
+	CandleMisses uint64
+	// Trades and Candles are the number of trades and candles held.
+	Trades  int
+	Candles int
+}
+
+type HistoryCacheOption func(*HistoryCache)
+
+// WithTradeCacheSize bounds the trades held, across all cached ranges, to
+// n, 100000 by default. The least recently used ranges are evicted first.
+func WithTradeCacheSize(n int) HistoryCacheOption {
+	return func(c *HistoryCache) {
+		c.trades.capacity = n
+	}
+}
+
+// WithCandleCacheSize bounds the candles held to n, 100000 by default.
+func WithCandleCacheSize(n int) HistoryCacheOption {
+	return func(c *HistoryCache) {
+		c.candles.capacity = n
+	}
+}
+
+// WithHistoryChunk sets the chunk size trade history is fetched in, an
+// hour by default.
+func WithHistoryChunk(d time.Duration) HistoryCacheOption {
+	return func(c *HistoryCache) {
+		c.chunk = d
+	}
+}
+
+// HistoryCache fetches trade history and builds candles, keeping recent
+// results in memory so repeated queries, such as when tuning analytics
+// over the same period, don't call the API again. Both caches are bounded
+// by the number of trades or candles held. Ranges ending within the last
+// minute are never cached, since trades may still be added to them.
+type HistoryCache struct {
+	client *valr.Client
+	chunk  time.Duration
+	now    func() time.Time
+
+	trades  *lru[tradeKey, []valr.TradeHistoryInfo]
+	candles *lru[candleKey, []Candle]
+}
+
+type tradeKey struct {
+	pair     string
+	from, to int64
+}
+
+type candleKey struct {
+	tradeKey
+	interval time.Duration
+	location string
+}
+
+// NewHistoryCache returns a cache fetching trade history with cl.
+func NewHistoryCache(cl *valr.Client, opts ...HistoryCacheOption) *HistoryCache {
+	c := &HistoryCache{
+		client:  cl,
+		chunk:   defaultHistoryChunk,
+		now:     time.Now,
+		trades:  newLRU[tradeKey, []valr.TradeHistoryInfo](defaultTradeCacheSize),
+		candles: newLRU[candleKey, []Candle](defaultCandleCacheSize),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Trades returns the trades of pair between from and to, oldest first. The
+// result must not be modified.
+func (c *HistoryCache) Trades(ctx context.Context, pair string, from, to time.Time) ([]valr.TradeHistoryInfo, error) {
+	key := tradeKey{pair: pair, from: from.UnixNano(), to: to.UnixNano()}
+	if trades, ok := c.trades.get(key); ok {
+		return trades, nil
+	}
+	trades, err := c.client.GetAuthTradeHistoryForPairRange(ctx, pair, from, to, c.chunk)
+	if err != nil {
+		return nil, err
+	}
+	if c.settled(to) {
+		c.trades.put(key, trades, len(trades))
+	}
+	return trades, nil
+}
+
+// Candles returns the candles of pair between from and to, built from its
+// trades as BuildCandles does. The result must not be modified.
+func (c *HistoryCache) Candles(ctx context.Context, pair string, from, to time.Time, interval time.Duration, opts ...CandleOption) ([]Candle, error) {
+	key := candleKey{
+		tradeKey: tradeKey{pair: pair, from: from.UnixNano(), to: to.UnixNano()},
+		interval: interval,
+		location: newCandleOptions(opts).location.String(),
+	}
+	if candles, ok := c.candles.get(key); ok {
+		return candles, nil
+	}
+	trades, err := c.Trades(ctx, pair, from, to)
+	if err != nil {
+		return nil, err
+	}
+	candles, err := BuildCandles(trades, interval, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.settled(to) {
+		c.candles.put(key, candles, len(candles))
+	}
+	return candles, nil
+}
+
+// settled returns true if no more trades are expected before to.
+func (c *HistoryCache) settled(to time.Time) bool {
+	return to.Before(c.now().Add(-settleDelay))
+}
+
+// candleSeries returns the cached candles, least recently used first.
+func (c *HistoryCache) candleSeries() []CandleSeries {
+	var series []CandleSeries
+	c.candles.each(func(k candleKey, candles []Candle) {
+		series = append(series, CandleSeries{
+			Pair:     k.pair,
+			From:     time.Unix(0, k.from).UTC(),
+			To:       time.Unix(0, k.to).UTC(),
+			Interval: k.interval,
+			Location: k.location,
+			Candles:  candles,
+		})
+	})
+	return series
+}
+
+// restoreCandles caches candles taken with candleSeries.
+func (c *HistoryCache) restoreCandles(series []CandleSeries) {
+	for _, cs := range series {
+		key := candleKey{
+			tradeKey: tradeKey{pair: cs.Pair, from: cs.From.UnixNano(), to: cs.To.UnixNano()},
+			interval: cs.Interval,
+			location: cs.Location,
+		}
+		c.candles.put(key, cs.Candles, len(cs.Candles))
+	}
+}
+
+// Purge discards everything cached.
+func (c *HistoryCache) Purge() {
+	c.trades.purge()
+	c.candles.purge()
+}
+
+// Stats returns the lookups made and what is held.
+func (c *HistoryCache) Stats() CacheStats {
+	var st CacheStats
+	st.TradeHits, st.TradeMisses, st.Trades = c.trades.stats()
+	st.CandleHits, st.CandleMisses, st.Candles = c.candles.stats()
+	return st
+}
+
+// ServeHTTP writes the stats as Prometheus metrics in the text exposition
+// format.
+func (c *HistoryCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st := c.Stats()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP valr_history_cache_hits_total Lookups served from the history cache.\n# TYPE valr_history_cache_hits_total counter\n")
+	fmt.Fprintf(w, "valr_history_cache_hits_total{cache=\"trades\"} %d\n", st.TradeHits)
+	fmt.Fprintf(w, "valr_history_cache_hits_total{cache=\"candles\"} %d\n", st.CandleHits)
+	fmt.Fprintf(w, "# HELP valr_history_cache_misses_total Lookups not served from the history cache.\n# TYPE valr_history_cache_misses_total counter\n")
+	fmt.Fprintf(w, "valr_history_cache_misses_total{cache=\"trades\"} %d\n", st.TradeMisses)
+	fmt.Fprintf(w, "valr_history_cache_misses_total{cache=\"candles\"} %d\n", st.CandleMisses)
+	fmt.Fprintf(w, "# HELP valr_history_cache_items Trades or candles held by the history cache.\n# TYPE valr_history_cache_items gauge\n")
+	fmt.Fprintf(w, "valr_history_cache_items{cache=\"trades\"} %d\n", st.Trades)
+	fmt.Fprintf(w, "valr_history_cache_items{cache=\"candles\"} %d\n", st.Candles)
+}
+
+// lru is a least recently used cache bounded by the total cost of its
+// entries.
+type lru[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	cost     int
+	order    *list.List // of *lruEntry, most recent first
+	entries  map[K]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int
+}
+
+func newLRU[K comparable, V any](capacity int) *lru[K, V] {
+	return &lru[K, V]{capacity: capacity, order: list.New(), entries: make(map[K]*list.Element)}
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry[K, V]).value, true
+}
+
+// put adds an entry, evicting the least recently used entries to make
+// room. Entries costing more than the capacity are not added.
+func (c *lru[K, V]) put(key K, value V, cost int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cost > c.capacity {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	for c.cost+cost > c.capacity {
+		c.remove(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+}
+
+func (c *lru[K, V]) remove(el *list.Element) {
+	e := c.order.Remove(el).(*lruEntry[K, V])
+	delete(c.entries, e.key)
+	c.cost -= e.cost
+}
+
+// each calls fn with the entries, least recently used first.
+func (c *lru[K, V]) each(fn func(key K, value V)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.order.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*lruEntry[K, V])
+		fn(e.key, e.value)
+	}
+}
+
+func (c *lru[K, V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[K]*list.Element)
+	c.cost = 0
+}
+
+func (c *lru[K, V]) stats() (hits, misses uint64, cost int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.cost
+}
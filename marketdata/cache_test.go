@@ -0,0 +1,113 @@
+package marketdata_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+func TestHistoryCache(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	var trades []valr.TradeHistoryInfo
+	for i := 0; i < 4; i++ {
+		trades = append(trades, valr.TradeHistoryInfo{
+			ID: string(rune('a' + i)), Pair: "BTCZAR", TradedAt: valr.NewTimestamp(start.Add(time.Duration(i) * 10 * time.Minute)),
+			Price: decimal.New(int64(100+i), 0), Quantity: decimal.New(1, 0),
+		})
+	}
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		items := []valr.TradeHistoryInfo{}
+		if q.Get("skip") == "" || q.Get("skip") == "0" {
+			from, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+			to, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+			for i := len(trades) - 1; i >= 0; i-- {
+				if tr := trades[i]; !tr.TradedAt.Before(from) && !tr.TradedAt.After(to) {
+					items = append(items, tr)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	ctx := context.Background()
+	c := marketdata.NewHistoryCache(cl, marketdata.WithTradeCacheSize(5))
+	end := start.Add(time.Hour)
+
+	got, err := c.Trades(ctx, "BTCZAR", start, end)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 trades, got %d", len(got))
+	}
+	fetches := requests.Load()
+	if _, err := c.Trades(ctx, "BTCZAR", start, end); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n := requests.Load(); n != fetches {
+		t.Errorf("Expected cached trades, got %d more requests", n-fetches)
+	}
+
+	candles, err := c.Candles(ctx, "BTCZAR", start, end, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(candles) != 2 || !candles[1].Close.Equal(decimal.New(103, 0)) {
+		t.Errorf("Expected 2 candles closing at 103, got %+v", candles)
+	}
+	if _, err := c.Candles(ctx, "BTCZAR", start, end, 30*time.Minute); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if n := requests.Load(); n != fetches {
+		t.Errorf("Expected cached candles, got %d more requests", n-fetches)
+	}
+
+	// A second range overflows the 5 trade cache, evicting the first.
+	if _, err := c.Trades(ctx, "BTCZAR", start.Add(10*time.Minute), end); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	st := c.Stats()
+	if st.Trades != 3 || st.Candles != 2 {
+		t.Errorf("Expected 3 trades and 2 candles held, got %+v", st)
+	}
+	if st.TradeHits != 2 || st.TradeMisses != 2 || st.CandleHits != 1 || st.CandleMisses != 1 {
+		t.Errorf("Expected 2/2 trade and 1/1 candle hits/misses, got %+v", st)
+	}
+
+	// Ranges that haven't settled are always fetched.
+	now := time.Now()
+	fetches = requests.Load()
+	for i := 0; i < 2; i++ {
+		if _, err := c.Trades(ctx, "BTCZAR", now.Add(-time.Minute), now); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	if n := requests.Load(); n != fetches+2 {
+		t.Errorf("Expected 2 requests for a recent range, got %d", n-fetches)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if want := `valr_history_cache_hits_total{cache="trades"} 2`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected metrics to contain %q, got %q", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,143 @@
+package marketdata
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidInterval is returned for candle intervals that are not positive.
+var ErrInvalidInterval = errors.New("marketdata: candle interval must be positive")
+
+// Candle is the open, high, low, close and volume of a pair's trades over an
+// interval.
+type Candle struct {
+	Pair     string
+	Start    time.Time
+	Interval time.Duration
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	// Volume is in the base currency and QuoteVolume in the quote currency.
+	Volume      decimal.Decimal
+	QuoteVolume decimal.Decimal
+	// VWAP is the volume weighted average price of the interval's trades.
+	VWAP   decimal.Decimal
+	Trades int
+}
+
+// End returns the end of the candle's interval, exclusive.
+func (c Candle) End() time.Time {
+	return c.Start.Add(c.Interval)
+}
+
+const (
+	day = 24 * time.Hour
+	// unixToZeroDays is the number of days from 0001-01-01 to 1970-01-01.
+	unixToZeroDays = 719162
+)
+
+type CandleOption func(*candleOptions)
+
+type candleOptions struct {
+	location *time.Location
+}
+
+// WithLocation aligns candles to the local time of loc, such as
+// Africa/Johannesburg, rather than UTC, so that daily candles start at local
+// midnight.
+func WithLocation(loc *time.Location) CandleOption {
+	return func(o *candleOptions) {
+		o.location = loc
+	}
+}
+
+func newCandleOptions(opts []CandleOption) candleOptions {
+	o := candleOptions{location: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// alignStart returns the start of the interval containing t, as
+// time.Truncate would align it in UTC but applied to loc's local time.
+// Intervals of whole days start at local midnight even when loc's offset
+// from UTC changes.
+func alignStart(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval%day != 0 {
+		_, offset := t.Zone()
+		shift := time.Duration(offset) * time.Second
+		return t.Add(shift).Truncate(interval).Add(-shift)
+	}
+	// Count days from 0001-01-01, the zero time Truncate aligns to.
+	n := int64(interval / day)
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()/86400 + unixToZeroDays
+	days -= (days%n + n) % n
+	return time.Date(1970, 1, 1+int(days-unixToZeroDays), 0, 0, 0, 0, loc)
+}
+
+// BuildCandles aggregates trade history into candles of the given interval,
+// aligned to multiples of the interval since the Unix epoch in UTC, or the
+// location set by WithLocation. Trades may be in any order and for several
+// pairs. Intervals without trades are omitted; see FillGaps. The result is
+// sorted by pair, then start time.
+func BuildCandles(trades []valr.TradeHistoryInfo, interval time.Duration, opts ...CandleOption) ([]Candle, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	o := newCandleOptions(opts)
+	sorted := append([]valr.TradeHistoryInfo(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Pair != sorted[j].Pair {
+			return sorted[i].Pair < sorted[j].Pair
+		}
+		if !sorted[i].TradedAt.Equal(sorted[j].TradedAt.Time) {
+			return sorted[i].TradedAt.Before(sorted[j].TradedAt.Time)
+		}
+		return sorted[i].SequenceID < sorted[j].SequenceID
+	})
+
+	var candles []Candle
+	for _, t := range sorted {
+		start := alignStart(t.TradedAt.Time, interval, o.location)
+		n := len(candles)
+		if n == 0 || candles[n-1].Pair != t.Pair || !candles[n-1].Start.Equal(start) {
+			candles = append(candles, newCandle(t, start, interval))
+			n++
+		}
+		candles[n-1].add(t)
+	}
+	return candles, nil
+}
+
+func newCandle(t valr.TradeHistoryInfo, start time.Time, interval time.Duration) Candle {
+	return Candle{
+		Pair:     t.Pair,
+		Start:    start,
+		Interval: interval,
+		Open:     t.Price,
+		High:     t.Price,
+		Low:      t.Price,
+	}
+}
+
+// add adds a trade within the candle's interval.
+func (c *Candle) add(t valr.TradeHistoryInfo) {
+	c.High = decimal.Max(c.High, t.Price)
+	c.Low = decimal.Min(c.Low, t.Price)
+	c.Close = t.Price
+	c.Volume = c.Volume.Add(t.Quantity)
+	c.QuoteVolume = c.QuoteVolume.Add(t.Price.Mul(t.Quantity))
+	c.Trades++
+	c.VWAP = c.Close
+	if c.Volume.IsPositive() {
+		c.VWAP = c.QuoteVolume.DivRound(c.Volume, 16)
+	}
+}
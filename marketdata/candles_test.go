@@ -0,0 +1,144 @@
+package marketdata_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+func TestBuildCandles(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	trade := func(offset time.Duration, price, qty string) valr.TradeHistoryInfo {
+		return valr.TradeHistoryInfo{
+			Pair: "BTCZAR", TradedAt: valr.NewTimestamp(at.Add(offset)),
+			Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString(qty),
+		}
+	}
+	candles, err := marketdata.BuildCandles([]valr.TradeHistoryInfo{
+		trade(90*time.Second, "120", "1"),
+		trade(10*time.Second, "100", "1"),
+		trade(30*time.Second, "90", "3"),
+		trade(50*time.Second, "110", "1"),
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("Expected 2 candles, got %d", len(candles))
+	}
+	c := candles[0]
+	if !c.Start.Equal(at) || c.Trades != 3 {
+		t.Errorf("Expected 3 trades from %v, got %d from %v", at, c.Trades, c.Start)
+	}
+	for _, f := range []struct {
+		name      string
+		got, want string
+	}{
+		{"open", c.Open.String(), "100"},
+		{"high", c.High.String(), "110"},
+		{"low", c.Low.String(), "90"},
+		{"close", c.Close.String(), "110"},
+		{"volume", c.Volume.String(), "5"},
+		{"vwap", c.VWAP.String(), "96"},
+	} {
+		if f.got != f.want {
+			t.Errorf("Expected %s %s, got %s", f.name, f.want, f.got)
+		}
+	}
+}
+
+func TestBuildCandlesLocation(t *testing.T) {
+	sast := time.FixedZone("SAST", 2*60*60)
+	trade := func(at time.Time) valr.TradeHistoryInfo {
+		return valr.TradeHistoryInfo{Pair: "BTCZAR", TradedAt: valr.NewTimestamp(at), Price: decimal.New(1, 0), Quantity: decimal.New(1, 0)}
+	}
+	// 23:00 UTC on the 1st is 01:00 on the 2nd in Johannesburg.
+	candles, err := marketdata.BuildCandles([]valr.TradeHistoryInfo{
+		trade(time.Date(2024, 1, 1, 21, 0, 0, 0, time.UTC)),
+		trade(time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)),
+	}, 24*time.Hour, marketdata.WithLocation(sast))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("Expected 2 candles, got %+v", candles)
+	}
+	if want := time.Date(2024, 1, 2, 0, 0, 0, 0, sast); !candles[1].Start.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, candles[1].Start)
+	}
+
+	// Daily candles start at local midnight across daylight saving changes.
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	candles, err = marketdata.BuildCandles([]valr.TradeHistoryInfo{
+		trade(time.Date(2024, 3, 30, 23, 30, 0, 0, time.UTC)),
+		trade(time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC)),
+	}, 24*time.Hour, marketdata.WithLocation(london))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, london); len(candles) != 2 || !candles[1].Start.Equal(want) {
+		t.Errorf("Expected second candle at %v, got %+v", want, candles)
+	}
+	twoDay, err := marketdata.Resample(candles, 48*time.Hour, marketdata.WithLocation(london))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, c := range twoDay {
+		if c.Start.Hour() != 0 || c.Start.Location() != london {
+			t.Errorf("Expected local midnight, got %v", c.Start)
+		}
+	}
+}
+
+func TestResampleAndAlign(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	candle := func(pair string, minute int, price string) marketdata.Candle {
+		p := decimal.RequireFromString(price)
+		return marketdata.Candle{
+			Pair: pair, Start: at.Add(time.Duration(minute) * time.Minute), Interval: time.Minute,
+			Open: p, High: p, Low: p, Close: p, VWAP: p,
+			Volume: decimal.New(1, 0), QuoteVolume: p, Trades: 1,
+		}
+	}
+
+	five, err := marketdata.Resample([]marketdata.Candle{
+		candle("BTCZAR", 0, "100"), candle("BTCZAR", 1, "120"), candle("BTCZAR", 6, "90"),
+	}, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(five) != 2 || five[0].Trades != 2 || five[0].High.String() != "120" || five[0].VWAP.String() != "110" {
+		t.Errorf("Unexpected resampled candles %+v", five)
+	}
+	if _, err := marketdata.Resample(five, 7*time.Minute); err == nil {
+		t.Errorf("Expected error resampling to a non-multiple interval")
+	}
+
+	filled := marketdata.FillGaps([]marketdata.Candle{candle("BTCZAR", 0, "100"), candle("BTCZAR", 3, "90")})
+	if len(filled) != 4 || filled[1].Close.String() != "100" || !filled[2].Volume.IsZero() {
+		t.Errorf("Unexpected filled candles %+v", filled)
+	}
+
+	index, aligned, err := marketdata.Align(map[string][]marketdata.Candle{
+		"BTCZAR": {candle("BTCZAR", 0, "100"), candle("BTCZAR", 3, "90")},
+		"ETHZAR": {candle("ETHZAR", 1, "10"), candle("ETHZAR", 2, "11")},
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(index) != 3 || !index[0].Equal(at.Add(time.Minute)) {
+		t.Fatalf("Unexpected index %v", index)
+	}
+	if got := aligned["BTCZAR"][0].Close.String(); got != "100" {
+		t.Errorf("Expected forward-filled close 100, got %s", got)
+	}
+	if got := aligned["ETHZAR"][2].Close.String(); got != "11" {
+		t.Errorf("Expected forward-filled close 11, got %s", got)
+	}
+}
@@ -0,0 +1,368 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+// Feed sources.
+const (
+	SourceStream = "stream"
+	SourceREST   = "rest"
+)
+
+const (
+	defaultFeedPollInterval    = 2 * time.Second
+	defaultFeedMaxPollInterval = 30 * time.Second
+	defaultFeedStaleAfter      = 30 * time.Second
+	defaultFeedBuffer          = 256
+	// feedSeenTrades is the number of trade IDs remembered per pair to
+	// drop trades delivered by both sources.
+	feedSeenTrades = 1000
+)
+
+type FeedOption func(*Feed)
+
+// WithStream serves trades from hub, attached to conn, while conn is
+// healthy. Without a stream the feed only polls.
+func WithStream(conn *streaming.Conn, hub *streaming.Hub) FeedOption {
+	return func(f *Feed) {
+		f.conn, f.hub = conn, hub
+	}
+}
+
+// WithFeedBooks sets the books used for the bid and ask of tickers served
+// from the stream. Without them, those tickers only carry the last price.
+func WithFeedBooks(k *streaming.BookKeeper) FeedOption {
+	return func(f *Feed) {
+		f.books = k
+	}
+}
+
+// WithPollInterval sets how often REST is polled during outages, 2 seconds
+// by default, and the longest interval it backs off to when the consumer
+// falls behind or calls are rate limited, 30 seconds by default.
+func WithPollInterval(interval, max time.Duration) FeedOption {
+	return func(f *Feed) {
+		f.pollInterval, f.maxPollInterval = interval, max
+	}
+}
+
+// WithStaleAfter sets how long the stream may be silent, including pongs,
+// before the feed falls back to polling, 30 seconds by default.
+func WithStaleAfter(d time.Duration) FeedOption {
+	return func(f *Feed) {
+		f.staleAfter = d
+	}
+}
+
+// WithFeedBuffer sets the size of the trade and ticker channel buffers, 256
+// by default.
+func WithFeedBuffer(n int) FeedOption {
+	return func(f *Feed) {
+		f.buffer = n
+	}
+}
+
+// WithFeedEventBus publishes the trades and tickers delivered by the feed to
+// b as Trade and Tick events, with the source serving the feed at the time.
+// Don't also attach b to the feed's connection with streaming.WithEventBus,
+// or streamed trades are published twice.
+func WithFeedEventBus(b *eventbus.Bus) FeedOption {
+	return func(f *Feed) {
+		f.bus = b
+	}
+}
+
+// Feed serves the trades and tickers of a set of pairs from the websocket
+// while it is healthy and from rate limited REST polling while it is down,
+// so consumers see a single stream across outages. Trades delivered by both
+// sources around a switch are only delivered once.
+//
+// Delivery never blocks: when a consumer falls behind, items are dropped and
+// counted, and polling slows down until the consumer catches up.
+type Feed struct {
+	client          *valr.Client
+	pairs           []string
+	conn            *streaming.Conn
+	hub             *streaming.Hub
+	books           *streaming.BookKeeper
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	staleAfter      time.Duration
+	buffer          int
+	bus             *eventbus.Bus
+
+	trades     chan valr.TradeHistoryInfo
+	tickers    chan Ticker
+	tradeSubs  fanout[valr.TradeHistoryInfo]
+	tickerSubs fanout[Ticker]
+	source     atomic.Value
+	dropped    atomic.Uint64
+
+	// Only accessed by Run.
+	seen      map[string]*tradeSet
+	lastTrade map[string]time.Time
+}
+
+// NewFeed returns a feed of pairs, polling with cl. Call Run to start it.
+func NewFeed(cl *valr.Client, pairs []string, opts ...FeedOption) *Feed {
+	f := &Feed{
+		client:          cl,
+		pairs:           pairs,
+		pollInterval:    defaultFeedPollInterval,
+		maxPollInterval: defaultFeedMaxPollInterval,
+		staleAfter:      defaultFeedStaleAfter,
+		buffer:          defaultFeedBuffer,
+		seen:            make(map[string]*tradeSet),
+		lastTrade:       make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.trades = make(chan valr.TradeHistoryInfo, f.buffer)
+	f.tickers = make(chan Ticker, f.buffer)
+	f.source.Store("")
+	return f
+}
+
+// Trades delivers the trades of all pairs, oldest first per pair. It is
+// closed when Run returns. Use SubscribeTrades for a single pair.
+func (f *Feed) Trades() <-chan valr.TradeHistoryInfo {
+	return f.trades
+}
+
+// Tickers delivers tickers. It is closed when Run returns.
+func (f *Feed) Tickers() <-chan Ticker {
+	return f.tickers
+}
+
+// Source returns the source currently serving the feed, SourceStream or
+// SourceREST, or empty before Run.
+func (f *Feed) Source() string {
+	return f.source.Load().(string)
+}
+
+// Dropped returns the number of trades and tickers dropped because the
+// consumer fell behind.
+func (f *Feed) Dropped() uint64 {
+	return f.dropped.Load()
+}
+
+// healthy returns true if the stream has been heard from recently.
+func (f *Feed) healthy(now time.Time) bool {
+	if f.conn == nil || f.conn.IsClosed() {
+		return false
+	}
+	last := f.conn.LastMessage()
+	return !last.IsZero() && now.Sub(last) < f.staleAfter
+}
+
+// Run serves the feed until ctx is done. Only trades made after Run starts
+// are delivered.
+func (f *Feed) Run(ctx context.Context) error {
+	defer close(f.tickers)
+	defer close(f.trades)
+	defer f.tickerSubs.close()
+	defer f.tradeSubs.close()
+
+	start := time.Now()
+	for _, pair := range f.pairs {
+		f.lastTrade[pair] = start
+	}
+	var updates <-chan streaming.MessageTradeUpdate
+	if f.hub != nil {
+		sub := f.hub.Subscribe(f.pairs, f.buffer)
+		defer sub.Unsubscribe()
+		updates = sub.C
+	}
+
+	interval := f.pollInterval
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case u, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			f.source.Store(SourceStream)
+			f.streamTrade(u)
+		case now := <-timer.C:
+			if f.healthy(now) {
+				f.source.Store(SourceStream)
+				interval = f.pollInterval
+				timer.Reset(f.pollInterval)
+				continue
+			}
+			if f.Source() != SourceREST {
+				log.Printf("valr/marketdata: Stream unavailable, polling REST")
+			}
+			f.source.Store(SourceREST)
+			err := f.poll(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case errors.Is(err, valr.ErrTooManyRequests), len(f.trades) > cap(f.trades)/2:
+				interval = min(interval*2, f.maxPollInterval)
+			case err != nil:
+				log.Printf("valr/marketdata: Failed to poll: %v", err)
+			default:
+				interval = f.pollInterval
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// streamTrade delivers a trade received from the stream and the ticker it
+// implies.
+func (f *Feed) streamTrade(u streaming.MessageTradeUpdate) {
+	t := tradeFromUpdate(u)
+	if f.deliverTrade(t) {
+		f.deliverTicker(tickerFromTrade(f.books, t))
+	}
+}
+
+// poll fetches recent trades and the market summaries of the pairs.
+func (f *Feed) poll(ctx context.Context) error {
+	for _, pair := range f.pairs {
+		res, err := f.client.GetAuthTradeHistoryForPairRequest(ctx, &valr.GetAuthTradeHistoryForPairRequest{Pair: pair, Limit: 100, StartTime: f.lastTrade[pair]})
+		if err != nil {
+			return err
+		}
+		// Trades are returned newest first.
+		for i := len(res) - 1; i >= 0; i-- {
+			t := res[i]
+			if t.Pair == "" {
+				t.Pair = pair
+			}
+			if !t.TradedAt.Before(f.lastTrade[pair]) {
+				f.deliverTrade(t)
+			}
+		}
+	}
+	summaries, err := f.client.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{})
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool, len(f.pairs))
+	for _, pair := range f.pairs {
+		want[pair] = true
+	}
+	for _, s := range summaries {
+		if want[s.Pair] {
+			t := TickerFromSummary(s)
+			if t.Time.IsZero() {
+				t.Time = time.Now()
+			}
+			f.deliverTicker(t)
+		}
+	}
+	return nil
+}
+
+// deliverTrade delivers t unless it was already delivered, and returns
+// whether it was new.
+func (f *Feed) deliverTrade(t valr.TradeHistoryInfo) bool {
+	seen, ok := f.seen[t.Pair]
+	if !ok {
+		seen = newTradeSet(feedSeenTrades)
+		f.seen[t.Pair] = seen
+	}
+	if t.ID != "" && !seen.add(t.ID) {
+		return false
+	}
+	if t.TradedAt.After(f.lastTrade[t.Pair]) {
+		f.lastTrade[t.Pair] = t.TradedAt.Time
+	}
+	select {
+	case f.trades <- t:
+	default:
+		f.dropped.Add(1)
+	}
+	f.tradeSubs.publish(t.Pair, t)
+	f.bus.Publish(eventbus.Trade{
+		Header:    eventbus.Header{Source: f.Source(), Time: t.TradedAt.Time},
+		TradeID:   t.ID,
+		Pair:      t.Pair,
+		Price:     t.Price,
+		Quantity:  t.Quantity,
+		TakerSide: string(t.TakerSide),
+	})
+	return true
+}
+
+func (f *Feed) deliverTicker(t Ticker) {
+	select {
+	case f.tickers <- t:
+	default:
+		f.dropped.Add(1)
+	}
+	f.tickerSubs.publish(t.Pair, t)
+	f.bus.Publish(eventbus.Tick{
+		Header: eventbus.Header{Source: f.Source(), Time: t.Time},
+		Pair:   t.Pair,
+		Bid:    t.Bid,
+		Ask:    t.Ask,
+		Last:   t.Last,
+	})
+}
+
+// SubscribeTicker implements Provider for the feed's pairs.
+func (f *Feed) SubscribeTicker(ctx context.Context, pair string) (<-chan Ticker, error) {
+	return f.tickerSubs.subscribe(ctx, pair, 1), nil
+}
+
+// SubscribeOrderBook implements Provider if the feed has books.
+func (f *Feed) SubscribeOrderBook(ctx context.Context, pair string, depth int) (<-chan streaming.TopN, error) {
+	return subscribeBook(ctx, f.books, pair, depth)
+}
+
+// SubscribeTrades implements Provider for the feed's pairs.
+func (f *Feed) SubscribeTrades(ctx context.Context, pair string) (<-chan valr.TradeHistoryInfo, error) {
+	return f.tradeSubs.subscribe(ctx, pair, f.buffer), nil
+}
+
+// SubscribeCandles implements Provider for the feed's pairs.
+func (f *Feed) SubscribeCandles(ctx context.Context, pair string, interval time.Duration) (<-chan Candle, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	return candlesFromTrades(ctx, f.tradeSubs.subscribe(ctx, pair, f.buffer), interval), nil
+}
+
+// tradeSet remembers the most recent trade IDs added to it.
+type tradeSet struct {
+	ids  map[string]bool
+	ring []string
+	next int
+}
+
+func newTradeSet(size int) *tradeSet {
+	return &tradeSet{ids: make(map[string]bool, size), ring: make([]string, size)}
+}
+
+// add returns false if id is already in the set.
+func (s *tradeSet) add(id string) bool {
+	if s.ids[id] {
+		return false
+	}
+	if old := s.ring[s.next]; old != "" {
+		delete(s.ids, old)
+	}
+	s.ring[s.next] = id
+	s.next = (s.next + 1) % len(s.ring)
+	s.ids[id] = true
+	return true
+}
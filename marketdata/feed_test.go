@@ -0,0 +1,78 @@
+package marketdata_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+)
+
+func TestFeedPollsWithoutStream(t *testing.T) {
+	start := time.Now()
+	var (
+		mu     sync.Mutex
+		trades = []string{tradeJSON("0", start.Add(-time.Hour))}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/marketsummary") {
+			w.Write([]byte(`[{"currencyPair":"BTCZAR","bidPrice":"99","askPrice":"101","lastTradedPrice":"100"},{"currencyPair":"ETHZAR"}]`))
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// Newest first, and add a trade on every poll.
+		trades = append([]string{tradeJSON(fmt.Sprint(len(trades)), time.Now())}, trades...)
+		fmt.Fprintf(w, "[%s]", strings.Join(trades, ","))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	feed := marketdata.NewFeed(cl, []string{"BTCZAR"}, marketdata.WithPollInterval(time.Millisecond, 10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- feed.Run(ctx) }()
+
+	var ids []string
+	for len(ids) < 3 {
+		select {
+		case tr := <-feed.Trades():
+			ids = append(ids, tr.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for trades, got %v", ids)
+		}
+	}
+	tk := <-feed.Tickers()
+	cancel()
+	<-done
+
+	if strings.Join(ids, ",") != "1,2,3" {
+		t.Errorf("Expected each new trade once, in order, got %v", ids)
+	}
+	if tk.Pair != "BTCZAR" || tk.Mid().String() != "100" {
+		t.Errorf("Unexpected ticker %+v", tk)
+	}
+	if feed.Source() != marketdata.SourceREST {
+		t.Errorf("Expected source %q, got %q", marketdata.SourceREST, feed.Source())
+	}
+	if _, ok := <-feed.Trades(); ok {
+		t.Errorf("Expected trades to be closed")
+	}
+}
+
+func tradeJSON(id string, at time.Time) string {
+	return fmt.Sprintf(`{"id":%q,"currencyPair":"BTCZAR","price":"100","quantity":"1","takerSide":"buy","tradedAt":%q}`,
+		id, at.UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,296 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+// ErrNoOrderBook is returned by providers without order books.
+var ErrNoOrderBook = errors.New("marketdata: order books not available")
+
+const defaultProviderBuffer = 256
+
+// Provider is a source of market data subscriptions, so strategies can be
+// written once and run live, on the REST fallback Feed or on a Replay of
+// history. Each subscription delivers until ctx is done and then closes its
+// channel. Tickers and books are conflated to the latest value. Live
+// providers drop trades for subscribers that fall behind rather than block.
+type Provider interface {
+	// SubscribeTicker delivers the pair's ticker as it changes.
+	SubscribeTicker(ctx context.Context, pair string) (<-chan Ticker, error)
+	// SubscribeOrderBook delivers the best depth levels of each side of
+	// the pair's book as they change.
+	SubscribeOrderBook(ctx context.Context, pair string, depth int) (<-chan streaming.TopN, error)
+	// SubscribeTrades delivers the pair's trades.
+	SubscribeTrades(ctx context.Context, pair string) (<-chan valr.TradeHistoryInfo, error)
+	// SubscribeCandles delivers the pair's candles of the given interval,
+	// built from its trades, as each interval completes.
+	SubscribeCandles(ctx context.Context, pair string, interval time.Duration) (<-chan Candle, error)
+}
+
+var (
+	_ Provider = (*Live)(nil)
+	_ Provider = (*Feed)(nil)
+	_ Provider = (*Replay)(nil)
+)
+
+// Live is a Provider serving market data from the websocket.
+type Live struct {
+	hub   *streaming.Hub
+	books *streaming.BookKeeper
+}
+
+// NewLive returns a Provider serving trades from hub and books from books,
+// which may be nil if order books are not needed. Tickers carry the bid and
+// ask from books, if set, and the price of the last trade.
+func NewLive(hub *streaming.Hub, books *streaming.BookKeeper) *Live {
+	return &Live{hub: hub, books: books}
+}
+
+// SubscribeTicker implements Provider.
+func (l *Live) SubscribeTicker(ctx context.Context, pair string) (<-chan Ticker, error) {
+	sub := l.hub.Subscribe([]string{pair}, defaultProviderBuffer)
+	out := make(chan Ticker, 1)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				conflate(out, tickerFromTrade(l.books, tradeFromUpdate(u)))
+			}
+		}
+	}()
+	return out, nil
+}
+
+// SubscribeOrderBook implements Provider.
+func (l *Live) SubscribeOrderBook(ctx context.Context, pair string, depth int) (<-chan streaming.TopN, error) {
+	return subscribeBook(ctx, l.books, pair, depth)
+}
+
+// SubscribeTrades implements Provider.
+func (l *Live) SubscribeTrades(ctx context.Context, pair string) (<-chan valr.TradeHistoryInfo, error) {
+	sub := l.hub.Subscribe([]string{pair}, defaultProviderBuffer)
+	out := make(chan valr.TradeHistoryInfo, defaultProviderBuffer)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				select {
+				case out <- tradeFromUpdate(u):
+				default:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// SubscribeCandles implements Provider.
+func (l *Live) SubscribeCandles(ctx context.Context, pair string, interval time.Duration) (<-chan Candle, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	trades, err := l.SubscribeTrades(ctx, pair)
+	if err != nil {
+		return nil, err
+	}
+	return candlesFromTrades(ctx, trades, interval), nil
+}
+
+func tradeFromUpdate(u streaming.MessageTradeUpdate) valr.TradeHistoryInfo {
+	pair := u.CurrencyPairSymbol
+	if pair == "" {
+		pair = u.Data.CurrencyPair
+	}
+	return valr.TradeHistoryInfo{
+		Price:     u.Data.Price,
+		Quantity:  u.Data.Quantity,
+		Pair:      pair,
+		TradedAt:  u.Data.TradedAt,
+		TakerSide: valr.ResponseSide(u.Data.TakerSide),
+		ID:        u.Data.ID,
+	}
+}
+
+// tickerFromTrade returns the ticker implied by a trade and, if books is set,
+// the top of the pair's book.
+func tickerFromTrade(books *streaming.BookKeeper, t valr.TradeHistoryInfo) Ticker {
+	tk := Ticker{Pair: t.Pair, Last: t.Price, Time: t.TradedAt.Time}
+	if books != nil {
+		if bids, asks := books.Book(t.Pair).TopN(1); len(bids) > 0 && len(asks) > 0 {
+			tk.Bid, tk.Ask = bids[0].Price, asks[0].Price
+		}
+	}
+	return tk
+}
+
+func subscribeBook(ctx context.Context, books *streaming.BookKeeper, pair string, depth int) (<-chan streaming.TopN, error) {
+	if books == nil {
+		return nil, ErrNoOrderBook
+	}
+	sub := books.SubscribeTopN(pair, depth)
+	out := make(chan streaming.TopN, 1)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v := <-sub.C:
+				conflate(out, v)
+			}
+		}
+	}()
+	return out, nil
+}
+
+// candlesFromTrades aggregates trades into candles, delivering each once a
+// trade in a later interval arrives. If trades is closed before ctx is done,
+// e.g. at the end of a replay, the last candle is delivered too.
+func candlesFromTrades(ctx context.Context, trades <-chan valr.TradeHistoryInfo, interval time.Duration) <-chan Candle {
+	out := make(chan Candle, defaultProviderBuffer)
+	go func() {
+		defer close(out)
+		var cur *Candle
+		for t := range trades {
+			start := t.TradedAt.UTC().Truncate(interval)
+			if cur != nil && start.After(cur.Start) {
+				send(out, *cur)
+				cur = nil
+			}
+			if cur == nil {
+				c := newCandle(t, start, interval)
+				cur = &c
+			}
+			cur.add(t)
+		}
+		if cur != nil && ctx.Err() == nil {
+			send(out, *cur)
+		}
+	}()
+	return out
+}
+
+// send delivers v unless ch is full.
+func send[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
+	}
+}
+
+// conflate delivers v to a channel with a buffer of one, replacing any
+// undelivered value.
+func conflate[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	send(ch, v)
+}
+
+// fanout delivers values to the subscribers of their pair.
+type fanout[T any] struct {
+	mu sync.Mutex
+	// subs holds the done channel of each subscriber's context.
+	subs   map[string]map[chan T]<-chan struct{}
+	closed bool
+}
+
+// subscribe registers a subscriber to pair with the given buffer, which
+// conflates values if it is one, until ctx is done or the fanout is closed.
+func (f *fanout[T]) subscribe(ctx context.Context, pair string, buffer int) <-chan T {
+	ch := make(chan T, buffer)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(ch)
+		return ch
+	}
+	if f.subs == nil {
+		f.subs = make(map[string]map[chan T]<-chan struct{})
+	}
+	if f.subs[pair] == nil {
+		f.subs[pair] = make(map[chan T]<-chan struct{})
+	}
+	f.subs[pair][ch] = ctx.Done()
+	context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subs[pair][ch]; ok {
+			delete(f.subs[pair], ch)
+			close(ch)
+		}
+	})
+	return ch
+}
+
+func (f *fanout[T]) publish(pair string, v T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs[pair] {
+		if cap(ch) == 1 {
+			conflate(ch, v)
+		} else {
+			send(ch, v)
+		}
+	}
+}
+
+// publishWait is like publish, but if wait is true it blocks until each
+// subscriber takes v, its context is done or ctx is done.
+func (f *fanout[T]) publishWait(ctx context.Context, pair string, v T, wait bool) error {
+	if !wait {
+		f.publish(pair, v)
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch, done := range f.subs[pair] {
+		select {
+		case ch <- v:
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// close closes all subscribers, and later subscribers immediately.
+func (f *fanout[T]) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for _, subs := range f.subs {
+		for ch := range subs {
+			close(ch)
+		}
+	}
+	f.subs = nil
+}
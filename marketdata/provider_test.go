@@ -0,0 +1,69 @@
+package marketdata_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+func TestReplay(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	trade := func(pair string, offset time.Duration, price string) valr.TradeHistoryInfo {
+		return valr.TradeHistoryInfo{
+			Pair: pair, TradedAt: valr.NewTimestamp(at.Add(offset)),
+			Price: decimal.RequireFromString(price), Quantity: decimal.New(1, 0),
+		}
+	}
+	var p marketdata.Provider = marketdata.NewReplay([]valr.TradeHistoryInfo{
+		trade("BTCZAR", 90*time.Second, "120"),
+		trade("BTCZAR", 10*time.Second, "100"),
+		trade("ETHZAR", 20*time.Second, "10"),
+		trade("BTCZAR", 30*time.Second, "90"),
+	})
+
+	ctx := context.Background()
+	trades, _ := p.SubscribeTrades(ctx, "BTCZAR")
+	candles, err := p.SubscribeCandles(ctx, "BTCZAR", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	tickers, _ := p.SubscribeTicker(ctx, "BTCZAR")
+	if _, err := p.SubscribeOrderBook(ctx, "BTCZAR", 5); !errors.Is(err, marketdata.ErrNoOrderBook) {
+		t.Errorf("Expected ErrNoOrderBook, got %v", err)
+	}
+	if _, err := p.SubscribeCandles(ctx, "BTCZAR", 0); !errors.Is(err, marketdata.ErrInvalidInterval) {
+		t.Errorf("Expected ErrInvalidInterval, got %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- p.(*marketdata.Replay).Run(ctx) }()
+
+	var prices []string
+	for tr := range trades {
+		prices = append(prices, tr.Price.String())
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(prices) != 3 || prices[0] != "100" || prices[1] != "90" || prices[2] != "120" {
+		t.Errorf("Expected BTCZAR trades in time order, got %v", prices)
+	}
+
+	var got []marketdata.Candle
+	for c := range candles {
+		got = append(got, c)
+	}
+	if len(got) != 2 || !got[0].High.Equal(decimal.New(100, 0)) || !got[0].Low.Equal(decimal.New(90, 0)) || got[1].Trades != 1 {
+		t.Errorf("Unexpected candles %+v", got)
+	}
+
+	tk, ok := <-tickers
+	if !ok || tk.Last.String() != "120" {
+		t.Errorf("Expected the last ticker, got %+v", tk)
+	}
+}
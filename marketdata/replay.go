@@ -0,0 +1,113 @@
+package marketdata
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+type ReplayOption func(*Replay)
+
+// WithReplaySpeed paces the replay at speed times the original rate, e.g. 60
+// replays an hour of trades in a minute. By default trades are replayed as
+// fast as subscribers take them.
+func WithReplaySpeed(speed float64) ReplayOption {
+	return func(r *Replay) {
+		r.speed = speed
+	}
+}
+
+// WithReplayClock sets the clock a paced replay waits on, clock.Real by
+// default.
+func WithReplayClock(c clock.Clock) ReplayOption {
+	return func(r *Replay) {
+		r.clock = c
+	}
+}
+
+// Replay is a Provider replaying historical trades, for backtests. Tickers
+// carry the price of the last trade only, and order books are not
+// available.
+type Replay struct {
+	trades []valr.TradeHistoryInfo
+	speed  float64
+	clock  clock.Clock
+
+	tradeSubs  fanout[valr.TradeHistoryInfo]
+	tickerSubs fanout[Ticker]
+}
+
+// NewReplay returns a Provider replaying trades, which may be in any order
+// and for several pairs. Subscribe before calling Run.
+func NewReplay(trades []valr.TradeHistoryInfo, opts ...ReplayOption) *Replay {
+	sorted := append([]valr.TradeHistoryInfo(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TradedAt.Before(sorted[j].TradedAt.Time)
+	})
+	r := &Replay{trades: sorted, clock: clock.Real}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run replays the trades in time order and then closes every subscription.
+// Without a speed, each trade is delivered to every subscriber before the
+// next, so none are dropped.
+func (r *Replay) Run(ctx context.Context) error {
+	defer r.tickerSubs.close()
+	defer r.tradeSubs.close()
+
+	var (
+		first time.Time
+		start = r.clock.Now()
+	)
+	for i, t := range r.trades {
+		if i == 0 {
+			first = t.TradedAt.Time
+		}
+		if r.speed > 0 {
+			due := start.Add(time.Duration(float64(t.TradedAt.Sub(first)) / r.speed))
+			timer := r.clock.NewTimer(due.Sub(r.clock.Now()))
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		if err := r.tradeSubs.publishWait(ctx, t.Pair, t, r.speed <= 0); err != nil {
+			return err
+		}
+		r.tickerSubs.publish(t.Pair, Ticker{Pair: t.Pair, Last: t.Price, Time: t.TradedAt.Time})
+	}
+	return nil
+}
+
+// SubscribeTicker implements Provider.
+func (r *Replay) SubscribeTicker(ctx context.Context, pair string) (<-chan Ticker, error) {
+	return r.tickerSubs.subscribe(ctx, pair, 1), nil
+}
+
+// SubscribeOrderBook implements Provider; replays have no order books.
+func (r *Replay) SubscribeOrderBook(context.Context, string, int) (<-chan streaming.TopN, error) {
+	return nil, ErrNoOrderBook
+}
+
+// SubscribeTrades implements Provider.
+func (r *Replay) SubscribeTrades(ctx context.Context, pair string) (<-chan valr.TradeHistoryInfo, error) {
+	return r.tradeSubs.subscribe(ctx, pair, defaultProviderBuffer), nil
+}
+
+// SubscribeCandles implements Provider. The last candle is delivered at the
+// end of the replay.
+func (r *Replay) SubscribeCandles(ctx context.Context, pair string, interval time.Duration) (<-chan Candle, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	return candlesFromTrades(ctx, r.tradeSubs.subscribe(ctx, pair, defaultProviderBuffer), interval), nil
+}
@@ -0,0 +1,136 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrIntervalMismatch is returned when candle series can't be combined
+// because of their intervals.
+var ErrIntervalMismatch = errors.New("marketdata: candle interval mismatch")
+
+// Resample combines candles into candles of a longer interval, such as 1m
+// into 5m or 1h. interval must be a multiple of the candles' interval.
+// Candles must be sorted by pair, then start time, as returned by
+// BuildCandles, and built with the same location as passed to Resample.
+func Resample(candles []Candle, interval time.Duration, opts ...CandleOption) ([]Candle, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	o := newCandleOptions(opts)
+	var out []Candle
+	for _, c := range candles {
+		if c.Interval <= 0 || interval%c.Interval != 0 {
+			return nil, fmt.Errorf("%w: %v is not a multiple of %v", ErrIntervalMismatch, interval, c.Interval)
+		}
+		start := alignStart(c.Start, interval, o.location)
+		n := len(out)
+		if n == 0 || out[n-1].Pair != c.Pair || !out[n-1].Start.Equal(start) {
+			c.Start, c.Interval = start, interval
+			out = append(out, c)
+			continue
+		}
+		r := &out[n-1]
+		r.High = decimal.Max(r.High, c.High)
+		r.Low = decimal.Min(r.Low, c.Low)
+		r.Close = c.Close
+		r.Volume = r.Volume.Add(c.Volume)
+		r.QuoteVolume = r.QuoteVolume.Add(c.QuoteVolume)
+		r.Trades += c.Trades
+		r.VWAP = r.Close
+		if r.Volume.IsPositive() {
+			r.VWAP = r.QuoteVolume.DivRound(r.Volume, 16)
+		}
+	}
+	return out, nil
+}
+
+// FillGaps inserts a candle for every empty interval between a pair's
+// candles, carrying the previous close forward with zero volume. Candles
+// must be sorted by pair, then start time.
+func FillGaps(candles []Candle) []Candle {
+	var out []Candle
+	for i, c := range candles {
+		if i > 0 && candles[i-1].Pair == c.Pair {
+			prev := candles[i-1]
+			for t := prev.End(); t.Before(c.Start); t = t.Add(prev.Interval) {
+				out = append(out, flat(prev, t))
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// flat returns an empty candle at start priced at prev's close.
+func flat(prev Candle, start time.Time) Candle {
+	return Candle{
+		Pair:     prev.Pair,
+		Start:    start,
+		Interval: prev.Interval,
+		Open:     prev.Close,
+		High:     prev.Close,
+		Low:      prev.Close,
+		Close:    prev.Close,
+		VWAP:     prev.Close,
+	}
+}
+
+// Align puts candle series, keyed by name and each sorted by start time, on
+// a common time index. The index runs from the latest first candle of any
+// series, so every series has a price throughout, to the latest candle of
+// any series. Missing candles are forward-filled as by FillGaps. All series
+// must share an interval.
+func Align(series map[string][]Candle) ([]time.Time, map[string][]Candle, error) {
+	var (
+		interval   time.Duration
+		start, end time.Time
+	)
+	for name, s := range series {
+		if len(s) == 0 {
+			return nil, nil, fmt.Errorf("marketdata: series %s is empty", name)
+		}
+		if interval == 0 {
+			interval = s[0].Interval
+			start, end = s[0].Start, s[len(s)-1].Start
+		}
+		for _, c := range s {
+			if c.Interval != interval {
+				return nil, nil, fmt.Errorf("%w: %s has %v, expected %v", ErrIntervalMismatch, name, c.Interval, interval)
+			}
+		}
+		if first := s[0].Start; first.After(start) {
+			start = first
+		}
+		if last := s[len(s)-1].Start; last.After(end) {
+			end = last
+		}
+	}
+
+	var index []time.Time
+	for t := start; !t.After(end); t = t.Add(interval) {
+		index = append(index, t)
+	}
+	aligned := make(map[string][]Candle, len(series))
+	for name, s := range series {
+		out := make([]Candle, 0, len(index))
+		i := 0
+		var prev Candle
+		for _, t := range index {
+			for i < len(s) && !s[i].Start.After(t) {
+				prev = s[i]
+				i++
+			}
+			if prev.Start.Equal(t) {
+				out = append(out, prev)
+			} else {
+				out = append(out, flat(prev, t))
+			}
+		}
+		aligned[name] = out
+	}
+	return index, aligned, nil
+}
@@ -0,0 +1,236 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// SettlementMethod is how a settlement price is derived from trades.
+type SettlementMethod string
+
+const (
+	// SettleVWAP settles at the volume weighted average price of the trades
+	// in the window before the cutoff.
+	SettleVWAP SettlementMethod = "vwap"
+	// SettleLastTrade settles at the price of the last trade before the
+	// cutoff.
+	SettleLastTrade SettlementMethod = "last_trade"
+)
+
+const (
+	defaultSettlementNamespace = "marketdata.settlements"
+	defaultSettlementWindow    = time.Hour
+	// settlementDateFormat is the format of settlement dates, which are
+	// the local dates of the cutoffs.
+	settlementDateFormat = "2006-01-02"
+)
+
+// ErrNoSettlementTrades is returned for pairs without trades in the
+// settlement window.
+var ErrNoSettlementTrades = errors.New("marketdata: no trades in settlement window")
+
+// Settlement is a pair's reference price at a daily cutoff, e.g. for NAV
+// and margin calculations.
+type Settlement struct {
+	Pair string `json:"pair"`
+	// Date is the local date of the cutoff, formatted as 2006-01-02.
+	Date   string           `json:"date"`
+	Cutoff time.Time        `json:"cutoff"`
+	Method SettlementMethod `json:"method"`
+	Price  decimal.Decimal  `json:"price"`
+	// Volume and Trades are of the trades in the window.
+	Volume decimal.Decimal `json:"volume"`
+	Trades int             `json:"trades"`
+}
+
+type SettlerOption func(*Settler)
+
+// WithCutoff sets the daily cutoff to offset after midnight in loc, e.g.
+// 17*time.Hour in Africa/Johannesburg. The default is midnight UTC.
+func WithCutoff(offset time.Duration, loc *time.Location) SettlerOption {
+	return func(s *Settler) {
+		s.offset = offset
+		s.location = loc
+	}
+}
+
+// WithSettlementMethod sets how prices are derived, SettleVWAP by default.
+func WithSettlementMethod(m SettlementMethod) SettlerOption {
+	return func(s *Settler) {
+		s.method = m
+	}
+}
+
+// WithSettlementWindow sets how long before the cutoff trades are
+// considered, an hour by default. Illiquid pairs need a longer window to
+// have any trades.
+func WithSettlementWindow(d time.Duration) SettlerOption {
+	return func(s *Settler) {
+		s.window = d
+	}
+}
+
+// WithSettlementNamespace sets the store namespace of the settlements. The
+// default is "marketdata.settlements".
+func WithSettlementNamespace(ns string) SettlerOption {
+	return func(s *Settler) {
+		s.namespace = ns
+	}
+}
+
+// Settler records a settlement price for each of a set of pairs at a daily
+// cutoff, keeping them in a store.Store.
+type Settler struct {
+	client    *valr.Client
+	store     store.Store
+	pairs     []string
+	offset    time.Duration
+	location  *time.Location
+	method    SettlementMethod
+	window    time.Duration
+	namespace string
+}
+
+// NewSettler returns a Settler of pairs, fetching trades with cl and
+// keeping settlements in st.
+func NewSettler(cl *valr.Client, st store.Store, pairs []string, opts ...SettlerOption) *Settler {
+	s := &Settler{
+		client:    cl,
+		store:     st,
+		pairs:     pairs,
+		location:  time.UTC,
+		method:    SettleVWAP,
+		window:    defaultSettlementWindow,
+		namespace: defaultSettlementNamespace,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Cutoff returns the cutoff on the local date of t.
+func (s *Settler) Cutoff(t time.Time) time.Time {
+	y, m, d := t.In(s.location).Date()
+	// time.Date normalises the nanoseconds as wall clock time, so the
+	// cutoff keeps its local time across daylight saving changes.
+	return time.Date(y, m, d, 0, 0, 0, int(s.offset), s.location)
+}
+
+// Next returns the first cutoff after t.
+func (s *Settler) Next(t time.Time) time.Time {
+	c := s.Cutoff(t)
+	if c.After(t) {
+		return c
+	}
+	return s.Cutoff(c.AddDate(0, 0, 1))
+}
+
+// Settle records the settlements at the cutoff on the local date of t. The
+// pairs that could not be settled are reported in the error; the others
+// are settled regardless.
+func (s *Settler) Settle(ctx context.Context, t time.Time) ([]Settlement, error) {
+	cutoff := s.Cutoff(t)
+	var (
+		out  []Settlement
+		errs []error
+	)
+	for _, pair := range s.pairs {
+		st, err := s.settle(ctx, pair, cutoff)
+		if err == nil {
+			err = store.PutJSON(ctx, s.store, s.namespace, settlementKey(pair, st.Date), st)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return out, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("marketdata: settling %s: %w", pair, err))
+			continue
+		}
+		out = append(out, st)
+	}
+	return out, errors.Join(errs...)
+}
+
+func (s *Settler) settle(ctx context.Context, pair string, cutoff time.Time) (Settlement, error) {
+	trades, err := s.client.GetAuthTradeHistoryForPairRange(ctx, pair, cutoff.Add(-s.window), cutoff, s.window)
+	if err != nil {
+		return Settlement{}, err
+	}
+	st := Settlement{
+		Pair:   pair,
+		Date:   cutoff.Format(settlementDateFormat),
+		Cutoff: cutoff,
+		Method: s.method,
+	}
+	var notional decimal.Decimal
+	var last valr.TradeHistoryInfo
+	for _, t := range trades {
+		// The range is inclusive, but trades at the cutoff belong to the
+		// next day.
+		if !t.TradedAt.Before(cutoff) {
+			continue
+		}
+		st.Trades++
+		st.Volume = st.Volume.Add(t.Quantity)
+		notional = notional.Add(t.Price.Mul(t.Quantity))
+		if !t.TradedAt.Before(last.TradedAt.Time) {
+			last = t
+		}
+	}
+	if st.Trades == 0 {
+		return Settlement{}, ErrNoSettlementTrades
+	}
+	switch s.method {
+	case SettleVWAP:
+		if st.Volume.IsZero() {
+			return Settlement{}, ErrNoSettlementTrades
+		}
+		st.Price = notional.Div(st.Volume)
+	case SettleLastTrade:
+		st.Price = last.Price
+	default:
+		return Settlement{}, fmt.Errorf("marketdata: unknown settlement method %q", s.method)
+	}
+	return st, nil
+}
+
+// Settlement returns the stored settlement of pair on the local date of t,
+// or store.ErrNotFound.
+func (s *Settler) Settlement(ctx context.Context, pair string, t time.Time) (*Settlement, error) {
+	var st Settlement
+	date := s.Cutoff(t).Format(settlementDateFormat)
+	if err := store.GetJSON(ctx, s.store, s.namespace, settlementKey(pair, date), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Run settles the pairs at every cutoff until ctx is done. Failures are
+// logged; settlements that failed can be recorded later with Settle.
+func (s *Settler) Run(ctx context.Context) error {
+	for {
+		next := s.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if _, err := s.Settle(ctx, next); err != nil && ctx.Err() == nil {
+			log.Printf("valr/marketdata: Failed to settle at %s: %v", next, err)
+		}
+	}
+}
+
+func settlementKey(pair, date string) string {
+	return pair + "/" + date
+}
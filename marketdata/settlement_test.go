@@ -0,0 +1,102 @@
+package marketdata_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// tradeServer serves the trades of BTCZAR within the requested range,
+// newest first.
+func tradeServer(t *testing.T, trades []valr.TradeHistoryInfo) *valr.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		items := []valr.TradeHistoryInfo{}
+		if strings.Contains(r.URL.Path, "/BTCZAR/") && (q.Get("skip") == "" || q.Get("skip") == "0") {
+			start, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+			end, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+			for i := len(trades) - 1; i >= 0; i-- {
+				if tr := trades[i]; !tr.TradedAt.Before(start) && !tr.TradedAt.After(end) {
+					items = append(items, tr)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl
+}
+
+func TestSettler(t *testing.T) {
+	sast := time.FixedZone("SAST", 2*60*60)
+	cutoff := time.Date(2024, 1, 2, 17, 0, 0, 0, sast)
+	trade := func(id string, before time.Duration, price, qty string) valr.TradeHistoryInfo {
+		return valr.TradeHistoryInfo{
+			ID: id, Pair: "BTCZAR", TradedAt: valr.NewTimestamp(cutoff.Add(-before)),
+			Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString(qty),
+		}
+	}
+	cl := tradeServer(t, []valr.TradeHistoryInfo{
+		trade("t1", 2*time.Hour, "50", "1"),
+		trade("t2", 40*time.Minute, "100", "1"),
+		trade("t3", 10*time.Minute, "130", "2"),
+		trade("t4", 0, "999", "1"),
+	})
+
+	st := store.NewMemory()
+	s := marketdata.NewSettler(cl, st, []string{"BTCZAR", "ETHZAR"}, marketdata.WithCutoff(17*time.Hour, sast))
+	if next := s.Next(cutoff.Add(-time.Minute)); !next.Equal(cutoff) {
+		t.Errorf("Expected %v, got %v", cutoff, next)
+	}
+	if next := s.Next(cutoff); !next.Equal(cutoff.AddDate(0, 0, 1)) {
+		t.Errorf("Expected the next day, got %v", next)
+	}
+
+	settled, err := s.Settle(context.Background(), cutoff)
+	if !errors.Is(err, marketdata.ErrNoSettlementTrades) {
+		t.Errorf("Expected ETHZAR to have no trades, got %v", err)
+	}
+	if len(settled) != 1 {
+		t.Fatalf("Expected a settlement, got %+v", settled)
+	}
+	// The VWAP of t2 and t3: (100 + 260) / 3.
+	if got := settled[0]; got.Price.String() != "120" || got.Trades != 2 || got.Date != "2024-01-02" {
+		t.Errorf("Expected 120 from 2 trades on 2024-01-02, got %+v", got)
+	}
+
+	stored, err := s.Settlement(context.Background(), "BTCZAR", cutoff)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !stored.Price.Equal(settled[0].Price) || !stored.Cutoff.Equal(cutoff) {
+		t.Errorf("Expected %+v, got %+v", settled[0], stored)
+	}
+
+	last := marketdata.NewSettler(cl, st, []string{"BTCZAR"},
+		marketdata.WithCutoff(17*time.Hour, sast),
+		marketdata.WithSettlementMethod(marketdata.SettleLastTrade),
+		marketdata.WithSettlementNamespace("last"))
+	settled, err = last.Settle(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if got := settled[0].Price.String(); got != "130" {
+		t.Errorf("Expected 130, got %s", got)
+	}
+}
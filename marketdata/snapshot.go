@@ -0,0 +1,146 @@
+package marketdata
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+// snapshotVersion is the version of the snapshot format written.
+const snapshotVersion = 1
+
+// SnapshotFormat is the encoding of a written snapshot.
+type SnapshotFormat int
+
+const (
+	// SnapshotGob is compact and fast, for warm starts of the same build.
+	SnapshotGob SnapshotFormat = iota
+	// SnapshotJSON can be inspected and read by other tools.
+	SnapshotJSON
+)
+
+// State is the in-memory market state of a process. Any part may be nil.
+type State struct {
+	Books   *streaming.BookKeeper
+	Tickers *TickerStore
+	Candles *HistoryCache
+}
+
+// CandleSeries is the candles of a pair over a range, as cached by a
+// HistoryCache.
+type CandleSeries struct {
+	Pair     string
+	From, To time.Time
+	Interval time.Duration
+	// Location is the name of the location the candles are aligned to.
+	Location string
+	Candles  []Candle
+}
+
+// MarketSnapshot is a copy of the market state of a process, so a restarted
+// process can warm-start from it within seconds instead of waiting for its
+// feeds to rebuild everything.
+type MarketSnapshot struct {
+	Version int
+	Taken   time.Time
+	Books   []streaming.BookSnapshot
+	Tickers []Ticker
+	Candles []CandleSeries
+}
+
+// Snapshot copies the books, tickers and cached candles of s.
+func Snapshot(s State) *MarketSnapshot {
+	snap := &MarketSnapshot{Version: snapshotVersion, Taken: time.Now()}
+	if s.Books != nil {
+		for _, pair := range s.Books.Pairs() {
+			if b := s.Books.Book(pair); b.Synced() {
+				snap.Books = append(snap.Books, b.Snapshot())
+			}
+		}
+	}
+	if s.Tickers != nil {
+		s.Tickers.mu.RLock()
+		for _, t := range s.Tickers.tickers {
+			snap.Tickers = append(snap.Tickers, t)
+		}
+		s.Tickers.mu.RUnlock()
+		sort.Slice(snap.Tickers, func(i, j int) bool {
+			return snap.Tickers[i].Pair < snap.Tickers[j].Pair
+		})
+	}
+	if s.Candles != nil {
+		snap.Candles = s.Candles.candleSeries()
+	}
+	return snap
+}
+
+// Restore loads snap into s. Books restored are marked synced but keep the
+// time of their last update, so staleness checks still apply, and are
+// replaced by the first snapshot the stream delivers. Parts of snap that s
+// has no holder for are skipped.
+func Restore(s State, snap *MarketSnapshot) error {
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("marketdata: unsupported snapshot version %d", snap.Version)
+	}
+	for _, cs := range snap.Candles {
+		if _, err := time.LoadLocation(cs.Location); err != nil {
+			return fmt.Errorf("marketdata: candles of %s: %w", cs.Pair, err)
+		}
+	}
+	if s.Books != nil {
+		for _, b := range snap.Books {
+			s.Books.Book(b.Pair).ApplySnapshot(streaming.BookUpdate{
+				Sequence: b.Sequence,
+				Bids:     b.Bids,
+				Asks:     b.Asks,
+				Time:     b.UpdatedAt,
+			})
+		}
+	}
+	if s.Tickers != nil {
+		for _, t := range snap.Tickers {
+			s.Tickers.Update(t)
+		}
+	}
+	if s.Candles != nil {
+		s.Candles.restoreCandles(snap.Candles)
+	}
+	return nil
+}
+
+// Write encodes the snapshot to w in format.
+func (snap *MarketSnapshot) Write(w io.Writer, format SnapshotFormat) error {
+	switch format {
+	case SnapshotGob:
+		return gob.NewEncoder(w).Encode(snap)
+	case SnapshotJSON:
+		return json.NewEncoder(w).Encode(snap)
+	default:
+		return fmt.Errorf("marketdata: unknown snapshot format %d", format)
+	}
+}
+
+// ReadSnapshot decodes a snapshot written in either format.
+func ReadSnapshot(r io.Reader) (*MarketSnapshot, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("marketdata: reading snapshot: %w", err)
+	}
+	snap := new(MarketSnapshot)
+	if first[0] == '{' {
+		err = json.NewDecoder(br).Decode(snap)
+	} else {
+		err = gob.NewDecoder(br).Decode(snap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marketdata: decoding snapshot: %w", err)
+	}
+	return snap, nil
+}
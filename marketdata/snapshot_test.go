@@ -0,0 +1,93 @@
+package marketdata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	at := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := []valr.TradeHistoryInfo{}
+		if q := r.URL.Query(); q.Get("skip") == "" || q.Get("skip") == "0" {
+			items = append(items, valr.TradeHistoryInfo{
+				ID: "t1", Pair: "BTCZAR", TradedAt: valr.NewTimestamp(at.Add(time.Minute)),
+				Price: decimal.New(100, 0), Quantity: decimal.New(1, 0),
+			})
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+
+	ctx := context.Background()
+	state := marketdata.State{
+		Books:   streaming.NewBookKeeper(),
+		Tickers: marketdata.NewTickerStore(),
+		Candles: marketdata.NewHistoryCache(cl),
+	}
+	state.Books.Book("BTCZAR").ApplySnapshot(streaming.BookUpdate{
+		Sequence: 7,
+		Bids:     []streaming.Level{{Price: decimal.New(99, 0), Quantity: decimal.New(2, 0), OrderCount: 1}},
+		Asks:     []streaming.Level{{Price: decimal.New(101, 0), Quantity: decimal.New(3, 0), OrderCount: 2}},
+		Time:     at,
+	})
+	state.Tickers.Update(marketdata.Ticker{Pair: "BTCZAR", Bid: decimal.New(99, 0), Ask: decimal.New(101, 0), Last: decimal.New(100, 0), Time: at})
+	if _, err := state.Candles.Candles(ctx, "BTCZAR", at, at.Add(time.Hour), time.Hour); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	for _, format := range []marketdata.SnapshotFormat{marketdata.SnapshotGob, marketdata.SnapshotJSON} {
+		var buf bytes.Buffer
+		if err := marketdata.Snapshot(state).Write(&buf, format); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		snap, err := marketdata.ReadSnapshot(&buf)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+
+		// The restored cache has no API to fall back on.
+		offline := valr.NewClient()
+		offline.SetBaseURL("http://127.0.0.1:1")
+		restored := marketdata.State{
+			Books:   streaming.NewBookKeeper(),
+			Tickers: marketdata.NewTickerStore(),
+			Candles: marketdata.NewHistoryCache(offline),
+		}
+		if err := marketdata.Restore(restored, snap); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		offline.Close()
+
+		book := restored.Books.Book("BTCZAR").Snapshot()
+		if !restored.Books.Book("BTCZAR").Synced() || book.Sequence != 7 || !book.UpdatedAt.Equal(at) ||
+			len(book.Asks) != 1 || !book.Asks[0].Quantity.Equal(decimal.New(3, 0)) || book.Asks[0].OrderCount != 2 {
+			t.Errorf("Expected the book restored, got %+v", book)
+		}
+		ticker, err := restored.Tickers.Ticker(ctx, "BTCZAR")
+		if err != nil || !ticker.Last.Equal(decimal.New(100, 0)) || !ticker.Time.Equal(at) {
+			t.Errorf("Expected the ticker restored, got %+v, %v", ticker, err)
+		}
+		candles, err := restored.Candles.Candles(ctx, "BTCZAR", at, at.Add(time.Hour), time.Hour)
+		if err != nil {
+			t.Fatalf("Expected cached candles, got %v", err)
+		}
+		if len(candles) != 1 || !candles[0].Close.Equal(decimal.New(100, 0)) {
+			t.Errorf("Expected 1 candle closing at 100, got %+v", candles)
+		}
+	}
+}
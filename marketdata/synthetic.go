@@ -0,0 +1,122 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SyntheticPair defines a pair that isn't listed directly, priced as the
+// ratio of two listed pairs sharing a quote currency. For example ETHBTC is
+// ETHZAR / BTCZAR.
+type SyntheticPair struct {
+	Pair        string
+	Numerator   string
+	Denominator string
+}
+
+// Cross returns the SyntheticPair base+quote derived via the common currency
+// via, e.g. Cross("ETH", "BTC", "ZAR") prices ETHBTC from ETHZAR and BTCZAR.
+func Cross(base, quote, via string) SyntheticPair {
+	return SyntheticPair{
+		Pair:        base + quote,
+		Numerator:   base + via,
+		Denominator: quote + via,
+	}
+}
+
+type SyntheticOption func(*SyntheticTickers)
+
+// WithMaxTickerAge sets the maximum age of either leg before a synthetic
+// ticker is reported as stale. Zero disables the staleness check.
+func WithMaxTickerAge(d time.Duration) SyntheticOption {
+	return func(s *SyntheticTickers) {
+		s.maxAge = d
+	}
+}
+
+// SyntheticTickers is a TickerSource that derives prices of synthetic pairs
+// from their legs and passes requests for any other pair through to the
+// underlying source.
+type SyntheticTickers struct {
+	source TickerSource
+	maxAge time.Duration
+
+	mu    sync.RWMutex
+	pairs map[string]SyntheticPair
+}
+
+// NewSyntheticTickers creates a source deriving the given synthetic pairs
+// from source.
+func NewSyntheticTickers(source TickerSource, pairs []SyntheticPair, opts ...SyntheticOption) *SyntheticTickers {
+	s := &SyntheticTickers{
+		source: source,
+		maxAge: time.Minute,
+		pairs:  make(map[string]SyntheticPair),
+	}
+	for _, p := range pairs {
+		s.pairs[p.Pair] = p
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add defines an additional synthetic pair.
+func (s *SyntheticTickers) Add(p SyntheticPair) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pairs[p.Pair] = p
+}
+
+// Ticker implements TickerSource. The synthetic bid is the numerator bid
+// divided by the denominator ask, i.e. the price achievable by selling
+// through both legs, and vice versa for the ask.
+func (s *SyntheticTickers) Ticker(ctx context.Context, pair string) (Ticker, error) {
+	s.mu.RLock()
+	def, ok := s.pairs[pair]
+	s.mu.RUnlock()
+	if !ok {
+		return s.source.Ticker(ctx, pair)
+	}
+
+	num, err := s.leg(ctx, def.Numerator)
+	if err != nil {
+		return Ticker{}, err
+	}
+	den, err := s.leg(ctx, def.Denominator)
+	if err != nil {
+		return Ticker{}, err
+	}
+	if !den.Bid.IsPositive() || !den.Ask.IsPositive() || !den.Last.IsPositive() {
+		return Ticker{}, fmt.Errorf("%w: %s", errZeroQuoteLeg, def.Denominator)
+	}
+
+	t := Ticker{
+		Pair: pair,
+		Bid:  num.Bid.Div(den.Ask),
+		Ask:  num.Ask.Div(den.Bid),
+		Last: num.Last.Div(den.Last),
+		Time: num.Time,
+	}
+	if den.Time.Before(t.Time) {
+		t.Time = den.Time
+	}
+	return t, nil
+}
+
+func (s *SyntheticTickers) leg(ctx context.Context, pair string) (Ticker, error) {
+	t, err := s.source.Ticker(ctx, pair)
+	if err != nil {
+		return Ticker{}, err
+	}
+	if t.Bid.IsZero() && t.Ask.IsZero() && t.Last.IsZero() {
+		return Ticker{}, fmt.Errorf("%w: %s", ErrEmptyTicker, pair)
+	}
+	if s.maxAge > 0 && time.Since(t.Time) > s.maxAge {
+		return Ticker{}, fmt.Errorf("%w: %s last updated %s ago", ErrStaleTicker, pair, time.Since(t.Time).Round(time.Second))
+	}
+	return t, nil
+}
@@ -0,0 +1,111 @@
+// Package marketdata provides market data sources built on top of the VALR
+// REST and streaming clients.
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrUnknownPair  = errors.New("marketdata: unknown pair")
+	ErrStaleTicker  = errors.New("marketdata: stale ticker")
+	ErrEmptyTicker  = errors.New("marketdata: ticker has no prices")
+	errZeroQuoteLeg = errors.New("marketdata: quote leg has zero price")
+)
+
+// Ticker is the best bid, best ask and last traded price of a pair.
+type Ticker struct {
+	Pair string
+	Bid  decimal.Decimal
+	Ask  decimal.Decimal
+	Last decimal.Decimal
+	Time time.Time
+}
+
+// Mid returns the midpoint of the bid and ask.
+func (t Ticker) Mid() decimal.Decimal {
+	return t.Bid.Add(t.Ask).Div(decimal.New(2, 0))
+}
+
+// TickerFromSummary converts a REST market summary to a Ticker.
+func TickerFromSummary(s valr.MarketSummary) Ticker {
+	return Ticker{
+		Pair: s.Pair,
+		Bid:  s.BidPrice,
+		Ask:  s.AskPrice,
+		Last: s.LastPrice,
+		Time: s.Created.Time,
+	}
+}
+
+// TickerSource provides the latest ticker of a pair.
+type TickerSource interface {
+	Ticker(ctx context.Context, pair string) (Ticker, error)
+}
+
+// RESTTickers is a TickerSource that fetches each ticker from the market
+// summary endpoint.
+type RESTTickers struct {
+	Client *valr.Client
+}
+
+// Ticker implements TickerSource.
+func (r RESTTickers) Ticker(ctx context.Context, pair string) (Ticker, error) {
+	s, err := r.Client.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: pair})
+	if err != nil {
+		return Ticker{}, err
+	}
+	t := TickerFromSummary(*s)
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+	return t, nil
+}
+
+// TickerStore is a TickerSource holding the latest ticker pushed for each
+// pair, e.g. from a streaming market summary subscription.
+type TickerStore struct {
+	mu      sync.RWMutex
+	tickers map[string]Ticker
+}
+
+// NewTickerStore creates an empty store.
+func NewTickerStore() *TickerStore {
+	return &TickerStore{tickers: make(map[string]Ticker)}
+}
+
+// Update stores t as the latest ticker of its pair.
+func (s *TickerStore) Update(t Ticker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickers[t.Pair] = t
+}
+
+// Ticker implements TickerSource.
+func (s *TickerStore) Ticker(_ context.Context, pair string) (Ticker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tickers[pair]
+	if !ok {
+		return Ticker{}, fmt.Errorf("%w: %s", ErrUnknownPair, pair)
+	}
+	return t, nil
+}
+
+// Pairs returns the pairs held in the store.
+func (s *TickerStore) Pairs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pairs := make([]string, 0, len(s.tickers))
+	for pair := range s.tickers {
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
@@ -0,0 +1,207 @@
+// Package money rounds and formats amounts by currency, so reports,
+// statements and command output agree on how many decimal places each
+// currency is shown with and how the last place is rounded.
+package money
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// FiatPlaces is the number of decimal places of fiat amounts.
+	FiatPlaces = 2
+	// CryptoPlaces is the number of decimal places of crypto amounts.
+	CryptoPlaces = 8
+)
+
+// fiat holds the fiat currencies, shown with FiatPlaces decimal places.
+var fiat = map[string]bool{
+	"ZAR": true, "USD": true, "EUR": true, "GBP": true, "AUD": true,
+	"CAD": true, "CHF": true, "JPY": true, "NGN": true, "KES": true,
+}
+
+// quoteCurrencies are the currencies pairs are quoted in, longest first so
+// that a symbol such as BTCUSDC doesn't match on a shorter suffix.
+var quoteCurrencies = []string{"USDC", "USDT", "ZAR", "BTC", "ETH", "EUR", "USD"}
+
+// IsFiat returns true for fiat currencies such as ZAR.
+func IsFiat(currency string) bool {
+	return fiat[strings.ToUpper(currency)]
+}
+
+// QuoteCurrency returns the currency a pair such as BTCZAR or BTCUSDTPERP is
+// quoted in, or an empty string if it is not recognised.
+func QuoteCurrency(pair string) string {
+	pair = strings.TrimSuffix(strings.ToUpper(pair), "PERP")
+	for _, q := range quoteCurrencies {
+		if len(pair) > len(q) && strings.HasSuffix(pair, q) {
+			return q
+		}
+	}
+	return ""
+}
+
+// Rounding is how an amount is rounded to a number of decimal places.
+type Rounding int
+
+const (
+	// HalfUp rounds halves away from zero, as on most invoices.
+	HalfUp Rounding = iota
+	// HalfEven rounds halves to the nearest even digit, known as banker's
+	// rounding, which avoids biasing totals of many rounded amounts.
+	HalfEven
+	// Down truncates towards zero, never overstating an amount.
+	Down
+)
+
+func (r Rounding) String() string {
+	switch r {
+	case HalfUp:
+		return "half_up"
+	case HalfEven:
+		return "half_even"
+	case Down:
+		return "down"
+	default:
+		return fmt.Sprintf("rounding(%d)", int(r))
+	}
+}
+
+// MarshalText encodes the mode by name, e.g. "half_even".
+func (r Rounding) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a mode encoded by MarshalText.
+func (r *Rounding) UnmarshalText(b []byte) error {
+	for _, m := range []Rounding{HalfUp, HalfEven, Down} {
+		if string(b) == m.String() {
+			*r = m
+			return nil
+		}
+	}
+	return fmt.Errorf("money: unknown rounding %q", b)
+}
+
+// Round rounds d to places decimal places using mode.
+func Round(d decimal.Decimal, places int32, mode Rounding) decimal.Decimal {
+	switch mode {
+	case HalfEven:
+		return roundHalfEven(d, places)
+	case Down:
+		return d.Truncate(places)
+	default:
+		return d.Round(places)
+	}
+}
+
+// Div divides d by d2, rounding the quotient to places decimal places using
+// mode. The rounding is exact: unlike decimal.Div, the result doesn't
+// depend on decimal.DivisionPrecision, so it is the same in every process.
+// It panics if d2 is zero.
+func Div(d, d2 decimal.Decimal, places int32, mode Rounding) decimal.Decimal {
+	// q is truncated towards zero, with d = d2*q + r.
+	q, r := d.QuoRem(d2, places)
+	if mode == Down || r.IsZero() {
+		return q
+	}
+	// Compare the remainder with half a unit of the last place, as
+	// 2|r|*10^places against |d2|.
+	c := r.Abs().Mul(decimal.New(2, places)).Cmp(d2.Abs())
+	if c < 0 || (c == 0 && mode == HalfEven && isEven(q, places)) {
+		return q
+	}
+	step := decimal.New(1, -places)
+	if d.Sign()*d2.Sign() < 0 {
+		return q.Sub(step)
+	}
+	return q.Add(step)
+}
+
+func roundHalfEven(d decimal.Decimal, places int32) decimal.Decimal {
+	truncated := d.Truncate(places)
+	rest := d.Sub(truncated).Abs()
+	half := decimal.New(5, -places-1)
+	if cmp := rest.Cmp(half); cmp < 0 || (cmp == 0 && isEven(truncated, places)) {
+		return truncated
+	}
+	step := decimal.New(1, -places)
+	if d.IsNegative() {
+		return truncated.Sub(step)
+	}
+	return truncated.Add(step)
+}
+
+// isEven returns true if the last of places decimal places of d is even.
+func isEven(d decimal.Decimal, places int32) bool {
+	digit := d.Shift(places).IntPart() % 2
+	return digit == 0
+}
+
+type Option func(*Formatter)
+
+// WithRounding sets the rounding of the formatter, HalfUp by default.
+func WithRounding(mode Rounding) Option {
+	return func(f *Formatter) {
+		f.rounding = mode
+	}
+}
+
+// WithPlaces overrides the decimal places of currency.
+func WithPlaces(currency string, places int32) Option {
+	return func(f *Formatter) {
+		f.places[strings.ToUpper(currency)] = places
+	}
+}
+
+// Formatter rounds and formats amounts to the decimal places of their
+// currency: FiatPlaces for fiat and CryptoPlaces for everything else unless
+// overridden.
+type Formatter struct {
+	rounding Rounding
+	places   map[string]int32
+}
+
+// Default formats amounts rounding halves away from zero.
+var Default = NewFormatter()
+
+// NewFormatter returns a Formatter configured by opts.
+func NewFormatter(opts ...Option) *Formatter {
+	f := &Formatter{places: make(map[string]int32)}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Places returns the number of decimal places of currency.
+func (f *Formatter) Places(currency string) int32 {
+	currency = strings.ToUpper(currency)
+	if p, ok := f.places[currency]; ok {
+		return p
+	}
+	if fiat[currency] {
+		return FiatPlaces
+	}
+	return CryptoPlaces
+}
+
+// Round rounds d to the decimal places of currency.
+func (f *Formatter) Round(currency string, d decimal.Decimal) decimal.Decimal {
+	return Round(d, f.Places(currency), f.rounding)
+}
+
+// Format rounds d to the decimal places of currency and formats it with
+// exactly that many places, e.g. "1234.50" for ZAR.
+func (f *Formatter) Format(currency string, d decimal.Decimal) string {
+	places := f.Places(currency)
+	return Round(d, places, f.rounding).StringFixed(places)
+}
+
+// Format formats d with the Default formatter.
+func Format(currency string, d decimal.Decimal) string {
+	return Default.Format(currency, d)
+}
@@ -0,0 +1,90 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+func TestRound(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		mode money.Rounding
+		want string
+	}{
+		{"1.005", money.HalfUp, "1.01"},
+		{"1.005", money.HalfEven, "1"},
+		{"1.015", money.HalfEven, "1.02"},
+		{"1.0051", money.HalfEven, "1.01"},
+		{"-1.005", money.HalfUp, "-1.01"},
+		{"-1.005", money.HalfEven, "-1"},
+		{"-1.015", money.HalfEven, "-1.02"},
+		{"1.009", money.Down, "1"},
+		{"-1.009", money.Down, "-1"},
+	} {
+		got := money.Round(decimal.RequireFromString(tc.in), 2, tc.mode)
+		if got.String() != tc.want {
+			t.Errorf("Expected %s to round to %q with %d, got %q", tc.in, tc.want, tc.mode, got)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	for _, tc := range []struct {
+		d, d2 string
+		mode  money.Rounding
+		want  string
+	}{
+		{"2", "3", money.HalfUp, "0.67"},
+		{"2", "3", money.Down, "0.66"},
+		{"1", "8", money.HalfUp, "0.13"},
+		{"1", "8", money.HalfEven, "0.12"},
+		{"3", "8", money.HalfEven, "0.38"},
+		{"-1", "8", money.HalfUp, "-0.13"},
+		{"1", "-8", money.HalfEven, "-0.12"},
+		{"-2", "3", money.Down, "-0.66"},
+		{"10", "4", money.HalfEven, "2.5"},
+	} {
+		got := money.Div(decimal.RequireFromString(tc.d), decimal.RequireFromString(tc.d2), 2, tc.mode)
+		if got.String() != tc.want {
+			t.Errorf("Expected %s/%s to be %q with %s, got %q", tc.d, tc.d2, tc.want, tc.mode, got)
+		}
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	amount := decimal.RequireFromString("1234.5678912345")
+	for _, tc := range []struct {
+		f        *money.Formatter
+		currency string
+		want     string
+	}{
+		{money.Default, "ZAR", "1234.57"},
+		{money.Default, "zar", "1234.57"},
+		{money.Default, "BTC", "1234.56789123"},
+		{money.NewFormatter(money.WithRounding(money.Down)), "ZAR", "1234.56"},
+		{money.NewFormatter(money.WithPlaces("USDC", 2)), "USDC", "1234.57"},
+	} {
+		if got := tc.f.Format(tc.currency, amount); got != tc.want {
+			t.Errorf("Expected %q for %s, got %q", tc.want, tc.currency, got)
+		}
+	}
+	if got := money.Format("ZAR", decimal.New(5, 0)); got != "5.00" {
+		t.Errorf("Expected %q, got %q", "5.00", got)
+	}
+}
+
+func TestQuoteCurrency(t *testing.T) {
+	for pair, want := range map[string]string{
+		"BTCZAR":      "ZAR",
+		"ETHBTC":      "BTC",
+		"BTCUSDC":     "USDC",
+		"BTCUSDTPERP": "USDT",
+		"ZAR":         "",
+	} {
+		if got := money.QuoteCurrency(pair); got != want {
+			t.Errorf("Expected %q for %s, got %q", want, pair, got)
+		}
+	}
+}
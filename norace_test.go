@@ -0,0 +1,5 @@
+//go:build !race
+
+package valr_test
+
+const raceEnabled = false
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailConfig configures an email sink.
+type EmailConfig struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Auth authenticates with the server, e.g. smtp.PlainAuth. It may be
+	// nil for servers that don't require authentication.
+	Auth smtp.Auth
+	From string
+	To   []string
+	// SubjectPrefix is prepended to the subject of every email, e.g.
+	// "[valr]".
+	SubjectPrefix string
+}
+
+// Email sends each message as a plain text email.
+func Email(cfg EmailConfig) Sink {
+	return SinkFunc(func(ctx context.Context, m Message) error {
+		if len(cfg.To) == 0 {
+			return errors.New("notify: email: no recipients")
+		}
+		done := make(chan error, 1)
+		go func() {
+			done <- smtp.SendMail(cfg.Addr, cfg.Auth, cfg.From, cfg.To, emailBody(cfg, m))
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("notify: email: %w", err)
+			}
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("notify: email: %w", ctx.Err())
+		}
+	})
+}
+
+func emailBody(cfg EmailConfig, m Message) []byte {
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(m.Level.String()), m.Title)
+	if cfg.SubjectPrefix != "" {
+		subject = cfg.SubjectPrefix + " " + subject
+	}
+	t := m.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", t.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	text := strings.ReplaceAll(m.String(), "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// headerSafe strips line breaks, which would otherwise allow a message to
+// inject headers.
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
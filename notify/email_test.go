@@ -0,0 +1,99 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/notify"
+)
+
+// fakeSMTP accepts a single message and returns its data.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				data <- b.String()
+				reply("250 ok")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func TestEmail(t *testing.T) {
+	addr, data := fakeSMTP(t)
+	s := notify.Email(notify.EmailConfig{
+		Addr:          addr,
+		From:          "bot@example.com",
+		To:            []string{"ops@example.com"},
+		SubjectPrefix: "[valr]",
+	})
+	m := testMessage
+	m.Title = "Breach\r\nBcc: someone@example.com"
+	if err := s.Notify(context.Background(), m); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	body := <-data
+	wantSubject := "Subject: [valr] [CRITICAL] Breach  Bcc: someone@example.com\r\n"
+	if !strings.Contains(body, wantSubject) {
+		t.Errorf("Expected %q in %q", wantSubject, body)
+	}
+	header, _, _ := strings.Cut(body, "\r\n\r\n")
+	if strings.Contains(header, "\r\nBcc:") {
+		t.Errorf("Expected no injected header in %q", body)
+	}
+	if !strings.Contains(body, "\r\n\r\n[CRITICAL] Breach") || !strings.Contains(body, "value: 60\r\nmax: 50") {
+		t.Errorf("Expected plain text body, got %q", body)
+	}
+}
+
+func TestEmailNoRecipients(t *testing.T) {
+	err := notify.Email(notify.EmailConfig{Addr: "127.0.0.1:1"}).Notify(context.Background(), testMessage)
+	if err == nil || err.Error() != "notify: email: no recipients" {
+		t.Errorf("Expected no recipients error, got %v", err)
+	}
+}
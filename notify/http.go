@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const telegramBaseURL = "https://api.telegram.org"
+
+type HTTPOption func(*httpSink)
+
+// WithHTTPClient sends requests with c rather than http.DefaultClient.
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(s *httpSink) {
+		s.client = c
+	}
+}
+
+// WithHeader sets a header on every request, e.g. for webhook
+// authentication.
+func WithHeader(key, value string) HTTPOption {
+	return func(s *httpSink) {
+		s.header.Set(key, value)
+	}
+}
+
+// WithTelegramBaseURL overrides the Telegram Bot API URL, e.g. for a local
+// Bot API server.
+func WithTelegramBaseURL(u string) HTTPOption {
+	return func(s *httpSink) {
+		if path, ok := strings.CutPrefix(s.url, telegramBaseURL); ok {
+			s.url = strings.TrimRight(u, "/") + path
+		}
+	}
+}
+
+// httpSink posts a JSON body built from each message to a URL.
+type httpSink struct {
+	name   string
+	url    string
+	client *http.Client
+	header http.Header
+	body   func(Message) any
+}
+
+func newHTTPSink(name, url string, body func(Message) any, opts []HTTPOption) *httpSink {
+	s := &httpSink{
+		name:   name,
+		url:    url,
+		client: http.DefaultClient,
+		header: make(http.Header),
+		body:   body,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *httpSink) Notify(ctx context.Context, m Message) error {
+	b, err := json.Marshal(s.body(m))
+	if err != nil {
+		return fmt.Errorf("notify: %s: %w", s.name, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("notify: %s: %w", s.name, err)
+	}
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %s: %w", s.name, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("notify: %s: %s: %s", s.name, res.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// webhookMessage is the body posted by Webhook.
+type webhookMessage struct {
+	Level string `json:"level"`
+	Message
+}
+
+// Webhook posts each message as JSON to url:
+//
+//	{"level":"critical","source":"risk","title":"...","text":"...","fields":[{"name":"...","value":"..."}],"time":"..."}
+func Webhook(url string, opts ...HTTPOption) Sink {
+	return newHTTPSink("webhook", url, func(m Message) any {
+		return webhookMessage{Level: m.Level.String(), Message: m}
+	}, opts)
+}
+
+// Slack posts each message to a Slack incoming webhook URL.
+func Slack(webhookURL string, opts ...HTTPOption) Sink {
+	return newHTTPSink("slack", webhookURL, func(m Message) any {
+		return map[string]string{"text": slackText(m)}
+	}, opts)
+}
+
+func slackText(m Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s*", slackEmoji[m.Level], m.Title)
+	if m.Source != "" {
+		fmt.Fprintf(&b, " _(%s)_", m.Source)
+	}
+	if m.Text != "" {
+		b.WriteString("\n" + m.Text)
+	}
+	for _, f := range m.Fields {
+		fmt.Fprintf(&b, "\n• %s: `%s`", f.Name, f.Value)
+	}
+	return b.String()
+}
+
+var slackEmoji = map[Level]string{
+	Info:     ":information_source:",
+	Warning:  ":warning:",
+	Critical: ":rotating_light:",
+}
+
+// Telegram sends each message to a chat through a Telegram bot.
+func Telegram(botToken, chatID string, opts ...HTTPOption) Sink {
+	url := telegramBaseURL + "/bot" + botToken + "/sendMessage"
+	return newHTTPSink("telegram", url, func(m Message) any {
+		return map[string]string{"chat_id": chatID, "text": m.String()}
+	}, opts)
+}
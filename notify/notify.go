@@ -0,0 +1,126 @@
+// Package notify delivers operational events, such as risk limit breaches,
+// unmatched deposits and order book integrity failures, to humans through
+// pluggable sinks: Slack, Telegram, email or a generic webhook.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Level is the severity of a message.
+type Level int
+
+const (
+	Info Level = iota
+	Warning
+	Critical
+)
+
+func (l Level) String() string {
+	switch l {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// Field is a named detail of a message, such as the pair of a breach.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Message is an event to notify.
+type Message struct {
+	Level Level `json:"-"`
+	// Source names the module raising the message, e.g. "risk".
+	Source string    `json:"source"`
+	Title  string    `json:"title"`
+	Text   string    `json:"text,omitempty"`
+	Fields []Field   `json:"fields,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// String renders the message as plain text, for sinks without formatting.
+func (m Message) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(m.Level.String()), m.Title)
+	if m.Source != "" {
+		fmt.Fprintf(&b, " (%s)", m.Source)
+	}
+	if m.Text != "" {
+		b.WriteString("\n" + m.Text)
+	}
+	for _, f := range m.Fields {
+		fmt.Fprintf(&b, "\n%s: %s", f.Name, f.Value)
+	}
+	return b.String()
+}
+
+// Sink delivers messages.
+type Sink interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, m Message) error
+
+func (f SinkFunc) Notify(ctx context.Context, m Message) error {
+	return f(ctx, m)
+}
+
+// Multi delivers each message to all of sinks, returning the errors of
+// those that failed.
+func Multi(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, m Message) error {
+		var errs []error
+		for _, s := range sinks {
+			if err := s.Notify(ctx, m); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// MinLevel delivers to s only messages of at least level.
+func MinLevel(s Sink, level Level) Sink {
+	return SinkFunc(func(ctx context.Context, m Message) error {
+		if m.Level < level {
+			return nil
+		}
+		return s.Notify(ctx, m)
+	})
+}
+
+// Log writes messages to the standard logger.
+var Log Sink = SinkFunc(func(ctx context.Context, m Message) error {
+	log.Printf("valr/notify: %s", strings.ReplaceAll(m.String(), "\n", "; "))
+	return nil
+})
+
+// Send delivers m to s without blocking the caller, timing out after
+// timeout. The time of m is set if zero. Failures are logged. It suits
+// modules raising messages on latency sensitive paths, such as risk checks.
+func Send(s Sink, m Message, timeout time.Duration) {
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := s.Notify(ctx, m); err != nil {
+			log.Printf("valr/notify: Failed to deliver %q: %v", m.Title, err)
+		}
+	}()
+}
@@ -0,0 +1,148 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/notify"
+)
+
+var testMessage = notify.Message{
+	Level:  notify.Critical,
+	Source: "risk",
+	Title:  "Risk limit daily_loss breached",
+	Fields: []notify.Field{{Name: "value", Value: "60"}, {Name: "max", Value: "50"}},
+	Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+// capture starts a server recording the path, headers and JSON body of the
+// last request.
+func capture(t *testing.T, status int) (*httptest.Server, *http.Request, map[string]any) {
+	t.Helper()
+	req := new(http.Request)
+	body := make(map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*req = *r
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Expected JSON body, got %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("nope"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, req, body
+}
+
+func TestWebhook(t *testing.T) {
+	srv, req, body := capture(t, http.StatusOK)
+	s := notify.Webhook(srv.URL+"/hook", notify.WithHeader("Authorization", "Bearer token"))
+	if err := s.Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if req.URL.Path != "/hook" {
+		t.Errorf("Expected %q, got %q", "/hook", req.URL.Path)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Expected %q, got %q", "Bearer token", got)
+	}
+	if body["level"] != "critical" {
+		t.Errorf("Expected %q, got %q", "critical", body["level"])
+	}
+	if body["title"] != testMessage.Title {
+		t.Errorf("Expected %q, got %q", testMessage.Title, body["title"])
+	}
+	if fields, _ := body["fields"].([]any); len(fields) != 2 {
+		t.Errorf("Expected 2 fields, got %v", body["fields"])
+	}
+}
+
+func TestSlack(t *testing.T) {
+	srv, _, body := capture(t, http.StatusOK)
+	if err := notify.Slack(srv.URL).Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	text, _ := body["text"].(string)
+	want := ":rotating_light: *Risk limit daily_loss breached* _(risk)_\n• value: `60`\n• max: `50`"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	srv, req, body := capture(t, http.StatusOK)
+	s := notify.Telegram("123:abc", "-42", notify.WithTelegramBaseURL(srv.URL+"/"))
+	if err := s.Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if req.URL.Path != "/bot123:abc/sendMessage" {
+		t.Errorf("Expected %q, got %q", "/bot123:abc/sendMessage", req.URL.Path)
+	}
+	if body["chat_id"] != "-42" {
+		t.Errorf("Expected %q, got %q", "-42", body["chat_id"])
+	}
+	want := "[CRITICAL] Risk limit daily_loss breached (risk)\nvalue: 60\nmax: 50"
+	if body["text"] != want {
+		t.Errorf("Expected %q, got %q", want, body["text"])
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	srv, _, _ := capture(t, http.StatusForbidden)
+	err := notify.Slack(srv.URL).Notify(context.Background(), testMessage)
+	want := "notify: slack: 403 Forbidden: nope"
+	if err == nil || err.Error() != want {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+}
+
+func TestMultiAndMinLevel(t *testing.T) {
+	var got []string
+	record := func(name string) notify.Sink {
+		return notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+			got = append(got, name+" "+m.Level.String())
+			return nil
+		})
+	}
+	failing := notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+		return errors.New("down")
+	})
+	s := notify.Multi(record("all"), notify.MinLevel(record("urgent"), notify.Warning), failing)
+
+	for _, level := range []notify.Level{notify.Info, notify.Critical} {
+		m := testMessage
+		m.Level = level
+		if err := s.Notify(context.Background(), m); err == nil || err.Error() != "down" {
+			t.Errorf("Expected %q, got %v", "down", err)
+		}
+	}
+	want := "all info,all critical,urgent critical"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(got, ","))
+	}
+}
+
+func TestSend(t *testing.T) {
+	done := make(chan notify.Message, 1)
+	notify.Send(notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected a deadline")
+		}
+		done <- m
+		return nil
+	}), notify.Message{Title: "hello"}, time.Second)
+
+	select {
+	case m := <-done:
+		if m.Time.IsZero() {
+			t.Error("Expected time to be set")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected message to be delivered")
+	}
+}
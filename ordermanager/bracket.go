@@ -0,0 +1,207 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrEntryNotFilled is returned by PlaceBracket if the entry order
+	// completed without any fills.
+	ErrEntryNotFilled = errors.New("ordermanager: entry order not filled")
+	// ErrBracketCancelled is returned by Bracket.Wait if the target order was
+	// cancelled other than by the stop.
+	ErrBracketCancelled = errors.New("ordermanager: bracket target cancelled")
+)
+
+// Bracket exit legs.
+const (
+	ExitTarget = "target"
+	ExitStop   = "stop"
+)
+
+// BracketConfig describes a bracket order.
+type BracketConfig struct {
+	Entry *valr.PostLimitOrderRequest
+	// StopOffset is the distance from the entry's average fill price to the
+	// protective stop.
+	StopOffset decimal.Decimal
+	// TargetOffset is the distance from the entry's average fill price to
+	// the profit target.
+	TargetOffset decimal.Decimal
+}
+
+// BracketResult describes how a bracket exited.
+type BracketResult struct {
+	// Exit is ExitTarget or ExitStop.
+	Exit   string
+	Target *Execution
+	// StopOrderID is the market order placed when the stop fired, empty if
+	// the target filled completely first.
+	StopOrderID string
+}
+
+// Bracket is an open position protected by a resting profit target and a
+// client side stop. The stop is evaluated against prices passed to OnPrice;
+// when it fires the target is cancelled and the remainder closed at market.
+type Bracket struct {
+	m *Manager
+
+	Entry       *Execution
+	Side        valr.RequestSide
+	StopPrice   decimal.Decimal
+	TargetPrice decimal.Decimal
+	TargetID    string
+
+	target *tracked
+
+	mu          sync.Mutex
+	stopping    bool
+	stopOrderID string
+	stopped     chan struct{}
+}
+
+// PlaceBracket places the entry order and waits for it to complete. Once
+// filled, a limit order for the filled quantity is placed at the profit
+// target, and the returned Bracket watches the stop.
+func (m *Manager) PlaceBracket(ctx context.Context, cfg BracketConfig) (*Bracket, error) {
+	if cfg.Entry == nil {
+		return nil, errors.New("ordermanager: bracket requires an entry order")
+	}
+	if !cfg.StopOffset.IsPositive() || !cfg.TargetOffset.IsPositive() {
+		return nil, errors.New("ordermanager: bracket offsets must be positive")
+	}
+
+	entry, err := m.PlaceAndAwait(ctx, cfg.Entry)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.FilledQuantity.IsPositive() {
+		return nil, ErrEntryNotFilled
+	}
+
+	b := &Bracket{
+		m:       m,
+		Entry:   entry,
+		stopped: make(chan struct{}),
+	}
+	if cfg.Entry.Side == valr.BUY {
+		b.Side = valr.SELL
+		b.StopPrice = entry.AveragePrice.Sub(cfg.StopOffset)
+		b.TargetPrice = entry.AveragePrice.Add(cfg.TargetOffset)
+	} else {
+		b.Side = valr.BUY
+		b.StopPrice = entry.AveragePrice.Add(cfg.StopOffset)
+		b.TargetPrice = entry.AveragePrice.Sub(cfg.TargetOffset)
+	}
+
+	var res *valr.PostLimitOrderResponse
+	err = m.mutate(ctx, entry.Pair, func() error {
+		var err error
+		res, err = m.client.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+			Pair:     entry.Pair,
+			Side:     b.Side,
+			Quantity: entry.FilledQuantity,
+			Price:    b.TargetPrice,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.TargetID = res.ID
+	b.target = m.track(res.ID, entry.Pair, "")
+	m.addBracket(b)
+	return b, nil
+}
+
+// OnPrice checks the stop against the latest traded price. If it is
+// crossed, the target is cancelled and any unfilled quantity is closed with
+// a market order. It returns true if the stop fired on this call.
+func (b *Bracket) OnPrice(ctx context.Context, price decimal.Decimal) (bool, error) {
+	if !price.IsPositive() {
+		return false, nil
+	}
+	hit := (b.Side == valr.SELL && price.LessThanOrEqual(b.StopPrice)) ||
+		(b.Side == valr.BUY && price.GreaterThanOrEqual(b.StopPrice))
+	if !hit {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopping {
+		return false, nil
+	}
+	if target, done, _ := b.m.state(b.target); done && target.Status == StatusFilled {
+		return false, nil
+	}
+	b.stopping = true
+
+	err := b.m.mutate(ctx, b.Entry.Pair, func() error {
+		_, err := b.m.client.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: b.Entry.Pair, ID: b.TargetID})
+		return err
+	})
+	if err != nil {
+		b.stopping = false
+		return false, err
+	}
+	target, _, _ := b.m.state(b.target)
+	remaining := b.Entry.FilledQuantity.Sub(target.FilledQuantity)
+	if remaining.IsPositive() {
+		var res *valr.PostMarketOrderResponse
+		err := b.m.mutate(ctx, b.Entry.Pair, func() error {
+			var err error
+			res, err = b.m.client.PostMarketBaseAmountRequest(ctx, &valr.PostMarketOrderBaseAmountRequest{
+				Pair:     b.Entry.Pair,
+				Side:     b.Side,
+				Quantity: remaining,
+			})
+			return err
+		})
+		if err != nil {
+			// The target is gone, so leave the bracket stopping and let the
+			// caller retry the exit.
+			return false, err
+		}
+		b.stopOrderID = res.ID
+	}
+	close(b.stopped)
+	return true, nil
+}
+
+// Wait blocks until the target fills, the stop fires or ctx expires.
+func (b *Bracket) Wait(ctx context.Context) (*BracketResult, error) {
+	defer func() {
+		b.m.untrack(b.TargetID)
+		b.m.removeBracket(b)
+	}()
+	for {
+		target, done, settled := b.m.state(b.target)
+		if settled && target.Status == StatusFilled {
+			return &BracketResult{Exit: ExitTarget, Target: target}, nil
+		}
+
+		b.mu.Lock()
+		stopping := b.stopping
+		b.mu.Unlock()
+		if done && !stopping {
+			return &BracketResult{Target: target}, ErrBracketCancelled
+		}
+
+		select {
+		case <-b.target.changed:
+		case <-b.stopped:
+			target, _, _ = b.m.state(b.target)
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return &BracketResult{Exit: ExitStop, Target: target, StopOrderID: b.stopOrderID}, nil
+		case <-ctx.Done():
+			return &BracketResult{Target: target}, ctx.Err()
+		}
+	}
+}
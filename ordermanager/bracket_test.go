@@ -0,0 +1,161 @@
+package ordermanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+// bracketServer serves a bracket whose entry buys 1 BTC at 100, returning
+// the manager and the calls made after the target was placed.
+func bracketServer(t *testing.T) (*ordermanager.Manager, func() []string) {
+	t.Helper()
+	var (
+		m      *ordermanager.Manager
+		mu     sync.Mutex
+		placed int
+		calls  []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "POST /orders/limit":
+			placed++
+			if placed == 1 {
+				m.HandleFill(ordermanager.Fill{
+					TradeID: "t1", OrderID: "entry", Pair: "BTCZAR",
+					Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1"),
+				})
+				m.HandleOrderUpdate(ordermanager.OrderUpdate{
+					OrderID: "entry", Status: ordermanager.StatusFilled,
+					OriginalQuantity: decimal.RequireFromString("1"),
+				})
+				w.Write([]byte(`{"id":"entry"}`))
+				return
+			}
+			w.Write([]byte(`{"id":"target"}`))
+		case "DELETE /orders/order":
+			calls = append(calls, "cancel "+body["orderId"].(string))
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{}`))
+		case "POST /orders/market":
+			calls = append(calls, "market "+body["side"].(string)+" "+body["baseAmount"].(string))
+			w.Write([]byte(`{"id":"stop"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m = ordermanager.New(cl, ordermanager.WithSettleTimeout(time.Minute))
+	return m, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+func placeBracket(t *testing.T, ctx context.Context, m *ordermanager.Manager) *ordermanager.Bracket {
+	t.Helper()
+	b, err := m.PlaceBracket(ctx, ordermanager.BracketConfig{
+		Entry: &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY,
+			Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("100"),
+		},
+		StopOffset:   decimal.RequireFromString("5"),
+		TargetOffset: decimal.RequireFromString("10"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if b.Side != valr.SELL || b.StopPrice.String() != "95" || b.TargetPrice.String() != "110" || b.TargetID != "target" {
+		t.Fatalf("Unexpected bracket %+v", b)
+	}
+	return b
+}
+
+func TestBracketTarget(t *testing.T) {
+	m, calls := bracketServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b := placeBracket(t, ctx, m)
+
+	m.HandleFill(ordermanager.Fill{
+		TradeID: "t2", OrderID: "target", Pair: "BTCZAR",
+		Price: decimal.RequireFromString("110"), Quantity: decimal.RequireFromString("1"),
+	})
+	m.HandleOrderUpdate(ordermanager.OrderUpdate{
+		OrderID: "target", Status: ordermanager.StatusFilled,
+		OriginalQuantity: decimal.RequireFromString("1"),
+	})
+
+	// The filled target disarms the stop.
+	if fired, err := b.OnPrice(ctx, decimal.RequireFromString("90")); fired || err != nil {
+		t.Errorf("Expected the stop not to fire, got %t, %v", fired, err)
+	}
+	res, err := b.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.Exit != ordermanager.ExitTarget || res.StopOrderID != "" || res.Target.FilledQuantity.String() != "1" {
+		t.Errorf("Expected the target exit, got %+v", res)
+	}
+	if c := calls(); len(c) != 0 {
+		t.Errorf("Expected no orders cancelled or placed, got %q", c)
+	}
+}
+
+func TestBracketStop(t *testing.T) {
+	m, calls := bracketServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b := placeBracket(t, ctx, m)
+
+	// The target is partly filled before the price falls.
+	m.HandleFill(ordermanager.Fill{
+		TradeID: "t2", OrderID: "target", Pair: "BTCZAR",
+		Price: decimal.RequireFromString("110"), Quantity: decimal.RequireFromString("0.4"),
+	})
+	for _, tc := range []struct {
+		price string
+		fired bool
+	}{
+		{"96", false},
+		{"95", true},
+		// The stop only fires once.
+		{"90", false},
+	} {
+		fired, err := b.OnPrice(ctx, decimal.RequireFromString(tc.price))
+		if err != nil || fired != tc.fired {
+			t.Errorf("Expected the stop at %s to fire %t, got %t, %v", tc.price, tc.fired, fired, err)
+		}
+	}
+
+	res, err := b.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.Exit != ordermanager.ExitStop || res.StopOrderID != "stop" {
+		t.Errorf("Expected the stop exit, got %+v", res)
+	}
+	want := []string{"cancel target", "market SELL 0.6"}
+	if c := calls(); len(c) != len(want) || c[0] != want[0] || c[1] != want[1] {
+		t.Errorf("Expected %q, got %q", want, c)
+	}
+}
@@ -0,0 +1,65 @@
+package ordermanager
+
+import (
+	"context"
+
+	"github.com/donohutcheon/valr-go/eventbus"
+)
+
+// Consume ingests the Order and Fill events published to b, whatever their
+// source, until ctx is done. It returns ctx.Err(), or nil if b was closed.
+// Events dropped because the manager fell behind are lost, so size buffer
+// for the expected burst of account events; a default is used if it is not
+// positive.
+func (m *Manager) Consume(ctx context.Context, b *eventbus.Bus, buffer int) error {
+	orders := eventbus.Subscribe[eventbus.Order](b, buffer)
+	defer orders.Unsubscribe()
+	fills := eventbus.Subscribe[eventbus.Fill](b, buffer)
+	defer fills.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-orders.C:
+			if !ok {
+				return nil
+			}
+			m.HandleOrderUpdate(OrderUpdateFromEvent(ev))
+		case ev, ok := <-fills.C:
+			if !ok {
+				return nil
+			}
+			m.HandleFill(FillFromEvent(ev))
+		}
+	}
+}
+
+// OrderUpdateFromEvent converts an Order event to an OrderUpdate.
+func OrderUpdateFromEvent(ev eventbus.Order) OrderUpdate {
+	return OrderUpdate{
+		OrderID:           ev.OrderID,
+		CustomerOrderID:   ev.CustomerOrderID,
+		Pair:              ev.Pair,
+		Status:            ev.Status,
+		OriginalQuantity:  ev.OriginalQuantity,
+		RemainingQuantity: ev.RemainingQuantity,
+		FailedReason:      ev.FailedReason,
+		Time:              ev.Time,
+	}
+}
+
+// FillFromEvent converts a Fill event to a Fill.
+func FillFromEvent(ev eventbus.Fill) Fill {
+	return Fill{
+		TradeID:     ev.TradeID,
+		OrderID:     ev.OrderID,
+		Pair:        ev.Pair,
+		Side:        ev.Side,
+		Price:       ev.Price,
+		Quantity:    ev.Quantity,
+		Fee:         ev.Fee,
+		FeeCurrency: ev.FeeCurrency,
+		TradedAt:    ev.Time,
+	}
+}
@@ -0,0 +1,47 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/donohutcheon/valr-go/calendar"
+)
+
+// ErrMarketClosed is returned for orders placed outside the trading
+// calendar of their pair.
+var ErrMarketClosed = errors.New("ordermanager: outside trading hours")
+
+// WithTradingSchedule rejects orders placed while the calendar of their
+// pair in s is closed, e.g. during quiet hours, with ErrMarketClosed.
+// Cancellations are always allowed. Individual orders, such as exits, can
+// skip the check with WithScheduleOverride.
+func WithTradingSchedule(s *calendar.Schedule) Option {
+	return func(m *Manager) {
+		m.schedule = s
+	}
+}
+
+type scheduleOverrideKey struct{}
+
+// WithScheduleOverride returns a context whose orders skip the trading
+// schedule.
+func WithScheduleOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scheduleOverrideKey{}, true)
+}
+
+// checkSchedule applies the trading schedule to an order on pair.
+func (m *Manager) checkSchedule(ctx context.Context, pair string) error {
+	if m.schedule == nil || ctx.Value(scheduleOverrideKey{}) != nil {
+		return nil
+	}
+	now := m.clock.Now()
+	if m.schedule.Open(pair, now) {
+		return nil
+	}
+	if next := m.schedule.For(pair).NextOpen(now); !next.IsZero() {
+		return fmt.Errorf("%w: %s opens at %s", ErrMarketClosed, pair, next.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: %s", ErrMarketClosed, pair)
+}
@@ -0,0 +1,36 @@
+package ordermanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// Cancel requests the cancellation of an open order, subject to the
+// manager's mutation throttles. The order is cancelled once its status
+// update reports it; an order that fills meanwhile is not cancelled.
+func (m *Manager) Cancel(ctx context.Context, pair, orderID string) error {
+	return m.mutate(ctx, pair, func() error {
+		_, err := m.client.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: pair, ID: orderID})
+		return err
+	})
+}
+
+// Replace cancels an open order and places req in its place, e.g. to move
+// a resting order to a new price or quantity, returning the execution of
+// the new order once acknowledged. The new order passes the same checks
+// as any other placement. Since the cancellation is not awaited, the old
+// order may still fill in the meantime, and req should allow for that.
+// The new order is not tracked or stored once acknowledged.
+func (m *Manager) Replace(ctx context.Context, orderID string, req *valr.PostLimitOrderRequest) (*Execution, error) {
+	if err := m.Cancel(ctx, req.Pair, orderID); err != nil {
+		return nil, fmt.Errorf("ordermanager: cancelling %s: %w", orderID, err)
+	}
+	exec, err := m.place(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m.forgetIntent(ctx, exec.CustomerOrderID)
+	return exec, nil
+}
@@ -0,0 +1,102 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// ErrDuplicateOrder is returned for orders identical to one submitted within
+// the duplicate detection window.
+var ErrDuplicateOrder = errors.New("ordermanager: duplicate order submission")
+
+// DuplicateMode is how the manager treats an order identical in pair, side,
+// price and quantity to one submitted shortly before, which usually means a
+// retry loop or strategy bug rather than a deliberate order.
+type DuplicateMode int
+
+const (
+	// DuplicateAllow places orders without checking.
+	DuplicateAllow DuplicateMode = iota
+	// DuplicateWarn logs duplicates and places them.
+	DuplicateWarn
+	// DuplicateBlock rejects duplicates with ErrDuplicateOrder.
+	DuplicateBlock
+)
+
+// WithDuplicateDetection remembers the orders submitted in the last window
+// and handles identical submissions according to mode. Customer order IDs
+// are ignored when comparing, since retry loops often generate new ones.
+// The mode can be overridden per call, e.g. for deliberate order ladders,
+// with WithDuplicateMode.
+func WithDuplicateDetection(mode DuplicateMode, window time.Duration) Option {
+	return func(m *Manager) {
+		m.duplicates = mode
+		m.duplicateWindow = window
+	}
+}
+
+type duplicateModeKey struct{}
+
+// WithDuplicateMode returns a context that overrides the manager's
+// duplicate mode for orders placed with it.
+func WithDuplicateMode(ctx context.Context, mode DuplicateMode) context.Context {
+	return context.WithValue(ctx, duplicateModeKey{}, mode)
+}
+
+func (m *Manager) duplicateMode(ctx context.Context) DuplicateMode {
+	if mode, ok := ctx.Value(duplicateModeKey{}).(DuplicateMode); ok {
+		return mode
+	}
+	return m.duplicates
+}
+
+// fingerprint identifies an order by what it trades, ignoring its customer
+// order ID.
+func fingerprint(req any) string {
+	switch r := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		return fmt.Sprintf("limit %s %s %s@%s", r.Pair, r.Side, r.Quantity, r.Price)
+	case *valr.PostMarketOrderBuyRequest:
+		return fmt.Sprintf("market %s %s quote %s", r.Pair, r.Side, r.Quantity)
+	case *valr.PostMarketOrderSellRequest:
+		return fmt.Sprintf("market %s %s base %s", r.Pair, r.Side, r.Quantity)
+	case *valr.PostMarketOrderBaseAmountRequest:
+		return fmt.Sprintf("market %s %s base %s", r.Pair, r.Side, r.Quantity)
+	}
+	return ""
+}
+
+// checkDuplicate records the submission of req, returning ErrDuplicateOrder
+// if it duplicates a recent one and the mode blocks duplicates.
+func (m *Manager) checkDuplicate(ctx context.Context, req any) error {
+	mode := m.duplicateMode(ctx)
+	if mode == DuplicateAllow || m.duplicateWindow <= 0 {
+		return nil
+	}
+	fp := fingerprint(req)
+	now := m.clock.Now()
+
+	m.recentMu.Lock()
+	defer m.recentMu.Unlock()
+	for k, at := range m.recent {
+		if now.Sub(at) >= m.duplicateWindow {
+			delete(m.recent, k)
+		}
+	}
+	at, dup := m.recent[fp]
+	if !dup {
+		m.recent[fp] = now
+		return nil
+	}
+	if mode == DuplicateBlock {
+		return fmt.Errorf("%w: %s, %s after the last", ErrDuplicateOrder, fp, now.Sub(at).Round(time.Millisecond))
+	}
+	log.Printf("valr/ordermanager: Duplicate order %s, %s after the last", fp, now.Sub(at).Round(time.Millisecond))
+	m.recent[fp] = now
+	return nil
+}
@@ -0,0 +1,118 @@
+package ordermanager
+
+import (
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Order statuses as reported by VALR.
+const (
+	StatusPlaced          = "Placed"
+	StatusPartiallyFilled = "Partially Filled"
+	StatusFilled          = "Filled"
+	StatusCancelled       = "Cancelled"
+	StatusFailed          = "Failed"
+)
+
+// terminal returns true if no further fills are expected after status.
+func terminal(status string) bool {
+	switch status {
+	case StatusFilled, StatusCancelled, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderUpdate is a change in an order's status, such as an
+// ORDER_STATUS_UPDATE message from the account stream.
+type OrderUpdate struct {
+	OrderID           string
+	CustomerOrderID   string
+	Pair              string
+	Status            string
+	OriginalQuantity  decimal.Decimal
+	RemainingQuantity decimal.Decimal
+	FailedReason      string
+	Time              time.Time
+}
+
+// Fill is a single trade against one of the account's orders, such as a
+// NEW_ACCOUNT_TRADE message from the account stream.
+type Fill struct {
+	TradeID     string
+	OrderID     string
+	Pair        string
+	Side        valr.ResponseSide
+	Price       decimal.Decimal
+	Quantity    decimal.Decimal
+	Fee         decimal.Decimal
+	FeeCurrency string
+	TradedAt    time.Time
+}
+
+// Execution is the aggregate of an order's fills.
+type Execution struct {
+	OrderID         string
+	CustomerOrderID string
+	Pair            string
+	Side            valr.RequestSide
+	Status          string
+	FailedReason    string
+	FilledQuantity  decimal.Decimal
+	// AveragePrice is the quantity weighted average fill price, zero if
+	// nothing was filled.
+	AveragePrice decimal.Decimal
+	// Fees holds the total fees charged, keyed by currency.
+	Fees  map[string]decimal.Decimal
+	Fills []Fill
+
+	// DecisionPrice is the price the order was based on, set with
+	// WithDecisionPrice.
+	DecisionPrice decimal.Decimal
+	// SubmittedAt and AckedAt are when the placement request was sent and
+	// answered. FirstFillAt and DoneAt are taken from the exchange's event
+	// times where available. Orders not placed through PlaceAndAwait have
+	// no submission times.
+	SubmittedAt time.Time
+	AckedAt     time.Time
+	FirstFillAt time.Time
+	DoneAt      time.Time
+}
+
+func (e *Execution) addFill(f Fill) {
+	for _, existing := range e.Fills {
+		if f.TradeID != "" && existing.TradeID == f.TradeID {
+			return
+		}
+	}
+	notional := e.AveragePrice.Mul(e.FilledQuantity).Add(f.Price.Mul(f.Quantity))
+	e.FilledQuantity = e.FilledQuantity.Add(f.Quantity)
+	if e.FilledQuantity.IsPositive() {
+		e.AveragePrice = notional.DivRound(e.FilledQuantity, 16)
+	}
+	if !f.Fee.IsZero() {
+		if e.Fees == nil {
+			e.Fees = make(map[string]decimal.Decimal)
+		}
+		e.Fees[f.FeeCurrency] = e.Fees[f.FeeCurrency].Add(f.Fee)
+	}
+	e.Fills = append(e.Fills, f)
+	if e.FirstFillAt.IsZero() {
+		e.FirstFillAt = f.TradedAt
+	}
+}
+
+func (e *Execution) clone() *Execution {
+	c := *e
+	c.Fills = append([]Fill(nil), e.Fills...)
+	if e.Fees != nil {
+		c.Fees = make(map[string]decimal.Decimal, len(e.Fees))
+		for k, v := range e.Fees {
+			c.Fees[k] = v
+		}
+	}
+	return &c
+}
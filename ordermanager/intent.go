@@ -0,0 +1,295 @@
+package ordermanager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/store"
+)
+
+// intentsNamespace holds the intents of orders being submitted.
+const intentsNamespace = "ordermanager.intents"
+
+// Intent is an order about to be submitted. With a store, the manager saves
+// an intent before every placement request and removes it once the order is
+// acknowledged or rejected, so that an order whose placement was
+// interrupted, e.g. by a crash between submission and acknowledgement, can
+// be reconciled by its customer order ID.
+type Intent struct {
+	CustomerOrderID string           `json:"customerOrderId"`
+	Pair            string           `json:"pair"`
+	Side            valr.RequestSide `json:"side"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	// Kind and Request hold the order request, to resubmit it.
+	Kind    string          `json:"kind"`
+	Request json.RawMessage `json:"request"`
+}
+
+// Kinds of order request in an Intent.
+const (
+	intentLimit      = "limit"
+	intentMarketBuy  = "marketBuy"
+	intentMarketSell = "marketSell"
+	intentMarketBase = "marketBase"
+)
+
+// OrderRequest returns the order request of the intent, e.g. to resubmit an
+// order that was never placed with PlaceAndAwait. It keeps the intent's
+// customer order ID, so VALR rejects it if the order was placed after all.
+func (in Intent) OrderRequest() (any, error) {
+	var req any
+	switch in.Kind {
+	case intentLimit:
+		req = new(valr.PostLimitOrderRequest)
+	case intentMarketBuy:
+		req = new(valr.PostMarketOrderBuyRequest)
+	case intentMarketSell:
+		req = new(valr.PostMarketOrderSellRequest)
+	case intentMarketBase:
+		req = new(valr.PostMarketOrderBaseAmountRequest)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedRequest, in.Kind)
+	}
+	if err := json.Unmarshal(in.Request, req); err != nil {
+		return nil, fmt.Errorf("ordermanager: decoding intent %s: %w", in.CustomerOrderID, err)
+	}
+	return req, nil
+}
+
+// newIntent returns the intent of submitting req.
+func newIntent(req any, pair, customerOrderID string, now time.Time) (*Intent, error) {
+	var kind string
+	switch req.(type) {
+	case *valr.PostLimitOrderRequest:
+		kind = intentLimit
+	case *valr.PostMarketOrderBuyRequest:
+		kind = intentMarketBuy
+	case *valr.PostMarketOrderSellRequest:
+		kind = intentMarketSell
+	case *valr.PostMarketOrderBaseAmountRequest:
+		kind = intentMarketBase
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedRequest, req)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return &Intent{
+		CustomerOrderID: customerOrderID,
+		Pair:            pair,
+		Side:            requestSide(req),
+		CreatedAt:       now,
+		Kind:            kind,
+		Request:         b,
+	}, nil
+}
+
+// assignCustomerOrderID gives req a random customer order ID if it has
+// none, since intents are reconciled by customer order ID.
+func assignCustomerOrderID(req any) error {
+	var id *string
+	switch r := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		id = &r.CustomerOrderID
+	case *valr.PostMarketOrderBuyRequest:
+		id = &r.CustomerOrderID
+	case *valr.PostMarketOrderSellRequest:
+		id = &r.CustomerOrderID
+	case *valr.PostMarketOrderBaseAmountRequest:
+		id = &r.CustomerOrderID
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedRequest, req)
+	}
+	if *id != "" {
+		return nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("ordermanager: generating customer order ID: %w", err)
+	}
+	*id = hex.EncodeToString(b)
+	return nil
+}
+
+// saveIntent stores the intent of submitting req, if the manager has a
+// store. The order must not be submitted if it fails.
+func (m *Manager) saveIntent(ctx context.Context, req any, pair, customerOrderID string) error {
+	if m.store == nil {
+		return nil
+	}
+	in, err := newIntent(req, pair, customerOrderID, m.clock.Now())
+	if err != nil {
+		return err
+	}
+	if err := store.PutJSON(ctx, m.store, intentsNamespace, customerOrderID, in); err != nil {
+		return fmt.Errorf("ordermanager: saving intent %s: %w", customerOrderID, err)
+	}
+	return nil
+}
+
+// forgetIntent removes a resolved intent from the store.
+func (m *Manager) forgetIntent(ctx context.Context, customerOrderID string) {
+	if m.store == nil || customerOrderID == "" {
+		return
+	}
+	if err := m.store.Delete(context.WithoutCancel(ctx), intentsNamespace, customerOrderID); err != nil {
+		log.Printf("valr/ordermanager: Failed to remove intent %s: %v", customerOrderID, err)
+	}
+}
+
+// unresolved returns true if a placement that failed with err may still
+// have reached VALR, so its intent must be kept for Reconcile.
+func unresolved(err error) bool {
+	return valr.IsRetryable(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Intents returns the intents of placements that were interrupted, e.g. by
+// a previous process, oldest first.
+func (m *Manager) Intents(ctx context.Context) ([]Intent, error) {
+	if m.store == nil {
+		return nil, nil
+	}
+	ids, err := m.store.List(ctx, intentsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	intents := make([]Intent, 0, len(ids))
+	for _, id := range ids {
+		var in Intent
+		if err := store.GetJSON(ctx, m.store, intentsNamespace, id, &in); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		intents = append(intents, in)
+	}
+	sort.SliceStable(intents, func(i, j int) bool {
+		return intents[i].CreatedAt.Before(intents[j].CreatedAt)
+	})
+	return intents, nil
+}
+
+// Outcomes of reconciling an intent.
+const (
+	// ReconcilePlaced is an intent whose order VALR placed.
+	ReconcilePlaced = "placed"
+	// ReconcileNotPlaced is an intent whose order never reached VALR, which
+	// may be resubmitted.
+	ReconcileNotPlaced = "notPlaced"
+)
+
+// Reconciliation is the outcome of an interrupted placement.
+type Reconciliation struct {
+	Intent  Intent
+	Outcome string
+	// Execution is the order VALR placed, nil if it was not placed. Orders
+	// in progress are tracked, so they can be awaited with Await.
+	Execution *Execution
+}
+
+// Reconcile resolves the intents of interrupted placements against VALR's
+// order history by customer order ID, so that no order is left live but
+// unknown. It should be called on startup, after Import and before new
+// orders are placed. Placed orders still in progress are tracked and
+// stored like orders placed by PlaceAndAwait; orders never placed are
+// returned for the caller to resubmit or drop. Resolved intents are
+// removed; on error, the unresolved ones are kept for the next call.
+func (m *Manager) Reconcile(ctx context.Context) ([]Reconciliation, error) {
+	intents, err := m.Intents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var recs []Reconciliation
+	for _, in := range intents {
+		exec, err := m.lookupIntent(ctx, in)
+		if err != nil {
+			return recs, fmt.Errorf("ordermanager: reconciling %s: %w", in.CustomerOrderID, err)
+		}
+		rec := Reconciliation{Intent: in, Outcome: ReconcileNotPlaced}
+		if exec != nil {
+			rec.Outcome, rec.Execution = ReconcilePlaced, exec
+			if !terminal(exec.Status) {
+				m.mu.Lock()
+				_, known := m.orders[exec.OrderID]
+				m.mu.Unlock()
+				if !known {
+					t := m.trackExecution(exec.clone())
+					m.mu.Lock()
+					t.expected = exec.FilledQuantity
+					m.mu.Unlock()
+				}
+				m.save(ctx, exec)
+			}
+		}
+		m.forgetIntent(ctx, in.CustomerOrderID)
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// lookupIntent returns the execution of the order placed for in, or nil if
+// VALR has no order with its customer order ID. Completed orders are found
+// in the order history, and orders still open by their status.
+func (m *Manager) lookupIntent(ctx context.Context, in Intent) (*Execution, error) {
+	sum, err := m.client.GetOrderHistorySummaryByCustomerOrderIDRequest(ctx,
+		&valr.GetOrderHistorySummaryByCustomerOrderIDRequest{ID: in.CustomerOrderID})
+	if err == nil {
+		exec := &Execution{
+			OrderID:         sum.OrderID,
+			CustomerOrderID: in.CustomerOrderID,
+			Pair:            sum.Pair,
+			Side:            in.Side,
+			Status:          sum.OrderStatusType,
+			FailedReason:    sum.FailedReason,
+			FilledQuantity:  sum.OriginalQuantity.Sub(sum.RemainingQuantity),
+			AveragePrice:    sum.AveragePrice,
+		}
+		if terminal(exec.Status) {
+			exec.DoneAt = sum.OrderUpdatedAt.Time
+		}
+		return exec, nil
+	}
+	if !orderNotFound(err) {
+		return nil, err
+	}
+
+	status, err := m.client.GetOrderStatusByCustomerOrderIDRequest(ctx,
+		&valr.GetOrderStatusByCustomerOrderIDRequest{Pair: in.Pair, ID: in.CustomerOrderID})
+	if err != nil {
+		if orderNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Execution{
+		OrderID:         status.OrderID,
+		CustomerOrderID: in.CustomerOrderID,
+		Pair:            in.Pair,
+		Side:            in.Side,
+		Status:          status.OrderStatusType,
+		FailedReason:    status.FailedReason,
+		FilledQuantity:  status.OriginalQuantity.Sub(status.RemainingQuantity),
+	}, nil
+}
+
+// orderNotFound returns true if err reports that VALR has no such order.
+func orderNotFound(err error) bool {
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound ||
+		(apiErr.Invalid() && strings.Contains(strings.ToLower(apiErr.Message), "not found"))
+}
@@ -0,0 +1,146 @@
+package ordermanager_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+func TestReconcileIntents(t *testing.T) {
+	// The placement of "live" reaches VALR but its acknowledgement is lost,
+	// "lost" never reaches VALR, and "rejected" is refused outright.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/orders/limit"):
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"rejected"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-1,"message":"Insufficient balance"}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/orders/history/summary/customerorderid/live":
+			w.Write([]byte(`{"orderId":"o1","customerOrderId":"live","orderStatusType":"Partially Filled",
+				"currencyPair":"BTCZAR","originalQuantity":"2","remainingQuantity":"1.5","averagePrice":"100"}`))
+		case strings.HasSuffix(r.URL.Path, "/customerorderid/lost"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1,"message":"Order not found"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	st := store.NewMemory()
+	ctx := context.Background()
+
+	m := ordermanager.New(cl, ordermanager.WithStore(st))
+	for _, id := range []string{"live", "lost", "rejected"} {
+		_, err := m.PlaceAndAwait(ctx, &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY, CustomerOrderID: id,
+			Quantity: decimal.RequireFromString("2"), Price: decimal.RequireFromString("100"),
+		})
+		if err == nil {
+			t.Fatalf("%s: Expected error", id)
+		}
+	}
+	intents, err := m.Intents(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(intents) != 2 || intents[0].CustomerOrderID != "live" || intents[1].CustomerOrderID != "lost" {
+		t.Fatalf("Expected intents live and lost, got %+v", intents)
+	}
+
+	// A new process reconciles the intents left behind.
+	m = ordermanager.New(cl, ordermanager.WithStore(st))
+	recs, err := m.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("Expected 2 reconciliations, got %d", len(recs))
+	}
+	live, lost := recs[0], recs[1]
+	if live.Outcome != ordermanager.ReconcilePlaced || live.Execution.OrderID != "o1" {
+		t.Errorf("Expected o1 placed, got %q %+v", live.Outcome, live.Execution)
+	}
+	if exp := "0.5"; live.Execution.FilledQuantity.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, live.Execution.FilledQuantity)
+	}
+	if lost.Outcome != ordermanager.ReconcileNotPlaced || lost.Execution != nil {
+		t.Errorf("Expected not placed, got %q %+v", lost.Outcome, lost.Execution)
+	}
+	req, err := lost.Intent.OrderRequest()
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if r, ok := req.(*valr.PostLimitOrderRequest); !ok || r.CustomerOrderID != "lost" || r.Price.String() != "100" {
+		t.Errorf("Expected the original limit order, got %+v", req)
+	}
+
+	stored, err := m.Stored(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(stored) != 1 || stored[0].OrderID != "o1" {
+		t.Errorf("Expected o1 stored, got %+v", stored)
+	}
+	m.HandleOrderUpdate(ordermanager.OrderUpdate{OrderID: "o1", Status: ordermanager.StatusCancelled})
+	exec, err := m.Await(ctx, "o1")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if exec.Status != ordermanager.StatusCancelled {
+		t.Errorf("Expected %q, got %q", ordermanager.StatusCancelled, exec.Status)
+	}
+	if intents, _ := m.Intents(ctx); len(intents) != 0 {
+		t.Errorf("Expected no intents, got %+v", intents)
+	}
+}
+
+func TestIntentAssignsCustomerOrderID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"o1"}`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	st := store.NewMemory()
+	m := ordermanager.New(cl, ordermanager.WithStore(st))
+
+	req := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("100"),
+	}
+	exec, err := m.Replace(context.Background(), "old", req)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if req.CustomerOrderID == "" || exec.CustomerOrderID != req.CustomerOrderID {
+		t.Errorf("Expected a customer order ID, got %q and %q", req.CustomerOrderID, exec.CustomerOrderID)
+	}
+	if intents, _ := m.Intents(context.Background()); len(intents) != 0 {
+		t.Errorf("Expected no intents, got %+v", intents)
+	}
+}
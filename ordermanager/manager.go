@@ -0,0 +1,474 @@
+// Package ordermanager tracks the lifecycle of orders placed through a
+// valr.Client using events from the account stream.
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/calendar"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/refdata"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultSettleTimeout = 2 * time.Second
+	defaultMaxOrphans    = 1024
+
+	// ordersNamespace holds the executions of orders in progress.
+	ordersNamespace = "ordermanager.orders"
+)
+
+// ErrUnsupportedRequest is returned by PlaceAndAwait for request types it
+// cannot place.
+var ErrUnsupportedRequest = errors.New("ordermanager: unsupported order request")
+
+// ErrOrderFailed is returned by PlaceAndAwait for orders VALR failed.
+var ErrOrderFailed = errors.New("ordermanager: order failed")
+
+type Option func(*Manager)
+
+// WithSettleTimeout sets how long PlaceAndAwait waits for outstanding fills
+// once an order reaches a terminal status. Fills and status updates are
+// separate messages and may arrive in either order.
+func WithSettleTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.settleTimeout = d
+	}
+}
+
+// WithMaxOrphans sets how many events for not yet known orders are buffered.
+// Events can arrive before the placement request returns the order ID.
+func WithMaxOrphans(n int) Option {
+	return func(m *Manager) {
+		m.maxOrphans = n
+	}
+}
+
+// WithClock sets the clock of timestamps, settle timeouts, duplicate
+// windows and trading schedule checks, clock.Real by default, e.g. a
+// clock.Manual to replay a run deterministically.
+func WithClock(c clock.Clock) Option {
+	return func(m *Manager) {
+		m.clock = c
+	}
+}
+
+// WithStore saves the executions of orders in progress to s, so that they
+// can be found after a restart, and the intent of every placement before it
+// is submitted, for Reconcile. Orders placed without a customer order ID are
+// given a random one.
+func WithStore(s store.Store) Option {
+	return func(m *Manager) {
+		m.store = s
+	}
+}
+
+type tracked struct {
+	exec *Execution
+	// expected is the filled quantity reported by the latest status update.
+	expected decimal.Decimal
+	changed  chan struct{}
+}
+
+func newTracked(exec *Execution) *tracked {
+	return &tracked{exec: exec, changed: make(chan struct{}, 1)}
+}
+
+func (t *tracked) signal() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Manager places orders and assembles their executions from order updates
+// and fills. Events are fed in by calling HandleOrderUpdate and HandleFill.
+type Manager struct {
+	client        *valr.Client
+	clock         clock.Clock
+	settleTimeout time.Duration
+	maxOrphans    int
+	store         store.Store
+
+	mu      sync.Mutex
+	orders  map[string]*tracked
+	orphans map[string][]any
+	// orphanOrder holds orphaned order IDs oldest first, for eviction.
+	orphanOrder []string
+
+	placements    map[int]Placement
+	nextPlacement int
+	brackets      map[string]*Bracket
+
+	// pairLocks is nil unless mutations are serialised per pair.
+	pairLocks map[string]chan struct{}
+	inFlight  chan struct{}
+
+	selfTrade SelfTradeMode
+	refData   *refdata.Cache
+	risk      *Risk
+
+	books        *streaming.BookKeeper
+	maxDeviation decimal.Decimal
+	schedule     *calendar.Schedule
+
+	duplicates      DuplicateMode
+	duplicateWindow time.Duration
+	recentMu        sync.Mutex
+	// recent holds the fingerprints of recent submissions by time.
+	recent map[string]time.Time
+
+	onReport func(ExecutionReport)
+}
+
+// New returns a Manager that places orders using cl.
+func New(cl *valr.Client, opts ...Option) *Manager {
+	m := &Manager{
+		client:        cl,
+		clock:         clock.Real,
+		settleTimeout: defaultSettleTimeout,
+		maxOrphans:    defaultMaxOrphans,
+		orders:        make(map[string]*tracked),
+		orphans:       make(map[string][]any),
+		placements:    make(map[int]Placement),
+		brackets:      make(map[string]*Bracket),
+		recent:        make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// HandleOrderUpdate ingests an order status change. Updates without a
+// time are stamped with the time they are ingested.
+func (m *Manager) HandleOrderUpdate(u OrderUpdate) {
+	if u.Time.IsZero() {
+		u.Time = m.clock.Now()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.orders[u.OrderID]
+	if !ok {
+		m.addOrphan(u.OrderID, u)
+		return
+	}
+	applyUpdate(t, u)
+	t.signal()
+}
+
+// HandleFill ingests a trade against an order. Fills without a time are
+// stamped with the time they are ingested.
+func (m *Manager) HandleFill(f Fill) {
+	if f.TradedAt.IsZero() {
+		f.TradedAt = m.clock.Now()
+	}
+	if m.risk != nil {
+		m.risk.HandleFill(f)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.orders[f.OrderID]
+	if !ok {
+		m.addOrphan(f.OrderID, f)
+		return
+	}
+	t.exec.addFill(f)
+	t.signal()
+}
+
+func applyUpdate(t *tracked, u OrderUpdate) {
+	t.exec.Status = u.Status
+	t.exec.FailedReason = u.FailedReason
+	if u.CustomerOrderID != "" {
+		t.exec.CustomerOrderID = u.CustomerOrderID
+	}
+	if u.Pair != "" {
+		t.exec.Pair = u.Pair
+	}
+	if !u.OriginalQuantity.IsZero() {
+		t.expected = u.OriginalQuantity.Sub(u.RemainingQuantity)
+	}
+	if terminal(u.Status) && t.exec.DoneAt.IsZero() {
+		t.exec.DoneAt = u.Time
+	}
+}
+
+func (m *Manager) addOrphan(orderID string, ev any) {
+	if m.maxOrphans <= 0 {
+		return
+	}
+	if _, ok := m.orphans[orderID]; !ok {
+		if len(m.orphanOrder) >= m.maxOrphans {
+			delete(m.orphans, m.orphanOrder[0])
+			m.orphanOrder = m.orphanOrder[1:]
+		}
+		m.orphanOrder = append(m.orphanOrder, orderID)
+	}
+	m.orphans[orderID] = append(m.orphans[orderID], ev)
+}
+
+// track registers orderID and replays any events received for it before it
+// was known.
+func (m *Manager) track(orderID, pair, customerOrderID string) *tracked {
+	return m.trackExecution(&Execution{
+		OrderID:         orderID,
+		CustomerOrderID: customerOrderID,
+		Pair:            pair,
+	})
+}
+
+// trackExecution is like track, starting from exec.
+func (m *Manager) trackExecution(exec *Execution) *tracked {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	orderID := exec.OrderID
+	t := newTracked(exec)
+	m.orders[orderID] = t
+	if evs, ok := m.orphans[orderID]; ok {
+		delete(m.orphans, orderID)
+		for i, id := range m.orphanOrder {
+			if id == orderID {
+				m.orphanOrder = append(m.orphanOrder[:i], m.orphanOrder[i+1:]...)
+				break
+			}
+		}
+		for _, ev := range evs {
+			switch ev := ev.(type) {
+			case OrderUpdate:
+				applyUpdate(t, ev)
+			case Fill:
+				t.exec.addFill(ev)
+			}
+		}
+		t.signal()
+	}
+	return t
+}
+
+func (m *Manager) untrack(orderID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.orders, orderID)
+}
+
+// state returns a copy of the execution and whether it is complete, i.e. in
+// a terminal status with all reported fills received.
+func (m *Manager) state(t *tracked) (*Execution, bool, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	done := terminal(t.exec.Status)
+	settled := done && t.exec.FilledQuantity.GreaterThanOrEqual(t.expected)
+	return t.exec.clone(), done, settled
+}
+
+// orderIdentity returns the pair and customer order ID of an order request.
+func orderIdentity(req any) (string, string, error) {
+	switch r := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		return r.Pair, r.CustomerOrderID, nil
+	case *valr.PostMarketOrderBuyRequest:
+		return r.Pair, r.CustomerOrderID, nil
+	case *valr.PostMarketOrderSellRequest:
+		return r.Pair, r.CustomerOrderID, nil
+	case *valr.PostMarketOrderBaseAmountRequest:
+		return r.Pair, r.CustomerOrderID, nil
+	default:
+		return "", "", fmt.Errorf("%w: %T", ErrUnsupportedRequest, req)
+	}
+}
+
+// place submits req and returns the new order's execution.
+func (m *Manager) place(ctx context.Context, req any) (*Execution, error) {
+	if m.store != nil {
+		if err := assignCustomerOrderID(req); err != nil {
+			return nil, err
+		}
+	}
+	pair, custOrdID, err := orderIdentity(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkSchedule(ctx, pair); err != nil {
+		return nil, err
+	}
+	if err := m.checkPrice(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := m.checkDuplicate(ctx, req); err != nil {
+		return nil, err
+	}
+	done := m.beginPlacement(pair, custOrdID)
+	defer done()
+
+	var (
+		res       *valr.PostMarketOrderResponse
+		submitted time.Time
+	)
+	err = m.mutate(ctx, pair, func() error {
+		// Check inside the mutation so that, with pair serialisation, no
+		// other order on the pair can change the outcome.
+		if m.risk != nil {
+			if err := m.risk.Check(req); err != nil {
+				return err
+			}
+		}
+		req, err := m.preventSelfTrade(ctx, req, pair)
+		if err != nil {
+			return err
+		}
+		if err := m.saveIntent(ctx, req, pair, custOrdID); err != nil {
+			return err
+		}
+		submitted = m.clock.Now()
+		switch r := req.(type) {
+		case *valr.PostLimitOrderRequest:
+			var lres *valr.PostLimitOrderResponse
+			lres, err = m.client.PostLimitOrderRequest(ctx, r)
+			if err == nil {
+				res = &valr.PostMarketOrderResponse{ID: lres.ID}
+			}
+		case *valr.PostMarketOrderBuyRequest:
+			res, err = m.client.PostMarketBuyRequest(ctx, r)
+		case *valr.PostMarketOrderSellRequest:
+			res, err = m.client.PostMarketSellRequest(ctx, r)
+		case *valr.PostMarketOrderBaseAmountRequest:
+			res, err = m.client.PostMarketBaseAmountRequest(ctx, r)
+		}
+		return err
+	})
+	if err != nil {
+		if !unresolved(err) {
+			m.forgetIntent(ctx, custOrdID)
+		}
+		return nil, err
+	}
+	decision, _ := ctx.Value(decisionPriceKey{}).(decimal.Decimal)
+	return &Execution{
+		OrderID:         res.ID,
+		CustomerOrderID: custOrdID,
+		Pair:            pair,
+		Side:            requestSide(req),
+		DecisionPrice:   decision,
+		SubmittedAt:     submitted,
+		AckedAt:         m.clock.Now(),
+	}, nil
+}
+
+// PlaceAndAwait places an order and blocks until it is filled, cancelled or
+// failed, or ctx expires. Failed orders are reported with ErrOrderFailed.
+// req must be one of *valr.PostLimitOrderRequest,
+// *valr.PostMarketOrderBuyRequest, *valr.PostMarketOrderSellRequest or
+// *valr.PostMarketOrderBaseAmountRequest.
+//
+// The returned execution aggregates the fills received so far. If ctx
+// expires before the order completes, the partial execution is returned
+// along with the context's error.
+func (m *Manager) PlaceAndAwait(ctx context.Context, req any) (*Execution, error) {
+	exec, err := m.place(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	t := m.trackExecution(exec)
+	defer func() {
+		m.untrack(exec.OrderID)
+		m.forget(ctx, exec.OrderID)
+	}()
+	// Save the order before removing its intent, so that a crash leaves a
+	// record of one or the other.
+	m.save(ctx, exec)
+	m.forgetIntent(ctx, exec.CustomerOrderID)
+	exec, err = m.await(ctx, t)
+	if m.onReport != nil && terminal(exec.Status) {
+		m.onReport(exec.Report())
+	}
+	return exec, err
+}
+
+// save stores an execution in progress, if the manager has a store.
+func (m *Manager) save(ctx context.Context, exec *Execution) {
+	if m.store == nil {
+		return
+	}
+	if err := store.PutJSON(ctx, m.store, ordersNamespace, exec.OrderID, exec); err != nil {
+		log.Printf("valr/ordermanager: Failed to save order %s: %v", exec.OrderID, err)
+	}
+}
+
+// forget removes a completed execution from the store.
+func (m *Manager) forget(ctx context.Context, orderID string) {
+	if m.store == nil {
+		return
+	}
+	// Remove the order even if the caller's context has expired.
+	if err := m.store.Delete(context.WithoutCancel(ctx), ordersNamespace, orderID); err != nil {
+		log.Printf("valr/ordermanager: Failed to remove order %s: %v", orderID, err)
+	}
+}
+
+// Stored returns the executions of orders that were in progress when last
+// saved, e.g. by a previous process.
+func (m *Manager) Stored(ctx context.Context) ([]*Execution, error) {
+	if m.store == nil {
+		return nil, nil
+	}
+	ids, err := m.store.List(ctx, ordersNamespace)
+	if err != nil {
+		return nil, err
+	}
+	execs := make([]*Execution, 0, len(ids))
+	for _, id := range ids {
+		exec := new(Execution)
+		if err := store.GetJSON(ctx, m.store, ordersNamespace, id, exec); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		execs = append(execs, exec)
+	}
+	return execs, nil
+}
+
+// await blocks until t completes or ctx expires.
+func (m *Manager) await(ctx context.Context, t *tracked) (*Execution, error) {
+	var settle <-chan time.Time
+	for {
+		exec, done, settled := m.state(t)
+		if settled {
+			return exec, execErr(exec)
+		}
+		m.save(ctx, exec)
+		if done && settle == nil {
+			timer := m.clock.NewTimer(m.settleTimeout)
+			defer timer.Stop()
+			settle = timer.C()
+		}
+
+		select {
+		case <-t.changed:
+		case <-settle:
+			exec, _, _ = m.state(t)
+			return exec, execErr(exec)
+		case <-ctx.Done():
+			exec, _, _ = m.state(t)
+			return exec, ctx.Err()
+		}
+	}
+}
+
+func execErr(e *Execution) error {
+	if e.Status == StatusFailed {
+		return fmt.Errorf("%w: %s", ErrOrderFailed, e.FailedReason)
+	}
+	return nil
+}
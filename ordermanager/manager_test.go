@@ -0,0 +1,164 @@
+package ordermanager_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+func TestPlaceAndAwait(t *testing.T) {
+	var m *ordermanager.Manager
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Deliver events before the placement response to exercise
+		// buffering of events for unknown orders.
+		m.HandleFill(ordermanager.Fill{
+			TradeID: "t1", OrderID: "o1", Pair: "BTCZAR",
+			Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1"),
+			Fee: decimal.RequireFromString("0.001"), FeeCurrency: "BTC",
+		})
+		m.HandleOrderUpdate(ordermanager.OrderUpdate{
+			OrderID: "o1", Status: ordermanager.StatusFilled,
+			OriginalQuantity: decimal.RequireFromString("3"),
+		})
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"o1"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m = ordermanager.New(cl, ordermanager.WithSettleTimeout(time.Minute))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.HandleFill(ordermanager.Fill{
+			TradeID: "t2", OrderID: "o1", Pair: "BTCZAR",
+			Price: decimal.RequireFromString("130"), Quantity: decimal.RequireFromString("2"),
+			Fee: decimal.RequireFromString("0.002"), FeeCurrency: "BTC",
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exec, err := m.PlaceAndAwait(ctx, &valr.PostLimitOrderRequest{
+		Pair:     "BTCZAR",
+		Side:     valr.BUY,
+		Quantity: decimal.RequireFromString("3"),
+		Price:    decimal.RequireFromString("130"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if exp := "3"; exec.FilledQuantity.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, exec.FilledQuantity)
+	}
+	if exp := "120"; exec.AveragePrice.String() != exp {
+		t.Errorf("Expected %q, got %q", exp, exec.AveragePrice)
+	}
+	if exp := "0.003"; exec.Fees["BTC"].String() != exp {
+		t.Errorf("Expected %q, got %q", exp, exec.Fees["BTC"])
+	}
+	if exec.Status != ordermanager.StatusFilled {
+		t.Errorf("Expected %q, got %q", ordermanager.StatusFilled, exec.Status)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"o2"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m := ordermanager.New(cl)
+
+	exec, err := m.Replace(context.Background(), "o1", &valr.PostLimitOrderRequest{
+		Pair:     "BTCZAR",
+		Side:     valr.SELL,
+		Quantity: decimal.RequireFromString("1"),
+		Price:    decimal.RequireFromString("110"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if exec.OrderID != "o2" {
+		t.Errorf("Expected %q, got %q", "o2", exec.OrderID)
+	}
+	if len(calls) != 2 || calls[0] != "DELETE /orders/order" || calls[1] != "POST /orders/limit" {
+		t.Errorf("Expected a cancel then a placement, got %q", calls)
+	}
+}
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	var m *ordermanager.Manager
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clk.Advance(time.Second)
+		// Filled without the fill, so the manager waits to settle.
+		m.HandleOrderUpdate(ordermanager.OrderUpdate{
+			OrderID: "o1", Status: ordermanager.StatusFilled,
+			OriginalQuantity: decimal.RequireFromString("1"),
+		})
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"o1"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m = ordermanager.New(cl, ordermanager.WithClock(clk), ordermanager.WithSettleTimeout(time.Minute))
+
+	go func() {
+		clk.BlockUntil(1)
+		clk.Advance(time.Minute)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exec, err := m.PlaceAndAwait(ctx, &valr.PostLimitOrderRequest{
+		Pair:     "BTCZAR",
+		Side:     valr.BUY,
+		Quantity: decimal.RequireFromString("1"),
+		Price:    decimal.RequireFromString("100"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if !exec.SubmittedAt.Equal(start) {
+		t.Errorf("Expected %v, got %v", start, exec.SubmittedAt)
+	}
+	if exp := start.Add(time.Second); !exec.AckedAt.Equal(exp) || !exec.DoneAt.Equal(exp) {
+		t.Errorf("Expected %v, got acked %v, done %v", exp, exec.AckedAt, exec.DoneAt)
+	}
+	if exp := start.Add(time.Second + time.Minute); !clk.Now().Equal(exp) {
+		t.Errorf("Expected %v, got %v", exp, clk.Now())
+	}
+}
@@ -0,0 +1,58 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrPriceDeviation is returned for limit orders priced too far from
+	// the mid of the local book.
+	ErrPriceDeviation = errors.New("ordermanager: limit price too far from mid")
+	// ErrNoReferencePrice is returned when the price guard is enabled but
+	// the local book has no mid to compare against.
+	ErrNoReferencePrice = errors.New("ordermanager: no mid price to check limit price against")
+)
+
+// WithPriceGuard rejects limit orders whose price differs from the mid of
+// the pair's book in books by more than maxDeviation, a fraction such as
+// 0.05 for 5%. Books must be kept up to date for every pair traded; orders
+// on pairs without a synced, two-sided book are rejected with
+// ErrNoReferencePrice. Individual orders can skip the check with
+// WithPriceOverride.
+func WithPriceGuard(books *streaming.BookKeeper, maxDeviation decimal.Decimal) Option {
+	return func(m *Manager) {
+		m.books = books
+		m.maxDeviation = maxDeviation
+	}
+}
+
+type priceOverrideKey struct{}
+
+// WithPriceOverride returns a context whose orders skip the price guard.
+func WithPriceOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priceOverrideKey{}, true)
+}
+
+// checkPrice applies the price guard to req.
+func (m *Manager) checkPrice(ctx context.Context, req any) error {
+	r, ok := req.(*valr.PostLimitOrderRequest)
+	if !ok || m.books == nil || ctx.Value(priceOverrideKey{}) != nil {
+		return nil
+	}
+	book := m.books.Book(r.Pair)
+	metrics, ok := book.Metrics(1)
+	if !book.Synced() || !ok || !metrics.Mid.IsPositive() {
+		return fmt.Errorf("%w: %s", ErrNoReferencePrice, r.Pair)
+	}
+	deviation := r.Price.Sub(metrics.Mid).Abs().DivRound(metrics.Mid, 8)
+	if deviation.GreaterThan(m.maxDeviation) {
+		return fmt.Errorf("%w: %s is %s from mid %s", ErrPriceDeviation, r.Price, deviation, metrics.Mid)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package ordermanager_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/calendar"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceGuard(t *testing.T) {
+	books := streaming.NewBookKeeper()
+	cl := valr.NewClient()
+	defer cl.Close()
+	m := ordermanager.New(cl, ordermanager.WithPriceGuard(books, decimal.RequireFromString("0.05")))
+
+	req := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("1000"),
+	}
+	_, err := m.PlaceAndAwait(context.Background(), req)
+	if !errors.Is(err, ordermanager.ErrNoReferencePrice) {
+		t.Errorf("Expected %v, got %v", ordermanager.ErrNoReferencePrice, err)
+	}
+
+	books.Book("BTCZAR").ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1,
+		Bids:     []streaming.Level{{Price: decimal.RequireFromString("99"), Quantity: decimal.RequireFromString("1")}},
+		Asks:     []streaming.Level{{Price: decimal.RequireFromString("101"), Quantity: decimal.RequireFromString("1")}},
+	})
+	_, err = m.PlaceAndAwait(context.Background(), req)
+	if !errors.Is(err, ordermanager.ErrPriceDeviation) {
+		t.Errorf("Expected %v, got %v", ordermanager.ErrPriceDeviation, err)
+	}
+}
+
+func TestTradingSchedule(t *testing.T) {
+	// Closed all day, every day.
+	closed := calendar.New(calendar.WithQuietHours(calendar.Daily(0, 0)))
+	cl := valr.NewClient()
+	defer cl.Close()
+	m := ordermanager.New(cl, ordermanager.WithTradingSchedule(calendar.NewSchedule(closed)))
+
+	req := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("1000"),
+	}
+	_, err := m.PlaceAndAwait(context.Background(), req)
+	if !errors.Is(err, ordermanager.ErrMarketClosed) {
+		t.Errorf("Expected %v, got %v", ordermanager.ErrMarketClosed, err)
+	}
+}
+
+func TestDuplicateDetection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-1,"message":"rejected"}`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+	m := ordermanager.New(cl, ordermanager.WithDuplicateDetection(ordermanager.DuplicateBlock, time.Minute))
+
+	order := func(id, price string) *valr.PostLimitOrderRequest {
+		return &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: valr.BUY, CustomerOrderID: id,
+			Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString(price),
+		}
+	}
+	ctx := context.Background()
+	if _, err := m.PlaceAndAwait(ctx, order("a", "1000")); err == nil || errors.Is(err, ordermanager.ErrDuplicateOrder) {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+	_, err := m.PlaceAndAwait(ctx, order("b", "1000"))
+	if !errors.Is(err, ordermanager.ErrDuplicateOrder) {
+		t.Errorf("Expected %v, got %v", ordermanager.ErrDuplicateOrder, err)
+	}
+	if _, err := m.PlaceAndAwait(ctx, order("c", "1001")); errors.Is(err, ordermanager.ErrDuplicateOrder) {
+		t.Errorf("Expected a different price not to be a duplicate, got %v", err)
+	}
+	ctx = ordermanager.WithDuplicateMode(ctx, ordermanager.DuplicateWarn)
+	if _, err := m.PlaceAndAwait(ctx, order("d", "1000")); errors.Is(err, ordermanager.ErrDuplicateOrder) {
+		t.Errorf("Expected duplicates to only be warned about, got %v", err)
+	}
+}
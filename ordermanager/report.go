@@ -0,0 +1,101 @@
+package ordermanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+type decisionPriceKey struct{}
+
+// WithDecisionPrice returns a context that records price as the decision
+// price of orders placed with it, the reference for slippage in
+// ExecutionReport.
+func WithDecisionPrice(ctx context.Context, price decimal.Decimal) context.Context {
+	return context.WithValue(ctx, decisionPriceKey{}, price)
+}
+
+// WithExecutionReports calls fn with the report of every order completed by
+// PlaceAndAwait.
+func WithExecutionReports(fn func(ExecutionReport)) Option {
+	return func(m *Manager) {
+		m.onReport = fn
+	}
+}
+
+// ExecutionReport summarises the latency and price quality of an order.
+// Durations are zero where either timestamp is unknown.
+type ExecutionReport struct {
+	OrderID         string
+	CustomerOrderID string
+	Pair            string
+	Side            valr.RequestSide
+	Status          string
+	FilledQuantity  decimal.Decimal
+	AveragePrice    decimal.Decimal
+	DecisionPrice   decimal.Decimal
+
+	// AckLatency is the time from submission to the placement response.
+	AckLatency time.Duration
+	// FirstFillLatency is the time from submission to the first fill.
+	FirstFillLatency time.Duration
+	// CompletionTime is the time from submission to a terminal status.
+	CompletionTime time.Duration
+
+	// Slippage is the fraction by which the average price is worse than the
+	// decision price, negative if better. It is zero without a decision
+	// price or fills.
+	Slippage decimal.Decimal
+	// SlippageCost is the slippage in the quote currency.
+	SlippageCost decimal.Decimal
+}
+
+// Report returns the execution's quality report.
+func (e *Execution) Report() ExecutionReport {
+	r := ExecutionReport{
+		OrderID:          e.OrderID,
+		CustomerOrderID:  e.CustomerOrderID,
+		Pair:             e.Pair,
+		Side:             e.Side,
+		Status:           e.Status,
+		FilledQuantity:   e.FilledQuantity,
+		AveragePrice:     e.AveragePrice,
+		DecisionPrice:    e.DecisionPrice,
+		AckLatency:       since(e.SubmittedAt, e.AckedAt),
+		FirstFillLatency: since(e.SubmittedAt, e.FirstFillAt),
+		CompletionTime:   since(e.SubmittedAt, e.DoneAt),
+	}
+	if e.DecisionPrice.IsPositive() && e.FilledQuantity.IsPositive() {
+		diff := e.AveragePrice.Sub(e.DecisionPrice)
+		if e.Side == valr.SELL {
+			diff = diff.Neg()
+		}
+		r.Slippage = diff.DivRound(e.DecisionPrice, 8)
+		r.SlippageCost = diff.Mul(e.FilledQuantity)
+	}
+	return r
+}
+
+func since(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// requestSide returns the side of an order request.
+func requestSide(req any) valr.RequestSide {
+	switch r := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		return r.Side
+	case *valr.PostMarketOrderBuyRequest:
+		return r.Side
+	case *valr.PostMarketOrderSellRequest:
+		return r.Side
+	case *valr.PostMarketOrderBaseAmountRequest:
+		return r.Side
+	}
+	return ""
+}
@@ -0,0 +1,401 @@
+package ordermanager
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/donohutcheon/valr-go/supervise"
+	"github.com/shopspring/decimal"
+)
+
+// ErrRiskLimit is returned for orders vetoed by the risk limits.
+var ErrRiskLimit = errors.New("ordermanager: risk limit exceeded")
+
+// notifyTimeout bounds the delivery of a breach notification.
+const notifyTimeout = 30 * time.Second
+
+// Limit names reported in LimitBreach.
+const (
+	LimitPosition      = "position"
+	LimitOrderNotional = "order_notional"
+	LimitDailyLoss     = "daily_loss"
+)
+
+// RiskLimits configures the checks made by Risk. Zero values disable a
+// limit.
+type RiskLimits struct {
+	// MaxPosition is the largest absolute net position allowed, in the
+	// base currency, keyed by pair.
+	MaxPosition map[string]decimal.Decimal
+	// MaxOrderNotional is the largest value of a single order, in its
+	// quote currency.
+	MaxOrderNotional decimal.Decimal
+	// MaxDailyLoss is the largest realised loss allowed per UTC day. Once
+	// reached, only orders that reduce a position are accepted. P&L is
+	// summed across pairs before fees, so all pairs should share a quote
+	// currency.
+	MaxDailyLoss decimal.Decimal
+}
+
+// Validate returns an error if any limit is negative.
+func (l RiskLimits) Validate() error {
+	for pair, max := range l.MaxPosition {
+		if max.IsNegative() {
+			return fmt.Errorf("ordermanager: negative max position %s for %s", max, pair)
+		}
+	}
+	if l.MaxOrderNotional.IsNegative() {
+		return fmt.Errorf("ordermanager: negative max order notional %s", l.MaxOrderNotional)
+	}
+	if l.MaxDailyLoss.IsNegative() {
+		return fmt.Errorf("ordermanager: negative max daily loss %s", l.MaxDailyLoss)
+	}
+	return nil
+}
+
+// LimitBreach reports an order vetoed by, or a fill that reached, a limit.
+type LimitBreach struct {
+	Limit string
+	// Pair is empty for the daily loss limit.
+	Pair  string
+	Value decimal.Decimal
+	Max   decimal.Decimal
+	Time  time.Time
+}
+
+// BreachCallback is called for each limit breach.
+type BreachCallback func(LimitBreach)
+
+type RiskOption func(*Risk)
+
+// WithBreachCallback sets a callback for limit breaches. It is called
+// without locks held.
+func WithBreachCallback(fn BreachCallback) RiskOption {
+	return func(r *Risk) {
+		r.onBreach = fn
+	}
+}
+
+// WithBreachNotifier delivers a notification of each limit breach to s. A
+// breach of the daily loss limit is critical, others are warnings.
+// Delivery doesn't block the order or fill that caused the breach.
+func WithBreachNotifier(s notify.Sink) RiskOption {
+	return func(r *Risk) {
+		r.notifier = s
+	}
+}
+
+// CallbackBreach names the breach callback, for supervise.WithPolicyFor.
+const CallbackBreach = "ordermanager.breach"
+
+// WithRiskSupervisor runs the breach callback under s, so a panic in it
+// doesn't propagate into the order or fill that caused the breach.
+func WithRiskSupervisor(s *supervise.Supervisor) RiskOption {
+	return func(r *Risk) {
+		r.supervisor = s
+	}
+}
+
+// WithRiskPrices sets the source of prices used to value orders that do not
+// carry one, such as market orders. By default the price of the latest fill
+// on the pair is used, and orders on pairs without fills are not valued.
+func WithRiskPrices(fn func(pair string) (decimal.Decimal, bool)) RiskOption {
+	return func(r *Risk) {
+		r.prices = fn
+	}
+}
+
+type position struct {
+	quantity decimal.Decimal
+	// cost is the average entry price of the open quantity.
+	cost      decimal.Decimal
+	lastPrice decimal.Decimal
+}
+
+// Risk tracks positions and realised P&L from fills and vetoes orders that
+// would exceed its limits. Positions only count filled quantities, not
+// resting orders.
+type Risk struct {
+	limits   RiskLimits
+	onBreach BreachCallback
+	notifier notify.Sink
+	prices   func(pair string) (decimal.Decimal, bool)
+	now      func() time.Time
+
+	supervisor *supervise.Supervisor
+
+	mu        sync.Mutex
+	positions map[string]*position
+	day       time.Time
+	realised  decimal.Decimal
+	tripped   bool
+	seen      map[string]bool
+}
+
+// WithRiskClock sets the clock of the daily P&L reset and breach times,
+// clock.Real by default.
+func WithRiskClock(c clock.Clock) RiskOption {
+	return func(r *Risk) {
+		r.now = c.Now
+	}
+}
+
+// NewRisk returns a Risk enforcing limits.
+func NewRisk(limits RiskLimits, opts ...RiskOption) *Risk {
+	r := &Risk{
+		limits:    limits,
+		now:       time.Now,
+		positions: make(map[string]*position),
+		seen:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithRisk vetoes orders that would exceed r's limits and feeds r the fills
+// passed to HandleFill.
+func WithRisk(r *Risk) Option {
+	return func(m *Manager) {
+		m.risk = r
+	}
+}
+
+// Limits returns the limits being enforced.
+func (r *Risk) Limits() RiskLimits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limits
+}
+
+// SetLimits replaces the limits, e.g. on a configuration reload, without
+// resetting positions or P&L. Raising the daily loss limit above today's
+// loss lifts the daily loss stop; lowering it below trips it.
+func (r *Risk) SetLimits(limits RiskLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	var breach *LimitBreach
+	r.mu.Lock()
+	r.rollDay()
+	limits.MaxPosition = maps.Clone(limits.MaxPosition)
+	r.limits = limits
+	max := limits.MaxDailyLoss
+	tripped := max.IsPositive() && r.realised.Neg().GreaterThanOrEqual(max)
+	if tripped && !r.tripped {
+		breach = &LimitBreach{Limit: LimitDailyLoss, Value: r.realised.Neg(), Max: max, Time: r.now()}
+	}
+	r.tripped = tripped
+	r.mu.Unlock()
+
+	if breach != nil {
+		r.breach(*breach)
+	}
+	return nil
+}
+
+// Position returns the net filled position on pair in the base currency,
+// negative if short.
+func (r *Risk) Position(pair string) decimal.Decimal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.positions[pair]; ok {
+		return p.quantity
+	}
+	return decimal.Zero
+}
+
+// RealisedPnL returns the P&L realised so far today, before fees.
+func (r *Risk) RealisedPnL() decimal.Decimal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollDay()
+	return r.realised
+}
+
+// rollDay resets the daily P&L at the start of each UTC day.
+func (r *Risk) rollDay() {
+	day := r.now().UTC().Truncate(24 * time.Hour)
+	if day.Equal(r.day) {
+		return
+	}
+	r.day = day
+	r.realised = decimal.Zero
+	r.tripped = false
+	r.seen = make(map[string]bool)
+}
+
+// HandleFill updates positions and realised P&L with f. Fills with a trade ID
+// already seen today are ignored.
+func (r *Risk) HandleFill(f Fill) {
+	var breach *LimitBreach
+	r.mu.Lock()
+	r.rollDay()
+	if f.TradeID != "" {
+		if r.seen[f.TradeID] {
+			r.mu.Unlock()
+			return
+		}
+		r.seen[f.TradeID] = true
+	}
+	p, ok := r.positions[f.Pair]
+	if !ok {
+		p = new(position)
+		r.positions[f.Pair] = p
+	}
+	qty := f.Quantity
+	if f.Side == valr.ResponseSideSell {
+		qty = qty.Neg()
+	}
+	r.realised = r.realised.Add(p.apply(qty, f.Price))
+	max := r.limits.MaxDailyLoss
+	if max.IsPositive() && !r.tripped && r.realised.Neg().GreaterThanOrEqual(max) {
+		r.tripped = true
+		breach = &LimitBreach{Limit: LimitDailyLoss, Value: r.realised.Neg(), Max: max, Time: r.now()}
+	}
+	r.mu.Unlock()
+
+	if breach != nil {
+		r.breach(*breach)
+	}
+}
+
+// apply adds a signed quantity traded at price to the position and returns
+// the P&L realised by any part of it that closes the position.
+func (p *position) apply(qty, price decimal.Decimal) decimal.Decimal {
+	p.lastPrice = price
+	realised := decimal.Zero
+	if !p.quantity.IsZero() && p.quantity.IsPositive() != qty.IsPositive() {
+		closed := decimal.Min(qty.Abs(), p.quantity.Abs())
+		pnl := price.Sub(p.cost).Mul(closed)
+		if p.quantity.IsNegative() {
+			pnl = pnl.Neg()
+		}
+		realised = pnl
+		if qty.IsPositive() {
+			p.quantity = p.quantity.Add(closed)
+			qty = qty.Sub(closed)
+		} else {
+			p.quantity = p.quantity.Sub(closed)
+			qty = qty.Add(closed)
+		}
+		if p.quantity.IsZero() {
+			p.cost = decimal.Zero
+		}
+	}
+	if !qty.IsZero() {
+		total := p.quantity.Add(qty)
+		p.cost = p.cost.Mul(p.quantity.Abs()).Add(price.Mul(qty.Abs())).DivRound(total.Abs(), 16)
+		p.quantity = total
+	}
+	return realised
+}
+
+// Check returns an error wrapping ErrRiskLimit if placing req would exceed
+// a limit. req must be one of the requests accepted by PlaceAndAwait.
+func (r *Risk) Check(req any) error {
+	var (
+		pair  string
+		side  valr.RequestSide
+		qty   decimal.Decimal
+		price decimal.Decimal
+		quote bool
+	)
+	switch o := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		pair, side, qty, price = o.Pair, o.Side, o.Quantity, o.Price
+	case *valr.PostMarketOrderBuyRequest:
+		pair, side, qty, quote = o.Pair, o.Side, o.Quantity, true
+	case *valr.PostMarketOrderSellRequest:
+		pair, side, qty = o.Pair, o.Side, o.Quantity
+	case *valr.PostMarketOrderBaseAmountRequest:
+		pair, side, qty = o.Pair, o.Side, o.Quantity
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedRequest, req)
+	}
+
+	r.mu.Lock()
+	r.rollDay()
+	var cur decimal.Decimal
+	p, ok := r.positions[pair]
+	if ok {
+		cur = p.quantity
+	}
+	if price.IsZero() {
+		if r.prices != nil {
+			price, _ = r.prices(pair)
+		} else if ok {
+			price = p.lastPrice
+		}
+	}
+	notional := qty.Mul(price)
+	if quote {
+		notional = qty
+		qty = decimal.Zero
+		if price.IsPositive() {
+			qty = notional.DivRound(price, 16)
+		}
+	}
+	if side == valr.SELL {
+		qty = qty.Neg()
+	}
+	next := cur.Add(qty)
+	reduces := next.Abs().LessThanOrEqual(cur.Abs())
+
+	var breach *LimitBreach
+	now := r.now()
+	switch {
+	case r.limits.MaxOrderNotional.IsPositive() && notional.GreaterThan(r.limits.MaxOrderNotional):
+		breach = &LimitBreach{Limit: LimitOrderNotional, Pair: pair, Value: notional, Max: r.limits.MaxOrderNotional, Time: now}
+	case r.tripped && !reduces:
+		breach = &LimitBreach{Limit: LimitDailyLoss, Value: r.realised.Neg(), Max: r.limits.MaxDailyLoss, Time: now}
+	case !reduces:
+		if max, ok := r.limits.MaxPosition[pair]; ok && next.Abs().GreaterThan(max) {
+			breach = &LimitBreach{Limit: LimitPosition, Pair: pair, Value: next.Abs(), Max: max, Time: now}
+		}
+	}
+	r.mu.Unlock()
+
+	if breach == nil {
+		return nil
+	}
+	r.breach(*breach)
+	return fmt.Errorf("%w: %s %s exceeds %s", ErrRiskLimit, breach.Limit, breach.Value, breach.Max)
+}
+
+func (r *Risk) breach(b LimitBreach) {
+	if r.onBreach != nil {
+		r.supervisor.Call(CallbackBreach, func() { r.onBreach(b) })
+	}
+	if r.notifier != nil {
+		notify.Send(r.notifier, breachMessage(b), notifyTimeout)
+	}
+}
+
+func breachMessage(b LimitBreach) notify.Message {
+	m := notify.Message{
+		Level:  notify.Warning,
+		Source: "risk",
+		Title:  fmt.Sprintf("Risk limit %s breached", b.Limit),
+		Fields: []notify.Field{
+			{Name: "value", Value: b.Value.String()},
+			{Name: "max", Value: b.Max.String()},
+		},
+		Time: b.Time,
+	}
+	if b.Limit == LimitDailyLoss {
+		m.Level = notify.Critical
+		m.Text = "Only orders reducing a position are accepted until the next day."
+	}
+	if b.Pair != "" {
+		m.Fields = append([]notify.Field{{Name: "pair", Value: b.Pair}}, m.Fields...)
+	}
+	return m
+}
@@ -0,0 +1,150 @@
+package ordermanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+func TestRisk(t *testing.T) {
+	var breaches []ordermanager.LimitBreach
+	r := ordermanager.NewRisk(ordermanager.RiskLimits{
+		MaxPosition:      map[string]decimal.Decimal{"BTCZAR": decimal.RequireFromString("2")},
+		MaxOrderNotional: decimal.RequireFromString("1000"),
+		MaxDailyLoss:     decimal.RequireFromString("50"),
+	}, ordermanager.WithBreachCallback(func(b ordermanager.LimitBreach) {
+		breaches = append(breaches, b)
+	}))
+
+	limit := func(side valr.RequestSide, qty, price string) *valr.PostLimitOrderRequest {
+		return &valr.PostLimitOrderRequest{
+			Pair: "BTCZAR", Side: side,
+			Quantity: decimal.RequireFromString(qty), Price: decimal.RequireFromString(price),
+		}
+	}
+	fill := func(id string, side valr.ResponseSide, qty, price string) {
+		r.HandleFill(ordermanager.Fill{
+			TradeID: id, Pair: "BTCZAR", Side: side,
+			Quantity: decimal.RequireFromString(qty), Price: decimal.RequireFromString(price),
+		})
+	}
+
+	if err := r.Check(limit(valr.BUY, "20", "100")); !errors.Is(err, ordermanager.ErrRiskLimit) {
+		t.Errorf("Expected notional breach, got %v", err)
+	}
+
+	fill("t1", valr.ResponseSideBuy, "2", "100")
+	fill("t1", valr.ResponseSideBuy, "2", "100")
+	if got := r.Position("BTCZAR"); !got.Equal(decimal.RequireFromString("2")) {
+		t.Errorf("Expected position 2, got %s", got)
+	}
+	if err := r.Check(limit(valr.BUY, "1", "100")); !errors.Is(err, ordermanager.ErrRiskLimit) {
+		t.Errorf("Expected position breach, got %v", err)
+	}
+	if err := r.Check(limit(valr.SELL, "1", "100")); err != nil {
+		t.Errorf("Expected reducing order to pass, got %v", err)
+	}
+
+	fill("t2", valr.ResponseSideSell, "1", "40")
+	if got := r.RealisedPnL(); !got.Equal(decimal.RequireFromString("-60")) {
+		t.Errorf("Expected realised P&L -60, got %s", got)
+	}
+	if err := r.Check(limit(valr.BUY, "0.5", "100")); !errors.Is(err, ordermanager.ErrRiskLimit) {
+		t.Errorf("Expected daily loss breach, got %v", err)
+	}
+	if err := r.Check(limit(valr.SELL, "1", "40")); err != nil {
+		t.Errorf("Expected reducing order to pass, got %v", err)
+	}
+
+	want := []string{
+		ordermanager.LimitOrderNotional,
+		ordermanager.LimitPosition,
+		ordermanager.LimitDailyLoss,
+		ordermanager.LimitDailyLoss,
+	}
+	if len(breaches) != len(want) {
+		t.Fatalf("Expected %d breaches, got %d", len(want), len(breaches))
+	}
+	for i, b := range breaches {
+		if b.Limit != want[i] {
+			t.Errorf("Expected breach %q, got %q", want[i], b.Limit)
+		}
+	}
+}
+
+func TestRiskSetLimits(t *testing.T) {
+	var breaches []ordermanager.LimitBreach
+	r := ordermanager.NewRisk(ordermanager.RiskLimits{}, ordermanager.WithBreachCallback(func(b ordermanager.LimitBreach) {
+		breaches = append(breaches, b)
+	}))
+	fill := func(id string, side valr.ResponseSide, price string) {
+		r.HandleFill(ordermanager.Fill{
+			TradeID: id, Pair: "BTCZAR", Side: side,
+			Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString(price),
+		})
+	}
+	fill("t1", valr.ResponseSideBuy, "100")
+	fill("t2", valr.ResponseSideSell, "70")
+
+	if err := r.SetLimits(ordermanager.RiskLimits{MaxDailyLoss: decimal.RequireFromString("-1")}); err == nil {
+		t.Errorf("Expected validation error")
+	}
+	if err := r.SetLimits(ordermanager.RiskLimits{MaxDailyLoss: decimal.RequireFromString("20")}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(breaches) != 1 || breaches[0].Limit != ordermanager.LimitDailyLoss {
+		t.Fatalf("Expected daily loss breach, got %+v", breaches)
+	}
+	buy := &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("70"),
+	}
+	if err := r.Check(buy); !errors.Is(err, ordermanager.ErrRiskLimit) {
+		t.Errorf("Expected daily loss veto, got %v", err)
+	}
+	if err := r.SetLimits(ordermanager.RiskLimits{MaxDailyLoss: decimal.RequireFromString("50")}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := r.Check(buy); err != nil {
+		t.Errorf("Expected success after raising the limit, got %v", err)
+	}
+}
+
+func TestRiskBreachNotifier(t *testing.T) {
+	msgs := make(chan notify.Message, 1)
+	r := ordermanager.NewRisk(ordermanager.RiskLimits{
+		MaxOrderNotional: decimal.RequireFromString("1000"),
+	}, ordermanager.WithBreachNotifier(notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+		msgs <- m
+		return nil
+	})))
+
+	err := r.Check(&valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("20"), Price: decimal.RequireFromString("100"),
+	})
+	if !errors.Is(err, ordermanager.ErrRiskLimit) {
+		t.Fatalf("Expected notional breach, got %v", err)
+	}
+
+	select {
+	case m := <-msgs:
+		if m.Level != notify.Warning {
+			t.Errorf("Expected %q, got %q", notify.Warning, m.Level)
+		}
+		if m.Source != "risk" {
+			t.Errorf("Expected %q, got %q", "risk", m.Source)
+		}
+		if len(m.Fields) == 0 || m.Fields[0].Name != "pair" || m.Fields[0].Value != "BTCZAR" {
+			t.Errorf("Expected pair field first, got %v", m.Fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected breach notification")
+	}
+}
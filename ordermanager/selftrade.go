@@ -0,0 +1,134 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/refdata"
+	"github.com/shopspring/decimal"
+)
+
+// ErrSelfTrade is returned for orders that would trade against one of the
+// account's own resting orders.
+var ErrSelfTrade = errors.New("ordermanager: order would trade against own resting order")
+
+// SelfTradeMode is how the manager treats orders that would trade against
+// the account's own resting orders.
+type SelfTradeMode int
+
+const (
+	// SelfTradeAllow places orders without checking.
+	SelfTradeAllow SelfTradeMode = iota
+	// SelfTradeBlock rejects such orders with ErrSelfTrade.
+	SelfTradeBlock
+	// SelfTradeAdjust reprices limit orders one tick inside the account's
+	// best opposite resting order. Market orders are blocked.
+	SelfTradeAdjust
+)
+
+// WithSelfTradePrevention checks each order against the account's open
+// orders before placing it, handling would-be self trades according to
+// mode. ref provides the tick sizes needed by SelfTradeAdjust and may be nil
+// otherwise. The mode can be overridden per call, e.g. per strategy, with
+// WithSelfTradeMode.
+func WithSelfTradePrevention(mode SelfTradeMode, ref *refdata.Cache) Option {
+	return func(m *Manager) {
+		m.selfTrade = mode
+		m.refData = ref
+	}
+}
+
+type selfTradeModeKey struct{}
+
+// WithSelfTradeMode returns a context that overrides the manager's self
+// trade mode for orders placed with it.
+func WithSelfTradeMode(ctx context.Context, mode SelfTradeMode) context.Context {
+	return context.WithValue(ctx, selfTradeModeKey{}, mode)
+}
+
+func (m *Manager) selfTradeMode(ctx context.Context) SelfTradeMode {
+	if mode, ok := ctx.Value(selfTradeModeKey{}).(SelfTradeMode); ok {
+		return mode
+	}
+	return m.selfTrade
+}
+
+// preventSelfTrade checks req against the account's open orders on its pair,
+// returning the request to place, which may be an adjusted copy.
+func (m *Manager) preventSelfTrade(ctx context.Context, req any, pair string) (any, error) {
+	mode := m.selfTradeMode(ctx)
+	if mode == SelfTradeAllow {
+		return req, nil
+	}
+
+	var (
+		side  valr.RequestSide
+		price decimal.Decimal
+		limit *valr.PostLimitOrderRequest
+	)
+	switch r := req.(type) {
+	case *valr.PostLimitOrderRequest:
+		side, price, limit = r.Side, r.Price, r
+	case *valr.PostMarketOrderBuyRequest:
+		side = r.Side
+	case *valr.PostMarketOrderSellRequest:
+		side = r.Side
+	case *valr.PostMarketOrderBaseAmountRequest:
+		side = r.Side
+	}
+
+	orders, err := m.client.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	// best is the account's most aggressive resting order on the opposite
+	// side, the first one an incoming order would reach.
+	var best *valr.OpenOrder
+	for i, o := range orders {
+		if o.Pair != pair {
+			continue
+		}
+		if side == valr.BUY && o.Side == valr.ResponseSideSell {
+			if best == nil || o.Price.LessThan(best.Price) {
+				best = &orders[i]
+			}
+		} else if side == valr.SELL && o.Side == valr.ResponseSideBuy {
+			if best == nil || o.Price.GreaterThan(best.Price) {
+				best = &orders[i]
+			}
+		}
+	}
+	if best == nil {
+		return req, nil
+	}
+	if limit != nil {
+		crosses := (side == valr.BUY && price.GreaterThanOrEqual(best.Price)) ||
+			(side == valr.SELL && price.LessThanOrEqual(best.Price))
+		if !crosses {
+			return req, nil
+		}
+	}
+	if mode == SelfTradeBlock || limit == nil {
+		return nil, fmt.Errorf("%w: %s at %s", ErrSelfTrade, best.OrderID, best.Price)
+	}
+
+	if m.refData == nil {
+		return nil, fmt.Errorf("%w: no reference data to adjust price", ErrSelfTrade)
+	}
+	info, err := m.refData.Pair(ctx, pair)
+	if err != nil {
+		return nil, err
+	}
+	adjusted := *limit
+	if side == valr.BUY {
+		adjusted.Price = best.Price.Sub(info.TickSize)
+	} else {
+		adjusted.Price = best.Price.Add(info.TickSize)
+	}
+	if !adjusted.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: %s at %s", ErrSelfTrade, best.OrderID, best.Price)
+	}
+	return &adjusted, nil
+}
@@ -0,0 +1,170 @@
+package ordermanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/donohutcheon/valr-go/refdata"
+	"github.com/shopspring/decimal"
+)
+
+// selfTradeServer serves the account's resting BTCZAR sells at 100 and 105,
+// filling each order placed, and returns the prices of the orders placed.
+func selfTradeServer(t *testing.T, opts ...func(*valr.Client) ordermanager.Option) (*ordermanager.Manager, func() []string) {
+	t.Helper()
+	var (
+		m      *ordermanager.Manager
+		mu     sync.Mutex
+		placed []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /orders/open":
+			w.Write([]byte(`[
+				{"orderId":"r1","side":"sell","price":"105","currencyPair":"BTCZAR"},
+				{"orderId":"r2","side":"sell","price":"100","currencyPair":"BTCZAR"},
+				{"orderId":"r3","side":"buy","price":"99","currencyPair":"BTCZAR"},
+				{"orderId":"r4","side":"sell","price":"50","currencyPair":"ETHZAR"}
+			]`))
+		case "GET /public/pairs":
+			w.Write([]byte(`[{"symbol":"BTCZAR","baseCurrency":"BTC","quoteCurrency":"ZAR","active":true,"tickSize":"1"}]`))
+		case "POST /orders/limit", "POST /orders/market":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			placed = append(placed, fmt.Sprint(body["price"]))
+			id := fmt.Sprintf("o%d", len(placed))
+			mu.Unlock()
+			m.HandleFill(ordermanager.Fill{
+				TradeID: "t" + id, OrderID: id, Pair: "BTCZAR",
+				Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1"),
+			})
+			m.HandleOrderUpdate(ordermanager.OrderUpdate{
+				OrderID: id, Status: ordermanager.StatusFilled,
+				OriginalQuantity: decimal.RequireFromString("1"),
+			})
+			w.Write([]byte(`{"id":"` + id + `"}`))
+		default:
+			t.Errorf("Unexpected call %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	mopts := []ordermanager.Option{ordermanager.WithSettleTimeout(time.Minute)}
+	for _, opt := range opts {
+		mopts = append(mopts, opt(cl))
+	}
+	m = ordermanager.New(cl, mopts...)
+	return m, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), placed...)
+	}
+}
+
+func selfTradePrevention(mode ordermanager.SelfTradeMode) func(*valr.Client) ordermanager.Option {
+	return func(cl *valr.Client) ordermanager.Option {
+		return ordermanager.WithSelfTradePrevention(mode, refdata.New(cl))
+	}
+}
+
+func limitBuy(price string) *valr.PostLimitOrderRequest {
+	return &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString(price),
+	}
+}
+
+func TestSelfTradeBlock(t *testing.T) {
+	m, placed := selfTradeServer(t, selfTradePrevention(ordermanager.SelfTradeBlock))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Buying at or above the account's best resting sell is rejected.
+	for _, price := range []string{"100", "120"} {
+		if _, err := m.PlaceAndAwait(ctx, limitBuy(price)); !errors.Is(err, ordermanager.ErrSelfTrade) {
+			t.Errorf("Expected ErrSelfTrade buying at %s, got %v", price, err)
+		}
+	}
+	// So is a market buy, which would reach it.
+	_, err := m.PlaceAndAwait(ctx, &valr.PostMarketOrderBaseAmountRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.RequireFromString("1"),
+	})
+	if !errors.Is(err, ordermanager.ErrSelfTrade) {
+		t.Errorf("Expected ErrSelfTrade for a market buy, got %v", err)
+	}
+	// A buy below it is placed unchanged.
+	if _, err := m.PlaceAndAwait(ctx, limitBuy("99")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if p := placed(); len(p) != 1 || p[0] != "99" {
+		t.Errorf("Expected only the buy at 99 placed, got %q", p)
+	}
+}
+
+func TestSelfTradeAdjust(t *testing.T) {
+	m, placed := selfTradeServer(t, selfTradePrevention(ordermanager.SelfTradeAdjust))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The buy is repriced one tick inside the best resting sell.
+	if _, err := m.PlaceAndAwait(ctx, limitBuy("101")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// Market orders cannot be repriced.
+	_, err := m.PlaceAndAwait(ctx, &valr.PostMarketOrderBaseAmountRequest{
+		Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.RequireFromString("1"),
+	})
+	if !errors.Is(err, ordermanager.ErrSelfTrade) {
+		t.Errorf("Expected ErrSelfTrade for a market buy, got %v", err)
+	}
+	if p := placed(); len(p) != 1 || p[0] != "99" {
+		t.Errorf("Expected the buy placed at 99, got %q", p)
+	}
+}
+
+func TestSelfTradeModeOverride(t *testing.T) {
+	m, placed := selfTradeServer(t, selfTradePrevention(ordermanager.SelfTradeBlock))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	allow := ordermanager.WithSelfTradeMode(ctx, ordermanager.SelfTradeAllow)
+	if _, err := m.PlaceAndAwait(allow, limitBuy("101")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	adjust := ordermanager.WithSelfTradeMode(ctx, ordermanager.SelfTradeAdjust)
+	if _, err := m.PlaceAndAwait(adjust, limitBuy("101")); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// Without an override the manager's mode applies.
+	if _, err := m.PlaceAndAwait(ctx, limitBuy("101")); !errors.Is(err, ordermanager.ErrSelfTrade) {
+		t.Errorf("Expected ErrSelfTrade, got %v", err)
+	}
+	if p := placed(); len(p) != 2 || p[0] != "101" || p[1] != "99" {
+		t.Errorf("Expected buys placed at 101 and 99, got %q", p)
+	}
+
+	// An override also enables prevention on a manager without it.
+	m, _ = selfTradeServer(t)
+	block := ordermanager.WithSelfTradeMode(ctx, ordermanager.SelfTradeBlock)
+	if _, err := m.PlaceAndAwait(block, limitBuy("101")); !errors.Is(err, ordermanager.ErrSelfTrade) {
+		t.Errorf("Expected ErrSelfTrade, got %v", err)
+	}
+}
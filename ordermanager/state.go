@@ -0,0 +1,209 @@
+package ordermanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// State is a snapshot of a Manager's in-memory state, for persisting across
+// deploys. It is JSON serialisable.
+type State struct {
+	Orders []OrderState `json:"orders"`
+	// Placements are orders that were being placed when the state was
+	// exported, whose order IDs were not yet known. They should be
+	// reconciled by customer order ID.
+	Placements []Placement    `json:"placements,omitempty"`
+	Brackets   []BracketState `json:"brackets,omitempty"`
+	Updates    []OrderUpdate  `json:"updates,omitempty"`
+	Fills      []Fill         `json:"fills,omitempty"`
+}
+
+// OrderState is a tracked order in progress.
+type OrderState struct {
+	Execution *Execution `json:"execution"`
+	// ExpectedFilled is the filled quantity reported by the latest status
+	// update.
+	ExpectedFilled decimal.Decimal `json:"expectedFilled"`
+}
+
+// Placement is an order placement in progress.
+type Placement struct {
+	Pair            string `json:"pair"`
+	CustomerOrderID string `json:"customerOrderId"`
+}
+
+// BracketState links a bracket's filled entry to its target order.
+type BracketState struct {
+	Entry       *Execution       `json:"entry"`
+	Side        valr.RequestSide `json:"side"`
+	StopPrice   decimal.Decimal  `json:"stopPrice"`
+	TargetPrice decimal.Decimal  `json:"targetPrice"`
+	TargetID    string           `json:"targetId"`
+	Stopping    bool             `json:"stopping"`
+	// Stopped is true once the stop has closed the position.
+	Stopped     bool   `json:"stopped"`
+	StopOrderID string `json:"stopOrderId,omitempty"`
+}
+
+// Export returns a snapshot of the orders in progress, pending placements,
+// open brackets and buffered events for unknown orders.
+func (m *Manager) Export() *State {
+	m.mu.Lock()
+	st := new(State)
+	for _, t := range m.orders {
+		st.Orders = append(st.Orders, OrderState{Execution: t.exec.clone(), ExpectedFilled: t.expected})
+	}
+	for _, p := range m.placements {
+		st.Placements = append(st.Placements, p)
+	}
+	for _, id := range m.orphanOrder {
+		for _, ev := range m.orphans[id] {
+			switch ev := ev.(type) {
+			case OrderUpdate:
+				st.Updates = append(st.Updates, ev)
+			case Fill:
+				st.Fills = append(st.Fills, ev)
+			}
+		}
+	}
+	brackets := make([]*Bracket, 0, len(m.brackets))
+	for _, b := range m.brackets {
+		brackets = append(brackets, b)
+	}
+	m.mu.Unlock()
+
+	// Bracket locks are taken before the manager's, so collect their state
+	// without holding it.
+	for _, b := range brackets {
+		st.Brackets = append(st.Brackets, b.state())
+	}
+	return st
+}
+
+// Import restores a snapshot made by Export, typically into a new Manager
+// before the account stream resumes so that no events are missed. Restored
+// orders can be awaited with Await, and restored brackets are returned for
+// the caller to feed prices to and wait on.
+func (m *Manager) Import(st *State) ([]*Bracket, error) {
+	for _, o := range st.Orders {
+		if o.Execution == nil || o.Execution.OrderID == "" {
+			return nil, fmt.Errorf("ordermanager: imported order has no ID")
+		}
+	}
+
+	m.mu.Lock()
+	for _, o := range st.Orders {
+		t := newTracked(o.Execution.clone())
+		t.expected = o.ExpectedFilled
+		m.orders[o.Execution.OrderID] = t
+	}
+	for _, p := range st.Placements {
+		m.nextPlacement++
+		m.placements[m.nextPlacement] = p
+	}
+	m.mu.Unlock()
+
+	// Replay buffered events through the handlers so they are matched with
+	// restored orders or buffered again.
+	for _, u := range st.Updates {
+		m.HandleOrderUpdate(u)
+	}
+	for _, f := range st.Fills {
+		m.HandleFill(f)
+	}
+
+	var brackets []*Bracket
+	for _, bs := range st.Brackets {
+		b := &Bracket{
+			m:           m,
+			Entry:       bs.Entry,
+			Side:        bs.Side,
+			StopPrice:   bs.StopPrice,
+			TargetPrice: bs.TargetPrice,
+			TargetID:    bs.TargetID,
+			stopping:    bs.Stopping,
+			stopOrderID: bs.StopOrderID,
+			stopped:     make(chan struct{}),
+		}
+		if bs.Stopped {
+			close(b.stopped)
+		}
+		m.mu.Lock()
+		t, ok := m.orders[bs.TargetID]
+		m.mu.Unlock()
+		if !ok {
+			t = m.track(bs.TargetID, bs.Entry.Pair, "")
+		}
+		b.target = t
+		m.addBracket(b)
+		brackets = append(brackets, b)
+	}
+	return brackets, nil
+}
+
+// Await blocks until a tracked order, such as one restored by Import,
+// completes or ctx expires. The order is no longer tracked afterwards.
+func (m *Manager) Await(ctx context.Context, orderID string) (*Execution, error) {
+	m.mu.Lock()
+	t, ok := m.orders[orderID]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ordermanager: order %s is not tracked", orderID)
+	}
+	defer func() {
+		m.untrack(orderID)
+		m.forget(ctx, orderID)
+	}()
+	return m.await(ctx, t)
+}
+
+// beginPlacement records an order placement in progress, returning a
+// function that removes it.
+func (m *Manager) beginPlacement(pair, customerOrderID string) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextPlacement++
+	id := m.nextPlacement
+	m.placements[id] = Placement{Pair: pair, CustomerOrderID: customerOrderID}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.placements, id)
+	}
+}
+
+func (m *Manager) addBracket(b *Bracket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.brackets[b.TargetID] = b
+}
+
+func (m *Manager) removeBracket(b *Bracket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.brackets, b.TargetID)
+}
+
+func (b *Bracket) state() BracketState {
+	var stopped bool
+	select {
+	case <-b.stopped:
+		stopped = true
+	default:
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BracketState{
+		Entry:       b.Entry.clone(),
+		Side:        b.Side,
+		StopPrice:   b.StopPrice,
+		TargetPrice: b.TargetPrice,
+		TargetID:    b.TargetID,
+		Stopping:    b.stopping,
+		Stopped:     stopped,
+		StopOrderID: b.stopOrderID,
+	}
+}
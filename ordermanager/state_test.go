@@ -0,0 +1,59 @@
+package ordermanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+func TestExportImport(t *testing.T) {
+	cl := valr.NewClient()
+	defer cl.Close()
+
+	m := ordermanager.New(cl, ordermanager.WithSettleTimeout(time.Minute))
+	_, err := m.Import(&ordermanager.State{
+		Orders: []ordermanager.OrderState{{
+			Execution: &ordermanager.Execution{OrderID: "o1", Pair: "BTCZAR", Status: ordermanager.StatusPlaced},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m.HandleFill(ordermanager.Fill{
+		TradeID: "t1", OrderID: "o1", Pair: "BTCZAR",
+		Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1"),
+	})
+
+	b, err := json.Marshal(m.Export())
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var st ordermanager.State
+	if err := json.Unmarshal(b, &st); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	restored := ordermanager.New(cl, ordermanager.WithSettleTimeout(time.Minute))
+	if _, err := restored.Import(&st); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	restored.HandleOrderUpdate(ordermanager.OrderUpdate{
+		OrderID: "o1", Status: ordermanager.StatusFilled,
+		OriginalQuantity: decimal.RequireFromString("1"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exec, err := restored.Await(ctx, "o1")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !exec.FilledQuantity.Equal(decimal.RequireFromString("1")) || exec.Status != ordermanager.StatusFilled {
+		t.Errorf("Expected filled execution, got %+v", exec)
+	}
+}
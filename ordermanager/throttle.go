@@ -0,0 +1,59 @@
+package ordermanager
+
+import "context"
+
+// WithPairSerialization serialises order mutations, i.e. placements and
+// cancellations made by the manager, per pair. This avoids an order racing
+// another on the same pair, such as crossing one's own resting order or
+// an amend overtaking a placement, while different pairs proceed in
+// parallel.
+func WithPairSerialization() Option {
+	return func(m *Manager) {
+		m.pairLocks = make(map[string]chan struct{})
+	}
+}
+
+// WithMaxInFlight limits the number of order mutations in flight across all
+// pairs to n.
+func WithMaxInFlight(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.inFlight = make(chan struct{}, n)
+		}
+	}
+}
+
+// pairLock returns the lock serialising mutations on pair.
+func (m *Manager) pairLock(pair string) chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.pairLocks[pair]
+	if !ok {
+		l = make(chan struct{}, 1)
+		m.pairLocks[pair] = l
+	}
+	return l
+}
+
+// mutate runs fn once the throttles allow a mutation on pair, or returns
+// ctx's error if that takes too long.
+func (m *Manager) mutate(ctx context.Context, pair string, fn func() error) error {
+	if m.pairLocks != nil {
+		l := m.pairLock(pair)
+		select {
+		case l <- struct{}{}:
+			defer func() { <-l }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if m.inFlight != nil {
+		select {
+		case m.inFlight <- struct{}{}:
+			defer func() { <-m.inFlight }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return fn()
+}
@@ -0,0 +1,134 @@
+package ordermanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+// throttleServer fills each order placed after a delay, returning the
+// manager and the most placements seen in progress at once, overall and per
+// pair.
+func throttleServer(t *testing.T, opts ...ordermanager.Option) (*ordermanager.Manager, func() (int, map[string]int)) {
+	t.Helper()
+	var (
+		m                    *ordermanager.Manager
+		mu                   sync.Mutex
+		placed, active, peak int
+		pairActive, pairPeak = make(map[string]int), make(map[string]int)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		pair := body["pair"].(string)
+
+		mu.Lock()
+		placed++
+		id := fmt.Sprintf("o%d", placed)
+		active++
+		pairActive[pair]++
+		peak = max(peak, active)
+		pairPeak[pair] = max(pairPeak[pair], pairActive[pair])
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		pairActive[pair]--
+		mu.Unlock()
+
+		m.HandleFill(ordermanager.Fill{
+			TradeID: "t" + id, OrderID: id, Pair: pair,
+			Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1"),
+		})
+		m.HandleOrderUpdate(ordermanager.OrderUpdate{
+			OrderID: id, Status: ordermanager.StatusFilled,
+			OriginalQuantity: decimal.RequireFromString("1"),
+		})
+		w.Write([]byte(`{"id":"` + id + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	m = ordermanager.New(cl, append([]ordermanager.Option{ordermanager.WithSettleTimeout(time.Minute)}, opts...)...)
+	return m, func() (int, map[string]int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return peak, pairPeak
+	}
+}
+
+// placeAll places a limit buy on each pair concurrently.
+func placeAll(t *testing.T, m *ordermanager.Manager, pairs ...string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, len(pairs))
+	for _, pair := range pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.PlaceAndAwait(ctx, &valr.PostLimitOrderRequest{
+				Pair: pair, Side: valr.BUY,
+				Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("100"),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected success, got %v", err)
+		}
+	}
+}
+
+func TestPairSerialization(t *testing.T) {
+	m, peaks := throttleServer(t, ordermanager.WithPairSerialization())
+	placeAll(t, m, "BTCZAR", "BTCZAR", "BTCZAR", "ETHZAR", "ETHZAR")
+
+	// Placements on one pair never overlap, but pairs proceed in parallel.
+	peak, pairPeak := peaks()
+	if pairPeak["BTCZAR"] != 1 || pairPeak["ETHZAR"] != 1 {
+		t.Errorf("Expected one placement at a time per pair, got %v", pairPeak)
+	}
+	if peak != 2 {
+		t.Errorf("Expected both pairs placed in parallel, got %d at once", peak)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	m, peaks := throttleServer(t, ordermanager.WithMaxInFlight(2))
+	placeAll(t, m, "BTCZAR", "BTCZAR", "BTCZAR", "ETHZAR", "XRPZAR", "SOLZAR")
+
+	if peak, _ := peaks(); peak != 2 {
+		t.Errorf("Expected 2 placements in flight at most, got %d", peak)
+	}
+}
+
+func TestUnthrottled(t *testing.T) {
+	m, peaks := throttleServer(t)
+	placeAll(t, m, "BTCZAR", "BTCZAR", "BTCZAR")
+
+	if _, pairPeak := peaks(); pairPeak["BTCZAR"] != 3 {
+		t.Errorf("Expected 3 placements on the pair at once, got %d", pairPeak["BTCZAR"])
+	}
+}
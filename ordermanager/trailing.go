@@ -0,0 +1,219 @@
+package ordermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.New(100, 0)
+
+// trailingNamespace holds the state of trailing stops.
+const trailingNamespace = "ordermanager.trailing"
+
+// TrailingStopConfig describes a trailing stop.
+type TrailingStopConfig struct {
+	Pair string
+	// Side is the side of the exit order: SELL protects a long position and
+	// BUY protects a short one.
+	Side     valr.RequestSide
+	Quantity decimal.Decimal
+	// TrailAmount is the absolute distance kept between the best price seen
+	// and the trigger. Exactly one of TrailAmount and TrailPercent must be
+	// set.
+	TrailAmount decimal.Decimal
+	// TrailPercent is the distance as a percentage of the best price seen,
+	// e.g. 2.5 for 2.5%.
+	TrailPercent decimal.Decimal
+	// LimitOffset, if set, exits with a limit order priced this far beyond
+	// the trigger instead of a market order.
+	LimitOffset     decimal.Decimal
+	CustomerOrderID string
+	// Store, if set, persists the stop's state under CustomerOrderID, which
+	// is then required, so it is restored by NewTrailingStop after a
+	// restart.
+	Store store.Store
+}
+
+// trailingState is the persisted state of a trailing stop.
+type trailingState struct {
+	Best    decimal.Decimal `json:"best"`
+	Trigger decimal.Decimal `json:"trigger"`
+	Fired   bool            `json:"fired"`
+	OrderID string          `json:"orderId"`
+}
+
+// TrailingStop emulates a trailing stop client side. The trigger ratchets as
+// the price moves favourably and never moves back; once the price crosses it
+// the exit order is placed.
+//
+// Prices are usually fed from a streaming connection, with Resync called
+// from its connect callback so that moves missed while disconnected are
+// accounted for:
+//
+//	conn, err := streaming.Dial(keyID, secret,
+//		streaming.WithConnectCallback(func(*streaming.Conn) { ts.Resync(ctx) }),
+//		streaming.WithUpdateCallback(func(u streaming.MessageTradeUpdate) {
+//			ts.OnPrice(ctx, u.Data.Price)
+//		}))
+type TrailingStop struct {
+	client *valr.Client
+	cfg    TrailingStopConfig
+
+	mu      sync.Mutex
+	best    decimal.Decimal
+	trigger decimal.Decimal
+	fired   bool
+	orderID string
+}
+
+// NewTrailingStop returns a trailing stop that places its exit using cl.
+func NewTrailingStop(cl *valr.Client, cfg TrailingStopConfig) (*TrailingStop, error) {
+	if cfg.Side != valr.BUY && cfg.Side != valr.SELL {
+		return nil, fmt.Errorf("ordermanager: invalid side %q", cfg.Side)
+	}
+	if !cfg.Quantity.IsPositive() {
+		return nil, errors.New("ordermanager: trailing stop quantity must be positive")
+	}
+	if cfg.TrailAmount.IsPositive() == cfg.TrailPercent.IsPositive() {
+		return nil, errors.New("ordermanager: exactly one of trail amount and trail percent must be set")
+	}
+	s := &TrailingStop{client: cl, cfg: cfg}
+	if cfg.Store == nil {
+		return s, nil
+	}
+	if cfg.CustomerOrderID == "" {
+		return nil, errors.New("ordermanager: a stored trailing stop requires a customer order ID")
+	}
+	var st trailingState
+	err := store.GetJSON(context.Background(), cfg.Store, trailingNamespace, cfg.CustomerOrderID, &st)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	s.best, s.trigger, s.fired, s.orderID = st.Best, st.Trigger, st.Fired, st.OrderID
+	return s, nil
+}
+
+// save persists the stop's state, if it has a store. It must be called with
+// s.mu held.
+func (s *TrailingStop) save(ctx context.Context) {
+	if s.cfg.Store == nil {
+		return
+	}
+	st := trailingState{Best: s.best, Trigger: s.trigger, Fired: s.fired, OrderID: s.orderID}
+	if err := store.PutJSON(ctx, s.cfg.Store, trailingNamespace, s.cfg.CustomerOrderID, st); err != nil {
+		log.Printf("valr/ordermanager: Failed to save trailing stop %s: %v", s.cfg.CustomerOrderID, err)
+	}
+}
+
+// Trigger returns the current trigger price, zero before the first price.
+func (s *TrailingStop) Trigger() decimal.Decimal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trigger
+}
+
+// Fired returns true and the exit order ID once the stop has fired.
+func (s *TrailingStop) Fired() (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fired, s.orderID
+}
+
+func (s *TrailingStop) trail(price decimal.Decimal) decimal.Decimal {
+	if s.cfg.TrailAmount.IsPositive() {
+		return s.cfg.TrailAmount
+	}
+	return price.Mul(s.cfg.TrailPercent).Div(hundred)
+}
+
+// OnPrice updates the stop with the latest traded price and places the exit
+// order if the trigger is crossed. It returns true if the stop fired on this
+// call. Prices received after the stop fired are ignored.
+func (s *TrailingStop) OnPrice(ctx context.Context, price decimal.Decimal) (bool, error) {
+	if !price.IsPositive() {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	if s.fired {
+		s.mu.Unlock()
+		return false, nil
+	}
+	long := s.cfg.Side == valr.SELL
+	if s.best.IsZero() || (long && price.GreaterThan(s.best)) || (!long && price.LessThan(s.best)) {
+		s.best = price
+		if long {
+			s.trigger = price.Sub(s.trail(price))
+		} else {
+			s.trigger = price.Add(s.trail(price))
+		}
+		s.save(ctx)
+	}
+	hit := (long && price.LessThanOrEqual(s.trigger)) || (!long && price.GreaterThanOrEqual(s.trigger))
+	if !hit {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.fired = true
+	trigger := s.trigger
+	s.mu.Unlock()
+
+	orderID, err := s.exit(ctx, trigger)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		// Allow a later price to retry the exit.
+		s.fired = false
+		return false, err
+	}
+	s.orderID = orderID
+	s.save(ctx)
+	return true, nil
+}
+
+// Resync fetches the last traded price over REST and applies it, e.g. after
+// a reconnect.
+func (s *TrailingStop) Resync(ctx context.Context) (bool, error) {
+	summary, err := s.client.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: s.cfg.Pair})
+	if err != nil {
+		return false, err
+	}
+	return s.OnPrice(ctx, summary.LastPrice)
+}
+
+func (s *TrailingStop) exit(ctx context.Context, trigger decimal.Decimal) (string, error) {
+	if s.cfg.LimitOffset.IsPositive() {
+		price := trigger.Sub(s.cfg.LimitOffset)
+		if s.cfg.Side == valr.BUY {
+			price = trigger.Add(s.cfg.LimitOffset)
+		}
+		res, err := s.client.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+			Pair:            s.cfg.Pair,
+			Side:            s.cfg.Side,
+			Quantity:        s.cfg.Quantity,
+			Price:           price,
+			CustomerOrderID: s.cfg.CustomerOrderID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return res.ID, nil
+	}
+	res, err := s.client.PostMarketBaseAmountRequest(ctx, &valr.PostMarketOrderBaseAmountRequest{
+		Pair:            s.cfg.Pair,
+		Side:            s.cfg.Side,
+		Quantity:        s.cfg.Quantity,
+		CustomerOrderID: s.cfg.CustomerOrderID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
@@ -0,0 +1,155 @@
+package ordermanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/ordermanager"
+	"github.com/shopspring/decimal"
+)
+
+// trailingServer serves a last traded price and records the exit orders
+// placed.
+func trailingServer(t *testing.T, last *atomic.Value) (*valr.Client, func() []string) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		exits []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "GET /public/BTCZAR/marketsummary":
+			w.Write([]byte(`{"currencyPair":"BTCZAR","lastTradedPrice":"` + last.Load().(string) + `"}`))
+		case "POST /orders/market":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			exits = append(exits, body["side"].(string)+" "+body["baseAmount"].(string))
+			w.Write([]byte(`{"id":"exit"}`))
+		default:
+			t.Errorf("Unexpected call %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), exits...)
+	}
+}
+
+func TestTrailingStop(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		side   valr.RequestSide
+		prices []string
+		// triggers are the trigger after each price.
+		triggers []string
+		// fires is the index of the price that fires the stop.
+		fires int
+	}{
+		{
+			name:     "long",
+			side:     valr.SELL,
+			prices:   []string{"100", "110", "105", "108", "115", "110", "100", "90"},
+			triggers: []string{"90", "100", "100", "100", "105", "105", "105", "105"},
+			fires:    6,
+		},
+		{
+			name:     "short",
+			side:     valr.BUY,
+			prices:   []string{"100", "90", "95", "85", "92", "96", "80"},
+			triggers: []string{"110", "100", "100", "95", "95", "95", "95"},
+			fires:    5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var last atomic.Value
+			last.Store("0")
+			cl, exits := trailingServer(t, &last)
+			s, err := ordermanager.NewTrailingStop(cl, ordermanager.TrailingStopConfig{
+				Pair: "BTCZAR", Side: tc.side,
+				Quantity:    decimal.RequireFromString("1"),
+				TrailAmount: decimal.RequireFromString("10"),
+			})
+			if err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			ctx := context.Background()
+			for i, price := range tc.prices {
+				fired, err := s.OnPrice(ctx, decimal.RequireFromString(price))
+				if err != nil {
+					t.Fatalf("Expected success, got %v", err)
+				}
+				// The stop fires once, and later prices are ignored.
+				if fired != (i == tc.fires) {
+					t.Errorf("Expected the stop at %s to fire %t, got %t", price, i == tc.fires, fired)
+				}
+				// The trigger only moves with favourable prices.
+				if got := s.Trigger().String(); got != tc.triggers[i] {
+					t.Errorf("Expected the trigger %s after %s, got %s", tc.triggers[i], price, got)
+				}
+			}
+			if fired, id := s.Fired(); !fired || id != "exit" {
+				t.Errorf("Expected the stop fired with the exit order, got %t, %q", fired, id)
+			}
+			if e := exits(); len(e) != 1 || e[0] != string(tc.side)+" 1" {
+				t.Errorf("Expected one %s exit for 1, got %q", tc.side, e)
+			}
+		})
+	}
+}
+
+func TestTrailingStopResync(t *testing.T) {
+	var last atomic.Value
+	last.Store("120")
+	cl, exits := trailingServer(t, &last)
+	s, err := ordermanager.NewTrailingStop(cl, ordermanager.TrailingStopConfig{
+		Pair: "BTCZAR", Side: valr.SELL,
+		Quantity:     decimal.RequireFromString("2"),
+		TrailPercent: decimal.RequireFromString("10"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+	if fired, err := s.OnPrice(ctx, decimal.RequireFromString("100")); fired || err != nil {
+		t.Fatalf("Expected the stop not to fire, got %t, %v", fired, err)
+	}
+
+	// The price rose while disconnected, raising the trigger.
+	if fired, err := s.Resync(ctx); fired || err != nil {
+		t.Fatalf("Expected the stop not to fire, got %t, %v", fired, err)
+	}
+	if got := s.Trigger().String(); got != "108" {
+		t.Errorf("Expected the trigger 108, got %s", got)
+	}
+
+	// The price then fell through the trigger during another gap.
+	last.Store("105")
+	if fired, err := s.Resync(ctx); !fired || err != nil {
+		t.Fatalf("Expected the stop to fire, got %t, %v", fired, err)
+	}
+	if fired, err := s.Resync(ctx); fired || err != nil {
+		t.Errorf("Expected the stop to fire once, got %t, %v", fired, err)
+	}
+	if e := exits(); len(e) != 1 || e[0] != "SELL 2" {
+		t.Errorf("Expected one SELL exit for 2, got %q", e)
+	}
+}
@@ -0,0 +1,261 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	// defaultHistoryLimit is the page size VALR uses for history endpoints
+	// when no limit is requested.
+	defaultHistoryLimit = 100
+	// defaultWalletHistoryLimit is the page size VALR uses for deposit and
+	// withdrawal history when no limit is requested.
+	defaultWalletHistoryLimit = 10
+)
+
+// Page is a single page of a paginated list response, along with the
+// parameters to request the following page.
+type Page[T any] struct {
+	Items []T
+	// Returned is the number of items in this page.
+	Returned int
+	// Skip and Limit are the parameters this page was requested with. Limit
+	// is the effective page size if none was requested.
+	Skip  int
+	Limit int
+	// HasMore is true if a full page was returned, so a further page may
+	// exist.
+	HasMore bool
+	// NextSkip is the skip value for the next page.
+	NextSkip int
+	// NextBeforeID is the cursor for the next page on endpoints that support
+	// beforeId pagination; empty otherwise.
+	NextBeforeID string
+}
+
+func newPage[T any](items []T, skip, limit, defaultLimit int) *Page[T] {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	return &Page[T]{
+		Items:    items,
+		Returned: len(items),
+		Skip:     skip,
+		Limit:    limit,
+		HasMore:  len(items) >= limit,
+		NextSkip: skip + len(items),
+	}
+}
+
+// GetTransactionHistoryPage is like GetTransactionHistoryRequest but returns
+// the results in a Page.
+func (cl *Client) GetTransactionHistoryPage(ctx context.Context, req *GetTransactionHistoryRequest) (*Page[TransactionInfo], error) {
+	res, err := cl.GetTransactionHistoryRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(res, req.Skip, req.Limit, defaultHistoryLimit), nil
+}
+
+// GetOrderHistoryPage is like GetOrderHistoryRequest but returns the results
+// in a Page.
+func (cl *Client) GetOrderHistoryPage(ctx context.Context, req *GetOrderHistoryRequest) (*Page[OrderReceipt], error) {
+	res, err := cl.GetOrderHistoryRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(res, req.Skip, req.Limit, defaultHistoryLimit), nil
+}
+
+// GetDepositHistoryForAssetPage is like GetDepositHistoryForAssetRequest but
+// returns the results in a Page.
+func (cl *Client) GetDepositHistoryForAssetPage(ctx context.Context, req *GetDepositHistoryForAssetRequest) (*Page[DepositInfo], error) {
+	res, err := cl.GetDepositHistoryForAssetRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(res, req.Skip, req.Limit, defaultWalletHistoryLimit), nil
+}
+
+// GetWithdrawHistoryForAssetPage is like GetWithdrawHistoryForAssetRequest
+// but returns the results in a Page.
+func (cl *Client) GetWithdrawHistoryForAssetPage(ctx context.Context, req *GetWithdrawHistoryForAssetRequest) (*Page[WithdrawInfo], error) {
+	res, err := cl.GetWithdrawHistoryForAssetRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(res, req.Skip, req.Limit, defaultWalletHistoryLimit), nil
+}
+
+// GetAuthTradeHistoryForPairPage is like GetAuthTradeHistoryForPairRequest
+// but returns the results in a Page. Trades are returned newest first, so
+// NextBeforeID is the ID of the oldest trade in the page.
+func (cl *Client) GetAuthTradeHistoryForPairPage(ctx context.Context, req *GetAuthTradeHistoryForPairRequest) (*Page[TradeHistoryInfo], error) {
+	res, err := cl.GetAuthTradeHistoryForPairRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	page := newPage(res, req.Skip, req.Limit, defaultHistoryLimit)
+	if len(res) > 0 {
+		page.NextBeforeID = res[len(res)-1].ID
+	}
+	return page, nil
+}
+
+// GetTradeHistoryPage is like GetTradeHistoryRequest but returns the results
+// in a Page, with NextBeforeID the ID of the oldest trade in the page.
+func (cl *Client) GetTradeHistoryPage(ctx context.Context, req *GetTradeHistoryRequest) (*Page[TradeHistoryInfo], error) {
+	res, err := cl.GetTradeHistoryRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	page := newPage(res, req.Skip, req.Limit, defaultHistoryLimit)
+	if len(res) > 0 {
+		page.NextBeforeID = res[len(res)-1].ID
+	}
+	return page, nil
+}
+
+type pageConfig struct {
+	concurrency int
+}
+
+type PageOption func(*pageConfig)
+
+// WithPageConcurrency sets how many pages are fetched at the same time. The
+// default of 1 fetches pages sequentially. Up to n-1 pages past the last
+// may be requested before the end of the list is known, so keep n small
+// for lists that are usually short.
+func WithPageConcurrency(n int) PageOption {
+	return func(c *pageConfig) {
+		c.concurrency = n
+	}
+}
+
+// FetchPages calls fetch for consecutive pages of limit items, starting at
+// skip 0, until a page has fewer than limit items. It returns the items of
+// all pages in page order, whatever order they were fetched in. On error
+// the remaining pages are cancelled and the first error is returned. All
+// fetches made through a Client share its rate limiter.
+func FetchPages[T any](ctx context.Context, limit int,
+	fetch func(ctx context.Context, skip, limit int) ([]T, error), opts ...PageOption) ([]T, error) {
+
+	cfg := pageConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+	if limit <= 0 {
+		return nil, errors.New("valr: page limit must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, cfg.concurrency)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		results  = make(map[int][]T)
+		// last is the index of the first page found to be short, or -1.
+		last = -1
+	)
+	done := func(i int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return last >= 0 && i > last
+	}
+	for i := 0; ; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil || done(i) {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := fetch(ctx, i*limit, limit)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			results[i] = res
+			if len(res) < limit && (last < 0 || i < last) {
+				last = i
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var merged []T
+	for i := 0; i <= last; i++ {
+		merged = append(merged, results[i]...)
+	}
+	return merged, nil
+}
+
+// GetTransactionHistoryAll fetches every page of the transaction history
+// matching req, newest first. req.Skip is ignored; req.Limit sets the page
+// size.
+func (cl *Client) GetTransactionHistoryAll(ctx context.Context, req *GetTransactionHistoryRequest, opts ...PageOption) ([]TransactionInfo, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return FetchPages(ctx, limit, func(ctx context.Context, skip, limit int) ([]TransactionInfo, error) {
+		page := *req
+		page.Skip, page.Limit = skip, limit
+		return cl.GetTransactionHistoryRequest(ctx, &page)
+	}, opts...)
+}
+
+// GetOrderHistoryAll fetches every page of the order history, newest first.
+// req.Skip is ignored; req.Limit sets the page size.
+func (cl *Client) GetOrderHistoryAll(ctx context.Context, req *GetOrderHistoryRequest, opts ...PageOption) ([]OrderReceipt, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return FetchPages(ctx, limit, func(ctx context.Context, skip, limit int) ([]OrderReceipt, error) {
+		page := *req
+		page.Skip, page.Limit = skip, limit
+		return cl.GetOrderHistoryRequest(ctx, &page)
+	}, opts...)
+}
+
+// GetAuthTradeHistoryForPairAll fetches every page of the trade history
+// matching req, newest first. req.Skip is ignored; req.Limit sets the page
+// size.
+func (cl *Client) GetAuthTradeHistoryForPairAll(ctx context.Context, req *GetAuthTradeHistoryForPairRequest, opts ...PageOption) ([]TradeHistoryInfo, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return FetchPages(ctx, limit, func(ctx context.Context, skip, limit int) ([]TradeHistoryInfo, error) {
+		page := *req
+		page.Skip, page.Limit = skip, limit
+		return cl.GetAuthTradeHistoryForPairRequest(ctx, &page)
+	}, opts...)
+}
@@ -0,0 +1,134 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestFetchPagesOrder(t *testing.T) {
+	const total = 23
+	var calls, inflight, peak atomic.Int32
+	fetch := func(ctx context.Context, skip, limit int) ([]int, error) {
+		calls.Add(1)
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		// Later pages finish first.
+		time.Sleep(time.Duration(10-skip/5) * time.Millisecond)
+		var items []int
+		for i := skip; i < min(skip+limit, total); i++ {
+			items = append(items, i)
+		}
+		return items, nil
+	}
+
+	items, err := valr.FetchPages(context.Background(), 5, fetch, valr.WithPageConcurrency(3))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(items) != total {
+		t.Fatalf("Expected %d items, got %d", total, len(items))
+	}
+	for i, item := range items {
+		if item != i {
+			t.Fatalf("Expected item %d at %d, got %d", i, i, item)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most %d concurrent fetches, got %d", 3, peak.Load())
+	}
+	// 5 pages are needed and at most 2 more are speculative.
+	if c := calls.Load(); c < 5 || c > 7 {
+		t.Errorf("Expected 5 to 7 fetches, got %d", c)
+	}
+}
+
+func TestFetchPagesError(t *testing.T) {
+	fail := errors.New("boom")
+	_, err := valr.FetchPages(context.Background(), 5, func(ctx context.Context, skip, limit int) ([]int, error) {
+		if skip == 10 {
+			return nil, fail
+		}
+		return make([]int, limit), nil
+	}, valr.WithPageConcurrency(2))
+	if !errors.Is(err, fail) {
+		t.Errorf("Expected %v, got %v", fail, err)
+	}
+}
+
+func TestGetTransactionHistoryAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if got := r.URL.Query().Get("currency"); got != "ZAR" {
+			t.Errorf("Expected %q, got %q", "ZAR", got)
+		}
+		var items []string
+		for i := skip; i < min(skip+limit, 7); i++ {
+			items = append(items, fmt.Sprintf(`{"id":"%d"}`, i))
+		}
+		w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+
+	res, err := cl.GetTransactionHistoryAll(context.Background(),
+		&valr.GetTransactionHistoryRequest{Currency: "ZAR", Limit: 3}, valr.WithPageConcurrency(2))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	var ids []string
+	for _, tx := range res {
+		ids = append(ids, tx.ID)
+	}
+	if got := strings.Join(ids, ","); got != "0,1,2,3,4,5,6" {
+		t.Errorf("Expected %q, got %q", "0,1,2,3,4,5,6", got)
+	}
+}
+
+func TestTradeHistoryCursors(t *testing.T) {
+	cl, last, _ := recordingServer(t, `[]`)
+	ctx := context.Background()
+
+	_, err := cl.GetAuthTradeHistoryForPairRequest(ctx, &valr.GetAuthTradeHistoryForPairRequest{
+		Pair: "BTCZAR", Limit: 10, BeforeID: "a1b2-c3&d4",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if last.URL.Path != "/marketdata/BTCZAR/tradehistory" {
+		t.Errorf("Expected %q, got %q", "/marketdata/BTCZAR/tradehistory", last.URL.Path)
+	}
+	q := last.URL.Query()
+	if got := q.Get("beforeId"); got != "a1b2-c3&d4" {
+		t.Errorf("Expected %q, got %q", "a1b2-c3&d4", got)
+	}
+	if q.Has("afterId") {
+		t.Errorf("Expected unset cursor to be omitted, got %s", last.URL.RawQuery)
+	}
+
+	_, err = cl.GetTradeHistoryRequest(ctx, &valr.GetTradeHistoryRequest{Pair: "BTCZAR", AfterID: "e5"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if last.URL.Path != "/public/BTCZAR/trades" {
+		t.Errorf("Expected %q, got %q", "/public/BTCZAR/trades", last.URL.Path)
+	}
+	if got := last.URL.RawQuery; got != "afterId=e5" {
+		t.Errorf("Expected %q, got %q", "afterId=e5", got)
+	}
+}
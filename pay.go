@@ -0,0 +1,35 @@
+package valr
+
+// Recipient returns whichever of the recipient's email, cell number and Pay
+// ID is set.
+func (r *PostPayRequest) Recipient() string {
+	switch {
+	case r.RecipientEmail != "":
+		return r.RecipientEmail
+	case r.RecipientCellNumber != "":
+		return r.RecipientCellNumber
+	default:
+		return r.RecipientPayID
+	}
+}
+
+// Validate checks the request for missing fields and that exactly one
+// recipient is set.
+func (r *PostPayRequest) Validate() error {
+	if r.Currency == "" {
+		return &ValidationError{Field: "currency", Reason: "required"}
+	}
+	if !r.Amount.IsPositive() {
+		return &ValidationError{Field: "amount", Reason: "must be positive"}
+	}
+	n := 0
+	for _, s := range []string{r.RecipientEmail, r.RecipientCellNumber, r.RecipientPayID} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return &ValidationError{Field: "recipient", Reason: "exactly one of recipientEmail, recipientCellNumber and recipientPayId must be set"}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+// Package payouts makes bulk VALR Pay payments, such as payroll or affiliate
+// payouts, from a list of recipients. Payments are checked against the Pay
+// limits up front, made one at a time and recorded in a store, so a run can
+// be repeated after a failure without paying anyone twice.
+package payouts
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+// Statuses of the payouts in a report.
+const (
+	// StatusPaid payouts were accepted by VALR in this run.
+	StatusPaid = "PAID"
+	// StatusSkipped payouts were paid by an earlier run.
+	StatusSkipped = "SKIPPED"
+	// StatusRejected payouts were rejected before or on submission and
+	// were not paid. They may be corrected and run again.
+	StatusRejected = "REJECTED"
+	// StatusUnknown payouts were sent but the outcome is unknown, e.g.
+	// after a timeout. They are not retried by later runs and must be
+	// reconciled by hand.
+	StatusUnknown = "UNKNOWN"
+	// StatusPending payouts were not attempted because the run stopped.
+	StatusPending = "PENDING"
+)
+
+const (
+	// paymentsNamespace holds the record of each payout, keyed by ref.
+	paymentsNamespace = "payouts.payments"
+
+	defaultAttempts = 3
+	defaultBackoff  = time.Second
+)
+
+var (
+	// ErrDuplicateRef is returned for payouts sharing a ref, or reusing the
+	// ref of a different earlier payout.
+	ErrDuplicateRef = errors.New("payouts: duplicate ref")
+	// ErrPayLimit is returned for payouts outside the Pay limits.
+	ErrPayLimit = errors.New("payouts: pay limit exceeded")
+)
+
+// Payout is a single payment to make.
+type Payout struct {
+	// Ref identifies the payout across runs, e.g. an employee and pay
+	// period. It must be unique.
+	Ref string
+	// Recipient is the recipient's email address, cell number or Pay ID.
+	Recipient string
+	Amount    decimal.Decimal
+	Currency  string
+	// Note is shown to the recipient.
+	Note string
+}
+
+// request returns the Pay request for p, setting the recipient field that
+// matches the form of Recipient.
+func (p Payout) request() *valr.PostPayRequest {
+	req := &valr.PostPayRequest{
+		Currency:      strings.ToUpper(p.Currency),
+		Amount:        p.Amount,
+		RecipientNote: p.Note,
+	}
+	switch r := strings.TrimSpace(p.Recipient); {
+	case strings.Contains(r, "@"):
+		req.RecipientEmail = r
+	case isCellNumber(r):
+		req.RecipientCellNumber = r
+	default:
+		req.RecipientPayID = r
+	}
+	return req
+}
+
+func isCellNumber(s string) bool {
+	s = strings.TrimPrefix(s, "+")
+	if len(s) < 9 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadCSV reads payouts from CSV with a header row naming the columns ref,
+// recipient, amount, currency and note, in any order. The currency column
+// is optional and defaults to ZAR; the note column is optional.
+func ReadCSV(r io.Reader) ([]Payout, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("payouts: reading header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"ref", "recipient", "amount"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("payouts: missing %s column", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var payouts []Payout
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return payouts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("payouts: %w", err)
+		}
+		amount, err := decimal.NewFromString(field(rec, "amount"))
+		if err != nil {
+			return nil, fmt.Errorf("payouts: line %d: invalid amount %q", line, field(rec, "amount"))
+		}
+		currency := field(rec, "currency")
+		if currency == "" {
+			currency = "ZAR"
+		}
+		payouts = append(payouts, Payout{
+			Ref:       field(rec, "ref"),
+			Recipient: field(rec, "recipient"),
+			Amount:    amount,
+			Currency:  currency,
+			Note:      field(rec, "note"),
+		})
+	}
+}
+
+// Result is the outcome of a single payout.
+type Result struct {
+	Ref           string          `json:"ref"`
+	Recipient     string          `json:"recipient"`
+	Currency      string          `json:"currency"`
+	Amount        decimal.Decimal `json:"amount"`
+	Status        string          `json:"status"`
+	Identifier    string          `json:"identifier,omitempty"`
+	TransactionID string          `json:"transactionId,omitempty"`
+	Attempts      int             `json:"attempts,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Time          time.Time       `json:"time,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// Report reconciles a run's payouts.
+type Report struct {
+	// Results is in the order of the payouts.
+	Results []Result `json:"results"`
+	// Counts holds the number of results per status.
+	Counts map[string]int `json:"counts"`
+	// Requested holds the amounts of all payouts, and Paid the amounts
+	// paid by this or an earlier run, keyed by currency.
+	Requested map[string]decimal.Decimal `json:"requested"`
+	Paid      map[string]decimal.Decimal `json:"paid"`
+}
+
+// WriteCSV writes the results as CSV with a header row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"ref", "recipient", "currency", "amount", "status", "identifier", "transaction_id", "attempts", "error", "time"})
+	for _, res := range r.Results {
+		var t string
+		if !res.Time.IsZero() {
+			t = res.Time.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			res.Ref, res.Recipient, res.Currency, res.Amount.String(), res.Status,
+			res.Identifier, res.TransactionID, fmt.Sprint(res.Attempts), res.Error, t,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func newReport(results []Result) *Report {
+	r := &Report{
+		Results:   results,
+		Counts:    make(map[string]int),
+		Requested: make(map[string]decimal.Decimal),
+		Paid:      make(map[string]decimal.Decimal),
+	}
+	for _, res := range results {
+		r.Counts[res.Status]++
+		r.Requested[res.Currency] = r.Requested[res.Currency].Add(res.Amount)
+		if res.Status == StatusPaid || res.Status == StatusSkipped {
+			r.Paid[res.Currency] = r.Paid[res.Currency].Add(res.Amount)
+		}
+	}
+	return r
+}
+
+// record is the stored state of a payout.
+type record struct {
+	Status        string          `json:"status"`
+	Recipient     string          `json:"recipient"`
+	Currency      string          `json:"currency"`
+	Amount        decimal.Decimal `json:"amount"`
+	Identifier    string          `json:"identifier,omitempty"`
+	TransactionID string          `json:"transactionId,omitempty"`
+	Time          time.Time       `json:"time"`
+}
+
+type Option func(*Runner)
+
+// WithStore records payouts in s, so a run repeated after a restart skips
+// the payouts already made. By default an in-memory store is used, which
+// only protects repeated runs by the same Runner.
+func WithStore(s store.Store) Option {
+	return func(r *Runner) {
+		r.store = s
+	}
+}
+
+// WithRetries sets how many times a rate limited payment is attempted, 3 by
+// default, and the backoff before the first retry, which doubles with each
+// retry.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(r *Runner) {
+		r.attempts, r.backoff = attempts, backoff
+	}
+}
+
+// WithStopOnError leaves the remaining payouts pending after the first one
+// that is rejected by VALR or whose outcome is unknown.
+func WithStopOnError() Option {
+	return func(r *Runner) {
+		r.stopOnError = true
+	}
+}
+
+// Runner makes bulk payouts.
+type Runner struct {
+	client      *valr.Client
+	store       store.Store
+	attempts    int
+	backoff     time.Duration
+	stopOnError bool
+}
+
+// New returns a Runner paying from cl's account.
+func New(cl *valr.Client, opts ...Option) *Runner {
+	r := &Runner{
+		client:   cl,
+		store:    store.NewMemory(),
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run validates the payouts and makes the valid ones in order. A payout is
+// rejected without being sent if it is malformed, shares its ref with
+// another, or is outside the Pay limits of its currency, counting the
+// payouts before it against the remaining limit. Payouts recorded as made
+// by an earlier run are skipped.
+//
+// Payments are only retried when rate limited, since VALR has then not
+// processed them. A payment that fails in a way that leaves its outcome
+// unknown is recorded as such and never sent again.
+//
+// The report is returned even if ctx expires or the store fails, with the
+// outstanding payouts pending.
+func (r *Runner) Run(ctx context.Context, payouts []Payout) (*Report, error) {
+	results := make([]Result, len(payouts))
+	for i, p := range payouts {
+		results[i] = Result{
+			Ref:       p.Ref,
+			Recipient: p.Recipient,
+			Currency:  strings.ToUpper(p.Currency),
+			Amount:    p.Amount,
+			Status:    StatusPending,
+		}
+	}
+	if err := r.validate(ctx, payouts, results); err != nil {
+		return newReport(results), err
+	}
+	for i, p := range payouts {
+		res := &results[i]
+		if res.Status != StatusPending {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return newReport(results), err
+		}
+		if err := r.pay(ctx, p, res); err != nil {
+			return newReport(results), err
+		}
+		if r.stopOnError && (res.Status == StatusRejected || res.Status == StatusUnknown) {
+			break
+		}
+	}
+	return newReport(results), nil
+}
+
+// validate rejects malformed payouts and those outside the limits, and
+// skips those already made.
+func (r *Runner) validate(ctx context.Context, payouts []Payout, results []Result) error {
+	reject := func(res *Result, err error) {
+		res.Status, res.Err, res.Error = StatusRejected, err, err.Error()
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range payouts {
+		res := &results[i]
+		switch {
+		case p.Ref == "":
+			reject(res, &valr.ValidationError{Field: "ref", Reason: "required"})
+			continue
+		case seen[p.Ref]:
+			reject(res, fmt.Errorf("%w: %s", ErrDuplicateRef, p.Ref))
+			continue
+		}
+		seen[p.Ref] = true
+		if err := p.request().Validate(); err != nil {
+			reject(res, err)
+			continue
+		}
+
+		var rec record
+		err := store.GetJSON(ctx, r.store, paymentsNamespace, p.Ref, &rec)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+		case err != nil:
+			return err
+		case rec.Currency != res.Currency || !rec.Amount.Equal(p.Amount) || rec.Recipient != p.Recipient:
+			reject(res, fmt.Errorf("%w: %s was used for %s %s to %s", ErrDuplicateRef, p.Ref, rec.Amount, rec.Currency, rec.Recipient))
+		case rec.Status == StatusPaid:
+			res.Status, res.Identifier, res.TransactionID, res.Time = StatusSkipped, rec.Identifier, rec.TransactionID, rec.Time
+		default:
+			res.Status, res.Time = StatusUnknown, rec.Time
+			res.Error = "outcome of an earlier attempt is unknown"
+		}
+	}
+
+	limits := make(map[string]*valr.GetPayLimitsResponse)
+	used := make(map[string]decimal.Decimal)
+	for i := range results {
+		res := &results[i]
+		if res.Status != StatusPending {
+			continue
+		}
+		l, ok := limits[res.Currency]
+		if !ok {
+			var err error
+			l, err = r.client.GetPayLimitsRequest(ctx, &valr.GetPayLimitsRequest{Currency: res.Currency})
+			if err != nil {
+				return err
+			}
+			limits[res.Currency] = l
+		}
+		next := used[res.Currency].Add(res.Amount)
+		switch {
+		case res.Amount.LessThan(l.MinPaymentAmount):
+			reject(res, fmt.Errorf("%w: %s %s is below the minimum of %s", ErrPayLimit, res.Amount, res.Currency, l.MinPaymentAmount))
+		case l.MaxPaymentAmount.IsPositive() && res.Amount.GreaterThan(l.MaxPaymentAmount):
+			reject(res, fmt.Errorf("%w: %s %s is above the maximum of %s", ErrPayLimit, res.Amount, res.Currency, l.MaxPaymentAmount))
+		case next.GreaterThan(l.LimitRemaining):
+			reject(res, fmt.Errorf("%w: %s %s would exceed the remaining limit of %s", ErrPayLimit, res.Amount, res.Currency, l.LimitRemaining.Sub(used[res.Currency])))
+		default:
+			used[res.Currency] = next
+		}
+	}
+	return nil
+}
+
+// pay makes a single payout, recording it as unknown before it is sent so
+// that a crash mid-request can't lead to it being sent again. Only store
+// errors are returned.
+func (r *Runner) pay(ctx context.Context, p Payout, res *Result) error {
+	rec := record{
+		Status:    StatusUnknown,
+		Recipient: p.Recipient,
+		Currency:  res.Currency,
+		Amount:    p.Amount,
+		Time:      time.Now().UTC(),
+	}
+	if err := store.PutJSON(ctx, r.store, paymentsNamespace, p.Ref, rec); err != nil {
+		return err
+	}
+
+	req := p.request()
+	backoff := r.backoff
+	var (
+		resp *valr.PostPayResponse
+		err  error
+	)
+	for {
+		res.Attempts++
+		resp, err = r.client.PostPayRequest(ctx, req)
+		if err == nil || !errors.Is(err, valr.ErrTooManyRequests) || res.Attempts >= r.attempts {
+			break
+		}
+		// The rate limited attempt was not processed, so err is kept if
+		// ctx expires while backing off.
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	res.Time = time.Now().UTC()
+
+	if err == nil {
+		res.Status, res.Identifier, res.TransactionID = StatusPaid, resp.Identifier, resp.TransactionID
+		rec.Status, rec.Identifier, rec.TransactionID, rec.Time = StatusPaid, resp.Identifier, resp.TransactionID, res.Time
+		return store.PutJSON(context.WithoutCancel(ctx), r.store, paymentsNamespace, p.Ref, rec)
+	}
+	res.Err, res.Error = err, err.Error()
+	if !notSent(err) {
+		res.Status = StatusUnknown
+		return nil
+	}
+	res.Status = StatusRejected
+	return r.store.Delete(context.WithoutCancel(ctx), paymentsNamespace, p.Ref)
+}
+
+// notSent returns true if err shows that a payment was not made: it was
+// refused locally, or VALR answered with a client error. Other errors, such
+// as timeouts, leave the outcome unknown.
+func notSent(err error) bool {
+	var apiErr *valr.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode < 500
+	}
+	for _, target := range refusals {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return valr.IsValidationError(err)
+}
+
+// refusals are the errors returned for payments refused by the client
+// before being sent.
+var refusals = []error{
+	valr.ErrApprovalRejected,
+	valr.ErrApprovalExpired,
+	valr.ErrWithdrawalNotAllowed,
+	valr.ErrWithdrawalCapExceeded,
+	valr.ErrTradingHalted,
+	valr.ErrDraining,
+	valr.ErrLimiterClosed,
+}
@@ -0,0 +1,150 @@
+package payouts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/payouts"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+const payoutsCSV = `ref,recipient,amount,note
+a,alice@example.com,500,March
+b,+27821234567,600,March
+c,carol,5,March
+d,dave,600,March
+b,bob@example.com,1,Duplicate
+e,erin,100,March
+f,frank,100,March
+`
+
+func TestReadCSV(t *testing.T) {
+	ps, err := payouts.ReadCSV(strings.NewReader(payoutsCSV))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(ps) != 7 {
+		t.Fatalf("Expected 7 payouts, got %d", len(ps))
+	}
+	if p := ps[1]; p.Ref != "b" || p.Recipient != "+27821234567" || !p.Amount.Equal(decimal.New(600, 0)) || p.Currency != "ZAR" || p.Note != "March" {
+		t.Errorf("Unexpected payout %+v", p)
+	}
+
+	if _, err := payouts.ReadCSV(strings.NewReader("ref,amount\na,1\n")); err == nil {
+		t.Errorf("Expected error for missing recipient column")
+	}
+	if _, err := payouts.ReadCSV(strings.NewReader("ref,recipient,amount\na,b,lots\n")); err == nil {
+		t.Errorf("Expected error for invalid amount")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		sent  []valr.PostPayRequest
+		tries = make(map[string]int)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"maxPaymentAmount":"1000","minPaymentAmount":"10","limitRemaining":"1500","limitCurrency":"ZAR"}`))
+			return
+		}
+		var req valr.PostPayRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		id := req.Recipient()
+		tries[id]++
+		switch {
+		case id == "erin" && tries[id] == 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case id == "frank":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			sent = append(sent, req)
+			w.Write([]byte(`{"identifier":"id-` + id + `","transactionId":"tx-` + id + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ps, err := payouts.ReadCSV(strings.NewReader(payoutsCSV))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ps[3].Amount = decimal.New(450, 0)
+	runner := payouts.New(cl, payouts.WithStore(store.NewMemory()), payouts.WithRetries(3, time.Millisecond))
+
+	report, err := runner.Run(context.Background(), ps)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := []string{
+		payouts.StatusPaid,
+		payouts.StatusPaid,
+		payouts.StatusRejected,
+		payouts.StatusRejected,
+		payouts.StatusRejected,
+		payouts.StatusPaid,
+		payouts.StatusUnknown,
+	}
+	for i, res := range report.Results {
+		if res.Status != want[i] {
+			t.Errorf("Expected %s to be %q, got %q (%s)", res.Ref, want[i], res.Status, res.Error)
+		}
+	}
+	if !errors.Is(report.Results[2].Err, payouts.ErrPayLimit) || !errors.Is(report.Results[3].Err, payouts.ErrPayLimit) {
+		t.Errorf("Expected limit errors, got %v and %v", report.Results[2].Err, report.Results[3].Err)
+	}
+	if !errors.Is(report.Results[4].Err, payouts.ErrDuplicateRef) {
+		t.Errorf("Expected duplicate ref error, got %v", report.Results[4].Err)
+	}
+	if res := report.Results[5]; res.Attempts != 2 || res.Identifier != "id-erin" {
+		t.Errorf("Expected a retried payment, got %+v", res)
+	}
+	if len(sent) != 3 || sent[1].RecipientCellNumber != "+27821234567" || sent[2].RecipientPayID != "erin" {
+		t.Errorf("Unexpected payments %+v", sent)
+	}
+	if got := report.Paid["ZAR"]; !got.Equal(decimal.New(1200, 0)) {
+		t.Errorf("Expected 1200 paid, got %s", got)
+	}
+
+	// A second run, with the limit reset, only pays dave. Nobody is paid
+	// twice, including frank, whose outcome is unknown.
+	report, err = runner.Run(context.Background(), ps)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(sent) != 4 || sent[3].RecipientPayID != "dave" || tries["frank"] != 1 {
+		t.Errorf("Expected only dave to be paid, got %+v and %d attempts for frank", sent, tries["frank"])
+	}
+	if report.Counts[payouts.StatusSkipped] != 3 || report.Counts[payouts.StatusPaid] != 1 || report.Counts[payouts.StatusUnknown] != 1 {
+		t.Errorf("Unexpected counts %v", report.Counts)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 8 {
+		t.Errorf("Expected 8 lines, got %d", lines)
+	}
+	if !strings.Contains(buf.String(), "a,alice@example.com,ZAR,500,SKIPPED,id-alice@example.com,") {
+		t.Errorf("Unexpected report\n%s", buf.String())
+	}
+}
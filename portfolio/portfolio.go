@@ -0,0 +1,158 @@
+// Package portfolio values account balances in a base currency, on demand
+// or on a schedule, and keeps the history for equity curves.
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/scheduler"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// valuationsNamespace holds valuations keyed by time.
+	valuationsNamespace = "portfolio.valuations"
+	// keyFormat is a fixed width UTC time, so keys sort chronologically.
+	keyFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+// Holding is the value of the balance of a single currency.
+type Holding struct {
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	Rate     decimal.Decimal `json:"rate"`
+	Value    decimal.Decimal `json:"value"`
+}
+
+// Valuation is the value of an account's balances at a point in time.
+type Valuation struct {
+	Time  time.Time       `json:"time"`
+	Base  string          `json:"base"`
+	Total decimal.Decimal `json:"total"`
+	// Holdings is in the order balances are reported.
+	Holdings []Holding `json:"holdings"`
+	// Unpriced lists currencies held that could not be converted to the
+	// base currency and are excluded from the total.
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+type Option func(*Valuer)
+
+// WithStore persists every valuation made by Run to s, for History.
+func WithStore(s store.Store) Option {
+	return func(v *Valuer) {
+		v.store = s
+	}
+}
+
+// Valuer values an account's balances in a base currency.
+type Valuer struct {
+	client *valr.Client
+	fx     *fx.Converter
+	base   string
+	store  store.Store
+}
+
+// NewValuer returns a Valuer of cl's balances, converted to base with conv.
+func NewValuer(cl *valr.Client, conv *fx.Converter, base string, opts ...Option) *Valuer {
+	v := &Valuer{client: cl, fx: conv, base: base}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Value values the account's current balances.
+func (v *Valuer) Value(ctx context.Context) (*Valuation, error) {
+	balances, err := v.client.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	val := &Valuation{Time: time.Now().UTC(), Base: v.base}
+	for _, b := range balances {
+		if b.Total.IsZero() {
+			continue
+		}
+		rate, err := v.fx.Rate(ctx, b.Currency, v.base)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			val.Unpriced = append(val.Unpriced, b.Currency)
+			continue
+		}
+		h := Holding{Currency: b.Currency, Amount: b.Total, Rate: rate, Value: b.Total.Mul(rate)}
+		val.Holdings = append(val.Holdings, h)
+		val.Total = val.Total.Add(h.Value)
+	}
+	return val, nil
+}
+
+// Run values the account every interval until ctx is done, saving each
+// valuation to the store if there is one. Failed valuations are logged and
+// retried at the next interval.
+func (v *Valuer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := v.snapshot(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/portfolio: Failed to value portfolio: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Schedule values the account about every interval on s, saving each
+// valuation like Run.
+func (v *Valuer) Schedule(s *scheduler.Scheduler, interval time.Duration) {
+	s.Every("portfolio", interval, v.snapshot)
+}
+
+func (v *Valuer) snapshot(ctx context.Context) error {
+	val, err := v.Value(ctx)
+	if err != nil {
+		return err
+	}
+	if v.store == nil {
+		return nil
+	}
+	return store.PutJSON(ctx, v.store, valuationsNamespace, val.Time.Format(keyFormat), val)
+}
+
+// History returns the stored valuations made from from until to, oldest
+// first.
+func (v *Valuer) History(ctx context.Context, from, to time.Time) ([]*Valuation, error) {
+	if v.store == nil {
+		return nil, nil
+	}
+	keys, err := v.store.List(ctx, valuationsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := from.UTC().Format(keyFormat), to.UTC().Format(keyFormat)
+	var vals []*Valuation
+	for _, key := range keys {
+		if key < lo || key > hi {
+			continue
+		}
+		val := new(Valuation)
+		if err := store.GetJSON(ctx, v.store, valuationsNamespace, key, val); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
@@ -0,0 +1,62 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/portfolio"
+	"github.com/donohutcheon/valr-go/store"
+	"github.com/shopspring/decimal"
+)
+
+func TestValuer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"currency":"ZAR","available":"100","reserved":"0","total":"100"},
+			{"currency":"BTC","available":"0.5","reserved":"0.5","total":"1"},
+			{"currency":"XYZ","available":"7","reserved":"0","total":"7"},
+			{"currency":"ETH","available":"0","reserved":"0","total":"0"}
+		]`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	tickers := marketdata.NewTickerStore()
+	p := decimal.RequireFromString("1000")
+	tickers.Update(marketdata.Ticker{Pair: "BTCZAR", Bid: p, Ask: p, Last: p})
+
+	s := store.NewMemory()
+	v := portfolio.NewValuer(cl, fx.New(tickers), "ZAR", portfolio.WithStore(s))
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	v.Run(ctx, time.Hour)
+
+	vals, err := v.History(context.Background(), start, time.Now())
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(vals) != 1 {
+		t.Fatalf("Expected 1 valuation, got %d", len(vals))
+	}
+	val := vals[0]
+	if !val.Total.Equal(decimal.RequireFromString("1100")) {
+		t.Errorf("Expected total 1100, got %s", val.Total)
+	}
+	if len(val.Holdings) != 2 || len(val.Unpriced) != 1 || val.Unpriced[0] != "XYZ" {
+		t.Errorf("Unexpected valuation %+v", val)
+	}
+}
@@ -0,0 +1,31 @@
+package valr
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetCancelPriority gives calls cancelling orders priority in the client's
+// rate limiter, so they get through during incidents even when other
+// traffic has used up the limit: reserved requests of every interval are
+// held back for them and they go ahead of other waiting calls when the
+// limit resets. Other calls can be given the same priority with
+// WithPriority.
+func (cl *Client) SetCancelPriority(reserved int) {
+	cl.cancelPriority = true
+	if rl, ok := cl.rateLimiter.(*RateLimiter); ok {
+		rl.setReserved(reserved)
+	}
+}
+
+func (l *RateLimiter) setReserved(n int) {
+	l.cond.L.Lock()
+	defer l.cond.L.Unlock()
+	l.reserved = n
+	l.cond.Broadcast()
+}
+
+// isCancel returns true for calls that cancel orders.
+func isCancel(method, path string) bool {
+	return method == http.MethodDelete && strings.HasPrefix(strings.TrimLeft(path, "/"), "orders")
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package valr_test
+
+// raceEnabled is true when tests run with the race detector, which makes
+// allocation counts unreliable.
+const raceEnabled = true
@@ -0,0 +1,104 @@
+package refdata
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dataset is a cached map of reference data keyed by symbol.
+type dataset[T any] struct {
+	kind   string
+	fetch  func(context.Context) (map[string]T, error)
+	notify func(Change)
+	// compare, if set, is called with the old and new data after every
+	// refresh but the initial load.
+	compare func(old, new map[string]T)
+
+	mu         sync.RWMutex
+	data       map[string]T
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func newDataset[T any](kind string, fetch func(context.Context) (map[string]T, error),
+	notify func(Change)) *dataset[T] {
+
+	return &dataset[T]{kind: kind, fetch: fetch, notify: notify}
+}
+
+// get returns the cached data. The first call blocks on the API; later
+// calls return immediately, starting a background refresh if the data is
+// older than ttl.
+func (d *dataset[T]) get(ctx context.Context, ttl time.Duration) (map[string]T, error) {
+	d.mu.Lock()
+	data := d.data
+	if data == nil {
+		d.mu.Unlock()
+		return d.refresh(ctx)
+	}
+	if time.Since(d.fetchedAt) >= ttl && !d.refreshing {
+		d.refreshing = true
+		go func() {
+			if _, err := d.refresh(context.Background()); err != nil {
+				log.Printf("valr/refdata: Failed to refresh %s: %v", d.kind, err)
+			}
+		}()
+	}
+	d.mu.Unlock()
+	return data, nil
+}
+
+func (d *dataset[T]) refresh(ctx context.Context) (map[string]T, error) {
+	data, err := d.fetch(ctx)
+
+	d.mu.Lock()
+	d.refreshing = false
+	if err != nil {
+		d.mu.Unlock()
+		return nil, err
+	}
+	old := d.data
+	d.data = data
+	d.fetchedAt = time.Now()
+	d.mu.Unlock()
+
+	if old != nil {
+		if ch, changed := diff(d.kind, old, data); changed {
+			d.notify(ch)
+		}
+		if d.compare != nil {
+			d.compare(old, data)
+		}
+	}
+	return data, nil
+}
+
+func diff[T any](kind string, old, new map[string]T) (Change, bool) {
+	ch := Change{Kind: kind}
+	for k, v := range new {
+		o, ok := old[k]
+		if !ok {
+			ch.Added = append(ch.Added, k)
+			continue
+		}
+		// Values hold decimals, which can't be compared directly.
+		ob, _ := json.Marshal(o)
+		nb, _ := json.Marshal(v)
+		if string(ob) != string(nb) {
+			ch.Modified = append(ch.Modified, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			ch.Removed = append(ch.Removed, k)
+		}
+	}
+	sort.Strings(ch.Added)
+	sort.Strings(ch.Removed)
+	sort.Strings(ch.Modified)
+	return ch, len(ch.Added)+len(ch.Removed)+len(ch.Modified) > 0
+}
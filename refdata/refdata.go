@@ -0,0 +1,242 @@
+// Package refdata caches VALR reference data such as currencies, currency
+// pairs and order types, so that hot paths don't block on REST calls. Pairs
+// being deactivated or delisted are reported as they are detected, so bots
+// can leave those markets.
+package refdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/donohutcheon/valr-go/scheduler"
+)
+
+const defaultTTL = time.Hour
+
+var (
+	ErrUnknownPair     = errors.New("refdata: unknown pair")
+	ErrUnknownCurrency = errors.New("refdata: unknown currency")
+)
+
+// Dataset kinds reported in Change.
+const (
+	KindPairs      = "pairs"
+	KindCurrencies = "currencies"
+	KindOrderTypes = "orderTypes"
+)
+
+// Change describes the difference between two versions of a dataset, keyed
+// by pair or currency symbol.
+type Change struct {
+	Kind     string
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// ChangeHook is called after a refresh that changed a dataset. It is not
+// called for the initial load.
+type ChangeHook func(Change)
+
+type Option func(*Cache)
+
+// WithTTL sets how long reference data is cached before being refreshed.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithChangeHook registers a hook that is called when reference data
+// changes, e.g. when a pair is listed or becomes inactive.
+func WithChangeHook(fn ChangeHook) Option {
+	return func(c *Cache) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
+
+// Cache holds reference data fetched from the VALR API. Only the first
+// access to a dataset blocks on the API; once loaded, expired data continues
+// to be served while it is refreshed in the background.
+type Cache struct {
+	client      *valr.Client
+	ttl         time.Duration
+	hooks       []ChangeHook
+	statusHooks []PairStatusCallback
+	bus         *eventbus.Bus
+
+	pairs      *dataset[valr.PairInfo]
+	currencies *dataset[valr.CurrencyInfo]
+	orderTypes *dataset[[]string]
+}
+
+// New creates an empty cache. Data is fetched lazily on first use, or
+// eagerly by Run.
+func New(cl *valr.Client, opts ...Option) *Cache {
+	c := &Cache{
+		client: cl,
+		ttl:    defaultTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.pairs = newDataset(KindPairs, c.fetchPairs, c.notify)
+	c.pairs.compare = c.comparePairs
+	c.currencies = newDataset(KindCurrencies, c.fetchCurrencies, c.notify)
+	c.orderTypes = newDataset(KindOrderTypes, c.fetchOrderTypes, c.notify)
+	return c
+}
+
+// Pair returns the info for the given pair symbol, e.g. "BTCZAR".
+func (c *Cache) Pair(ctx context.Context, symbol string) (valr.PairInfo, error) {
+	pairs, err := c.Pairs(ctx)
+	if err != nil {
+		return valr.PairInfo{}, err
+	}
+	p, ok := pairs[symbol]
+	if !ok {
+		return valr.PairInfo{}, fmt.Errorf("%w: %s", ErrUnknownPair, symbol)
+	}
+	return p, nil
+}
+
+// Pairs returns all pairs keyed by symbol. The returned map must not be
+// modified.
+func (c *Cache) Pairs(ctx context.Context) (map[string]valr.PairInfo, error) {
+	return c.pairs.get(ctx, c.ttl)
+}
+
+// RefreshPairs fetches the pairs from the API, regardless of their age.
+func (c *Cache) RefreshPairs(ctx context.Context) (map[string]valr.PairInfo, error) {
+	return c.pairs.refresh(ctx)
+}
+
+// Currency returns the info for the given currency symbol, e.g. "BTC".
+func (c *Cache) Currency(ctx context.Context, symbol string) (valr.CurrencyInfo, error) {
+	currencies, err := c.currencies.get(ctx, c.ttl)
+	if err != nil {
+		return valr.CurrencyInfo{}, err
+	}
+	cur, ok := currencies[symbol]
+	if !ok {
+		return valr.CurrencyInfo{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, symbol)
+	}
+	return cur, nil
+}
+
+// Currencies returns all currencies keyed by symbol. The returned map must
+// not be modified.
+func (c *Cache) Currencies(ctx context.Context) (map[string]valr.CurrencyInfo, error) {
+	return c.currencies.get(ctx, c.ttl)
+}
+
+// OrderTypes returns the order types supported by pair.
+func (c *Cache) OrderTypes(ctx context.Context, pair string) ([]string, error) {
+	orderTypes, err := c.orderTypes.get(ctx, c.ttl)
+	if err != nil {
+		return nil, err
+	}
+	types, ok := orderTypes[pair]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPair, pair)
+	}
+	return types, nil
+}
+
+// SupportsOrderType returns true if pair supports the given order type.
+func (c *Cache) SupportsOrderType(ctx context.Context, pair, orderType string) (bool, error) {
+	types, err := c.OrderTypes(ctx, pair)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range types {
+		if t == orderType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Refresh fetches all datasets from the API.
+func (c *Cache) Refresh(ctx context.Context) error {
+	_, errP := c.pairs.refresh(ctx)
+	_, errC := c.currencies.refresh(ctx)
+	_, errO := c.orderTypes.refresh(ctx)
+	return errors.Join(errP, errC, errO)
+}
+
+// Run loads all datasets and refreshes them every TTL until ctx is
+// cancelled, so that readers never find expired data.
+func (c *Cache) Run(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		log.Printf("valr/refdata: Initial refresh failed: %v", err)
+	}
+
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("valr/refdata: Refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Schedule refreshes all datasets about every TTL on s, starting as soon as
+// s runs, as an alternative to Run that spreads the refreshes out among the
+// scheduler's other tasks.
+func (c *Cache) Schedule(s *scheduler.Scheduler) {
+	s.Every("refdata", c.ttl, c.Refresh, scheduler.WithImmediate())
+}
+
+func (c *Cache) notify(ch Change) {
+	for _, hook := range c.hooks {
+		hook(ch)
+	}
+}
+
+func (c *Cache) fetchPairs(ctx context.Context) (map[string]valr.PairInfo, error) {
+	res, err := c.client.GetCurrencyPairs(ctx, &valr.GetCurrencyPairsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	pairs := make(map[string]valr.PairInfo, len(res))
+	for _, p := range res {
+		pairs[p.Symbol] = p
+	}
+	return pairs, nil
+}
+
+func (c *Cache) fetchCurrencies(ctx context.Context) (map[string]valr.CurrencyInfo, error) {
+	res, err := c.client.GetCurrencies(ctx, &valr.GetCurrenciesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	currencies := make(map[string]valr.CurrencyInfo, len(res))
+	for _, cur := range res {
+		currencies[cur.Symbol] = cur
+	}
+	return currencies, nil
+}
+
+func (c *Cache) fetchOrderTypes(ctx context.Context) (map[string][]string, error) {
+	res, err := c.client.GetOrderTypesRequest(ctx, &valr.GetOrderTypesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	orderTypes := make(map[string][]string, len(res))
+	for _, ot := range res {
+		orderTypes[ot.Pair] = ot.OrderTypes
+	}
+	return orderTypes, nil
+}
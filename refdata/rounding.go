@@ -0,0 +1,54 @@
+package refdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// RoundPrice snaps price to the nearest multiple of the pair's tick size.
+func (c *Cache) RoundPrice(ctx context.Context, pair string, price decimal.Decimal) (decimal.Decimal, error) {
+	p, err := c.Pair(ctx, pair)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return RoundToTick(price, p.TickSize), nil
+}
+
+// RoundQuantity truncates quantity to the pair's base decimal places.
+// Quantities are truncated rather than rounded so that an order never
+// exceeds the amount it was derived from, e.g. an available balance.
+func (c *Cache) RoundQuantity(ctx context.Context, pair string, quantity decimal.Decimal) (decimal.Decimal, error) {
+	p, err := c.Pair(ctx, pair)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	places, err := BaseDecimalPlaces(p)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return quantity.Truncate(places), nil
+}
+
+// RoundToTick snaps d to the nearest multiple of tick. A zero tick leaves d
+// unchanged.
+func RoundToTick(d, tick decimal.Decimal) decimal.Decimal {
+	if !tick.IsPositive() {
+		return d
+	}
+	return d.Div(tick).Round(0).Mul(tick)
+}
+
+// BaseDecimalPlaces returns the number of decimal places allowed in a
+// quantity of the pair's base currency.
+func BaseDecimalPlaces(p valr.PairInfo) (int32, error) {
+	places, err := strconv.ParseInt(p.BaseDecimalPlaces, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("refdata: invalid base decimal places %q for %s: %w",
+			p.BaseDecimalPlaces, p.Symbol, err)
+	}
+	return int32(places), nil
+}
@@ -0,0 +1,123 @@
+package refdata
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/eventbus"
+)
+
+// Pair statuses reported in PairStatus.
+const (
+	// PairListed is reported for pairs that appear in the currency pairs.
+	PairListed = "listed"
+	// PairDeactivated is reported for pairs that are no longer active.
+	// Orders can't be placed, and open orders may be cancelled by VALR.
+	PairDeactivated = "deactivated"
+	// PairReactivated is reported for inactive pairs that are active again.
+	PairReactivated = "reactivated"
+	// PairDelisted is reported for pairs that have disappeared from the
+	// currency pairs altogether.
+	PairDelisted = "delisted"
+)
+
+// PairStatus describes a change in whether a pair can be traded.
+type PairStatus struct {
+	Pair   string
+	Status string
+	// Info is the pair's latest info, or its last known info once it has
+	// been delisted.
+	Info valr.PairInfo
+	Time time.Time
+}
+
+// PairStatusCallback is called for each change in a pair's status, so bots
+// can cancel their orders and exit their positions in markets that are
+// closing. It is not called for the initial load.
+type PairStatusCallback func(PairStatus)
+
+// WithPairStatusCallback registers a callback for pairs that are listed,
+// deactivated, reactivated or delisted.
+func WithPairStatusCallback(fn PairStatusCallback) Option {
+	return func(c *Cache) {
+		c.statusHooks = append(c.statusHooks, fn)
+	}
+}
+
+// WithPairStatusBus publishes an eventbus.PairStatus to b for each change
+// in a pair's status.
+func WithPairStatusBus(b *eventbus.Bus) Option {
+	return func(c *Cache) {
+		c.bus = b
+	}
+}
+
+// Active returns true if pair is listed and active.
+func (c *Cache) Active(ctx context.Context, pair string) (bool, error) {
+	pairs, err := c.Pairs(ctx)
+	if err != nil {
+		return false, err
+	}
+	return pairs[pair].Active, nil
+}
+
+// WatchPairs refreshes the currency pairs every interval until ctx is
+// done, reporting changes in their status to the pair status callbacks and
+// bus. Unlike Run it doesn't refresh the other datasets, so it can poll
+// more often.
+func (c *Cache) WatchPairs(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.pairs.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/refdata: Failed to refresh pairs: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// comparePairs reports the changes in pair status between two versions of
+// the pairs.
+func (c *Cache) comparePairs(old, new map[string]valr.PairInfo) {
+	if len(c.statusHooks) == 0 && c.bus == nil {
+		return
+	}
+	now := time.Now()
+	var changes []PairStatus
+	for symbol, p := range new {
+		o, ok := old[symbol]
+		switch {
+		case !ok:
+			changes = append(changes, PairStatus{Pair: symbol, Status: PairListed, Info: p, Time: now})
+		case o.Active && !p.Active:
+			changes = append(changes, PairStatus{Pair: symbol, Status: PairDeactivated, Info: p, Time: now})
+		case !o.Active && p.Active:
+			changes = append(changes, PairStatus{Pair: symbol, Status: PairReactivated, Info: p, Time: now})
+		}
+	}
+	for symbol, o := range old {
+		if _, ok := new[symbol]; !ok {
+			changes = append(changes, PairStatus{Pair: symbol, Status: PairDelisted, Info: o, Time: now})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Pair < changes[j].Pair })
+
+	for _, ch := range changes {
+		log.Printf("valr/refdata: Pair %s %s", ch.Pair, ch.Status)
+		for _, fn := range c.statusHooks {
+			fn(ch)
+		}
+		c.bus.Publish(eventbus.PairStatus{
+			Header: eventbus.Header{Source: eventbus.SourceREST, Time: ch.Time},
+			Pair:   ch.Pair,
+			Status: ch.Status,
+		})
+	}
+}
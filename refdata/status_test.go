@@ -0,0 +1,73 @@
+package refdata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/donohutcheon/valr-go/refdata"
+)
+
+func TestPairStatus(t *testing.T) {
+	versions := []string{
+		`[{"symbol":"BTCZAR","active":true},{"symbol":"ETHZAR","active":true},{"symbol":"XRPZAR","active":false}]`,
+		`[{"symbol":"BTCZAR","active":false},{"symbol":"XRPZAR","active":true},{"symbol":"SOLZAR","active":true}]`,
+	}
+	var version atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(versions[version.Load()]))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+
+	b := eventbus.New()
+	defer b.Close()
+	sub := eventbus.Subscribe[eventbus.PairStatus](b, 8)
+	var got []refdata.PairStatus
+	c := refdata.New(cl, refdata.WithPairStatusBus(b), refdata.WithPairStatusCallback(func(s refdata.PairStatus) {
+		got = append(got, s)
+	}))
+
+	ctx := context.Background()
+	if _, err := c.RefreshPairs(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected no changes on the initial load, got %+v", got)
+	}
+	version.Store(1)
+	if _, err := c.RefreshPairs(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	want := []struct{ pair, status string }{
+		{"BTCZAR", refdata.PairDeactivated},
+		{"ETHZAR", refdata.PairDelisted},
+		{"SOLZAR", refdata.PairListed},
+		{"XRPZAR", refdata.PairReactivated},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Pair != w.pair || got[i].Status != w.status {
+			t.Errorf("Expected %s %s, got %s %s", w.pair, w.status, got[i].Pair, got[i].Status)
+		}
+		if ev := <-sub.C; ev.Pair != w.pair || ev.Status != w.status {
+			t.Errorf("Expected event %s %s, got %+v", w.pair, w.status, ev)
+		}
+	}
+	if got[1].Info.Symbol != "ETHZAR" || !got[1].Info.Active {
+		t.Errorf("Expected the delisted pair's last known info, got %+v", got[1].Info)
+	}
+
+	if active, err := c.Active(ctx, "BTCZAR"); err != nil || active {
+		t.Errorf("Expected BTCZAR to be inactive, got %v, %v", active, err)
+	}
+}
@@ -0,0 +1,154 @@
+package refdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrOrderTooSmall = errors.New("order below minimum size")
+	ErrOrderTooLarge = errors.New("order above maximum size")
+	ErrPairInactive  = errors.New("pair is not active")
+)
+
+// OrderSizeError describes an order rejected locally because its size falls
+// outside the pair's limits.
+type OrderSizeError struct {
+	Pair     string
+	Currency string
+	Amount   decimal.Decimal
+	Limit    decimal.Decimal
+	Err      error
+}
+
+func (e *OrderSizeError) Error() string {
+	return fmt.Sprintf("refdata: %s %s: %s %s (limit %s %s)",
+		e.Pair, e.Err, e.Amount, e.Currency, e.Limit, e.Currency)
+}
+
+func (e *OrderSizeError) Unwrap() error {
+	return e.Err
+}
+
+// Invalid returns true, so valr.IsValidationError recognises the error.
+func (e *OrderSizeError) Invalid() bool {
+	return true
+}
+
+// ValidateOrderSize checks an order's base and quote amounts against the
+// pair's limits. A zero amount skips the corresponding check, e.g. a market
+// buy only specifies a quote amount.
+func (c *Cache) ValidateOrderSize(ctx context.Context, pair string, base, quote decimal.Decimal) error {
+	p, err := c.Pair(ctx, pair)
+	if err != nil {
+		return err
+	}
+	if !p.Active {
+		return fmt.Errorf("refdata: %s: %w", pair, ErrPairInactive)
+	}
+	if !base.IsZero() {
+		if err := checkRange(p.Symbol, p.BaseCurrency, base, p.MinBaseAmount, p.MaxBaseAmount); err != nil {
+			return err
+		}
+	}
+	if !quote.IsZero() {
+		if err := checkRange(p.Symbol, p.QuoteCurrency, quote, p.MinQuoteAmount, p.MaxQuoteAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRange(pair, currency string, amount, min, max decimal.Decimal) error {
+	if min.IsPositive() && amount.LessThan(min) {
+		return &OrderSizeError{Pair: pair, Currency: currency, Amount: amount, Limit: min, Err: ErrOrderTooSmall}
+	}
+	if max.IsPositive() && amount.GreaterThan(max) {
+		return &OrderSizeError{Pair: pair, Currency: currency, Amount: amount, Limit: max, Err: ErrOrderTooLarge}
+	}
+	return nil
+}
+
+// ValidateReduceOnly rejects reduce-only orders on pairs that aren't
+// futures.
+func (c *Cache) ValidateReduceOnly(ctx context.Context, pair string, reduceOnly bool) error {
+	if !reduceOnly {
+		return nil
+	}
+	p, err := c.Pair(ctx, pair)
+	if err != nil {
+		return err
+	}
+	if p.CurrencyPairType != valr.PairTypeFuture {
+		return &valr.ValidationError{
+			Field:  "reduceOnly",
+			Reason: fmt.Sprintf("only supported on futures pairs, %s is %s", pair, p.CurrencyPairType),
+		}
+	}
+	return nil
+}
+
+// ValidateTimeInForce checks that pair supports orderType, one of the
+// valr.OrderType constants, and that tif is usable with it. Only limit and
+// stop-limit orders accept a time in force.
+func (c *Cache) ValidateTimeInForce(ctx context.Context, pair, orderType string, tif valr.TimeInForce) error {
+	ok, err := c.SupportsOrderType(ctx, pair, orderType)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &valr.ValidationError{
+			Field:  "orderType",
+			Reason: fmt.Sprintf("%s not supported on %s", orderType, pair),
+		}
+	}
+	if tif == "" {
+		return nil
+	}
+	switch orderType {
+	case valr.OrderTypeLimit, valr.OrderTypeStopLimit:
+	default:
+		return &valr.ValidationError{
+			Field:  "timeInForce",
+			Reason: fmt.Sprintf("not applicable to %s orders", orderType),
+		}
+	}
+	switch tif {
+	case valr.GoodTillCancelled, valr.ImmediateOrCancel, valr.FillOrKill:
+		return nil
+	default:
+		return &valr.ValidationError{Field: "timeInForce", Reason: fmt.Sprintf("unknown value %q", tif)}
+	}
+}
+
+// ValidateLimitOrder checks the quantity, notional value, time in force and
+// flags of a limit order.
+func (c *Cache) ValidateLimitOrder(ctx context.Context, req *valr.PostLimitOrderRequest) error {
+	if err := c.ValidateTimeInForce(ctx, req.Pair, valr.OrderTypeLimit, req.TimeInForce); err != nil {
+		return err
+	}
+	if err := c.ValidateReduceOnly(ctx, req.Pair, req.ReduceOnly); err != nil {
+		return err
+	}
+	return c.ValidateOrderSize(ctx, req.Pair, req.Quantity, req.Quantity.Mul(req.Price))
+}
+
+// ValidateMarketBuy checks the quote amount and flags of a market buy.
+func (c *Cache) ValidateMarketBuy(ctx context.Context, req *valr.PostMarketOrderBuyRequest) error {
+	if err := c.ValidateReduceOnly(ctx, req.Pair, req.ReduceOnly); err != nil {
+		return err
+	}
+	return c.ValidateOrderSize(ctx, req.Pair, decimal.Zero, req.Quantity)
+}
+
+// ValidateMarketSell checks the base amount and flags of a market sell.
+func (c *Cache) ValidateMarketSell(ctx context.Context, req *valr.PostMarketOrderSellRequest) error {
+	if err := c.ValidateReduceOnly(ctx, req.Pair, req.ReduceOnly); err != nil {
+		return err
+	}
+	return c.ValidateOrderSize(ctx, req.Pair, req.Quantity, decimal.Zero)
+}
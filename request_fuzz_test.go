@@ -0,0 +1,189 @@
+package valr_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func FuzzSignRequest(f *testing.F) {
+	f.Add("secret", "1558014486185", "GET", "/v1/account/balances", []byte(nil))
+	f.Add("secret", "1558017528946", "post", "/v1/orders/market", []byte(`{"customerOrderId":"ORDER-000001","pair":"BTCZAR","side":"BUY","quoteAmount":"80000"}`))
+	f.Add("", "", "", "", []byte{})
+	f.Add("sécret", "0", "DELETE", "/v1/orders/{currencyPair}/ñ", []byte{0xff, 0x00})
+
+	f.Fuzz(func(t *testing.T, secret, timestamp, verb, path string, body []byte) {
+		sig := valr.SignRequest(secret, timestamp, verb, path, body)
+		if len(sig) != 2*sha512.Size {
+			t.Fatalf("Expected %d hex characters, got %q", 2*sha512.Size, sig)
+		}
+		mac := hmac.New(sha512.New, []byte(secret))
+		mac.Write([]byte(timestamp + strings.ToUpper(verb) + path))
+		mac.Write(body)
+		if want := hex.EncodeToString(mac.Sum(nil)); sig != want {
+			t.Fatalf("Expected %q, got %q", want, sig)
+		}
+		if lower := valr.SignRequest(secret, timestamp, strings.ToLower(verb), path, body); lower != sig {
+			t.Fatalf("Expected the verb's case not to matter, got %q and %q", sig, lower)
+		}
+
+		// GetAuthHeaders signs exactly the request URI that is sent.
+		u, err := url.Parse("https://api.valr.com" + path)
+		if err != nil || u.RequestURI() != path || secret == "" {
+			return
+		}
+		h, err := valr.GetAuthHeaders(u.String(), verb, "key", secret, body)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if want := valr.SignRequest(secret, h.Get("X-VALR-TIMESTAMP"), verb, path, body); h.Get("X-VALR-SIGNATURE") != want {
+			t.Fatalf("Expected %q, got %q", want, h.Get("X-VALR-SIGNATURE"))
+		}
+	})
+}
+
+func FuzzPathTemplate(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"BTCZAR", "e5886f2d-191b-4330-a221-c7b41b0bc553"},
+		{"{orderId}", "x"},
+		{"BTC/ZAR", "a?b=c#d"},
+		{"ÉTHZAR", "%2F.."},
+		{"..", "1"},
+		{"", "1"},
+		{"BTCZAR", " "},
+	} {
+		f.Add(seed[0], seed[1])
+	}
+
+	type request struct {
+		pair, id, rawQuery, uri, signature, timestamp string
+	}
+	received := make(chan request, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{currencyPair}/orderid/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- request{
+			pair:      r.PathValue("currencyPair"),
+			id:        r.PathValue("orderId"),
+			rawQuery:  r.URL.RawQuery,
+			uri:       r.URL.RequestURI(),
+			signature: r.Header.Get("X-VALR-SIGNATURE"),
+			timestamp: r.Header.Get("X-VALR-TIMESTAMP"),
+		}:
+		default:
+		}
+		w.Write([]byte(`{}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f.Fuzz(func(t *testing.T, pair, id string) {
+		cl := valr.NewClient()
+		defer cl.Close()
+		cl.SetBaseURL(srv.URL)
+		cl.SetAuth("key", "secret")
+
+		// Drop a request left over from an iteration that didn't wait for it.
+		select {
+		case <-received:
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: pair, ID: id})
+		invalid := func(v string) bool { return v == "" || v == "." || v == ".." }
+		if invalid(pair) || invalid(id) {
+			if err == nil {
+				t.Fatalf("Expected error for pair %q and ID %q", pair, id)
+			}
+			return
+		}
+		// The test server's ServeMux routes on the cleaned, unescaped path,
+		// so it can't route values such as "/a" that clean differently.
+		if p := "/orders/" + pair + "/orderid/" + id; path.Clean(p) != p {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Expected success for pair %q and ID %q, got %v", pair, id, err)
+		}
+		got := <-received
+		if got.pair != pair || got.id != id {
+			t.Fatalf("Expected pair %q and ID %q, got %q and %q", pair, id, got.pair, got.id)
+		}
+		if got.rawQuery != "" {
+			t.Fatalf("Expected no query string, got %q", got.rawQuery)
+		}
+		if want := valr.SignRequest("secret", got.timestamp, http.MethodGet, got.uri, nil); got.signature != want {
+			t.Fatalf("Expected the sent URI %q to be signed", got.uri)
+		}
+	})
+}
+
+func FuzzMakeURLValues(f *testing.F) {
+	f.Add("foo", int64(42), true, []byte("foo"), int64(1514851032))
+	f.Add("{pair}", int64(-1), false, []byte{}, int64(0))
+	f.Add("a=b&c=d", int64(0), false, []byte{0xff}, int64(-62135596800))
+	f.Add("\x00ñ%", int64(1)<<62, true, []byte(nil), int64(253402300799))
+
+	type Req struct {
+		S  string    `url:"s"`
+		I  int64     `url:"i"`
+		B  bool      `url:"b,omitempty"`
+		By []byte    `url:"by"`
+		T  time.Time `url:"t,omitempty"`
+		P  *string   `url:"p"`
+		u  string    `url:"u"`
+	}
+
+	f.Fuzz(func(t *testing.T, s string, i int64, b bool, by []byte, ts int64) {
+		r := Req{S: s, I: i, B: b, By: by, T: time.Unix(ts, 0), P: &s, u: s}
+		v, err := valr.MakeURLValues(&r)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		q, err := url.ParseQuery(v.Encode())
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if q.Get("s") != s || q.Get("p") != s || q.Get("by") != string(by) {
+			t.Fatalf("Expected %q and %q, got %v", s, by, q)
+		}
+		if q.Has("u") {
+			t.Fatalf("Expected unexported field to be skipped, got %v", q)
+		}
+		if q.Has("b") != b {
+			t.Fatalf("Expected b present only when true, got %v", q)
+		}
+		if want := r.T.UTC().Format(time.RFC3339); q.Get("t") != want {
+			t.Fatalf("Expected %q, got %q", want, q.Get("t"))
+		}
+
+		r.P = nil
+		v, err = valr.MakeURLValues(&r)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if v.Has("p") {
+			t.Fatalf("Expected nil pointer to be skipped, got %v", v)
+		}
+	})
+}
+
+func TestMakeURLValuesRejectsNonStruct(t *testing.T) {
+	for _, v := range []any{nil, "s", struct{}{}, new(int), (*struct{})(nil)} {
+		if _, err := valr.MakeURLValues(v); err == nil {
+			t.Errorf("Expected error for %T", v)
+		}
+	}
+}
@@ -63,6 +63,32 @@ type GetMarketSummaryForPairRequest struct {
 	Pair string `json:"-" url:"currencyPair"`
 }
 
+// GetTradeHistoryRequest is the request struct for GetTradeHistory
+type GetTradeHistoryRequest struct {
+	// https://api.valr.com/v1/public/:currencyPair/trades?limit=10
+	// Currency Pair
+	// required: true
+	// Limit
+	// required: false
+	// Skip
+	// required: false
+	// StartTime
+	// required: false
+	// EndTime
+	// required: false
+	// BeforeID: return trades older than the trade with this ID
+	// required: false
+	// AfterID: return trades newer than the trade with this ID
+	// required: false
+	Pair      string    `json:"-" url:"currencyPair"`
+	Limit     int       `json:"-" url:"limit,omitempty"`
+	Skip      int       `json:"-" url:"skip,omitempty"`
+	StartTime time.Time `json:"-" url:"startTime,omitempty"`
+	EndTime   time.Time `json:"-" url:"endTime,omitempty"`
+	BeforeID  string    `json:"-" url:"beforeId,omitempty"`
+	AfterID   string    `json:"-" url:"afterId,omitempty"`
+}
+
 // GetServerTimeRequest is the request struct for GetServerTime
 type GetServerTimeRequest struct {
 	// https://api.valr.com/v1/public/time
@@ -73,6 +99,12 @@ type GetServerTimeRequest struct {
 PRIVATE API GET REQUESTS
 */
 
+// GetCurrentAPIKeyRequest is the request struct for GetCurrentAPIKey
+type GetCurrentAPIKeyRequest struct {
+	// https://api.valr.com/v1/account/api-keys/current
+	// Empty
+}
+
 // GetAccountBalancesRequest is the request struct for GetAccountBalances
 type GetAccountBalancesRequest struct {
 	// https://api.valr.com/v1/account/balances
@@ -84,9 +116,19 @@ type GetTransactionHistoryRequest struct {
 	// https://api.valr.com/v1/account/transactionhistory?skip=0&limit=100
 	// Skip
 	// Limit
+	// Transaction Types
+	// Currency
+	// Start Time
+	// End Time
+	// Before ID
 	// required: false
-	Skip  int `json:"-" url:"skip"`
-	Limit int `json:"-" url:"limit"`
+	Skip             int              `json:"-" url:"skip"`
+	Limit            int              `json:"-" url:"limit"`
+	TransactionTypes TransactionTypes `json:"-" url:"transactionTypes,omitempty"`
+	Currency         string           `json:"-" url:"currency,omitempty"`
+	StartTime        time.Time        `json:"-" url:"startTime,omitempty"`
+	EndTime          time.Time        `json:"-" url:"endTime,omitempty"`
+	BeforeID         string           `json:"-" url:"beforeId,omitempty"`
 }
 
 // GetTradeHistoryForPairRequest is the request struct for GetTradeHistoryForPair
@@ -189,11 +231,17 @@ type GetAuthTradeHistoryForPairRequest struct {
 	// required: false
 	// EndTime
 	// required: false
+	// BeforeID: return trades older than the trade with this ID
+	// required: false
+	// AfterID: return trades newer than the trade with this ID
+	// required: false
 	Pair      string    `json:"-" url:"currencyPair"`
 	Limit     int       `json:"-" url:"limit"`
 	Skip      int       `json:"-" url:"skip"`
 	StartTime time.Time `json:"-" url:"startTime,omitempty"`
 	EndTime   time.Time `json:"-" url:"endTime,omitempty"`
+	BeforeID  string    `json:"-" url:"beforeId,omitempty"`
+	AfterID   string    `json:"-" url:"afterId,omitempty"`
 }
 
 // GetSimpleBuyOrSellOrderStatusRequest is the request struct for GetSimpleBuyOrSellOrderStatus
@@ -274,6 +322,35 @@ type GetOrderHistoryDetailsByCustomerOrderIDRequest struct {
 	ID string `json:"-" url:"customerOrderId"`
 }
 
+// GetOpenPositionsRequest is the request struct for GetOpenPositions
+type GetOpenPositionsRequest struct {
+	// https://api.valr.com/v1/positions/open?currencyPair=BTCUSDTPERP
+	// Currency Pair
+	// required: false
+	Pair string `json:"-" url:"currencyPair,omitempty"`
+}
+
+// GetPayLimitsRequest is the request struct for GetPayLimits
+type GetPayLimitsRequest struct {
+	// https://api.valr.com/v1/pay/limits
+	// Currency Code
+	// required: true
+	Currency string `json:"-" url:"currency"`
+}
+
+// GetPayStatusRequest is the request struct for GetPayStatus
+type GetPayStatusRequest struct {
+	// https://api.valr.com/v1/pay/identifier/:identifier
+	// Payment Identifier
+	// required: true
+	Identifier string `json:"-" url:"identifier"`
+}
+
+// GetSubaccountsRequest is the request struct for GetSubaccounts
+type GetSubaccountsRequest struct {
+	// https://api.valr.com/v1/account/subaccounts
+}
+
 /*
 PRIVATE API POST REQUESTS
 */
@@ -285,9 +362,16 @@ type PostNewCryptoWithdrawRequest struct {
 	// Amount
 	// Address
 	// required: true
-	Asset   string          `json:"currencyCode" url:"-"`
-	Amount  decimal.Decimal `json:"amount" url:"-"`
-	Address string          `json:"address" url:"-"`
+	// Network Type (defaults to the currency's default network)
+	// Payment Reference (XRP, XMR, XEM and XLM only)
+	// Beneficiary (travel rule details of the recipient)
+	// required: false
+	Asset            string                 `json:"currencyCode" url:"currencyCode"`
+	Amount           decimal.Decimal        `json:"amount" url:"-"`
+	Address          string                 `json:"address" url:"-"`
+	Network          string                 `json:"networkType,omitempty" url:"-"`
+	PaymentReference string                 `json:"paymentReference,omitempty" url:"-"`
+	Beneficiary      *WithdrawalBeneficiary `json:"beneficiary,omitempty" url:"-"`
 }
 
 // PostNewFiatWithdrawRequest is the request struct for PostNewFiatWithdraw
@@ -297,11 +381,33 @@ type PostNewFiatWithdrawRequest struct {
 	// Amount
 	// BankAccountID
 	// required: true
-	Asset         string          `json:"currencyCode" url:"-"`
+	Asset         string          `json:"currencyCode" url:"currencyCode"`
 	Amount        decimal.Decimal `json:"amount" url:"-"`
 	BankAccountID string          `json:"linkedBankAccountId" url:"-"`
 }
 
+// PostPayRequest is the request struct for PostPay
+type PostPayRequest struct {
+	// https://api.valr.com/v1/pay
+	// Currency Code
+	// Amount
+	// required: true
+	// Exactly one of Recipient Email, Cell Number and Pay ID
+	// required: true
+	// Recipient Note
+	// Sender Note
+	// Anonymous (hides the sender's details from the recipient)
+	// required: false
+	Currency            string          `json:"currency" url:"-"`
+	Amount              decimal.Decimal `json:"amount" url:"-"`
+	RecipientEmail      string          `json:"recipientEmail,omitempty" url:"-"`
+	RecipientCellNumber string          `json:"recipientCellNumber,omitempty" url:"-"`
+	RecipientPayID      string          `json:"recipientPayId,omitempty" url:"-"`
+	RecipientNote       string          `json:"recipientNote,omitempty" url:"-"`
+	SenderNote          string          `json:"senderNote,omitempty" url:"-"`
+	Anonymous           bool            `json:"anonymous,omitempty" url:"-"`
+}
+
 // PostSimpleBuyOrSellQuoteRequest is the request stuct for PostSimpleBuyOrSellQuote
 type PostSimpleBuyOrSellQuoteRequest struct {
 	// https://api.valr.com/v1/simple/:currencyPair/quote
@@ -310,7 +416,7 @@ type PostSimpleBuyOrSellQuoteRequest struct {
 	// Pay amount
 	// Side
 	// required: true
-	Pair          string          `json:"currencyPair" url:"-"`
+	Pair          string          `json:"currencyPair" url:"currencyPair"`
 	PayInCurrency string          `json:"payInCurrency" url:"-"`
 	PayAmount     decimal.Decimal `json:"payAmount" url:"-"`
 	Side          RequestSide     `json:"side" url:"-"`
@@ -324,7 +430,7 @@ type PostSimpleBuyOrSellOrderRequest struct {
 	// Pay amount
 	// Side
 	// required: true
-	Pair          string          `json:"currencyPair" url:"-"`
+	Pair          string          `json:"currencyPair" url:"currencyPair"`
 	PayInCurrency string          `json:"payInCurrency" url:"-"`
 	PayAmount     decimal.Decimal `json:"payAmount" url:"-"`
 	Side          RequestSide     `json:"side" url:"-"`
@@ -339,6 +445,8 @@ type PostLimitOrderRequest struct {
 	// Side
 	// required: true
 	// Post Only
+	// Reduce Only (futures only)
+	// Time In Force (defaults to GTC)
 	// Customer Order ID
 	// required: false
 	Pair            string          `json:"pair" url:"-"`
@@ -346,6 +454,8 @@ type PostLimitOrderRequest struct {
 	Price           decimal.Decimal `json:"price" url:"-"`
 	Side            RequestSide     `json:"side" url:"-"`
 	PostOnly        bool            `json:"postOnly" url:"-"`
+	ReduceOnly      bool            `json:"reduceOnly,omitempty" url:"-"`
+	TimeInForce     TimeInForce     `json:"timeInForce,omitempty" url:"-"`
 	CustomerOrderID string          `json:"customerOrderId" url:"-"`
 }
 
@@ -357,11 +467,13 @@ type PostMarketOrderBuyRequest struct {
 	// Side
 	// required: true
 	// Customer Order ID
+	// Reduce Only (futures only)
 	// required: false
 	Side            RequestSide     `json:"side" url:"-"`
 	Quantity        decimal.Decimal `json:"quoteAmount" url:"-"`
 	Pair            string          `json:"pair" url:"-"`
 	CustomerOrderID string          `json:"customerOrderId" url:"-"`
+	ReduceOnly      bool            `json:"reduceOnly,omitempty" url:"-"`
 }
 
 // PostMarketOrderBuyRequest is the request struct for PostMarketOrder
@@ -372,11 +484,88 @@ type PostMarketOrderSellRequest struct {
 	// Side
 	// required: true
 	// Customer Order ID
+	// Reduce Only (futures only)
 	// required: false
 	Side            RequestSide     `json:"side" url:"-"`
 	Quantity        decimal.Decimal `json:"baseAmount" url:"-"`
 	Pair            string          `json:"pair" url:"-"`
 	CustomerOrderID string          `json:"customerOrderId" url:"-"`
+	ReduceOnly      bool            `json:"reduceOnly,omitempty" url:"-"`
+}
+
+// PostMarketOrderBaseAmountRequest is the request struct for a market order
+// on either side sized in the base currency
+type PostMarketOrderBaseAmountRequest struct {
+	// https://api.valr.com/v1/orders/market
+	// Currency Pair
+	// Quantity
+	// Side
+	// required: true
+	// Customer Order ID
+	// Reduce Only
+	// required: false
+	Side            RequestSide     `json:"side" url:"-"`
+	Quantity        decimal.Decimal `json:"baseAmount" url:"-"`
+	Pair            string          `json:"pair" url:"-"`
+	CustomerOrderID string          `json:"customerOrderId" url:"-"`
+	ReduceOnly      bool            `json:"reduceOnly,omitempty" url:"-"`
+}
+
+// PostStopLimitOrderRequest is the request struct for PostStopLimitOrder
+type PostStopLimitOrderRequest struct {
+	// https://api.valr.com/v1/orders/stop/limit
+	// Currency Pair
+	// Quantity
+	// Price
+	// Stop Price
+	// Side
+	// Type
+	// required: true
+	// Time In Force (defaults to GTC)
+	// Customer Order ID
+	// required: false
+	Pair            string          `json:"pair" url:"-"`
+	Quantity        decimal.Decimal `json:"quantity" url:"-"`
+	Price           decimal.Decimal `json:"price" url:"-"`
+	StopPrice       decimal.Decimal `json:"stopPrice" url:"-"`
+	Side            RequestSide     `json:"side" url:"-"`
+	Type            StopLimitType   `json:"type" url:"-"`
+	TimeInForce     TimeInForce     `json:"timeInForce,omitempty" url:"-"`
+	CustomerOrderID string          `json:"customerOrderId,omitempty" url:"-"`
+}
+
+// PostBatchOrdersRequest is the request struct for PostBatchOrders
+type PostBatchOrdersRequest struct {
+	// https://api.valr.com/v1/batch/orders
+	// Requests, see BatchLimitOrder and the other BatchOrder constructors
+	// required: true
+	Requests []BatchOrder `json:"requests" url:"-"`
+}
+
+// PostSubaccountRequest is the request struct for PostSubaccount
+type PostSubaccountRequest struct {
+	// https://api.valr.com/v1/account/subaccount
+	// Label
+	// required: true
+	Label string `json:"label" url:"-"`
+}
+
+// PostSubaccountTransferRequest is the request struct for
+// PostSubaccountTransfer
+type PostSubaccountTransferRequest struct {
+	// https://api.valr.com/v1/account/subaccounts/transfer
+	// From Account ID, "0" for the primary account
+	// To Account ID, "0" for the primary account
+	// Currency Code
+	// Amount
+	// required: true
+	// Allow Borrow
+	// required: false
+	FromID      string          `json:"fromId" url:"-"`
+	ToID        string          `json:"toId" url:"-"`
+	Currency    string          `json:"currencyCode" url:"-"`
+	Amount      decimal.Decimal `json:"amount" url:"-"`
+	AllowBorrow bool            `json:"allowBorrow,omitempty" url:"-"`
 }
 
 /*
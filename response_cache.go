@@ -0,0 +1,136 @@
+package valr
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups public endpoints whose responses change at a similar
+// rate, so they can share a cache TTL.
+type EndpointClass string
+
+const (
+	// ClassReference covers currencies, pairs and order types.
+	ClassReference EndpointClass = "reference"
+	// ClassMarketSummary covers the market summary endpoints.
+	ClassMarketSummary EndpointClass = "marketSummary"
+	// ClassOrderBook covers the public order book.
+	ClassOrderBook EndpointClass = "orderBook"
+)
+
+// maxCacheEntries bounds the response cache before expired entries are
+// purged.
+const maxCacheEntries = 1024
+
+// DefaultCacheTTLs returns suggested TTLs: reference data rarely changes,
+// while market summaries and order books are only briefly reused.
+func DefaultCacheTTLs() map[EndpointClass]time.Duration {
+	return map[EndpointClass]time.Duration{
+		ClassReference:     5 * time.Minute,
+		ClassMarketSummary: time.Second,
+		ClassOrderBook:     0,
+	}
+}
+
+// endpointClass classifies a public path template. Endpoints that must not
+// be cached, such as server time, return an empty class.
+func endpointClass(path string) EndpointClass {
+	path = strings.TrimLeft(path, "/")
+	if !strings.HasPrefix(path, "public/") {
+		return ""
+	}
+	switch {
+	case strings.HasSuffix(path, "/currencies"),
+		strings.HasPrefix(path, "public/pairs"),
+		strings.HasSuffix(path, "/ordertypes"):
+		return ClassReference
+	case strings.HasSuffix(path, "/marketsummary"):
+		return ClassMarketSummary
+	case strings.HasSuffix(path, "/orderbook"):
+		return ClassOrderBook
+	default:
+		return ""
+	}
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// responseCache holds raw response bodies of public GET requests, keyed by
+// URL.
+type responseCache struct {
+	ttls map[EndpointClass]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+func newResponseCache(ttls map[EndpointClass]time.Duration) *responseCache {
+	c := &responseCache{
+		ttls:    make(map[EndpointClass]time.Duration, len(ttls)),
+		entries: make(map[string]cachedResponse),
+	}
+	for class, ttl := range ttls {
+		c.ttls[class] = ttl
+	}
+	return c
+}
+
+func (c *responseCache) get(url string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[url]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+func (c *responseCache) put(path, url string, body []byte) {
+	ttl := c.ttls[endpointClass(path)]
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) < maxCacheEntries {
+		c.entries[url] = cachedResponse{body: body, expires: now.Add(ttl)}
+	}
+}
+
+func (c *responseCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cachedResponse)
+}
+
+// SetResponseCache enables an in-memory cache of public market data
+// responses, with a TTL per endpoint class. Classes without a positive TTL
+// are not cached; see DefaultCacheTTLs for suggested values. Cached
+// responses are served without using the rate limit. Pass nil to disable
+// caching.
+func (cl *Client) SetResponseCache(ttls map[EndpointClass]time.Duration) {
+	if ttls == nil {
+		cl.cache = nil
+		return
+	}
+	cl.cache = newResponseCache(ttls)
+}
+
+// PurgeResponseCache discards all cached responses.
+func (cl *Client) PurgeResponseCache() {
+	if cl.cache != nil {
+		cl.cache.purge()
+	}
+}
@@ -1,8 +1,6 @@
 package valr
 
 import (
-	"time"
-
 	"github.com/shopspring/decimal"
 )
 
@@ -13,13 +11,22 @@ PUBLIC API GET RESPONSES
 // GetServerTimeResponse is the struct that GetServerTime responses are unpacked into
 type GetServerTimeResponse struct {
 	EpochTime int       `json:"epochTime"`
-	Time      time.Time `json:"time"`
+	Time      Timestamp `json:"time"`
 }
 
 /*
 PRIVATE API GET RESPONSES
 */
 
+// GetCurrentAPIKeyResponse is the struct that GetCurrentAPIKey responses are unpacked into
+type GetCurrentAPIKeyResponse struct {
+	Label                string    `json:"label"`
+	Permissions          []string  `json:"permissions"`
+	AddedAt              Timestamp `json:"addedAt"`
+	IsSubAccount         bool      `json:"isSubAccount"`
+	AllowedIPAddressCIDR string    `json:"allowedIpAddressCidr"`
+}
+
 // GetDepositAddressResponse is the struct that GetDepositAddress responses are unpacked into
 type GetDepositAddressResponse struct {
 	Currency string `json:"currency"`
@@ -33,6 +40,37 @@ type GetWithdrawInfoResponse struct {
 	Active                   bool            `json:"isActive"`
 	WithdrawCost             decimal.Decimal `json:"withdrawCost"`
 	SupportsPaymentReference bool            `json:"supportsPaymentReference"`
+	// SupportedNetworks lists the networks the currency can be withdrawn
+	// on, for currencies available on more than one.
+	SupportedNetworks []WithdrawNetworkInfo `json:"supportedNetworks"`
+}
+
+// WithdrawNetworkInfo is the withdrawal information of a currency on a
+// single network
+type WithdrawNetworkInfo struct {
+	NetworkType              string          `json:"networkType"`
+	MinimumWithdrawAmount    decimal.Decimal `json:"minimumWithdrawAmount"`
+	Active                   bool            `json:"isActive"`
+	WithdrawCost             decimal.Decimal `json:"withdrawCost"`
+	SupportsPaymentReference bool            `json:"supportsPaymentReference"`
+}
+
+// GetPayLimitsResponse is the struct that GetPayLimits responses are unpacked into
+type GetPayLimitsResponse struct {
+	MaxPaymentAmount decimal.Decimal `json:"maxPaymentAmount"`
+	MinPaymentAmount decimal.Decimal `json:"minPaymentAmount"`
+	LimitRemaining   decimal.Decimal `json:"limitRemaining"`
+	LimitCurrency    string          `json:"limitCurrency"`
+}
+
+// GetPayStatusResponse is the struct that GetPayStatus responses are unpacked into
+type GetPayStatusResponse struct {
+	Identifier    string          `json:"identifier"`
+	TransactionID string          `json:"transactionId"`
+	Currency      string          `json:"currency"`
+	Amount        decimal.Decimal `json:"amount"`
+	Status        string          `json:"status"`
+	Timestamp     Timestamp       `json:"timestamp"`
 }
 
 // GetSimpleBuyOrSellOrderStatusResponse is the struct that GetSimpleBuyOrSellOrderStatus responses are unpacked into
@@ -45,13 +83,13 @@ type GetSimpleBuyOrSellOrderStatusResponse struct {
 	ReceiveAmount   decimal.Decimal `json:"receivedAmount"`
 	FeeAmount       decimal.Decimal `json:"feeAmount"`
 	FeeCurrency     string          `json:"feeCurrency"`
-	OrderExecutedAt time.Time       `json:"orderExecutedAt"`
+	OrderExecutedAt Timestamp       `json:"orderExecutedAt"`
 }
 
 // GetOrderStatusByOrderIDResponse is the struct that GetOrderStatusByOrderID responses are unpacked into
 type GetOrderStatusByOrderIDResponse struct {
 	OrderID           string          `json:"orderId"`
-	OrderStatusType   bool            `json:"orderStatusType"`
+	OrderStatusType   string          `json:"orderStatusType"`
 	CurrencyPair      string          `json:"currencyPair"`
 	OriginalPrice     decimal.Decimal `json:"originalPrice"`
 	RemainingQuantity decimal.Decimal `json:"remainingQuantity"`
@@ -60,14 +98,14 @@ type GetOrderStatusByOrderIDResponse struct {
 	OrderType         string          `json:"orderType"`
 	FailedReason      string          `json:"failedReason"`
 	CustomerOrderID   string          `json:"customerOrderId"`
-	OrderUpdatedAt    time.Time       `json:"orderUpdatedAt"`
-	OrderCreatedAt    time.Time       `json:"orderCreatedAt"`
+	OrderUpdatedAt    Timestamp       `json:"orderUpdatedAt"`
+	OrderCreatedAt    Timestamp       `json:"orderCreatedAt"`
 }
 
 // GetOrderHistorySummaryByOrderIDResponse is the struct that GetOrderHistorySummaryByOrderID responses are unpacked into
 type GetOrderHistorySummaryByOrderIDResponse struct {
 	OrderID           string          `json:"orderId"`
-	OrderStatusType   bool            `json:"orderStatusType"`
+	OrderStatusType   string          `json:"orderStatusType"`
 	Pair              string          `json:"currencyPair"`
 	AveragePrice      decimal.Decimal `json:"averagePrice"`
 	OriginalPrice     decimal.Decimal `json:"originalPrice"`
@@ -79,15 +117,15 @@ type GetOrderHistorySummaryByOrderIDResponse struct {
 	OrderSide         ResponseSide    `json:"orderSide"`
 	OrderType         string          `json:"orderType"`
 	FailedReason      string          `json:"failedReason"`
-	OrderUpdatedAt    time.Time       `json:"orderUpdatedAt"`
-	OrderCreatedAt    time.Time       `json:"orderCreatedAt"`
+	OrderUpdatedAt    Timestamp       `json:"orderUpdatedAt"`
+	OrderCreatedAt    Timestamp       `json:"orderCreatedAt"`
 }
 
 // GetOrderHistorySummaryByCustomerOrderIDResponse is the struct that GetOrderHistorySummaryByCustomerOrderID responses are unpacked into
 type GetOrderHistorySummaryByCustomerOrderIDResponse struct {
 	OrderID           string          `json:"orderId"`
 	CustomerOrderID   string          `json:"customerOrderId"`
-	OrderStatusType   bool            `json:"orderStatusType"`
+	OrderStatusType   string          `json:"orderStatusType"`
 	Pair              string          `json:"currencyPair"`
 	AveragePrice      decimal.Decimal `json:"averagePrice"`
 	OriginalPrice     decimal.Decimal `json:"originalPrice"`
@@ -99,8 +137,8 @@ type GetOrderHistorySummaryByCustomerOrderIDResponse struct {
 	OrderSide         ResponseSide    `json:"orderSide"`
 	OrderType         string          `json:"orderType"`
 	FailedReason      string          `json:"failedReason"`
-	OrderUpdatedAt    time.Time       `json:"orderUpdatedAt"`
-	OrderCreatedAt    time.Time       `json:"orderCreatedAt"`
+	OrderUpdatedAt    Timestamp       `json:"orderUpdatedAt"`
+	OrderCreatedAt    Timestamp       `json:"orderCreatedAt"`
 }
 
 /*
@@ -117,6 +155,12 @@ type PostNewFiatWithdrawResponse struct {
 	ID string `json:"id"`
 }
 
+// PostPayResponse is the struct that PostPay responses are unpacked into
+type PostPayResponse struct {
+	Identifier    string `json:"identifier"`
+	TransactionID string `json:"transactionId"`
+}
+
 // PostSimpleBuyOrSellQuoteResponse is the struct that PostSimpleBuyOrSellQuote responses are unpacked into
 type PostSimpleBuyOrSellQuoteResponse struct {
 	Pair          string          `json:"currencyPair"`
@@ -124,12 +168,13 @@ type PostSimpleBuyOrSellQuoteResponse struct {
 	ReceiveAmount decimal.Decimal `json:"receiveAmount"`
 	Fee           decimal.Decimal `json:"fee"`
 	FeeCurrency   string          `json:"feeCurrency"`
-	CreatedAt     time.Time       `json:"createdAt"`
+	CreatedAt     Timestamp       `json:"createdAt"`
 	OrderID       string          `json:"id"`
 }
 
 // PostSimpleBuyOrSellOrderResponse is the struct that PostSimpleBuyOrSellOrder responses are unpacked into
 type PostSimpleBuyOrSellOrderResponse struct {
+	OrderID string `json:"id"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
@@ -144,6 +189,27 @@ type PostMarketOrderResponse struct {
 	ID string `json:"id"`
 }
 
+// PostStopLimitOrderResponse is the struct that PostStopLimitOrder responses are unpacked into
+type PostStopLimitOrderResponse struct {
+	ID string `json:"id"`
+}
+
+// PostBatchOrdersResponse is the struct that PostBatchOrders responses are
+// unpacked into. Outcomes are in the order of the batch's requests.
+type PostBatchOrdersResponse struct {
+	Outcomes []BatchOrderOutcome `json:"outcomes"`
+}
+
+// PostSubaccountResponse is the struct that PostSubaccount responses are unpacked into
+type PostSubaccountResponse struct {
+	ID string `json:"id"`
+}
+
+// PostSubaccountTransferResponse is the struct that PostSubaccountTransfer responses are unpacked into
+type PostSubaccountTransferResponse struct {
+	ID string `json:"id"`
+}
+
 /*
 PRIVATE API DEL RESPONSES
 */
@@ -0,0 +1,138 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures the automatic retry of calls that failed because
+// VALR rate limited them, returned a server error or timed out. Rate
+// limited calls are retried for every method, since VALR rejected them
+// without processing them. Other failures may have happened after VALR
+// acted on the call, so they are only retried for calls that are safe to
+// repeat: GET and DELETE requests, and orders with a customer order ID,
+// which VALR won't accept twice. Every attempt is sent with a fresh body
+// and signature.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// MinBackoff is the wait before the first retry. It doubles with every
+	// further retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomises each backoff by up to this fraction, between 0 and
+	// 1, so clients that failed together don't retry together.
+	Jitter float64
+	// Rand, if set, is the source of the jitter rather than the global
+	// source, e.g. a clock.NewRand to replay a run deterministically.
+	Rand *rand.Rand
+}
+
+// DefaultRetryPolicy returns a policy making up to 5 retries with backoff
+// between 500ms and 30s.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries: 5,
+		MinBackoff: 500 * time.Millisecond,
+		MaxBackoff: 30 * time.Second,
+		Jitter:     0.2,
+	}
+}
+
+// SetRetryPolicy retries failed calls according to p. Calls are retried
+// within the deadline of their context: a retry that would overrun it fails
+// with a DeadlineBudgetError. Pass nil to disable retries, the default.
+func (cl *Client) SetRetryPolicy(p *RetryPolicy) {
+	cl.retryPolicy = p
+}
+
+// backoff returns how long to wait before retrying a call that failed with
+// err after attempts attempts, or false if it must not be retried.
+func (p *RetryPolicy) backoff(method string, req any, attempts int, err error) (time.Duration, bool) {
+	if p == nil || attempts == 0 || attempts > p.MaxRetries || !IsRetryable(err) {
+		return 0, false
+	}
+	var apiErr *APIError
+	rateLimited := errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+	if !rateLimited && !replayable(method, req) {
+		return 0, false
+	}
+	if apiErr != nil && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	d := p.MinBackoff
+	for i := 1; i < attempts && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		f := rand.Float64
+		if p.Rand != nil {
+			f = p.Rand.Float64
+		}
+		d -= time.Duration(f() * p.Jitter * float64(d))
+	}
+	return d, true
+}
+
+// idempotent is implemented by requests carrying a key that stops VALR from
+// acting on them twice.
+type idempotent interface {
+	idempotencyKey() string
+}
+
+func (r *PostLimitOrderRequest) idempotencyKey() string            { return r.CustomerOrderID }
+func (r *PostMarketOrderBuyRequest) idempotencyKey() string        { return r.CustomerOrderID }
+func (r *PostMarketOrderSellRequest) idempotencyKey() string       { return r.CustomerOrderID }
+func (r *PostMarketOrderBaseAmountRequest) idempotencyKey() string { return r.CustomerOrderID }
+func (r *PostStopLimitOrderRequest) idempotencyKey() string        { return r.CustomerOrderID }
+
+// replayable returns true if a call may be repeated without risk of VALR
+// acting on it twice, even if the first attempt reached VALR.
+func replayable(method string, req any) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	k, ok := req.(idempotent)
+	return ok && k.idempotencyKey() != ""
+}
+
+// sleep waits for d, accounting the time spent against the budget. It fails
+// without waiting if d would overrun the deadline.
+func (b *budget) sleep(ctx context.Context, d time.Duration) error {
+	if rem := b.remaining(); rem >= 0 && rem < d {
+		return b.exceeded(d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	start := time.Now()
+	defer func() { b.waited += time.Since(start) }()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or
+// as an HTTP date. It returns 0 if the header is absent or invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,165 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// failingServer responds with status to the first failures requests and
+// with body thereafter, counting the requests received.
+func failingServer(t *testing.T, status, failures int, header http.Header, body string) (*valr.Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":-11,"message":"slow down"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+	cl.SetRetryPolicy(&valr.RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	return cl, &calls
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	cl, calls := failingServer(t, http.StatusServiceUnavailable, 2, nil, `{"epochTime":1}`)
+	res, err := cl.GetServerTimeRequest(context.Background(), &valr.GetServerTimeRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.EpochTime != 1 {
+		t.Errorf("Expected %d, got %d", 1, res.EpochTime)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected %d calls, got %d", 3, calls.Load())
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	cl, calls := failingServer(t, http.StatusTooManyRequests, 10, nil, `{}`)
+	_, err := cl.GetServerTimeRequest(context.Background(), &valr.GetServerTimeRequest{})
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != -11 || apiErr.Message != "slow down" {
+		t.Errorf("Expected parsed 429 error, got %+v", apiErr)
+	}
+	if !errors.Is(err, valr.ErrTooManyRequests) {
+		t.Errorf("Expected ErrTooManyRequests, got %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("Expected %d calls, got %d", 4, calls.Load())
+	}
+}
+
+func TestRetryNonIdempotentServerError(t *testing.T) {
+	cl, calls := failingServer(t, http.StatusInternalServerError, 10, nil, `{}`)
+	_, err := cl.PostLimitOrderRequest(context.Background(), &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY,
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("100"),
+	})
+	if err == nil {
+		t.Fatal("Expected error, got success")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected %d call, got %d", 1, calls.Load())
+	}
+}
+
+func TestRetryAfterDeadline(t *testing.T) {
+	cl, calls := failingServer(t, http.StatusTooManyRequests, 10, http.Header{"Retry-After": {"5"}}, `{}`)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := cl.GetServerTimeRequest(ctx, &valr.GetServerTimeRequest{})
+	if !errors.Is(err, valr.ErrDeadlineBudgetExceeded) {
+		t.Fatalf("Expected ErrDeadlineBudgetExceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected call to fail fast, took %s", time.Since(start))
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected %d call, got %d", 1, calls.Load())
+	}
+}
+
+func TestRetryAfterPausesLimiter(t *testing.T) {
+	cl, _ := failingServer(t, http.StatusTooManyRequests, 1, http.Header{"Retry-After": {"5"}}, `{}`)
+	cl.SetRetryPolicy(nil)
+
+	_, err := cl.GetServerTimeRequest(context.Background(), &valr.GetServerTimeRequest{})
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 5*time.Second {
+		t.Fatalf("Expected RetryAfter of 5s, got %v", err)
+	}
+
+	// Other calls are held back by the client's rate limiter.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = cl.GetServerTimeRequest(ctx, &valr.GetServerTimeRequest{})
+	if !errors.Is(err, valr.ErrDeadlineBudgetExceeded) {
+		t.Errorf("Expected ErrDeadlineBudgetExceeded, got %v", err)
+	}
+}
+
+func TestRetryReplaysBodyWithFreshSignature(t *testing.T) {
+	type attempt struct{ body, timestamp, signature string }
+	var attempts []attempt
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		attempts = append(attempts, attempt{string(body), r.Header.Get("X-VALR-TIMESTAMP"), r.Header.Get("X-VALR-SIGNATURE")})
+		if len(attempts) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"id":"o1"}`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetAuth("key", "secret")
+	cl.SetRetryPolicy(&valr.RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond})
+
+	res, err := cl.PostLimitOrderRequest(context.Background(), &valr.PostLimitOrderRequest{
+		Pair: "BTCZAR", Side: valr.BUY, CustomerOrderID: "c1",
+		Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("100"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if res.ID != "o1" {
+		t.Errorf("Expected %q, got %q", "o1", res.ID)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expected %d attempts, got %d", 3, len(attempts))
+	}
+	for i := 1; i < len(attempts); i++ {
+		if attempts[i].body == "" || attempts[i].body != attempts[0].body {
+			t.Errorf("Expected body %q, got %q", attempts[0].body, attempts[i].body)
+		}
+		if attempts[i].timestamp == attempts[i-1].timestamp || attempts[i].signature == attempts[i-1].signature {
+			t.Errorf("Expected a fresh signature for attempt %d", i+1)
+		}
+	}
+}
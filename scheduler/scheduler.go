@@ -0,0 +1,189 @@
+// Package scheduler runs periodic API tasks in-process, such as reference
+// data refreshes and balance snapshots. Tasks started together would fire
+// together forever, so the scheduler staggers their first runs and jitters
+// every interval, and it holds tasks back while the client's rate limiter is
+// short of requests, so that many tasks don't synchronise into bursts that
+// VALR answers with 429s.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+)
+
+const (
+	defaultJitter = 0.1
+	// deferral is how long a task waits for the rate limiter to recover
+	// before checking again.
+	deferral = 5 * time.Second
+)
+
+// Task is a periodic task. Errors are logged and the task runs again at
+// its next interval.
+type Task func(ctx context.Context) error
+
+type Option func(*Scheduler)
+
+// WithClock sets the clock of intervals, clock.Real by default.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// WithRand sets the source of jitter, which must be safe for concurrent
+// use, e.g. one returned by clock.NewRand.
+func WithRand(rnd *rand.Rand) Option {
+	return func(s *Scheduler) {
+		s.rnd = rnd
+	}
+}
+
+// WithRateLimit defers tasks that fall due while remaining reports fewer
+// than min requests left, e.g. with Client.RateLimitRemaining, until the
+// limiter recovers. Deferred tasks are checked again every few seconds,
+// with jitter so they don't resume together.
+func WithRateLimit(remaining func() (int, bool), min int) Option {
+	return func(s *Scheduler) {
+		s.remaining, s.minRemaining = remaining, min
+	}
+}
+
+type TaskOption func(*task)
+
+// WithJitter varies each interval of the task uniformly by up to fraction
+// of it either way, 0.1 by default.
+func WithJitter(fraction float64) TaskOption {
+	return func(t *task) {
+		t.jitter = fraction
+	}
+}
+
+// WithImmediate runs the task as soon as the scheduler starts. By default
+// the first run is at a random point of the first interval, so tasks
+// started together are spread out.
+func WithImmediate() TaskOption {
+	return func(t *task) {
+		t.immediate = true
+	}
+}
+
+type task struct {
+	name      string
+	interval  time.Duration
+	fn        Task
+	jitter    float64
+	immediate bool
+}
+
+// Scheduler runs tasks periodically.
+type Scheduler struct {
+	clock        clock.Clock
+	rnd          *rand.Rand
+	remaining    func() (int, bool)
+	minRemaining int
+
+	mu    sync.Mutex
+	tasks []*task
+	// ctx is the context of Run while it is running.
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// New returns a scheduler without tasks.
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{clock: clock.Real}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.rnd == nil {
+		s.rnd = clock.NewRand(time.Now().UnixNano())
+	}
+	return s
+}
+
+// Every adds a task running fn about every interval, measured from the end
+// of one run to the start of the next, so runs of a task never overlap.
+// Tasks added while the scheduler is running start at once.
+func (s *Scheduler) Every(name string, interval time.Duration, fn Task, opts ...TaskOption) {
+	t := &task{name: name, interval: interval, fn: fn, jitter: defaultJitter}
+	for _, opt := range opts {
+		opt(t)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, t)
+	if s.ctx != nil {
+		s.start(s.ctx, t)
+	}
+}
+
+// Run runs the tasks until ctx is done, then waits for runs in progress to
+// return.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	for _, t := range s.tasks {
+		s.start(ctx, t)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+	return ctx.Err()
+}
+
+// start runs t in a goroutine. It must be called with s.mu held.
+func (s *Scheduler) start(ctx context.Context, t *task) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(ctx, t)
+	}()
+}
+
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	var delay time.Duration
+	if !t.immediate {
+		delay = time.Duration(s.rnd.Int63n(int64(t.interval) + 1))
+	}
+	for {
+		if !clock.Sleep(s.clock, delay, ctx.Done()) || !s.awaitHeadroom(ctx) {
+			return
+		}
+		if err := t.fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/scheduler: Task %s failed: %v", t.name, err)
+		}
+		delay = s.jittered(t.interval, t.jitter)
+	}
+}
+
+// awaitHeadroom blocks until the rate limiter has enough requests left,
+// returning false if ctx is done first.
+func (s *Scheduler) awaitHeadroom(ctx context.Context) bool {
+	for s.remaining != nil {
+		if n, ok := s.remaining(); !ok || n >= s.minRemaining {
+			break
+		}
+		if !clock.Sleep(s.clock, s.jittered(deferral, 0.5), ctx.Done()) {
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// jittered returns d varied uniformly by up to fraction of it either way.
+func (s *Scheduler) jittered(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return d + time.Duration((s.rnd.Float64()*2-1)*fraction*float64(d))
+}
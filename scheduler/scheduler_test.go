@@ -0,0 +1,101 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/scheduler"
+)
+
+func TestSchedulerJitter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	s := scheduler.New(scheduler.WithClock(clk), scheduler.WithRand(clock.NewRand(1)))
+
+	var mu sync.Mutex
+	runs := make(map[string][]time.Time)
+	task := func(name string) scheduler.Task {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[name] = append(runs[name], clk.Now())
+			return nil
+		}
+	}
+	s.Every("a", time.Minute, task("a"), scheduler.WithImmediate())
+	s.Every("b", time.Minute, task("b"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	for i := 0; i < 60; i++ {
+		clk.BlockUntil(2)
+		clk.Advance(10 * time.Second)
+	}
+	clk.BlockUntil(2)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs["a"]) == 0 || !runs["a"][0].Equal(start) {
+		t.Fatalf("Expected a to run at once, got %v", runs["a"])
+	}
+	for name, times := range runs {
+		if len(times) < 8 || len(times) > 12 {
+			t.Errorf("%s: Expected about 10 runs, got %d", name, len(times))
+		}
+		// Runs are observed at the 10s steps of the clock, so intervals
+		// of 54s to 66s are seen as 50s to 70s.
+		for i := 1; i < len(times); i++ {
+			if d := times[i].Sub(times[i-1]); d < 50*time.Second || d > 70*time.Second {
+				t.Errorf("%s: Expected a jittered minute between runs, got %s", name, d)
+			}
+		}
+	}
+}
+
+func TestSchedulerRateLimit(t *testing.T) {
+	clk := clock.NewManual(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var remaining atomic.Int64
+	s := scheduler.New(
+		scheduler.WithClock(clk),
+		scheduler.WithRand(clock.NewRand(1)),
+		scheduler.WithRateLimit(func() (int, bool) { return int(remaining.Load()), true }, 10),
+	)
+	var runs atomic.Int64
+	ran := make(chan struct{}, 1)
+	s.Every("a", time.Hour, func(context.Context) error {
+		runs.Add(1)
+		ran <- struct{}{}
+		return errors.New("failed")
+	}, scheduler.WithImmediate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	for i := 0; i < 5; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(10 * time.Second)
+	}
+	if n := runs.Load(); n != 0 {
+		t.Fatalf("Expected the task deferred, got %d runs", n)
+	}
+
+	remaining.Store(100)
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Second)
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the task to run once the limiter recovered")
+	}
+}
@@ -0,0 +1,360 @@
+package valr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultZeroThreshold  = 0.95
+	defaultZeroMinSamples = 20
+)
+
+// Kinds of schema mismatch.
+const (
+	// SchemaUnknownField is a response field the response type doesn't
+	// declare, e.g. one VALR has added.
+	SchemaUnknownField = "unknownField"
+	// SchemaZeroField is a declared field that is zero suspiciously often,
+	// e.g. one VALR has renamed or removed.
+	SchemaZeroField = "zeroField"
+)
+
+// SchemaEvent reports a mismatch between the responses of an endpoint and
+// the type they are decoded into.
+type SchemaEvent struct {
+	Endpoint Endpoint `json:"endpoint"`
+	Kind     string   `json:"kind"`
+	// Field is the JSON path of the field, e.g. "currencyPair", or
+	// "[].data.price" in arrays and nested objects.
+	Field string `json:"field"`
+	// Count is the number of times the field was unknown or zero.
+	Count uint64 `json:"count"`
+	// Samples is the number of times the field's object was decoded.
+	Samples uint64 `json:"samples"`
+	// SampleHash is the SHA-256 of a response body showing the mismatch,
+	// truncated to 16 hex digits, to match against logged responses
+	// without exposing their contents.
+	SampleHash string `json:"sampleHash"`
+}
+
+// SchemaMonitor watches successfully decoded responses for signs that
+// VALR's schema has changed: fields the response types don't declare, and
+// declared fields that are zero in most responses. Each mismatch is reported
+// to the monitor's hook once, when first detected, and counted thereafter.
+// Booleans, numbers and fields tagged omitempty, which are often
+// legitimately zero, are not checked for zero values.
+type SchemaMonitor struct {
+	hook          func(SchemaEvent)
+	zeroThreshold float64
+	minSamples    uint64
+
+	mu     sync.Mutex
+	fields map[schemaKey]*schemaStats
+}
+
+type schemaKey struct {
+	endpoint Endpoint
+	kind     string
+	field    string
+}
+
+type schemaStats struct {
+	count, samples uint64
+	sampleHash     string
+	reported       bool
+}
+
+type SchemaOption func(*SchemaMonitor)
+
+// WithSchemaHook sets a function receiving each mismatch when detected.
+func WithSchemaHook(fn func(SchemaEvent)) SchemaOption {
+	return func(m *SchemaMonitor) {
+		m.hook = fn
+	}
+}
+
+// WithZeroThreshold reports declared fields that are zero in at least
+// fraction of at least minSamples decoded objects, by default 95% of 20.
+func WithZeroThreshold(fraction float64, minSamples int) SchemaOption {
+	return func(m *SchemaMonitor) {
+		m.zeroThreshold, m.minSamples = fraction, uint64(minSamples)
+	}
+}
+
+// NewSchemaMonitor returns a monitor that has seen no responses.
+func NewSchemaMonitor(opts ...SchemaOption) *SchemaMonitor {
+	m := &SchemaMonitor{
+		zeroThreshold: defaultZeroThreshold,
+		minSamples:    defaultZeroMinSamples,
+		fields:        make(map[schemaKey]*schemaStats),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// SetSchemaMonitor checks every response the client decodes with m. Pass
+// nil to stop checking.
+func (cl *Client) SetSchemaMonitor(m *SchemaMonitor) {
+	cl.schema = m
+}
+
+// Mismatches returns the counters of every mismatch detected so far,
+// sorted by endpoint and field.
+func (m *SchemaMonitor) Mismatches() []SchemaEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []SchemaEvent
+	for k, s := range m.fields {
+		if s.reported {
+			events = append(events, k.event(s))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Endpoint != b.Endpoint {
+			if a.Endpoint.Path != b.Endpoint.Path {
+				return a.Endpoint.Path < b.Endpoint.Path
+			}
+			return a.Endpoint.Method < b.Endpoint.Method
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Kind < b.Kind
+	})
+	return events
+}
+
+func (k schemaKey) event(s *schemaStats) SchemaEvent {
+	return SchemaEvent{
+		Endpoint:   k.endpoint,
+		Kind:       k.kind,
+		Field:      k.field,
+		Count:      s.count,
+		Samples:    s.samples,
+		SampleHash: s.sampleHash,
+	}
+}
+
+// observe checks the response body of a call to method and path, already
+// decoded into res.
+func (m *SchemaMonitor) observe(method, path string, body []byte, res any) {
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return
+	}
+	w := schemaWalk{
+		endpoint: Endpoint{Method: method, Path: "/" + strings.TrimLeft(path, "/")},
+		hash:     hashBody(body),
+	}
+	w.walk("", raw, reflect.ValueOf(res))
+
+	var events []SchemaEvent
+	m.mu.Lock()
+	for _, o := range w.observations {
+		key := schemaKey{endpoint: w.endpoint, kind: o.kind, field: o.field}
+		s := m.fields[key]
+		if s == nil {
+			s = new(schemaStats)
+			m.fields[key] = s
+		}
+		s.samples++
+		if !o.hit {
+			continue
+		}
+		s.count++
+		if s.sampleHash == "" {
+			s.sampleHash = w.hash
+		}
+		if !s.reported && m.mismatched(o.kind, s) {
+			s.reported = true
+			events = append(events, key.event(s))
+		}
+	}
+	m.mu.Unlock()
+
+	if m.hook != nil {
+		for _, ev := range events {
+			m.hook(ev)
+		}
+	}
+}
+
+// mismatched returns true once a field's counters show a mismatch.
+func (m *SchemaMonitor) mismatched(kind string, s *schemaStats) bool {
+	if kind == SchemaUnknownField {
+		return true
+	}
+	return s.samples >= m.minSamples && float64(s.count) >= m.zeroThreshold*float64(s.samples)
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:8])
+}
+
+// schemaWalk collects the observations of a response.
+type schemaWalk struct {
+	endpoint     Endpoint
+	hash         string
+	observations []schemaObservation
+}
+
+// schemaObservation is a field seen in a decoded object. Hit is true if it
+// was unknown or zero. Unknown fields are only observed when hit.
+type schemaObservation struct {
+	kind  string
+	field string
+	hit   bool
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// walk compares the raw JSON value at path with v, the value it was
+// decoded into.
+func (w *schemaWalk) walk(path string, raw any, v reflect.Value) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonUnmarshalerType) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]any)
+		if !ok {
+			return
+		}
+		for i := 0; i < len(arr) && i < v.Len(); i++ {
+			w.walk(path+"[]", arr[i], v.Index(i))
+		}
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		w.walkStruct(path, obj, v)
+	}
+}
+
+func (w *schemaWalk) walkStruct(path string, obj map[string]any, v reflect.Value) {
+	fields := jsonFields(v)
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := lookupField(fields, name); !ok {
+			w.observations = append(w.observations, schemaObservation{SchemaUnknownField, joinField(path, name), true})
+		}
+	}
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.checkZero {
+			w.observations = append(w.observations, schemaObservation{SchemaZeroField, joinField(path, f.name), fv.IsZero()})
+		}
+		if raw, ok := obj[f.name]; ok {
+			w.walk(joinField(path, f.name), raw, fv)
+		} else if name, ok := lookupKey(obj, f.name); ok {
+			w.walk(joinField(path, f.name), obj[name], fv)
+		}
+	}
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonField is a field of a struct as encoding/json sees it.
+type jsonField struct {
+	name      string
+	index     []int
+	checkZero bool
+}
+
+// jsonFields returns the JSON fields of the struct v, including those of
+// embedded structs.
+func jsonFields(v reflect.Value) []jsonField {
+	var fields []jsonField
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			idx := append(append([]int(nil), index...), i)
+			if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+				collect(sf.Type, idx)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			fields = append(fields, jsonField{
+				name:      name,
+				index:     idx,
+				checkZero: !strings.Contains(opts, "omitempty") && zeroCheckable(sf.Type),
+			})
+		}
+	}
+	collect(v.Type(), nil)
+	return fields
+}
+
+// zeroCheckable returns false for types often legitimately zero.
+func zeroCheckable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return false
+	}
+	return true
+}
+
+// lookupField finds the field a JSON key decodes into, matching names
+// case-insensitively like encoding/json.
+func lookupField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// lookupKey finds the key of obj matching name case-insensitively.
+func lookupKey(obj map[string]any, name string) (string, bool) {
+	for k := range obj {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestSchemaMonitor(t *testing.T) {
+	// VALR has renamed "created" and added "quoteVolume".
+	summary := `{"currencyPair":"BTCZAR","askPrice":"2","bidPrice":"1","lastTradedPrice":"1","previousClosePrice":"1",
+		"baseVolume":"10","quoteVolume":"15","highPrice":"2","lowPrice":"1","createdAt":"2024-01-02T03:04:05Z",
+		"changeFromPrevious":"0"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[` + summary + `,` + summary + `]`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+
+	var events []valr.SchemaEvent
+	m := valr.NewSchemaMonitor(
+		valr.WithZeroThreshold(0.9, 3),
+		valr.WithSchemaHook(func(ev valr.SchemaEvent) { events = append(events, ev) }))
+	cl.SetSchemaMonitor(m)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := cl.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{}); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+
+	// Unknown fields are reported at once, zero fields after 3 samples.
+	want := []struct{ kind, field string }{
+		{valr.SchemaUnknownField, "[].createdAt"},
+		{valr.SchemaUnknownField, "[].quoteVolume"},
+		{valr.SchemaZeroField, "[].created"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want[:2] {
+		if ev := events[i]; ev.Kind != w.kind || ev.Field != w.field || ev.Count != 1 {
+			t.Errorf("Expected %s %s, got %+v", w.kind, w.field, ev)
+		}
+	}
+	if ev := events[2]; ev.Kind != valr.SchemaZeroField || ev.Field != "[].created" || ev.Samples != 3 {
+		t.Errorf("Expected the zero field after 3 samples, got %+v", ev)
+	}
+	if ev := events[0]; ev.Endpoint.Path != "/public/marketsummary" || len(ev.SampleHash) != 16 {
+		t.Errorf("Expected the endpoint and a sample hash, got %+v", ev)
+	}
+
+	got := m.Mismatches()
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 mismatches, got %+v", got)
+	}
+	for _, ev := range got {
+		if ev.Count != 4 || ev.Samples != 4 {
+			t.Errorf("Expected 4 of 4 samples, got %+v", ev)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package valr
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// API key permissions, as listed by GetCurrentAPIKeyRequest.
+const (
+	PermissionView     = "View access"
+	PermissionTrade    = "Trade"
+	PermissionTransfer = "Transfer"
+	PermissionWithdraw = "Withdraw"
+)
+
+// ScopeAdvisor records the endpoints a program calls and reports the
+// API key permissions they require, so keys can be provisioned with least
+// privilege: run the program against a key with broad permissions, e.g. in
+// a staging environment, and then issue a key with only those reported.
+//
+// Only REST calls are observed. The account stream requires View access.
+type ScopeAdvisor struct {
+	mu    sync.Mutex
+	calls map[Endpoint]bool
+}
+
+// NewScopeAdvisor returns an advisor that has observed no calls.
+func NewScopeAdvisor() *ScopeAdvisor {
+	return &ScopeAdvisor{calls: make(map[Endpoint]bool)}
+}
+
+// SetScopeAdvisor records every authenticated call the client sends, or
+// attempts, in a. Pass nil to stop recording.
+func (cl *Client) SetScopeAdvisor(a *ScopeAdvisor) {
+	cl.scopes = a
+}
+
+func (a *ScopeAdvisor) observe(method, path string) {
+	e := Endpoint{Method: method, Path: "/" + strings.TrimLeft(path, "/")}
+	a.mu.Lock()
+	a.calls[e] = true
+	a.mu.Unlock()
+}
+
+// ScopeRequirement is a permission and the endpoints called requiring it.
+type ScopeRequirement struct {
+	Permission string     `json:"permission"`
+	Endpoints  []Endpoint `json:"endpoints"`
+}
+
+// ScopeReport lists the permissions required by the calls observed.
+type ScopeReport struct {
+	// Required holds each permission required, in the order of the
+	// Permission constants.
+	Required []ScopeRequirement `json:"required"`
+	// Unclassified are endpoints whose permission isn't known, to be
+	// checked against the VALR documentation.
+	Unclassified []Endpoint `json:"unclassified,omitempty"`
+}
+
+// Permissions returns the permissions required.
+func (r ScopeReport) Permissions() []string {
+	perms := make([]string, len(r.Required))
+	for i, req := range r.Required {
+		perms[i] = req.Permission
+	}
+	return perms
+}
+
+// Missing returns the permissions required but not in granted.
+func (r ScopeReport) Missing(granted []string) []string {
+	var missing []string
+	for _, p := range r.Permissions() {
+		if !slices.Contains(granted, p) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// Excess returns the permissions in granted that no observed call
+// required, candidates for removal from the key.
+func (r ScopeReport) Excess(granted []string) []string {
+	var excess []string
+	for _, p := range granted {
+		if !slices.Contains(r.Permissions(), p) {
+			excess = append(excess, p)
+		}
+	}
+	return excess
+}
+
+// Report returns the permissions required by the calls observed so far.
+func (a *ScopeAdvisor) Report() ScopeReport {
+	a.mu.Lock()
+	calls := make([]Endpoint, 0, len(a.calls))
+	for e := range a.calls {
+		calls = append(calls, e)
+	}
+	a.mu.Unlock()
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Path != calls[j].Path {
+			return calls[i].Path < calls[j].Path
+		}
+		return calls[i].Method < calls[j].Method
+	})
+
+	byPerm := make(map[string][]Endpoint)
+	var report ScopeReport
+	for _, e := range calls {
+		perm := endpointPermission(e.Method, e.Path)
+		if perm == "" {
+			report.Unclassified = append(report.Unclassified, e)
+			continue
+		}
+		byPerm[perm] = append(byPerm[perm], e)
+	}
+	for _, perm := range []string{PermissionView, PermissionTrade, PermissionTransfer, PermissionWithdraw} {
+		if eps := byPerm[perm]; len(eps) > 0 {
+			report.Required = append(report.Required, ScopeRequirement{Permission: perm, Endpoints: eps})
+		}
+	}
+	return report
+}
+
+// endpointPermission returns the permission an authenticated call
+// requires, or "" if it isn't known.
+func endpointPermission(method, path string) string {
+	path = strings.TrimLeft(path, "/")
+	switch {
+	case method == http.MethodGet:
+		return PermissionView
+	case isPlacement(method, path) || strings.HasPrefix(path, "orders/"):
+		return PermissionTrade
+	case path == "account/subaccounts/transfer":
+		return PermissionTransfer
+	case strings.HasPrefix(path, "wallet/") && strings.HasSuffix(path, "/withdraw"):
+		return PermissionWithdraw
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,83 @@
+package valr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestScopeAdvisor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	a := valr.NewScopeAdvisor()
+	cl.SetScopeAdvisor(a)
+
+	ctx := context.Background()
+	calls := []error{
+		func() error { _, err := cl.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{}); return err }(),
+		func() error {
+			_, err := cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+			return err
+		}(),
+		func() error { _, err := cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{}); return err }(),
+		func() error {
+			_, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+				Pair: "BTCZAR", Side: valr.BUY, Quantity: decimal.New(1, -2), Price: decimal.New(500000, 0),
+			})
+			return err
+		}(),
+		func() error {
+			_, err := cl.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: "BTCZAR", ID: "o1"})
+			return err
+		}(),
+		func() error {
+			_, err := cl.PostSubaccountRequest(ctx, &valr.PostSubaccountRequest{Label: "bot"})
+			return err
+		}(),
+	}
+	for i, err := range calls {
+		if err != nil {
+			t.Fatalf("Expected call %d to succeed, got %v", i, err)
+		}
+	}
+
+	r := a.Report()
+	if exp := []string{valr.PermissionView, valr.PermissionTrade}; !reflect.DeepEqual(r.Permissions(), exp) {
+		t.Errorf("Expected %q, got %q", exp, r.Permissions())
+	}
+	trade := []valr.Endpoint{{Method: http.MethodPost, Path: "/orders/limit"}, {Method: http.MethodDelete, Path: "/orders/order"}}
+	if !reflect.DeepEqual(r.Required[1].Endpoints, trade) {
+		t.Errorf("Expected %v, got %v", trade, r.Required[1].Endpoints)
+	}
+	if len(r.Required[0].Endpoints) != 2 {
+		t.Errorf("Expected the public call not to be recorded, got %v", r.Required[0].Endpoints)
+	}
+	if exp := []valr.Endpoint{{Method: http.MethodPost, Path: "/account/subaccount"}}; !reflect.DeepEqual(r.Unclassified, exp) {
+		t.Errorf("Expected %v, got %v", exp, r.Unclassified)
+	}
+
+	granted := []string{valr.PermissionView, valr.PermissionWithdraw}
+	if exp := []string{valr.PermissionTrade}; !reflect.DeepEqual(r.Missing(granted), exp) {
+		t.Errorf("Expected %q, got %q", exp, r.Missing(granted))
+	}
+	if exp := []string{valr.PermissionWithdraw}; !reflect.DeepEqual(r.Excess(granted), exp) {
+		t.Errorf("Expected %q, got %q", exp, r.Excess(granted))
+	}
+}
@@ -0,0 +1,92 @@
+package valr
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// Signer produces the X-VALR-SIGNATURE value for a request. Implementations
+// may delegate to a remote signing service or KMS so that the raw API secret
+// never has to be loaded into this process.
+type Signer interface {
+	Sign(ctx context.Context, timestamp, verb, path string, body []byte) (string, error)
+}
+
+// SignerFunc adapts an ordinary function to the Signer interface.
+type SignerFunc func(ctx context.Context, timestamp, verb, path string, body []byte) (string, error)
+
+// Sign calls fn(ctx, timestamp, verb, path, body).
+func (fn SignerFunc) Sign(ctx context.Context, timestamp, verb, path string, body []byte) (string, error) {
+	return fn(ctx, timestamp, verb, path, body)
+}
+
+// HMACSigner is the default Signer, computing an HMAC-SHA512 of the request
+// with the API secret held in memory. It keeps keyed HMAC states and buffers
+// for reuse, so signing allocates only the returned signature, and is safe
+// for concurrent use.
+type HMACSigner struct {
+	secret string
+	states sync.Pool // of *signState
+}
+
+// NewHMACSigner returns a Signer for the given API secret.
+func NewHMACSigner(apiSecret string) (*HMACSigner, error) {
+	if apiSecret == "" {
+		return nil, errors.New("valr: no api secret provided")
+	}
+	s := &HMACSigner{secret: apiSecret}
+	s.states.New = func() any {
+		return newSignState(apiSecret)
+	}
+	return s, nil
+}
+
+// Sign implements Signer.
+func (s *HMACSigner) Sign(_ context.Context, timestamp, verb, path string, body []byte) (string, error) {
+	st := s.states.Get().(*signState)
+	defer s.states.Put(st)
+	return st.sign(timestamp, verb, path, body), nil
+}
+
+// SignRequest returns the signature of a request made with apiSecret. It
+// keys a new HMAC on every call; use an HMACSigner to sign many requests
+// with the same secret.
+func SignRequest(apiSecret string, timestampString, verb, path string, body []byte) string {
+	return newSignState(apiSecret).sign(timestampString, verb, path, body)
+}
+
+// signState is an HMAC keyed with the API secret along with the buffers
+// used to sign a request. It is not safe for concurrent use.
+type signState struct {
+	mac hash.Hash
+	// prefix holds the timestamp, verb and path, written as one.
+	prefix []byte
+	sum    [sha512.Size]byte
+	hex    [2 * sha512.Size]byte
+}
+
+func newSignState(apiSecret string) *signState {
+	// Create a new Keyed-Hash Message Authentication Code (HMAC) using SHA512 and API Secret
+	return &signState{
+		mac:    hmac.New(sha512.New, []byte(apiSecret)),
+		prefix: make([]byte, 0, 128),
+	}
+}
+
+func (st *signState) sign(timestamp, verb, path string, body []byte) string {
+	st.mac.Reset()
+	st.prefix = append(st.prefix[:0], timestamp...)
+	st.prefix = append(st.prefix, strings.ToUpper(verb)...)
+	st.prefix = append(st.prefix, path...)
+	st.mac.Write(st.prefix)
+	st.mac.Write(body)
+	// Gets the byte hash from HMAC and converts it into a hex string
+	hex.Encode(st.hex[:], st.mac.Sum(st.sum[:0]))
+	return string(st.hex[:])
+}
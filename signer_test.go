@@ -0,0 +1,93 @@
+package valr_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+var signBody = []byte(`{"customerOrderId":"ORDER-000001","pair":"BTCZAR","side":"BUY","quantity":"0.001","price":"1000000","postOnly":true}`)
+
+func TestHMACSigner(t *testing.T) {
+	signer, err := valr.NewHMACSigner("secret")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ts := fmt.Sprint(1558017528946 + i*1000 + j)
+				got, err := signer.Sign(ctx, ts, "post", "/v1/orders/limit", signBody)
+				if err != nil {
+					t.Errorf("Expected success, got %v", err)
+					return
+				}
+				if want := valr.SignRequest("secret", ts, "POST", "/v1/orders/limit", signBody); got != want {
+					t.Errorf("Expected %q, got %q", want, got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if raceEnabled {
+		t.Skip("Allocations are not representative with the race detector")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		signer.Sign(ctx, "1558017528946", "POST", "/v1/orders/limit", signBody)
+	})
+	if allocs > 1 {
+		t.Errorf("Expected at most 1 allocation per signature, got %v", allocs)
+	}
+}
+
+func BenchmarkSignRequest(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		valr.SignRequest("secret", "1558017528946", "POST", "/v1/orders/limit", signBody)
+	}
+}
+
+func BenchmarkHMACSigner(b *testing.B) {
+	signer, err := valr.NewHMACSigner("secret")
+	if err != nil {
+		b.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		signer.Sign(ctx, "1558017528946", "POST", "/v1/orders/limit", signBody)
+	}
+}
+
+// BenchmarkHMACSignerBatch signs batches of distinct orders from parallel
+// goroutines, as a high-frequency order flow does.
+func BenchmarkHMACSignerBatch(b *testing.B) {
+	signer, err := valr.NewHMACSigner("secret")
+	if err != nil {
+		b.Fatalf("Expected success, got %v", err)
+	}
+	const batch = 100
+	bodies := make([][]byte, batch)
+	for i := range bodies {
+		bodies[i] = []byte(fmt.Sprintf(`{"customerOrderId":"ORDER-%06d","pair":"BTCZAR","side":"BUY","quantity":"0.001","price":"%d"}`, i, 1000000+i))
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for _, body := range bodies {
+				signer.Sign(ctx, "1558017528946", "POST", "/v1/orders/limit", body)
+			}
+		}
+	})
+}
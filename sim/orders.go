@@ -0,0 +1,270 @@
+package sim
+
+import (
+	"errors"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Order failure reasons, as reported by VALR.
+const (
+	reasonPostOnly = "Post only cancelled as it would have matched"
+	reasonHalted   = "Market is halted"
+)
+
+// errNotFound is returned for orders or pairs that don't exist.
+var errNotFound = errors.New("not found")
+
+// quantityPlaces is the precision of base amounts bought by market orders.
+const quantityPlaces = 8
+
+func (s *Server) market(pair string) (*market, error) {
+	m, ok := s.markets[pair]
+	if !ok {
+		return nil, errNotFound
+	}
+	return m, nil
+}
+
+// newOrder records a new order of the account.
+func (s *Server) newOrder(m *market, typ, customerOrderID string, side valr.RequestSide, price, qty decimal.Decimal, now time.Time) *order {
+	o := &order{
+		OrderStatus: valr.OrderStatus{
+			OrderID:           s.newID(),
+			OrderStatusType:   StatusPlaced,
+			Pair:              m.Pair,
+			OriginalPrice:     price,
+			RemainingQuantity: qty,
+			OriginalQuantity:  qty,
+			OrderSide:         responseSide(side),
+			OrderType:         typ,
+			OrderUpdatedAt:    valr.NewTimestamp(now),
+			OrderCreatedAt:    valr.NewTimestamp(now),
+			CustomerOrderID:   customerOrderID,
+		},
+		price: price,
+	}
+	s.account.orders[o.OrderID] = o
+	return o
+}
+
+func responseSide(side valr.RequestSide) valr.ResponseSide {
+	if side == valr.SELL {
+		return valr.ResponseSideSell
+	}
+	return valr.ResponseSideBuy
+}
+
+// fail marks o failed for reason.
+func (s *Server) fail(o *order, reason string, now time.Time) {
+	o.OrderStatusType = StatusFailed
+	o.FailedReason = reason
+	o.OrderUpdatedAt = valr.NewTimestamp(now)
+	s.publishOrder(o, false)
+}
+
+// placeLimit places a limit order, filling it at once against the book if
+// it crosses and it isn't post only.
+func (s *Server) placeLimit(req *valr.PostLimitOrderRequest) (string, error) {
+	m, err := s.market(req.Pair)
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	o := s.newOrder(m, "limit", req.CustomerOrderID, req.Side, req.Price, req.Quantity, now)
+	if m.Scenario == Halted {
+		s.fail(o, reasonHalted, now)
+		return o.OrderID, nil
+	}
+
+	crosses := (req.Side == valr.BUY && !req.Price.LessThan(m.ask())) ||
+		(req.Side == valr.SELL && !req.Price.GreaterThan(m.bid()))
+	if crosses && req.PostOnly {
+		s.fail(o, reasonPostOnly, now)
+		return o.OrderID, nil
+	}
+	currency, amount := m.Base, req.Quantity
+	if req.Side == valr.BUY {
+		currency, amount = m.Quote, req.Quantity.Mul(req.Price)
+	}
+	if err := s.account.reserve(currency, amount); err != nil {
+		s.fail(o, err.Error(), now)
+		return o.OrderID, nil
+	}
+	s.publishBalance(currency)
+	s.publishOrder(o, true)
+
+	if crosses {
+		price := m.ask()
+		if req.Side == valr.SELL {
+			price = m.bid()
+		}
+		s.fill(m, o, price, o.RemainingQuantity, now)
+	}
+	return o.OrderID, nil
+}
+
+// marketOrderRequest is the body of a market order, which gives either the
+// quote amount to spend or the base amount to sell.
+type marketOrderRequest struct {
+	Side            valr.RequestSide `json:"side"`
+	Pair            string           `json:"pair"`
+	QuoteAmount     decimal.Decimal  `json:"quoteAmount"`
+	BaseAmount      decimal.Decimal  `json:"baseAmount"`
+	CustomerOrderID string           `json:"customerOrderId"`
+}
+
+// placeMarket places a market order, filling it at once at the touch.
+func (s *Server) placeMarket(req *marketOrderRequest) (string, error) {
+	m, err := s.market(req.Pair)
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	price := m.ask()
+	qty := req.BaseAmount
+	if req.Side == valr.SELL {
+		price = m.bid()
+	} else if qty.IsZero() {
+		qty = req.QuoteAmount.Div(price).Truncate(quantityPlaces)
+	}
+	o := s.newOrder(m, "market", req.CustomerOrderID, req.Side, decimal.Zero, qty, now)
+	if m.Scenario == Halted {
+		s.fail(o, reasonHalted, now)
+		return o.OrderID, nil
+	}
+	if !qty.IsPositive() {
+		s.fail(o, "Invalid quantity", now)
+		return o.OrderID, nil
+	}
+
+	currency, amount := m.Base, qty
+	if req.Side == valr.BUY {
+		currency, amount = m.Quote, qty.Mul(price)
+	}
+	if err := s.account.reserve(currency, amount); err != nil {
+		s.fail(o, err.Error(), now)
+		return o.OrderID, nil
+	}
+	o.price = price
+	s.publishOrder(o, true)
+	s.fill(m, o, price, qty, now)
+	return o.OrderID, nil
+}
+
+// fill fills qty of o at price, settling against the funds it reserved.
+func (s *Server) fill(m *market, o *order, price, qty decimal.Decimal, now time.Time) {
+	if o.OrderSide == valr.ResponseSideBuy {
+		reserved := qty.Mul(o.price)
+		cost := qty.Mul(price)
+		s.account.settle(m.Quote, cost, m.Base, qty)
+		if refund := reserved.Sub(cost); refund.IsPositive() {
+			s.account.release(m.Quote, refund)
+		}
+	} else {
+		s.account.settle(m.Base, qty, m.Quote, qty.Mul(price))
+	}
+	o.RemainingQuantity = o.RemainingQuantity.Sub(qty)
+	o.OrderStatusType = StatusFilled
+	if o.RemainingQuantity.IsPositive() {
+		o.OrderStatusType = StatusPartiallyFilled
+	}
+	o.OrderUpdatedAt = valr.NewTimestamp(now)
+
+	t := s.recordTrade(m, o.OrderSide, price, qty, now)
+	s.account.trades = append([]valr.TradeHistoryInfo{t}, s.account.trades...)
+	if len(s.account.trades) > maxTrades {
+		s.account.trades = s.account.trades[:maxTrades]
+	}
+	s.publishAccountTrade(o, t)
+	s.publishOrderStatus(o)
+	s.publishBalance(m.Base)
+	s.publishBalance(m.Quote)
+}
+
+// fillResting fills the resting orders of m crossed by its book, at their
+// own price.
+func (s *Server) fillResting(m *market, now time.Time) {
+	bid, ask := m.bid(), m.ask()
+	for _, id := range s.sortedOrderIDs() {
+		o := s.account.orders[id]
+		if o.Pair != m.Pair || !o.open() {
+			continue
+		}
+		if (o.OrderSide == valr.ResponseSideBuy && !o.price.LessThan(ask)) ||
+			(o.OrderSide == valr.ResponseSideSell && !o.price.GreaterThan(bid)) {
+			s.fill(m, o, o.price, o.RemainingQuantity, now)
+		}
+	}
+}
+
+// cancel cancels an open order by ID or customer order ID.
+func (s *Server) cancel(pair, orderID, customerOrderID string) error {
+	o := s.findOrder(pair, orderID, customerOrderID)
+	if o == nil || !o.open() {
+		s.publishFailedCancel(orderID, "Order not found")
+		return errNotFound
+	}
+	m := s.markets[o.Pair]
+	if o.OrderSide == valr.ResponseSideBuy {
+		s.account.release(m.Quote, o.RemainingQuantity.Mul(o.price))
+		s.publishBalance(m.Quote)
+	} else {
+		s.account.release(m.Base, o.RemainingQuantity)
+		s.publishBalance(m.Base)
+	}
+	o.OrderStatusType = StatusCancelled
+	o.OrderUpdatedAt = valr.NewTimestamp(s.now())
+	s.publishOrderStatus(o)
+	return nil
+}
+
+func (s *Server) findOrder(pair, orderID, customerOrderID string) *order {
+	for _, o := range s.account.orders {
+		if o.Pair != pair {
+			continue
+		}
+		if (orderID != "" && o.OrderID == orderID) ||
+			(customerOrderID != "" && o.CustomerOrderID == customerOrderID) {
+			return o
+		}
+	}
+	return nil
+}
+
+// openOrders returns the account's open orders, oldest first.
+func (s *Server) openOrders() []valr.OpenOrder {
+	var open []valr.OpenOrder
+	for _, id := range s.sortedOrderIDs() {
+		o := s.account.orders[id]
+		if !o.open() {
+			continue
+		}
+		filled := o.OriginalQuantity.Sub(o.RemainingQuantity)
+		open = append(open, valr.OpenOrder{
+			OrderID:           o.OrderID,
+			Side:              o.OrderSide,
+			Price:             o.price,
+			Pair:              o.Pair,
+			CreatedAt:         o.OrderCreatedAt,
+			RemainingQuantity: o.RemainingQuantity,
+			OriginalQuantity:  o.OriginalQuantity,
+			FilledPercentage:  filled.Div(o.OriginalQuantity).Mul(decimal.New(100, 0)).Round(2),
+			CustomerOrderID:   o.CustomerOrderID,
+		})
+	}
+	return open
+}
+
+// sortedOrderIDs returns the IDs of the account's orders, oldest first. IDs
+// are allocated in increasing numeric order.
+func (s *Server) sortedOrderIDs() []string {
+	ids := make([]string, 0, len(s.account.orders))
+	for id := range s.account.orders {
+		ids = append(ids, id)
+	}
+	sortNumeric(ids)
+	return ids
+}
@@ -0,0 +1,339 @@
+package sim
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// VALR error codes returned by the simulator.
+const (
+	codeInvalidRequest   = -1
+	codeInvalidSignature = -11
+	codeNotFound         = -21
+)
+
+func (s *Server) routes() {
+	public := map[string]http.HandlerFunc{
+		"GET /v1/public/time":                        s.getTime,
+		"GET /v1/public/pairs":                       s.getPairs,
+		"GET /v1/public/marketsummary":               s.getMarketSummaries,
+		"GET /v1/public/{pair}/marketsummary":        s.getMarketSummary,
+		"GET /v1/public/{pair}/orderbook":            s.getOrderBook,
+		"GET /v1/public/{pair}/trades":               s.getTrades,
+		"GET /v1/marketdata/{pair}/orderbook":        s.getOrderBook,
+		"GET /v1/marketdata/{pair}/tradehistory":     s.getTrades,
+		"GET /ws/trade":                              s.serveStream(false),
+		"GET /ws/account":                            s.serveStream(true),
+		"GET /v1/account/balances":                   s.auth(s.getBalances),
+		"GET /v1/account/{pair}/tradehistory":        s.auth(s.getAccountTrades),
+		"GET /v1/orders/open":                        s.auth(s.getOpenOrders),
+		"GET /v1/orders/{pair}/orderid/{id}":         s.auth(s.getOrderStatus),
+		"GET /v1/orders/{pair}/customerorderid/{id}": s.auth(s.getOrderStatus),
+		"GET /v1/positions/open":                     s.auth(s.getPositions),
+		"POST /v1/orders/limit":                      s.auth(s.postLimitOrder),
+		"POST /v1/orders/market":                     s.auth(s.postMarketOrder),
+		"DELETE /v1/orders/order":                    s.auth(s.deleteOrder),
+	}
+	for pattern, h := range public {
+		s.mux.HandleFunc(pattern, h)
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Not supported by the simulator: "+r.Method+" "+r.URL.Path)
+	})
+}
+
+// errInvalidSignature is returned for requests that are not correctly
+// signed.
+var errInvalidSignature = errors.New("Request has an invalid signature")
+
+// authenticate checks the API key and signature of r, whose body is body.
+func (s *Server) authenticate(r *http.Request, body []byte) error {
+	key := r.Header.Get("X-VALR-API-KEY")
+	if key == "" {
+		return errors.New("API key is missing")
+	}
+	if s.secret == "" {
+		return nil
+	}
+	if key != s.keyID {
+		return errors.New("API key is invalid")
+	}
+	signer, err := valr.NewHMACSigner(s.secret)
+	if err != nil {
+		return err
+	}
+	want, err := signer.Sign(r.Context(), r.Header.Get("X-VALR-TIMESTAMP"), r.Method, r.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(want), []byte(r.Header.Get("X-VALR-SIGNATURE"))) != 1 {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// auth wraps a handler of an authenticated endpoint.
+func (s *Server) auth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.authenticate(r, body); err != nil {
+			writeCodedError(w, http.StatusUnauthorized, codeInvalidSignature, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	code := codeInvalidRequest
+	if status == http.StatusNotFound {
+		code = codeNotFound
+	}
+	writeCodedError(w, status, code, message)
+}
+
+func writeCodedError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]any{"code": code, "message": message})
+}
+
+func (s *Server) getTime(w http.ResponseWriter, r *http.Request) {
+	now := s.now()
+	writeJSON(w, http.StatusOK, valr.GetServerTimeResponse{EpochTime: int(now.Unix()), Time: valr.NewTimestamp(now)})
+}
+
+func (s *Server) getPairs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pairs := make([]valr.PairInfo, 0, len(s.order))
+	for _, p := range s.order {
+		m := s.markets[p]
+		pairs = append(pairs, valr.PairInfo{
+			Symbol:            m.Pair,
+			BaseCurrency:      m.Base,
+			QuoteCurrency:     m.Quote,
+			ShortName:         m.Base + "/" + m.Quote,
+			Active:            m.Scenario != Halted,
+			TickSize:          m.TickSize,
+			BaseDecimalPlaces: strconv.Itoa(quantityPlaces),
+			CurrencyPairType:  valr.PairTypeSpot,
+		})
+	}
+	writeJSON(w, http.StatusOK, pairs)
+}
+
+func (s *Server) getMarketSummaries(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	summaries := make([]valr.MarketSummary, 0, len(s.order))
+	for _, p := range s.order {
+		summaries = append(summaries, s.markets[p].marketSummary(now))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// withMarket wraps a handler of an endpoint with a {pair} wildcard.
+func (s *Server) withMarket(w http.ResponseWriter, r *http.Request, h func(m *market)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.market(strings.ToUpper(r.PathValue("pair")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid currency pair")
+		return
+	}
+	h(m)
+}
+
+func (s *Server) getMarketSummary(w http.ResponseWriter, r *http.Request) {
+	s.withMarket(w, r, func(m *market) {
+		writeJSON(w, http.StatusOK, m.marketSummary(s.now()))
+	})
+}
+
+func (s *Server) getOrderBook(w http.ResponseWriter, r *http.Request) {
+	s.withMarket(w, r, func(m *market) {
+		bids, asks := m.book()
+		writeJSON(w, http.StatusOK, valr.OrderBook{Bids: bids, Asks: asks, LastChange: valr.NewTimestamp(s.now()), SequenceNumber: m.sequence})
+	})
+}
+
+func (s *Server) getTrades(w http.ResponseWriter, r *http.Request) {
+	s.withMarket(w, r, func(m *market) {
+		writeJSON(w, http.StatusOK, pageTrades(m.trades, r))
+	})
+}
+
+func (s *Server) getAccountTrades(w http.ResponseWriter, r *http.Request) {
+	s.withMarket(w, r, func(m *market) {
+		var trades []valr.TradeHistoryInfo
+		for _, t := range s.account.trades {
+			if t.Pair == m.Pair {
+				trades = append(trades, t)
+			}
+		}
+		writeJSON(w, http.StatusOK, pageTrades(trades, r))
+	})
+}
+
+// pageTrades applies the skip, limit, startTime, endTime, beforeId and
+// afterId parameters of r to trades, which are newest first.
+func pageTrades(trades []valr.TradeHistoryInfo, r *http.Request) []valr.TradeHistoryInfo {
+	q := r.URL.Query()
+	if id := q.Get("beforeId"); id != "" {
+		// An unknown cursor matches no trades.
+		i := slices.IndexFunc(trades, func(t valr.TradeHistoryInfo) bool { return t.ID == id })
+		if i < 0 {
+			i = len(trades) - 1
+		}
+		trades = trades[i+1:]
+	}
+	if id := q.Get("afterId"); id != "" {
+		if i := slices.IndexFunc(trades, func(t valr.TradeHistoryInfo) bool { return t.ID == id }); i >= 0 {
+			trades = trades[:i]
+		}
+	}
+	start, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+	end, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+	skip, _ := strconv.Atoi(q.Get("skip"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	out := []valr.TradeHistoryInfo{}
+	for _, t := range trades {
+		if (!start.IsZero() && t.TradedAt.Before(start)) || (!end.IsZero() && !t.TradedAt.Before(end)) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (s *Server) getBalances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.account.balanceList())
+}
+
+func (s *Server) getOpenOrders(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	open := s.openOrders()
+	if open == nil {
+		open = []valr.OpenOrder{}
+	}
+	writeJSON(w, http.StatusOK, open)
+}
+
+func (s *Server) getOrderStatus(w http.ResponseWriter, r *http.Request) {
+	s.withMarket(w, r, func(m *market) {
+		var o *order
+		if strings.Contains(r.URL.Path, "/customerorderid/") {
+			o = s.findOrder(m.Pair, "", r.PathValue("id"))
+		} else {
+			o = s.findOrder(m.Pair, r.PathValue("id"), "")
+		}
+		if o == nil {
+			writeError(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, o.OrderStatus)
+	})
+}
+
+func (s *Server) getPositions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []valr.OpenPosition{})
+}
+
+func (s *Server) postLimitOrder(w http.ResponseWriter, r *http.Request) {
+	req := new(valr.PostLimitOrderRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, err := s.placeLimit(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid currency pair")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, valr.PostLimitOrderResponse{ID: id})
+}
+
+func (s *Server) postMarketOrder(w http.ResponseWriter, r *http.Request) {
+	req := new(marketOrderRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, err := s.placeMarket(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid currency pair")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, valr.PostMarketOrderResponse{ID: id})
+}
+
+func (s *Server) deleteOrder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pair            string `json:"pair"`
+		OrderID         string `json:"orderId"`
+		CustomerOrderID string `json:"customerOrderId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// VALR accepts cancellations asynchronously, reporting failures on the
+	// account stream.
+	s.cancel(req.Pair, req.OrderID, req.CustomerOrderID)
+	writeJSON(w, http.StatusAccepted, struct{}{})
+}
+
+// sortNumeric sorts numeric strings by value.
+func sortNumeric(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+}
@@ -0,0 +1,392 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Script is a timed sequence of events, such as trades, book moves, fills
+// and injected failures, for reproducing edge cases deterministically. It is
+// usually loaded from a JSON file:
+//
+//	{"events": [
+//		{"at": "1s", "disconnect": {"stream": "account", "for": "2s"}},
+//		{"at": "1.5s", "fill": {"pair": "BTCZAR", "quantity": "0.005"}},
+//		{"at": "4s", "fail": {"method": "POST", "path": "/v1/orders/limit", "status": 503}},
+//		{"at": "5s", "dropSubscriptions": {"event": "NEW_TRADE"}}
+//	]}
+//
+// Each event sets exactly one action.
+type Script struct {
+	Events []ScriptEvent `json:"events"`
+}
+
+// ScriptEvent is an action taken at an offset from the start of a script.
+type ScriptEvent struct {
+	At Offset `json:"at"`
+
+	Trade      *ScriptTrade      `json:"trade,omitempty"`
+	Book       *ScriptBook       `json:"book,omitempty"`
+	Fill       *ScriptFill       `json:"fill,omitempty"`
+	Fail       *ScriptFail       `json:"fail,omitempty"`
+	Disconnect *ScriptDisconnect `json:"disconnect,omitempty"`
+	Scenario   *ScriptScenario   `json:"scenario,omitempty"`
+
+	DropSubscriptions *ScriptDropSubscriptions `json:"dropSubscriptions,omitempty"`
+}
+
+// ScriptTrade makes a trade between other participants.
+type ScriptTrade struct {
+	Pair string `json:"pair"`
+	// Side is the taker side, "buy" or "sell".
+	Side     valr.ResponseSide `json:"side"`
+	Price    decimal.Decimal   `json:"price"`
+	Quantity decimal.Decimal   `json:"quantity"`
+}
+
+// ScriptBook moves the order book of a market. Resting orders crossed by the
+// new book are filled, as on every tick.
+type ScriptBook struct {
+	Pair string `json:"pair"`
+	// Price is the new mid price.
+	Price decimal.Decimal `json:"price"`
+	// Spread is the new fractional spread, unchanged if zero.
+	Spread float64 `json:"spread,omitempty"`
+}
+
+// ScriptFill fills resting orders of the account at their own price,
+// whether or not the book crosses them. Orders are filled oldest first until
+// Quantity is exhausted, leaving the last partially filled.
+type ScriptFill struct {
+	Pair     string          `json:"pair"`
+	Quantity decimal.Decimal `json:"quantity"`
+	// OrderID or CustomerOrderID restrict the fill to a single order.
+	OrderID         string `json:"orderId,omitempty"`
+	CustomerOrderID string `json:"customerOrderId,omitempty"`
+}
+
+// ScriptFail fails matching REST requests with an error response.
+type ScriptFail struct {
+	// Method and Path match requests, such as "POST" and "/v1/orders/limit".
+	// Empty fields match any request, and a Path ending in "*" matches by
+	// prefix.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Status is the HTTP status returned, 500 by default.
+	Status  int    `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+	// RetryAfter sets the Retry-After header, for rate limit responses.
+	RetryAfter Offset `json:"retryAfter,omitempty"`
+	// Count is the number of requests failed, 1 by default.
+	Count int `json:"count,omitempty"`
+}
+
+// ScriptDisconnect drops stream connections.
+type ScriptDisconnect struct {
+	// Stream is "trade", "account" or empty for both.
+	Stream string `json:"stream,omitempty"`
+	// For refuses reconnections for this long, so later events happen
+	// while clients are disconnected.
+	For Offset `json:"for,omitempty"`
+	// Status is the HTTP status of refused reconnections, 503 by default.
+	// 401 simulates the server rejecting the client's credentials.
+	Status int `json:"status,omitempty"`
+	// MidMessage cuts connections off part way through a frame, as a
+	// network failure would, rather than closing them cleanly.
+	MidMessage bool `json:"midMessage,omitempty"`
+}
+
+// ScriptDropSubscriptions makes the trade stream forget the subscriptions
+// of connected clients without telling them, so they stop receiving
+// updates while their connections stay up.
+type ScriptDropSubscriptions struct {
+	// Event restricts the subscriptions dropped to one event, such as
+	// "NEW_TRADE". All subscriptions are dropped if it is empty.
+	Event string `json:"event,omitempty"`
+}
+
+// ScriptScenario changes the scenario of a market.
+type ScriptScenario struct {
+	Pair     string   `json:"pair"`
+	Scenario Scenario `json:"scenario"`
+}
+
+// Offset is a duration encoded in JSON as a string such as "1.5s".
+type Offset time.Duration
+
+func (o Offset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(o).String())
+}
+
+func (o *Offset) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("sim: offset must be a string such as \"1.5s\": %w", err)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("sim: %w", err)
+	}
+	*o = Offset(d)
+	return nil
+}
+
+// LoadScript reads a script from a JSON file. Unknown fields are rejected,
+// so typos don't silently drop an action.
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := ParseScript(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("sim: decoding %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// ParseScript decodes a JSON script from r and validates it.
+func ParseScript(r io.Reader) (*Script, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	sc := new(Script)
+	if err := dec.Decode(sc); err != nil {
+		return nil, err
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Validate checks that every event sets exactly one action and that
+// offsets aren't negative.
+func (sc *Script) Validate() error {
+	for i, ev := range sc.Events {
+		if ev.At < 0 {
+			return fmt.Errorf("sim: event %d: negative offset", i)
+		}
+		n := 0
+		for _, set := range []bool{ev.Trade != nil, ev.Book != nil, ev.Fill != nil, ev.Fail != nil, ev.Disconnect != nil, ev.Scenario != nil, ev.DropSubscriptions != nil} {
+			if set {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("sim: event %d: expected one action, got %d", i, n)
+		}
+	}
+	return nil
+}
+
+// Play applies the events of sc at their offsets from now, until they are
+// done or done is closed. Events at the same offset are applied in the
+// order listed. It stops at the first event that fails to apply.
+func (s *Server) Play(done <-chan struct{}, sc *Script) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	events := append([]ScriptEvent(nil), sc.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+
+	start := time.Now()
+	for _, ev := range events {
+		if wait := time.Duration(ev.At) - time.Since(start); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-done:
+				t.Stop()
+				return nil
+			case <-t.C:
+			}
+		}
+		if err := s.Apply(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the action of ev at once, ignoring its offset. Tests can
+// use it to step through a script without waiting.
+func (s *Server) Apply(ev ScriptEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	switch {
+	case ev.Trade != nil:
+		m, err := s.scriptMarket(ev.Trade.Pair)
+		if err != nil {
+			return err
+		}
+		s.recordTrade(m, ev.Trade.Side, ev.Trade.Price, ev.Trade.Quantity, now)
+	case ev.Book != nil:
+		m, err := s.scriptMarket(ev.Book.Pair)
+		if err != nil {
+			return err
+		}
+		if !ev.Book.Price.IsPositive() {
+			return errors.New("sim: book price must be positive")
+		}
+		m.mid, _ = ev.Book.Price.Float64()
+		if ev.Book.Spread > 0 {
+			m.Spread = ev.Book.Spread
+		}
+		m.sequence++
+		s.fillResting(m, now)
+		s.publishBook(m, now)
+	case ev.Fill != nil:
+		m, err := s.scriptMarket(ev.Fill.Pair)
+		if err != nil {
+			return err
+		}
+		s.scriptFill(m, ev.Fill, now)
+	case ev.Fail != nil:
+		f := *ev.Fail
+		if f.Count <= 0 {
+			f.Count = 1
+		}
+		if f.Status == 0 {
+			f.Status = http.StatusInternalServerError
+		}
+		s.failures = append(s.failures, &f)
+	case ev.Disconnect != nil:
+		s.disconnect(ev.Disconnect, now)
+	case ev.Scenario != nil:
+		m, err := s.scriptMarket(ev.Scenario.Pair)
+		if err != nil {
+			return err
+		}
+		sc, err := ParseScenario(string(ev.Scenario.Scenario))
+		if err != nil {
+			return err
+		}
+		m.Scenario = sc
+	case ev.DropSubscriptions != nil:
+		for c := range s.streams.trade {
+			if ev.DropSubscriptions.Event == "" {
+				c.subs = make(map[string]map[string]bool)
+			} else {
+				delete(c.subs, ev.DropSubscriptions.Event)
+			}
+		}
+	default:
+		return errors.New("sim: event has no action")
+	}
+	return nil
+}
+
+// Trade makes the trade t at once, like a trade event, and returns it as
+// published to the trade stream.
+func (s *Server) Trade(t ScriptTrade) (valr.TradeHistoryInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.scriptMarket(t.Pair)
+	if err != nil {
+		return valr.TradeHistoryInfo{}, err
+	}
+	return s.recordTrade(m, t.Side, t.Price, t.Quantity, s.now()), nil
+}
+
+func (s *Server) scriptMarket(pair string) (*market, error) {
+	m, err := s.market(pair)
+	if err != nil {
+		return nil, fmt.Errorf("sim: unknown pair %q", pair)
+	}
+	return m, nil
+}
+
+func (s *Server) scriptFill(m *market, f *ScriptFill, now time.Time) {
+	remaining := f.Quantity
+	for _, id := range s.sortedOrderIDs() {
+		if !remaining.IsPositive() {
+			return
+		}
+		o := s.account.orders[id]
+		if o.Pair != m.Pair || !o.open() ||
+			(f.OrderID != "" && o.OrderID != f.OrderID) ||
+			(f.CustomerOrderID != "" && o.CustomerOrderID != f.CustomerOrderID) {
+			continue
+		}
+		qty := decimal.Min(remaining, o.RemainingQuantity)
+		s.fill(m, o, o.price, qty, now)
+		remaining = remaining.Sub(qty)
+	}
+}
+
+// disconnect closes the connections of the stream of d, or of both streams
+// if it is empty, refusing new ones for d.For.
+func (s *Server) disconnect(d *ScriptDisconnect, now time.Time) {
+	until := now.Add(time.Duration(d.For))
+	status := d.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	var sets []map[*streamConn]struct{}
+	if d.Stream != "account" {
+		sets = append(sets, s.streams.trade)
+		s.streams.tradeDown = outage{until: until, status: status}
+	}
+	if d.Stream != "trade" {
+		sets = append(sets, s.streams.account)
+		s.streams.accountDown = outage{until: until, status: status}
+	}
+	for _, set := range sets {
+		for c := range set {
+			if d.MidMessage {
+				c.cut()
+			} else {
+				c.ws.Close()
+			}
+		}
+	}
+}
+
+// injectedFailure returns the scripted failure matching r, if any, counting
+// it as used.
+func (s *Server) injectedFailure(r *http.Request) *ScriptFail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.failures {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(f.Path, "*"); ok {
+			if !strings.HasPrefix(r.URL.Path, prefix) {
+				continue
+			}
+		} else if f.Path != "" && f.Path != r.URL.Path {
+			continue
+		}
+		f.Count--
+		if f.Count <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return f
+	}
+	return nil
+}
+
+func writeInjectedFailure(w http.ResponseWriter, f *ScriptFail) {
+	if f.RetryAfter > 0 {
+		secs := (time.Duration(f.RetryAfter) + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.Itoa(int(secs)))
+	}
+	msg := f.Message
+	if msg == "" {
+		msg = http.StatusText(f.Status)
+	}
+	writeError(w, f.Status, msg)
+}
@@ -0,0 +1,171 @@
+package sim_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseScript(t *testing.T) {
+	sc, err := sim.ParseScript(strings.NewReader(`{"events": [
+		{"at": "1.5s", "fill": {"pair": "BTCZAR", "quantity": "0.005"}},
+		{"at": "2s", "disconnect": {"stream": "account", "for": "1s"}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(sc.Events) != 2 || time.Duration(sc.Events[0].At) != 1500*time.Millisecond {
+		t.Errorf("Expected 2 events starting at 1.5s, got %+v", sc.Events)
+	}
+	if d := time.Duration(sc.Events[1].Disconnect.For); d != time.Second {
+		t.Errorf("Expected %v, got %v", time.Second, d)
+	}
+
+	invalid := []string{
+		`{"events": [{"at": "1s"}]}`,
+		`{"events": [{"at": "1s", "trade": {}, "book": {}}]}`,
+		`{"events": [{"at": "-1s", "trade": {}}]}`,
+		`{"events": [{"at": 1, "trade": {}}]}`,
+		`{"events": [{"at": "1s", "trades": {}}]}`,
+	}
+	for _, s := range invalid {
+		if _, err := sim.ParseScript(strings.NewReader(s)); err == nil {
+			t.Errorf("Expected error for %s", s)
+		}
+	}
+}
+
+func TestScriptPartialFill(t *testing.T) {
+	s, cl, _ := newSim(t)
+	ctx := context.Background()
+	res, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Side:            valr.BUY,
+		Quantity:        decimal.New(1, -2),
+		Price:           decimal.New(500000, 0),
+		Pair:            "BTCZAR",
+		CustomerOrderID: "resting",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	err = s.Apply(sim.ScriptEvent{Fill: &sim.ScriptFill{Pair: "BTCZAR", Quantity: decimal.New(4, -3), CustomerOrderID: "resting"}})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	status, err := cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: "BTCZAR", ID: res.ID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if status.OrderStatusType != sim.StatusPartiallyFilled {
+		t.Errorf("Expected %q, got %q", sim.StatusPartiallyFilled, status.OrderStatusType)
+	}
+	if want := "0.006"; status.RemainingQuantity.String() != want {
+		t.Errorf("Expected %q, got %q", want, status.RemainingQuantity)
+	}
+
+	open, err := cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(open) != 1 || open[0].FilledPercentage.String() != "40" {
+		t.Errorf("Expected order 40%% filled, got %+v", open)
+	}
+
+	// Moving the book through the order fills the rest.
+	if err := s.Apply(sim.ScriptEvent{Book: &sim.ScriptBook{Pair: "BTCZAR", Price: decimal.New(490000, 0)}}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	status, err = cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: "BTCZAR", ID: res.ID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if status.OrderStatusType != sim.StatusFilled {
+		t.Errorf("Expected %q, got %q", sim.StatusFilled, status.OrderStatusType)
+	}
+}
+
+func TestScriptFail(t *testing.T) {
+	s, cl, env := newSim(t)
+	err := s.Apply(sim.ScriptEvent{Fail: &sim.ScriptFail{
+		Method:     "POST",
+		Path:       "/v1/orders/*",
+		Status:     http.StatusTooManyRequests,
+		RetryAfter: sim.Offset(2 * time.Second),
+	}})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	req := &valr.PostLimitOrderRequest{Side: valr.BUY, Quantity: decimal.New(1, -2), Price: decimal.New(500000, 0), Pair: "BTCZAR"}
+	_, err = cl.PostLimitOrderRequest(context.Background(), req)
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 API error, got %v", err)
+	}
+	if apiErr.RetryAfter != 2*time.Second {
+		t.Errorf("Expected %v, got %v", 2*time.Second, apiErr.RetryAfter)
+	}
+	// A new client, as this one waits out the Retry-After.
+	cl = valr.NewClient()
+	cl.SetEnvironment(env)
+	cl.SetAuth("key", "secret")
+	if _, err := cl.PostLimitOrderRequest(context.Background(), req); err != nil {
+		t.Errorf("Expected success after the injected failure, got %v", err)
+	}
+}
+
+func TestScriptDisconnect(t *testing.T) {
+	s, _, env := newSim(t)
+	if err := s.Apply(sim.ScriptEvent{Disconnect: &sim.ScriptDisconnect{Stream: "trade", For: sim.Offset(time.Hour)}}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	base := strings.TrimSuffix(env.BaseURL, "/v1")
+	for path, want := range map[string]int{"/ws/trade": http.StatusServiceUnavailable, "/ws/account": http.StatusBadRequest} {
+		req, _ := http.NewRequest(http.MethodGet, base+path, nil)
+		headers, err := valr.GetAuthHeaders(req.URL.String(), http.MethodGet, "key", "secret", nil)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		req.Header = headers
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		res.Body.Close()
+		// Without a websocket handshake, a stream that is up fails the
+		// upgrade instead.
+		if res.StatusCode != want {
+			t.Errorf("%s: Expected %d, got %d", path, want, res.StatusCode)
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	s, cl, _ := newSim(t)
+	sc := &sim.Script{Events: []sim.ScriptEvent{
+		{At: sim.Offset(10 * time.Millisecond), Trade: &sim.ScriptTrade{Pair: "BTCZAR", Side: valr.ResponseSideSell, Price: decimal.New(990000, 0), Quantity: decimal.New(1, 0)}},
+		{Scenario: &sim.ScriptScenario{Pair: "BTCZAR", Scenario: sim.Halted}},
+	}}
+	if err := s.Play(make(chan struct{}), sc); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	sum, err := cl.GetMarketSummaryForPairRequest(context.Background(), &valr.GetMarketSummaryForPairRequest{Pair: "BTCZAR"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if want := "990000"; sum.LastPrice.String() != want {
+		t.Errorf("Expected %q, got %q", want, sum.LastPrice)
+	}
+
+	err = s.Play(make(chan struct{}), &sim.Script{Events: []sim.ScriptEvent{{Book: &sim.ScriptBook{Pair: "ETHZAR"}}}})
+	if err == nil {
+		t.Error("Expected error for unknown pair")
+	}
+}
@@ -0,0 +1,470 @@
+// Package sim is an in-process simulation of the VALR REST and websocket
+// APIs, for running integration tests and whole systems offline. Each
+// market follows a scenario, such as a trend or a choppy range, producing
+// trades and order book updates on every tick. A single simulated account
+// can place and cancel limit and market orders, which fill against the
+// simulated book without fees.
+//
+// Point clients at a running Server with valr.MockEnvironment:
+//
+//	srv := httptest.NewServer(sim.New(sim.WithMarket(sim.Market{Pair: "BTCZAR", Price: decimal.New(1000000, 0)})))
+//	env, _ := valr.MockEnvironment(srv.URL)
+//	cl.SetEnvironment(env)
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// Scenario is how the price of a simulated market evolves.
+type Scenario string
+
+const (
+	// Trending moves the price by Market.Drift every tick, with noise.
+	Trending Scenario = "trending"
+	// Choppy moves the price randomly around its starting price.
+	Choppy Scenario = "choppy"
+	// Halted freezes the market: no trades happen and orders are rejected.
+	Halted Scenario = "halted"
+)
+
+// ParseScenario returns the scenario named s.
+func ParseScenario(s string) (Scenario, error) {
+	switch sc := Scenario(strings.ToLower(s)); sc {
+	case Trending, Choppy, Halted:
+		return sc, nil
+	default:
+		return "", fmt.Errorf("sim: unknown scenario %q", s)
+	}
+}
+
+// Order statuses, as reported by VALR.
+const (
+	StatusPlaced          = "Placed"
+	StatusPartiallyFilled = "Partially Filled"
+	StatusFilled          = "Filled"
+	StatusCancelled       = "Cancelled"
+	StatusFailed          = "Failed"
+)
+
+const (
+	defaultDrift      = 0.001
+	defaultVolatility = 0.002
+	defaultSpread     = 0.001
+	bookLevels        = 10
+)
+
+// Market configures a simulated market.
+type Market struct {
+	Pair  string
+	Base  string
+	Quote string
+	// Price is the starting price.
+	Price decimal.Decimal
+	// TickSize is the price increment, 1 by default.
+	TickSize decimal.Decimal
+	Scenario Scenario
+	// Drift is the fractional price change per tick of a trending market,
+	// negative for a downtrend. It is 0.001 by default.
+	Drift float64
+	// Volatility is the standard deviation of the fractional random price
+	// change per tick, 0.002 by default.
+	Volatility float64
+	// Spread is the fractional distance between the best bid and ask, 0.001
+	// by default.
+	Spread float64
+}
+
+type Option func(*Server)
+
+// WithMarket adds a market. Base and Quote are derived from the pair if not
+// set, for pairs quoted in ZAR, USDC, USDT or BTC.
+func WithMarket(m Market) Option {
+	return func(s *Server) {
+		s.addMarket(m)
+	}
+}
+
+// WithBalance sets the starting available balance of a currency.
+func WithBalance(currency string, amount decimal.Decimal) Option {
+	return func(s *Server) {
+		s.account.balance(currency).available = amount
+	}
+}
+
+// WithSeed seeds the random number generator, so runs are repeatable.
+func WithSeed(seed int64) Option {
+	return func(s *Server) {
+		s.rnd = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithCredentials only accepts authenticated requests signed with the given
+// API key. By default any key is accepted and signatures are not checked.
+func WithCredentials(keyID, secret string) Option {
+	return func(s *Server) {
+		s.keyID, s.secret = keyID, secret
+	}
+}
+
+// WithClock sets the source of the current time, time.Now by default.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server simulates the VALR REST API under /v1 and the streaming API at
+// /ws/trade and /ws/account. It implements http.Handler. Markets only move
+// when Step is called, or while Run is running.
+type Server struct {
+	mux    *http.ServeMux
+	now    func() time.Time
+	keyID  string
+	secret string
+
+	mu      sync.Mutex
+	rnd     *rand.Rand
+	markets map[string]*market
+	order   []string // market pairs in the order added
+	account *account
+	nextID  int64
+	streams streams
+	// failures holds the failures injected by scripts.
+	failures []*ScriptFail
+}
+
+// New returns a server configured by opts.
+func New(opts ...Option) *Server {
+	s := &Server{
+		mux:     http.NewServeMux(),
+		now:     time.Now,
+		rnd:     rand.New(rand.NewSource(1)),
+		markets: make(map[string]*market),
+		account: newAccount(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := s.injectedFailure(r); f != nil {
+		writeInjectedFailure(w, f)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetScenario changes the scenario of pair.
+func (s *Server) SetScenario(pair string, sc Scenario) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.markets[pair]
+	if !ok {
+		return fmt.Errorf("sim: unknown pair %q", pair)
+	}
+	m.Scenario = sc
+	return nil
+}
+
+// Pairs returns the simulated pairs in the order they were added.
+func (s *Server) Pairs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.order...)
+}
+
+// Step advances every market by one tick: prices move according to each
+// market's scenario, a trade is made in every market that isn't halted, and
+// resting orders crossed by the new book are filled. Updates are sent to
+// stream subscribers.
+func (s *Server) Step() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for _, pair := range s.order {
+		m := s.markets[pair]
+		if m.Scenario == Halted {
+			continue
+		}
+		m.move(s.rnd)
+		s.marketTrade(m, now)
+		s.fillResting(m, now)
+		s.publishBook(m, now)
+	}
+}
+
+// Run calls Step every interval until done is closed.
+func (s *Server) Run(done <-chan struct{}, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			s.Step()
+		}
+	}
+}
+
+// market is the state of a simulated market.
+type market struct {
+	Market
+	start    float64
+	mid      float64
+	sequence int64
+	trades   []valr.TradeHistoryInfo // newest first
+	summary  valr.MarketSummary
+}
+
+var quoteCurrencies = []string{"USDC", "USDT", "ZAR", "BTC"}
+
+func (s *Server) addMarket(cfg Market) {
+	if cfg.Base == "" || cfg.Quote == "" {
+		for _, q := range quoteCurrencies {
+			if base, ok := strings.CutSuffix(cfg.Pair, q); ok && base != "" {
+				cfg.Base, cfg.Quote = base, q
+				break
+			}
+		}
+	}
+	if cfg.TickSize.IsZero() {
+		cfg.TickSize = decimal.New(1, 0)
+	}
+	if cfg.Scenario == "" {
+		cfg.Scenario = Choppy
+	}
+	if cfg.Drift == 0 {
+		cfg.Drift = defaultDrift
+	}
+	if cfg.Volatility == 0 {
+		cfg.Volatility = defaultVolatility
+	}
+	if cfg.Spread == 0 {
+		cfg.Spread = defaultSpread
+	}
+	price, _ := cfg.Price.Float64()
+	m := &market{Market: cfg, start: price, mid: price}
+	m.summary = valr.MarketSummary{
+		Pair:       cfg.Pair,
+		LastPrice:  cfg.Price,
+		ClosePrice: cfg.Price,
+		HighPrice:  cfg.Price,
+		LowPrice:   cfg.Price,
+	}
+	if _, ok := s.markets[cfg.Pair]; !ok {
+		s.order = append(s.order, cfg.Pair)
+	}
+	s.markets[cfg.Pair] = m
+}
+
+// move moves the mid price by one tick of the market's scenario.
+func (m *market) move(rnd *rand.Rand) {
+	change := rnd.NormFloat64() * m.Volatility
+	switch m.Scenario {
+	case Trending:
+		change += m.Drift
+	case Choppy:
+		// Revert a tenth of the way to the starting price.
+		change += (m.start - m.mid) / m.mid / 10
+	}
+	m.mid *= 1 + change
+	if m.mid <= 0 {
+		m.mid, _ = m.TickSize.Float64()
+	}
+}
+
+// round rounds a price to the market's tick size.
+func (m *market) round(price float64) decimal.Decimal {
+	ticks := decimal.NewFromFloat(price).Div(m.TickSize).Round(0)
+	if !ticks.IsPositive() {
+		ticks = decimal.New(1, 0)
+	}
+	return ticks.Mul(m.TickSize)
+}
+
+func (m *market) bid() decimal.Decimal {
+	return m.round(m.mid * (1 - m.Spread/2))
+}
+
+func (m *market) ask() decimal.Decimal {
+	ask := m.round(m.mid * (1 + m.Spread/2))
+	if !ask.GreaterThan(m.bid()) {
+		ask = m.bid().Add(m.TickSize)
+	}
+	return ask
+}
+
+// book returns the top levels of the simulated order book, best first.
+func (m *market) book() (bids, asks []valr.OrderBookEntry) {
+	bid, ask := m.bid(), m.ask()
+	for i := 0; i < bookLevels; i++ {
+		step := m.TickSize.Mul(decimal.New(int64(i), 0))
+		qty := decimal.New(int64(i+1), -1)
+		bids = append(bids, valr.OrderBookEntry{Side: "buy", Pair: m.Pair, Price: bid.Sub(step), Quantity: qty, OrderCount: i + 1})
+		asks = append(asks, valr.OrderBookEntry{Side: "sell", Pair: m.Pair, Price: ask.Add(step), Quantity: qty, OrderCount: i + 1})
+	}
+	return bids, asks
+}
+
+// marketTrade makes a trade between other participants at the touch.
+func (s *Server) marketTrade(m *market, now time.Time) {
+	side, price := valr.ResponseSideBuy, m.ask()
+	if s.rnd.Intn(2) == 0 {
+		side, price = valr.ResponseSideSell, m.bid()
+	}
+	qty := decimal.New(int64(1+s.rnd.Intn(100)), -3)
+	s.recordTrade(m, side, price, qty, now)
+}
+
+func (s *Server) recordTrade(m *market, side valr.ResponseSide, price, qty decimal.Decimal, now time.Time) valr.TradeHistoryInfo {
+	m.sequence++
+	t := valr.TradeHistoryInfo{
+		Price:      price,
+		Quantity:   qty,
+		Pair:       m.Pair,
+		TradedAt:   valr.NewTimestamp(now),
+		TakerSide:  side,
+		SequenceID: int(m.sequence),
+		ID:         s.newID(),
+	}
+	m.trades = append([]valr.TradeHistoryInfo{t}, m.trades...)
+	if len(m.trades) > maxTrades {
+		m.trades = m.trades[:maxTrades]
+	}
+	sum := &m.summary
+	sum.LastPrice = price
+	sum.HighPrice = decimal.Max(sum.HighPrice, price)
+	sum.LowPrice = decimal.Min(sum.LowPrice, price)
+	sum.BaseVolume = sum.BaseVolume.Add(qty)
+	s.publishTrade(t)
+	return t
+}
+
+// maxTrades is the number of recent trades kept per market.
+const maxTrades = 1000
+
+// marketSummary returns the current summary of m.
+func (m *market) marketSummary(now time.Time) valr.MarketSummary {
+	sum := m.summary
+	sum.BidPrice, sum.AskPrice = m.bid(), m.ask()
+	sum.Created = valr.NewTimestamp(now)
+	if sum.ClosePrice.IsPositive() {
+		sum.ChangeFromPrevious = sum.LastPrice.Sub(sum.ClosePrice).Div(sum.ClosePrice).Mul(decimal.New(100, 0)).Round(2)
+	}
+	return sum
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.FormatInt(s.nextID, 10)
+}
+
+// balance is an account balance of a currency.
+type balance struct {
+	available decimal.Decimal
+	reserved  decimal.Decimal
+}
+
+// order is an order of the simulated account.
+type order struct {
+	valr.OrderStatus
+	price decimal.Decimal
+}
+
+// open returns true if o is resting in the book.
+func (o *order) open() bool {
+	return o.OrderStatusType == StatusPlaced || o.OrderStatusType == StatusPartiallyFilled
+}
+
+// account is the simulated account.
+type account struct {
+	balances map[string]*balance
+	orders   map[string]*order
+	trades   []valr.TradeHistoryInfo // newest first
+}
+
+func newAccount() *account {
+	return &account{
+		balances: make(map[string]*balance),
+		orders:   make(map[string]*order),
+	}
+}
+
+func (a *account) balance(currency string) *balance {
+	b, ok := a.balances[currency]
+	if !ok {
+		b = new(balance)
+		a.balances[currency] = b
+	}
+	return b
+}
+
+// errInsufficientBalance is the failure reason of orders the account can't
+// afford.
+var errInsufficientBalance = errors.New("Insufficient Balance")
+
+// reserve moves amount of currency from available to reserved.
+func (a *account) reserve(currency string, amount decimal.Decimal) error {
+	b := a.balance(currency)
+	if b.available.LessThan(amount) {
+		return errInsufficientBalance
+	}
+	b.available = b.available.Sub(amount)
+	b.reserved = b.reserved.Add(amount)
+	return nil
+}
+
+// release moves amount of currency from reserved back to available.
+func (a *account) release(currency string, amount decimal.Decimal) {
+	b := a.balance(currency)
+	b.reserved = b.reserved.Sub(amount)
+	b.available = b.available.Add(amount)
+}
+
+// settle exchanges the currencies of a fill: spent from reserved and
+// received into available.
+func (a *account) settle(spendCurrency string, spend decimal.Decimal, receiveCurrency string, receive decimal.Decimal) {
+	b := a.balance(spendCurrency)
+	b.reserved = b.reserved.Sub(spend)
+	r := a.balance(receiveCurrency)
+	r.available = r.available.Add(receive)
+}
+
+func (a *account) balanceList() []valr.AccountBalance {
+	currencies := make([]string, 0, len(a.balances))
+	for c := range a.balances {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	list := make([]valr.AccountBalance, 0, len(currencies))
+	for _, c := range currencies {
+		list = append(list, a.accountBalance(c))
+	}
+	return list
+}
+
+func (a *account) accountBalance(currency string) valr.AccountBalance {
+	b := a.balance(currency)
+	return valr.AccountBalance{
+		Currency:  currency,
+		Available: b.available,
+		Reserved:  b.reserved,
+		Total:     b.available.Add(b.reserved),
+	}
+}
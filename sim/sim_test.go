@@ -0,0 +1,235 @@
+package sim_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func newSim(t *testing.T, opts ...sim.Option) (*sim.Server, *valr.Client, valr.Environment) {
+	t.Helper()
+	opts = append([]sim.Option{
+		sim.WithMarket(sim.Market{Pair: "BTCZAR", Price: decimal.New(1000000, 0)}),
+		sim.WithBalance("ZAR", decimal.New(100000, 0)),
+		sim.WithCredentials("key", "secret"),
+		sim.WithSeed(1),
+	}, opts...)
+	s := sim.New(opts...)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	cl := valr.NewClient()
+	cl.SetEnvironment(env)
+	cl.SetAuth("key", "secret")
+	return s, cl, env
+}
+
+func TestMarketSummary(t *testing.T) {
+	s, cl, _ := newSim(t)
+	for i := 0; i < 10; i++ {
+		s.Step()
+	}
+	sum, err := cl.GetMarketSummaryForPairRequest(context.Background(), &valr.GetMarketSummaryForPairRequest{Pair: "BTCZAR"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if sum.Pair != "BTCZAR" {
+		t.Errorf("Expected %q, got %q", "BTCZAR", sum.Pair)
+	}
+	if !sum.AskPrice.GreaterThan(sum.BidPrice) {
+		t.Errorf("Expected ask above bid, got bid %s ask %s", sum.BidPrice, sum.AskPrice)
+	}
+	if !sum.BaseVolume.IsPositive() {
+		t.Errorf("Expected trading volume, got %s", sum.BaseVolume)
+	}
+}
+
+func TestLimitOrderFills(t *testing.T) {
+	_, cl, _ := newSim(t)
+	ctx := context.Background()
+
+	book, err := cl.GetOrderBook(ctx, &valr.GetOrderBookRequest{Pair: "BTCZAR"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ask := book.Asks[0].Price
+	res, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Side:            valr.BUY,
+		Quantity:        decimal.New(1, -2),
+		Price:           ask,
+		Pair:            "BTCZAR",
+		CustomerOrderID: "buy1",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	status, err := cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: "BTCZAR", ID: res.ID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if status.OrderStatusType != sim.StatusFilled {
+		t.Errorf("Expected %q, got %q", sim.StatusFilled, status.OrderStatusType)
+	}
+
+	balances, err := cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	got := make(map[string]string)
+	for _, b := range balances {
+		got[b.Currency] = b.Available.String()
+	}
+	if want := decimal.New(1, -2).String(); got["BTC"] != want {
+		t.Errorf("Expected %q, got %q", want, got["BTC"])
+	}
+	if want := decimal.New(100000, 0).Sub(ask.Mul(decimal.New(1, -2))).String(); got["ZAR"] != want {
+		t.Errorf("Expected %q, got %q", want, got["ZAR"])
+	}
+}
+
+func TestRestingOrderCancel(t *testing.T) {
+	_, cl, _ := newSim(t)
+	ctx := context.Background()
+
+	res, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Side:     valr.BUY,
+		Quantity: decimal.New(1, -2),
+		Price:    decimal.New(500000, 0),
+		Pair:     "BTCZAR",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	open, err := cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(open) != 1 || open[0].OrderID != res.ID {
+		t.Fatalf("Expected order %s open, got %+v", res.ID, open)
+	}
+
+	if _, err := cl.DelOrderRequest(ctx, &valr.DelOrderRequest{Pair: "BTCZAR", ID: res.ID}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	open, err = cl.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(open) != 0 {
+		t.Errorf("Expected no open orders, got %+v", open)
+	}
+}
+
+func TestHaltedRejectsOrders(t *testing.T) {
+	s, cl, _ := newSim(t)
+	if err := s.SetScenario("BTCZAR", sim.Halted); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	ctx := context.Background()
+	res, err := cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Side:     valr.BUY,
+		Quantity: decimal.New(1, -2),
+		Price:    decimal.New(500000, 0),
+		Pair:     "BTCZAR",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	status, err := cl.GetOrderStatusByOrderIDRequest(ctx, &valr.GetOrderStatusByOrderIDRequest{Pair: "BTCZAR", ID: res.ID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if status.OrderStatusType != sim.StatusFailed {
+		t.Errorf("Expected %q, got %q", sim.StatusFailed, status.OrderStatusType)
+	}
+
+	before, _ := cl.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: "BTCZAR"})
+	s.Step()
+	after, _ := cl.GetMarketSummaryForPairRequest(ctx, &valr.GetMarketSummaryForPairRequest{Pair: "BTCZAR"})
+	if !before.LastPrice.Equal(after.LastPrice) || !before.BaseVolume.Equal(after.BaseVolume) {
+		t.Errorf("Expected halted market not to trade, got %s then %s", before.LastPrice, after.LastPrice)
+	}
+}
+
+func TestRejectsBadSignature(t *testing.T) {
+	_, cl, _ := newSim(t)
+	cl.SetAuth("key", "wrong")
+	_, err := cl.GetAccountBalancesRequest(context.Background(), &valr.GetAccountBalancesRequest{})
+	var apiErr *valr.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Errorf("Expected 401 API error, got %v", err)
+	}
+}
+
+func TestStreamTrades(t *testing.T) {
+	s, _, env := newSim(t)
+	trades := make(chan streaming.MessageTradeUpdate, 10)
+	c, err := streaming.Dial("key", "secret",
+		streaming.WithEnvironment(env),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { trades <- m }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.SubscribeToMarkets([]string{"BTCZAR"}).Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	s.Step()
+	select {
+	case m := <-trades:
+		if m.Data.CurrencyPair != "BTCZAR" {
+			t.Errorf("Expected %q, got %q", "BTCZAR", m.Data.CurrencyPair)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected a trade")
+	}
+}
+
+func TestTradeHistoryCursors(t *testing.T) {
+	s, cl, _ := newSim(t)
+	for i := 0; i < 20; i++ {
+		s.Step()
+	}
+	ctx := context.Background()
+	all, err := cl.GetTradeHistoryRequest(ctx, &valr.GetTradeHistoryRequest{Pair: "BTCZAR"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(all) < 4 {
+		t.Fatalf("Expected at least 4 trades, got %d", len(all))
+	}
+
+	page, err := cl.GetTradeHistoryPage(ctx, &valr.GetTradeHistoryRequest{Pair: "BTCZAR", Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	older, err := cl.GetTradeHistoryRequest(ctx, &valr.GetTradeHistoryRequest{Pair: "BTCZAR", BeforeID: page.NextBeforeID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(older) != len(all)-2 || older[0].ID != all[2].ID {
+		t.Errorf("Expected trades from %q, got %d trades", all[2].ID, len(older))
+	}
+
+	newer, err := cl.GetTradeHistoryRequest(ctx, &valr.GetTradeHistoryRequest{Pair: "BTCZAR", AfterID: all[2].ID})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(newer) != 2 || newer[1].ID != all[1].ID {
+		t.Errorf("Expected the 2 newest trades, got %d", len(newer))
+	}
+}
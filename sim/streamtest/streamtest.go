@@ -0,0 +1,222 @@
+// Package streamtest checks that streaming consumers recover from the
+// failures seen in production, such as connections cut off mid-message,
+// credentials rejected on reconnecting and subscriptions silently lost.
+// Each scenario runs a consumer against a fresh sim.Server, checks that it
+// receives trades, injects the fault and checks that it receives trades
+// again:
+//
+//	func TestReconnect(t *testing.T) {
+//		if err := streamtest.RunAll(context.Background(), startConsumer); err != nil {
+//			t.Error(err)
+//		}
+//	}
+package streamtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Pair is the pair traded in every scenario.
+	Pair = "BTCZAR"
+
+	defaultRecoveryTimeout = 30 * time.Second
+	defaultProbeInterval   = 100 * time.Millisecond
+)
+
+// Consumer starts the streaming consumer under test against env,
+// subscribed to the trades of pair, and returns once it is started. It
+// must call received with the ID of every trade it handles, and stop when
+// ctx is done.
+type Consumer func(ctx context.Context, env valr.Environment, pair string, received func(tradeID string)) error
+
+// Scenario is a fault a consumer must recover from.
+type Scenario struct {
+	Name        string
+	Description string
+	Fault       sim.ScriptEvent
+}
+
+var (
+	// Disconnect closes the trade stream cleanly.
+	Disconnect = Scenario{
+		Name:        "disconnect",
+		Description: "the trade stream is closed and reconnections are accepted",
+		Fault:       sim.ScriptEvent{Disconnect: &sim.ScriptDisconnect{Stream: "trade"}},
+	}
+	// MidMessageDisconnect cuts the trade stream off part way through a
+	// frame.
+	MidMessageDisconnect = Scenario{
+		Name:        "mid-message disconnect",
+		Description: "the trade stream is cut off part way through a frame",
+		Fault:       sim.ScriptEvent{Disconnect: &sim.ScriptDisconnect{Stream: "trade", MidMessage: true}},
+	}
+	// AuthRejection closes the trade stream and rejects reconnections as
+	// unauthorised for two seconds.
+	AuthRejection = Scenario{
+		Name:        "auth rejection",
+		Description: "the trade stream is closed and reconnections are rejected with 401 for 2s",
+		Fault: sim.ScriptEvent{Disconnect: &sim.ScriptDisconnect{
+			Stream: "trade",
+			For:    sim.Offset(2 * time.Second),
+			Status: http.StatusUnauthorized,
+		}},
+	}
+	// SubscriptionLoss makes the server forget the trade subscriptions
+	// while the connection stays up.
+	SubscriptionLoss = Scenario{
+		Name:        "subscription loss",
+		Description: "the server silently drops the trade subscriptions",
+		Fault:       sim.ScriptEvent{DropSubscriptions: &sim.ScriptDropSubscriptions{}},
+	}
+)
+
+// Scenarios returns the canned scenarios.
+func Scenarios() []Scenario {
+	return []Scenario{Disconnect, MidMessageDisconnect, AuthRejection, SubscriptionLoss}
+}
+
+type Option func(*config)
+
+type config struct {
+	recoveryTimeout time.Duration
+	probeInterval   time.Duration
+	simOpts         []sim.Option
+}
+
+// WithRecoveryTimeout sets how long the consumer has to receive trades,
+// both at the start and after the fault. The default of 30s allows for the
+// backoff of most reconnect policies.
+func WithRecoveryTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.recoveryTimeout = d
+	}
+}
+
+// WithProbeInterval sets how often a probe trade is made while waiting
+// for the consumer, 100ms by default.
+func WithProbeInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.probeInterval = d
+	}
+}
+
+// WithSimOptions configures the simulated server, e.g. to require
+// credentials with sim.WithCredentials.
+func WithSimOptions(opts ...sim.Option) Option {
+	return func(c *config) {
+		c.simOpts = append(c.simOpts, opts...)
+	}
+}
+
+// Run runs sc against the consumer started by start, returning an error if
+// the consumer doesn't receive trades before or after the fault.
+func Run(ctx context.Context, sc Scenario, start Consumer, opts ...Option) error {
+	cfg := config{recoveryTimeout: defaultRecoveryTimeout, probeInterval: defaultProbeInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := sim.New(append([]sim.Option{
+		sim.WithMarket(sim.Market{Pair: Pair, Price: decimal.New(1000000, 0)}),
+	}, cfg.simOpts...)...)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p := &prober{server: s, interval: cfg.probeInterval, seen: make(map[string]bool)}
+	if err := start(ctx, env, Pair, p.received); err != nil {
+		return fmt.Errorf("streamtest: %s: starting consumer: %w", sc.Name, err)
+	}
+	if err := p.await(ctx, cfg.recoveryTimeout); err != nil {
+		return fmt.Errorf("streamtest: %s: before fault: %w", sc.Name, err)
+	}
+	if err := s.Apply(sc.Fault); err != nil {
+		return fmt.Errorf("streamtest: %s: %w", sc.Name, err)
+	}
+	if err := p.await(ctx, cfg.recoveryTimeout); err != nil {
+		return fmt.Errorf("streamtest: %s: after fault: %w", sc.Name, err)
+	}
+	return nil
+}
+
+// RunAll runs every canned scenario, each against a new server and
+// consumer, returning the failures joined.
+func RunAll(ctx context.Context, start Consumer, opts ...Option) error {
+	var errs []error
+	for _, sc := range Scenarios() {
+		if err := Run(ctx, sc, start, opts...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// prober makes trades until the consumer receives one of them.
+type prober struct {
+	server   *sim.Server
+	interval time.Duration
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (p *prober) received(tradeID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen[tradeID] = true
+}
+
+// await makes a trade every interval until the consumer receives one made
+// since await was called, or the timeout passes.
+func (p *prober) await(ctx context.Context, timeout time.Duration) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	var probes []string
+	for {
+		t, err := p.server.Trade(sim.ScriptTrade{
+			Pair:     Pair,
+			Side:     valr.ResponseSideBuy,
+			Price:    decimal.New(1000000, 0),
+			Quantity: decimal.New(1, -3),
+		})
+		if err != nil {
+			return err
+		}
+		probes = append(probes, t.ID)
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return fmt.Errorf("no trades received within %s", timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		p.mu.Lock()
+		for _, id := range probes {
+			if p.seen[id] {
+				p.mu.Unlock()
+				return nil
+			}
+		}
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,81 @@
+package streamtest_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim/streamtest"
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+// startConn starts a consumer that subscribes on every connection and
+// resubscribes when trades stop arriving.
+func startConn(ctx context.Context, env valr.Environment, pair string, received func(string)) error {
+	var last atomic.Int64
+	c, err := streaming.Dial("key", "secret",
+		streaming.WithEnvironment(env),
+		streaming.WithBackoffHandler(func(int) time.Duration { return 50 * time.Millisecond }, time.Minute),
+		streaming.WithConnectCallback(func(c *streaming.Conn) {
+			c.SubscribeToMarkets([]string{pair})
+		}),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+			last.Store(time.Now().UnixNano())
+			received(m.Data.ID)
+		}))
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer c.Close()
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if time.Since(time.Unix(0, last.Load())) > 500*time.Millisecond {
+					c.SubscribeToMarkets([]string{pair})
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestScenarios(t *testing.T) {
+	for _, sc := range streamtest.Scenarios() {
+		t.Run(sc.Name, func(t *testing.T) {
+			if err := streamtest.Run(context.Background(), sc, startConn, streamtest.WithRecoveryTimeout(10*time.Second)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// startUnsubscribed starts a consumer that never subscribes again after
+// connecting.
+func startUnsubscribed(ctx context.Context, env valr.Environment, pair string, received func(string)) error {
+	c, err := streaming.Dial("key", "secret",
+		streaming.WithEnvironment(env),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { received(m.Data.ID) }))
+	if err != nil {
+		return err
+	}
+	c.SubscribeToMarkets([]string{pair})
+	go func() {
+		<-ctx.Done()
+		c.Close()
+	}()
+	return nil
+}
+
+func TestSubscriptionLossDetected(t *testing.T) {
+	err := streamtest.Run(context.Background(), streamtest.SubscriptionLoss, startUnsubscribed, streamtest.WithRecoveryTimeout(time.Second))
+	if err == nil {
+		t.Error("Expected error")
+	}
+}
@@ -0,0 +1,350 @@
+package sim
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// sendBuffer is the number of messages queued for a stream connection
+// before it is considered too slow and disconnected.
+const sendBuffer = 256
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streams holds the connected stream clients. It is guarded by Server.mu.
+type streams struct {
+	trade   map[*streamConn]struct{}
+	account map[*streamConn]struct{}
+	// tradeDown and accountDown are the outages of scripted disconnects,
+	// during which new connections are refused.
+	tradeDown   outage
+	accountDown outage
+}
+
+// outage refuses stream connections with status until until.
+type outage struct {
+	until  time.Time
+	status int
+}
+
+// streamConn is a client of the trade or account stream.
+type streamConn struct {
+	ws   *websocket.Conn
+	send chan []byte
+	done chan struct{}
+	// subs holds the subscribed pairs by event. It is guarded by
+	// Server.mu.
+	subs map[string]map[string]bool
+}
+
+// queue sends msg to the client without blocking, disconnecting clients
+// that have fallen too far behind.
+func (c *streamConn) queue(msg any) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("valr/sim: Failed to marshal message: %v", err)
+		return
+	}
+	select {
+	case c.send <- b:
+	case <-c.done:
+	default:
+		log.Printf("valr/sim: Disconnecting slow stream client")
+		c.ws.Close()
+	}
+}
+
+// cut makes the write loop cut the connection off part way through a
+// frame once the messages already queued are sent.
+func (c *streamConn) cut() {
+	select {
+	case c.send <- nil:
+	default:
+		c.ws.Close()
+	}
+}
+
+// partialFrame is the start of a text frame announcing more payload than
+// follows it.
+var partialFrame = append([]byte{0x81, 0x7d}, `{"type":"NEW_TRADE","currencyPairSymbol":`...)
+
+func (c *streamConn) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case b := <-c.send:
+			if b == nil {
+				_, _ = c.ws.UnderlyingConn().Write(partialFrame)
+				c.ws.Close()
+				return
+			}
+			if err := c.ws.WriteMessage(websocket.TextMessage, b); err != nil {
+				c.ws.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *streamConn) subscribed(event, pair string) bool {
+	return c.subs[event][pair]
+}
+
+// serveStream serves the trade stream, or the account stream if account is
+// true.
+func (s *Server) serveStream(account bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.authenticate(r, nil); err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		s.mu.Lock()
+		down := s.streams.tradeDown
+		if account {
+			down = s.streams.accountDown
+		}
+		refused := s.now().Before(down.until)
+		s.mu.Unlock()
+		if refused {
+			writeError(w, down.status, http.StatusText(down.status))
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		c := &streamConn{
+			ws:   ws,
+			send: make(chan []byte, sendBuffer),
+			done: make(chan struct{}),
+			subs: make(map[string]map[string]bool),
+		}
+		defer close(c.done)
+		go c.writeLoop()
+
+		s.mu.Lock()
+		set := &s.streams.trade
+		if account {
+			set = &s.streams.account
+		}
+		if *set == nil {
+			*set = make(map[*streamConn]struct{})
+		}
+		(*set)[c] = struct{}{}
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			delete(*set, c)
+			s.mu.Unlock()
+		}()
+
+		c.queue(streaming.MessageType{Type: "AUTHENTICATED"})
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.receivedStreamMessage(c, data)
+		}
+	}
+}
+
+// streamRequest is a message sent by a stream client.
+type streamRequest struct {
+	Type          string                    `json:"type"`
+	Subscriptions []streaming.Subscriptions `json:"subscriptions"`
+}
+
+func (s *Server) receivedStreamMessage(c *streamConn, data []byte) {
+	var req streamRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.queue(map[string]string{"type": "ERROR", "message": "Invalid message"})
+		return
+	}
+	switch req.Type {
+	case "PING":
+		c.queue(streaming.MessageType{Type: "PONG"})
+	case "SUBSCRIBE":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := s.now()
+		for _, sub := range req.Subscriptions {
+			pairs := make(map[string]bool, len(sub.Pairs))
+			for _, p := range sub.Pairs {
+				pairs[p] = true
+			}
+			c.subs[sub.Event] = pairs
+			c.queue(map[string]string{"type": "SUBSCRIBED", "message": "Subscribed to " + sub.Event})
+			if sub.Event != streaming.EventAggregatedOrderBookUpdate && sub.Event != streaming.EventFullOrderBookUpdate {
+				continue
+			}
+			// Send the current books, as VALR does on subscribing.
+			for _, p := range sub.Pairs {
+				if m, ok := s.markets[p]; ok {
+					c.queue(bookMessage(m, sub.Event, now))
+				}
+			}
+		}
+	}
+}
+
+// publishTrade sends a trade to the trade stream subscribers of its pair.
+func (s *Server) publishTrade(t valr.TradeHistoryInfo) {
+	msg := streaming.MessageTradeUpdate{
+		MessageType:        streaming.MessageType{Type: streaming.EventNewTrade},
+		CurrencyPairSymbol: t.Pair,
+	}
+	msg.Data.Price = t.Price
+	msg.Data.Quantity = t.Quantity
+	msg.Data.CurrencyPair = t.Pair
+	msg.Data.TradedAt = t.TradedAt
+	msg.Data.TakerSide = string(t.TakerSide)
+	msg.Data.ID = t.ID
+	for c := range s.streams.trade {
+		if c.subscribed(streaming.EventNewTrade, t.Pair) {
+			c.queue(msg)
+		}
+	}
+}
+
+// publishBook sends the book of m to its order book subscribers.
+func (s *Server) publishBook(m *market, now time.Time) {
+	for c := range s.streams.trade {
+		for _, event := range []string{streaming.EventAggregatedOrderBookUpdate, streaming.EventFullOrderBookUpdate} {
+			if c.subscribed(event, m.Pair) {
+				c.queue(bookMessage(m, event, now))
+			}
+		}
+	}
+}
+
+// bookMessage returns the book of m as a message for subscribers of event.
+// Full order book subscribers receive a snapshot on every change.
+func bookMessage(m *market, event string, now time.Time) any {
+	bids, asks := m.book()
+	checksum := int64(streaming.Checksum(levels(bids), levels(asks)))
+	if event == streaming.EventFullOrderBookUpdate {
+		msg := streaming.MessageFullOrderBook{
+			MessageType:        streaming.MessageType{Type: streaming.EventFullOrderBookSnapshot},
+			CurrencyPairSymbol: m.Pair,
+		}
+		msg.Data.Bids = fullLevels(bids)
+		msg.Data.Asks = fullLevels(asks)
+		msg.Data.LastChange = valr.NewTimestamp(now)
+		msg.Data.SequenceNumber = m.sequence
+		msg.Data.Checksum = checksum
+		return msg
+	}
+	msg := streaming.MessageAggregatedOrderBook{
+		MessageType:        streaming.MessageType{Type: streaming.EventAggregatedOrderBookUpdate},
+		CurrencyPairSymbol: m.Pair,
+	}
+	msg.Data.Bids = aggregatedLevels(bids)
+	msg.Data.Asks = aggregatedLevels(asks)
+	msg.Data.LastChange = valr.NewTimestamp(now)
+	msg.Data.SequenceNumber = m.sequence
+	msg.Data.Checksum = checksum
+	return msg
+}
+
+func levels(entries []valr.OrderBookEntry) []streaming.Level {
+	out := make([]streaming.Level, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, streaming.Level{Price: e.Price, Quantity: e.Quantity, OrderCount: e.OrderCount})
+	}
+	return out
+}
+
+func aggregatedLevels(entries []valr.OrderBookEntry) []streaming.AggregatedLevel {
+	out := make([]streaming.AggregatedLevel, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, streaming.AggregatedLevel{Side: e.Side, Quantity: e.Quantity, Price: e.Price, CurrencyPair: e.Pair, OrderCount: e.OrderCount})
+	}
+	return out
+}
+
+// fullLevels splits each level into OrderCount equal orders.
+func fullLevels(entries []valr.OrderBookEntry) []streaming.FullOrderBookLevel {
+	out := make([]streaming.FullOrderBookLevel, 0, len(entries))
+	for _, e := range entries {
+		l := streaming.FullOrderBookLevel{Price: e.Price}
+		qty := e.Quantity.Div(decimal.New(int64(e.OrderCount), 0))
+		for i := 0; i < e.OrderCount; i++ {
+			l.Orders = append(l.Orders, streaming.FullOrderBookOrder{
+				OrderID:  e.Side + ":" + e.Price.String() + ":" + decimal.New(int64(i), 0).String(),
+				Quantity: qty,
+			})
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// publishAccount sends msg to every account stream client.
+func (s *Server) publishAccount(msg any) {
+	for c := range s.streams.account {
+		c.queue(msg)
+	}
+}
+
+// publishOrder reports that an order was processed, successfully or not,
+// and its status.
+func (s *Server) publishOrder(o *order, success bool) {
+	processed := streaming.MessageOrderProcessed{MessageType: streaming.MessageType{Type: streaming.EventOrderProcessed}}
+	processed.Data = streaming.OrderProcessed{OrderID: o.OrderID, Success: success, FailureReason: o.FailedReason}
+	s.publishAccount(processed)
+	s.publishOrderStatus(o)
+}
+
+func (s *Server) publishOrderStatus(o *order) {
+	s.publishAccount(streaming.MessageOrderStatusUpdate{
+		MessageType: streaming.MessageType{Type: streaming.EventOrderStatusUpdate},
+		Data:        o.OrderStatus,
+	})
+}
+
+func (s *Server) publishAccountTrade(o *order, t valr.TradeHistoryInfo) {
+	s.publishAccount(streaming.MessageAccountTrade{
+		MessageType:        streaming.MessageType{Type: streaming.EventAccountTrade},
+		CurrencyPairSymbol: o.Pair,
+		Data: streaming.AccountTrade{
+			Price:        t.Price,
+			Quantity:     t.Quantity,
+			CurrencyPair: o.Pair,
+			TradedAt:     t.TradedAt,
+			Side:         o.OrderSide,
+			OrderID:      o.OrderID,
+			ID:           t.ID,
+		},
+	})
+}
+
+func (s *Server) publishBalance(currency string) {
+	b := s.account.accountBalance(currency)
+	msg := streaming.MessageBalanceUpdate{MessageType: streaming.MessageType{Type: streaming.EventBalanceUpdate}}
+	msg.Data.Currency.Symbol = currency
+	msg.Data.Currency.ShortName = currency
+	msg.Data.Available = b.Available
+	msg.Data.Reserved = b.Reserved
+	msg.Data.Total = b.Total
+	msg.Data.UpdatedAt = valr.NewTimestamp(s.now())
+	s.publishAccount(msg)
+}
+
+func (s *Server) publishFailedCancel(orderID, reason string) {
+	s.publishAccount(streaming.MessageFailedCancelOrder{
+		MessageType: streaming.MessageType{Type: streaming.EventFailedCancelOrder},
+		Data:        streaming.FailedCancelOrder{OrderID: orderID, Message: reason},
+	})
+}
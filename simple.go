@@ -0,0 +1,304 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrSlippageExceeded is returned when a quote's price is worse than the
+	// live ticker by more than the maximum slippage.
+	ErrSlippageExceeded = errors.New("valr: quote exceeds maximum slippage")
+	// ErrSimpleOrderFailed is returned when a simple buy or sell order
+	// completes unsuccessfully.
+	ErrSimpleOrderFailed = errors.New("valr: simple order failed")
+	// ErrQuoteExpired is returned when quotes keep expiring before they can
+	// be accepted.
+	ErrQuoteExpired = errors.New("valr: quote expired")
+	// ErrQuoteDrift is returned when a fresh quote's price has moved too far
+	// from the first quote's.
+	ErrQuoteDrift = errors.New("valr: quote price drifted")
+)
+
+// DefaultMaxSlippage is the default maximum fraction by which a quote's
+// price may be worse than the live ticker.
+var DefaultMaxSlippage = decimal.RequireFromString("0.01")
+
+// DefaultMaxQuoteDrift is the default maximum fraction by which the price of
+// a fresh quote may differ from that of the first quote.
+var DefaultMaxQuoteDrift = decimal.RequireFromString("0.005")
+
+const (
+	defaultSimpleTradePollInterval = time.Second
+	defaultQuoteTTL                = 10 * time.Second
+	defaultMaxRequotes             = 3
+)
+
+type simpleTradeConfig struct {
+	maxSlippage  decimal.Decimal
+	pollInterval time.Duration
+	quoteTTL     time.Duration
+	maxDrift     decimal.Decimal
+	maxRequotes  int
+}
+
+type SimpleTradeOption func(*simpleTradeConfig)
+
+// WithMaxSlippage sets the maximum fraction, e.g. 0.005 for 0.5%, by which
+// the quoted price may be worse than the live ticker.
+func WithMaxSlippage(fraction decimal.Decimal) SimpleTradeOption {
+	return func(c *simpleTradeConfig) {
+		c.maxSlippage = fraction
+	}
+}
+
+// WithSimpleTradePollInterval sets how often the order is polled until it
+// completes.
+func WithSimpleTradePollInterval(d time.Duration) SimpleTradeOption {
+	return func(c *simpleTradeConfig) {
+		c.pollInterval = d
+	}
+}
+
+// WithQuoteTTL sets how long a quote is considered valid after it is
+// received, 10 seconds by default.
+func WithQuoteTTL(d time.Duration) SimpleTradeOption {
+	return func(c *simpleTradeConfig) {
+		c.quoteTTL = d
+	}
+}
+
+// WithMaxQuoteDrift sets the maximum fraction by which the price of a fresh
+// quote, requested because the previous one expired, may differ from the
+// price of the first quote.
+func WithMaxQuoteDrift(fraction decimal.Decimal) SimpleTradeOption {
+	return func(c *simpleTradeConfig) {
+		c.maxDrift = fraction
+	}
+}
+
+// WithMaxRequotes sets how many times an expired quote is replaced before
+// giving up with ErrQuoteExpired.
+func WithMaxRequotes(n int) SimpleTradeOption {
+	return func(c *simpleTradeConfig) {
+		c.maxRequotes = n
+	}
+}
+
+// SimpleQuote is a simple buy or sell quote with its validity.
+type SimpleQuote struct {
+	*PostSimpleBuyOrSellQuoteResponse
+	Side RequestSide
+	// Price is in the quote currency per unit of base currency, including
+	// fees.
+	Price      decimal.Decimal
+	ReceivedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// Expired returns true if the quote is no longer valid at t.
+func (q *SimpleQuote) Expired(t time.Time) bool {
+	return !t.Before(q.ExpiresAt)
+}
+
+// drift returns the relative price difference between q and a later quote.
+func (q *SimpleQuote) drift(next *SimpleQuote) decimal.Decimal {
+	return next.Price.Sub(q.Price).Abs().Div(q.Price)
+}
+
+// BuyWithQuote buys the base currency of pair, paying amount of the quote
+// currency, e.g. BuyWithQuote(ctx, "BTCZAR", decimal.New(500, 0)) buys
+// R500 of bitcoin. It requests a quote, checks its price against the live
+// ask, places the simple order and polls it until it completes, returning
+// the resulting fill.
+//
+// The quote is advisory. VALR's simple orders don't refer to a quote, so the
+// order fills at the market price when it is placed, which may be worse
+// than the quoted price. The slippage check only refuses to place an order
+// when the quote is already too far from the market.
+//
+// Quotes expire quickly. If the quote has expired by the time the order
+// would be placed, a fresh quote is requested, provided its price has not
+// drifted too far from the first, and checked against the live ask again.
+func (cl *Client) BuyWithQuote(ctx context.Context, pair string, amount decimal.Decimal, opts ...SimpleTradeOption) (*GetSimpleBuyOrSellOrderStatusResponse, error) {
+	return cl.simpleTrade(ctx, pair, BUY, amount, opts)
+}
+
+// SellWithQuote sells amount of the base currency of pair for the quote
+// currency, like BuyWithQuote, checking the quoted price against the live
+// bid.
+func (cl *Client) SellWithQuote(ctx context.Context, pair string, amount decimal.Decimal, opts ...SimpleTradeOption) (*GetSimpleBuyOrSellOrderStatusResponse, error) {
+	return cl.simpleTrade(ctx, pair, SELL, amount, opts)
+}
+
+func (cl *Client) simpleTrade(ctx context.Context, pair string, side RequestSide,
+	amount decimal.Decimal, opts []SimpleTradeOption) (*GetSimpleBuyOrSellOrderStatusResponse, error) {
+
+	cfg := simpleTradeConfig{
+		maxSlippage:  DefaultMaxSlippage,
+		pollInterval: defaultSimpleTradePollInterval,
+		quoteTTL:     defaultQuoteTTL,
+		maxDrift:     DefaultMaxQuoteDrift,
+		maxRequotes:  defaultMaxRequotes,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	info, err := cl.pairInfo(ctx, pair)
+	if err != nil {
+		return nil, err
+	}
+	payIn := info.QuoteCurrency
+	if side == SELL {
+		payIn = info.BaseCurrency
+	}
+
+	req := &PostSimpleBuyOrSellQuoteRequest{
+		Pair:          pair,
+		PayInCurrency: payIn,
+		PayAmount:     amount,
+		Side:          side,
+	}
+	first, err := cl.SimpleQuote(ctx, req, cfg.quoteTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := first
+	for requotes := 0; ; requotes++ {
+		if err := cl.checkSlippage(ctx, pair, side, quote.Price, cfg.maxSlippage); err != nil {
+			return nil, err
+		}
+		// Checking the ticker takes a round trip, so the quote may have
+		// expired since; this is the last step before the order is placed.
+		if !quote.Expired(time.Now()) {
+			break
+		}
+		if requotes >= cfg.maxRequotes {
+			return nil, fmt.Errorf("%w: requoted %d times", ErrQuoteExpired, requotes)
+		}
+		if quote, err = cl.SimpleQuote(ctx, req, cfg.quoteTTL); err != nil {
+			return nil, err
+		}
+		if drift := first.drift(quote); drift.GreaterThan(cfg.maxDrift) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrQuoteDrift, first.Price, quote.Price)
+		}
+	}
+
+	order, err := cl.PostSimpleBuyOrSellOrderRequest(ctx, &PostSimpleBuyOrSellOrderRequest{
+		Pair:          pair,
+		PayInCurrency: payIn,
+		PayAmount:     amount,
+		Side:          side,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cl.awaitSimpleOrder(ctx, pair, order.OrderID, cfg.pollInterval)
+}
+
+// pairInfo returns the reference data of pair.
+func (cl *Client) pairInfo(ctx context.Context, pair string) (*PairInfo, error) {
+	pairs, err := cl.GetCurrencyPairs(ctx, &GetCurrencyPairsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pairs {
+		if pairs[i].Symbol == pair {
+			return &pairs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("valr: unknown currency pair %q", pair)
+}
+
+// SimpleQuote requests a quote for a simple buy or sell, valid for ttl from
+// when it is received.
+func (cl *Client) SimpleQuote(ctx context.Context, req *PostSimpleBuyOrSellQuoteRequest, ttl time.Duration) (*SimpleQuote, error) {
+	res, err := cl.PostSimpleBuyOrSellQuoteRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	price, err := quotePrice(req.Side, res)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &SimpleQuote{
+		PostSimpleBuyOrSellQuoteResponse: res,
+		Side:                             req.Side,
+		Price:                            price,
+		ReceivedAt:                       now,
+		ExpiresAt:                        now.Add(ttl),
+	}, nil
+}
+
+// quotePrice returns the price of a quote in the quote currency per unit of
+// base currency, including fees.
+func quotePrice(side RequestSide, q *PostSimpleBuyOrSellQuoteResponse) (decimal.Decimal, error) {
+	base, counter := q.ReceiveAmount, q.PayAmount
+	if side == SELL {
+		base, counter = q.PayAmount, q.ReceiveAmount
+	}
+	if !base.IsPositive() || !counter.IsPositive() {
+		return decimal.Decimal{}, fmt.Errorf("valr: quote %s has no amount", q.OrderID)
+	}
+	return counter.Div(base), nil
+}
+
+// checkSlippage compares a quoted price with the live ask when buying, or
+// bid when selling.
+func (cl *Client) checkSlippage(ctx context.Context, pair string, side RequestSide,
+	price, maxSlippage decimal.Decimal) error {
+
+	ticker, err := cl.GetMarketSummaryForPairRequest(ctx, &GetMarketSummaryForPairRequest{Pair: pair})
+	if err != nil {
+		return err
+	}
+
+	one := decimal.New(1, 0)
+	if side == BUY {
+		limit := ticker.AskPrice.Mul(one.Add(maxSlippage))
+		if price.GreaterThan(limit) {
+			return fmt.Errorf("%w: price %s, ask %s", ErrSlippageExceeded, price, ticker.AskPrice)
+		}
+		return nil
+	}
+	limit := ticker.BidPrice.Mul(one.Sub(maxSlippage))
+	if price.LessThan(limit) {
+		return fmt.Errorf("%w: price %s, bid %s", ErrSlippageExceeded, price, ticker.BidPrice)
+	}
+	return nil
+}
+
+// awaitSimpleOrder polls a simple order until it is no longer processing.
+func (cl *Client) awaitSimpleOrder(ctx context.Context, pair, orderID string,
+	interval time.Duration) (*GetSimpleBuyOrSellOrderStatusResponse, error) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := cl.GetSimpleBuyOrSellOrderStatusRequest(ctx,
+			&GetSimpleBuyOrSellOrderStatusRequest{Pair: pair, ID: orderID})
+		if err != nil {
+			return nil, err
+		}
+		if !status.Processing {
+			if !status.Success {
+				return status, fmt.Errorf("%w: %s", ErrSimpleOrderFailed, orderID)
+			}
+			return status, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
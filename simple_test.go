@@ -0,0 +1,224 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+// simpleExchange serves the calls of a simple buy of BTCZAR paying R1000.
+// Each list gives the responses to successive calls, repeating its last.
+type simpleExchange struct {
+	// received are the BTC amounts of successive quotes.
+	received []string
+	asks     []string
+	// tickerDelays delay the live ask returned.
+	tickerDelays []time.Duration
+	// statuses are the success and processing flags of the order's status.
+	statuses [][2]bool
+
+	mu                     sync.Mutex
+	quotes, tickers, polls int
+	orders                 int
+}
+
+func nth[T any](list []T, i int) T {
+	var zero T
+	if len(list) == 0 {
+		return zero
+	}
+	return list[min(i, len(list)-1)]
+}
+
+func (e *simpleExchange) counts() (quotes, tickers, orders, polls int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.quotes, e.tickers, e.orders, e.polls
+}
+
+func (e *simpleExchange) client(t *testing.T) *valr.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "GET /public/pairs":
+			w.Write([]byte(`[{"symbol":"BTCZAR","baseCurrency":"BTC","quoteCurrency":"ZAR","active":true}]`))
+		case "POST /simple/BTCZAR/quote":
+			fmt.Fprintf(w, `{"currencyPair":"BTCZAR","payAmount":"1000","receiveAmount":%q,"id":"q%d"}`,
+				nth(e.received, e.quotes), e.quotes)
+			e.quotes++
+		case "GET /public/BTCZAR/marketsummary":
+			delay := nth(e.tickerDelays, e.tickers)
+			ask := nth(e.asks, e.tickers)
+			e.tickers++
+			e.mu.Unlock()
+			time.Sleep(delay)
+			e.mu.Lock()
+			fmt.Fprintf(w, `{"currencyPair":"BTCZAR","askPrice":%q,"bidPrice":%q}`, ask, ask)
+		case "POST /simple/BTCZAR/order":
+			e.orders++
+			w.Write([]byte(`{"id":"order-1"}`))
+		case "GET /simple/BTCZAR/order/order-1":
+			status := nth(e.statuses, e.polls)
+			e.polls++
+			fmt.Fprintf(w, `{"orderId":"order-1","success":%t,"processing":%t,"paidAmount":"1000","receivedAmount":"0.001"}`,
+				status[0], status[1])
+		default:
+			t.Errorf("Unexpected call %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	cl := valr.NewClient()
+	t.Cleanup(func() { cl.Close() })
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return cl
+}
+
+func buy(cl *valr.Client, opts ...valr.SimpleTradeOption) (*valr.GetSimpleBuyOrSellOrderStatusResponse, error) {
+	opts = append([]valr.SimpleTradeOption{valr.WithSimpleTradePollInterval(time.Millisecond)}, opts...)
+	return cl.BuyWithQuote(context.Background(), "BTCZAR", decimal.New(1000, 0), opts...)
+}
+
+func TestBuyWithQuote(t *testing.T) {
+	e := &simpleExchange{
+		received: []string{"0.000995"},
+		asks:     []string{"1000000"},
+		statuses: [][2]bool{{false, true}, {false, true}, {true, false}},
+	}
+	status, err := buy(e.client(t))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if !status.Success || !status.ReceiveAmount.Equal(decimal.RequireFromString("0.001")) {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if quotes, _, orders, polls := e.counts(); quotes != 1 || orders != 1 || polls != 3 {
+		t.Errorf("Expected 1 quote, 1 order and 3 polls, got %d, %d and %d", quotes, orders, polls)
+	}
+}
+
+func TestBuyWithQuoteSlippage(t *testing.T) {
+	// R1000 for 0.00099 BTC is more than 1% above the ask.
+	e := &simpleExchange{received: []string{"0.00099"}, asks: []string{"1000000"}}
+	if _, err := buy(e.client(t)); !errors.Is(err, valr.ErrSlippageExceeded) {
+		t.Fatalf("Expected ErrSlippageExceeded, got %v", err)
+	}
+	if _, _, orders, _ := e.counts(); orders != 0 {
+		t.Errorf("Expected no order placed, got %d", orders)
+	}
+
+	// A larger allowance accepts it.
+	e = &simpleExchange{
+		received: []string{"0.00099"},
+		asks:     []string{"1000000"},
+		statuses: [][2]bool{{true, false}},
+	}
+	if _, err := buy(e.client(t), valr.WithMaxSlippage(decimal.RequireFromString("0.02"))); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+}
+
+func TestSellWithQuoteSlippage(t *testing.T) {
+	e := &simpleExchange{received: []string{"0.00099"}, asks: []string{"1000000"}}
+	cl := e.client(t)
+	// Selling 1000 BTC for 0.00099 ZAR is far below the bid.
+	_, err := cl.SellWithQuote(context.Background(), "BTCZAR", decimal.New(1000, 0))
+	if !errors.Is(err, valr.ErrSlippageExceeded) {
+		t.Errorf("Expected ErrSlippageExceeded, got %v", err)
+	}
+}
+
+func TestBuyWithQuoteFailedOrder(t *testing.T) {
+	e := &simpleExchange{
+		received: []string{"0.001"},
+		asks:     []string{"1000000"},
+		statuses: [][2]bool{{false, true}, {false, false}},
+	}
+	status, err := buy(e.client(t))
+	if !errors.Is(err, valr.ErrSimpleOrderFailed) {
+		t.Fatalf("Expected ErrSimpleOrderFailed, got %v", err)
+	}
+	if status == nil || status.OrderID != "order-1" {
+		t.Errorf("Expected the failed order's status, got %+v", status)
+	}
+}
+
+func TestBuyWithQuoteRequote(t *testing.T) {
+	// The first quote expires while the ticker is slow to answer, so a
+	// fresh quote is checked and accepted.
+	e := &simpleExchange{
+		received:     []string{"0.001", "0.000999"},
+		asks:         []string{"1000000"},
+		tickerDelays: []time.Duration{50 * time.Millisecond, 0},
+		statuses:     [][2]bool{{true, false}},
+	}
+	if _, err := buy(e.client(t), valr.WithQuoteTTL(30*time.Millisecond)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if quotes, tickers, orders, _ := e.counts(); quotes != 2 || tickers != 2 || orders != 1 {
+		t.Errorf("Expected 2 quotes, 2 ticker checks and 1 order, got %d, %d and %d", quotes, tickers, orders)
+	}
+}
+
+func TestBuyWithQuoteRequoteRejected(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		e    *simpleExchange
+		opts []valr.SimpleTradeOption
+		want error
+	}{
+		{
+			name: "drift",
+			e: &simpleExchange{
+				received:     []string{"0.001", "0.00099"},
+				asks:         []string{"1000000"},
+				tickerDelays: []time.Duration{50 * time.Millisecond, 0},
+			},
+			want: valr.ErrQuoteDrift,
+		},
+		{
+			name: "slippage",
+			// The fresh quote is close to the first, but the ask fell.
+			e: &simpleExchange{
+				received:     []string{"0.001", "0.000999"},
+				asks:         []string{"1000000", "980000"},
+				tickerDelays: []time.Duration{50 * time.Millisecond, 0},
+			},
+			want: valr.ErrSlippageExceeded,
+		},
+		{
+			name: "expired",
+			e: &simpleExchange{
+				received:     []string{"0.001"},
+				asks:         []string{"1000000"},
+				tickerDelays: []time.Duration{50 * time.Millisecond},
+			},
+			opts: []valr.SimpleTradeOption{valr.WithMaxRequotes(2)},
+			want: valr.ErrQuoteExpired,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]valr.SimpleTradeOption{valr.WithQuoteTTL(30 * time.Millisecond)}, tc.opts...)
+			if _, err := buy(tc.e.client(t), opts...); !errors.Is(err, tc.want) {
+				t.Fatalf("Expected %v, got %v", tc.want, err)
+			}
+			if _, _, orders, _ := tc.e.counts(); orders != 0 {
+				t.Errorf("Expected no order placed, got %d", orders)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package valr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSimplePollInterval = 2 * time.Second
+	defaultSimpleWatchTimeout = 5 * time.Minute
+)
+
+// ErrSimpleOrderTimeout is reported for simple orders that did not reach a
+// terminal state within the watch timeout.
+var ErrSimpleOrderTimeout = errors.New("valr: simple order did not complete in time")
+
+// SimpleOrderEvent is reported when a watched simple buy/sell order
+// completes, fails or times out.
+type SimpleOrderEvent struct {
+	Pair    string                                 `json:"currencyPair"`
+	OrderID string                                 `json:"orderId"`
+	Status  *GetSimpleBuyOrSellOrderStatusResponse `json:"status,omitempty"`
+	Error   string                                 `json:"error,omitempty"`
+}
+
+// Succeeded returns true if the order completed successfully.
+func (e SimpleOrderEvent) Succeeded() bool {
+	return e.Status != nil && e.Status.Success
+}
+
+// SimpleOrderCallback is called once for each watched order.
+type SimpleOrderCallback func(SimpleOrderEvent)
+
+type SimpleOrderWatcherOption func(*SimpleOrderWatcher)
+
+// WithSimplePollInterval sets how often pending orders are polled.
+func WithSimplePollInterval(d time.Duration) SimpleOrderWatcherOption {
+	return func(w *SimpleOrderWatcher) {
+		w.interval = d
+	}
+}
+
+// WithSimpleWatchTimeout sets how long an order is watched before it is
+// reported as timed out.
+func WithSimpleWatchTimeout(d time.Duration) SimpleOrderWatcherOption {
+	return func(w *SimpleOrderWatcher) {
+		w.timeout = d
+	}
+}
+
+// WithSimpleOrderCallback registers a callback for completed orders.
+func WithSimpleOrderCallback(fn SimpleOrderCallback) SimpleOrderWatcherOption {
+	return func(w *SimpleOrderWatcher) {
+		w.callbacks = append(w.callbacks, fn)
+	}
+}
+
+// WithSimpleOrderWebhook registers a URL that completed orders are POSTed to
+// as a JSON encoded SimpleOrderEvent.
+func WithSimpleOrderWebhook(url string) SimpleOrderWatcherOption {
+	return func(w *SimpleOrderWatcher) {
+		w.webhooks = append(w.webhooks, url)
+	}
+}
+
+type watchedSimpleOrder struct {
+	pair     string
+	orderID  string
+	deadline time.Time
+}
+
+// SimpleOrderWatcher polls submitted simple buy/sell orders until they reach
+// a terminal state and notifies callbacks and webhooks of the outcome.
+type SimpleOrderWatcher struct {
+	client    *Client
+	interval  time.Duration
+	timeout   time.Duration
+	callbacks []SimpleOrderCallback
+	webhooks  []string
+
+	mu      sync.Mutex
+	pending map[string]watchedSimpleOrder
+}
+
+// NewSimpleOrderWatcher creates a watcher. Call Run to start polling.
+func NewSimpleOrderWatcher(cl *Client, opts ...SimpleOrderWatcherOption) *SimpleOrderWatcher {
+	w := &SimpleOrderWatcher{
+		client:   cl,
+		interval: defaultSimplePollInterval,
+		timeout:  defaultSimpleWatchTimeout,
+		pending:  make(map[string]watchedSimpleOrder),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Watch adds an order to the set of orders being polled.
+func (w *SimpleOrderWatcher) Watch(pair, orderID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[orderID] = watchedSimpleOrder{
+		pair:     pair,
+		orderID:  orderID,
+		deadline: time.Now().Add(w.timeout),
+	}
+}
+
+// Pending returns the number of orders still being watched.
+func (w *SimpleOrderWatcher) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Run polls pending orders until ctx is cancelled.
+func (w *SimpleOrderWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+func (w *SimpleOrderWatcher) poll(ctx context.Context) {
+	w.mu.Lock()
+	orders := make([]watchedSimpleOrder, 0, len(w.pending))
+	for _, o := range w.pending {
+		orders = append(orders, o)
+	}
+	w.mu.Unlock()
+
+	for _, o := range orders {
+		status, err := w.client.GetSimpleBuyOrSellOrderStatusRequest(ctx,
+			&GetSimpleBuyOrSellOrderStatusRequest{Pair: o.pair, ID: o.orderID})
+		if ctx.Err() != nil {
+			return
+		}
+
+		ev := SimpleOrderEvent{Pair: o.pair, OrderID: o.orderID}
+		switch {
+		case err == nil && !status.Processing:
+			ev.Status = status
+		case time.Now().After(o.deadline):
+			ev.Status = status
+			ev.Error = ErrSimpleOrderTimeout.Error()
+		case err != nil:
+			log.Printf("valr: Failed to poll simple order %s: %v", o.orderID, err)
+			continue
+		default:
+			continue
+		}
+
+		w.mu.Lock()
+		delete(w.pending, o.orderID)
+		w.mu.Unlock()
+
+		w.notify(ctx, ev)
+	}
+}
+
+func (w *SimpleOrderWatcher) notify(ctx context.Context, ev SimpleOrderEvent) {
+	for _, fn := range w.callbacks {
+		fn(ev)
+	}
+	if len(w.webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("valr: Failed to marshal simple order event: %v", err)
+		return
+	}
+	for _, url := range w.webhooks {
+		if err := w.post(ctx, url, body); err != nil {
+			log.Printf("valr: Failed to deliver webhook to %s: %v", url, err)
+		}
+	}
+}
+
+func (w *SimpleOrderWatcher) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
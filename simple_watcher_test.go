@@ -0,0 +1,130 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// webhookTransport reports the events delivered to webhooks once each
+// delivery has been answered.
+type webhookTransport struct {
+	delivered chan valr.SimpleOrderEvent
+}
+
+func (rt *webhookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/hook" {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	var ev valr.SimpleOrderEvent
+	if err := json.NewDecoder(body).Decode(&ev); err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && res.StatusCode == http.StatusOK {
+		rt.delivered <- ev
+	}
+	return res, err
+}
+
+func TestSimpleOrderWatcher(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		polls = make(map[string]int)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /simple/BTCZAR/order/done":
+			mu.Lock()
+			polls["done"]++
+			processing := polls["done"] < 3
+			mu.Unlock()
+			if processing {
+				w.Write([]byte(`{"orderId":"done","success":false,"processing":true}`))
+				return
+			}
+			w.Write([]byte(`{"orderId":"done","success":true,"processing":false,"receivedAmount":"0.001"}`))
+		case "GET /simple/BTCZAR/order/failed":
+			w.Write([]byte(`{"orderId":"failed","success":false,"processing":false}`))
+		case "GET /simple/BTCZAR/order/stuck":
+			w.Write([]byte(`{"orderId":"stuck","success":false,"processing":true}`))
+		case "POST /hook":
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected a JSON content type, got %q", ct)
+			}
+		default:
+			t.Errorf("Unexpected call %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	rt := &webhookTransport{delivered: make(chan valr.SimpleOrderEvent, 10)}
+	cl.SetHTTPClient(&http.Client{Transport: rt})
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	events := make(chan valr.SimpleOrderEvent, 10)
+	w := valr.NewSimpleOrderWatcher(cl,
+		valr.WithSimplePollInterval(time.Millisecond),
+		valr.WithSimpleWatchTimeout(100*time.Millisecond),
+		valr.WithSimpleOrderCallback(func(ev valr.SimpleOrderEvent) { events <- ev }),
+		valr.WithSimpleOrderWebhook(srv.URL+"/hook"))
+	for _, id := range []string{"done", "failed", "stuck"} {
+		w.Watch("BTCZAR", id)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	running := make(chan error, 1)
+	go func() { running <- w.Run(ctx) }()
+
+	// Each order is reported once, to the callback and the webhook.
+	byID := make(map[string]valr.SimpleOrderEvent)
+	hooked := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(byID) < 3 || len(hooked) < 3 {
+		select {
+		case ev := <-events:
+			if _, ok := byID[ev.OrderID]; ok {
+				t.Errorf("Expected %s reported once", ev.OrderID)
+			}
+			byID[ev.OrderID] = ev
+		case ev := <-rt.delivered:
+			hooked[ev.OrderID] = true
+		case <-timeout:
+			t.Fatalf("Expected 3 events and webhooks, got %v and %v", byID, hooked)
+		}
+	}
+	if ev := byID["done"]; !ev.Succeeded() || ev.Error != "" || ev.Pair != "BTCZAR" || ev.Status.ReceiveAmount.String() != "0.001" {
+		t.Errorf("Expected the completed order, got %+v", ev)
+	}
+	if ev := byID["failed"]; ev.Succeeded() || ev.Status == nil || ev.Error != "" {
+		t.Errorf("Expected the failed order, got %+v", ev)
+	}
+	if ev := byID["stuck"]; ev.Succeeded() || ev.Error != valr.ErrSimpleOrderTimeout.Error() {
+		t.Errorf("Expected the order timed out, got %+v", ev)
+	}
+	if n := w.Pending(); n != 0 {
+		t.Errorf("Expected no orders pending, got %d", n)
+	}
+
+	cancel()
+	if err := <-running; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
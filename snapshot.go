@@ -0,0 +1,111 @@
+package valr
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MarketSnapshot is a consolidated view of market summaries, order books and
+// account balances fetched together by Client.Snapshot.
+type MarketSnapshot struct {
+	// Summaries holds the market summary of each requested pair.
+	Summaries map[string]MarketSummary
+	// OrderBooks holds the top of the order book of each requested pair.
+	OrderBooks map[string]*OrderBook
+	// Balances is only populated if the client has credentials.
+	Balances []AccountBalance
+	// FetchedAt is when the last of the requests completed.
+	FetchedAt time.Time
+}
+
+// Snapshot concurrently fetches the market summary and order book of each
+// of the given pairs, as well as the account balances if the client has
+// credentials. All requests share the client's rate limiter. If any request
+// fails the remaining requests are cancelled and the first error is returned.
+func (cl *Client) Snapshot(ctx context.Context, pairs []string) (*MarketSnapshot, error) {
+	g, ctx := newFetchGroup(ctx)
+	defer g.cancel()
+
+	snap := &MarketSnapshot{
+		Summaries:  make(map[string]MarketSummary, len(pairs)),
+		OrderBooks: make(map[string]*OrderBook, len(pairs)),
+	}
+	var mu sync.Mutex
+	for _, pair := range pairs {
+		pair := pair
+		g.fetch(func() error {
+			res, err := cl.GetMarketSummaryForPairRequest(ctx, &GetMarketSummaryForPairRequest{Pair: pair})
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			snap.Summaries[pair] = *res
+			mu.Unlock()
+			return nil
+		})
+		g.fetch(func() error {
+			res, err := cl.GetOrderBook(ctx, &GetOrderBookRequest{Pair: pair})
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			snap.OrderBooks[pair] = res
+			mu.Unlock()
+			return nil
+		})
+	}
+	if cl.signer != nil {
+		g.fetch(func() error {
+			res, err := cl.GetAccountBalancesRequest(ctx, &GetAccountBalancesRequest{})
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			snap.Balances = res
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.wait(); err != nil {
+		return nil, err
+	}
+	snap.FetchedAt = time.Now()
+	return snap, nil
+}
+
+// fetchGroup runs requests concurrently, cancelling the remaining requests
+// when one fails.
+type fetchGroup struct {
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	firstErr error
+}
+
+func newFetchGroup(ctx context.Context) (*fetchGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &fetchGroup{cancel: cancel}, ctx
+}
+
+func (g *fetchGroup) fetch(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.mu.Lock()
+			if g.firstErr == nil {
+				g.firstErr = err
+				g.cancel()
+			}
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// wait waits for all requests, returning the first error.
+func (g *fetchGroup) wait() error {
+	g.wg.Wait()
+	return g.firstErr
+}
@@ -0,0 +1,103 @@
+package valr_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestSnapshot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.URL.Path == "/account/balances":
+			w.Write([]byte(`[{"currency":"ZAR","available":"100","total":"100"}]`))
+		case len(parts) == 3 && parts[2] == "marketsummary":
+			fmt.Fprintf(w, `{"currencyPair":%q,"askPrice":"10"}`, parts[1])
+		case len(parts) == 3 && parts[2] == "orderbook":
+			w.Write([]byte(`{"Asks":[{"side":"sell","quantity":"1","price":"10"}],"Bids":[],"SequenceNumber":7}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	snap, err := cl.Snapshot(context.Background(), []string{"BTCZAR", "ETHZAR"})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, pair := range []string{"BTCZAR", "ETHZAR"} {
+		if s, ok := snap.Summaries[pair]; !ok || s.Pair != pair {
+			t.Errorf("Expected the summary of %s, got %+v", pair, s)
+		}
+		if b := snap.OrderBooks[pair]; b == nil || b.SequenceNumber != 7 || len(b.Asks) != 1 {
+			t.Errorf("Expected the order book of %s, got %+v", pair, b)
+		}
+	}
+	if len(snap.Balances) != 1 || snap.Balances[0].Currency != "ZAR" {
+		t.Errorf("Expected the ZAR balance, got %+v", snap.Balances)
+	}
+	if snap.FetchedAt.IsZero() {
+		t.Error("Expected the fetch time set")
+	}
+}
+
+func TestSnapshotFailure(t *testing.T) {
+	var started, cancelled atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public/ETHZAR/orderbook" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1,"message":"Invalid currency pair"}`))
+			return
+		}
+		// Every other request waits until it is cancelled.
+		started.Add(1)
+		select {
+		case <-r.Context().Done():
+			cancelled.Add(1)
+		case <-time.After(5 * time.Second):
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	start := time.Now()
+	snap, err := cl.Snapshot(context.Background(), []string{"BTCZAR", "ETHZAR"})
+	if err == nil || !strings.Contains(err.Error(), "Invalid currency pair") {
+		t.Fatalf("Expected the order book error, got %v", err)
+	}
+	if snap != nil {
+		t.Errorf("Expected no snapshot, got %+v", snap)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected the other fetches cancelled, took %s", d)
+	}
+	// The server sees the cancellations once their connections close.
+	for deadline := time.Now().Add(2 * time.Second); cancelled.Load() < started.Load() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if n, m := cancelled.Load(), started.Load(); n != m {
+		t.Errorf("Expected the %d fetches in progress cancelled, got %d", m, n)
+	}
+}
@@ -0,0 +1,430 @@
+// Package statement compiles account statements for a period: opening and
+// closing balances, trades, deposits, withdrawals and fees, as a document
+// model that can be rendered as CSV, or in other formats such as PDF with a
+// custom Renderer.
+package statement
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/shopspring/decimal"
+)
+
+// Entry kinds.
+const (
+	KindTrade      = "TRADE"
+	KindDeposit    = "DEPOSIT"
+	KindWithdrawal = "WITHDRAWAL"
+	KindOther      = "OTHER"
+)
+
+const pageSize = 100
+
+var (
+	depositTypes    = []string{"FIAT_DEPOSIT", "BLOCKCHAIN_RECEIVE", "INTERNAL_TRANSFER_IN", "VALR_PAY_RECEIVED"}
+	withdrawalTypes = []string{"FIAT_WITHDRAWAL", "BLOCKCHAIN_SEND", "INTERNAL_TRANSFER_OUT", "VALR_PAY_SENT"}
+)
+
+// Balance is the balance of a currency at the start and end of the period.
+type Balance struct {
+	Currency string          `json:"currency"`
+	Opening  decimal.Decimal `json:"opening"`
+	Closing  decimal.Decimal `json:"closing"`
+	// Value is the closing balance in the statement's base currency, if it
+	// has one.
+	Value decimal.Decimal `json:"value"`
+}
+
+// Entry is a single transaction in the period.
+type Entry struct {
+	Time           time.Time       `json:"time"`
+	Kind           string          `json:"kind"`
+	Type           string          `json:"type"`
+	Description    string          `json:"description"`
+	Pair           string          `json:"pair,omitempty"`
+	DebitCurrency  string          `json:"debitCurrency,omitempty"`
+	DebitValue     decimal.Decimal `json:"debitValue"`
+	CreditCurrency string          `json:"creditCurrency,omitempty"`
+	CreditValue    decimal.Decimal `json:"creditValue"`
+	FeeCurrency    string          `json:"feeCurrency,omitempty"`
+	FeeValue       decimal.Decimal `json:"feeValue"`
+}
+
+// Statement is an account's activity over a period.
+type Statement struct {
+	// Account is the subaccount ID, empty for the primary account.
+	Account     string    `json:"account"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Location is the time zone the statement's times are reported in, UTC
+	// if nil.
+	Location *time.Location `json:"-"`
+	// Formatter rounds and formats the amounts of rendered statements to
+	// the decimal places of their currency. Amounts are rendered exactly if
+	// nil.
+	Formatter *money.Formatter `json:"-"`
+	// Balances is sorted by currency.
+	Balances []Balance `json:"balances"`
+	// Entries is in chronological order.
+	Entries []Entry `json:"entries"`
+	// Fees holds the fees paid, keyed by currency.
+	Fees map[string]decimal.Decimal `json:"fees"`
+
+	// Base is the currency balances and fees are valued in, if the builder
+	// was given one with WithBaseCurrency. Values are at the rates when the
+	// statement was built.
+	Base string `json:"base,omitempty"`
+	// TotalValue is the sum of the closing balance values.
+	TotalValue decimal.Decimal `json:"totalValue"`
+	// FeesValue is the sum of the fees in the base currency.
+	FeesValue decimal.Decimal `json:"feesValue"`
+	// Unpriced lists currencies that could not be converted to the base
+	// currency and are excluded from the values.
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// Kind returns the entries of the given kind.
+func (s *Statement) Kind(kind string) []Entry {
+	var entries []Entry
+	for _, e := range s.Entries {
+		if e.Kind == kind {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Renderer writes a statement in some format.
+type Renderer interface {
+	Render(w io.Writer, s *Statement) error
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(w io.Writer, s *Statement) error
+
+func (f RendererFunc) Render(w io.Writer, s *Statement) error {
+	return f(w, s)
+}
+
+// CSV renders statements as CSV sections, separated by blank lines: a
+// summary, the balances, the fees and the entries.
+var CSV Renderer = RendererFunc(renderCSV)
+
+func renderCSV(w io.Writer, s *Statement) error {
+	cw := csv.NewWriter(w)
+	account := s.Account
+	if account == "" {
+		account = "primary"
+	}
+	if s.Base == "" {
+		cw.Write([]string{"account", "from", "to", "generated_at"})
+		cw.Write([]string{account, s.formatTime(s.From), s.formatTime(s.To), s.formatTime(s.GeneratedAt)})
+	} else {
+		cw.Write([]string{"account", "from", "to", "generated_at", "base", "total_value", "fees_value"})
+		cw.Write([]string{account, s.formatTime(s.From), s.formatTime(s.To), s.formatTime(s.GeneratedAt),
+			s.Base, s.formatAmount(s.Base, s.TotalValue), s.formatAmount(s.Base, s.FeesValue)})
+	}
+	cw.Write(nil)
+
+	if s.Base == "" {
+		cw.Write([]string{"currency", "opening", "closing"})
+	} else {
+		cw.Write([]string{"currency", "opening", "closing", "value"})
+	}
+	for _, b := range s.Balances {
+		row := []string{b.Currency, s.formatAmount(b.Currency, b.Opening), s.formatAmount(b.Currency, b.Closing)}
+		if s.Base != "" {
+			row = append(row, s.formatAmount(s.Base, b.Value))
+		}
+		cw.Write(row)
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{"fee_currency", "fees"})
+	for _, c := range sortedKeys(s.Fees) {
+		cw.Write([]string{c, s.formatAmount(c, s.Fees[c])})
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{"time", "kind", "type", "description", "pair", "debit_currency", "debit_value", "credit_currency", "credit_value", "fee_currency", "fee_value"})
+	for _, e := range s.Entries {
+		cw.Write([]string{
+			s.formatTime(e.Time), e.Kind, e.Type, e.Description, e.Pair,
+			e.DebitCurrency, s.formatAmount(e.DebitCurrency, e.DebitValue),
+			e.CreditCurrency, s.formatAmount(e.CreditCurrency, e.CreditValue),
+			e.FeeCurrency, s.formatAmount(e.FeeCurrency, e.FeeValue),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *Statement) formatTime(t time.Time) string {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+func (s *Statement) formatAmount(currency string, d decimal.Decimal) string {
+	if s.Formatter == nil || currency == "" {
+		return d.String()
+	}
+	return s.Formatter.Format(currency, d)
+}
+
+// Month returns the period of a calendar month in loc, such as
+// Africa/Johannesburg for statements aligned to local tax periods.
+func Month(year int, month time.Month, loc *time.Location) (from, to time.Time) {
+	from = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 1, 0)
+}
+
+func sortedKeys(m map[string]decimal.Decimal) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Builder compiles statements from the account's balances and transaction
+// history.
+type Builder struct {
+	client    *valr.Client
+	now       func() time.Time
+	location  *time.Location
+	formatter *money.Formatter
+	fx        *fx.Converter
+	base      string
+}
+
+type BuilderOption func(*Builder)
+
+// WithLocation reports the times of statements in loc rather than UTC.
+func WithLocation(loc *time.Location) BuilderOption {
+	return func(b *Builder) {
+		b.location = loc
+	}
+}
+
+// WithFormatter rounds the amounts of rendered statements with f, such as
+// money.Default for ZAR in cents.
+func WithFormatter(f *money.Formatter) BuilderOption {
+	return func(b *Builder) {
+		b.formatter = f
+	}
+}
+
+// WithBaseCurrency values the closing balances and fees of statements in
+// base, such as ZAR, USDT or BTC, converted with conv at the rates when
+// each statement is built.
+func WithBaseCurrency(conv *fx.Converter, base string) BuilderOption {
+	return func(b *Builder) {
+		b.fx = conv
+		b.base = base
+	}
+}
+
+// NewBuilder returns a Builder of statements for cl's accounts.
+func NewBuilder(cl *valr.Client, opts ...BuilderOption) *Builder {
+	b := &Builder{client: cl, now: time.Now, location: time.UTC}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build compiles the statement of account, a subaccount ID or empty for the
+// primary account, from from until to. Balances are derived from the
+// current balances by unwinding the transactions made since, so the whole
+// history back to from is read. Fees are taken to be charged on top of the
+// debit and credit values.
+func (b *Builder) Build(ctx context.Context, account string, from, to time.Time) (*Statement, error) {
+	if account != "" {
+		ctx = valr.WithSubaccount(ctx, account)
+	}
+	balances, err := b.client.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	txs, err := b.history(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Statement{
+		Account:     account,
+		From:        from.In(b.location),
+		To:          to.In(b.location),
+		GeneratedAt: b.now().In(b.location),
+		Location:    b.location,
+		Formatter:   b.formatter,
+		Fees:        make(map[string]decimal.Decimal),
+	}
+	closing := make(map[string]decimal.Decimal)
+	for _, bal := range balances {
+		closing[bal.Currency] = bal.Total
+	}
+	// Unwind the transactions after to, then those in the period, newest
+	// first.
+	opening := make(map[string]decimal.Decimal)
+	for _, t := range txs {
+		if !t.EventAt.Before(to) {
+			unwind(closing, t)
+		}
+	}
+	for c, v := range closing {
+		opening[c] = v
+	}
+	for i := len(txs) - 1; i >= 0; i-- {
+		t := txs[i]
+		if t.EventAt.Before(to) {
+			e := newEntry(t)
+			e.Time = e.Time.In(b.location)
+			s.Entries = append(s.Entries, e)
+			if t.FeeValue.IsPositive() {
+				s.Fees[t.FeeCurrency] = s.Fees[t.FeeCurrency].Add(t.FeeValue)
+			}
+		}
+	}
+	for _, t := range txs {
+		if t.EventAt.Before(to) {
+			unwind(opening, t)
+		}
+	}
+
+	for c, v := range closing {
+		if o := opening[c]; !v.IsZero() || !o.IsZero() {
+			s.Balances = append(s.Balances, Balance{Currency: c, Opening: o, Closing: v})
+		}
+	}
+	sort.Slice(s.Balances, func(i, j int) bool { return s.Balances[i].Currency < s.Balances[j].Currency })
+	if b.fx != nil {
+		if err := b.value(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// value values the balances and fees of s in the builder's base currency.
+func (b *Builder) value(ctx context.Context, s *Statement) error {
+	s.Base = b.base
+	unpriced := make(map[string]bool)
+	convert := func(currency string, amount decimal.Decimal) (decimal.Decimal, bool, error) {
+		v, err := b.fx.Convert(ctx, amount, currency, b.base)
+		if err != nil {
+			if ctx.Err() != nil {
+				return decimal.Zero, false, ctx.Err()
+			}
+			if !unpriced[currency] {
+				unpriced[currency] = true
+				s.Unpriced = append(s.Unpriced, currency)
+			}
+			return decimal.Zero, false, nil
+		}
+		return v, true, nil
+	}
+	for i, bal := range s.Balances {
+		if bal.Closing.IsZero() {
+			continue
+		}
+		v, ok, err := convert(bal.Currency, bal.Closing)
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.Balances[i].Value = v
+			s.TotalValue = s.TotalValue.Add(v)
+		}
+	}
+	for _, c := range sortedKeys(s.Fees) {
+		v, ok, err := convert(c, s.Fees[c])
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.FeesValue = s.FeesValue.Add(v)
+		}
+	}
+	sort.Strings(s.Unpriced)
+	return nil
+}
+
+// history returns the transactions made since from, newest first.
+func (b *Builder) history(ctx context.Context, from time.Time) ([]valr.TransactionInfo, error) {
+	var txs []valr.TransactionInfo
+	req := &valr.GetTransactionHistoryRequest{Limit: pageSize}
+	for {
+		page, err := b.client.GetTransactionHistoryPage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Items {
+			if t.EventAt.Before(from) {
+				return txs, nil
+			}
+			txs = append(txs, t)
+		}
+		if !page.HasMore {
+			return txs, nil
+		}
+		req.Skip = page.NextSkip
+	}
+}
+
+// unwind reverses the effect of t on balances.
+func unwind(balances map[string]decimal.Decimal, t valr.TransactionInfo) {
+	if t.CreditCurrency != "" {
+		balances[t.CreditCurrency] = balances[t.CreditCurrency].Sub(t.CreditValue)
+	}
+	if t.DebitCurrency != "" {
+		balances[t.DebitCurrency] = balances[t.DebitCurrency].Add(t.DebitValue)
+	}
+	if t.FeeCurrency != "" {
+		balances[t.FeeCurrency] = balances[t.FeeCurrency].Add(t.FeeValue)
+	}
+}
+
+func newEntry(t valr.TransactionInfo) Entry {
+	return Entry{
+		Time:           t.EventAt.Time,
+		Kind:           kind(t.TransactionType.Type),
+		Type:           t.TransactionType.Type,
+		Description:    t.TransactionType.Description,
+		Pair:           t.AdditionalInfo.CurrencyPairSymbol,
+		DebitCurrency:  t.DebitCurrency,
+		DebitValue:     t.DebitValue,
+		CreditCurrency: t.CreditCurrency,
+		CreditValue:    t.CreditValue,
+		FeeCurrency:    t.FeeCurrency,
+		FeeValue:       t.FeeValue,
+	}
+}
+
+// kind classifies a transaction type.
+func kind(typ string) string {
+	switch {
+	case slices.Contains(depositTypes, typ):
+		return KindDeposit
+	case slices.Contains(withdrawalTypes, typ):
+		return KindWithdrawal
+	case strings.HasSuffix(typ, "_BUY") || strings.HasSuffix(typ, "_SELL"):
+		return KindTrade
+	default:
+		return KindOther
+	}
+}
@@ -0,0 +1,170 @@
+package statement_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/fx"
+	"github.com/donohutcheon/valr-go/marketdata"
+	"github.com/donohutcheon/valr-go/money"
+	"github.com/donohutcheon/valr-go/statement"
+	"github.com/shopspring/decimal"
+)
+
+func TestBuild(t *testing.T) {
+	var subaccount string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subaccount = r.Header.Get("X-VALR-SUB-ACCOUNT-ID")
+		if strings.HasSuffix(r.URL.Path, "/balances") {
+			w.Write([]byte(`[{"currency":"ZAR","total":"400"},{"currency":"BTC","total":"0.02"}]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"transactionType":{"type":"FIAT_WITHDRAWAL","description":"Withdraw"},"debitCurrency":"ZAR","debitValue":"100","eventAt":"2024-02-02T00:00:00Z"},
+			{"transactionType":{"type":"LIMIT_BUY","description":"Limit Buy"},"debitCurrency":"ZAR","debitValue":"500","creditCurrency":"BTC","creditValue":"0.01","feeCurrency":"BTC","feeValue":"0.0001","eventAt":"2024-01-20T00:00:00Z","additionalInfo":{"currencyPairSymbol":"BTCZAR"}},
+			{"transactionType":{"type":"FIAT_DEPOSIT","description":"Deposit"},"creditCurrency":"ZAR","creditValue":"1000","eventAt":"2024-01-10T00:00:00Z"},
+			{"transactionType":{"type":"FIAT_DEPOSIT","description":"Deposit"},"creditCurrency":"ZAR","creditValue":"1","eventAt":"2023-12-10T00:00:00Z"}
+		]`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	s, err := statement.NewBuilder(cl).Build(context.Background(), "sub1", from, to)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if subaccount != "sub1" {
+		t.Errorf("Expected subaccount header %q, got %q", "sub1", subaccount)
+	}
+
+	// BTC opens at 0.02 - 0.01 + 0.0001, since the fee is charged on top,
+	// and ZAR closes at 400 + 100 withdrawn after the period.
+	want := []statement.Balance{
+		{Currency: "BTC", Opening: decimal.RequireFromString("0.0101"), Closing: decimal.RequireFromString("0.02")},
+		{Currency: "ZAR", Opening: decimal.Zero, Closing: decimal.New(500, 0)},
+	}
+	if len(s.Balances) != len(want) {
+		t.Fatalf("Expected %d balances, got %+v", len(want), s.Balances)
+	}
+	for i, b := range s.Balances {
+		if b.Currency != want[i].Currency || !b.Opening.Equal(want[i].Opening) || !b.Closing.Equal(want[i].Closing) {
+			t.Errorf("Expected %+v, got %+v", want[i], b)
+		}
+	}
+
+	if len(s.Entries) != 2 || s.Entries[0].Kind != statement.KindDeposit || s.Entries[1].Kind != statement.KindTrade || s.Entries[1].Pair != "BTCZAR" {
+		t.Errorf("Unexpected entries %+v", s.Entries)
+	}
+	if got := s.Kind(statement.KindWithdrawal); len(got) != 0 {
+		t.Errorf("Expected no withdrawals in the period, got %+v", got)
+	}
+	if got := s.Fees["BTC"]; !got.Equal(decimal.RequireFromString("0.0001")) {
+		t.Errorf("Expected BTC fees of 0.0001, got %s", got)
+	}
+
+	var buf bytes.Buffer
+	if err := statement.CSV.Render(&buf, s); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, line := range []string{
+		"sub1,2024-01-01T00:00:00Z,2024-02-01T00:00:00Z,",
+		"ZAR,0,500",
+		"BTC,0.0001",
+		"2024-01-20T00:00:00Z,TRADE,LIMIT_BUY,Limit Buy,BTCZAR,ZAR,500,BTC,0.01,BTC,0.0001",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected CSV to contain %q, got\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestRenderLocationAndFormatter(t *testing.T) {
+	sast := time.FixedZone("SAST", 2*60*60)
+	from, to := statement.Month(2024, time.February, sast)
+	if want := "2024-01-31T22:00:00Z"; from.UTC().Format(time.RFC3339) != want {
+		t.Errorf("Expected %q, got %q", want, from.UTC().Format(time.RFC3339))
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, sast); !to.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, to)
+	}
+
+	s := &statement.Statement{
+		From: from, To: to, Location: sast, Formatter: money.Default,
+		Balances: []statement.Balance{{Currency: "ZAR", Opening: decimal.RequireFromString("0.005"), Closing: decimal.New(500, 0)}},
+	}
+	var buf bytes.Buffer
+	if err := statement.CSV.Render(&buf, s); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, line := range []string{
+		"primary,2024-02-01T00:00:00+02:00,2024-03-01T00:00:00+02:00,",
+		"ZAR,0.01,500.00",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected CSV to contain %q, got\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestBuildBaseCurrency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/balances") {
+			w.Write([]byte(`[{"currency":"ZAR","total":"400"},{"currency":"BTC","total":"0.02"},{"currency":"XYZ","total":"5"}]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"transactionType":{"type":"LIMIT_BUY","description":"Limit Buy"},"debitCurrency":"ZAR","debitValue":"500","creditCurrency":"BTC","creditValue":"0.01","feeCurrency":"BTC","feeValue":"0.0001","eventAt":"2024-01-20T00:00:00Z","additionalInfo":{"currencyPairSymbol":"BTCZAR"}}
+		]`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	tickers := marketdata.NewTickerStore()
+	for pair, mid := range map[string]string{"BTCZAR": "1000000", "USDTZAR": "20"} {
+		p := decimal.RequireFromString(mid)
+		tickers.Update(marketdata.Ticker{Pair: pair, Bid: p, Ask: p, Last: p})
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	s, err := statement.NewBuilder(cl, statement.WithBaseCurrency(fx.New(tickers), "USDT")).Build(context.Background(), "", from, to)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// 0.02 BTC is 20000 ZAR, or 1000 USDT, and 400 ZAR is 20 USDT.
+	if s.Base != "USDT" || s.TotalValue.String() != "1020" || s.FeesValue.String() != "5" {
+		t.Errorf("Expected 1020 USDT with 5 of fees, got %s with %s %s", s.TotalValue, s.FeesValue, s.Base)
+	}
+	if len(s.Unpriced) != 1 || s.Unpriced[0] != "XYZ" {
+		t.Errorf("Expected XYZ to be unpriced, got %v", s.Unpriced)
+	}
+
+	var buf bytes.Buffer
+	if err := statement.CSV.Render(&buf, s); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, line := range []string{",USDT,1020,5", "BTC,0.0101,0.02,1000"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected CSV to contain %q, got\n%s", line, buf.String())
+		}
+	}
+}
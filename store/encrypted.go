@@ -0,0 +1,196 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DefaultKeyVar is the environment variable KeyFromEnv reads by
+// convention.
+const DefaultKeyVar = "VA_STORE_KEY"
+
+// formatVersion prefixes every file written by EncryptedFile, so the format
+// can change without misreading old files.
+const formatVersion = 1
+
+// ErrDecrypt is returned for values that can't be decrypted, because the
+// key is wrong or the file was modified or moved.
+var ErrDecrypt = errors.New("store: cannot decrypt value")
+
+// KeyFunc returns an AES key of 16, 24 or 32 bytes.
+type KeyFunc func(ctx context.Context) ([]byte, error)
+
+// KeyFromEnv returns a KeyFunc reading a base64 encoded key from the
+// environment variable name, e.g. one generated with
+// "openssl rand -base64 32".
+func KeyFromEnv(name string) KeyFunc {
+	return func(context.Context) ([]byte, error) {
+		v := os.Getenv(name)
+		if v == "" {
+			return nil, fmt.Errorf("store: %s not set", name)
+		}
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("store: decoding %s: %w", name, err)
+		}
+		return key, nil
+	}
+}
+
+// KeyFromKMS returns a KeyFunc unwrapping a data key encrypted by a key
+// management service, with decrypt calling the service, so only the
+// wrapped key needs to be kept with the store.
+func KeyFromKMS(wrapped []byte, decrypt func(ctx context.Context, ciphertext []byte) ([]byte, error)) KeyFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		key, err := decrypt(ctx, wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("store: unwrapping key: %w", err)
+		}
+		return key, nil
+	}
+}
+
+// EncryptedFile is a Store keeping each value in its own file under a
+// directory, encrypted with AES-GCM, for state that may contain sensitive
+// account details. Namespaces are subdirectories. The namespace and key of
+// a value are authenticated with it, so a file moved to another key fails
+// to decrypt. Writes replace files atomically.
+type EncryptedFile struct {
+	dir  string
+	aead cipher.AEAD
+
+	mu sync.RWMutex
+}
+
+// NewEncryptedFile returns a store under dir, creating it if needed, with
+// the key returned by key.
+func NewEncryptedFile(ctx context.Context, dir string, key KeyFunc) (*EncryptedFile, error) {
+	k, err := key(ctx)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &EncryptedFile{dir: dir, aead: aead}, nil
+}
+
+// encodeName makes a namespace or key safe to use as a file name.
+func encodeName(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func (s *EncryptedFile) path(namespace, key string) string {
+	return filepath.Join(s.dir, encodeName(namespace), encodeName(key))
+}
+
+// additionalData binds a value to its namespace and key.
+func additionalData(namespace, key string) []byte {
+	return []byte(namespace + "\x00" + key)
+}
+
+func (s *EncryptedFile) Get(_ context.Context, namespace, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, err := os.ReadFile(s.path(namespace, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	n := s.aead.NonceSize()
+	if len(b) < 1+n || b[0] != formatVersion {
+		return nil, fmt.Errorf("%w: %s/%s: unknown format", ErrDecrypt, namespace, key)
+	}
+	v, err := s.aead.Open(nil, b[1:1+n], b[1+n:], additionalData(namespace, key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrDecrypt, namespace, key)
+	}
+	return v, nil
+}
+
+func (s *EncryptedFile) Put(_ context.Context, namespace, key string, value []byte) error {
+	var b bytes.Buffer
+	b.WriteByte(formatVersion)
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	b.Write(nonce)
+	b.Write(s.aead.Seal(nil, nonce, value, additionalData(namespace, key)))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Join(s.dir, encodeName(namespace))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(namespace, key))
+}
+
+func (s *EncryptedFile) Delete(_ context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(namespace, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *EncryptedFile) List(_ context.Context, namespace string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries, err := os.ReadDir(filepath.Join(s.dir, encodeName(namespace)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		k, err := base64.RawURLEncoding.DecodeString(e.Name())
+		if err != nil || e.IsDir() {
+			// Partial writes and foreign files.
+			continue
+		}
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,89 @@
+package store_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/store"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv("TEST_STORE_KEY", base64.StdEncoding.EncodeToString(key))
+
+	s, err := store.NewEncryptedFile(ctx, dir, store.KeyFromEnv("TEST_STORE_KEY"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := s.Get(ctx, "orders", "a"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected %v, got %v", store.ErrNotFound, err)
+	}
+	for _, k := range []string{"b/1", "a"} {
+		if err := s.Put(ctx, "orders", k, []byte(`{"secret":"`+k+`"}`)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	v, err := s.Get(ctx, "orders", "b/1")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if string(v) != `{"secret":"b/1"}` {
+		t.Errorf("Expected %q, got %q", `{"secret":"b/1"}`, v)
+	}
+	keys, err := s.List(ctx, "orders")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if strings.Join(keys, ",") != "a,b/1" {
+		t.Errorf("Expected %q, got %q", "a,b/1", keys)
+	}
+
+	// Nothing is stored in the clear.
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if b, _ := os.ReadFile(path); !d.IsDir() && strings.Contains(string(b), "secret") {
+			t.Errorf("Expected %s to be encrypted, got %q", path, b)
+		}
+		return nil
+	})
+
+	// A value copied to another key doesn't decrypt.
+	names, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	b, _ := os.ReadFile(names[0])
+	os.WriteFile(filepath.Join(filepath.Dir(names[0]), "Yw"), b, 0o600) // "c"
+	if _, err := s.Get(ctx, "orders", "c"); !errors.Is(err, store.ErrDecrypt) {
+		t.Errorf("Expected %v, got %v", store.ErrDecrypt, err)
+	}
+
+	// Values are read back with the key after a restart, and not without.
+	s, err = store.NewEncryptedFile(ctx, dir, store.KeyFromKMS([]byte("wrapped"), func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		return key, nil
+	}))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := s.Get(ctx, "orders", "a"); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if err := s.Delete(ctx, "orders", "a"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := s.Get(ctx, "orders", "a"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected %v, got %v", store.ErrNotFound, err)
+	}
+	other, err := store.NewEncryptedFile(ctx, dir, func(context.Context) ([]byte, error) {
+		return []byte("fedcba9876543210fedcba9876543210"), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := other.Get(ctx, "orders", "b/1"); !errors.Is(err, store.ErrDecrypt) {
+		t.Errorf("Expected %v, got %v", store.ErrDecrypt, err)
+	}
+}
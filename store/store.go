@@ -0,0 +1,99 @@
+// Package store defines the persistence interface used by stateful helpers
+// such as the order manager, so their state survives restarts. Implement
+// Store to back them with Redis, SQL, bolt or similar; Memory is provided as
+// an in-process default and EncryptedFile for encrypted state on disk.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is returned by Get for keys that are not stored.
+var ErrNotFound = errors.New("store: not found")
+
+// Store is a key-value store partitioned into namespaces. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Get returns the value of key in namespace, or ErrNotFound.
+	Get(ctx context.Context, namespace, key string) ([]byte, error)
+	// Put sets the value of key in namespace.
+	Put(ctx context.Context, namespace, key string, value []byte) error
+	// Delete removes key from namespace. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, namespace, key string) error
+	// List returns the keys in namespace in ascending order.
+	List(ctx context.Context, namespace string) ([]string, error)
+}
+
+// Memory is an in-memory Store.
+type Memory struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{data: make(map[string]map[string][]byte)}
+}
+
+func (m *Memory) Get(_ context.Context, namespace, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.data[namespace][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), v...), nil
+}
+
+func (m *Memory) Put(_ context.Context, namespace, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ns, ok := m.data[namespace]
+	if !ok {
+		ns = make(map[string][]byte)
+		m.data[namespace] = ns
+	}
+	ns[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, namespace, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data[namespace], key)
+	return nil
+}
+
+func (m *Memory) List(_ context.Context, namespace string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.data[namespace]))
+	for k := range m.data[namespace] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetJSON decodes the JSON value of key in namespace into v.
+func GetJSON(ctx context.Context, s Store, namespace, key string, v any) error {
+	b, err := s.Get(ctx, namespace, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// PutJSON stores v, encoded as JSON, as the value of key in namespace.
+func PutJSON(ctx context.Context, s Store, namespace, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, namespace, key, b)
+}
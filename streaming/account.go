@@ -0,0 +1,169 @@
+package streaming
+
+import (
+	"errors"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// streamAccount names the account stream in journal entries.
+const streamAccount = "account"
+
+// Account event types, in addition to those reconstructed by
+// BackfillAccount.
+const (
+	EventOpenOrdersUpdate  = "OPEN_ORDERS_UPDATE"
+	EventOrderProcessed    = "ORDER_PROCESSED"
+	EventFailedCancelOrder = "FAILED_CANCEL_ORDER"
+)
+
+type (
+	BalanceUpdateCallback     func(MessageBalanceUpdate)
+	OpenOrdersUpdateCallback  func(MessageOpenOrdersUpdate)
+	OrderStatusUpdateCallback func(MessageOrderStatusUpdate)
+	AccountTradeCallback      func(MessageAccountTrade)
+	OrderProcessedCallback    func(MessageOrderProcessed)
+	FailedCancelOrderCallback func(MessageFailedCancelOrder)
+)
+
+// accountCallbacks holds the callbacks of account stream messages.
+type accountCallbacks struct {
+	balanceUpdate     BalanceUpdateCallback
+	openOrdersUpdate  OpenOrdersUpdateCallback
+	orderStatusUpdate OrderStatusUpdateCallback
+	accountTrade      AccountTradeCallback
+	orderProcessed    OrderProcessedCallback
+	failedCancelOrder FailedCancelOrderCallback
+}
+
+// WithBalanceUpdateCallback sets a callback for BALANCE_UPDATE messages.
+func WithBalanceUpdateCallback(fn BalanceUpdateCallback) DialOption {
+	return func(c *Conn) {
+		c.account.balanceUpdate = fn
+	}
+}
+
+// WithOpenOrdersUpdateCallback sets a callback for OPEN_ORDERS_UPDATE
+// messages.
+func WithOpenOrdersUpdateCallback(fn OpenOrdersUpdateCallback) DialOption {
+	return func(c *Conn) {
+		c.account.openOrdersUpdate = fn
+	}
+}
+
+// WithOrderStatusUpdateCallback sets a callback for ORDER_STATUS_UPDATE
+// messages.
+func WithOrderStatusUpdateCallback(fn OrderStatusUpdateCallback) DialOption {
+	return func(c *Conn) {
+		c.account.orderStatusUpdate = fn
+	}
+}
+
+// WithAccountTradeCallback sets a callback for NEW_ACCOUNT_TRADE messages.
+func WithAccountTradeCallback(fn AccountTradeCallback) DialOption {
+	return func(c *Conn) {
+		c.account.accountTrade = fn
+	}
+}
+
+// WithOrderProcessedCallback sets a callback for ORDER_PROCESSED messages.
+func WithOrderProcessedCallback(fn OrderProcessedCallback) DialOption {
+	return func(c *Conn) {
+		c.account.orderProcessed = fn
+	}
+}
+
+// WithFailedCancelOrderCallback sets a callback for FAILED_CANCEL_ORDER
+// messages.
+func WithFailedCancelOrderCallback(fn FailedCancelOrderCallback) DialOption {
+	return func(c *Conn) {
+		c.account.failedCancelOrder = fn
+	}
+}
+
+// DialAccount connects to the account stream, which delivers balance, order
+// and trade updates for the API key's account to the callbacks set by opts.
+// The server sends every account event without subscriptions. The
+// connection will automatically reconnect on error.
+func DialAccount(keyID, keySecret string, opts ...DialOption) (*Conn, error) {
+	if keyID == "" || keySecret == "" {
+		return nil, errors.New("streaming: streaming API requires credentials")
+	}
+	signer, err := valr.NewHMACSigner(keySecret)
+	if err != nil {
+		return nil, err
+	}
+	return DialAccountWithSigner(keyID, signer, opts...)
+}
+
+// DialAccountWithSigner is like DialAccount but authenticates using the
+// given Signer rather than a raw API secret.
+func DialAccountWithSigner(keyID string, signer valr.Signer, opts ...DialOption) (*Conn, error) {
+	return DialWithSigner(keyID, signer, append([]DialOption{withStream(streamAccount)}, opts...)...)
+}
+
+func withStream(stream string) DialOption {
+	return func(c *Conn) {
+		c.stream = stream
+	}
+}
+
+// receivedAccountUpdate passes an account stream message to its callback,
+// returning false for message types not from the account stream.
+func (c *Conn) receivedAccountUpdate(msgType string, data []byte) (bool, error) {
+	var (
+		msg any
+		fn  func()
+	)
+	switch msgType {
+	case EventBalanceUpdate:
+		m := new(MessageBalanceUpdate)
+		msg = m
+		if cb := c.account.balanceUpdate; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	case EventOpenOrdersUpdate:
+		m := new(MessageOpenOrdersUpdate)
+		msg = m
+		if cb := c.account.openOrdersUpdate; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	case EventOrderStatusUpdate:
+		m := new(MessageOrderStatusUpdate)
+		msg = m
+		if cb := c.account.orderStatusUpdate; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	case EventAccountTrade:
+		m := new(MessageAccountTrade)
+		msg = m
+		if cb := c.account.accountTrade; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	case EventOrderProcessed:
+		m := new(MessageOrderProcessed)
+		msg = m
+		if cb := c.account.orderProcessed; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	case EventFailedCancelOrder:
+		m := new(MessageFailedCancelOrder)
+		msg = m
+		if cb := c.account.failedCancelOrder; cb != nil {
+			fn = func() { cb(*m) }
+		}
+	default:
+		return false, nil
+	}
+	if fn == nil && c.bus == nil && c.eventTimeCallback == nil && c.latency == nil {
+		return true, nil
+	}
+	if err := c.decode(data, msg); err != nil {
+		return true, err
+	}
+	if fn != nil {
+		c.deliver(CallbackAccount, msg, fn)
+	}
+	c.publish(msg)
+	return true, nil
+}
@@ -0,0 +1,106 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+func TestAccountReplay(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"BALANCE_UPDATE","data":{"currency":{"symbol":"ZAR"},"available":"90","reserved":"10","total":"100"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"OPEN_ORDERS_UPDATE","data":[{"orderId":"o1","side":"buy","quantity":"1","price":"100","currencyPair":"BTCZAR"}]}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"ORDER_STATUS_UPDATE","data":{"orderId":"o1","orderStatusType":"Filled","currencyPair":"BTCZAR"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"NEW_ACCOUNT_TRADE","currencyPairSymbol":"BTCZAR","data":{"price":"100","quantity":"1","orderId":"o1","id":"t1"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"ORDER_PROCESSED","data":{"orderId":"o2","success":false,"failureReason":"Insufficient balance"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"FAILED_CANCEL_ORDER","data":{"orderId":"o3","message":"Order not found"}}}`,
+	}, "\n")
+
+	var got []string
+	err := streaming.Replay(context.Background(), strings.NewReader(journal),
+		streaming.WithBalanceUpdateCallback(func(m streaming.MessageBalanceUpdate) {
+			got = append(got, m.Type+" "+m.Data.Currency.Symbol+" "+m.Data.Total.String())
+		}),
+		streaming.WithOpenOrdersUpdateCallback(func(m streaming.MessageOpenOrdersUpdate) {
+			got = append(got, m.Type+" "+m.Data[0].OrderID+" "+m.Data[0].Price.String())
+		}),
+		streaming.WithOrderStatusUpdateCallback(func(m streaming.MessageOrderStatusUpdate) {
+			got = append(got, m.Type+" "+m.Data.OrderID+" "+m.Data.OrderStatusType)
+		}),
+		streaming.WithAccountTradeCallback(func(m streaming.MessageAccountTrade) {
+			got = append(got, m.Type+" "+m.Data.ID+" "+m.Data.OrderID)
+		}),
+		streaming.WithOrderProcessedCallback(func(m streaming.MessageOrderProcessed) {
+			got = append(got, m.Type+" "+m.Data.OrderID+" "+m.Data.FailureReason)
+		}),
+		streaming.WithFailedCancelOrderCallback(func(m streaming.MessageFailedCancelOrder) {
+			got = append(got, m.Type+" "+m.Data.OrderID+" "+m.Data.Message)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := []string{
+		"BALANCE_UPDATE ZAR 100",
+		"OPEN_ORDERS_UPDATE o1 100",
+		"ORDER_STATUS_UPDATE o1 Filled",
+		"NEW_ACCOUNT_TRADE t1 o1",
+		"ORDER_PROCESSED o2 Insufficient balance",
+		"FAILED_CANCEL_ORDER o3 Order not found",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestDialAccount(t *testing.T) {
+	paths := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		paths <- r.URL.Path
+		ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"AUTHENTICATED"}`))
+		ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"ORDER_PROCESSED","data":{"orderId":"o1","success":true}}`))
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	processed := make(chan streaming.OrderProcessed, 1)
+	c, err := streaming.DialAccount("key", "secret",
+		streaming.WithEnvironment(env),
+		streaming.WithOrderProcessedCallback(func(m streaming.MessageOrderProcessed) {
+			processed <- m.Data
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	select {
+	case p := <-processed:
+		if p.OrderID != "o1" || !p.Success {
+			t.Errorf("Unexpected message %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected ORDER_PROCESSED message")
+	}
+	if p := <-paths; p != "/ws/account" {
+		t.Errorf("Expected %q, got %q", "/ws/account", p)
+	}
+}
@@ -0,0 +1,99 @@
+package streaming
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BookMetrics are short-horizon analytics computed from the top of an order
+// book.
+type BookMetrics struct {
+	Pair     string
+	Sequence int64
+	// Depth is the number of levels per side used for the depth-based
+	// metrics.
+	Depth   int
+	BestBid decimal.Decimal
+	BestAsk decimal.Decimal
+	Mid     decimal.Decimal
+	Spread  decimal.Decimal
+	// Microprice is the mid weighted by the opposite side's top-level
+	// quantity: (bid*askQty + ask*bidQty) / (bidQty + askQty).
+	Microprice decimal.Decimal
+	// WeightedMid is the average of the volume weighted bid and ask prices
+	// across Depth levels.
+	WeightedMid decimal.Decimal
+	// Imbalance is (bidQty - askQty) / (bidQty + askQty) across Depth
+	// levels, ranging from -1 (all asks) to 1 (all bids).
+	Imbalance decimal.Decimal
+	UpdatedAt time.Time
+}
+
+// ComputeBookMetrics computes BookMetrics from a snapshot using depth levels
+// per side. It returns false if either side of the book is empty.
+func ComputeBookMetrics(snap BookSnapshot, depth int) (BookMetrics, bool) {
+	if len(snap.Bids) == 0 || len(snap.Asks) == 0 {
+		return BookMetrics{}, false
+	}
+	if depth < 1 {
+		depth = 1
+	}
+
+	two := decimal.New(2, 0)
+	bid, ask := snap.Bids[0], snap.Asks[0]
+	m := BookMetrics{
+		Pair:      snap.Pair,
+		Sequence:  snap.Sequence,
+		Depth:     depth,
+		BestBid:   bid.Price,
+		BestAsk:   ask.Price,
+		Mid:       bid.Price.Add(ask.Price).Div(two),
+		Spread:    ask.Price.Sub(bid.Price),
+		UpdatedAt: snap.UpdatedAt,
+	}
+
+	topQty := bid.Quantity.Add(ask.Quantity)
+	if topQty.IsPositive() {
+		m.Microprice = bid.Price.Mul(ask.Quantity).Add(ask.Price.Mul(bid.Quantity)).Div(topQty)
+	} else {
+		m.Microprice = m.Mid
+	}
+
+	bidVWAP, bidQty := vwap(snap.Bids, depth)
+	askVWAP, askQty := vwap(snap.Asks, depth)
+	m.WeightedMid = bidVWAP.Add(askVWAP).Div(two)
+	if total := bidQty.Add(askQty); total.IsPositive() {
+		m.Imbalance = bidQty.Sub(askQty).Div(total)
+	}
+	return m, true
+}
+
+func vwap(levels []Level, depth int) (price, qty decimal.Decimal) {
+	var notional decimal.Decimal
+	for _, l := range levels[:min(depth, len(levels))] {
+		notional = notional.Add(l.Price.Mul(l.Quantity))
+		qty = qty.Add(l.Quantity)
+	}
+	if !qty.IsPositive() {
+		return levels[0].Price, qty
+	}
+	return notional.Div(qty), qty
+}
+
+// Metrics computes BookMetrics for the current state of the book.
+func (b *OrderBook) Metrics(depth int) (BookMetrics, bool) {
+	return ComputeBookMetrics(b.Snapshot(), depth)
+}
+
+// SubscribeMetrics returns a subscription that receives fresh BookMetrics
+// for pair, computed over depth levels, after every change to the book.
+func (k *BookKeeper) SubscribeMetrics(pair string, depth int) *Subscription[BookMetrics] {
+	sub := newSubscription[BookMetrics]()
+	sub.remove = k.Book(pair).addListener(func(b *OrderBook) {
+		if m, ok := b.Metrics(depth); ok {
+			sub.deliver(m)
+		}
+	})
+	return sub
+}
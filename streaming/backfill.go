@@ -0,0 +1,155 @@
+package streaming
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// Account event types.
+const (
+	EventAccountTrade      = "NEW_ACCOUNT_TRADE"
+	EventOrderStatusUpdate = "ORDER_STATUS_UPDATE"
+	EventBalanceUpdate     = "BALANCE_UPDATE"
+)
+
+const backfillPageSize = 100
+
+// AccountEvent is a single account event. Exactly one of Trade, OrderStatus
+// and Balance is set, according to Type.
+type AccountEvent struct {
+	Type string
+	Time time.Time
+	// Backfilled is true for events reconstructed from REST history rather
+	// than received from the account stream.
+	Backfilled  bool
+	Trade       *AccountTrade
+	OrderStatus *valr.OrderStatus
+	Balance     *BalanceUpdate
+}
+
+// BackfillAccount reconstructs account events since the given time from REST
+// history: trades from transaction history, order status changes from order
+// history and the current balances. Events are returned oldest first, with
+// balances last, so they can be replayed ahead of live account stream
+// events to give a continuous log across restarts.
+//
+// History is paged newest first until an entry older than since is
+// reached. Trade events reconstructed from transaction history carry no
+// trade ID.
+func BackfillAccount(ctx context.Context, cl *valr.Client, since time.Time) ([]AccountEvent, error) {
+	trades, err := backfillTrades(ctx, cl, since)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := backfillOrders(ctx, cl, since)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	events := append(trades, orders...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	now := time.Now()
+	for _, b := range balances {
+		bu := &BalanceUpdate{
+			Available: b.Available,
+			Reserved:  b.Reserved,
+			Total:     b.Total,
+			UpdatedAt: valr.NewTimestamp(now),
+		}
+		bu.Currency.Symbol = b.Currency
+		events = append(events, AccountEvent{
+			Type:       EventBalanceUpdate,
+			Time:       now,
+			Backfilled: true,
+			Balance:    bu,
+		})
+	}
+	return events, nil
+}
+
+func backfillTrades(ctx context.Context, cl *valr.Client, since time.Time) ([]AccountEvent, error) {
+	var events []AccountEvent
+	for skip := 0; ; skip += backfillPageSize {
+		page, err := cl.GetTransactionHistoryPage(ctx, &valr.GetTransactionHistoryRequest{Skip: skip, Limit: backfillPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range page.Items {
+			if tx.EventAt.Before(since) {
+				return events, nil
+			}
+			kind := tx.TransactionType.Type
+			t := &AccountTrade{
+				Price:        tx.AdditionalInfo.CostPerCoin,
+				CurrencyPair: tx.AdditionalInfo.CurrencyPairSymbol,
+				TradedAt:     tx.EventAt,
+				OrderID:      tx.AdditionalInfo.OrderID,
+			}
+			switch {
+			case strings.HasSuffix(kind, "_BUY"):
+				t.Side, t.Quantity = valr.ResponseSideBuy, tx.CreditValue
+			case strings.HasSuffix(kind, "_SELL"):
+				t.Side, t.Quantity = valr.ResponseSideSell, tx.DebitValue
+			default:
+				continue
+			}
+			events = append(events, AccountEvent{
+				Type:       EventAccountTrade,
+				Time:       tx.EventAt.Time,
+				Backfilled: true,
+				Trade:      t,
+			})
+		}
+		if !page.HasMore {
+			return events, nil
+		}
+	}
+}
+
+func backfillOrders(ctx context.Context, cl *valr.Client, since time.Time) ([]AccountEvent, error) {
+	var events []AccountEvent
+	for skip := 0; ; skip += backfillPageSize {
+		page, err := cl.GetOrderHistoryPage(ctx, &valr.GetOrderHistoryRequest{Skip: skip, Limit: backfillPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Items {
+			if o.OrderUpdatedAt.Before(since) {
+				return events, nil
+			}
+			events = append(events, AccountEvent{
+				Type:       EventOrderStatusUpdate,
+				Time:       o.OrderUpdatedAt.Time,
+				Backfilled: true,
+				OrderStatus: &valr.OrderStatus{
+					OrderID:           o.OrderID,
+					OrderStatusType:   o.OrderStatusType,
+					Pair:              o.Pair,
+					OriginalPrice:     o.OriginalPrice,
+					RemainingQuantity: o.RemainingQuantity,
+					OriginalQuantity:  o.OriginalQuantity,
+					OrderSide:         o.OrderSide,
+					OrderType:         o.OrderType,
+					FailedReason:      o.FailedReason,
+					OrderUpdatedAt:    o.OrderUpdatedAt,
+					OrderCreatedAt:    o.OrderCreatedAt,
+					CustomerOrderID:   o.CustomerOrderID,
+				},
+			})
+		}
+		if !page.HasMore {
+			return events, nil
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestBackfillOrders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/orders/history":
+			// Order history sends original and remaining quantities, but no
+			// quantity.
+			w.Write([]byte(`[{"orderId":"o1","orderStatusType":"Filled","currencyPair":"BTCZAR",
+				"originalQuantity":"1","remainingQuantity":"0","orderUpdatedAt":"2024-01-02T03:04:05Z"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetEnvironment(env)
+	cl.SetAuth("key", "secret")
+
+	events, err := streaming.BackfillAccount(context.Background(), cl, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(events) != 1 || events[0].OrderStatus == nil {
+		t.Fatalf("Expected one order event, got %+v", events)
+	}
+	os := events[0].OrderStatus
+	if !os.RemainingQuantity.Equal(decimal.Zero) || !os.OriginalQuantity.Equal(decimal.New(1, 0)) {
+		t.Errorf("Expected 0 of 1 remaining, got %s of %s", os.RemainingQuantity, os.OriginalQuantity)
+	}
+}
@@ -11,8 +11,14 @@ type backoffParams struct {
 	lastAttempt time.Time
 }
 
-func defaultBackoffHandler(attempts int) time.Duration {
-	jitter := time.Duration(rand.Intn(200)-100) * time.Millisecond                       // ±100ms
+// defaultBackoff is the backoff without a BackoffHandler, jittered by rnd,
+// or the global source if nil.
+func defaultBackoff(rnd *rand.Rand, attempts int) time.Duration {
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	jitter := time.Duration(intn(200)-100) * time.Millisecond                            // ±100ms
 	backoff := time.Duration(math.Min(math.Pow(2, float64(attempts)), 60)) * time.Second // Exponential backoff up to 60s
 	return backoff + jitter
 }
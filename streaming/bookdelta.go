@@ -0,0 +1,136 @@
+package streaming
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BookDelta is a consolidated change to an order book. Each level replaces
+// the level at the same price and a zero quantity removes the level, as in
+// a BookUpdate, so applying every delta in turn to an empty book rebuilds
+// it.
+type BookDelta struct {
+	Pair     string
+	Sequence int64
+	Bids     []Level
+	Asks     []Level
+	// Merged is the number of raw book updates consolidated into the delta.
+	Merged    int
+	UpdatedAt time.Time
+}
+
+// SubscribeDeltas returns a subscription that receives the changes to the
+// pair's book at most once per interval, each delta consolidating every
+// update since the one before, for sinks too slow to take every update.
+// The first delta holds the whole book. Unlike other subscriptions a delta
+// the reader hasn't received yet is never skipped: the next one is merged
+// into it instead. An interval of zero delivers a delta per update.
+func (k *BookKeeper) SubscribeDeltas(pair string, interval time.Duration) *Subscription[BookDelta] {
+	sub := newSubscription[BookDelta]()
+	book := k.Book(pair)
+
+	var (
+		mu     sync.Mutex
+		base   = BookSnapshot{Pair: pair}
+		merged int
+		timer  *time.Timer
+		closed bool
+	)
+	flush := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		timer = nil
+		if closed {
+			return
+		}
+		snap := book.Snapshot()
+		bids, asks := sortedLevels(diffLevels(base.Bids, snap.Bids), diffLevels(base.Asks, snap.Asks))
+		d := BookDelta{
+			Pair:      pair,
+			Sequence:  snap.Sequence,
+			Bids:      bids,
+			Asks:      asks,
+			Merged:    merged,
+			UpdatedAt: snap.UpdatedAt,
+		}
+		if len(d.Bids) == 0 && len(d.Asks) == 0 {
+			// The updates cancelled out; count them towards the next delta.
+			return
+		}
+		base = snap
+		merged = 0
+		select {
+		case prev := <-sub.ch:
+			d = prev.merge(d)
+		default:
+		}
+		sub.ch <- d
+	}
+	remove := book.addListener(func(*OrderBook) {
+		mu.Lock()
+		merged++
+		if interval > 0 {
+			if timer == nil {
+				timer = time.AfterFunc(interval, flush)
+			}
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		flush()
+	})
+	sub.remove = func() {
+		remove()
+		mu.Lock()
+		defer mu.Unlock()
+		closed = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	return sub
+}
+
+// merge returns the delta of d followed by next.
+func (d BookDelta) merge(next BookDelta) BookDelta {
+	bids := make(map[string]Level, len(d.Bids)+len(next.Bids))
+	asks := make(map[string]Level, len(d.Asks)+len(next.Asks))
+	for _, levels := range [][]Level{d.Bids, next.Bids} {
+		for _, l := range levels {
+			bids[l.Price.String()] = l
+		}
+	}
+	for _, levels := range [][]Level{d.Asks, next.Asks} {
+		for _, l := range levels {
+			asks[l.Price.String()] = l
+		}
+	}
+	next.Bids, next.Asks = sortedLevels(bids, asks)
+	next.Merged += d.Merged
+	return next
+}
+
+// diffLevels returns the levels of one side of a book that change from
+// from to to, by price, with zero quantities for removed levels.
+func diffLevels(from, to []Level) map[string]Level {
+	old := make(map[string]Level, len(from))
+	for _, l := range from {
+		old[l.Price.String()] = l
+	}
+	changes := make(map[string]Level)
+	for _, l := range to {
+		key := l.Price.String()
+		prev, ok := old[key]
+		delete(old, key)
+		if ok && prev.Quantity.Equal(l.Quantity) && prev.OrderCount == l.OrderCount {
+			continue
+		}
+		changes[key] = l
+	}
+	for key, l := range old {
+		changes[key] = Level{Price: l.Price, Quantity: decimal.Zero}
+	}
+	return changes
+}
@@ -0,0 +1,79 @@
+package streaming_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func deltaString(d streaming.BookDelta) string {
+	return bookString(streaming.BookSnapshot{Bids: d.Bids, Asks: d.Asks})
+}
+
+func TestSubscribeDeltasConflated(t *testing.T) {
+	k := streaming.NewBookKeeper()
+	sub := k.SubscribeDeltas("BTCZAR", 100*time.Millisecond)
+	defer sub.Close()
+
+	book := k.Book("BTCZAR")
+	book.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1,
+		Bids:     []streaming.Level{level("99", "1"), level("98", "1")},
+		Asks:     []streaming.Level{level("101", "1")},
+	})
+	for i, u := range []streaming.BookUpdate{
+		{Sequence: 2, Bids: []streaming.Level{level("98", "0")}},
+		{Sequence: 3, Asks: []streaming.Level{level("101", "3")}},
+	} {
+		if err := book.ApplyUpdate(context.Background(), u); err != nil {
+			t.Fatalf("Update %d: Expected success, got %v", i, err)
+		}
+	}
+
+	select {
+	case d := <-sub.C:
+		if got, want := deltaString(d), "b99x1 a101x3"; got != want || d.Merged != 3 || d.Sequence != 3 {
+			t.Errorf("Expected %q at 3 merging 3, got %q at %d merging %d", want, got, d.Sequence, d.Merged)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a delta")
+	}
+
+	if err := book.ApplyUpdate(context.Background(), streaming.BookUpdate{
+		Sequence: 4,
+		Bids:     []streaming.Level{level("99", "0"), level("100", "2")},
+	}); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	select {
+	case d := <-sub.C:
+		if got, want := deltaString(d), "b100x2 b99x0"; got != want || d.Merged != 1 {
+			t.Errorf("Expected %q merging 1, got %q merging %d", want, got, d.Merged)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a delta")
+	}
+}
+
+func TestSubscribeDeltasUnread(t *testing.T) {
+	k := streaming.NewBookKeeper()
+	sub := k.SubscribeDeltas("BTCZAR", 0)
+	defer sub.Close()
+
+	book := k.Book("BTCZAR")
+	book.ApplySnapshot(streaming.BookUpdate{Sequence: 1, Bids: []streaming.Level{level("99", "1")}})
+	book.ApplySnapshot(streaming.BookUpdate{Sequence: 2, Asks: []streaming.Level{level("101", "1")}})
+
+	// The second delta, removing the bid, is merged into the unread first.
+	d := <-sub.C
+	if got, want := deltaString(d), "b99x0 a101x1"; got != want || d.Merged != 2 {
+		t.Errorf("Expected %q merging 2, got %q merging %d", want, got, d.Merged)
+	}
+	select {
+	case d := <-sub.C:
+		t.Errorf("Expected a single delta, got %+v", d)
+	default:
+	}
+}
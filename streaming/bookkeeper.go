@@ -0,0 +1,139 @@
+package streaming
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TopN is a depth-limited view of an order book.
+type TopN struct {
+	Pair      string
+	Sequence  int64
+	Bids      []Level
+	Asks      []Level
+	UpdatedAt time.Time
+}
+
+// Subscription delivers values derived from an order book as it changes.
+// Only the latest value is buffered: a slow reader skips intermediate values
+// rather than blocking the book.
+type Subscription[T any] struct {
+	C <-chan T
+
+	ch     chan T
+	remove func()
+	once   sync.Once
+}
+
+func newSubscription[T any]() *Subscription[T] {
+	ch := make(chan T, 1)
+	return &Subscription[T]{C: ch, ch: ch}
+}
+
+// Close stops delivery to the subscription.
+func (s *Subscription[T]) Close() {
+	s.once.Do(s.remove)
+}
+
+func (s *Subscription[T]) deliver(v T) {
+	select {
+	case s.ch <- v:
+		return
+	default:
+	}
+	// Replace the undelivered value with the latest one.
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- v:
+	default:
+	}
+}
+
+// BookKeeper maintains local order books for a set of pairs.
+type BookKeeper struct {
+	opts []BookOption
+
+	mu    sync.Mutex
+	books map[string]*OrderBook
+}
+
+// NewBookKeeper creates a keeper whose books are created with opts.
+func NewBookKeeper(opts ...BookOption) *BookKeeper {
+	return &BookKeeper{
+		opts:  opts,
+		books: make(map[string]*OrderBook),
+	}
+}
+
+// Book returns the book for pair, creating it if necessary.
+func (k *BookKeeper) Book(pair string) *OrderBook {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	b, ok := k.books[pair]
+	if !ok {
+		b = NewOrderBook(pair, k.opts...)
+		k.books[pair] = b
+	}
+	return b
+}
+
+// Pairs returns the pairs with books, sorted.
+func (k *BookKeeper) Pairs() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	pairs := make([]string, 0, len(k.books))
+	for pair := range k.books {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// SubscribeTopN returns a subscription that receives the best n levels of
+// each side of the pair's book whenever any of those levels change. Changes
+// deeper in the book are not delivered.
+func (k *BookKeeper) SubscribeTopN(pair string, n int) *Subscription[TopN] {
+	sub := newSubscription[TopN]()
+
+	var (
+		mu   sync.Mutex
+		last *TopN
+	)
+	sub.remove = k.Book(pair).addListener(func(b *OrderBook) {
+		snap := b.Snapshot()
+		view := TopN{
+			Pair:      pair,
+			Sequence:  snap.Sequence,
+			Bids:      snap.Bids[:min(n, len(snap.Bids))],
+			Asks:      snap.Asks[:min(n, len(snap.Asks))],
+			UpdatedAt: snap.UpdatedAt,
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if last != nil && levelsEqual(last.Bids, view.Bids) && levelsEqual(last.Asks, view.Asks) {
+			return
+		}
+		last = &view
+		sub.deliver(view)
+	})
+	return sub
+}
+
+func levelsEqual(a, b []Level) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Price.Equal(b[i].Price) || !a[i].Quantity.Equal(b[i].Quantity) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package streaming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoBookRecord is returned by BookPlayer when no snapshot of a pair was
+// recorded at or before the requested time.
+var ErrNoBookRecord = errors.New("streaming: no recorded book at time")
+
+// BookRecord is a recorded full snapshot of, or delta to, an order book.
+type BookRecord struct {
+	Time     time.Time  `json:"time"`
+	Pair     string     `json:"pair"`
+	Snapshot bool       `json:"snapshot"`
+	Update   BookUpdate `json:"update"`
+}
+
+// BookRecorder writes the changes to order books as one JSON encoded
+// BookRecord per line. Deltas are recorded as they are applied, and a full
+// snapshot of each book is recorded at least once per interval so that a
+// BookPlayer can reconstruct the book at any time without replaying from the
+// start.
+type BookRecorder struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	w        io.Writer
+	enc      *json.Encoder
+	lastSnap map[string]time.Time
+	err      error
+}
+
+// NewBookRecorder returns a recorder writing to w that records full
+// snapshots every interval.
+func NewBookRecorder(w io.Writer, interval time.Duration) *BookRecorder {
+	return &BookRecorder{
+		interval: interval,
+		w:        w,
+		enc:      json.NewEncoder(w),
+		lastSnap: make(map[string]time.Time),
+	}
+}
+
+// WithBookRecorder records every snapshot and delta applied to the book.
+func WithBookRecorder(r *BookRecorder) BookOption {
+	return func(b *OrderBook) {
+		b.recorder = r
+	}
+}
+
+// Err returns the first error encountered writing records. Recording stops
+// after an error.
+func (r *BookRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (r *BookRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// recordSnapshot records a snapshot applied to b.
+func (r *BookRecorder) recordSnapshot(b *OrderBook, u BookUpdate) {
+	r.write(BookRecord{Time: updateTime(u), Pair: b.pair, Snapshot: true, Update: u})
+}
+
+// recordUpdate records a delta applied to b, or b's full state if a snapshot
+// is due.
+func (r *BookRecorder) recordUpdate(b *OrderBook, u BookUpdate) {
+	t := updateTime(u)
+	r.mu.Lock()
+	due := t.Sub(r.lastSnap[b.pair]) >= r.interval
+	r.mu.Unlock()
+	if !due {
+		r.write(BookRecord{Time: t, Pair: b.pair, Update: u})
+		return
+	}
+	snap := b.Snapshot()
+	r.write(BookRecord{Time: t, Pair: b.pair, Snapshot: true, Update: BookUpdate{
+		Sequence: snap.Sequence,
+		Bids:     snap.Bids,
+		Asks:     snap.Asks,
+		Time:     t,
+	}})
+}
+
+func (r *BookRecorder) write(rec BookRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if rec.Snapshot {
+		r.lastSnap[rec.Pair] = rec.Time
+	}
+	r.err = r.enc.Encode(rec)
+}
+
+// BookPlayer reconstructs recorded order books at any point in time.
+type BookPlayer struct {
+	// records holds each pair's records, oldest first.
+	records map[string][]BookRecord
+}
+
+// LoadBookPlayer reads all the records written by a BookRecorder from r.
+func LoadBookPlayer(r io.Reader) (*BookPlayer, error) {
+	p := &BookPlayer{records: make(map[string][]BookRecord)}
+	dec := json.NewDecoder(r)
+	for {
+		var rec BookRecord
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("streaming: failed to read book record: %w", err)
+		}
+		p.records[rec.Pair] = append(p.records[rec.Pair], rec)
+	}
+	for _, recs := range p.records {
+		sort.SliceStable(recs, func(i, j int) bool {
+			return recs[i].Time.Before(recs[j].Time)
+		})
+	}
+	return p, nil
+}
+
+// Pairs returns the recorded pairs, sorted.
+func (p *BookPlayer) Pairs() []string {
+	pairs := make([]string, 0, len(p.records))
+	for pair := range p.records {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// At returns the state of pair's book as of t, built from the latest
+// snapshot recorded at or before t and the deltas that followed it.
+func (p *BookPlayer) At(pair string, t time.Time) (BookSnapshot, error) {
+	recs := p.records[pair]
+	// end is the number of records at or before t.
+	end := sort.Search(len(recs), func(i int) bool { return recs[i].Time.After(t) })
+	start := -1
+	for i := end - 1; i >= 0; i-- {
+		if recs[i].Snapshot {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return BookSnapshot{}, fmt.Errorf("%w: %s at %s", ErrNoBookRecord, pair, t)
+	}
+
+	b := NewOrderBook(pair)
+	b.ApplySnapshot(recs[start].Update)
+	for _, rec := range recs[start+1 : end] {
+		if rec.Snapshot {
+			b.ApplySnapshot(rec.Update)
+			continue
+		}
+		if err := b.applyUpdate(rec.Update); err != nil {
+			return BookSnapshot{}, err
+		}
+	}
+	return b.Snapshot(), nil
+}
@@ -0,0 +1,64 @@
+package streaming_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func TestBookRecorderPlayback(t *testing.T) {
+	var buf bytes.Buffer
+	rec := streaming.NewBookRecorder(&buf, time.Minute)
+	b := streaming.NewOrderBook("BTCZAR", streaming.WithBookRecorder(rec))
+
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	b.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1, Time: at,
+		Bids: []streaming.Level{level("99", "1")},
+		Asks: []streaming.Level{level("101", "1")},
+	})
+	updates := []streaming.BookUpdate{
+		{Sequence: 2, Time: at.Add(10 * time.Second), Bids: []streaming.Level{level("100", "2")}},
+		{Sequence: 3, Time: at.Add(20 * time.Second), Asks: []streaming.Level{level("101", "0"), level("102", "4")}},
+		// Recorded as a full snapshot, a minute after the first.
+		{Sequence: 4, Time: at.Add(70 * time.Second), Bids: []streaming.Level{level("99", "0")}},
+		{Sequence: 5, Time: at.Add(80 * time.Second), Bids: []streaming.Level{level("100", "0")}},
+	}
+	for _, u := range updates {
+		if err := b.ApplyUpdate(ctx, u); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	p, err := streaming.LoadBookPlayer(&buf)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := p.At("BTCZAR", at.Add(-time.Second)); !errors.Is(err, streaming.ErrNoBookRecord) {
+		t.Errorf("Expected %v, got %v", streaming.ErrNoBookRecord, err)
+	}
+
+	snap, err := p.At("BTCZAR", at.Add(15*time.Second))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if snap.Sequence != 2 || len(snap.Bids) != 2 || snap.Bids[0].Price.String() != "100" || snap.Asks[0].Price.String() != "101" {
+		t.Errorf("Unexpected book %+v", snap)
+	}
+
+	snap, err = p.At("BTCZAR", at.Add(90*time.Second))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if snap.Sequence != 5 || len(snap.Bids) != 0 || len(snap.Asks) != 1 || snap.Asks[0].Price.String() != "102" {
+		t.Errorf("Unexpected book %+v", snap)
+	}
+}
@@ -0,0 +1,208 @@
+package streaming
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order book event types of the trade stream.
+const (
+	EventAggregatedOrderBookUpdate = "AGGREGATED_ORDERBOOK_UPDATE"
+	EventFullOrderBookSnapshot     = "FULL_ORDERBOOK_SNAPSHOT"
+	EventFullOrderBookUpdate       = "FULL_ORDERBOOK_UPDATE"
+)
+
+// bookSubscriptions tracks the order book subscriptions of a Conn so they
+// can be renewed when a book must be resynchronised.
+type bookSubscriptions struct {
+	mu    sync.Mutex
+	pairs map[string][]string // by event
+}
+
+func (s *bookSubscriptions) set(event string, pairs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pairs == nil {
+		s.pairs = make(map[string][]string)
+	}
+	s.pairs[event] = append([]string(nil), pairs...)
+}
+
+func (s *bookSubscriptions) events() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string][]string, len(s.pairs))
+	for event, pairs := range s.pairs {
+		out[event] = pairs
+	}
+	return out
+}
+
+// WithBookKeeper maintains the order books of subscribed pairs in k rather
+// than a keeper created by Dial. Options of the books, such as
+// WithChecksumValidation, are set on the keeper.
+func WithBookKeeper(k *BookKeeper) DialOption {
+	return func(c *Conn) {
+		c.books = k
+	}
+}
+
+// Books returns the keeper holding the order books maintained by the
+// connection.
+func (c *Conn) Books() *BookKeeper {
+	return c.books
+}
+
+// OrderBook returns the locally maintained order book of pair. The book is
+// only populated once the pair has been subscribed with SubscribeToOrderBooks
+// or SubscribeToAggregatedOrderBooks.
+func (c *Conn) OrderBook(pair string) *OrderBook {
+	return c.books.Book(pair)
+}
+
+// SubscribeToOrderBooks subscribes to the full order books of the given
+// pairs. Each book is built from a FULL_ORDERBOOK_SNAPSHOT and kept up to
+// date with FULL_ORDERBOOK_UPDATE deltas. When a sequence gap or other
+// integrity violation is detected, and after every reconnect, the
+// subscription is renewed so that the server sends a fresh snapshot.
+// It replaces any previous full order book subscription.
+func (c *Conn) SubscribeToOrderBooks(pairs []string) *SubscriptionAck {
+	return c.subscribeBooks(EventFullOrderBookUpdate, pairs)
+}
+
+// SubscribeToAggregatedOrderBooks subscribes to the aggregated top levels of
+// the order books of the given pairs. Every AGGREGATED_ORDERBOOK_UPDATE
+// replaces the contents of the pair's book.
+func (c *Conn) SubscribeToAggregatedOrderBooks(pairs []string) *SubscriptionAck {
+	return c.subscribeBooks(EventAggregatedOrderBookUpdate, pairs)
+}
+
+func (c *Conn) subscribeBooks(event string, pairs []string) *SubscriptionAck {
+	for _, pair := range pairs {
+		c.books.Book(pair)
+	}
+	ack := newSubscriptionAck(1, c.subscribeTimeout)
+	c.subscribeReqs <- subscribeRequest{event: event, pairs: pairs, ack: ack}
+	return ack
+}
+
+// resubscribeBooks renews the order book subscriptions, causing the server
+// to send fresh snapshots. It must be called from the goroutine writing to
+// the websocket.
+func (c *Conn) resubscribeBooks(ctx context.Context) {
+	events := c.bookSubs.events()
+	names := make([]string, 0, len(events))
+	for event := range events {
+		names = append(names, event)
+	}
+	sort.Strings(names)
+	for _, event := range names {
+		c.subscribe(ctx, event, events[event], nil)
+	}
+}
+
+// isBookEvent returns true for the subscription events of order books.
+func isBookEvent(event string) bool {
+	return event == EventFullOrderBookUpdate || event == EventAggregatedOrderBookUpdate
+}
+
+// invalidateBooks marks every book unsynced, e.g. after the connection
+// dropped and updates may have been missed. Deltas are ignored until the
+// next snapshot arrives.
+func (c *Conn) invalidateBooks() {
+	if c.books == nil {
+		return
+	}
+	for _, pair := range c.books.Pairs() {
+		c.books.Book(pair).invalidate()
+	}
+}
+
+// requestBookResync asks the writing goroutine to renew the order book
+// subscriptions. Requests made while one is pending are merged.
+func (c *Conn) requestBookResync() {
+	select {
+	case c.bookResync <- struct{}{}:
+	default:
+	}
+}
+
+// receivedBookUpdate applies an order book message to the pair's book,
+// returning false for message types that are not order book updates.
+func (c *Conn) receivedBookUpdate(msgType string, data []byte) (bool, error) {
+	switch msgType {
+	case EventAggregatedOrderBookUpdate:
+		msg := new(MessageAggregatedOrderBook)
+		if err := c.decode(data, msg); err != nil {
+			return true, err
+		}
+		if c.books == nil {
+			return true, nil
+		}
+		c.books.Book(msg.CurrencyPairSymbol).ApplySnapshot(BookUpdate{
+			Sequence: msg.Data.SequenceNumber,
+			Bids:     aggregatedLevels(msg.Data.Bids),
+			Asks:     aggregatedLevels(msg.Data.Asks),
+			Checksum: uint32(msg.Data.Checksum),
+			Time:     msg.Data.LastChange.Time,
+		})
+	case EventFullOrderBookSnapshot, EventFullOrderBookUpdate:
+		msg := new(MessageFullOrderBook)
+		if err := c.decode(data, msg); err != nil {
+			return true, err
+		}
+		if c.books == nil {
+			return true, nil
+		}
+		u := BookUpdate{
+			Sequence: msg.Data.SequenceNumber,
+			Bids:     fullLevels(msg.Data.Bids),
+			Asks:     fullLevels(msg.Data.Asks),
+			Checksum: uint32(msg.Data.Checksum),
+			Time:     msg.Data.LastChange.Time,
+		}
+		b := c.books.Book(msg.CurrencyPairSymbol)
+		if msgType == EventFullOrderBookSnapshot {
+			b.ApplySnapshot(u)
+			return true, nil
+		}
+		if !b.Synced() {
+			// Awaiting the snapshot of a renewed subscription.
+			return true, nil
+		}
+		if ierr := b.applyDelta(u); ierr != nil {
+			b.reportIntegrity(ierr)
+			log.Printf("valr/streaming: Resubscribing to order books pair=%s", b.pair)
+			c.requestBookResync()
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func aggregatedLevels(in []AggregatedLevel) []Level {
+	levels := make([]Level, 0, len(in))
+	for _, l := range in {
+		levels = append(levels, Level{Price: l.Price, Quantity: l.Quantity, OrderCount: l.OrderCount})
+	}
+	return levels
+}
+
+// fullLevels aggregates the orders of each level. A level without orders
+// has a zero quantity, which removes it from the book.
+func fullLevels(in []FullOrderBookLevel) []Level {
+	levels := make([]Level, 0, len(in))
+	for _, l := range in {
+		qty := decimal.Zero
+		for _, o := range l.Orders {
+			qty = qty.Add(o.Quantity)
+		}
+		levels = append(levels, Level{Price: l.Price, Quantity: qty, OrderCount: len(l.Orders)})
+	}
+	return levels
+}
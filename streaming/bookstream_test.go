@@ -0,0 +1,125 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+func bookString(snap streaming.BookSnapshot) string {
+	var parts []string
+	for _, l := range snap.Bids {
+		parts = append(parts, "b"+l.Price.String()+"x"+l.Quantity.String())
+	}
+	for _, l := range snap.Asks {
+		parts = append(parts, "a"+l.Price.String()+"x"+l.Quantity.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestOrderBookReplay(t *testing.T) {
+	frame := func(s string) string {
+		return `{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":` + s + `}`
+	}
+	journal := strings.Join([]string{
+		frame(`{"type":"FULL_ORDERBOOK_SNAPSHOT","currencyPairSymbol":"BTCZAR","data":{"SequenceNumber":1,` +
+			`"Bids":[{"Price":"99","Orders":[{"orderId":"b1","quantity":"1"},{"orderId":"b2","quantity":"0.5"}]}],` +
+			`"Asks":[{"Price":"101","Orders":[{"orderId":"a1","quantity":"2"}]}]}}`),
+		frame(`{"type":"FULL_ORDERBOOK_UPDATE","currencyPairSymbol":"BTCZAR","data":{"SequenceNumber":2,` +
+			`"Bids":[{"Price":"99","Orders":[]},{"Price":"100","Orders":[{"orderId":"b3","quantity":"3"}]}],"Asks":[]}}`),
+		frame(`{"type":"AGGREGATED_ORDERBOOK_UPDATE","currencyPairSymbol":"ETHZAR","data":{"SequenceNumber":7,` +
+			`"Bids":[{"side":"buy","price":"10","quantity":"4","orderCount":2}],"Asks":[{"side":"sell","price":"11","quantity":"5","orderCount":1}]}}`),
+	}, "\n")
+
+	books := streaming.NewBookKeeper()
+	err := streaming.Replay(context.Background(), strings.NewReader(journal), streaming.WithBookKeeper(books))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	snap := books.Book("BTCZAR").Snapshot()
+	if got, want := bookString(snap), "b100x3 a101x2"; got != want || snap.Sequence != 2 {
+		t.Errorf("Expected %q at 2, got %q at %d", want, got, snap.Sequence)
+	}
+	snap = books.Book("ETHZAR").Snapshot()
+	if got, want := bookString(snap), "b10x4 a11x5"; got != want || snap.Bids[0].OrderCount != 2 {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestOrderBookResubscribeOnGap(t *testing.T) {
+	subscribes := make(chan streaming.SubscribeToMarketsRequest, 4)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		snapshot := func(seq int, bid string) {
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"FULL_ORDERBOOK_SNAPSHOT","currencyPairSymbol":"BTCZAR",`+
+				`"data":{"SequenceNumber":`+strconv.Itoa(seq)+`,"Bids":[{"Price":"`+bid+`","Orders":[{"orderId":"b","quantity":"1"}]}],"Asks":[]}}`))
+		}
+		for seq := 1; ; seq += 5 {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			subscribes <- req
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+			if seq == 1 {
+				snapshot(seq, "99")
+				// Sequence 2 is missed.
+				ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"FULL_ORDERBOOK_UPDATE","currencyPairSymbol":"BTCZAR",`+
+					`"data":{"SequenceNumber":3,"Bids":[{"Price":"98","Orders":[{"orderId":"c","quantity":"1"}]}],"Asks":[]}}`))
+				continue
+			}
+			snapshot(seq, "97")
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SubscribeToOrderBooks([]string{"BTCZAR"}).Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-subscribes:
+			if s := req.Subscriptions; len(s) != 1 || s[0].Event != "FULL_ORDERBOOK_UPDATE" || s[0].Pairs[0] != "BTCZAR" {
+				t.Errorf("Unexpected subscription %+v", req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected subscription %d", i+1)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snap := c.OrderBook("BTCZAR").Snapshot()
+		if bookString(snap) == "b97x1" && snap.Sequence == 6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected resynced book, got %q at %d", bookString(snap), snap.Sequence)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,157 @@
+package streaming
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SubscriptionBuilder builds a request subscribing to several trade stream
+// events at once, e.g.
+//
+//	sub := streaming.NewSubscription().
+//		Trades("BTCZAR").
+//		AggregatedBook("ETHZAR").
+//		MarketSummary("BTCZAR", "ETHZAR")
+//	err := conn.Subscribe(sub).Wait(ctx)
+//
+// Pairs are upper-cased. Mistakes, such as a pair subscribed to both kinds
+// of order book, are reported by Validate rather than by the server.
+type SubscriptionBuilder struct {
+	events []string
+	pairs  map[string][]string
+	errs   []error
+}
+
+// NewSubscription returns an empty subscription builder.
+func NewSubscription() *SubscriptionBuilder {
+	return &SubscriptionBuilder{pairs: make(map[string][]string)}
+}
+
+// Trades subscribes to the public trades of pairs, NEW_TRADE.
+func (s *SubscriptionBuilder) Trades(pairs ...string) *SubscriptionBuilder {
+	return s.add(EventNewTrade, pairs)
+}
+
+// AggregatedBook subscribes to the aggregated top levels of the order books
+// of pairs, AGGREGATED_ORDERBOOK_UPDATE.
+func (s *SubscriptionBuilder) AggregatedBook(pairs ...string) *SubscriptionBuilder {
+	return s.add(EventAggregatedOrderBookUpdate, pairs)
+}
+
+// FullBook subscribes to the full order books of pairs,
+// FULL_ORDERBOOK_UPDATE, which are kept in sync as described by
+// Conn.SubscribeToOrderBooks.
+func (s *SubscriptionBuilder) FullBook(pairs ...string) *SubscriptionBuilder {
+	return s.add(EventFullOrderBookUpdate, pairs)
+}
+
+// MarketSummary subscribes to the market summaries of pairs,
+// MARKET_SUMMARY_UPDATE, delivered to WithMarketSummaryCallback.
+func (s *SubscriptionBuilder) MarketSummary(pairs ...string) *SubscriptionBuilder {
+	return s.add(EventMarketSummaryUpdate, pairs)
+}
+
+func (s *SubscriptionBuilder) add(event string, pairs []string) *SubscriptionBuilder {
+	if len(pairs) == 0 {
+		s.errs = append(s.errs, fmt.Errorf("streaming: %s subscription has no pairs", event))
+		return s
+	}
+	if _, ok := s.pairs[event]; !ok {
+		s.events = append(s.events, event)
+	}
+	for _, pair := range pairs {
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		if !validPair(pair) {
+			s.errs = append(s.errs, fmt.Errorf("streaming: invalid pair %q for %s", pair, event))
+			continue
+		}
+		if slices.Contains(s.pairs[event], pair) {
+			continue
+		}
+		s.pairs[event] = append(s.pairs[event], pair)
+	}
+	return s
+}
+
+// validPair returns true for pair symbols such as "BTCZAR".
+func validPair(pair string) bool {
+	if len(pair) < 6 {
+		return false
+	}
+	for _, r := range pair {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate reports the mistakes made building the subscription: events
+// without pairs, invalid pair symbols and pairs subscribed to both the full
+// and the aggregated order book, which would overwrite each other in the
+// connection's books.
+func (s *SubscriptionBuilder) Validate() error {
+	errs := append([]error(nil), s.errs...)
+	if len(s.events) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("streaming: empty subscription"))
+	}
+	for _, pair := range s.pairs[EventFullOrderBookUpdate] {
+		if slices.Contains(s.pairs[EventAggregatedOrderBookUpdate], pair) {
+			errs = append(errs, fmt.Errorf("streaming: %s subscribed to both full and aggregated order books", pair))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscriptions returns the events and pairs subscribed, in the order the
+// events were first added.
+func (s *SubscriptionBuilder) Subscriptions() []Subscriptions {
+	out := make([]Subscriptions, 0, len(s.events))
+	for _, event := range s.events {
+		out = append(out, Subscriptions{Event: event, Pairs: append([]string(nil), s.pairs[event]...)})
+	}
+	return out
+}
+
+// Payload returns the subscription as a single SUBSCRIBE message, after
+// validating it, e.g. to send over another websocket client. Subscribe
+// sends each event separately instead, so acknowledgements can be matched.
+func (s *SubscriptionBuilder) Payload() (SubscribeToMarketsRequest, error) {
+	if err := s.Validate(); err != nil {
+		return SubscribeToMarketsRequest{}, err
+	}
+	return SubscribeToMarketsRequest{Type: "SUBSCRIBE", Subscriptions: s.Subscriptions()}, nil
+}
+
+// Subscribe validates and sends sub, returning an acknowledgement that
+// resolves once the server has confirmed every event, or at once with the
+// validation error. Each event replaces any previous subscription to it,
+// and order book subscriptions are renewed as needed, as with
+// SubscribeToOrderBooks.
+func (c *Conn) Subscribe(sub *SubscriptionBuilder) *SubscriptionAck {
+	err := sub.Validate()
+	if err == nil && c.stream == streamAccount {
+		err = errors.New("streaming: market subscriptions need a trade stream connection")
+	}
+	if err != nil {
+		ack := newSubscriptionAck(0, 0)
+		ack.resolve(err)
+		return ack
+	}
+
+	subs := sub.Subscriptions()
+	for _, s := range subs {
+		if isBookEvent(s.Event) {
+			for _, pair := range s.Pairs {
+				c.books.Book(pair)
+			}
+		}
+	}
+	ack := newSubscriptionAck(len(subs), c.subscribeTimeout)
+	for _, s := range subs {
+		c.subscribeReqs <- subscribeRequest{event: s.Event, pairs: s.Pairs, ack: ack}
+	}
+	return ack
+}
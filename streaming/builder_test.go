@@ -0,0 +1,50 @@
+package streaming_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func TestSubscriptionBuilderPayload(t *testing.T) {
+	req, err := streaming.NewSubscription().
+		Trades("btczar", "BTCZAR").
+		AggregatedBook("ETHZAR").
+		MarketSummary("BTCZAR", "ETHZAR").
+		Payload()
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := `{"type":"SUBSCRIBE","subscriptions":[` +
+		`{"event":"NEW_TRADE","pairs":["BTCZAR"]},` +
+		`{"event":"AGGREGATED_ORDERBOOK_UPDATE","pairs":["ETHZAR"]},` +
+		`{"event":"MARKET_SUMMARY_UPDATE","pairs":["BTCZAR","ETHZAR"]}]}`
+	if string(b) != want {
+		t.Errorf("Expected %s, got %s", want, b)
+	}
+}
+
+func TestSubscriptionBuilderValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  *streaming.SubscriptionBuilder
+		want string
+	}{
+		{"empty", streaming.NewSubscription(), "empty subscription"},
+		{"no pairs", streaming.NewSubscription().Trades(), "has no pairs"},
+		{"invalid pair", streaming.NewSubscription().Trades("BTC-ZAR"), `invalid pair "BTC-ZAR"`},
+		{"both books", streaming.NewSubscription().FullBook("BTCZAR").AggregatedBook("btczar"), "both full and aggregated"},
+	}
+	for _, test := range tests {
+		err := test.sub.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: Expected %q, got %v", test.name, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package streaming
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Chaos injects faults into a connection so applications can verify their
+// reconnect and resync behaviour. It is intended for tests only.
+type Chaos struct {
+	mu        sync.Mutex
+	ws        *websocket.Conn
+	delay     time.Duration
+	corrupt   int
+	frameHook func([]byte) []byte
+}
+
+// NewChaos returns a Chaos that initially injects no faults. Attach it to a
+// connection with WithChaos.
+func NewChaos() *Chaos {
+	return new(Chaos)
+}
+
+// WithChaos attaches ch to the connection.
+func WithChaos(ch *Chaos) DialOption {
+	return func(c *Conn) {
+		c.chaos = ch
+	}
+}
+
+// Disconnect forcibly closes the current websocket, causing the connection
+// to reconnect as it would after a network failure.
+func (ch *Chaos) Disconnect() {
+	ch.mu.Lock()
+	ws := ch.ws
+	ch.mu.Unlock()
+	if ws != nil {
+		_ = ws.Close()
+	}
+}
+
+// SetDelay delays the processing of every received frame by d.
+func (ch *Chaos) SetDelay(d time.Duration) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.delay = d
+}
+
+// CorruptSequence skips the sequence number of the next n frames that carry
+// one, introducing gaps.
+func (ch *Chaos) CorruptSequence(n int) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.corrupt += n
+}
+
+// SetFrameHook registers fn to rewrite every received frame before it is
+// processed. A nil result drops the frame.
+func (ch *Chaos) SetFrameHook(fn func([]byte) []byte) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.frameHook = fn
+}
+
+func (ch *Chaos) attach(ws *websocket.Conn) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.ws = ws
+}
+
+// apply returns the frame to process, or nil to drop it.
+func (ch *Chaos) apply(frame []byte) []byte {
+	ch.mu.Lock()
+	delay, hook := ch.delay, ch.frameHook
+	ch.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if hook != nil {
+		if frame = hook(frame); frame == nil {
+			return nil
+		}
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.corrupt > 0 {
+		if corrupted, ok := bumpSequence(frame); ok {
+			ch.corrupt--
+			frame = corrupted
+		}
+	}
+	return frame
+}
+
+// bumpSequence increments the sequence number in a frame's data, if present.
+func bumpSequence(frame []byte) ([]byte, bool) {
+	// Decode numbers as json.Number so that other values in the frame are
+	// re-encoded without loss of precision.
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	dec.UseNumber()
+	var msg map[string]any
+	if err := dec.Decode(&msg); err != nil {
+		return nil, false
+	}
+	data, ok := msg["data"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	for _, key := range []string{"SequenceNumber", "sequenceNumber"} {
+		num, ok := data[key].(json.Number)
+		if !ok {
+			continue
+		}
+		seq, err := num.Int64()
+		if err != nil {
+			return nil, false
+		}
+		data[key] = seq + 1
+		b, err := json.Marshal(msg)
+		return b, err == nil
+	}
+	return nil, false
+}
@@ -0,0 +1,207 @@
+package streaming_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+// chaosServer writes the frames sent on the returned channel to the current
+// connection, and counts the open connections.
+func chaosServer(t *testing.T) (valr.Environment, chan<- string, *atomic.Int32) {
+	t.Helper()
+	frames := make(chan string)
+	var conns atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Counted before the upgrade completes, so the client never sees
+		// a connection the server has not counted.
+		conns.Add(1)
+		defer conns.Add(-1)
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		for {
+			select {
+			case f := <-frames:
+				ws.WriteMessage(websocket.TextMessage, []byte(f))
+			case <-closed:
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return env, frames, &conns
+}
+
+func tradeFrame(price string) string {
+	return `{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","data":{"price":"` + price + `","quantity":"1"}}`
+}
+
+func bookFrame(seq string) string {
+	return `{"type":"AGGREGATED_ORDERBOOK_UPDATE","currencyPairSymbol":"BTCZAR","data":{"SequenceNumber":` + seq +
+		`,"Bids":[{"side":"buy","price":"10","quantity":"4","orderCount":2}],"Asks":[]}}`
+}
+
+func TestChaosDelay(t *testing.T) {
+	env, frames, _ := chaosServer(t)
+	ch := streaming.NewChaos()
+	trades := make(chan time.Time, 1)
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env), streaming.WithChaos(ch),
+		streaming.WithUpdateCallback(func(streaming.MessageTradeUpdate) { trades <- time.Now() }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	for _, delay := range []time.Duration{100 * time.Millisecond, 0} {
+		ch.SetDelay(delay)
+		sent := time.Now()
+		frames <- tradeFrame("100")
+		select {
+		case received := <-trades:
+			if d := received.Sub(sent); d < delay || (delay == 0 && d > 50*time.Millisecond) {
+				t.Errorf("Expected the frame delayed by %s, took %s", delay, d)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the trade")
+		}
+	}
+}
+
+func TestChaosCorruptSequence(t *testing.T) {
+	env, frames, _ := chaosServer(t)
+	ch := streaming.NewChaos()
+	books := streaming.NewBookKeeper()
+	trades := make(chan string, 1)
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env), streaming.WithChaos(ch),
+		streaming.WithBookKeeper(books),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { trades <- m.Data.Price.String() }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	awaitSequence := func(want int64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for books.Book("BTCZAR").Snapshot().Sequence != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected sequence %d, got %d", want, books.Book("BTCZAR").Snapshot().Sequence)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ch.CorruptSequence(2)
+	// Frames without a sequence number pass through untouched.
+	frames <- tradeFrame("100")
+	if p := <-trades; p != "100" {
+		t.Errorf("Expected the trade at 100, got %s", p)
+	}
+	frames <- bookFrame("7")
+	awaitSequence(8)
+	frames <- bookFrame("8")
+	awaitSequence(9)
+	// Only the requested number of frames is corrupted.
+	frames <- bookFrame("12")
+	awaitSequence(12)
+}
+
+func TestChaosFrameHook(t *testing.T) {
+	env, frames, _ := chaosServer(t)
+	ch := streaming.NewChaos()
+	trades := make(chan string, 2)
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env), streaming.WithChaos(ch),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { trades <- m.Data.Price.String() }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	ch.SetFrameHook(func(f []byte) []byte {
+		if bytes.Contains(f, []byte(`"100"`)) {
+			return nil
+		}
+		return []byte(strings.Replace(string(f), `"101"`, `"102"`, 1))
+	})
+	frames <- tradeFrame("100")
+	frames <- tradeFrame("101")
+	select {
+	case p := <-trades:
+		if p != "102" {
+			t.Errorf("Expected the first frame dropped and the second rewritten, got %s", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the trade")
+	}
+}
+
+func TestChaosDisconnect(t *testing.T) {
+	env, frames, conns := chaosServer(t)
+	ch := streaming.NewChaos()
+	connected := make(chan struct{}, 2)
+	trades := make(chan string, 1)
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env), streaming.WithChaos(ch),
+		streaming.WithBackoffHandler(func(int) time.Duration { return time.Millisecond }, time.Minute),
+		streaming.WithConnectCallback(func(*streaming.Conn) { connected <- struct{}{} }),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { trades <- m.Data.Price.String() }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	// Disconnecting before a connection is made is harmless.
+	streaming.NewChaos().Disconnect()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-connected:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected connection %d", i+1)
+		}
+		if i == 0 {
+			ch.Disconnect()
+		}
+	}
+	// The server sees the first connection closed.
+	for deadline := time.Now().Add(5 * time.Second); conns.Load() != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 open connection, got %d", conns.Load())
+		}
+	}
+	// The new connection receives frames.
+	frames <- tradeFrame("100")
+	select {
+	case p := <-trades:
+		if p != "100" {
+			t.Errorf("Expected the trade at 100, got %s", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the trade on the new connection")
+	}
+}
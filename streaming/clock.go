@@ -0,0 +1,16 @@
+package streaming
+
+import "time"
+
+// EventTimeCallback is passed the time VALR stamped a streamed trade,
+// balance, order or fill event with and when its frame was received, e.g.
+// to monitor clock skew.
+type EventTimeCallback func(stamped, received time.Time)
+
+// WithEventTimeCallback sets a callback passed the timestamps of streamed
+// events as they are received.
+func WithEventTimeCallback(fn EventTimeCallback) DialOption {
+	return func(c *Conn) {
+		c.eventTimeCallback = fn
+	}
+}
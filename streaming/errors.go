@@ -0,0 +1,115 @@
+package streaming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnauthorized is matched by errors reporting that the server rejected
+	// the API key, e.g. because it expired or lacks the required permission.
+	ErrUnauthorized = errors.New("streaming: unauthorized")
+	// ErrSubscriptionRejected is matched by errors reporting that the server
+	// refused a subscription.
+	ErrSubscriptionRejected = errors.New("streaming: subscription rejected")
+)
+
+// Server message types that report errors.
+const (
+	messageUnauthorized       = "UNAUTHORIZED"
+	messageSubscriptionFailed = "SUBSCRIPTION_FAILED"
+	messageError              = "ERROR"
+)
+
+// ErrorCallback is notified of errors on the connection, including error
+// frames sent by the server and failed connection attempts.
+type ErrorCallback func(error)
+
+// WithErrorCallback sets a callback notified of connection errors.
+func WithErrorCallback(fn ErrorCallback) DialOption {
+	return func(c *Conn) {
+		c.errorCallback = fn
+	}
+}
+
+// ServerError is an error reported by the streaming server, either as an
+// error frame or by refusing the websocket handshake.
+type ServerError struct {
+	// Type is the message type of the error frame, empty for handshake
+	// failures.
+	Type string
+	// StatusCode is the HTTP status of a refused handshake, zero for error
+	// frames.
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	kind := e.Type
+	if kind == "" {
+		kind = fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	if e.Message == "" {
+		return "streaming: server error " + kind
+	}
+	return fmt.Sprintf("streaming: server error %s: %s", kind, e.Message)
+}
+
+// Unwrap returns ErrUnauthorized or ErrSubscriptionRejected when the error
+// is one of those, so they can be detected with errors.Is.
+func (e *ServerError) Unwrap() error {
+	switch {
+	case e.Type == messageUnauthorized,
+		e.StatusCode == http.StatusUnauthorized,
+		e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.Type == messageSubscriptionFailed:
+		return ErrSubscriptionRejected
+	}
+	return nil
+}
+
+// Unauthorized returns true if the server rejected the API key, so
+// valr.IsAuthError recognises the error.
+func (e *ServerError) Unauthorized() bool {
+	return errors.Is(e, ErrUnauthorized)
+}
+
+// Retryable returns true for refused handshakes that may succeed later, such
+// as when the server is overloaded.
+func (e *ServerError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// parseServerError decodes an error frame of the given type.
+func parseServerError(msgType string, data []byte) *ServerError {
+	var frame struct {
+		Message string `json:"message"`
+		Data    struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(data, &frame)
+	msg := frame.Message
+	if msg == "" {
+		msg = frame.Data.Message
+	}
+	return &ServerError{Type: msgType, Message: msg}
+}
+
+// handshakeError returns a ServerError if the server refused the websocket
+// handshake with res.
+func handshakeError(res *http.Response) error {
+	if res == nil || res.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	return &ServerError{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+}
+
+func (c *Conn) reportError(err error) {
+	if c.errorCallback != nil {
+		c.call(CallbackError, func() { c.errorCallback(err) })
+	}
+}
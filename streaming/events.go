@@ -0,0 +1,88 @@
+package streaming
+
+import (
+	"github.com/donohutcheon/valr-go/eventbus"
+)
+
+// WithEventBus publishes the trades, balance, order and fill updates
+// received by the connection to b, in addition to passing them to any
+// callbacks.
+func WithEventBus(b *eventbus.Bus) DialOption {
+	return func(c *Conn) {
+		c.bus = b
+	}
+}
+
+// publish converts a decoded message to an event and publishes it to the
+// connection's bus, if any, passing its time to the event time callback.
+func (c *Conn) publish(msg any) {
+	if c.bus == nil && c.eventTimeCallback == nil {
+		return
+	}
+	ev := streamEvent(msg)
+	if ev == nil {
+		return
+	}
+	if c.eventTimeCallback != nil && !ev.EventTime().IsZero() {
+		c.call(CallbackEventTime, func() { c.eventTimeCallback(ev.EventTime(), c.LastMessage()) })
+	}
+	if c.bus != nil {
+		c.bus.Publish(ev)
+	}
+}
+
+func streamEvent(msg any) eventbus.Event {
+	switch m := msg.(type) {
+	case *MessageTradeUpdate:
+		return eventbus.Trade{
+			Header:    eventbus.Header{Source: eventbus.SourceStream, Time: m.Data.TradedAt.Time},
+			TradeID:   m.Data.ID,
+			Pair:      m.CurrencyPairSymbol,
+			Price:     m.Data.Price,
+			Quantity:  m.Data.Quantity,
+			TakerSide: m.Data.TakerSide,
+		}
+	case *MessageBalanceUpdate:
+		source := eventbus.SourceStream
+		if m.Snapshot {
+			source = eventbus.SourceREST
+		}
+		return eventbus.Balance{
+			Header:    eventbus.Header{Source: source, Time: m.Data.UpdatedAt.Time},
+			Currency:  m.Data.Currency.Symbol,
+			Available: m.Data.Available,
+			Reserved:  m.Data.Reserved,
+			Total:     m.Data.Total,
+		}
+	case *MessageOrderStatusUpdate:
+		return eventbus.Order{
+			Header:            eventbus.Header{Source: eventbus.SourceStream, Time: m.Data.OrderUpdatedAt.Time},
+			OrderID:           m.Data.OrderID,
+			CustomerOrderID:   m.Data.CustomerOrderID,
+			Pair:              m.Data.Pair,
+			Side:              m.Data.OrderSide,
+			Type:              m.Data.OrderType,
+			Status:            m.Data.OrderStatusType,
+			FailedReason:      m.Data.FailedReason,
+			Price:             m.Data.OriginalPrice,
+			OriginalQuantity:  m.Data.OriginalQuantity,
+			RemainingQuantity: m.Data.RemainingQuantity,
+		}
+	case *MessageAccountTrade:
+		pair := m.Data.CurrencyPair
+		if pair == "" {
+			pair = m.CurrencyPairSymbol
+		}
+		return eventbus.Fill{
+			Header:   eventbus.Header{Source: eventbus.SourceStream, Time: m.Data.TradedAt.Time},
+			TradeID:  m.Data.ID,
+			OrderID:  m.Data.OrderID,
+			Pair:     pair,
+			Side:     m.Data.Side,
+			Price:    m.Data.Price,
+			Quantity: m.Data.Quantity,
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package streaming_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/donohutcheon/valr-go/supervise"
+)
+
+func TestEventBus(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"BALANCE_UPDATE","data":{"currency":{"symbol":"ZAR"},"available":"90","reserved":"10","total":"100"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"ORDER_STATUS_UPDATE","data":{"orderId":"o1","orderStatusType":"Filled","currencyPair":"BTCZAR","remainingQuantity":"0"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"NEW_ACCOUNT_TRADE","currencyPairSymbol":"BTCZAR","data":{"price":"100","quantity":"1","orderId":"o1","id":"t1"}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"account","frame":{"type":"ORDER_PROCESSED","data":{"orderId":"o2","success":true}}}`,
+		`{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"price":"50","quantity":"2","id":"m1","takerSide":"sell"}}}`,
+	}, "\n")
+
+	b := eventbus.New()
+	defer b.Close()
+	sub := eventbus.Subscribe[eventbus.Event](b, 0)
+	if err := streaming.Replay(context.Background(), strings.NewReader(journal), streaming.WithEventBus(b)); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var got []string
+	for len(sub.C) > 0 {
+		switch ev := (<-sub.C).(type) {
+		case eventbus.Balance:
+			got = append(got, fmt.Sprintf("balance %s %s", ev.Currency, ev.Total))
+		case eventbus.Order:
+			got = append(got, fmt.Sprintf("order %s %s %s", ev.OrderID, ev.Pair, ev.Status))
+		case eventbus.Fill:
+			got = append(got, fmt.Sprintf("fill %s %s %s %s", ev.TradeID, ev.OrderID, ev.Pair, ev.Quantity))
+		case eventbus.Trade:
+			got = append(got, fmt.Sprintf("trade %s %s %s %s", ev.TradeID, ev.Pair, ev.Price, ev.TakerSide))
+		default:
+			t.Errorf("Unexpected event %#v", ev)
+		}
+	}
+	want := []string{
+		"balance ZAR 100",
+		"order o1 BTCZAR Filled",
+		"fill t1 o1 BTCZAR 1",
+		"trade m1 ETHZAR 50 sell",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestEventTimeCallback(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:06Z","stream":"account","frame":{"type":"BALANCE_UPDATE","data":{"currency":{"symbol":"ZAR"},"total":"100","updatedAt":"2024-01-02T03:04:05Z"}}}`,
+		`{"time":"2024-01-02T03:04:08Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"price":"50","quantity":"2","id":"m1","tradedAt":"2024-01-02T03:04:07.5Z"}}}`,
+		`{"time":"2024-01-02T03:04:09Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"price":"50","quantity":"2","id":"m2"}}}`,
+	}, "\n")
+
+	var got []string
+	err := streaming.Replay(context.Background(), strings.NewReader(journal), streaming.WithEventTimeCallback(func(stamped, received time.Time) {
+		got = append(got, received.Sub(stamped).String())
+	}))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// The trade without a timestamp is skipped.
+	want := []string{"1s", "500ms"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSupervisedCallback(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"m1"}}}`,
+		`{"time":"2024-01-02T03:04:06Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"m2"}}}`,
+	}, "\n")
+
+	var panics []string
+	s := supervise.New(supervise.WithPanicCallback(func(p supervise.Panic) {
+		panics = append(panics, p.Callback)
+	}))
+	var got []string
+	err := streaming.Replay(context.Background(), strings.NewReader(journal),
+		streaming.WithSupervisor(s),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+			if m.Data.ID == "m1" {
+				panic("boom")
+			}
+			got = append(got, m.Data.ID)
+		}))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(got) != 1 || got[0] != "m2" {
+		t.Errorf("Expected the callback to be restarted for %q, got %q", "m2", got)
+	}
+	if len(panics) != 1 || panics[0] != streaming.CallbackUpdate {
+		t.Errorf("Expected a panic of %q, got %q", streaming.CallbackUpdate, panics)
+	}
+}
@@ -0,0 +1,164 @@
+package streaming
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultSubscriberBuffer = 256
+
+// Hub fans trade updates received by a connection out to any number of
+// in-process subscribers. Each subscriber has its own buffer: when it is
+// full, updates for that subscriber are dropped rather than holding up the
+// connection or other subscribers.
+type Hub struct {
+	conn *Conn
+	// subMu orders the subscriptions sent by Subscribe, so the last one
+	// sent holds every pair.
+	subMu sync.Mutex
+
+	mu     sync.Mutex
+	subs   map[*Subscriber]struct{}
+	pairs  map[string]int
+	closed bool
+}
+
+// NewHub returns a hub. Attach it to a connection with WithHub.
+func NewHub() *Hub {
+	return &Hub{
+		subs:  make(map[*Subscriber]struct{}),
+		pairs: make(map[string]int),
+	}
+}
+
+// WithHub delivers the connection's trade updates to h. It replaces any
+// callback set by WithUpdateCallback.
+func WithHub(h *Hub) DialOption {
+	return func(c *Conn) {
+		h.conn = c
+		c.updateCallback = h.publish
+	}
+}
+
+// Subscriber receives the trade updates of a set of pairs from a Hub.
+type Subscriber struct {
+	// C delivers updates. It is closed by Unsubscribe and Hub.Close.
+	C <-chan MessageTradeUpdate
+	// Ack acknowledges the market subscription made when the subscriber
+	// added pairs that no other subscriber had subscribed to, or is nil if
+	// there were none.
+	Ack *SubscriptionAck
+
+	hub     *Hub
+	ch      chan MessageTradeUpdate
+	pairs   map[string]bool
+	dropped atomic.Uint64
+}
+
+// Subscribe registers a subscriber for the given pairs with a buffer of the
+// given size, or a default size if buffer is not positive. If any of the
+// pairs are not yet subscribed to by any subscriber, the connection's trade
+// subscription is replaced by one to every pair of the hub, blocking until
+// it is ready to send the request.
+func (h *Hub) Subscribe(pairs []string, buffer int) *Subscriber {
+	if buffer <= 0 {
+		buffer = defaultSubscriberBuffer
+	}
+	ch := make(chan MessageTradeUpdate, buffer)
+	s := &Subscriber{C: ch, hub: h, ch: ch, pairs: make(map[string]bool)}
+
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return s
+	}
+	var added []string
+	for _, p := range pairs {
+		if s.pairs[p] {
+			continue
+		}
+		s.pairs[p] = true
+		if h.pairs[p] == 0 {
+			added = append(added, p)
+		}
+		h.pairs[p]++
+	}
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+
+	if len(added) > 0 && h.conn != nil {
+		// Each subscription replaces the previous one, so it must hold
+		// every pair of the hub.
+		all := h.Pairs()
+		sort.Strings(all)
+		s.Ack = h.conn.SubscribeToMarkets(all)
+	}
+	return s
+}
+
+// Unsubscribe stops delivery to the subscriber and closes its channel. The
+// connection remains subscribed to its pairs.
+func (s *Subscriber) Unsubscribe() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	for p := range s.pairs {
+		if h.pairs[p]--; h.pairs[p] <= 0 {
+			delete(h.pairs, p)
+		}
+	}
+	close(s.ch)
+}
+
+// Dropped returns the number of updates dropped because the subscriber's
+// buffer was full.
+func (s *Subscriber) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Pairs returns the pairs with at least one subscriber.
+func (h *Hub) Pairs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pairs := make([]string, 0, len(h.pairs))
+	for p := range h.pairs {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// Close unsubscribes all subscribers. Later subscribers are closed
+// immediately.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		close(s.ch)
+	}
+	h.subs = make(map[*Subscriber]struct{})
+	h.pairs = make(map[string]int)
+}
+
+func (h *Hub) publish(update MessageTradeUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.pairs[update.CurrencyPairSymbol] {
+			continue
+		}
+		select {
+		case s.ch <- update:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
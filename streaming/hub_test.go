@@ -0,0 +1,108 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+func TestHub(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","data":{"id":"1"}}}`,
+		`{"stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"2"}}}`,
+		`{"stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","data":{"id":"3"}}}`,
+	}, "\n")
+
+	h := streaming.NewHub()
+	fast := h.Subscribe([]string{"BTCZAR", "ETHZAR"}, 10)
+	slow := h.Subscribe([]string{"BTCZAR"}, 1)
+
+	err := streaming.Replay(context.Background(), strings.NewReader(journal), streaming.WithHub(h))
+	if err != nil {
+		t.Errorf("Expected success, got %v", err)
+		return
+	}
+	h.Close()
+
+	var ids []string
+	for u := range fast.C {
+		ids = append(ids, u.Data.ID)
+	}
+	if strings.Join(ids, ",") != "1,2,3" {
+		t.Errorf("Expected %q, got %q", "1,2,3", strings.Join(ids, ","))
+	}
+
+	ids = nil
+	for u := range slow.C {
+		ids = append(ids, u.Data.ID)
+	}
+	if strings.Join(ids, ",") != "1" {
+		t.Errorf("Expected %q, got %q", "1", strings.Join(ids, ","))
+	}
+	if slow.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped update, got %d", slow.Dropped())
+	}
+}
+
+func TestHubSubscriptions(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	reqs := make(chan streaming.SubscribeToMarketsRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			reqs <- req
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	h := streaming.NewHub()
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env), streaming.WithHub(h))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Subscribe([]string{"ETHZAR", "BTCZAR"}, 0).Ack.Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if s := h.Subscribe([]string{"BTCZAR"}, 0); s.Ack != nil {
+		t.Errorf("Expected no subscription for pairs already subscribed")
+	}
+	// The new subscription replaces the first, so it keeps its pairs.
+	if err := h.Subscribe([]string{"XRPZAR"}, 0).Ack.Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	for _, want := range [][]string{{"BTCZAR", "ETHZAR"}, {"BTCZAR", "ETHZAR", "XRPZAR"}} {
+		req := <-reqs
+		if len(req.Subscriptions) != 1 || !reflect.DeepEqual(req.Subscriptions[0].Pairs, want) {
+			t.Errorf("Expected a subscription to %q, got %+v", want, req.Subscriptions)
+		}
+	}
+	if n := len(reqs); n != 0 {
+		t.Errorf("Expected 2 subscription messages, got %d more", n)
+	}
+}
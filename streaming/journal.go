@@ -0,0 +1,127 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// streamTrade names the trade stream in journal entries.
+const streamTrade = "trade"
+
+// JournalEntry is a single raw frame received from a stream.
+type JournalEntry struct {
+	Time   time.Time       `json:"time"`
+	Stream string          `json:"stream"`
+	Frame  json.RawMessage `json:"frame"`
+}
+
+// Journal is an append-only log of raw streaming frames, written as one JSON
+// encoded JournalEntry per line. Journals can be fed back through a
+// connection's message handling with Replay, e.g. to debug an incident.
+type Journal struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJournal returns a journal that appends entries to w.
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w, enc: json.NewEncoder(w)}
+}
+
+// OpenJournalFile opens, or creates, a journal file for appending.
+func OpenJournalFile(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournal(f), nil
+}
+
+// Record appends a frame received on the named stream.
+func (j *Journal) Record(stream string, frame []byte) error {
+	e := JournalEntry{Time: time.Now(), Stream: stream, Frame: frame}
+	if !json.Valid(frame) {
+		// Keep the journal parseable by storing invalid frames as strings.
+		s, _ := json.Marshal(string(frame))
+		e.Frame = s
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(e)
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// WithJournal records every frame received by the connection to j.
+func WithJournal(j *Journal) DialOption {
+	return func(c *Conn) {
+		c.journal = j
+	}
+}
+
+// JournalReader reads entries written by a Journal.
+type JournalReader struct {
+	dec *json.Decoder
+}
+
+// NewJournalReader returns a reader of the journal in r.
+func NewJournalReader(r io.Reader) *JournalReader {
+	return &JournalReader{dec: json.NewDecoder(r)}
+}
+
+// Next returns the next entry, or io.EOF at the end of the journal.
+func (r *JournalReader) Next() (JournalEntry, error) {
+	var e JournalEntry
+	err := r.dec.Decode(&e)
+	return e, err
+}
+
+// Replay feeds the trade and account stream frames of a journal through the
+// same handling as a live connection, invoking the callbacks configured by
+// opts.
+// No connection is made. Replay stops at the end of the journal, when ctx is
+// done or when a frame fails to process.
+func Replay(ctx context.Context, r io.Reader, opts ...DialOption) error {
+	c := &Conn{replay: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// Replayed frames must not be journalled again.
+	c.journal = nil
+
+	jr := NewJournalReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		e, err := jr.Next()
+		if errors.Is(err, io.EOF) {
+			c.throttle.flush()
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if e.Stream != streamTrade && e.Stream != streamAccount {
+			continue
+		}
+		// Frames were received when they were journalled.
+		c.lastMessage.Store(e.Time.UnixNano())
+		if err := c.dispatch(e.Frame); err != nil {
+			return err
+		}
+	}
+}
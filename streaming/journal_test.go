@@ -0,0 +1,137 @@
+package streaming_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+// recordFrames records a trade, a market summary, an account balance and a
+// frame of an unreplayed stream, which is not valid JSON.
+func recordFrames(t *testing.T, j *streaming.Journal) {
+	t.Helper()
+	for _, f := range []struct{ stream, frame string }{
+		{"trade", `{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","data":{"price":"100","quantity":"1"}}`},
+		{"other", `not json`},
+		{"trade", `{"type":"MARKET_SUMMARY_UPDATE","currencyPairSymbol":"BTCZAR","data":{"currencyPairSymbol":"BTCZAR","lastTradedPrice":"101"}}`},
+		{"account", `{"type":"BALANCE_UPDATE","data":{"currency":{"symbol":"ZAR"},"available":"90","reserved":"10","total":"100"}}`},
+		{"trade", `{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"price":"50","quantity":"2"}}`},
+	} {
+		if err := j.Record(f.stream, []byte(f.frame)); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+}
+
+// replayFrames replays a journal, returning the messages handled in order.
+func replayFrames(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var got []string
+	err := streaming.Replay(context.Background(), r,
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+			got = append(got, m.Type+" "+m.CurrencyPairSymbol+" "+m.Data.Price.String())
+		}),
+		streaming.WithMarketSummaryCallback(func(m streaming.MessageMarketSummaryUpdate) {
+			got = append(got, m.Type+" "+m.CurrencyPairSymbol+" "+m.Data.LastPrice.String())
+		}),
+		streaming.WithBalanceUpdateCallback(func(m streaming.MessageBalanceUpdate) {
+			got = append(got, m.Type+" "+m.Data.Currency.Symbol+" "+m.Data.Total.String())
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	return got
+}
+
+var replayed = []string{
+	"NEW_TRADE BTCZAR 100",
+	"MARKET_SUMMARY_UPDATE BTCZAR 101",
+	"BALANCE_UPDATE ZAR 100",
+	"NEW_TRADE ETHZAR 50",
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	recordFrames(t, streaming.NewJournal(&buf))
+
+	// Invalid frames are kept as strings so the journal stays readable.
+	jr := streaming.NewJournalReader(bytes.NewReader(buf.Bytes()))
+	var entries []streaming.JournalEntry
+	for {
+		e, err := jr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("Expected 5 entries, got %d", len(entries))
+	}
+	var s string
+	if err := json.Unmarshal(entries[1].Frame, &s); err != nil || entries[1].Stream != "other" || s != "not json" {
+		t.Errorf("Expected the invalid frame stored as a string, got %s", entries[1].Frame)
+	}
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			t.Errorf("Expected the entry's time recorded, got %+v", e)
+		}
+	}
+
+	// Only trade and account frames are replayed, in order.
+	got := replayFrames(t, &buf)
+	if len(got) != len(replayed) {
+		t.Fatalf("Expected %q, got %q", replayed, got)
+	}
+	for i := range replayed {
+		if got[i] != replayed[i] {
+			t.Errorf("Expected %q, got %q", replayed[i], got[i])
+		}
+	}
+}
+
+func TestJournalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frames.jsonl")
+
+	// A reopened journal appends to the file.
+	for i := 0; i < 2; i++ {
+		j, err := streaming.OpenJournalFile(path)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		recordFrames(t, j)
+		if err := j.Close(); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer f.Close()
+	if got := replayFrames(t, f); len(got) != 2*len(replayed) || got[len(replayed)] != replayed[0] {
+		t.Errorf("Expected the frames replayed twice, got %q", got)
+	}
+}
+
+func TestReplayCancelled(t *testing.T) {
+	var buf bytes.Buffer
+	recordFrames(t, streaming.NewJournal(&buf))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := streaming.Replay(ctx, &buf, streaming.WithUpdateCallback(func(streaming.MessageTradeUpdate) { called = true }))
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("Expected %v before any frame, got %v, %t", context.Canceled, err, called)
+	}
+}
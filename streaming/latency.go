@@ -0,0 +1,279 @@
+package streaming
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBounds are the upper bounds of the buckets of a
+// LatencyRecorder's histograms by default.
+var DefaultLatencyBounds = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Received stamps a streamed message with when its frame was received.
+type Received struct {
+	// ReceivedAt is when the frame holding the message was read from the
+	// websocket, or journalled for replayed messages. It is zero for
+	// messages fetched over REST, such as account snapshots.
+	ReceivedAt time.Time `json:"-"`
+}
+
+func (r *Received) setReceived(t time.Time) {
+	r.ReceivedAt = t
+}
+
+func (r *Received) receivedAt() time.Time {
+	return r.ReceivedAt
+}
+
+func (m *MessageType) event() string {
+	return m.Type
+}
+
+// streamedMessage is a decoded message stamped with its receipt.
+type streamedMessage interface {
+	event() string
+	receivedAt() time.Time
+	setReceived(time.Time)
+}
+
+// LatencyHistogram is a distribution of latencies.
+type LatencyHistogram struct {
+	// Bounds are the upper bounds of the buckets, in increasing order.
+	Bounds []time.Duration
+	// Counts holds the number of latencies in each bucket: Counts[i] those
+	// up to Bounds[i] and above the previous bound, and the last count
+	// those above every bound.
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+	Min    time.Duration
+	Max    time.Duration
+}
+
+func newLatencyHistogram(bounds []time.Duration) LatencyHistogram {
+	return LatencyHistogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *LatencyHistogram) observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	if h.Count == 0 || d < h.Min {
+		h.Min = d
+	}
+	if h.Count == 0 || d > h.Max {
+		h.Max = d
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// Mean returns the mean latency, zero if none were observed.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns an upper estimate of the q quantile, e.g. 0.99 for the
+// 99th percentile: the bound of the bucket holding it, or the maximum if
+// that is lower or above every bound. It is zero if no latencies were
+// observed.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(h.Count) + 0.5)
+	rank = max(rank, 1)
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if seen >= rank && i < len(h.Bounds) {
+			return min(h.Bounds[i], h.Max)
+		}
+	}
+	return h.Max
+}
+
+func (h LatencyHistogram) clone() LatencyHistogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// LatencyStats are the latencies of the streamed messages of one type.
+type LatencyStats struct {
+	// Event is the message type, e.g. EventNewTrade.
+	Event string
+	// Network holds the time from VALR stamping each event, e.g. a trade's
+	// tradedAt, to its frame being received. It includes any clock skew, so
+	// latencies may be negative; see health.SkewMonitor.
+	Network LatencyHistogram
+	// Processing holds the time from receiving each message to delivering
+	// it to its callback, including decoding, throttling and waiting for
+	// earlier callbacks to return.
+	Processing LatencyHistogram
+}
+
+type LatencyOption func(*LatencyRecorder)
+
+// WithLatencyBounds sets the upper bounds of the histogram buckets, by
+// default DefaultLatencyBounds.
+func WithLatencyBounds(bounds ...time.Duration) LatencyOption {
+	return func(r *LatencyRecorder) {
+		r.bounds = bounds
+	}
+}
+
+// LatencyRecorder records histograms of end-to-end streaming latency by
+// message type, split into the network latency up to receipt and the local
+// processing delay from receipt to callback delivery, to tell a slow
+// connection apart from slow callbacks. Pass it to a connection with
+// WithLatencyRecorder.
+type LatencyRecorder struct {
+	bounds []time.Duration
+
+	mu     sync.Mutex
+	events map[string]*LatencyStats
+}
+
+// NewLatencyRecorder returns a recorder without latencies.
+func NewLatencyRecorder(opts ...LatencyOption) *LatencyRecorder {
+	r := &LatencyRecorder{bounds: DefaultLatencyBounds}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.bounds = append([]time.Duration(nil), r.bounds...)
+	sort.Slice(r.bounds, func(i, j int) bool { return r.bounds[i] < r.bounds[j] })
+	r.events = make(map[string]*LatencyStats)
+	return r
+}
+
+// stats returns the stats of event, creating them if needed. It must be
+// called with r.mu held.
+func (r *LatencyRecorder) stats(event string) *LatencyStats {
+	s, ok := r.events[event]
+	if !ok {
+		s = &LatencyStats{
+			Event:      event,
+			Network:    newLatencyHistogram(r.bounds),
+			Processing: newLatencyHistogram(r.bounds),
+		}
+		r.events[event] = s
+	}
+	return s
+}
+
+// ObserveNetwork records the network latency of an event VALR stamped at
+// stamped, received at received.
+func (r *LatencyRecorder) ObserveNetwork(event string, stamped, received time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats(event).Network.observe(received.Sub(stamped))
+}
+
+// ObserveProcessing records the processing delay of a message received at
+// received and delivered at delivered.
+func (r *LatencyRecorder) ObserveProcessing(event string, received, delivered time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats(event).Processing.observe(delivered.Sub(received))
+}
+
+// Stats returns the latencies recorded for each message type, sorted by
+// type.
+func (r *LatencyRecorder) Stats() []LatencyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]LatencyStats, 0, len(r.events))
+	for _, s := range r.events {
+		out = append(out, LatencyStats{
+			Event:      s.Event,
+			Network:    s.Network.clone(),
+			Processing: s.Processing.clone(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Event < out[j].Event })
+	return out
+}
+
+// Reset forgets all recorded latencies.
+func (r *LatencyRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = make(map[string]*LatencyStats)
+}
+
+// WithLatencyRecorder records the latencies of the connection's messages
+// in r. Messages of Replay record network latencies only, as their receipt
+// times are those of the journal.
+func WithLatencyRecorder(r *LatencyRecorder) DialOption {
+	return func(c *Conn) {
+		c.latency = r
+	}
+}
+
+// stampedAt returns when VALR stamped the event of msg, zero if it has no
+// timestamp.
+func stampedAt(msg any) time.Time {
+	switch m := msg.(type) {
+	case *MessageTradeUpdate:
+		return m.Data.TradedAt.Time
+	case *MessageAccountTrade:
+		return m.Data.TradedAt.Time
+	case *MessageOrderStatusUpdate:
+		return m.Data.OrderUpdatedAt.Time
+	case *MessageBalanceUpdate:
+		return m.Data.UpdatedAt.Time
+	case *MessageMarketSummaryUpdate:
+		return m.Data.Created.Time
+	case *MessageAggregatedOrderBook:
+		return m.Data.LastChange.Time
+	case *MessageFullOrderBook:
+		return m.Data.LastChange.Time
+	default:
+		return time.Time{}
+	}
+}
+
+// received stamps a decoded message with the time its frame was received,
+// recording its network latency.
+func (c *Conn) received(msg any) {
+	m, ok := msg.(streamedMessage)
+	if !ok {
+		return
+	}
+	at := c.LastMessage()
+	m.setReceived(at)
+	if c.latency == nil || at.IsZero() {
+		return
+	}
+	if stamped := stampedAt(msg); !stamped.IsZero() {
+		c.latency.ObserveNetwork(m.event(), stamped, at)
+	}
+}
+
+// deliver runs the named callback of a message, recording the delay since
+// the message was received.
+func (c *Conn) deliver(callback string, msg any, fn func()) {
+	c.call(callback, func() {
+		if m, ok := msg.(streamedMessage); ok && c.latency != nil && !c.replay && !m.receivedAt().IsZero() {
+			c.latency.ObserveProcessing(m.event(), m.receivedAt(), time.Now())
+		}
+		fn()
+	})
+}
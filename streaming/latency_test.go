@@ -0,0 +1,84 @@
+package streaming_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func TestLatencyRecorder(t *testing.T) {
+	journal := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05.15Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"m1","tradedAt":"2024-01-02T03:04:05Z"}}}`,
+		`{"time":"2024-01-02T03:04:06.03Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"m2","tradedAt":"2024-01-02T03:04:06Z"}}}`,
+		`{"time":"2024-01-02T03:04:07Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"ETHZAR","data":{"id":"m3"}}}`,
+		`{"time":"2024-01-02T03:04:09Z","stream":"account","frame":{"type":"BALANCE_UPDATE","data":{"currency":{"symbol":"ZAR"},"total":"100","updatedAt":"2024-01-02T03:04:05Z"}}}`,
+	}, "\n")
+
+	r := streaming.NewLatencyRecorder()
+	var received []time.Time
+	err := streaming.Replay(context.Background(), strings.NewReader(journal),
+		streaming.WithLatencyRecorder(r),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+			received = append(received, m.ReceivedAt)
+		}))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(received) != 3 || !received[2].Equal(time.Date(2024, 1, 2, 3, 4, 7, 0, time.UTC)) {
+		t.Errorf("Expected messages stamped with their journal times, got %v", received)
+	}
+
+	stats := r.Stats()
+	if len(stats) != 2 || stats[0].Event != streaming.EventBalanceUpdate || stats[1].Event != streaming.EventNewTrade {
+		t.Fatalf("Expected balance and trade stats, got %+v", stats)
+	}
+	// The trade without a timestamp is skipped, and replayed messages
+	// record no processing delay.
+	trades := stats[1]
+	if trades.Network.Count != 2 || trades.Processing.Count != 0 {
+		t.Errorf("Expected 2 network latencies only, got %d and %d", trades.Network.Count, trades.Processing.Count)
+	}
+	if trades.Network.Min != 30*time.Millisecond || trades.Network.Max != 150*time.Millisecond {
+		t.Errorf("Expected latencies from 30ms to 150ms, got %s to %s", trades.Network.Min, trades.Network.Max)
+	}
+	if q := trades.Network.Quantile(0.5); q != 50*time.Millisecond {
+		t.Errorf("Expected a median of %s, got %s", 50*time.Millisecond, q)
+	}
+	if q := trades.Network.Quantile(1); q != 150*time.Millisecond {
+		t.Errorf("Expected a maximum of %s, got %s", 150*time.Millisecond, q)
+	}
+	if got := stats[0].Network.Max; got != 4*time.Second {
+		t.Errorf("Expected %s, got %s", 4*time.Second, got)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	r := streaming.NewLatencyRecorder(streaming.WithLatencyBounds(time.Second, 10*time.Millisecond, 100*time.Millisecond))
+	received := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, d := range []time.Duration{-time.Millisecond, 5 * time.Millisecond, 50 * time.Millisecond, 70 * time.Millisecond, 3 * time.Second} {
+		r.ObserveProcessing(streaming.EventNewTrade, received, received.Add(d))
+	}
+
+	h := r.Stats()[0].Processing
+	want := []uint64{2, 2, 0, 1}
+	for i, n := range want {
+		if h.Counts[i] != n {
+			t.Errorf("Expected counts %v, got %v", want, h.Counts)
+			break
+		}
+	}
+	if h.Count != 5 || h.Mean() != 624800*time.Microsecond {
+		t.Errorf("Expected 5 latencies averaging 624.8ms, got %d averaging %s", h.Count, h.Mean())
+	}
+	if q := h.Quantile(0.99); q != 3*time.Second {
+		t.Errorf("Expected %s, got %s", 3*time.Second, q)
+	}
+
+	r.Reset()
+	if stats := r.Stats(); len(stats) != 0 {
+		t.Errorf("Expected no stats after a reset, got %+v", stats)
+	}
+}
@@ -0,0 +1,194 @@
+package streaming
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var tenThousand = decimal.New(10000, 0)
+
+// LiquidityMetrics describe the quality of a pair's market.
+type LiquidityMetrics struct {
+	Pair   string
+	Spread decimal.Decimal
+	// SpreadBps is the spread in basis points of the mid.
+	SpreadBps decimal.Decimal
+	// BidDepth and AskDepth are the values, in the quote currency, of the
+	// orders within DepthBps of the mid.
+	DepthBps decimal.Decimal
+	BidDepth decimal.Decimal
+	AskDepth decimal.Decimal
+	// UpdateRate is the number of book updates per second over the
+	// monitor's window.
+	UpdateRate float64
+	UpdatedAt  time.Time
+}
+
+// ComputeLiquidity computes the spread and depth within bps of the mid from
+// a snapshot. It returns false if either side of the book is empty.
+func ComputeLiquidity(snap BookSnapshot, bps decimal.Decimal) (LiquidityMetrics, bool) {
+	if len(snap.Bids) == 0 || len(snap.Asks) == 0 {
+		return LiquidityMetrics{}, false
+	}
+	bid, ask := snap.Bids[0].Price, snap.Asks[0].Price
+	mid := bid.Add(ask).Div(decimal.New(2, 0))
+	m := LiquidityMetrics{
+		Pair:      snap.Pair,
+		Spread:    ask.Sub(bid),
+		DepthBps:  bps,
+		UpdatedAt: snap.UpdatedAt,
+	}
+	if !mid.IsPositive() {
+		return m, true
+	}
+	m.SpreadBps = m.Spread.Mul(tenThousand).DivRound(mid, 4)
+
+	band := mid.Mul(bps).Div(tenThousand)
+	for _, l := range snap.Bids {
+		if l.Price.LessThan(mid.Sub(band)) {
+			break
+		}
+		m.BidDepth = m.BidDepth.Add(l.Price.Mul(l.Quantity))
+	}
+	for _, l := range snap.Asks {
+		if l.Price.GreaterThan(mid.Add(band)) {
+			break
+		}
+		m.AskDepth = m.AskDepth.Add(l.Price.Mul(l.Quantity))
+	}
+	return m, true
+}
+
+// LiquidityMonitor tracks LiquidityMetrics for books in a BookKeeper and
+// serves them as Prometheus gauges.
+type LiquidityMonitor struct {
+	keeper *BookKeeper
+	bps    decimal.Decimal
+	window time.Duration
+
+	mu      sync.Mutex
+	metrics map[string]LiquidityMetrics
+	// updates holds the times of each pair's updates within the window.
+	updates map[string][]time.Time
+	subs    map[string][]*Subscription[LiquidityMetrics]
+	removes map[string]func()
+}
+
+// NewLiquidityMonitor returns a monitor measuring depth within bps of the
+// mid and update rates over window.
+func NewLiquidityMonitor(k *BookKeeper, bps decimal.Decimal, window time.Duration) *LiquidityMonitor {
+	return &LiquidityMonitor{
+		keeper:  k,
+		bps:     bps,
+		window:  window,
+		metrics: make(map[string]LiquidityMetrics),
+		updates: make(map[string][]time.Time),
+		subs:    make(map[string][]*Subscription[LiquidityMetrics]),
+		removes: make(map[string]func()),
+	}
+}
+
+// Watch starts monitoring pair and returns a subscription to its metrics,
+// delivered after every change to the book.
+func (m *LiquidityMonitor) Watch(pair string) *Subscription[LiquidityMetrics] {
+	sub := newSubscription[LiquidityMetrics]()
+	sub.remove = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.subs[pair] = slices.DeleteFunc(m.subs[pair], func(s *Subscription[LiquidityMetrics]) bool {
+			return s == sub
+		})
+	}
+
+	m.mu.Lock()
+	m.subs[pair] = append(m.subs[pair], sub)
+	_, watching := m.removes[pair]
+	m.mu.Unlock()
+	if !watching {
+		remove := m.keeper.Book(pair).addListener(m.update)
+		m.mu.Lock()
+		m.removes[pair] = remove
+		m.mu.Unlock()
+	}
+	return sub
+}
+
+func (m *LiquidityMonitor) update(b *OrderBook) {
+	metrics, ok := ComputeLiquidity(b.Snapshot(), m.bps)
+	now := time.Now()
+
+	m.mu.Lock()
+	times := append(m.updates[b.pair], now)
+	cutoff := now.Add(-m.window)
+	for len(times) > 0 && times[0].Before(cutoff) {
+		times = times[1:]
+	}
+	m.updates[b.pair] = times
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	if m.window > 0 {
+		metrics.UpdateRate = float64(len(times)) / m.window.Seconds()
+	}
+	m.metrics[b.pair] = metrics
+	subs := append([]*Subscription[LiquidityMetrics](nil), m.subs[b.pair]...)
+	m.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.deliver(metrics)
+	}
+}
+
+// Metrics returns the latest metrics of each monitored pair, sorted by pair.
+func (m *LiquidityMonitor) Metrics() []LiquidityMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]LiquidityMetrics, 0, len(m.metrics))
+	for _, lm := range m.metrics {
+		out = append(out, lm)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Pair < out[j].Pair })
+	return out
+}
+
+// Close stops monitoring all pairs.
+func (m *LiquidityMonitor) Close() {
+	m.mu.Lock()
+	removes := m.removes
+	m.removes = make(map[string]func())
+	m.mu.Unlock()
+	for _, remove := range removes {
+		remove()
+	}
+}
+
+// ServeHTTP writes the latest metrics as Prometheus gauges in the text
+// exposition format.
+func (m *LiquidityMonitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	metrics := m.Metrics()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	gauges := []struct {
+		name, help string
+		value      func(LiquidityMetrics) string
+	}{
+		{"valr_book_spread", "Best ask minus best bid.", func(lm LiquidityMetrics) string { return lm.Spread.String() }},
+		{"valr_book_spread_bps", "Spread in basis points of the mid.", func(lm LiquidityMetrics) string { return lm.SpreadBps.String() }},
+		{"valr_book_bid_depth", "Quote value of bids near the mid.", func(lm LiquidityMetrics) string { return lm.BidDepth.String() }},
+		{"valr_book_ask_depth", "Quote value of asks near the mid.", func(lm LiquidityMetrics) string { return lm.AskDepth.String() }},
+		{"valr_book_update_rate", "Book updates per second.", func(lm LiquidityMetrics) string { return fmt.Sprint(lm.UpdateRate) }},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, lm := range metrics {
+			fmt.Fprintf(w, "%s{pair=%q,depth_bps=%q} %s\n", g.name, lm.Pair, lm.DepthBps.String(), g.value(lm))
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package streaming_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestLiquidityMonitor(t *testing.T) {
+	k := streaming.NewBookKeeper()
+	m := streaming.NewLiquidityMonitor(k, decimal.New(200, 0), time.Minute)
+	defer m.Close()
+	sub := m.Watch("BTCZAR")
+	defer sub.Close()
+
+	k.Book("BTCZAR").ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1,
+		Bids:     []streaming.Level{level("99", "1"), level("98", "2"), level("90", "5")},
+		Asks:     []streaming.Level{level("101", "1"), level("110", "5")},
+	})
+
+	lm := <-sub.C
+	// The mid is 100, so 2% either side covers 98 to 102.
+	for _, c := range []struct{ name, got, want string }{
+		{"spread", lm.Spread.String(), "2"},
+		{"spread bps", lm.SpreadBps.String(), "200"},
+		{"bid depth", lm.BidDepth.String(), "295"},
+		{"ask depth", lm.AskDepth.String(), "101"},
+	} {
+		if c.got != c.want {
+			t.Errorf("Expected %s %s, got %s", c.name, c.want, c.got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `valr_book_spread_bps{pair="BTCZAR",depth_bps="200"} 200`) {
+		t.Errorf("Expected spread gauge in %q", body)
+	}
+}
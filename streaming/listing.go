@@ -0,0 +1,171 @@
+package streaming
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/refdata"
+)
+
+// PairFilter selects the pairs a ListingWatcher subscribes to.
+type PairFilter func(valr.PairInfo) bool
+
+// SpotPairsQuotedIn selects the spot pairs quoted in quote, e.g. all ZAR
+// spot markets.
+func SpotPairsQuotedIn(quote string) PairFilter {
+	return func(p valr.PairInfo) bool {
+		return p.QuoteCurrency == quote && (p.CurrencyPairType == "" || p.CurrencyPairType == valr.PairTypeSpot)
+	}
+}
+
+// ListingCallback is called with the pairs newly subscribed by a
+// ListingWatcher.
+type ListingCallback func([]valr.PairInfo)
+
+type ListingOption func(*ListingWatcher)
+
+// WithListingSubscription sets the subscription made for the watched
+// pairs. build adds the events wanted for pairs to sub; by default only
+// trades are subscribed.
+func WithListingSubscription(build func(sub *SubscriptionBuilder, pairs ...string)) ListingOption {
+	return func(w *ListingWatcher) {
+		w.build = build
+	}
+}
+
+// WithListingCallback sets a callback for newly subscribed pairs.
+func WithListingCallback(fn ListingCallback) ListingOption {
+	return func(w *ListingWatcher) {
+		w.callback = fn
+	}
+}
+
+// WithNewListingsOnly ignores the pairs listed when the watcher first
+// checks, subscribing only to pairs listed later.
+func WithNewListingsOnly() ListingOption {
+	return func(w *ListingWatcher) {
+		w.newOnly = true
+	}
+}
+
+// ListingWatcher periodically refreshes the currency pairs and subscribes
+// a connection to the active pairs matching a filter, including pairs
+// listed while it runs, so data collection starts from a listing's first
+// minute.
+type ListingWatcher struct {
+	conn     *Conn
+	pairs    *refdata.Cache
+	filter   PairFilter
+	build    func(sub *SubscriptionBuilder, pairs ...string)
+	callback ListingCallback
+	newOnly  bool
+
+	mu      sync.Mutex
+	checked bool
+	seen    map[string]bool
+	watched []string
+}
+
+// NewListingWatcher returns a watcher subscribing c to the pairs in cache
+// selected by filter.
+func NewListingWatcher(c *Conn, cache *refdata.Cache, filter PairFilter, opts ...ListingOption) *ListingWatcher {
+	w := &ListingWatcher{
+		conn:   c,
+		pairs:  cache,
+		filter: filter,
+		build: func(sub *SubscriptionBuilder, pairs ...string) {
+			sub.Trades(pairs...)
+		},
+		seen: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run checks for new pairs every interval until ctx is done.
+func (w *ListingWatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Check(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("valr/streaming: Failed to check for new listings: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Check refreshes the pairs and subscribes to the matching pairs not yet
+// watched, returning them. Every watched pair is included in the
+// subscription, since order book subscriptions replace earlier ones. Pairs
+// that are not yet active are picked up once they are.
+func (w *ListingWatcher) Check(ctx context.Context) ([]valr.PairInfo, error) {
+	pairs, err := w.pairs.RefreshPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	first := !w.checked
+	w.checked = true
+	var added []valr.PairInfo
+	for symbol, p := range pairs {
+		if w.seen[symbol] || !p.Active || !w.filter(p) {
+			continue
+		}
+		w.seen[symbol] = true
+		if first && w.newOnly {
+			continue
+		}
+		added = append(added, p)
+	}
+	if len(added) == 0 {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].Symbol < added[j].Symbol })
+	watched := slices.Clone(w.watched)
+	for _, p := range added {
+		watched = append(watched, p.Symbol)
+	}
+	sort.Strings(watched)
+	w.mu.Unlock()
+
+	sub := NewSubscription()
+	w.build(sub, watched...)
+	if err := w.conn.Subscribe(sub).Wait(ctx); err != nil {
+		// Retry the pairs at the next check.
+		w.mu.Lock()
+		for _, p := range added {
+			delete(w.seen, p.Symbol)
+		}
+		w.mu.Unlock()
+		return nil, err
+	}
+
+	w.mu.Lock()
+	w.watched = watched
+	w.mu.Unlock()
+	log.Printf("valr/streaming: Subscribed to %d new pairs", len(added))
+	if w.callback != nil {
+		w.callback(added)
+	}
+	return added, nil
+}
+
+// Pairs returns the watched pairs, sorted.
+func (w *ListingWatcher) Pairs() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.watched)
+}
@@ -0,0 +1,106 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/refdata"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+func TestListingWatcher(t *testing.T) {
+	listings := []string{
+		`[{"symbol":"BTCZAR","quoteCurrency":"ZAR","currencyPairType":"SPOT","active":true},` +
+			`{"symbol":"BTCUSDT","quoteCurrency":"USDT","currencyPairType":"SPOT","active":true},` +
+			`{"symbol":"BTCZARPERP","quoteCurrency":"ZAR","currencyPairType":"FUTURE","active":true}]`,
+		`[{"symbol":"BTCZAR","quoteCurrency":"ZAR","currencyPairType":"SPOT","active":true},` +
+			`{"symbol":"SOLZAR","quoteCurrency":"ZAR","currencyPairType":"SPOT","active":true},` +
+			`{"symbol":"XRPZAR","quoteCurrency":"ZAR","currencyPairType":"SPOT","active":false}]`,
+	}
+	var listing atomic.Int32
+	subscribes := make(chan streaming.SubscribeToMarketsRequest, 8)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/ws/") {
+			w.Write([]byte(listings[listing.Load()]))
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			subscribes <- req
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+
+	var listed []string
+	w := streaming.NewListingWatcher(c, refdata.New(cl), streaming.SpotPairsQuotedIn("ZAR"),
+		streaming.WithListingSubscription(func(sub *streaming.SubscriptionBuilder, pairs ...string) {
+			sub.Trades(pairs...).AggregatedBook(pairs...)
+		}),
+		streaming.WithListingCallback(func(pairs []valr.PairInfo) {
+			for _, p := range pairs {
+				listed = append(listed, p.Symbol)
+			}
+		}))
+
+	ctx := context.Background()
+	if _, err := w.Check(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	listing.Store(1)
+	added, err := w.Check(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(added) != 1 || added[0].Symbol != "SOLZAR" {
+		t.Errorf("Expected SOLZAR to be added, got %+v", added)
+	}
+	if got := strings.Join(listed, ","); got != "BTCZAR,SOLZAR" {
+		t.Errorf("Expected BTCZAR then SOLZAR, got %s", got)
+	}
+	if got := strings.Join(w.Pairs(), ","); got != "BTCZAR,SOLZAR" {
+		t.Errorf("Expected BTCZAR and SOLZAR to be watched, got %s", got)
+	}
+
+	// Both events are sent for BTCZAR, then for both pairs.
+	var got []string
+	for len(subscribes) > 0 {
+		req := <-subscribes
+		for _, s := range req.Subscriptions {
+			got = append(got, s.Event+":"+strings.Join(s.Pairs, ","))
+		}
+	}
+	want := "NEW_TRADE:BTCZAR AGGREGATED_ORDERBOOK_UPDATE:BTCZAR " +
+		"NEW_TRADE:BTCZAR,SOLZAR AGGREGATED_ORDERBOOK_UPDATE:BTCZAR,SOLZAR"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, " "))
+	}
+}
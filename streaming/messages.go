@@ -1,6 +1,9 @@
 package streaming
 
-import "time"
+import (
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
 
 type MessageType struct {
 	Type string `json:"type"`
@@ -8,14 +11,16 @@ type MessageType struct {
 
 type MessageTradeUpdate struct {
 	MessageType
+	RawFields
+	Received
 	CurrencyPairSymbol string `json:"currencyPairSymbol"`
 	Data               struct {
-		Price        string    `json:"price"`
-		Quantity     string    `json:"quantity"`
-		CurrencyPair string    `json:"currencyPair"`
-		TradedAt     time.Time `json:"tradedAt"`
-		TakerSide    string    `json:"takerSide"`
-		ID           string    `json:"id"`
+		Price        decimal.Decimal `json:"price"`
+		Quantity     decimal.Decimal `json:"quantity"`
+		CurrencyPair string          `json:"currencyPair"`
+		TradedAt     valr.Timestamp  `json:"tradedAt"`
+		TakerSide    string          `json:"takerSide"`
+		ID           string          `json:"id"`
 	} `json:"data"`
 }
 
@@ -28,3 +33,171 @@ type SubscribeToMarketsRequest struct {
 	Type          string          `json:"type"`
 	Subscriptions []Subscriptions `json:"subscriptions"`
 }
+
+// AccountTrade is a fill on one of the account's orders.
+type AccountTrade struct {
+	Price        decimal.Decimal   `json:"price"`
+	Quantity     decimal.Decimal   `json:"quantity"`
+	CurrencyPair string            `json:"currencyPair"`
+	TradedAt     valr.Timestamp    `json:"tradedAt"`
+	Side         valr.ResponseSide `json:"side"`
+	OrderID      string            `json:"orderId"`
+	ID           string            `json:"id"`
+}
+
+// MessageAccountTrade is a NEW_ACCOUNT_TRADE message from the account stream.
+type MessageAccountTrade struct {
+	MessageType
+	RawFields
+	Received
+	CurrencyPairSymbol string       `json:"currencyPairSymbol"`
+	Data               AccountTrade `json:"data"`
+}
+
+// MessageOrderStatusUpdate is an ORDER_STATUS_UPDATE message from the
+// account stream.
+type MessageOrderStatusUpdate struct {
+	MessageType
+	RawFields
+	Received
+	Data valr.OrderStatus `json:"data"`
+}
+
+// BalanceUpdate is the new balance of a single currency.
+type BalanceUpdate struct {
+	Currency struct {
+		Symbol    string `json:"symbol"`
+		ShortName string `json:"shortName"`
+		LongName  string `json:"longName"`
+	} `json:"currency"`
+	Available decimal.Decimal `json:"available"`
+	Reserved  decimal.Decimal `json:"reserved"`
+	Total     decimal.Decimal `json:"total"`
+	UpdatedAt valr.Timestamp  `json:"updatedAt"`
+}
+
+// MessageBalanceUpdate is a BALANCE_UPDATE message from the account stream.
+type MessageBalanceUpdate struct {
+	MessageType
+	RawFields
+	Received
+	// Snapshot is true for balances fetched over REST on connecting, see
+	// WithAccountSnapshot.
+	Snapshot bool          `json:"-"`
+	Data     BalanceUpdate `json:"data"`
+}
+
+// OpenOrder is an open order as listed by the account stream.
+type OpenOrder struct {
+	OrderID           string            `json:"orderId"`
+	Side              valr.ResponseSide `json:"side"`
+	Quantity          decimal.Decimal   `json:"quantity"`
+	Price             decimal.Decimal   `json:"price"`
+	CurrencyPair      string            `json:"currencyPair"`
+	CreatedAt         valr.Timestamp    `json:"createdAt"`
+	OriginalQuantity  decimal.Decimal   `json:"originalQuantity"`
+	FilledPercentage  decimal.Decimal   `json:"filledPercentage"`
+	CustomerOrderID   string            `json:"customerOrderId"`
+	Type              string            `json:"type"`
+	Status            string            `json:"status"`
+	UpdatedAt         valr.Timestamp    `json:"updatedAt"`
+	RemainingQuantity decimal.Decimal   `json:"remainingQuantity"`
+}
+
+// MessageOpenOrdersUpdate is an OPEN_ORDERS_UPDATE message from the account
+// stream, listing all of the account's open orders.
+type MessageOpenOrdersUpdate struct {
+	MessageType
+	RawFields
+	Received
+	// Snapshot is true for open orders fetched over REST on connecting,
+	// see WithAccountSnapshot.
+	Snapshot bool        `json:"-"`
+	Data     []OpenOrder `json:"data"`
+}
+
+// OrderProcessed reports whether an order was accepted by the matching
+// engine.
+type OrderProcessed struct {
+	OrderID       string `json:"orderId"`
+	Success       bool   `json:"success"`
+	FailureReason string `json:"failureReason"`
+}
+
+// MessageOrderProcessed is an ORDER_PROCESSED message from the account
+// stream.
+type MessageOrderProcessed struct {
+	MessageType
+	RawFields
+	Received
+	Data OrderProcessed `json:"data"`
+}
+
+// FailedCancelOrder reports an order that could not be cancelled.
+type FailedCancelOrder struct {
+	OrderID string `json:"orderId"`
+	Message string `json:"message"`
+}
+
+// MessageFailedCancelOrder is a FAILED_CANCEL_ORDER message from the account
+// stream.
+type MessageFailedCancelOrder struct {
+	MessageType
+	RawFields
+	Received
+	Data FailedCancelOrder `json:"data"`
+}
+
+// AggregatedLevel is a price level of an AGGREGATED_ORDERBOOK_UPDATE.
+type AggregatedLevel struct {
+	Side         string          `json:"side"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Price        decimal.Decimal `json:"price"`
+	CurrencyPair string          `json:"currencyPair"`
+	OrderCount   int             `json:"orderCount"`
+}
+
+// MessageAggregatedOrderBook is an AGGREGATED_ORDERBOOK_UPDATE message,
+// holding the top levels of a pair's book.
+type MessageAggregatedOrderBook struct {
+	MessageType
+	RawFields
+	Received
+	CurrencyPairSymbol string `json:"currencyPairSymbol"`
+	Data               struct {
+		Asks           []AggregatedLevel `json:"Asks"`
+		Bids           []AggregatedLevel `json:"Bids"`
+		LastChange     valr.Timestamp    `json:"LastChange"`
+		SequenceNumber int64             `json:"SequenceNumber"`
+		Checksum       int64             `json:"Checksum"`
+	} `json:"data"`
+}
+
+// FullOrderBookOrder is a single order at a price level of the full book.
+type FullOrderBookOrder struct {
+	OrderID  string          `json:"orderId"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// FullOrderBookLevel is a price level of the full book with its orders.
+type FullOrderBookLevel struct {
+	Price  decimal.Decimal      `json:"Price"`
+	Orders []FullOrderBookOrder `json:"Orders"`
+}
+
+// MessageFullOrderBook is a FULL_ORDERBOOK_SNAPSHOT or FULL_ORDERBOOK_UPDATE
+// message. In an update each level lists all remaining orders at its price;
+// a level without orders has been removed.
+type MessageFullOrderBook struct {
+	MessageType
+	RawFields
+	Received
+	CurrencyPairSymbol string `json:"currencyPairSymbol"`
+	Data               struct {
+		Asks           []FullOrderBookLevel `json:"Asks"`
+		Bids           []FullOrderBookLevel `json:"Bids"`
+		LastChange     valr.Timestamp       `json:"LastChange"`
+		SequenceNumber int64                `json:"SequenceNumber"`
+		Checksum       int64                `json:"Checksum"`
+	} `json:"data"`
+}
@@ -1,7 +1,11 @@
 package streaming
 
 import (
+	"math/rand"
+	"strings"
 	"time"
+
+	"github.com/donohutcheon/valr-go"
 )
 
 type DialOption func(*Conn)
@@ -32,3 +36,54 @@ func WithBackoffHandler(fn BackoffHandler, attemptReset time.Duration) DialOptio
 		c.attemptReset = attemptReset
 	}
 }
+
+// WithRand jitters the default reconnect backoff with rnd rather than the
+// global source, e.g. a clock.NewRand to replay a run deterministically.
+func WithRand(rnd *rand.Rand) DialOption {
+	return func(c *Conn) {
+		c.rnd = rnd
+	}
+}
+
+// WithEnvironment connects to the streaming API of env rather than
+// production.
+func WithEnvironment(env valr.Environment) DialOption {
+	return func(c *Conn) {
+		c.env = env
+	}
+}
+
+// WithBaseURL connects to the streaming API at baseURL, e.g.
+// "ws://localhost:8080", instead of the environment's websocket URL.
+func WithBaseURL(baseURL string) DialOption {
+	return func(c *Conn) {
+		c.env.WebSocketURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithTradePath overrides the path of the trade stream, "/ws/trade" by
+// default.
+func WithTradePath(path string) DialOption {
+	return func(c *Conn) {
+		c.tradePath = path
+	}
+}
+
+// WithAccountPath overrides the path of the account stream, "/ws/account" by
+// default.
+func WithAccountPath(path string) DialOption {
+	return func(c *Conn) {
+		c.accountPath = path
+	}
+}
+
+// WithPingInterval sets how often the connection pings the server, 30
+// seconds by default. Both a websocket ping and a JSON PING message are sent.
+// Non-positive intervals are ignored.
+func WithPingInterval(d time.Duration) DialOption {
+	return func(c *Conn) {
+		if d > 0 {
+			c.pingInterval = d
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultStaleAfter = time.Minute
+	// checksumDepth is the number of levels per side included in the book
+	// checksum.
+	checksumDepth = 25
+)
+
+var (
+	ErrSequenceGap      = errors.New("sequence gap")
+	ErrCrossedBook      = errors.New("crossed book")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrStaleBook        = errors.New("stale book")
+	ErrBookNotSynced    = errors.New("book not synced")
+)
+
+// IntegrityError is reported when the local order book is detected to have
+// diverged from the exchange.
+type IntegrityError struct {
+	Pair   string
+	Err    error
+	Detail string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("streaming: %s order book integrity: %v (%s)", e.Pair, e.Err, e.Detail)
+}
+
+func (e *IntegrityError) Unwrap() error {
+	return e.Err
+}
+
+// Level is an aggregated price level in an order book.
+type Level struct {
+	Price      decimal.Decimal
+	Quantity   decimal.Decimal
+	OrderCount int
+}
+
+// BookUpdate is a full snapshot of, or a delta to, an order book. In a delta
+// each level replaces the level at the same price; a zero quantity removes
+// the level.
+type BookUpdate struct {
+	Sequence int64
+	Bids     []Level
+	Asks     []Level
+	// Checksum of the book after the update is applied, if provided by the
+	// exchange.
+	Checksum uint32
+	Time     time.Time
+}
+
+// BookSnapshot is a consistent copy of an order book. Bids are sorted by
+// price descending and asks by price ascending.
+type BookSnapshot struct {
+	Pair      string
+	Sequence  int64
+	Bids      []Level
+	Asks      []Level
+	UpdatedAt time.Time
+}
+
+type (
+	// ResyncFunc fetches a full snapshot of the book for pair.
+	ResyncFunc func(ctx context.Context, pair string) (*BookUpdate, error)
+	// IntegrityCallback is called whenever an integrity check fails.
+	IntegrityCallback func(*IntegrityError)
+)
+
+type BookOption func(*OrderBook)
+
+// notifyTimeout bounds the delivery of an integrity notification.
+const notifyTimeout = 30 * time.Second
+
+// WithResync sets the function used to fetch a fresh snapshot when the
+// book's integrity is violated.
+func WithResync(fn ResyncFunc) BookOption {
+	return func(b *OrderBook) {
+		b.resync = fn
+	}
+}
+
+// WithStaleAfter sets how long the book may go without updates before it is
+// considered stale and resynchronised.
+func WithStaleAfter(d time.Duration) BookOption {
+	return func(b *OrderBook) {
+		b.staleAfter = d
+	}
+}
+
+// WithChecksumValidation enables verification of exchange provided
+// checksums after each update.
+func WithChecksumValidation() BookOption {
+	return func(b *OrderBook) {
+		b.checksums = true
+	}
+}
+
+// WithIntegrityCallback registers a callback for integrity violations.
+func WithIntegrityCallback(fn IntegrityCallback) BookOption {
+	return func(b *OrderBook) {
+		b.integrityCallback = fn
+	}
+}
+
+// WithIntegrityNotifier delivers a warning of each integrity violation to
+// s, without blocking the processing of updates.
+func WithIntegrityNotifier(s notify.Sink) BookOption {
+	return func(b *OrderBook) {
+		b.notifier = s
+	}
+}
+
+// OrderBook is a locally maintained order book for a single pair, built from
+// a snapshot and subsequent deltas. Sequence continuity, crossed books,
+// checksums and staleness are checked as updates arrive, and a fresh snapshot
+// is fetched when corruption is detected.
+type OrderBook struct {
+	pair              string
+	resync            ResyncFunc
+	staleAfter        time.Duration
+	checksums         bool
+	integrityCallback IntegrityCallback
+	notifier          notify.Sink
+	recorder          *BookRecorder
+
+	listenerMu sync.Mutex
+	listeners  map[int]func(*OrderBook)
+	nextID     int
+
+	mu        sync.RWMutex
+	bids      map[string]Level
+	asks      map[string]Level
+	sequence  int64
+	synced    bool
+	updatedAt time.Time
+}
+
+// NewOrderBook creates an empty, unsynced book for pair.
+func NewOrderBook(pair string, opts ...BookOption) *OrderBook {
+	b := &OrderBook{
+		pair:       pair,
+		staleAfter: defaultStaleAfter,
+		bids:       make(map[string]Level),
+		asks:       make(map[string]Level),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// RESTResync returns a ResyncFunc that fetches the full order book from the
+// REST API.
+func RESTResync(cl *valr.Client) ResyncFunc {
+	return func(ctx context.Context, pair string) (*BookUpdate, error) {
+		ob, err := cl.GetAuthFullOrderBookRequest(ctx, &valr.GetAuthFullOrderBookRequest{Pair: pair})
+		if err != nil {
+			return nil, err
+		}
+		return &BookUpdate{
+			Sequence: ob.SequenceNumber,
+			Bids:     levelsFromEntries(ob.Bids),
+			Asks:     levelsFromEntries(ob.Asks),
+			Time:     ob.LastChange.Time,
+		}, nil
+	}
+}
+
+// levelsFromEntries aggregates REST order book entries by price.
+func levelsFromEntries(entries []valr.OrderBookEntry) []Level {
+	var levels []Level
+	index := make(map[string]int)
+	for _, e := range entries {
+		key := e.Price.String()
+		count := e.OrderCount
+		if count == 0 {
+			count = 1
+		}
+		if i, ok := index[key]; ok {
+			levels[i].Quantity = levels[i].Quantity.Add(e.Quantity)
+			levels[i].OrderCount += count
+			continue
+		}
+		index[key] = len(levels)
+		levels = append(levels, Level{Price: e.Price, Quantity: e.Quantity, OrderCount: count})
+	}
+	return levels
+}
+
+// Pair returns the pair of the book.
+func (b *OrderBook) Pair() string {
+	return b.pair
+}
+
+// Synced returns true if the book holds a valid snapshot.
+func (b *OrderBook) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+// ApplySnapshot replaces the contents of the book.
+func (b *OrderBook) ApplySnapshot(u BookUpdate) {
+	b.mu.Lock()
+	b.bids = make(map[string]Level, len(u.Bids))
+	b.asks = make(map[string]Level, len(u.Asks))
+	applyLevels(b.bids, u.Bids)
+	applyLevels(b.asks, u.Asks)
+	b.sequence = u.Sequence
+	b.synced = true
+	b.updatedAt = updateTime(u)
+	b.mu.Unlock()
+
+	if b.recorder != nil {
+		b.recorder.recordSnapshot(b, u)
+	}
+	b.notifyListeners()
+}
+
+// ApplyUpdate applies a delta to the book. Deltas older than the book are
+// ignored. If the delta reveals corruption the book is resynchronised and
+// the IntegrityError is returned.
+func (b *OrderBook) ApplyUpdate(ctx context.Context, u BookUpdate) error {
+	ierr := b.applyDelta(u)
+	if ierr == nil {
+		return nil
+	}
+	b.reportIntegrity(ierr)
+	if err := b.Resync(ctx); err != nil {
+		return errors.Join(ierr, err)
+	}
+	return ierr
+}
+
+// applyDelta applies a delta and notifies the recorder and listeners of
+// the change. It leaves resynchronisation to the caller.
+func (b *OrderBook) applyDelta(u BookUpdate) *IntegrityError {
+	if ierr := b.applyUpdate(u); ierr != nil {
+		return ierr
+	}
+	if b.recorder != nil {
+		b.recorder.recordUpdate(b, u)
+	}
+	b.notifyListeners()
+	return nil
+}
+
+func (b *OrderBook) applyUpdate(u BookUpdate) *IntegrityError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.synced {
+		return &IntegrityError{Pair: b.pair, Err: ErrBookNotSynced, Detail: "delta received before snapshot"}
+	}
+	if u.Sequence != 0 {
+		if u.Sequence <= b.sequence {
+			return nil
+		}
+		if u.Sequence != b.sequence+1 {
+			detail := fmt.Sprintf("expected %d, got %d", b.sequence+1, u.Sequence)
+			b.synced = false
+			return &IntegrityError{Pair: b.pair, Err: ErrSequenceGap, Detail: detail}
+		}
+		b.sequence = u.Sequence
+	}
+
+	applyLevels(b.bids, u.Bids)
+	applyLevels(b.asks, u.Asks)
+	b.updatedAt = updateTime(u)
+
+	bids, asks := sortedLevels(b.bids, b.asks)
+	if len(bids) > 0 && len(asks) > 0 && bids[0].Price.GreaterThanOrEqual(asks[0].Price) {
+		detail := fmt.Sprintf("best bid %s >= best ask %s", bids[0].Price, asks[0].Price)
+		b.synced = false
+		return &IntegrityError{Pair: b.pair, Err: ErrCrossedBook, Detail: detail}
+	}
+	if b.checksums && u.Checksum != 0 {
+		if sum := Checksum(bids, asks); sum != u.Checksum {
+			detail := fmt.Sprintf("expected %d, computed %d", u.Checksum, sum)
+			b.synced = false
+			return &IntegrityError{Pair: b.pair, Err: ErrChecksumMismatch, Detail: detail}
+		}
+	}
+	return nil
+}
+
+// invalidate marks the book unsynced until the next snapshot.
+func (b *OrderBook) invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.synced = false
+}
+
+// CheckStale returns an IntegrityError if the book has not been updated
+// within the stale period.
+func (b *OrderBook) CheckStale(now time.Time) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.synced || b.staleAfter <= 0 {
+		return nil
+	}
+	if age := now.Sub(b.updatedAt); age > b.staleAfter {
+		return &IntegrityError{Pair: b.pair, Err: ErrStaleBook, Detail: fmt.Sprintf("no update for %s", age)}
+	}
+	return nil
+}
+
+// Resync replaces the book with a fresh snapshot from the resync function.
+func (b *OrderBook) Resync(ctx context.Context) error {
+	if b.resync == nil {
+		return fmt.Errorf("streaming: no resync function configured for %s", b.pair)
+	}
+	u, err := b.resync(ctx, b.pair)
+	if err != nil {
+		return fmt.Errorf("streaming: failed to resync %s: %w", b.pair, err)
+	}
+	b.ApplySnapshot(*u)
+	log.Printf("valr/streaming: Resynced order book pair=%s sequence=%d", b.pair, u.Sequence)
+	return nil
+}
+
+// WatchStaleness periodically checks the book for staleness and resyncs it
+// when no updates have arrived within the stale period. It blocks until ctx
+// is cancelled.
+func (b *OrderBook) WatchStaleness(ctx context.Context) {
+	if b.staleAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(b.staleAfter / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			err := b.CheckStale(now)
+			var ierr *IntegrityError
+			if !errors.As(err, &ierr) {
+				continue
+			}
+			b.reportIntegrity(ierr)
+			if err := b.Resync(ctx); err != nil {
+				log.Printf("valr/streaming: %v", err)
+			}
+		}
+	}
+}
+
+// Snapshot returns a copy of the book.
+func (b *OrderBook) Snapshot() BookSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	bids, asks := sortedLevels(b.bids, b.asks)
+	return BookSnapshot{
+		Pair:      b.pair,
+		Sequence:  b.sequence,
+		Bids:      bids,
+		Asks:      asks,
+		UpdatedAt: b.updatedAt,
+	}
+}
+
+// TopN returns the best n levels of each side of the book.
+func (b *OrderBook) TopN(n int) (bids, asks []Level) {
+	snap := b.Snapshot()
+	return snap.Bids[:min(n, len(snap.Bids))], snap.Asks[:min(n, len(snap.Asks))]
+}
+
+// addListener registers fn to be called after every change to the book and
+// returns a function that removes it.
+func (b *OrderBook) addListener(fn func(*OrderBook)) func() {
+	b.listenerMu.Lock()
+	defer b.listenerMu.Unlock()
+
+	if b.listeners == nil {
+		b.listeners = make(map[int]func(*OrderBook))
+	}
+	id := b.nextID
+	b.nextID++
+	b.listeners[id] = fn
+
+	return func() {
+		b.listenerMu.Lock()
+		defer b.listenerMu.Unlock()
+		delete(b.listeners, id)
+	}
+}
+
+func (b *OrderBook) notifyListeners() {
+	b.listenerMu.Lock()
+	fns := make([]func(*OrderBook), 0, len(b.listeners))
+	for _, fn := range b.listeners {
+		fns = append(fns, fn)
+	}
+	b.listenerMu.Unlock()
+
+	for _, fn := range fns {
+		fn(b)
+	}
+}
+
+func (b *OrderBook) reportIntegrity(err *IntegrityError) {
+	log.Printf("valr/streaming: %v", err)
+	if b.integrityCallback != nil {
+		b.integrityCallback(err)
+	}
+	if b.notifier != nil {
+		notify.Send(b.notifier, notify.Message{
+			Level:  notify.Warning,
+			Source: "orderbook",
+			Title:  fmt.Sprintf("%s order book integrity: %v", b.pair, err.Err),
+			Text:   err.Detail,
+			Fields: []notify.Field{{Name: "pair", Value: b.pair}},
+		}, notifyTimeout)
+	}
+}
+
+// Checksum computes the CRC32 checksum of the top levels of a book in the
+// form "bidPrice:bidQty:askPrice:askQty:...", alternating sides.
+func Checksum(bids, asks []Level) uint32 {
+	var parts []string
+	for i := 0; i < checksumDepth; i++ {
+		if i < len(bids) {
+			parts = append(parts, bids[i].Price.String(), bids[i].Quantity.String())
+		}
+		if i < len(asks) {
+			parts = append(parts, asks[i].Price.String(), asks[i].Quantity.String())
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(strings.Join(parts, ":")))
+}
+
+func applyLevels(side map[string]Level, levels []Level) {
+	for _, l := range levels {
+		key := l.Price.String()
+		if l.Quantity.IsZero() {
+			delete(side, key)
+			continue
+		}
+		side[key] = l
+	}
+}
+
+func sortedLevels(bidMap, askMap map[string]Level) (bids, asks []Level) {
+	bids = make([]Level, 0, len(bidMap))
+	for _, l := range bidMap {
+		bids = append(bids, l)
+	}
+	asks = make([]Level, 0, len(askMap))
+	for _, l := range askMap {
+		asks = append(asks, l)
+	}
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price.GreaterThan(bids[j].Price) })
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price.LessThan(asks[j].Price) })
+	return bids, asks
+}
+
+func updateTime(u BookUpdate) time.Time {
+	if u.Time.IsZero() {
+		return time.Now()
+	}
+	return u.Time
+}
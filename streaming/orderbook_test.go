@@ -0,0 +1,70 @@
+package streaming_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func level(price, qty string) streaming.Level {
+	return streaming.Level{
+		Price:    decimal.RequireFromString(price),
+		Quantity: decimal.RequireFromString(qty),
+	}
+}
+
+func TestOrderBookApplyUpdate(t *testing.T) {
+	resyncs := 0
+	resync := func(ctx context.Context, pair string) (*streaming.BookUpdate, error) {
+		resyncs++
+		return &streaming.BookUpdate{
+			Sequence: 100,
+			Bids:     []streaming.Level{level("99", "1")},
+			Asks:     []streaming.Level{level("101", "1")},
+		}, nil
+	}
+
+	b := streaming.NewOrderBook("BTCZAR", streaming.WithResync(resync))
+	b.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1,
+		Bids:     []streaming.Level{level("99", "1"), level("98", "2")},
+		Asks:     []streaming.Level{level("101", "1")},
+	})
+
+	ctx := context.Background()
+	err := b.ApplyUpdate(ctx, streaming.BookUpdate{
+		Sequence: 2,
+		Bids:     []streaming.Level{level("99", "0"), level("100", "3")},
+	})
+	if err != nil {
+		t.Errorf("Expected success, got %v", err)
+		return
+	}
+
+	snap := b.Snapshot()
+	if len(snap.Bids) != 2 || !snap.Bids[0].Price.Equal(decimal.RequireFromString("100")) {
+		t.Errorf("Expected best bid 100, got %+v", snap.Bids)
+	}
+
+	err = b.ApplyUpdate(ctx, streaming.BookUpdate{Sequence: 4})
+	if !errors.Is(err, streaming.ErrSequenceGap) {
+		t.Errorf("Expected ErrSequenceGap, got %v", err)
+	}
+	if resyncs != 1 || b.Snapshot().Sequence != 100 {
+		t.Errorf("Expected book to be resynced, got %d resyncs", resyncs)
+	}
+
+	err = b.ApplyUpdate(ctx, streaming.BookUpdate{
+		Sequence: 101,
+		Bids:     []streaming.Level{level("102", "1")},
+	})
+	if !errors.Is(err, streaming.ErrCrossedBook) {
+		t.Errorf("Expected ErrCrossedBook, got %v", err)
+	}
+	if resyncs != 2 {
+		t.Errorf("Expected book to be resynced, got %d resyncs", resyncs)
+	}
+}
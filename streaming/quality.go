@@ -0,0 +1,383 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/shopspring/decimal"
+)
+
+// Data quality issues reported by a QualityMonitor.
+const (
+	// IssueCrossedBook is a book whose best bid is at or above its best ask.
+	IssueCrossedBook = "crossed_book"
+	// IssueStaleFeed is a pair without book updates or trades for longer
+	// than the stale period.
+	IssueStaleFeed = "stale_feed"
+	// IssueDuplicateTrade is a trade ID received more than once.
+	IssueDuplicateTrade = "duplicate_trade"
+	// IssuePriceGap is a trade price or book mid further from the previous
+	// one than the maximum gap.
+	IssuePriceGap = "price_gap"
+)
+
+const (
+	defaultMaxGapBps   = 500
+	defaultQualityHold = time.Minute
+	// recentTradeIDs is the number of trade IDs per pair remembered to
+	// detect duplicates.
+	recentTradeIDs = 1000
+)
+
+// QualityEvent reports a data quality issue on a pair.
+type QualityEvent struct {
+	Pair   string
+	Issue  string
+	Detail string
+	// Resolved is true once a crossed book or stale feed has recovered.
+	// Duplicate trades and price gaps are never resolved; they lapse after
+	// the hold period.
+	Resolved bool
+	Time     time.Time
+}
+
+// QualityCallback is notified of data quality events.
+type QualityCallback func(QualityEvent)
+
+type QualityOption func(*QualityMonitor)
+
+// WithQualityStaleAfter sets how long a pair may go without book updates or
+// trades before its feed is stale, one minute by default.
+func WithQualityStaleAfter(d time.Duration) QualityOption {
+	return func(m *QualityMonitor) {
+		m.staleAfter = d
+	}
+}
+
+// WithMaxGapBps sets the largest move, in basis points, between consecutive
+// trade prices or book mids that isn't reported as a gap, 500 by default.
+func WithMaxGapBps(bps decimal.Decimal) QualityOption {
+	return func(m *QualityMonitor) {
+		m.maxGapBps = bps
+	}
+}
+
+// WithQualityHold sets how long a duplicate trade or price gap keeps a pair
+// unhealthy, one minute by default.
+func WithQualityHold(d time.Duration) QualityOption {
+	return func(m *QualityMonitor) {
+		m.hold = d
+	}
+}
+
+// WithQualityCallback sets a callback notified of every event.
+func WithQualityCallback(fn QualityCallback) QualityOption {
+	return func(m *QualityMonitor) {
+		m.callback = fn
+	}
+}
+
+// WithQualityNotifier delivers a warning of each issue, and a message when
+// crossed books and stale feeds recover, to s.
+func WithQualityNotifier(s notify.Sink) QualityOption {
+	return func(m *QualityMonitor) {
+		m.notifier = s
+	}
+}
+
+type pairQuality struct {
+	lastActivity time.Time
+	lastMid      decimal.Decimal
+	lastTrade    decimal.Decimal
+	// tradeIDs holds recent trade IDs, with their order of arrival in
+	// tradeOrder so the oldest can be forgotten.
+	tradeIDs   map[string]struct{}
+	tradeOrder []string
+	// active holds the ongoing issues of the pair, and when one-off issues
+	// lapse.
+	active map[string]QualityEvent
+	until  map[string]time.Time
+}
+
+// QualityMonitor checks books and trades for signs of corrupted input:
+// crossed books, stale feeds, duplicate trade IDs and unusually large price
+// gaps. Strategies can stand down while Healthy reports false.
+//
+// Books are monitored with Watch, trades by passing ObserveTrade to
+// WithUpdateCallback, and staleness by Run.
+type QualityMonitor struct {
+	keeper     *BookKeeper
+	staleAfter time.Duration
+	maxGapBps  decimal.Decimal
+	hold       time.Duration
+	callback   QualityCallback
+	notifier   notify.Sink
+
+	mu      sync.Mutex
+	pairs   map[string]*pairQuality
+	removes map[string]func()
+}
+
+// NewQualityMonitor returns a monitor of the books in k, which may be nil
+// to monitor only trades.
+func NewQualityMonitor(k *BookKeeper, opts ...QualityOption) *QualityMonitor {
+	m := &QualityMonitor{
+		keeper:     k,
+		staleAfter: defaultStaleAfter,
+		maxGapBps:  decimal.New(defaultMaxGapBps, 0),
+		hold:       defaultQualityHold,
+		pairs:      make(map[string]*pairQuality),
+		removes:    make(map[string]func()),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Watch starts monitoring the book of pair, including its staleness.
+func (m *QualityMonitor) Watch(pair string) {
+	m.mu.Lock()
+	_, watching := m.removes[pair]
+	m.pair(pair)
+	m.mu.Unlock()
+	if watching || m.keeper == nil {
+		return
+	}
+	remove := m.keeper.Book(pair).addListener(func(b *OrderBook) {
+		m.ObserveBook(b.Snapshot())
+	})
+	m.mu.Lock()
+	m.removes[pair] = remove
+	m.mu.Unlock()
+}
+
+// pair returns the state of pair, creating it if needed. It must be called
+// with m.mu held.
+func (m *QualityMonitor) pair(pair string) *pairQuality {
+	pq, ok := m.pairs[pair]
+	if !ok {
+		pq = &pairQuality{
+			lastActivity: time.Now(),
+			tradeIDs:     make(map[string]struct{}),
+			active:       make(map[string]QualityEvent),
+			until:        make(map[string]time.Time),
+		}
+		m.pairs[pair] = pq
+	}
+	return pq
+}
+
+// ObserveBook checks a snapshot of a book.
+func (m *QualityMonitor) ObserveBook(snap BookSnapshot) {
+	now := time.Now()
+	var events []QualityEvent
+
+	m.mu.Lock()
+	pq := m.pair(snap.Pair)
+	pq.lastActivity = now
+	events = m.resolve(pq, snap.Pair, IssueStaleFeed, now, events)
+	if len(snap.Bids) > 0 && len(snap.Asks) > 0 {
+		bid, ask := snap.Bids[0].Price, snap.Asks[0].Price
+		if bid.GreaterThanOrEqual(ask) {
+			events = m.raise(pq, QualityEvent{
+				Pair:   snap.Pair,
+				Issue:  IssueCrossedBook,
+				Detail: fmt.Sprintf("best bid %s >= best ask %s", bid, ask),
+				Time:   now,
+			}, events)
+		} else {
+			events = m.resolve(pq, snap.Pair, IssueCrossedBook, now, events)
+			mid := bid.Add(ask).Div(decimal.New(2, 0))
+			if detail, ok := m.gap(pq.lastMid, mid, "mid"); ok {
+				events = m.raise(pq, QualityEvent{Pair: snap.Pair, Issue: IssuePriceGap, Detail: detail, Time: now}, events)
+			}
+			pq.lastMid = mid
+		}
+	}
+	m.mu.Unlock()
+
+	m.emit(events)
+}
+
+// ObserveTrade checks a trade. It can be passed to WithUpdateCallback.
+func (m *QualityMonitor) ObserveTrade(t MessageTradeUpdate) {
+	pair := t.CurrencyPairSymbol
+	if pair == "" {
+		pair = t.Data.CurrencyPair
+	}
+	now := time.Now()
+	var events []QualityEvent
+
+	m.mu.Lock()
+	pq := m.pair(pair)
+	pq.lastActivity = now
+	events = m.resolve(pq, pair, IssueStaleFeed, now, events)
+	if id := t.Data.ID; id != "" {
+		if _, seen := pq.tradeIDs[id]; seen {
+			events = m.raise(pq, QualityEvent{Pair: pair, Issue: IssueDuplicateTrade, Detail: "trade " + id, Time: now}, events)
+		} else {
+			pq.tradeIDs[id] = struct{}{}
+			pq.tradeOrder = append(pq.tradeOrder, id)
+			if len(pq.tradeOrder) > recentTradeIDs {
+				delete(pq.tradeIDs, pq.tradeOrder[0])
+				pq.tradeOrder = pq.tradeOrder[1:]
+			}
+		}
+	}
+	if detail, ok := m.gap(pq.lastTrade, t.Data.Price, "trade price"); ok {
+		events = m.raise(pq, QualityEvent{Pair: pair, Issue: IssuePriceGap, Detail: detail, Time: now}, events)
+	}
+	if t.Data.Price.IsPositive() {
+		pq.lastTrade = t.Data.Price
+	}
+	m.mu.Unlock()
+
+	m.emit(events)
+}
+
+// gap reports whether price moved more than the maximum gap from prev.
+func (m *QualityMonitor) gap(prev, price decimal.Decimal, what string) (string, bool) {
+	if !prev.IsPositive() || !price.IsPositive() {
+		return "", false
+	}
+	bps := price.Sub(prev).Abs().Mul(tenThousand).DivRound(prev, 2)
+	if bps.LessThanOrEqual(m.maxGapBps) {
+		return "", false
+	}
+	return fmt.Sprintf("%s moved %s bps from %s to %s", what, bps, prev, price), true
+}
+
+// raise records ev as active, appending it to events unless an ongoing
+// issue of the same kind was already reported. It must be called with m.mu
+// held.
+func (m *QualityMonitor) raise(pq *pairQuality, ev QualityEvent, events []QualityEvent) []QualityEvent {
+	_, ongoing := pq.active[ev.Issue]
+	pq.active[ev.Issue] = ev
+	switch ev.Issue {
+	case IssueDuplicateTrade, IssuePriceGap:
+		pq.until[ev.Issue] = ev.Time.Add(m.hold)
+	case IssueCrossedBook, IssueStaleFeed:
+		if ongoing {
+			return events
+		}
+	}
+	return append(events, ev)
+}
+
+// resolve clears an ongoing issue, appending a resolved event to events.
+// It must be called with m.mu held.
+func (m *QualityMonitor) resolve(pq *pairQuality, pair, issue string, now time.Time, events []QualityEvent) []QualityEvent {
+	if _, ok := pq.active[issue]; !ok {
+		return events
+	}
+	delete(pq.active, issue)
+	return append(events, QualityEvent{Pair: pair, Issue: issue, Resolved: true, Time: now})
+}
+
+func (m *QualityMonitor) emit(events []QualityEvent) {
+	for _, ev := range events {
+		if m.callback != nil {
+			m.callback(ev)
+		}
+		if m.notifier != nil {
+			notify.Send(m.notifier, qualityMessage(ev), notifyTimeout)
+		}
+	}
+}
+
+func qualityMessage(ev QualityEvent) notify.Message {
+	msg := notify.Message{
+		Level:  notify.Warning,
+		Source: "quality",
+		Title:  fmt.Sprintf("Data quality issue on %s: %s", ev.Pair, ev.Issue),
+		Text:   ev.Detail,
+		Fields: []notify.Field{{Name: "pair", Value: ev.Pair}},
+		Time:   ev.Time,
+	}
+	if ev.Resolved {
+		msg.Level = notify.Info
+		msg.Title = fmt.Sprintf("Data quality issue on %s resolved: %s", ev.Pair, ev.Issue)
+	}
+	return msg
+}
+
+// CheckStale reports the feeds of pairs without activity within the stale
+// period as stale.
+func (m *QualityMonitor) CheckStale() {
+	now := time.Now()
+	var events []QualityEvent
+
+	m.mu.Lock()
+	for pair, pq := range m.pairs {
+		if age := now.Sub(pq.lastActivity); m.staleAfter > 0 && age > m.staleAfter {
+			events = m.raise(pq, QualityEvent{
+				Pair:   pair,
+				Issue:  IssueStaleFeed,
+				Detail: fmt.Sprintf("no updates for %s", age.Round(time.Second)),
+				Time:   now,
+			}, events)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Pair < events[j].Pair })
+	m.emit(events)
+}
+
+// Run checks for stale feeds every interval until ctx is done.
+func (m *QualityMonitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.CheckStale()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Issues returns the ongoing issues of pair, sorted by kind. Duplicate
+// trades and price gaps are included until their hold period lapses.
+func (m *QualityMonitor) Issues(pair string) []QualityEvent {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pq, ok := m.pairs[pair]
+	if !ok {
+		return nil
+	}
+	var out []QualityEvent
+	for issue, ev := range pq.active {
+		if until, ok := pq.until[issue]; ok && !now.Before(until) {
+			delete(pq.active, issue)
+			delete(pq.until, issue)
+			continue
+		}
+		out = append(out, ev)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Issue < out[j].Issue })
+	return out
+}
+
+// Healthy returns true if pair has no ongoing issues.
+func (m *QualityMonitor) Healthy(pair string) bool {
+	return len(m.Issues(pair)) == 0
+}
+
+// Close stops monitoring all books.
+func (m *QualityMonitor) Close() {
+	m.mu.Lock()
+	removes := m.removes
+	m.removes = make(map[string]func())
+	m.mu.Unlock()
+	for _, remove := range removes {
+		remove()
+	}
+}
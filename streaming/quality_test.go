@@ -0,0 +1,117 @@
+package streaming_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func trade(pair, id, price string) streaming.MessageTradeUpdate {
+	var t streaming.MessageTradeUpdate
+	t.CurrencyPairSymbol = pair
+	t.Data.ID = id
+	t.Data.Price = decimal.RequireFromString(price)
+	t.Data.Quantity = decimal.New(1, 0)
+	return t
+}
+
+func TestQualityMonitorBooks(t *testing.T) {
+	var events []streaming.QualityEvent
+	k := streaming.NewBookKeeper()
+	m := streaming.NewQualityMonitor(k, streaming.WithQualityCallback(func(ev streaming.QualityEvent) {
+		events = append(events, ev)
+	}))
+	defer m.Close()
+	m.Watch("BTCZAR")
+
+	book := k.Book("BTCZAR")
+	book.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 1,
+		Bids:     []streaming.Level{level("100", "1")},
+		Asks:     []streaming.Level{level("99", "1")},
+	})
+	if m.Healthy("BTCZAR") {
+		t.Error("Expected a crossed book to be unhealthy")
+	}
+	book.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 2,
+		Bids:     []streaming.Level{level("99", "1")},
+		Asks:     []streaming.Level{level("101", "1")},
+	})
+	if !m.Healthy("BTCZAR") {
+		t.Errorf("Expected the book to recover, got %+v", m.Issues("BTCZAR"))
+	}
+	// The mid jumps from 100 to 120, a 2000 bps gap.
+	book.ApplySnapshot(streaming.BookUpdate{
+		Sequence: 3,
+		Bids:     []streaming.Level{level("119", "1")},
+		Asks:     []streaming.Level{level("121", "1")},
+	})
+
+	var got []string
+	for _, ev := range events {
+		s := ev.Issue
+		if ev.Resolved {
+			s += " resolved"
+		}
+		got = append(got, s)
+	}
+	want := []string{"crossed_book", "crossed_book resolved", "price_gap"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want, got)
+			break
+		}
+	}
+	if issues := m.Issues("BTCZAR"); len(issues) != 1 || issues[0].Issue != streaming.IssuePriceGap {
+		t.Errorf("Expected an ongoing price gap, got %+v", issues)
+	}
+}
+
+func TestQualityMonitorTrades(t *testing.T) {
+	m := streaming.NewQualityMonitor(nil, streaming.WithQualityHold(50*time.Millisecond))
+	m.ObserveTrade(trade("BTCZAR", "t1", "100"))
+	m.ObserveTrade(trade("BTCZAR", "t2", "101"))
+	if !m.Healthy("BTCZAR") {
+		t.Fatalf("Expected healthy, got %+v", m.Issues("BTCZAR"))
+	}
+	m.ObserveTrade(trade("BTCZAR", "t1", "100"))
+	issues := m.Issues("BTCZAR")
+	if len(issues) != 1 || issues[0].Issue != streaming.IssueDuplicateTrade {
+		t.Errorf("Expected a duplicate trade, got %+v", issues)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !m.Healthy("BTCZAR") {
+		t.Errorf("Expected the duplicate to lapse, got %+v", m.Issues("BTCZAR"))
+	}
+}
+
+func TestQualityMonitorStale(t *testing.T) {
+	var events []streaming.QualityEvent
+	m := streaming.NewQualityMonitor(nil,
+		streaming.WithQualityStaleAfter(20*time.Millisecond),
+		streaming.WithQualityCallback(func(ev streaming.QualityEvent) {
+			events = append(events, ev)
+		}))
+	m.ObserveTrade(trade("BTCZAR", "t1", "100"))
+
+	time.Sleep(30 * time.Millisecond)
+	m.CheckStale()
+	m.CheckStale()
+	if m.Healthy("BTCZAR") {
+		t.Error("Expected a stale feed to be unhealthy")
+	}
+	m.ObserveTrade(trade("BTCZAR", "t2", "100"))
+	if !m.Healthy("BTCZAR") {
+		t.Errorf("Expected the feed to recover, got %+v", m.Issues("BTCZAR"))
+	}
+	if len(events) != 2 || events[0].Issue != streaming.IssueStaleFeed || !events[1].Resolved {
+		t.Errorf("Expected a stale feed reported once then resolved, got %+v", events)
+	}
+}
@@ -0,0 +1,116 @@
+package streaming
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// RawFields holds the fields of a message that its type does not declare.
+// It is only populated when the connection is dialled WithRawFields, giving
+// access to fields VALR adds before the message types are updated.
+type RawFields struct {
+	// Raw maps each unknown field to its raw JSON value. Unknown fields of
+	// the message's "data" object are keyed "data.<name>".
+	Raw map[string]json.RawMessage `json:"-"`
+}
+
+func (r *RawFields) setRaw(raw map[string]json.RawMessage) {
+	r.Raw = raw
+}
+
+// WithRawFields captures undeclared fields of received messages in their
+// Raw map.
+func WithRawFields() DialOption {
+	return func(c *Conn) {
+		c.rawFields = true
+	}
+}
+
+// decode unmarshals a frame into v, stamping it with its receipt and
+// capturing unknown fields if enabled.
+func (c *Conn) decode(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	c.received(v)
+	if !c.rawFields {
+		return nil
+	}
+	rs, ok := v.(interface {
+		setRaw(map[string]json.RawMessage)
+	})
+	if !ok {
+		return nil
+	}
+	raw, err := unknownFields(data, reflect.TypeOf(v).Elem())
+	if err != nil {
+		return err
+	}
+	rs.setRaw(raw)
+	return nil
+}
+
+// unknownFields returns the fields of the JSON object in data, and of its
+// "data" object, that t does not declare.
+func unknownFields(data []byte, t reflect.Type) (map[string]json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	known := knownFields(t)
+
+	raw := make(map[string]json.RawMessage)
+	for k, v := range obj {
+		f, ok := known[strings.ToLower(k)]
+		if !ok {
+			raw[k] = v
+			continue
+		}
+		if strings.ToLower(k) != "data" || f.Kind() != reflect.Struct {
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(v, &nested) != nil {
+			continue
+		}
+		nestedKnown := knownFields(f)
+		for nk, nv := range nested {
+			if _, ok := nestedKnown[strings.ToLower(nk)]; !ok {
+				raw["data."+nk] = nv
+			}
+		}
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// knownFields maps the lower cased JSON names of the fields of struct type t
+// to their types, following the rules of encoding/json for embedded structs.
+func knownFields(t reflect.Type) map[string]reflect.Type {
+	known := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range knownFields(f.Type) {
+				known[k] = v
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		known[strings.ToLower(name)] = f.Type
+	}
+	return known
+}
@@ -0,0 +1,36 @@
+package streaming_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func TestRawFields(t *testing.T) {
+	journal := `{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","venue":"main","data":{"price":"100","quantity":"1","tradeType":"SPOT"}}}`
+
+	var got streaming.MessageTradeUpdate
+	err := streaming.Replay(context.Background(), strings.NewReader(journal),
+		streaming.WithRawFields(),
+		streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) { got = m }),
+	)
+	if err != nil {
+		t.Errorf("Expected success, got %v", err)
+		return
+	}
+
+	if len(got.Raw) != 2 {
+		t.Errorf("Expected 2 unknown fields, got %v", got.Raw)
+	}
+	if string(got.Raw["venue"]) != `"main"` {
+		t.Errorf("Expected %q, got %q", `"main"`, got.Raw["venue"])
+	}
+	if string(got.Raw["data.tradeType"]) != `"SPOT"` {
+		t.Errorf("Expected %q, got %q", `"SPOT"`, got.Raw["data.tradeType"])
+	}
+	if got.Data.Price.String() != "100" {
+		t.Errorf("Expected %q, got %q", "100", got.Data.Price)
+	}
+}
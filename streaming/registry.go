@@ -0,0 +1,222 @@
+package streaming
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ErrRegistryClosed is returned for feeds requested from a closed
+// Registry.
+var ErrRegistryClosed = errors.New("streaming: registry closed")
+
+var (
+	registriesMu sync.Mutex
+	registries   = make(map[string]*Registry)
+)
+
+// Shared returns the process-wide registry of the API key keyID, creating
+// it on first use. Independent modules asking for feeds of the same pair
+// through it share one trade stream connection, subscription and book
+// keeper instead of each opening their own. The connection is only dialled,
+// with opts, when the first feed is requested; opts passed by later callers
+// are ignored.
+func Shared(keyID, keySecret string, opts ...DialOption) *Registry {
+	registriesMu.Lock()
+	defer registriesMu.Unlock()
+	if r, ok := registries[keyID]; ok {
+		return r
+	}
+	r := NewRegistry(func(opts ...DialOption) (*Conn, error) {
+		return Dial(keyID, keySecret, opts...)
+	}, opts...)
+	r.onClose = func() {
+		registriesMu.Lock()
+		defer registriesMu.Unlock()
+		if registries[keyID] == r {
+			delete(registries, keyID)
+		}
+	}
+	registries[keyID] = r
+	return r
+}
+
+// Registry lazily dials a trade stream connection and shares its trade
+// and order book subscriptions among any number of feeds. Its methods are
+// safe for concurrent use.
+type Registry struct {
+	dial    func(...DialOption) (*Conn, error)
+	opts    []DialOption
+	onClose func()
+
+	mu     sync.Mutex
+	conn   *Conn
+	hub    *Hub
+	books  *BookKeeper
+	pairs  map[string]int // book feeds by pair
+	closed bool
+
+	// subMu orders the order book subscriptions, which replace each other.
+	subMu sync.Mutex
+}
+
+// NewRegistry returns a registry dialling its connection with dial, passing
+// opts followed by the options the registry needs. A connect callback in
+// opts is replaced by one renewing the trade subscriptions.
+func NewRegistry(dial func(...DialOption) (*Conn, error), opts ...DialOption) *Registry {
+	return &Registry{
+		dial:  dial,
+		opts:  opts,
+		hub:   NewHub(),
+		books: NewBookKeeper(),
+		pairs: make(map[string]int),
+	}
+}
+
+// Conn returns the shared connection, dialling it if needed.
+func (r *Registry) Conn() (*Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connLocked()
+}
+
+func (r *Registry) connLocked() (*Conn, error) {
+	if r.closed {
+		return nil, ErrRegistryClosed
+	}
+	if r.conn != nil {
+		return r.conn, nil
+	}
+	opts := append(slices.Clone(r.opts),
+		WithHub(r.hub),
+		WithBookKeeper(r.books),
+		WithConnectCallback(r.resubscribeTrades))
+	c, err := r.dial(opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.conn = c
+	return c, nil
+}
+
+// resubscribeTrades renews the trade subscriptions of the hub after the
+// connection is re-established.
+func (r *Registry) resubscribeTrades(c *Conn) {
+	if pairs := r.hub.Pairs(); len(pairs) > 0 {
+		sort.Strings(pairs)
+		go c.SubscribeToMarkets(pairs)
+	}
+}
+
+// Trades returns a subscriber to the trades of pairs, with a buffer of the
+// given size or a default size if buffer is not positive. Unsubscribe it
+// when done.
+func (r *Registry) Trades(pairs []string, buffer int) (*Subscriber, error) {
+	if _, err := r.Conn(); err != nil {
+		return nil, err
+	}
+	return r.hub.Subscribe(pairs, buffer), nil
+}
+
+// BookFeed is a shared subscription to the full order book of a pair.
+type BookFeed struct {
+	Pair string
+	// Book is the shared book, kept in sync by the registry's connection.
+	Book *OrderBook
+	// Ack acknowledges the order book subscription made for the feed, or
+	// is nil if the pair already had a feed.
+	Ack *SubscriptionAck
+
+	registry *Registry
+	once     sync.Once
+}
+
+// Book returns a feed of the full order book of pair. The pair is
+// subscribed to on the shared connection unless it already has a feed.
+// Close the feed when done.
+func (r *Registry) Book(pair string) (*BookFeed, error) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	r.mu.Lock()
+	c, err := r.connLocked()
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.pairs[pair]++
+	added := r.pairs[pair] == 1
+	pairs := r.bookPairsLocked()
+	r.mu.Unlock()
+
+	f := &BookFeed{Pair: pair, Book: r.books.Book(pair), registry: r}
+	if added {
+		// Book subscriptions replace each other, so every pair is sent.
+		f.Ack = c.SubscribeToOrderBooks(pairs)
+	}
+	return f, nil
+}
+
+// Close releases the feed. The pair is unsubscribed once it has no feeds
+// left.
+func (f *BookFeed) Close() {
+	f.once.Do(func() {
+		f.registry.release(f.Pair)
+	})
+}
+
+func (r *Registry) release(pair string) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	r.mu.Lock()
+	if r.pairs[pair]--; r.pairs[pair] > 0 || r.closed {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.pairs, pair)
+	pairs := r.bookPairsLocked()
+	c := r.conn
+	r.mu.Unlock()
+	c.SubscribeToOrderBooks(pairs)
+}
+
+// bookPairsLocked returns the pairs with book feeds, sorted.
+func (r *Registry) bookPairsLocked() []string {
+	pairs := make([]string, 0, len(r.pairs))
+	for p := range r.pairs {
+		pairs = append(pairs, p)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// BookPairs returns the pairs with book feeds, sorted.
+func (r *Registry) BookPairs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bookPairsLocked()
+}
+
+// Close closes the shared connection and every trade subscriber. A
+// registry returned by Shared is removed, so the next call to Shared
+// creates a new one.
+func (r *Registry) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	c := r.conn
+	r.mu.Unlock()
+
+	r.hub.Close()
+	if c != nil {
+		c.Close()
+	}
+	if r.onClose != nil {
+		r.onClose()
+	}
+}
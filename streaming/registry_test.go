@@ -0,0 +1,95 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestSharedRegistry(t *testing.T) {
+	s := sim.New(sim.WithMarket(sim.Market{Pair: "BTCZAR", Price: decimal.New(1000000, 0)}))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	r := streaming.Shared("registry-key", "secret", streaming.WithEnvironment(env))
+	defer r.Close()
+	if other := streaming.Shared("registry-key", "secret"); other != r {
+		t.Fatal("Expected the same registry for the same key")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	a, err := r.Trades([]string{"BTCZAR"}, 0)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := a.Ack.Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	b, err := r.Trades([]string{"BTCZAR"}, 0)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if b.Ack != nil {
+		t.Error("Expected no new subscription for a pair already subscribed")
+	}
+	trade, err := s.Trade(sim.ScriptTrade{Pair: "BTCZAR", Side: valr.ResponseSideBuy, Price: decimal.New(1000000, 0), Quantity: decimal.New(1, -3)})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	for _, sub := range []*streaming.Subscriber{a, b} {
+		select {
+		case m := <-sub.C:
+			if m.Data.ID != trade.ID {
+				t.Errorf("Expected %q, got %q", trade.ID, m.Data.ID)
+			}
+		case <-ctx.Done():
+			t.Fatal("Expected a trade")
+		}
+	}
+
+	f1, err := r.Book("BTCZAR")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := f1.Ack.Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	f2, err := r.Book("BTCZAR")
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if f2.Ack != nil || f2.Book != f1.Book {
+		t.Error("Expected the second feed to share the first's book and subscription")
+	}
+	f1.Close()
+	f1.Close()
+	if got, want := r.BookPairs(), []string{"BTCZAR"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	f2.Close()
+	if got := r.BookPairs(); len(got) != 0 {
+		t.Errorf("Expected no book pairs, got %v", got)
+	}
+
+	r.Close()
+	if _, err := r.Book("BTCZAR"); err != streaming.ErrRegistryClosed {
+		t.Errorf("Expected %v, got %v", streaming.ErrRegistryClosed, err)
+	}
+	next := streaming.Shared("registry-key", "secret")
+	defer next.Close()
+	if next == r {
+		t.Error("Expected a new registry after Close")
+	}
+}
@@ -0,0 +1,83 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+// accountSnapshotTimeout bounds fetching the account snapshot on connect.
+const accountSnapshotTimeout = 10 * time.Second
+
+// WithAccountSnapshot makes the account stream fetch the open orders and
+// balances with cl each time it connects, after the connect callback and
+// before any live update. They are delivered as an OPEN_ORDERS_UPDATE and a
+// BALANCE_UPDATE per currency, marked Snapshot, so consumers always start,
+// and restart after reconnecting, from a complete state. If the snapshot
+// can't be fetched the connection is dropped and retried. It has no effect
+// on the trade stream.
+func WithAccountSnapshot(cl *valr.Client) DialOption {
+	return func(c *Conn) {
+		c.snapshotClient = cl
+	}
+}
+
+// sendAccountSnapshot delivers the open orders and balances fetched over
+// REST to the account callbacks and bus.
+func (c *Conn) sendAccountSnapshot(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, accountSnapshotTimeout)
+	defer cancel()
+	orders, err := c.snapshotClient.GetAllOpenOrdersRequest(ctx, &valr.GetAllOpenOrdersRequest{})
+	if err != nil {
+		return fmt.Errorf("fetching open orders snapshot: %w", err)
+	}
+	balances, err := c.snapshotClient.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return fmt.Errorf("fetching balances snapshot: %w", err)
+	}
+
+	open := MessageOpenOrdersUpdate{
+		MessageType: MessageType{Type: EventOpenOrdersUpdate},
+		Snapshot:    true,
+		Data:        make([]OpenOrder, 0, len(orders)),
+	}
+	for _, o := range orders {
+		open.Data = append(open.Data, OpenOrder{
+			OrderID:           o.OrderID,
+			Side:              o.Side,
+			Quantity:          o.RemainingQuantity,
+			Price:             o.Price,
+			CurrencyPair:      o.Pair,
+			CreatedAt:         o.CreatedAt,
+			OriginalQuantity:  o.OriginalQuantity,
+			FilledPercentage:  o.FilledPercentage,
+			CustomerOrderID:   o.CustomerOrderID,
+			RemainingQuantity: o.RemainingQuantity,
+		})
+	}
+	if cb := c.account.openOrdersUpdate; cb != nil {
+		c.call(CallbackAccount, func() { cb(open) })
+	}
+	c.publish(&open)
+
+	now := time.Now()
+	for _, b := range balances {
+		msg := MessageBalanceUpdate{
+			MessageType: MessageType{Type: EventBalanceUpdate},
+			Snapshot:    true,
+		}
+		msg.Data.Currency.Symbol = b.Currency
+		msg.Data.Currency.ShortName = b.Currency
+		msg.Data.Available = b.Available
+		msg.Data.Reserved = b.Reserved
+		msg.Data.Total = b.Total
+		msg.Data.UpdatedAt = valr.NewTimestamp(now)
+		if cb := c.account.balanceUpdate; cb != nil {
+			c.call(CallbackAccount, func() { cb(msg) })
+		}
+		c.publish(&msg)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/sim"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/shopspring/decimal"
+)
+
+func TestAccountSnapshot(t *testing.T) {
+	srv := httptest.NewServer(sim.New(
+		sim.WithMarket(sim.Market{Pair: "BTCZAR", Price: decimal.New(1000000, 0)}),
+		sim.WithBalance("ZAR", decimal.New(100000, 0)),
+	))
+	defer srv.Close()
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetEnvironment(env)
+	cl.SetAuth("key", "secret")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = cl.PostLimitOrderRequest(ctx, &valr.PostLimitOrderRequest{
+		Side:            valr.BUY,
+		Quantity:        decimal.New(1, -2),
+		Price:           decimal.New(500000, 0),
+		Pair:            "BTCZAR",
+		CustomerOrderID: "resting",
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	orders := make(chan streaming.MessageOpenOrdersUpdate, 1)
+	balances := make(chan streaming.MessageBalanceUpdate, 10)
+	c, err := streaming.DialAccount("key", "secret",
+		streaming.WithEnvironment(env),
+		streaming.WithAccountSnapshot(cl),
+		streaming.WithOpenOrdersUpdateCallback(func(m streaming.MessageOpenOrdersUpdate) { orders <- m }),
+		streaming.WithBalanceUpdateCallback(func(m streaming.MessageBalanceUpdate) { balances <- m }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	select {
+	case m := <-orders:
+		if !m.Snapshot || len(m.Data) != 1 || m.Data[0].CustomerOrderID != "resting" {
+			t.Errorf("Expected snapshot of the resting order, got %+v", m)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected open orders snapshot")
+	}
+	select {
+	case m := <-balances:
+		if !m.Snapshot || m.Data.Currency.Symbol != "ZAR" || m.Data.Reserved.String() != "5000" {
+			t.Errorf("Expected ZAR balance snapshot with 5000 reserved, got %+v", m)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected balance snapshot")
+	}
+}
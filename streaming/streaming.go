@@ -6,25 +6,31 @@ import (
 	"errors"
 	"fmt"
 	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/eventbus"
+	"github.com/donohutcheon/valr-go/supervise"
 	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
 const (
-	tradeWebSocketAddr   = "wss://api.valr.com/ws/trade"
-	accountWebSocketAddr = "wss://api.valr.com/ws/account"
+	tradeWebSocketPath   = "/ws/trade"
+	accountWebSocketPath = "/ws/account"
 
 	readTimeout         = time.Minute
 	writeTimeout        = 30 * time.Second
-	pingInterval        = 30 * time.Second
+	defaultPingInterval = 30 * time.Second
 	defaultAttemptReset = time.Minute * 30
 )
 
+var pingMessage = []byte(`{"type":"PING"}`)
+
 type (
 	ConnectCallback func(*Conn)
 	UpdateCallback  func(MessageTradeUpdate)
@@ -32,13 +38,51 @@ type (
 )
 
 type Conn struct {
-	keyID, keySecret string
-	pair             string
-	connectCallback  ConnectCallback
-	updateCallback   UpdateCallback
+	keyID       string
+	signer      valr.Signer
+	env         valr.Environment
+	tradePath   string
+	accountPath string
+	// stream is the stream dialled, streamTrade or streamAccount.
+	stream          string
+	pair            string
+	connectCallback ConnectCallback
+	updateCallback  UpdateCallback
+	summaryCallback MarketSummaryCallback
+	account         accountCallbacks
+	snapshotClient  *valr.Client
 
 	backoffHandler BackoffHandler
+	writeLimit     *writeLimiter
+	rnd            *rand.Rand
 	attemptReset   time.Duration
+	pingInterval   time.Duration
+	stallTimeout   time.Duration
+	stallCallback  StallCallback
+	errorCallback  ErrorCallback
+	rawFields      bool
+	lastMessage    atomic.Int64
+	journal        *Journal
+	chaos          *Chaos
+	bus            *eventbus.Bus
+
+	eventTimeCallback EventTimeCallback
+	supervisor        *supervise.Supervisor
+	throttle          *throttle
+	latency           *LatencyRecorder
+	// replay is true for the connection of Replay.
+	replay bool
+
+	subscribedCallback SubscribedCallback
+	subscribeTimeout   time.Duration
+	subscribeReqs      chan subscribeRequest
+	pendingMu          sync.Mutex
+	pending            []SubscriptionBatch
+	active             map[string][]string // by event
+
+	books      *BookKeeper
+	bookSubs   bookSubscriptions
+	bookResync chan struct{}
 
 	closed bool
 
@@ -54,28 +98,54 @@ func Dial(keyID, keySecret string, opts ...DialOption) (*Conn, error) {
 	if keyID == "" || keySecret == "" {
 		return nil, errors.New("streaming: streaming API requires credentials")
 	}
+	signer, err := valr.NewHMACSigner(keySecret)
+	if err != nil {
+		return nil, err
+	}
+	return DialWithSigner(keyID, signer, opts...)
+}
+
+// DialWithSigner is like Dial but authenticates using the given Signer rather
+// than a raw API secret.
+func DialWithSigner(keyID string, signer valr.Signer, opts ...DialOption) (*Conn, error) {
+	if keyID == "" || signer == nil {
+		return nil, errors.New("streaming: streaming API requires credentials")
+	}
 
 	c := &Conn{
-		keyID:        keyID,
-		keySecret:    keySecret,
-		attemptReset: defaultAttemptReset,
-		SubscribeCh:  make(chan []string),
+		keyID:            keyID,
+		signer:           signer,
+		env:              valr.Production,
+		tradePath:        tradeWebSocketPath,
+		stream:           streamTrade,
+		subscribeTimeout: defaultSubscribeTimeout,
+		subscribeReqs:    make(chan subscribeRequest),
+		accountPath:      accountWebSocketPath,
+		attemptReset:     defaultAttemptReset,
+		pingInterval:     defaultPingInterval,
+		writeLimit:       newWriteLimiter(defaultWriteRate, defaultWriteBurst),
+		bookResync:       make(chan struct{}, 1),
+		SubscribeCh:      make(chan []string),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.books == nil {
+		c.books = NewBookKeeper()
+	}
 
-	go c.manageForever(keyID, keySecret)
+	go c.manageForever()
 	return c, nil
 }
 
-func (c *Conn) manageForever(keyID, keySecret string) {
+func (c *Conn) manageForever() {
 	p := new(backoffParams)
 
 	for {
-		if err := c.connect(keyID, keySecret); err != nil {
+		if err := c.connect(); err != nil {
 			log.Printf("valr/streaming: Connection error key=%s pair=%s: %v",
 				c.keyID, c.pair, err)
+			c.reportError(err)
 		}
 		if c.IsClosed() {
 			return
@@ -88,16 +158,24 @@ func (c *Conn) manageForever(keyID, keySecret string) {
 	}
 }
 
-func (c *Conn) connect(keyID, keySecret string) error {
-	url := tradeWebSocketAddr
-	headers, err := valr.GetAuthHeaders(tradeWebSocketAddr, http.MethodGet, keyID, keySecret, nil)
+func (c *Conn) connect() error {
+	path := c.tradePath
+	if c.stream == streamAccount {
+		path = c.accountPath
+	}
+	url := c.env.WebSocketURL + path
+	headers, err := valr.GetSignedHeaders(context.Background(), url, http.MethodGet, c.keyID, c.signer, nil)
 	if err != nil {
 		return errors.Join(err, errors.New("failed to calculate auth headers"))
 	}
-	c.ws, _, err = websocket.DefaultDialer.Dial(url, headers)
+	ws, res, err := websocket.DefaultDialer.Dial(url, headers)
 	if err != nil {
+		if herr := handshakeError(res); herr != nil {
+			return herr
+		}
 		return fmt.Errorf("unable to dial server: %w", err)
 	}
+	c.ws = ws
 	defer func() {
 		_ = c.ws.Close()
 		c.reset()
@@ -106,9 +184,40 @@ func (c *Conn) connect(keyID, keySecret string) error {
 	log.Printf("valr/streaming: Connection established key=%s pair=%s",
 		c.keyID, c.pair)
 
+	if c.chaos != nil {
+		c.chaos.attach(c.ws)
+	}
+
+	// Updates may have been missed while disconnected; sendPings renews the
+	// order book subscriptions to fetch fresh snapshots.
+	c.invalidateBooks()
+
+	// Both goroutines use c.ws, so they must stop before a reconnect
+	// replaces it.
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go c.sendPings(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.sendPings(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		c.watchdog(ctx)
+	}()
+
+	if c.connectCallback != nil {
+		c.call(CallbackConnect, func() { c.connectCallback(c) })
+	}
+	if c.stream == streamAccount && c.snapshotClient != nil {
+		if err := c.sendAccountSnapshot(ctx); err != nil {
+			return err
+		}
+	}
 
 	for {
 		if c.IsClosed() {
@@ -119,6 +228,7 @@ func (c *Conn) connect(keyID, keySecret string) error {
 		if err != nil {
 			return err
 		}
+		c.markAlive()
 		if errors.Is(err, io.EOF) {
 			// Server closed the connection. Return gracefully.
 			return nil
@@ -127,38 +237,99 @@ func (c *Conn) connect(keyID, keySecret string) error {
 			return fmt.Errorf("failed to receive message: %w", err)
 		}
 
-		if string(data) == "\"\"" {
-			// Ignore server keep alive messages
-			continue
+		if c.journal != nil {
+			if err := c.journal.Record(c.stream, data); err != nil {
+				log.Printf("valr/streaming: Failed to journal frame: %v", err)
+			}
 		}
-
-		msgType := new(MessageType)
-		err = json.Unmarshal(data, msgType)
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal message and establish type: %w", err)
+		if c.chaos != nil {
+			if data = c.chaos.apply(data); data == nil {
+				continue
+			}
 		}
 
-		if err := c.receivedUpdate(msgType.Type, data); err != nil {
-			return fmt.Errorf("failed to process update: %w", err)
+		if err := c.dispatch(data); err != nil {
+			return err
 		}
 	}
 }
 
+// dispatch decodes a raw frame and passes it to the handler for its type.
+func (c *Conn) dispatch(data []byte) error {
+	if string(data) == "\"\"" {
+		// Ignore server keep alive messages
+		return nil
+	}
+
+	msgType := new(MessageType)
+	err := json.Unmarshal(data, msgType)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal message and establish type: %w", err)
+	}
+
+	if err := c.receivedUpdate(msgType.Type, data); err != nil {
+		return fmt.Errorf("failed to process update: %w", err)
+	}
+	return nil
+}
+
 func (c *Conn) receivedUpdate(msgType string, data []byte) error {
 	switch msgType {
-	case "NEW_TRADE":
+	case EventNewTrade:
 		message := new(MessageTradeUpdate)
-		err := json.Unmarshal(data, message)
+		err := c.decode(data, message)
 		if err != nil {
 			return err
 		}
-		c.updateCallback(*message)
+		if c.updateCallback != nil {
+			c.throttle.deliver(EventNewTrade+"/"+message.CurrencyPairSymbol, func() {
+				c.deliver(CallbackUpdate, message, func() { c.updateCallback(*message) })
+			})
+		}
+		c.publish(message)
+	case EventMarketSummaryUpdate:
+		if c.summaryCallback == nil {
+			return nil
+		}
+		message := new(MessageMarketSummaryUpdate)
+		if err := c.decode(data, message); err != nil {
+			return err
+		}
+		c.throttle.deliver(EventMarketSummaryUpdate+"/"+message.CurrencyPairSymbol, func() {
+			c.deliver(CallbackMarketSummary, message, func() { c.summaryCallback(*message) })
+		})
 	case "AUTHENTICATED":
 		// Ignore
+	case "PONG":
+		// Reply to our JSON ping; the connection is alive.
+		c.extendReadDeadline()
 	case "SUBSCRIBED":
-		// Ignore
+		message := new(MessageSubscribed)
+		err := c.decode(data, message)
+		if err != nil {
+			return err
+		}
+		c.acknowledge(*message)
+	case messageUnauthorized, messageError:
+		c.reportError(parseServerError(msgType, data))
+	case messageSubscriptionFailed:
+		err := parseServerError(msgType, data)
+		c.reject(err)
+		c.reportError(err)
 	default:
-		fmt.Printf("unknown message type: %s", msgType)
+		handled, err := c.receivedAccountUpdate(msgType, data)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			handled, err = c.receivedBookUpdate(msgType, data)
+			if err != nil {
+				return err
+			}
+		}
+		if !handled {
+			fmt.Printf("unknown message type: %s", msgType)
+		}
 	}
 
 	return nil
@@ -171,28 +342,29 @@ func (c *Conn) calculateBackoff(p *backoffParams, ts time.Time) time.Duration {
 
 	p.attempts++
 
-	backoff := defaultBackoffHandler
-	if c.backoffHandler != nil {
-		backoff = c.backoffHandler
-	}
-
 	p.lastAttempt = ts
 
-	return backoff(p.attempts)
+	if c.backoffHandler != nil {
+		return c.backoffHandler(p.attempts)
+	}
+	return defaultBackoff(c.rnd, p.attempts)
 }
 
 func (c *Conn) sendPings(ctx context.Context) {
-	pingTicker := time.NewTicker(pingInterval)
+	pingTicker := time.NewTicker(c.pingInterval)
 	defer pingTicker.Stop()
 
 	// Set initial read deadline
-	_ = c.ws.SetReadDeadline(time.Now().Add(readTimeout))
+	c.extendReadDeadline()
 
 	c.ws.SetPongHandler(func(data string) error {
 		// Connection is alive, extend read deadline
+		c.markAlive()
 		return c.ws.SetReadDeadline(time.Now().Add(readTimeout))
 	})
 
+	c.resubscribeBooks(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -202,42 +374,52 @@ func (c *Conn) sendPings(ctx context.Context) {
 				return
 			}
 
+			if c.writeLimit.wait(ctx) != nil {
+				return
+			}
 			_ = c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
 			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
 				log.Printf("valr/streaming: Failed to ping server: %v", err)
 			}
-		case pairs := <-c.SubscribeCh:
-			payload := SubscribeToMarketsRequest{
-				Type: "SUBSCRIBE",
-				Subscriptions: []Subscriptions{
-					{
-						Event: "NEW_TRADE",
-						Pairs: pairs,
-					},
-				},
+			// Intermediaries may strip control frames, so also send VALR's
+			// application level ping, which the server answers with a PONG.
+			if c.writeLimit.wait(ctx) != nil {
+				return
 			}
-			b, err := json.MarshalIndent(payload, "", "  ")
-			if err != nil {
-				log.Printf("valr/streaming: Failed to marshal payload: %v", err)
-				continue
+			if err := c.ws.WriteMessage(websocket.TextMessage, pingMessage); err != nil {
+				log.Printf("valr/streaming: Failed to send PING: %v", err)
 			}
-			log.Printf("valr/streaming: Sending payload: %s", b)
-
-			err = c.ws.WriteJSON(payload)
-			if err != nil {
-				log.Printf("valr/streaming: Failed to subscribe to pairs: %v", err)
-				continue
+		case pairs := <-c.SubscribeCh:
+			c.subscribe(ctx, EventNewTrade, pairs, nil)
+		case req := <-c.subscribeReqs:
+			if isBookEvent(req.event) {
+				// Recorded once sent, so that it is renewed after a
+				// reconnect or gap but never sent twice.
+				c.bookSubs.set(req.event, req.pairs)
 			}
+			c.subscribe(ctx, req.event, req.pairs, req.ack)
+		case <-c.bookResync:
+			c.invalidateBooks()
+			c.resubscribeBooks(ctx)
 		}
 	}
 }
 
+// extendReadDeadline pushes back the read deadline after a sign of life from
+// the server.
+func (c *Conn) extendReadDeadline() {
+	if c.ws != nil {
+		_ = c.ws.SetReadDeadline(time.Now().Add(readTimeout))
+	}
+}
+
 // Close the stream. After calling this the client will stop receiving new updates and the results of querying the Conn
 // struct (Snapshot, Status...) will be zeroed values.
 func (c *Conn) Close() {
 	c.mu.Lock()
 	c.closed = true
 	c.mu.Unlock()
+	c.throttle.stop()
 
 	c.reset()
 }
@@ -245,6 +427,11 @@ func (c *Conn) Close() {
 func (c *Conn) reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+
+	// Acknowledgements of requests sent on the old websocket will never
+	// arrive, and its subscriptions ended with it.
+	c.failPending()
+	c.clearActive()
 }
 
 // IsClosed returns true if the Conn has been closed.
@@ -253,7 +440,3 @@ func (c *Conn) IsClosed() bool {
 	defer c.mu.RUnlock()
 	return c.closed
 }
-
-func (c *Conn) SubscribeToMarkets(pairs []string) {
-	c.SubscribeCh <- pairs
-}
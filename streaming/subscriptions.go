@@ -0,0 +1,289 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultSubscribeTimeout = 10 * time.Second
+
+// EventNewTrade is the trade stream event of public trades.
+const EventNewTrade = "NEW_TRADE"
+
+var (
+	// ErrSubscribeTimeout is reported for subscriptions the server did not
+	// acknowledge within the subscribe timeout.
+	ErrSubscribeTimeout = errors.New("streaming: subscription not acknowledged in time")
+	// ErrSubscribeFailed is reported for subscriptions that could not be
+	// sent, or whose connection was lost before they were acknowledged.
+	ErrSubscribeFailed = errors.New("streaming: subscription failed")
+)
+
+// MessageSubscribed is a SUBSCRIBED message confirming a subscription.
+type MessageSubscribed struct {
+	MessageType
+	RawFields
+	Received
+	Message string `json:"message"`
+}
+
+// SubscriptionBatch is a single subscription message sent to the server. A
+// message replaces any previous subscription to its event, so every event
+// is sent as one batch holding all of its pairs.
+type SubscriptionBatch struct {
+	Event string
+	Pairs []string
+	// Ack is the server's confirmation, set once it has been received.
+	Ack *MessageSubscribed
+
+	req *SubscriptionAck
+}
+
+// SubscriptionAck resolves once every batch of a subscription request has
+// been acknowledged, or with an error if that doesn't happen in time.
+type SubscriptionAck struct {
+	done chan struct{}
+	once sync.Once
+
+	mu        sync.Mutex
+	remaining int
+	batches   []SubscriptionBatch
+	err       error
+}
+
+func newSubscriptionAck(batches int, timeout time.Duration) *SubscriptionAck {
+	a := &SubscriptionAck{done: make(chan struct{}), remaining: batches}
+	if timeout > 0 {
+		t := time.AfterFunc(timeout, func() { a.resolve(ErrSubscribeTimeout) })
+		go func() {
+			<-a.done
+			t.Stop()
+		}()
+	}
+	return a
+}
+
+// Done is closed once the subscription is acknowledged or has failed.
+func (a *SubscriptionAck) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err returns nil if the subscription was acknowledged, and the reason it
+// failed otherwise. It is only meaningful once Done is closed.
+func (a *SubscriptionAck) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Batches returns the acknowledged batches.
+func (a *SubscriptionAck) Batches() []SubscriptionBatch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]SubscriptionBatch(nil), a.batches...)
+}
+
+// Wait blocks until the subscription resolves or ctx is done.
+func (a *SubscriptionAck) Wait(ctx context.Context) error {
+	select {
+	case <-a.done:
+		return a.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *SubscriptionAck) confirm(b SubscriptionBatch) {
+	a.mu.Lock()
+	a.batches = append(a.batches, b)
+	a.remaining--
+	complete := a.remaining <= 0
+	a.mu.Unlock()
+	if complete {
+		a.resolve(nil)
+	}
+}
+
+func (a *SubscriptionAck) resolve(err error) {
+	a.once.Do(func() {
+		a.mu.Lock()
+		a.err = err
+		a.mu.Unlock()
+		close(a.done)
+	})
+}
+
+type subscribeRequest struct {
+	event string
+	pairs []string
+	ack   *SubscriptionAck
+}
+
+// WithSubscribeTimeout sets how long SubscribeToMarkets waits for the server
+// to acknowledge a subscription before failing it with ErrSubscribeTimeout.
+func WithSubscribeTimeout(d time.Duration) DialOption {
+	return func(c *Conn) {
+		c.subscribeTimeout = d
+	}
+}
+
+// SubscribeToMarkets subscribes to trades on the given pairs, replacing any
+// previous trade subscription. It blocks until the connection is ready to
+// send the request; the returned acknowledgement resolves once the server
+// has confirmed it.
+func (c *Conn) SubscribeToMarkets(pairs []string) *SubscriptionAck {
+	ack := newSubscriptionAck(1, c.subscribeTimeout)
+	c.subscribeReqs <- subscribeRequest{event: EventNewTrade, pairs: pairs, ack: ack}
+	return ack
+}
+
+// SubscribedCallback is called as each subscription batch is acknowledged.
+type SubscribedCallback func(SubscriptionBatch)
+
+// WithSubscribedCallback registers a callback for subscription
+// acknowledgements. Acknowledgements are matched to batches in the order
+// the batches were sent.
+func WithSubscribedCallback(fn SubscribedCallback) DialOption {
+	return func(c *Conn) {
+		c.subscribedCallback = fn
+	}
+}
+
+// subscribe sends a single subscription message, once the write rate limit
+// allows, and queues it for acknowledgement by ack, which may be nil.
+func (c *Conn) subscribe(ctx context.Context, event string, pairs []string, ack *SubscriptionAck) {
+	payload := SubscribeToMarketsRequest{
+		Type: "SUBSCRIBE",
+		Subscriptions: []Subscriptions{
+			{
+				Event: event,
+				Pairs: pairs,
+			},
+		},
+	}
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		log.Printf("valr/streaming: Failed to marshal payload: %v", err)
+		if ack != nil {
+			ack.resolve(errors.Join(ErrSubscribeFailed, err))
+		}
+		return
+	}
+	if err := c.writeLimit.wait(ctx); err != nil {
+		if ack != nil {
+			ack.resolve(errors.Join(ErrSubscribeFailed, err))
+		}
+		return
+	}
+	log.Printf("valr/streaming: Sending payload: %s", b)
+
+	c.pendingMu.Lock()
+	c.pending = append(c.pending, SubscriptionBatch{Event: event, Pairs: pairs, req: ack})
+	c.pendingMu.Unlock()
+
+	err = c.ws.WriteJSON(payload)
+	if err != nil {
+		log.Printf("valr/streaming: Failed to subscribe to pairs: %v", err)
+		c.pendingMu.Lock()
+		if n := len(c.pending); n > 0 {
+			c.pending = c.pending[:n-1]
+		}
+		c.pendingMu.Unlock()
+		if ack != nil {
+			ack.resolve(errors.Join(ErrSubscribeFailed, err))
+		}
+	}
+}
+
+// acknowledge matches a SUBSCRIBED message to the oldest pending batch,
+// whose pairs replace those subscribed to its event.
+func (c *Conn) acknowledge(msg MessageSubscribed) {
+	c.pendingMu.Lock()
+	if len(c.pending) == 0 {
+		c.pendingMu.Unlock()
+		return
+	}
+	batch := c.pending[0]
+	c.pending = c.pending[1:]
+	if c.active == nil {
+		c.active = make(map[string][]string)
+	}
+	if len(batch.Pairs) == 0 {
+		delete(c.active, batch.Event)
+	} else {
+		c.active[batch.Event] = slices.Clone(batch.Pairs)
+	}
+	c.pendingMu.Unlock()
+
+	batch.Ack = &msg
+	if batch.req != nil {
+		batch.req.confirm(batch)
+	}
+	if c.subscribedCallback != nil {
+		c.call(CallbackSubscribed, func() { c.subscribedCallback(batch) })
+	}
+}
+
+// reject fails the oldest pending batch with err.
+func (c *Conn) reject(err error) {
+	c.pendingMu.Lock()
+	if len(c.pending) == 0 {
+		c.pendingMu.Unlock()
+		return
+	}
+	batch := c.pending[0]
+	c.pending = c.pending[1:]
+	c.pendingMu.Unlock()
+
+	if batch.req != nil {
+		batch.req.resolve(err)
+	}
+}
+
+// failPending fails the acknowledgements of all pending batches, e.g. when
+// the websocket they were sent on is closed.
+func (c *Conn) failPending() {
+	c.pendingMu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.pendingMu.Unlock()
+	for _, b := range pending {
+		if b.req != nil {
+			b.req.resolve(ErrSubscribeFailed)
+		}
+	}
+}
+
+// Subscriptions returns the subscriptions the server has confirmed on the
+// current websocket, by event sorted by name, with pairs in the order they
+// were sent. It is empty while disconnected, and a subscription only
+// appears once acknowledged, so comparing it to the desired subscriptions
+// shows what needs to be subscribed again.
+func (c *Conn) Subscriptions() []Subscriptions {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	events := make([]string, 0, len(c.active))
+	for event := range c.active {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	out := make([]Subscriptions, 0, len(events))
+	for _, event := range events {
+		out = append(out, Subscriptions{Event: event, Pairs: append([]string(nil), c.active[event]...)})
+	}
+	return out
+}
+
+// clearActive forgets the confirmed subscriptions, e.g. when the websocket
+// they were made on is closed.
+func (c *Conn) clearActive() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	c.active = nil
+}
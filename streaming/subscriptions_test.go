@@ -0,0 +1,179 @@
+package streaming_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/streaming"
+	"github.com/gorilla/websocket"
+)
+
+func TestActiveSubscriptions(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	if got := c.Subscriptions(); len(got) != 0 {
+		t.Errorf("Expected no subscriptions, got %+v", got)
+	}
+	sub := streaming.NewSubscription().Trades("BTCZAR", "ETHZAR").AggregatedBook("BTCZAR", "ETHZAR")
+	if err := c.Subscribe(sub).Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	// A new subscription to an event replaces the previous one.
+	if err := c.SubscribeToAggregatedOrderBooks([]string{"ETHZAR"}).Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := c.SubscribeToMarkets([]string{"XRPZAR", "BTCZAR"}).Wait(context.Background()); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	want := []streaming.Subscriptions{
+		{Event: streaming.EventAggregatedOrderBookUpdate, Pairs: []string{"ETHZAR"}},
+		{Event: streaming.EventNewTrade, Pairs: []string{"XRPZAR", "BTCZAR"}},
+	}
+	if got := c.Subscriptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestWriteRateLimit(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env),
+		streaming.WithWriteRateLimit(20, 2))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	// Six frames: a burst of two, then one every 50ms.
+	start := time.Now()
+	var acks []*streaming.SubscriptionAck
+	for _, pair := range []string{"BTCZAR", "ETHZAR", "XRPZAR", "SOLZAR", "ADAZAR", "DOTZAR"} {
+		acks = append(acks, c.SubscribeToMarkets([]string{pair}))
+	}
+	for _, ack := range acks {
+		if err := ack.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected subscriptions to be paced, took %v", elapsed)
+	}
+}
+
+func TestSubscriptionsUnbatched(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	reqs := make(chan streaming.SubscribeToMarketsRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req streaming.SubscribeToMarketsRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			reqs <- req
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"SUBSCRIBED"}`))
+		}
+	}))
+	defer srv.Close()
+
+	env, err := valr.MockEnvironment(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	c, err := streaming.Dial("key", "secret", streaming.WithEnvironment(env))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	defer c.Close()
+
+	var pairs []string
+	for i := 0; i < 25; i++ {
+		pairs = append(pairs, fmt.Sprintf("T%02dZAR", i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Subscribe(streaming.NewSubscription().Trades(pairs...).AggregatedBook(pairs...)).Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := c.SubscribeToOrderBooks(pairs).Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := c.SubscribeToMarkets(pairs).Wait(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	// Each subscription message replaces the previous one for its event,
+	// so all the pairs of an event must be sent in one message.
+	if n := len(reqs); n != 4 {
+		t.Fatalf("Expected 4 subscription messages, got %d", n)
+	}
+	for i := 0; i < 4; i++ {
+		req := <-reqs
+		if len(req.Subscriptions) != 1 || !reflect.DeepEqual(req.Subscriptions[0].Pairs, pairs) {
+			t.Errorf("Expected one subscription to %q, got %+v", pairs, req.Subscriptions)
+		}
+	}
+	want := []streaming.Subscriptions{
+		{Event: streaming.EventAggregatedOrderBookUpdate, Pairs: pairs},
+		{Event: streaming.EventFullOrderBookUpdate, Pairs: pairs},
+		{Event: streaming.EventNewTrade, Pairs: pairs},
+	}
+	if got := c.Subscriptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
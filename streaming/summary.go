@@ -0,0 +1,27 @@
+package streaming
+
+import "github.com/donohutcheon/valr-go"
+
+// EventMarketSummaryUpdate is the trade stream event of market summaries.
+const EventMarketSummaryUpdate = "MARKET_SUMMARY_UPDATE"
+
+// MessageMarketSummaryUpdate is a MARKET_SUMMARY_UPDATE message. The pair is
+// given by CurrencyPairSymbol, not Data.Pair.
+type MessageMarketSummaryUpdate struct {
+	MessageType
+	RawFields
+	Received
+	CurrencyPairSymbol string             `json:"currencyPairSymbol"`
+	Data               valr.MarketSummary `json:"data"`
+}
+
+// MarketSummaryCallback is called for each market summary update.
+type MarketSummaryCallback func(MessageMarketSummaryUpdate)
+
+// WithMarketSummaryCallback sets a callback for MARKET_SUMMARY_UPDATE
+// messages, sent for pairs subscribed with SubscriptionBuilder.MarketSummary.
+func WithMarketSummaryCallback(fn MarketSummaryCallback) DialOption {
+	return func(c *Conn) {
+		c.summaryCallback = fn
+	}
+}
@@ -0,0 +1,30 @@
+package streaming
+
+import "github.com/donohutcheon/valr-go/supervise"
+
+// Names of a connection's callbacks, for supervise.WithPolicyFor. All the
+// account stream callbacks share CallbackAccount.
+const (
+	CallbackConnect       = "streaming.connect"
+	CallbackUpdate        = "streaming.update"
+	CallbackMarketSummary = "streaming.market_summary"
+	CallbackAccount       = "streaming.account"
+	CallbackError         = "streaming.error"
+	CallbackEventTime     = "streaming.event_time"
+	CallbackSubscribed    = "streaming.subscribed"
+	CallbackStall         = "streaming.stall"
+)
+
+// WithSupervisor runs the connection's callbacks under s, so a panicking
+// callback is recovered according to s's policies instead of killing the
+// connection's goroutine.
+func WithSupervisor(s *supervise.Supervisor) DialOption {
+	return func(c *Conn) {
+		c.supervisor = s
+	}
+}
+
+// call runs the named callback under the connection's supervisor, if any.
+func (c *Conn) call(callback string, fn func()) {
+	c.supervisor.Call(callback, fn)
+}
@@ -0,0 +1,145 @@
+package streaming
+
+import (
+	"sync"
+	"time"
+)
+
+type throttleMode int
+
+const (
+	throttleSample throttleMode = iota
+	throttleConflate
+)
+
+// WithSampling delivers at most one trade and one market summary update
+// per pair to the callbacks in every interval d, the first received, and
+// drops the rest. It suits consumers such as UIs that don't need every
+// tick. The event bus still receives every update.
+func WithSampling(d time.Duration) DialOption {
+	return func(c *Conn) {
+		c.throttle = newThrottle(throttleSample, d)
+	}
+}
+
+// WithConflation is like WithSampling but delivers the latest update of
+// each interval rather than the first: an update arriving after a quiet
+// interval is delivered at once, and later ones are held until the interval
+// has passed, each replacing the one held before it. Replay delivers any
+// held updates before returning.
+func WithConflation(d time.Duration) DialOption {
+	return func(c *Conn) {
+		c.throttle = newThrottle(throttleConflate, d)
+	}
+}
+
+// throttle limits the rate of callback deliveries by key. A nil throttle
+// delivers everything.
+type throttle struct {
+	mode     throttleMode
+	interval time.Duration
+
+	// deliverMu serialises deliveries, since held updates are delivered by
+	// timers rather than by the reading goroutine.
+	deliverMu sync.Mutex
+
+	mu      sync.Mutex
+	last    map[string]time.Time
+	held    map[string]func()
+	timers  map[string]*time.Timer
+	stopped bool
+}
+
+func newThrottle(mode throttleMode, d time.Duration) *throttle {
+	return &throttle{
+		mode:     mode,
+		interval: d,
+		last:     make(map[string]time.Time),
+		held:     make(map[string]func()),
+		timers:   make(map[string]*time.Timer),
+	}
+}
+
+// deliver calls fn now, later or never, according to the throttle's mode.
+func (t *throttle) deliver(key string, fn func()) {
+	if t == nil || t.interval <= 0 {
+		fn()
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	elapsed := now.Sub(t.last[key])
+	if elapsed >= t.interval && t.timers[key] == nil {
+		t.last[key] = now
+		t.mu.Unlock()
+		t.run(fn)
+		return
+	}
+	if t.mode == throttleSample {
+		t.mu.Unlock()
+		return
+	}
+	t.held[key] = fn
+	if t.timers[key] == nil {
+		t.timers[key] = time.AfterFunc(t.interval-elapsed, func() { t.release(key) })
+	}
+	t.mu.Unlock()
+}
+
+// release delivers the update held for key, if any.
+func (t *throttle) release(key string) {
+	t.mu.Lock()
+	fn := t.held[key]
+	delete(t.held, key)
+	delete(t.timers, key)
+	if t.stopped || fn == nil {
+		t.mu.Unlock()
+		return
+	}
+	t.last[key] = time.Now()
+	t.mu.Unlock()
+	t.run(fn)
+}
+
+func (t *throttle) run(fn func()) {
+	t.deliverMu.Lock()
+	defer t.deliverMu.Unlock()
+	fn()
+}
+
+// flush delivers every held update at once.
+func (t *throttle) flush() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	keys := make([]string, 0, len(t.timers))
+	for key, timer := range t.timers {
+		if timer.Stop() {
+			keys = append(keys, key)
+		}
+	}
+	t.mu.Unlock()
+	for _, key := range keys {
+		t.release(key)
+	}
+}
+
+// stop drops the held updates and stops further deliveries.
+func (t *throttle) stop() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	for key, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, key)
+		delete(t.held, key)
+	}
+}
@@ -0,0 +1,81 @@
+package streaming_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go/streaming"
+)
+
+func TestThrottledUpdates(t *testing.T) {
+	frame := func(pair, id string) string {
+		return `{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"` +
+			pair + `","data":{"id":"` + id + `"}}}`
+	}
+	journal := strings.Join([]string{
+		frame("BTCZAR", "b1"),
+		frame("BTCZAR", "b2"),
+		frame("ETHZAR", "e1"),
+		frame("BTCZAR", "b3"),
+	}, "\n")
+
+	tests := []struct {
+		name string
+		opt  streaming.DialOption
+		want string
+	}{
+		{"unthrottled", streaming.WithSampling(0), "b1 b2 e1 b3"},
+		{"sampling", streaming.WithSampling(time.Hour), "b1 e1"},
+		{"conflation", streaming.WithConflation(time.Hour), "b1 e1 b3"},
+	}
+	for _, test := range tests {
+		var got []string
+		err := streaming.Replay(context.Background(), strings.NewReader(journal), test.opt,
+			streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+				got = append(got, m.Data.ID)
+			}))
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if s := strings.Join(got, " "); s != test.want {
+			t.Errorf("%s: Expected %q, got %q", test.name, test.want, s)
+		}
+	}
+}
+
+func TestConflationReleasesLatest(t *testing.T) {
+	got := make(chan string, 4)
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- streaming.Replay(context.Background(), r,
+			streaming.WithConflation(100*time.Millisecond),
+			streaming.WithUpdateCallback(func(m streaming.MessageTradeUpdate) {
+				got <- m.Data.ID
+			}))
+	}()
+
+	for _, id := range []string{"b1", "b2", "b3"} {
+		io.WriteString(w, `{"time":"2024-01-02T03:04:05Z","stream":"trade","frame":{"type":"NEW_TRADE","currencyPairSymbol":"BTCZAR","data":{"id":"`+id+`"}}}`+"\n")
+	}
+	for _, want := range []string{"b1", "b3"} {
+		select {
+		case id := <-got:
+			if id != want {
+				t.Errorf("Expected %q, got %q", want, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %q to be delivered", want)
+		}
+	}
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected nothing more, got %q", <-got)
+	}
+}
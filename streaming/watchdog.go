@@ -0,0 +1,85 @@
+package streaming
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StallDetected describes a connection that was dropped by the watchdog
+// because the server went silent.
+type StallDetected struct {
+	// LastMessage is when the last frame or pong was received.
+	LastMessage time.Time
+	// Silence is how long the connection had been silent when it was dropped.
+	Silence time.Duration
+}
+
+// StallCallback is notified when the watchdog drops a stalled connection.
+type StallCallback func(StallDetected)
+
+// WithStallTimeout enables a watchdog that closes and reconnects the
+// connection if nothing, including pongs, is received from the server for d.
+// This detects silent stalls sooner than the read deadline does. The
+// watchdog is disabled by default.
+func WithStallTimeout(d time.Duration) DialOption {
+	return func(c *Conn) {
+		c.stallTimeout = d
+	}
+}
+
+// WithStallCallback sets a callback notified whenever the watchdog detects a
+// stall, before the connection is reset.
+func WithStallCallback(fn StallCallback) DialOption {
+	return func(c *Conn) {
+		c.stallCallback = fn
+	}
+}
+
+// LastMessage returns when a frame or pong was last received from the
+// server, including the answers to pings, so it advances on quiet markets
+// while the connection is healthy. It is zero before the first connection.
+func (c *Conn) LastMessage() time.Time {
+	ns := c.lastMessage.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// markAlive records that something was received from the server.
+func (c *Conn) markAlive() {
+	c.lastMessage.Store(time.Now().UnixNano())
+}
+
+// watchdog closes the websocket once it has been silent for longer than the
+// stall timeout, which makes the read loop return and the connection
+// reconnect.
+func (c *Conn) watchdog(ctx context.Context) {
+	if c.stallTimeout <= 0 {
+		return
+	}
+	c.markAlive()
+
+	ticker := time.NewTicker(c.stallTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			last := time.Unix(0, c.lastMessage.Load())
+			silence := now.Sub(last)
+			if silence < c.stallTimeout {
+				continue
+			}
+			log.Printf("valr/streaming: No messages for %s, reconnecting", silence)
+			if c.stallCallback != nil {
+				c.call(CallbackStall, func() { c.stallCallback(StallDetected{LastMessage: last, Silence: silence}) })
+			}
+			_ = c.ws.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package streaming
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWriteRate  = 10
+	defaultWriteBurst = 20
+)
+
+// WithWriteRateLimit limits the frames sent to the server, subscriptions
+// and pings alike, to rate per second in bursts of up to burst, so that
+// bulk subscription changes can't trip VALR's websocket message limits and
+// get the connection closed. Frames over the limit wait their turn. The
+// default is 10 frames per second in bursts of 20; a rate of zero removes
+// the limit.
+func WithWriteRateLimit(rate float64, burst int) DialOption {
+	return func(c *Conn) {
+		c.writeLimit = newWriteLimiter(rate, burst)
+	}
+}
+
+// writeLimiter is a token bucket pacing outbound frames. A nil limiter
+// never waits.
+type writeLimiter struct {
+	interval time.Duration
+	burst    float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newWriteLimiter(rate float64, burst int) *writeLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	b := float64(max(burst, 1))
+	return &writeLimiter{interval: time.Duration(float64(time.Second) / rate), burst: b, tokens: b}
+}
+
+// wait blocks until a frame may be sent, or returns ctx's error if it is
+// done first.
+func (l *writeLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return nil
+	}
+
+	d := time.Duration((1 - l.tokens) * float64(l.interval))
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// The token accrued while waiting is spent on this frame.
+	l.tokens, l.last = 0, now.Add(d)
+	return nil
+}
@@ -0,0 +1,16 @@
+package valr
+
+import "context"
+
+type subaccountKey struct{}
+
+// WithSubaccount returns a context that makes authenticated calls act on
+// behalf of the given subaccount rather than the primary account.
+func WithSubaccount(ctx context.Context, subaccountID string) context.Context {
+	return context.WithValue(ctx, subaccountKey{}, subaccountID)
+}
+
+func subaccountFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(subaccountKey{}).(string)
+	return id
+}
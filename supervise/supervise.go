@@ -0,0 +1,240 @@
+// Package supervise recovers panics in user callbacks, such as streaming
+// update handlers, risk breach callbacks and notification sinks, so one
+// buggy handler doesn't kill a connection or the whole process. What
+// happens after a panic is decided by a Policy, per callback if needed, and
+// every panic is reported as a Panic event.
+package supervise
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go/notify"
+)
+
+// Policy decides what happens after a callback panics.
+type Policy int
+
+const (
+	// Restart recovers the panic and keeps calling the callback with later
+	// events. The event that caused the panic is lost to it.
+	Restart Policy = iota
+	// Drop recovers the panic and stops calling the callback.
+	Drop
+	// Escalate reports the panic and panics again, crashing the goroutine
+	// as if the callback were unsupervised.
+	Escalate
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Restart:
+		return "restart"
+	case Drop:
+		return "drop"
+	case Escalate:
+		return "escalate"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+const notifyTimeout = 30 * time.Second
+
+// Panic describes a panic recovered from a callback.
+type Panic struct {
+	// Callback names the callback, e.g. "streaming.update".
+	Callback string
+	Value    any
+	Stack    []byte
+	// Policy is the policy applied.
+	Policy Policy
+	Time   time.Time
+}
+
+// PanicCallback is notified of every panic, before the policy is applied.
+type PanicCallback func(Panic)
+
+type Option func(*Supervisor)
+
+// WithPolicy sets the policy of callbacks without their own, Restart by
+// default.
+func WithPolicy(p Policy) Option {
+	return func(s *Supervisor) {
+		s.policy = p
+	}
+}
+
+// WithPolicyFor sets the policy of the named callback.
+func WithPolicyFor(callback string, p Policy) Option {
+	return func(s *Supervisor) {
+		s.policies[callback] = p
+	}
+}
+
+// WithMaxRestarts drops a callback once it has panicked more than n times
+// under the Restart policy. Zero, the default, restarts indefinitely.
+func WithMaxRestarts(n int) Option {
+	return func(s *Supervisor) {
+		s.maxRestarts = n
+	}
+}
+
+// WithPanicCallback sets a callback notified of every panic. It is not
+// itself supervised.
+func WithPanicCallback(fn PanicCallback) Option {
+	return func(s *Supervisor) {
+		s.onPanic = fn
+	}
+}
+
+// WithNotifier delivers a critical notification of every panic to n.
+func WithNotifier(n notify.Sink) Option {
+	return func(s *Supervisor) {
+		s.notifier = n
+	}
+}
+
+// Supervisor runs callbacks, recovering their panics according to its
+// policies. A nil *Supervisor runs callbacks unsupervised, so modules can
+// hold one unconditionally.
+type Supervisor struct {
+	policy      Policy
+	policies    map[string]Policy
+	maxRestarts int
+	onPanic     PanicCallback
+	notifier    notify.Sink
+
+	mu      sync.Mutex
+	panics  map[string]int
+	dropped map[string]bool
+}
+
+// New returns a supervisor.
+func New(opts ...Option) *Supervisor {
+	s := &Supervisor{
+		policy:   Restart,
+		policies: make(map[string]Policy),
+		panics:   make(map[string]int),
+		dropped:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Call runs fn, the named callback, recovering a panic according to the
+// callback's policy. It returns false if fn panicked or was not called
+// because the callback has been dropped.
+func (s *Supervisor) Call(callback string, fn func()) (ok bool) {
+	if s == nil {
+		fn()
+		return true
+	}
+	s.mu.Lock()
+	dropped := s.dropped[callback]
+	s.mu.Unlock()
+	if dropped {
+		return false
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			ok = false
+			s.recovered(callback, v, debug.Stack())
+		}
+	}()
+	fn()
+	return true
+}
+
+// recovered reports a panic and applies the callback's policy.
+func (s *Supervisor) recovered(callback string, v any, stack []byte) {
+	s.mu.Lock()
+	p, ok := s.policies[callback]
+	if !ok {
+		p = s.policy
+	}
+	s.panics[callback]++
+	if p == Restart && s.maxRestarts > 0 && s.panics[callback] > s.maxRestarts {
+		p = Drop
+	}
+	if p == Drop {
+		s.dropped[callback] = true
+	}
+	s.mu.Unlock()
+
+	ev := Panic{Callback: callback, Value: v, Stack: stack, Policy: p, Time: time.Now()}
+	log.Printf("valr/supervise: Callback %s panicked (%s): %v\n%s", callback, p, v, stack)
+	if s.onPanic != nil {
+		s.onPanic(ev)
+	}
+	if s.notifier != nil {
+		notify.Send(s.notifier, panicMessage(ev), notifyTimeout)
+	}
+	if p == Escalate {
+		panic(v)
+	}
+}
+
+func panicMessage(ev Panic) notify.Message {
+	return notify.Message{
+		Level:  notify.Critical,
+		Source: "supervise",
+		Title:  fmt.Sprintf("Callback %s panicked", ev.Callback),
+		Text:   fmt.Sprint(ev.Value),
+		Fields: []notify.Field{{Name: "policy", Value: ev.Policy.String()}},
+		Time:   ev.Time,
+	}
+}
+
+// Sink wraps the named notification sink, turning its panics into errors.
+// The sink is supervised like any other callback, so a dropped sink fails
+// every delivery.
+func (s *Supervisor) Sink(callback string, sink notify.Sink) notify.Sink {
+	return notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+		var err error
+		if !s.Call(callback, func() { err = sink.Notify(ctx, m) }) {
+			return fmt.Errorf("supervise: sink %s panicked or was dropped", callback)
+		}
+		return err
+	})
+}
+
+// Panics returns the number of panics of each callback that has panicked.
+func (s *Supervisor) Panics() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.panics))
+	for k, v := range s.panics {
+		out[k] = v
+	}
+	return out
+}
+
+// Dropped returns the callbacks that have been dropped, sorted.
+func (s *Supervisor) Dropped() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for k := range s.dropped {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Reinstate resumes calling a dropped callback, e.g. once the bug has been
+// worked around, and resets its panic count.
+func (s *Supervisor) Reinstate(callback string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.dropped, callback)
+	delete(s.panics, callback)
+}
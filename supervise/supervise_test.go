@@ -0,0 +1,116 @@
+package supervise_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/donohutcheon/valr-go/notify"
+	"github.com/donohutcheon/valr-go/supervise"
+)
+
+func TestRestart(t *testing.T) {
+	var panics []supervise.Panic
+	s := supervise.New(supervise.WithPanicCallback(func(p supervise.Panic) {
+		panics = append(panics, p)
+	}))
+	calls := 0
+	for i := 0; i < 3; i++ {
+		ok := s.Call("cb", func() {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+		})
+		if ok != (i > 0) {
+			t.Errorf("Call %d: Expected %v, got %v", i, i > 0, ok)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Expected %d calls, got %d", 3, calls)
+	}
+	if len(panics) != 1 || panics[0].Value != "boom" || panics[0].Policy != supervise.Restart || len(panics[0].Stack) == 0 {
+		t.Errorf("Expected a single restarted panic, got %+v", panics)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	s := supervise.New(supervise.WithPolicy(supervise.Restart), supervise.WithPolicyFor("bad", supervise.Drop))
+	calls := 0
+	for i := 0; i < 3; i++ {
+		s.Call("bad", func() {
+			calls++
+			panic("boom")
+		})
+	}
+	if calls != 1 {
+		t.Errorf("Expected %d calls, got %d", 1, calls)
+	}
+	if d := s.Dropped(); len(d) != 1 || d[0] != "bad" {
+		t.Errorf("Expected %q dropped, got %q", "bad", d)
+	}
+
+	s.Reinstate("bad")
+	s.Call("bad", func() { calls++ })
+	if calls != 2 || len(s.Dropped()) != 0 {
+		t.Errorf("Expected the callback to be reinstated, got %d calls and %q dropped", calls, s.Dropped())
+	}
+}
+
+func TestMaxRestarts(t *testing.T) {
+	s := supervise.New(supervise.WithMaxRestarts(2))
+	calls := 0
+	for i := 0; i < 5; i++ {
+		s.Call("cb", func() {
+			calls++
+			panic("boom")
+		})
+	}
+	if calls != 3 {
+		t.Errorf("Expected %d calls, got %d", 3, calls)
+	}
+	if n := s.Panics()["cb"]; n != 3 {
+		t.Errorf("Expected %d panics, got %d", 3, n)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	var reported bool
+	s := supervise.New(supervise.WithPolicy(supervise.Escalate), supervise.WithPanicCallback(func(supervise.Panic) {
+		reported = true
+	}))
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("Expected %q, got %v", "boom", v)
+		}
+		if !reported {
+			t.Error("Expected the panic to be reported")
+		}
+	}()
+	s.Call("cb", func() { panic("boom") })
+	t.Error("Expected the panic to escalate")
+}
+
+func TestSink(t *testing.T) {
+	s := supervise.New()
+	sink := s.Sink("slack", notify.SinkFunc(func(ctx context.Context, m notify.Message) error {
+		if m.Title == "panic" {
+			panic("boom")
+		}
+		return errors.New("unreachable")
+	}))
+	if err := sink.Notify(context.Background(), notify.Message{Title: "panic"}); err == nil {
+		t.Error("Expected an error for the panicking sink")
+	}
+	if err := sink.Notify(context.Background(), notify.Message{Title: "ok"}); err == nil || err.Error() != "unreachable" {
+		t.Errorf("Expected the sink's error, got %v", err)
+	}
+}
+
+func TestNilSupervisor(t *testing.T) {
+	var s *supervise.Supervisor
+	called := false
+	if !s.Call("cb", func() { called = true }) || !called {
+		t.Error("Expected a nil supervisor to call the callback")
+	}
+}
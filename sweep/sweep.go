@@ -0,0 +1,329 @@
+// Package sweep keeps a futures account funded. A Sweeper monitors the
+// margin of the account and, when its margin ratio falls below a threshold,
+// sweeps collateral from a spot account with a subaccount transfer.
+//
+// VALR margins futures against the collateral of the account holding the
+// positions, so sweeping applies where futures trade in a dedicated
+// subaccount, funded from the primary account. Sweeps are bounded per
+// transfer and per day, never drain the spot account below a reserve, and
+// every decision is reported as an audit Record.
+package sweep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultInterval = time.Minute
+	day             = 24 * time.Hour
+	// places is the precision of the amounts swept.
+	places = 8
+)
+
+// PrimaryAccount is the ID of the primary account in transfers.
+const PrimaryAccount = "0"
+
+// Margin is the margin of a futures account, in the collateral currency.
+type Margin struct {
+	Collateral decimal.Decimal
+	// Required is the margin required by the open positions.
+	Required decimal.Decimal
+}
+
+// Ratio returns the collateral over the required margin, or zero without
+// positions.
+func (m Margin) Ratio() decimal.Decimal {
+	if !m.Required.IsPositive() {
+		return decimal.Zero
+	}
+	return m.Collateral.DivRound(m.Required, places)
+}
+
+// MarginFunc reports the margin of the account being funded.
+type MarginFunc func(ctx context.Context) (Margin, error)
+
+// PositionMargin returns a MarginFunc for the account with the given ID:
+// its collateral is its total balance of currency, and the margin required
+// is the notional of its open positions at their average entry price, times
+// fraction, e.g. the pairs' initial margin fraction.
+func PositionMargin(cl *valr.Client, account, currency string, fraction decimal.Decimal) MarginFunc {
+	return func(ctx context.Context) (Margin, error) {
+		ctx = accountContext(ctx, account)
+		collateral, err := balance(ctx, cl, currency, func(b valr.AccountBalance) decimal.Decimal { return b.Total })
+		if err != nil {
+			return Margin{}, err
+		}
+		positions, err := cl.GetOpenPositionsRequest(ctx, &valr.GetOpenPositionsRequest{})
+		if err != nil {
+			return Margin{}, err
+		}
+		required := decimal.Zero
+		for _, p := range positions {
+			required = required.Add(p.Quantity.Abs().Mul(p.AverageEntryPrice).Mul(fraction))
+		}
+		return Margin{Collateral: collateral, Required: required}, nil
+	}
+}
+
+// Config sets what a Sweeper moves and when.
+type Config struct {
+	// From is the ID of the spot account funds are swept from,
+	// PrimaryAccount by default.
+	From string
+	// To is the ID of the futures account funds are swept to.
+	To string
+	// Currency is the collateral currency swept, e.g. "USDC".
+	Currency string
+	// Threshold is the margin ratio below which funds are swept.
+	Threshold decimal.Decimal
+	// Target is the margin ratio a sweep restores, at least Threshold.
+	Target decimal.Decimal
+	// MaxTransfer, if positive, caps each sweep.
+	MaxTransfer decimal.Decimal
+	// MaxDaily, if positive, caps the sweeps in any 24 hours.
+	MaxDaily decimal.Decimal
+	// Reserve is the balance always left in the spot account.
+	Reserve decimal.Decimal
+}
+
+// Validate checks the accounts and limits of the configuration.
+func (c Config) Validate() error {
+	switch {
+	case c.To == "" || c.Currency == "":
+		return errors.New("sweep: destination account and currency required")
+	case c.From == c.To || (c.From == "" && c.To == PrimaryAccount):
+		return errors.New("sweep: source and destination accounts are the same")
+	case !c.Threshold.IsPositive():
+		return errors.New("sweep: threshold must be positive")
+	case c.Target.LessThan(c.Threshold):
+		return errors.New("sweep: target below threshold")
+	case c.MaxTransfer.IsNegative() || c.MaxDaily.IsNegative() || c.Reserve.IsNegative():
+		return errors.New("sweep: negative limit")
+	}
+	return nil
+}
+
+// Sweep outcomes.
+const (
+	// StatusSwept is a completed transfer, possibly less than wanted.
+	StatusSwept = "swept"
+	// StatusSkipped is a sweep that was needed but not made, because of the
+	// limits or the spot balance.
+	StatusSkipped = "skipped"
+	// StatusFailed is a transfer that failed. Unless VALR rejected it, it
+	// may still have been made, so it counts towards the daily limit.
+	StatusFailed = "failed"
+)
+
+// Record is the audit record of a sweep decision.
+type Record struct {
+	Time   time.Time
+	Margin Margin
+	// Wanted is the amount that would restore the target ratio.
+	Wanted decimal.Decimal
+	// Amount is the amount swept, after the limits.
+	Amount decimal.Decimal
+	Status string
+	// Reason explains limited, skipped and failed sweeps.
+	Reason string
+}
+
+type Option func(*Sweeper)
+
+// WithAudit sets a function receiving the record of every sweep decision.
+func WithAudit(fn func(Record)) Option {
+	return func(s *Sweeper) {
+		s.audit = fn
+	}
+}
+
+// WithInterval sets how often Run checks the margin, every minute by
+// default.
+func WithInterval(d time.Duration) Option {
+	return func(s *Sweeper) {
+		s.interval = d
+	}
+}
+
+// WithClock sets the clock of records and the daily limit, clock.Real by
+// default.
+func WithClock(c clock.Clock) Option {
+	return func(s *Sweeper) {
+		s.clock = c
+	}
+}
+
+// Sweeper tops up a futures account from a spot account.
+type Sweeper struct {
+	client   *valr.Client
+	margin   MarginFunc
+	cfg      Config
+	audit    func(Record)
+	interval time.Duration
+	clock    clock.Clock
+
+	// swept holds the sweeps of the last day, oldest first.
+	swept []sweep
+}
+
+type sweep struct {
+	at     time.Time
+	amount decimal.Decimal
+}
+
+// New returns a Sweeper funding cfg.To whenever margin reports a ratio
+// below cfg.Threshold.
+func New(cl *valr.Client, margin MarginFunc, cfg Config, opts ...Option) (*Sweeper, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.From == "" {
+		cfg.From = PrimaryAccount
+	}
+	s := &Sweeper{
+		client:   cl,
+		margin:   margin,
+		cfg:      cfg,
+		interval: defaultInterval,
+		clock:    clock.Real,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Check sweeps funds if the margin ratio is below the threshold, returning
+// the record of the decision, or nil if no sweep was needed. It must not be
+// called concurrently.
+func (s *Sweeper) Check(ctx context.Context) (*Record, error) {
+	m, err := s.margin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sweep: reading margin: %w", err)
+	}
+	if !m.Required.IsPositive() || m.Ratio().GreaterThanOrEqual(s.cfg.Threshold) {
+		return nil, nil
+	}
+
+	now := s.clock.Now()
+	rec := &Record{Time: now, Margin: m, Status: StatusSwept}
+	rec.Wanted = s.cfg.Target.Mul(m.Required).Sub(m.Collateral).Truncate(places)
+	amount := rec.Wanted
+	limit := func(max decimal.Decimal, reason string) {
+		if amount.GreaterThan(max) {
+			amount, rec.Reason = max, reason
+		}
+	}
+
+	if s.cfg.MaxTransfer.IsPositive() {
+		limit(s.cfg.MaxTransfer, "transfer limit")
+	}
+	if s.cfg.MaxDaily.IsPositive() {
+		limit(s.cfg.MaxDaily.Sub(s.sweptToday(now)), "daily limit")
+	}
+	available, err := balance(accountContext(ctx, s.cfg.From), s.client, s.cfg.Currency,
+		func(b valr.AccountBalance) decimal.Decimal { return b.Available })
+	if err != nil {
+		return nil, fmt.Errorf("sweep: reading spot balance: %w", err)
+	}
+	limit(available.Sub(s.cfg.Reserve).Truncate(places), "spot balance")
+
+	if !amount.IsPositive() {
+		rec.Amount, rec.Status = decimal.Zero, StatusSkipped
+		s.record(*rec)
+		return rec, nil
+	}
+	rec.Amount = amount
+
+	_, err = s.client.PostSubaccountTransferRequest(ctx, &valr.PostSubaccountTransferRequest{
+		FromID:   s.cfg.From,
+		ToID:     s.cfg.To,
+		Currency: s.cfg.Currency,
+		Amount:   amount,
+	})
+	if err != nil {
+		if !valr.IsRejected(err) {
+			// The transfer may have been made with only the response lost.
+			s.swept = append(s.swept, sweep{at: now, amount: amount})
+		}
+		rec.Status, rec.Reason = StatusFailed, err.Error()
+		s.record(*rec)
+		return rec, fmt.Errorf("sweep: transferring %s %s: %w", amount, s.cfg.Currency, err)
+	}
+	s.swept = append(s.swept, sweep{at: now, amount: amount})
+	s.record(*rec)
+	return rec, nil
+}
+
+// Run checks the margin every interval until ctx is done. Failures are
+// logged and retried at the next check.
+func (s *Sweeper) Run(ctx context.Context) error {
+	for {
+		if rec, err := s.Check(ctx); err != nil {
+			log.Printf("valr/sweep: %v", err)
+		} else if rec != nil {
+			log.Printf("valr/sweep: %s %s %s of %s wanted at margin ratio %s",
+				rec.Status, rec.Amount, s.cfg.Currency, rec.Wanted, rec.Margin.Ratio())
+		}
+		t := s.clock.NewTimer(s.interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+// sweptToday returns the total swept in the day to now, forgetting older
+// sweeps.
+func (s *Sweeper) sweptToday(now time.Time) decimal.Decimal {
+	for len(s.swept) > 0 && !s.swept[0].at.After(now.Add(-day)) {
+		s.swept = s.swept[1:]
+	}
+	total := decimal.Zero
+	for _, sw := range s.swept {
+		total = total.Add(sw.amount)
+	}
+	return total
+}
+
+func (s *Sweeper) record(rec Record) {
+	if s.audit != nil {
+		s.audit(rec)
+	}
+}
+
+// accountContext returns ctx acting on behalf of account, unless it is the
+// primary account.
+func accountContext(ctx context.Context, account string) context.Context {
+	if account == "" || account == PrimaryAccount {
+		return ctx
+	}
+	return valr.WithSubaccount(ctx, account)
+}
+
+// balance returns a field of the balance of currency, or zero if the
+// account holds none.
+func balance(ctx context.Context, cl *valr.Client, currency string,
+	field func(valr.AccountBalance) decimal.Decimal) (decimal.Decimal, error) {
+
+	balances, err := cl.GetAccountBalancesRequest(ctx, &valr.GetAccountBalancesRequest{})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, b := range balances {
+		if b.Currency == currency {
+			return field(b), nil
+		}
+	}
+	return decimal.Zero, nil
+}
@@ -0,0 +1,208 @@
+package sweep_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/clock"
+	"github.com/donohutcheon/valr-go/sweep"
+	"github.com/shopspring/decimal"
+)
+
+func TestSweeper(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		spot       = decimal.RequireFromString("500")
+		collateral = decimal.RequireFromString("100")
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		account := r.Header.Get("X-VALR-SUB-ACCOUNT-ID")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /account/balances":
+			b := spot
+			if account == "futures" {
+				b = collateral
+			}
+			fmt.Fprintf(w, `[{"currency":"USDC","available":"%s","total":"%s"}]`, b, b)
+		case "GET /positions/open":
+			if account != "futures" {
+				t.Errorf("Expected positions of the futures account, got %q", account)
+			}
+			w.Write([]byte(`[{"pair":"BTCUSDCPERP","side":"sell","quantity":"-1","averageEntryPrice":"1000"}]`))
+		case "POST /account/subaccounts/transfer":
+			var req valr.PostSubaccountTransferRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("Expected success, got %v", err)
+			}
+			if req.FromID != "0" || req.ToID != "futures" || req.Currency != "USDC" {
+				t.Errorf("Expected a USDC transfer to the futures account, got %+v", req)
+			}
+			spot = spot.Sub(req.Amount)
+			collateral = collateral.Add(req.Amount)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	clk := clock.NewManual(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	var records []sweep.Record
+	s, err := sweep.New(cl,
+		sweep.PositionMargin(cl, "futures", "USDC", decimal.RequireFromString("0.1")),
+		sweep.Config{
+			To:          "futures",
+			Currency:    "USDC",
+			Threshold:   decimal.RequireFromString("1.5"),
+			Target:      decimal.RequireFromString("2"),
+			MaxTransfer: decimal.RequireFromString("60"),
+			MaxDaily:    decimal.RequireFromString("100"),
+			Reserve:     decimal.RequireFromString("100"),
+		},
+		sweep.WithClock(clk),
+		sweep.WithAudit(func(rec sweep.Record) { records = append(records, rec) }))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	ctx := context.Background()
+	check := func(status, amount, reason string) {
+		t.Helper()
+		rec, err := s.Check(ctx)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if status == "" {
+			if rec != nil {
+				t.Errorf("Expected no sweep, got %+v", rec)
+			}
+			return
+		}
+		if rec == nil || rec.Status != status || rec.Amount.String() != amount || rec.Reason != reason {
+			t.Errorf("Expected %s %s (%s), got %+v", status, amount, reason, rec)
+		}
+	}
+
+	// Margin ratio 1: 100 is wanted to restore 2, capped per transfer.
+	check(sweep.StatusSwept, "60", "transfer limit")
+	// Ratio 1.6 is above the threshold.
+	check("", "", "")
+
+	mu.Lock()
+	collateral = decimal.RequireFromString("50")
+	mu.Unlock()
+	check(sweep.StatusSwept, "40", "daily limit")
+	check(sweep.StatusSkipped, "0", "daily limit")
+
+	clk.Advance(25 * time.Hour)
+	mu.Lock()
+	spot = decimal.RequireFromString("130")
+	mu.Unlock()
+	check(sweep.StatusSwept, "30", "spot balance")
+
+	if len(records) != 4 {
+		t.Errorf("Expected 4 audit records, got %+v", records)
+	}
+	if exp := "0.5"; records[1].Margin.Ratio().String() != exp {
+		t.Errorf("Expected %q, got %q", exp, records[1].Margin.Ratio())
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := sweep.Config{To: "futures", Currency: "USDC", Threshold: decimal.New(15, -1), Target: decimal.New(2, 0)}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	for _, c := range []sweep.Config{
+		{Currency: "USDC", Threshold: decimal.New(1, 0), Target: decimal.New(2, 0)},
+		{To: "0", Currency: "USDC", Threshold: decimal.New(1, 0), Target: decimal.New(2, 0)},
+		{To: "futures", Currency: "USDC", Threshold: decimal.New(2, 0), Target: decimal.New(1, 0)},
+		{To: "futures", Currency: "USDC", Threshold: decimal.New(1, 0), Target: decimal.New(2, 0), MaxDaily: decimal.New(-1, 0)},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", c)
+		}
+	}
+}
+
+func TestSweeperFailedTransfer(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		status = http.StatusBadRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "GET /account/balances":
+			b := "500"
+			if r.Header.Get("X-VALR-SUB-ACCOUNT-ID") == "futures" {
+				b = "100"
+			}
+			fmt.Fprintf(w, `[{"currency":"USDC","available":"%s","total":"%s"}]`, b, b)
+		case "GET /positions/open":
+			w.Write([]byte(`[{"pair":"BTCUSDCPERP","side":"sell","quantity":"-1","averageEntryPrice":"1000"}]`))
+		case "POST /account/subaccounts/transfer":
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":-1,"message":"failed"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	s, err := sweep.New(cl,
+		sweep.PositionMargin(cl, "futures", "USDC", decimal.RequireFromString("0.1")),
+		sweep.Config{
+			To:        "futures",
+			Currency:  "USDC",
+			Threshold: decimal.RequireFromString("1.5"),
+			Target:    decimal.RequireFromString("2"),
+			MaxDaily:  decimal.RequireFromString("150"),
+		},
+		sweep.WithClock(clock.NewManual(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	check := func(amount string) {
+		t.Helper()
+		rec, err := s.Check(context.Background())
+		if err == nil || rec == nil || rec.Status != sweep.StatusFailed || rec.Amount.String() != amount {
+			t.Errorf("Expected a failed transfer of %s, got %+v, %v", amount, rec, err)
+		}
+	}
+
+	// A rejected transfer was not made, so doesn't count towards the daily
+	// limit.
+	check("100")
+	check("100")
+
+	// A transfer failing with a server error may have been made.
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	check("100")
+	check("50")
+}
@@ -0,0 +1,134 @@
+package valr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MonotonicTimestamps issues strictly increasing millisecond timestamps for
+// signing requests. VALR rejects timestamps that go backwards, which can
+// otherwise happen when NTP steps the system clock back or when several
+// requests are signed within the same millisecond.
+//
+// While the clock is behind the last issued timestamp, timestamps advance by
+// one millisecond per request until the clock catches up.
+type MonotonicTimestamps struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	last int64
+}
+
+// NewMonotonicTimestamps returns a timestamp source reading now, or
+// time.Now if nil.
+func NewMonotonicTimestamps(now func() time.Time) *MonotonicTimestamps {
+	if now == nil {
+		now = time.Now
+	}
+	return &MonotonicTimestamps{now: now}
+}
+
+// Next returns the next timestamp in milliseconds since the Unix epoch.
+func (m *MonotonicTimestamps) Next() int64 {
+	ts := m.now().UnixMilli()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ts <= m.last {
+		ts = m.last + 1
+	}
+	m.last = ts
+	return ts
+}
+
+// signingTimestamps is shared by all clients, since requests signed with the
+// same API key must be ordered regardless of the client used.
+var signingTimestamps = NewMonotonicTimestamps(nil)
+
+// NextTimestamp returns the next signing timestamp, as passed to
+// SignRequest and Signer.Sign.
+func NextTimestamp() string {
+	return strconv.FormatInt(signingTimestamps.Next(), 10)
+}
+
+// Timestamp is a time in a VALR payload. VALR encodes times either as RFC
+// 3339 strings or as milliseconds since the Unix epoch, as numbers or
+// strings, and Timestamp decodes all of them, in UTC. It embeds time.Time,
+// so timestamps compare with Before, After and Equal like times, and
+// encode as RFC 3339.
+type Timestamp struct {
+	time.Time
+}
+
+// NewTimestamp returns the Timestamp of t.
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{t}
+}
+
+// TimestampFromMillis returns the Timestamp ms milliseconds after the Unix
+// epoch, in UTC.
+func TimestampFromMillis(ms int64) Timestamp {
+	return Timestamp{time.UnixMilli(ms).UTC()}
+}
+
+// Millis returns the timestamp in milliseconds since the Unix epoch, or 0
+// if it is zero.
+func (t Timestamp) Millis() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// timestampLayouts are the string formats of times VALR is known to send,
+// after RFC 3339. Times without a zone are in UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// UnmarshalJSON decodes an RFC 3339 string or epoch milliseconds. Null and
+// empty strings decode as the zero Timestamp.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*t = Timestamp{}
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
+// ParseTimestamp parses s as epoch milliseconds or an RFC 3339 time. An
+// empty string is the zero Timestamp.
+func ParseTimestamp(s string) (Timestamp, error) {
+	if s == "" {
+		return Timestamp{}, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return TimestampFromMillis(ms), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return TimestampFromMillis(int64(f)), nil
+	}
+	for _, layout := range timestampLayouts {
+		if tm, err := time.Parse(layout, s); err == nil {
+			return Timestamp{tm.UTC()}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("valr: invalid timestamp %q", s)
+}
@@ -0,0 +1,81 @@
+package valr_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+)
+
+func TestMonotonicTimestamps(t *testing.T) {
+	t0 := time.UnixMilli(1000)
+	clock := []time.Time{t0, t0, t0.Add(-time.Second), t0.Add(time.Second)}
+	i := 0
+	ts := valr.NewMonotonicTimestamps(func() time.Time {
+		now := clock[i]
+		i++
+		return now
+	})
+
+	exp := []int64{1000, 1001, 1002, 2000}
+	for _, e := range exp {
+		if act := ts.Next(); act != e {
+			t.Errorf("Expected %d, got %d", e, act)
+		}
+	}
+}
+
+func TestTimestampUnmarshal(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 15, 123000000, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"2024-03-01T12:30:15.123Z"`, want},
+		{`"2024-03-01T14:30:15.123+02:00"`, want},
+		{`"2024-03-01T12:30:15.123"`, want},
+		{`1709296215123`, want},
+		{`"1709296215123"`, want},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+	}
+	for _, test := range tests {
+		var v struct {
+			At valr.Timestamp `json:"at"`
+		}
+		if err := json.Unmarshal([]byte(`{"at":`+test.in+`}`), &v); err != nil {
+			t.Errorf("%s: Expected success, got %v", test.in, err)
+			continue
+		}
+		if !v.At.Equal(test.want) {
+			t.Errorf("%s: Expected %v, got %v", test.in, test.want, v.At)
+		}
+	}
+
+	var ts valr.Timestamp
+	if err := json.Unmarshal([]byte(`"yesterday"`), &ts); err == nil {
+		t.Errorf("Expected error, got %v", ts)
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	ts := valr.TimestampFromMillis(1709296215123)
+	if ts.Millis() != 1709296215123 {
+		t.Errorf("Expected %d, got %d", int64(1709296215123), ts.Millis())
+	}
+	b, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if string(b) != `"2024-03-01T12:30:15.123Z"` {
+		t.Errorf("Expected %s, got %s", `"2024-03-01T12:30:15.123Z"`, b)
+	}
+	var back valr.Timestamp
+	if err := json.Unmarshal(b, &back); err != nil || back != ts {
+		t.Errorf("Expected %v, got %v (%v)", ts, back, err)
+	}
+	if (valr.Timestamp{}).Millis() != 0 {
+		t.Errorf("Expected 0, got %d", (valr.Timestamp{}).Millis())
+	}
+}
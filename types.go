@@ -1,7 +1,7 @@
 package valr
 
 import (
-	"time"
+	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -17,8 +17,10 @@ type OrderBookEntry struct {
 
 // OrderBook holds OrderBookEntries
 type OrderBook struct {
-	Asks []OrderBookEntry `json:"Asks"`
-	Bids []OrderBookEntry `json:"Bids"`
+	Asks           []OrderBookEntry `json:"Asks"`
+	Bids           []OrderBookEntry `json:"Bids"`
+	LastChange     Timestamp        `json:"LastChange"`
+	SequenceNumber int64            `json:"SequenceNumber"`
 }
 
 // CurrencyInfo holds info for a specific asset
@@ -65,8 +67,8 @@ type MarketSummary struct {
 	BaseVolume         decimal.Decimal `json:"baseVolume"`
 	HighPrice          decimal.Decimal `json:"highPrice"`
 	LowPrice           decimal.Decimal `json:"lowPrice"`
-	Created            time.Time       `json:"created"`
-	ChangeFromPrevious string          `json:"changeFromPrevious"`
+	Created            Timestamp       `json:"created"`
+	ChangeFromPrevious decimal.Decimal `json:"changeFromPrevious"`
 }
 
 // AccountBalance represent the balance info for a specific asset
@@ -86,8 +88,9 @@ type TransactionInfo struct {
 	CreditValue     decimal.Decimal           `json:"creditValue"`
 	FeeCurrency     string                    `json:"feeCurrency"`
 	FeeValue        decimal.Decimal           `json:"feeValue"`
-	EventAt         time.Time                 `json:"eventAt"`
+	EventAt         Timestamp                 `json:"eventAt"`
 	AdditionalInfo  AdditionalTransactionInfo `json:"additionalInfo"`
+	ID              string                    `json:"id"`
 }
 
 // TransactionType associates a transction type with its description
@@ -102,6 +105,8 @@ type AdditionalTransactionInfo struct {
 	CostPerCoinSymbol  string          `json:"costPerCoinSymbol"`
 	CurrencyPairSymbol string          `json:"currencyPairSymbol"`
 	OrderID            string          `json:"orderId"`
+	// Reference is the payment reference of fiat deposits.
+	Reference string `json:"reference"`
 }
 
 // TradeInfo holds info about a specific trade
@@ -109,7 +114,7 @@ type TradeInfo struct {
 	Price    decimal.Decimal `json:"price"`
 	Quantity decimal.Decimal `json:"quantity"`
 	Pair     string          `json:"currencyPair"`
-	TradedAt time.Time       `json:"tradedAt"`
+	TradedAt Timestamp       `json:"tradedAt"`
 	Side     ResponseSide    `json:"side"`
 	TradeID  int             `json:"tradeId"`
 }
@@ -120,10 +125,10 @@ type DepositInfo struct {
 	ReceiveAddress  string          `json:"receiveAddress"`
 	TransactionHash string          `json:"transactionHash"`
 	Amount          decimal.Decimal `json:"amount"`
-	CreatedAt       time.Time       `json:"createdAt"`
+	CreatedAt       Timestamp       `json:"createdAt"`
 	Confirmations   int             `json:"confirmations"`
 	Confirmed       bool            `json:"confirmed"`
-	ConfirmedAt     time.Time       `json:"confirmedAt"`
+	ConfirmedAt     Timestamp       `json:"confirmedAt"`
 }
 
 // WithdrawInfo holds info about a specific withdraw
@@ -134,9 +139,9 @@ type WithdrawInfo struct {
 	FeeAmount          decimal.Decimal `json:"feeAmount"`
 	TransactionHash    string          `json:"transactionHash"`
 	Confirmations      int             `json:"confirmations"`
-	LastConfirmationAt time.Time       `json:"lastConfirmationAt"`
+	LastConfirmationAt Timestamp       `json:"lastConfirmationAt"`
 	UniqueID           string          `json:"uniqueId"`
-	CreateAt           time.Time       `json:"createdAt"`
+	CreateAt           Timestamp       `json:"createdAt"`
 	Verified           bool            `json:"verified"`
 	State              string          `json:"status"`
 }
@@ -149,7 +154,7 @@ type BankInfo struct {
 	AccountNumber string    `json:"accountNumber"`
 	BranchCode    string    `json:"branchCode"`
 	AccountType   string    `json:"accountType"`
-	CreatedAt     time.Time `json:"createdAt"`
+	CreatedAt     Timestamp `json:"createdAt"`
 }
 
 // TradeHistoryInfo is the data for a specific trade in trade history
@@ -157,7 +162,7 @@ type TradeHistoryInfo struct {
 	Price      decimal.Decimal `json:"price"`
 	Quantity   decimal.Decimal `json:"quantity"`
 	Pair       string          `json:"currencyPair"`
-	TradedAt   time.Time       `json:"tradedAt"`
+	TradedAt   Timestamp       `json:"tradedAt"`
 	TakerSide  ResponseSide    `json:"takerSide"`
 	SequenceID int             `json:"sequenceId"`
 	ID         string          `json:"id"`
@@ -169,7 +174,7 @@ type OpenOrder struct {
 	Side              ResponseSide    `json:"side"`
 	Price             decimal.Decimal `json:"price"`
 	Pair              string          `json:"currencyPair"`
-	CreatedAt         time.Time       `json:"createdAt"`
+	CreatedAt         Timestamp       `json:"createdAt"`
 	RemainingQuantity decimal.Decimal `json:"remainingQuantity"`
 	OriginalQuantity  decimal.Decimal `json:"originalQuantity"`
 	FilledPercentage  decimal.Decimal `json:"filledPercentage"`
@@ -178,21 +183,24 @@ type OpenOrder struct {
 
 // OrderReceipt collects info for a successful order
 type OrderReceipt struct {
-	OrderID          string          `json:"orderId"`
-	CustomerOrderID  string          `json:"customerOrderId"`
-	OrderStatusType  bool            `json:"orderStatusType"`
-	Pair             string          `json:"currencyPair"`
-	AveragePrice     decimal.Decimal `json:"averagePrice"`
-	OriginalPrice    decimal.Decimal `json:"originalPrice"`
-	Quantity         decimal.Decimal `json:"quantity"`
-	OriginalQuantity decimal.Decimal `json:"originalQuantity"`
-	Total            decimal.Decimal `json:"total"`
-	TotalFee         decimal.Decimal `json:"totalFee"`
-	OrderSide        ResponseSide    `json:"orderSide"`
-	OrderType        string          `json:"orderType"`
-	FailedReason     string          `json:"failedReason"`
-	OrderUpdatedAt   time.Time       `json:"orderUpdatedAt"`
-	OrderCreatedAt   time.Time       `json:"orderCreatedAt"`
+	OrderID           string          `json:"orderId"`
+	CustomerOrderID   string          `json:"customerOrderId"`
+	OrderStatusType   string          `json:"orderStatusType"`
+	Pair              string          `json:"currencyPair"`
+	AveragePrice      decimal.Decimal `json:"averagePrice"`
+	OriginalPrice     decimal.Decimal `json:"originalPrice"`
+	Quantity          decimal.Decimal `json:"quantity"`
+	RemainingQuantity decimal.Decimal `json:"remainingQuantity"`
+	OriginalQuantity  decimal.Decimal `json:"originalQuantity"`
+	Total             decimal.Decimal `json:"total"`
+	TotalFee          decimal.Decimal `json:"totalFee"`
+	FeeCurrency       string          `json:"feeCurrency"`
+	OrderSide         ResponseSide    `json:"orderSide"`
+	OrderType         string          `json:"orderType"`
+	FailedReason      string          `json:"failedReason"`
+	TimeInForce       TimeInForce     `json:"timeInForce"`
+	OrderUpdatedAt    Timestamp       `json:"orderUpdatedAt"`
+	OrderCreatedAt    Timestamp       `json:"orderCreatedAt"`
 }
 
 // OrderStatus holds info related to the status of a specific order
@@ -206,11 +214,28 @@ type OrderStatus struct {
 	OrderSide         ResponseSide    `json:"orderSide"`
 	OrderType         string          `json:"orderType"`
 	FailedReason      string          `json:"failedReason"`
-	OrderUpdatedAt    time.Time       `json:"orderUpdatedAt"`
-	OrderCreatedAt    time.Time       `json:"orderCreatedAt"`
+	OrderUpdatedAt    Timestamp       `json:"orderUpdatedAt"`
+	OrderCreatedAt    Timestamp       `json:"orderCreatedAt"`
 	CustomerOrderID   string          `json:"customerOrderId"`
 }
 
+// OpenPosition holds info for an open futures position
+type OpenPosition struct {
+	Pair                      string          `json:"pair"`
+	Side                      ResponseSide    `json:"side"`
+	Quantity                  decimal.Decimal `json:"quantity"`
+	RealisedPnl               decimal.Decimal `json:"realisedPnl"`
+	UnrealisedPnl             decimal.Decimal `json:"unrealisedPnl"`
+	TotalSessionEntryQuantity decimal.Decimal `json:"totalSessionEntryQuantity"`
+	TotalSessionValue         decimal.Decimal `json:"totalSessionValue"`
+	SessionAverageEntryPrice  decimal.Decimal `json:"sessionAverageEntryPrice"`
+	AverageEntryPrice         decimal.Decimal `json:"averageEntryPrice"`
+	PositionID                string          `json:"positionId"`
+	LeverageTier              int             `json:"leverageTier"`
+	UpdatedAt                 Timestamp       `json:"updatedAt"`
+	CreatedAt                 Timestamp       `json:"createdAt"`
+}
+
 // RequestSide type for explicitly representing the two options
 type RequestSide string
 
@@ -233,3 +258,50 @@ const (
 	PairTypeSpot   PairType = "SPOT"
 	PairTypeFuture PairType = "FUTURE"
 )
+
+// TimeInForce controls how long an order remains on the book
+type TimeInForce string
+
+const (
+	// GoodTillCancelled orders rest on the book until filled or cancelled
+	GoodTillCancelled TimeInForce = "GTC"
+	// ImmediateOrCancel orders fill what they can immediately and cancel the rest
+	ImmediateOrCancel TimeInForce = "IOC"
+	// FillOrKill orders fill completely and immediately or are cancelled
+	FillOrKill TimeInForce = "FOK"
+)
+
+// Order types as reported by the order types endpoints
+const (
+	OrderTypeLimit     = "PLACE_LIMIT"
+	OrderTypeMarket    = "PLACE_MARKET"
+	OrderTypeStopLimit = "PLACE_STOP_LIMIT"
+	OrderTypeSimple    = "SIMPLE"
+)
+
+// StopLimitType is the trigger of a stop-limit order
+type StopLimitType string
+
+const (
+	// TakeProfitLimit orders are placed once the price reaches the stop
+	// price from below for sells, or above for buys
+	TakeProfitLimit StopLimitType = "TAKE_PROFIT_LIMIT"
+	// StopLossLimit orders are placed once the price reaches the stop price
+	// from above for sells, or below for buys
+	StopLossLimit StopLimitType = "STOP_LOSS_LIMIT"
+)
+
+// TransactionTypes filters transaction history by type, such as
+// "LIMIT_BUY" or "FIAT_DEPOSIT"
+type TransactionTypes []string
+
+// String returns the types in the comma separated form of the query string
+func (t TransactionTypes) String() string {
+	return strings.Join(t, ",")
+}
+
+// Subaccount is a subaccount of the primary account
+type Subaccount struct {
+	Label string `json:"label"`
+	ID    string `json:"id"`
+}
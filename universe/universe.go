@@ -0,0 +1,300 @@
+// Package universe resolves the pairs a bot trades from rules, such as
+// every ZAR pair but stablecoins with at least R1m of 24h volume, against
+// live exchange data. The universe is refreshed as pairs are listed,
+// delisted and move in and out of the volume threshold, and changes are
+// reported so subscriptions and strategy loops can follow them.
+package universe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/refdata"
+	"github.com/shopspring/decimal"
+)
+
+const defaultInterval = 15 * time.Minute
+
+// Stablecoins are the currencies excluded as base currencies by
+// Rules.ExcludeStablecoins.
+var Stablecoins = []string{"USDC", "USDT", "DAI", "TUSD", "BUSD", "PYUSD", "ZARP", "ZARU"}
+
+// Rules select the pairs of a universe. A pair is included if it is active
+// and passes every rule that is set. They can be decoded from JSON, e.g.
+//
+//	{"quote": ["ZAR"], "excludeStablecoins": true, "minQuoteVolume": "1000000"}
+type Rules struct {
+	// Quote restricts pairs to these quote currencies, e.g. "ZAR".
+	Quote []string `json:"quote,omitempty"`
+	// Base restricts pairs to these base currencies.
+	Base []string `json:"base,omitempty"`
+	// Types restricts pairs to these types, e.g. valr.PairTypeSpot.
+	Types []valr.PairType `json:"types,omitempty"`
+	// Include restricts pairs to those matching one of these patterns, in
+	// the syntax of path.Match, e.g. "BTC*".
+	Include []string `json:"include,omitempty"`
+	// Exclude removes pairs matching any of these patterns.
+	Exclude []string `json:"exclude,omitempty"`
+	// ExcludeStablecoins removes pairs with one of the Stablecoins as base
+	// currency.
+	ExcludeStablecoins bool `json:"excludeStablecoins,omitempty"`
+	// MinQuoteVolume is the minimum 24h volume of a pair, in its quote
+	// currency.
+	MinQuoteVolume decimal.Decimal `json:"minQuoteVolume"`
+	// Max, if positive, limits the universe to the pairs with the largest
+	// 24h quote volume.
+	Max int `json:"max,omitempty"`
+}
+
+// Validate checks the patterns and limits of the rules.
+func (r Rules) Validate() error {
+	for _, p := range slices.Concat(r.Include, r.Exclude) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("universe: invalid pattern %q: %w", p, err)
+		}
+	}
+	if r.MinQuoteVolume.IsNegative() {
+		return errors.New("universe: negative minimum volume")
+	}
+	if r.Max < 0 {
+		return errors.New("universe: negative maximum size")
+	}
+	return nil
+}
+
+// needsVolume returns true if the rules depend on market summaries.
+func (r Rules) needsVolume() bool {
+	return r.MinQuoteVolume.IsPositive() || r.Max > 0
+}
+
+// match returns true if p passes every rule but the volume rules.
+func (r Rules) match(p valr.PairInfo) bool {
+	switch {
+	case !p.Active:
+		return false
+	case len(r.Quote) > 0 && !slices.Contains(r.Quote, p.QuoteCurrency):
+		return false
+	case len(r.Base) > 0 && !slices.Contains(r.Base, p.BaseCurrency):
+		return false
+	case len(r.Types) > 0 && !slices.Contains(r.Types, p.CurrencyPairType):
+		return false
+	case len(r.Include) > 0 && !matchAny(r.Include, p.Symbol):
+		return false
+	case matchAny(r.Exclude, p.Symbol):
+		return false
+	case r.ExcludeStablecoins && slices.Contains(Stablecoins, p.BaseCurrency):
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []string, symbol string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, symbol); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Change describes a refresh that changed the universe.
+type Change struct {
+	// Pairs is the new universe.
+	Pairs   []string
+	Added   []string
+	Removed []string
+}
+
+// ChangeCallback is called after a refresh that changed the universe,
+// including the initial resolution.
+type ChangeCallback func(Change)
+
+type Option func(*Universe)
+
+// WithInterval sets how often Run refreshes the universe, every 15 minutes
+// by default.
+func WithInterval(d time.Duration) Option {
+	return func(u *Universe) {
+		u.interval = d
+	}
+}
+
+// WithRefData takes pairs from c rather than fetching them on every
+// refresh.
+func WithRefData(c *refdata.Cache) Option {
+	return func(u *Universe) {
+		u.refdata = c
+	}
+}
+
+// WithChangeCallback adds a callback for changes of the universe, e.g. to
+// renew subscriptions with the new pairs.
+func WithChangeCallback(fn ChangeCallback) Option {
+	return func(u *Universe) {
+		u.callbacks = append(u.callbacks, fn)
+	}
+}
+
+// Universe is the set of pairs selected by rules.
+type Universe struct {
+	client    *valr.Client
+	rules     Rules
+	interval  time.Duration
+	refdata   *refdata.Cache
+	callbacks []ChangeCallback
+
+	// refreshMu serialises refreshes, so changes are reported in order.
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	pairs     []string
+}
+
+// New returns an empty universe of the pairs selected by rules. It is
+// resolved by Refresh or Run.
+func New(cl *valr.Client, rules Rules, opts ...Option) *Universe {
+	u := &Universe{
+		client:   cl,
+		rules:    rules,
+		interval: defaultInterval,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// Pairs returns the pairs of the universe, sorted by symbol.
+func (u *Universe) Pairs() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return slices.Clone(u.pairs)
+}
+
+// Contains returns true if pair is in the universe.
+func (u *Universe) Contains(pair string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	_, ok := slices.BinarySearch(u.pairs, pair)
+	return ok
+}
+
+// Refresh resolves the universe against the exchange, returning its pairs.
+// On error the universe is left unchanged.
+func (u *Universe) Refresh(ctx context.Context) ([]string, error) {
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+
+	pairs, err := u.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	old := u.pairs
+	u.pairs = pairs
+	u.mu.Unlock()
+
+	ch := Change{Pairs: slices.Clone(pairs), Added: diff(pairs, old), Removed: diff(old, pairs)}
+	if len(ch.Added) > 0 || len(ch.Removed) > 0 {
+		for _, fn := range u.callbacks {
+			fn(ch)
+		}
+	}
+	return slices.Clone(pairs), nil
+}
+
+// Run refreshes the universe at once and then periodically until ctx is
+// done. Failed refreshes are logged and leave the universe unchanged.
+func (u *Universe) Run(ctx context.Context) error {
+	if _, err := u.Refresh(ctx); err != nil {
+		log.Printf("valr/universe: Initial refresh failed: %v", err)
+	}
+
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := u.Refresh(ctx); err != nil {
+				log.Printf("valr/universe: Refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// resolve applies the rules to the current pairs and market summaries.
+func (u *Universe) resolve(ctx context.Context) ([]string, error) {
+	infos, err := u.fetchPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pairs []string
+	for _, p := range infos {
+		if u.rules.match(p) {
+			pairs = append(pairs, p.Symbol)
+		}
+	}
+
+	if u.rules.needsVolume() {
+		summaries, err := u.client.GetMarketSummaryRequest(ctx, &valr.GetMarketSummaryRequest{})
+		if err != nil {
+			return nil, err
+		}
+		volumes := make(map[string]decimal.Decimal, len(summaries))
+		for _, s := range summaries {
+			volumes[s.Pair] = s.BaseVolume.Mul(s.LastPrice)
+		}
+		// Pairs without a summary have no volume.
+		pairs = slices.DeleteFunc(pairs, func(p string) bool {
+			return volumes[p].LessThan(u.rules.MinQuoteVolume)
+		})
+		if u.rules.Max > 0 && len(pairs) > u.rules.Max {
+			sort.SliceStable(pairs, func(i, j int) bool {
+				if c := volumes[pairs[i]].Cmp(volumes[pairs[j]]); c != 0 {
+					return c > 0
+				}
+				return pairs[i] < pairs[j]
+			})
+			pairs = pairs[:u.rules.Max]
+		}
+	}
+
+	sort.Strings(pairs)
+	return pairs, nil
+}
+
+func (u *Universe) fetchPairs(ctx context.Context) ([]valr.PairInfo, error) {
+	if u.refdata != nil {
+		m, err := u.refdata.Pairs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pairs := make([]valr.PairInfo, 0, len(m))
+		for _, p := range m {
+			pairs = append(pairs, p)
+		}
+		return pairs, nil
+	}
+	return u.client.GetCurrencyPairs(ctx, &valr.GetCurrencyPairsRequest{})
+}
+
+// diff returns the elements of a not in b, both sorted.
+func diff(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if _, ok := slices.BinarySearch(b, s); !ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
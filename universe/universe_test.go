@@ -0,0 +1,133 @@
+package universe_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/donohutcheon/valr-go/universe"
+)
+
+const pairs = `[
+	{"symbol":"BTCZAR","baseCurrency":"BTC","quoteCurrency":"ZAR","active":true,"currencyPairType":"SPOT"},
+	{"symbol":"ETHZAR","baseCurrency":"ETH","quoteCurrency":"ZAR","active":true,"currencyPairType":"SPOT"},
+	{"symbol":"XRPZAR","baseCurrency":"XRP","quoteCurrency":"ZAR","active":true,"currencyPairType":"SPOT"},
+	{"symbol":"SOLZAR","baseCurrency":"SOL","quoteCurrency":"ZAR","active":false,"currencyPairType":"SPOT"},
+	{"symbol":"USDCZAR","baseCurrency":"USDC","quoteCurrency":"ZAR","active":true,"currencyPairType":"SPOT"},
+	{"symbol":"BTCUSDC","baseCurrency":"BTC","quoteCurrency":"USDC","active":true,"currencyPairType":"SPOT"},
+	{"symbol":"BTCZARPERP","baseCurrency":"BTC","quoteCurrency":"ZAR","active":true,"currencyPairType":"FUTURE"}
+]`
+
+func TestUniverse(t *testing.T) {
+	// XRPZAR's volume grows past the threshold on the second refresh.
+	summaries := []string{
+		`[{"currencyPair":"BTCZAR","lastTradedPrice":"1000000","baseVolume":"10"},
+		  {"currencyPair":"ETHZAR","lastTradedPrice":"50000","baseVolume":"100"},
+		  {"currencyPair":"XRPZAR","lastTradedPrice":"10","baseVolume":"1000"},
+		  {"currencyPair":"USDCZAR","lastTradedPrice":"18","baseVolume":"10000000"}]`,
+		`[{"currencyPair":"BTCZAR","lastTradedPrice":"1000000","baseVolume":"10"},
+		  {"currencyPair":"ETHZAR","lastTradedPrice":"50000","baseVolume":"1"},
+		  {"currencyPair":"XRPZAR","lastTradedPrice":"10","baseVolume":"1000000"}]`,
+	}
+	var version atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/public/pairs":
+			w.Write([]byte(pairs))
+		case "/public/marketsummary":
+			w.Write([]byte(summaries[version.Load()]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+
+	var rules universe.Rules
+	err := json.Unmarshal([]byte(`{"quote":["ZAR"],"types":["SPOT"],"excludeStablecoins":true,"minQuoteVolume":"1000000"}`), &rules)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	var changes []universe.Change
+	u := universe.New(cl, rules, universe.WithChangeCallback(func(ch universe.Change) {
+		changes = append(changes, ch)
+	}))
+
+	ctx := context.Background()
+	got, err := u.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if exp := []string{"BTCZAR", "ETHZAR"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q, got %q", exp, got)
+	}
+	if !u.Contains("ETHZAR") || u.Contains("XRPZAR") {
+		t.Errorf("Expected ETHZAR but not XRPZAR, got %q", u.Pairs())
+	}
+
+	version.Store(1)
+	if _, err := u.Refresh(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := u.Refresh(ctx); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	ch := changes[1]
+	if !reflect.DeepEqual(ch.Pairs, []string{"BTCZAR", "XRPZAR"}) ||
+		!reflect.DeepEqual(ch.Added, []string{"XRPZAR"}) || !reflect.DeepEqual(ch.Removed, []string{"ETHZAR"}) {
+		t.Errorf("Expected XRPZAR to replace ETHZAR, got %+v", ch)
+	}
+}
+
+func TestRules(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public/marketsummary" {
+			w.Write([]byte(`[{"currencyPair":"BTCZAR","lastTradedPrice":"1000000","baseVolume":"10"},
+				{"currencyPair":"BTCUSDC","lastTradedPrice":"60000","baseVolume":"1"},
+				{"currencyPair":"BTCZARPERP","lastTradedPrice":"1000000","baseVolume":"100"}]`))
+			return
+		}
+		w.Write([]byte(pairs))
+	}))
+	defer srv.Close()
+	cl := valr.NewClient()
+	defer cl.Close()
+	cl.SetBaseURL(srv.URL)
+
+	for _, tc := range []struct {
+		rules universe.Rules
+		want  []string
+	}{
+		{universe.Rules{}, []string{"BTCUSDC", "BTCZAR", "BTCZARPERP", "ETHZAR", "USDCZAR", "XRPZAR"}},
+		{universe.Rules{Include: []string{"BTC*"}, Exclude: []string{"*PERP"}}, []string{"BTCUSDC", "BTCZAR"}},
+		{universe.Rules{Base: []string{"BTC"}, Max: 2}, []string{"BTCZAR", "BTCZARPERP"}},
+		{universe.Rules{Quote: []string{"USDC", "ZAR"}, ExcludeStablecoins: true, Types: []valr.PairType{valr.PairTypeSpot}},
+			[]string{"BTCUSDC", "BTCZAR", "ETHZAR", "XRPZAR"}},
+	} {
+		got, err := universe.New(cl, tc.rules).Refresh(context.Background())
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expected %q for %+v, got %q", tc.want, tc.rules, got)
+		}
+	}
+
+	if err := (universe.Rules{Exclude: []string{"[BTC"}}).Validate(); err == nil {
+		t.Error("Expected an invalid pattern error")
+	}
+}
@@ -4,26 +4,33 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// MakeURLValues converts a request struct into a url.Values map.
+// MakeURLValues converts a request struct, passed by pointer, into a
+// url.Values map. Float fields are rejected since they can't represent
+// amounts exactly. Unexported fields and nil pointers are skipped.
 func MakeURLValues(v interface{}) (url.Values, error) {
 	values := make(url.Values)
 
-	valElem := reflect.ValueOf(v).Elem()
-	typElem := reflect.TypeOf(v).Elem()
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("valr: url values need a pointer to a struct, got %T", v)
+	}
+	valElem := rv.Elem()
+	typElem := valElem.Type()
 
 	for i := 0; i < typElem.NumField(); i++ {
 		field := typElem.Field(i)
-		tagParams := strings.Split(field.Tag.Get("url"), ",")
-		if len(tagParams) == 0 {
+		if !field.IsExported() {
 			continue
 		}
+		tagParams := strings.Split(field.Tag.Get("url"), ",")
 		urlTag := tagParams[0]
-		omitEmpty := len(tagParams) == 2 && tagParams[1] == "omitempty"
+		omitEmpty := slices.Contains(tagParams[1:], "omitempty")
 
 		if urlTag == "" || urlTag == "-" {
 			continue
@@ -33,6 +40,12 @@ func MakeURLValues(v interface{}) (url.Values, error) {
 		if omitEmpty && fieldValue.IsZero() {
 			continue
 		}
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
 
 		if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
 			values.Set(urlTag, fieldValue.Interface().(time.Time).UTC().Format(time.RFC3339))
@@ -54,10 +67,10 @@ func MakeURLValues(v interface{}) (url.Values, error) {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
 			reflect.Uint64:
 			s = strconv.FormatUint(fieldValue.Uint(), 10)
-		case reflect.Float32:
-			s = strconv.FormatFloat(fieldValue.Float(), 'f', 4, 32)
-		case reflect.Float64:
-			s = strconv.FormatFloat(fieldValue.Float(), 'f', 4, 64)
+		case reflect.Float32, reflect.Float64:
+			// Floats can't represent amounts exactly; rather than
+			// silently rounding, require decimal.Decimal.
+			return nil, fmt.Errorf("valr: float field %s not supported, use decimal.Decimal", field.Name)
 		case reflect.Slice:
 			if field.Type.Elem().Kind() == reflect.Uint8 {
 				s = string(fieldValue.Bytes())
@@ -14,20 +14,16 @@ func TestMakeURLValues(t *testing.T) {
 		S   string    `url:"s"`
 		I   int       `url:"i"`
 		I64 int64     `url:"i64"`
-		F32 float32   `url:"f32"`
-		F64 float64   `url:"f64"`
 		B   bool      `url:"b"`
 		ABy []byte    `url:"aby"`
 		TS  S         `url:"ts"`
-		T   time.Time `url:"t,omitempty"` // implements fmt.Stringer
+		T   time.Time `url:"t,omitempty"` // formatted as RFC 3339
 	}
 
 	r := Req{
 		S:   "foo",
 		I:   42,
 		I64: 42,
-		F32: 42.42,
-		F64: 42.42,
 		B:   true,
 		ABy: []byte("foo"),
 		TS:  S("foo"),
@@ -39,7 +35,7 @@ func TestMakeURLValues(t *testing.T) {
 		t.Errorf("Expected success, got %v", err)
 		return
 	}
-	exp := "aby=foo&b=true&f32=42.4200&f64=42.4200&i=42&i64=42&s=foo&t=2018-01-01+23%3A57%3A12+%2B0000+UTC&ts=foo"
+	exp := "aby=foo&b=true&i=42&i64=42&s=foo&t=2018-01-01T23%3A57%3A12Z&ts=foo"
 	act := v.Encode()
 	if act != exp {
 		t.Errorf("Expected %q, got %q", exp, act)
@@ -66,3 +62,14 @@ func TestMakeURLValues2(t *testing.T) {
 		return
 	}
 }
+
+func TestMakeURLValuesRejectsFloats(t *testing.T) {
+	type Req struct {
+		F float64 `url:"f"`
+	}
+
+	_, err := valr.MakeURLValues(&Req{F: 0.1})
+	if err == nil {
+		t.Errorf("Expected error, got success")
+	}
+}
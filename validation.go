@@ -0,0 +1,151 @@
+package valr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/shopspring/decimal"
+)
+
+// customerOrderIDPattern is the format VALR accepts for customerOrderId.
+var customerOrderIDPattern = regexp.MustCompile(`^[0-9a-zA-Z-]{0,50}$`)
+
+// ValidationError is returned for requests rejected locally, before being
+// sent to VALR, because a field is missing or invalid.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("valr: invalid %s: %s", e.Field, e.Reason)
+}
+
+// validator is implemented by requests that can be checked locally. Client.do
+// validates such requests before sending them.
+type validator interface {
+	Validate() error
+}
+
+// Validate checks the request for missing fields and illegal combinations.
+func (r *PostLimitOrderRequest) Validate() error {
+	if err := validateOrder(r.Pair, r.Side, r.Quantity, "quantity", r.CustomerOrderID); err != nil {
+		return err
+	}
+	if !r.Price.IsPositive() {
+		return &ValidationError{Field: "price", Reason: "must be positive"}
+	}
+	return validateTimeInForce(r.TimeInForce, r.PostOnly)
+}
+
+// validateTimeInForce rejects unknown values and post-only orders that
+// wouldn't rest on the book.
+func validateTimeInForce(tif TimeInForce, postOnly bool) error {
+	switch tif {
+	case "", GoodTillCancelled:
+		return nil
+	case ImmediateOrCancel, FillOrKill:
+		if postOnly {
+			return &ValidationError{Field: "timeInForce", Reason: fmt.Sprintf("%s cannot be combined with postOnly", tif)}
+		}
+		return nil
+	default:
+		return &ValidationError{Field: "timeInForce", Reason: fmt.Sprintf("unknown value %q", tif)}
+	}
+}
+
+// Validate checks the request for missing fields.
+func (r *PostMarketOrderBuyRequest) Validate() error {
+	return validateOrder(r.Pair, r.Side, r.Quantity, "quoteAmount", r.CustomerOrderID)
+}
+
+// Validate checks the request for missing fields.
+func (r *PostMarketOrderSellRequest) Validate() error {
+	return validateOrder(r.Pair, r.Side, r.Quantity, "baseAmount", r.CustomerOrderID)
+}
+
+// Validate checks the request for missing fields.
+func (r *PostMarketOrderBaseAmountRequest) Validate() error {
+	return validateOrder(r.Pair, r.Side, r.Quantity, "baseAmount", r.CustomerOrderID)
+}
+
+// Validate checks the request for missing fields.
+func (r *PostStopLimitOrderRequest) Validate() error {
+	if err := validateOrder(r.Pair, r.Side, r.Quantity, "quantity", r.CustomerOrderID); err != nil {
+		return err
+	}
+	if !r.Price.IsPositive() {
+		return &ValidationError{Field: "price", Reason: "must be positive"}
+	}
+	if !r.StopPrice.IsPositive() {
+		return &ValidationError{Field: "stopPrice", Reason: "must be positive"}
+	}
+	if r.Type != TakeProfitLimit && r.Type != StopLossLimit {
+		return &ValidationError{Field: "type", Reason: fmt.Sprintf("must be %s or %s, got %q", TakeProfitLimit, StopLossLimit, r.Type)}
+	}
+	return validateTimeInForce(r.TimeInForce, false)
+}
+
+// Validate checks the size of the batch and each of its requests.
+func (r *PostBatchOrdersRequest) Validate() error {
+	if len(r.Requests) == 0 || len(r.Requests) > maxBatchOrders {
+		return &ValidationError{Field: "requests", Reason: fmt.Sprintf("must hold 1 to %d requests, got %d", maxBatchOrders, len(r.Requests))}
+	}
+	for i, o := range r.Requests {
+		v, ok := o.Data.(validator)
+		if !ok {
+			continue
+		}
+		if err := v.Validate(); err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				return &ValidationError{Field: fmt.Sprintf("requests[%d].%s", i, verr.Field), Reason: verr.Reason}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the request for missing fields.
+func (r *PostSubaccountRequest) Validate() error {
+	if r.Label == "" {
+		return &ValidationError{Field: "label", Reason: "required"}
+	}
+	return nil
+}
+
+// Validate checks the request for missing fields and transfers to the
+// source account.
+func (r *PostSubaccountTransferRequest) Validate() error {
+	switch {
+	case r.FromID == "":
+		return &ValidationError{Field: "fromId", Reason: "required"}
+	case r.ToID == "":
+		return &ValidationError{Field: "toId", Reason: "required"}
+	case r.FromID == r.ToID:
+		return &ValidationError{Field: "toId", Reason: "must differ from fromId"}
+	case r.Currency == "":
+		return &ValidationError{Field: "currencyCode", Reason: "required"}
+	case !r.Amount.IsPositive():
+		return &ValidationError{Field: "amount", Reason: "must be positive"}
+	}
+	return nil
+}
+
+func validateOrder(pair string, side RequestSide, amount decimal.Decimal, amountField, customerOrderID string) error {
+	if pair == "" {
+		return &ValidationError{Field: "pair", Reason: "required"}
+	}
+	if side != BUY && side != SELL {
+		return &ValidationError{Field: "side", Reason: fmt.Sprintf("must be %s or %s, got %q", BUY, SELL, side)}
+	}
+	if !amount.IsPositive() {
+		return &ValidationError{Field: amountField, Reason: "must be positive"}
+	}
+	if !customerOrderIDPattern.MatchString(customerOrderID) {
+		return &ValidationError{Field: "customerOrderId", Reason: "must match " + customerOrderIDPattern.String()}
+	}
+	return nil
+}
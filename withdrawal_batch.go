@@ -0,0 +1,263 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statuses of the items in a withdrawal batch.
+const (
+	// BatchPending items have not been sent yet.
+	BatchPending = "PENDING"
+	// BatchRejected items were rejected before or on submission.
+	BatchRejected = "REJECTED"
+	// BatchSubmitted items were accepted by VALR but have not completed.
+	// Fiat withdrawals can't be tracked and end in this status.
+	BatchSubmitted = "SUBMITTED"
+	// BatchCompleted items were sent on chain.
+	BatchCompleted = "COMPLETED"
+	// BatchFailed items were accepted but later failed or were cancelled.
+	BatchFailed = "FAILED"
+)
+
+const defaultBatchPollInterval = 10 * time.Second
+
+var (
+	// withdrawCompleted and withdrawFailed are the terminal withdrawal
+	// statuses, in upper case.
+	withdrawCompleted = []string{"COMPLETE", "COMPLETED", "SUCCESS", "SUCCESSFUL", "CONFIRMED", "SENT"}
+	withdrawFailed    = []string{"FAILED", "CANCELLED", "CANCELED", "REJECTED", "DECLINED"}
+)
+
+// ErrBelowWithdrawMinimum is returned for withdrawals smaller than the
+// currency's minimum.
+var ErrBelowWithdrawMinimum = errors.New("valr: withdrawal below minimum amount")
+
+// WithdrawalInstruction is a single withdrawal in a batch. Exactly one of
+// Crypto and Fiat must be set.
+type WithdrawalInstruction struct {
+	// Ref identifies the instruction in the report, e.g. an internal
+	// payment ID.
+	Ref    string
+	Crypto *PostNewCryptoWithdrawRequest
+	Fiat   *PostNewFiatWithdrawRequest
+}
+
+// WithdrawalResult is the outcome of a single withdrawal in a batch.
+type WithdrawalResult struct {
+	Ref             string          `json:"ref"`
+	ID              string          `json:"id,omitempty"`
+	Currency        string          `json:"currency"`
+	Amount          decimal.Decimal `json:"amount"`
+	Status          string          `json:"status"`
+	TransactionHash string          `json:"transactionHash,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	SubmittedAt     time.Time       `json:"submittedAt,omitempty"`
+	CompletedAt     time.Time       `json:"completedAt,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// WithdrawalBatchReport is the consolidated outcome of a withdrawal batch.
+type WithdrawalBatchReport struct {
+	// Results is in the order of the instructions.
+	Results []WithdrawalResult `json:"results"`
+	// Counts holds the number of results per status.
+	Counts map[string]int `json:"counts"`
+	// Withdrawn holds the amounts submitted or completed, keyed by
+	// currency.
+	Withdrawn map[string]decimal.Decimal `json:"withdrawn"`
+}
+
+type batchConfig struct {
+	pollInterval time.Duration
+	stopOnError  bool
+	noTracking   bool
+}
+
+type WithdrawalBatchOption func(*batchConfig)
+
+// WithBatchPollInterval sets how often submitted withdrawals are polled until
+// they complete, 10 seconds by default.
+func WithBatchPollInterval(d time.Duration) WithdrawalBatchOption {
+	return func(c *batchConfig) {
+		c.pollInterval = d
+	}
+}
+
+// WithBatchStopOnError leaves the remaining instructions pending after the
+// first one is rejected.
+func WithBatchStopOnError() WithdrawalBatchOption {
+	return func(c *batchConfig) {
+		c.stopOnError = true
+	}
+}
+
+// WithoutBatchTracking returns once every instruction is submitted, without
+// waiting for crypto withdrawals to complete.
+func WithoutBatchTracking() WithdrawalBatchOption {
+	return func(c *batchConfig) {
+		c.noTracking = true
+	}
+}
+
+// WithdrawBatch sends the instructions one at a time, so the client's rate
+// limiter, withdrawal policy and approval gate apply to each, then polls the
+// crypto withdrawals until they complete. Crypto withdrawals below the
+// currency's minimum or on an inactive currency are rejected without being
+// sent. Submission is never retried, to avoid duplicate withdrawals.
+//
+// The report is returned even if ctx expires, with the outstanding items in
+// their last known status.
+func (cl *Client) WithdrawBatch(ctx context.Context, items []WithdrawalInstruction, opts ...WithdrawalBatchOption) (*WithdrawalBatchReport, error) {
+	cfg := batchConfig{pollInterval: defaultBatchPollInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	results := make([]WithdrawalResult, len(items))
+	for i, item := range items {
+		results[i] = WithdrawalResult{Ref: item.Ref, Status: BatchPending}
+	}
+	var ctxErr error
+	for i, item := range items {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			break
+		}
+		r := &results[i]
+		cl.submitWithdrawal(ctx, item, r)
+		if r.Status == BatchRejected && cfg.stopOnError {
+			break
+		}
+	}
+	if ctxErr == nil && !cfg.noTracking {
+		ctxErr = cl.trackWithdrawals(ctx, items, results, cfg.pollInterval)
+	}
+	return newBatchReport(results), ctxErr
+}
+
+func (cl *Client) submitWithdrawal(ctx context.Context, item WithdrawalInstruction, r *WithdrawalResult) {
+	reject := func(err error) {
+		r.Status, r.Err, r.Error = BatchRejected, err, err.Error()
+	}
+
+	switch {
+	case item.Crypto != nil && item.Fiat == nil:
+		req := item.Crypto
+		r.Currency, r.Amount = req.Asset, req.Amount
+		info, err := cl.GetWithdrawInfoRequest(ctx, &GetWithdrawInfoRequest{Asset: req.Asset})
+		if err != nil {
+			reject(err)
+			return
+		}
+		if err := checkWithdrawInfo(info, req); err != nil {
+			reject(err)
+			return
+		}
+		res, err := cl.PostNewCryptoWithdrawRequest(ctx, req)
+		if err != nil {
+			reject(err)
+			return
+		}
+		r.ID = res.ID
+	case item.Fiat != nil && item.Crypto == nil:
+		req := item.Fiat
+		r.Currency, r.Amount = req.Asset, req.Amount
+		res, err := cl.PostNewFiatWithdrawRequest(ctx, req)
+		if err != nil {
+			reject(err)
+			return
+		}
+		r.ID = res.ID
+	default:
+		reject(&ValidationError{Field: "instruction", Reason: "exactly one of Crypto and Fiat must be set"})
+		return
+	}
+	r.Status = BatchSubmitted
+	r.SubmittedAt = time.Now()
+}
+
+// checkWithdrawInfo checks a crypto withdrawal against the currency's
+// minimum and whether it is active, on the requested network if any.
+func checkWithdrawInfo(info *GetWithdrawInfoResponse, req *PostNewCryptoWithdrawRequest) error {
+	minimum, active := info.MinimumWithdrawAmount, info.Active
+	if req.Network != "" {
+		for _, n := range info.SupportedNetworks {
+			if n.NetworkType == req.Network {
+				minimum, active = n.MinimumWithdrawAmount, n.Active
+			}
+		}
+	}
+	if !active {
+		return fmt.Errorf("%w: %s withdrawals are not active", ErrNoWithdrawalNetwork, req.Asset)
+	}
+	if req.Amount.LessThan(minimum) {
+		return fmt.Errorf("%w: %s %s is below %s", ErrBelowWithdrawMinimum, req.Amount, req.Asset, minimum)
+	}
+	return nil
+}
+
+// trackWithdrawals polls submitted crypto withdrawals until each reaches a
+// terminal status or ctx is done.
+func (cl *Client) trackWithdrawals(ctx context.Context, items []WithdrawalInstruction, results []WithdrawalResult, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		outstanding := 0
+		for i := range results {
+			r := &results[i]
+			if r.Status != BatchSubmitted || items[i].Crypto == nil {
+				continue
+			}
+			info, err := cl.GetWithdrawStatusRequest(ctx, &GetWithdrawStatusRequest{Asset: r.Currency, ID: r.ID})
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// Poll again at the next interval.
+				outstanding++
+				continue
+			}
+			r.TransactionHash = info.TransactionHash
+			state := strings.ToUpper(info.State)
+			switch {
+			case slices.Contains(withdrawCompleted, state):
+				r.Status, r.CompletedAt = BatchCompleted, time.Now()
+			case slices.Contains(withdrawFailed, state):
+				r.Status, r.CompletedAt = BatchFailed, time.Now()
+				r.Error = "withdrawal " + strings.ToLower(state)
+			default:
+				outstanding++
+			}
+		}
+		if outstanding == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func newBatchReport(results []WithdrawalResult) *WithdrawalBatchReport {
+	report := &WithdrawalBatchReport{
+		Results:   results,
+		Counts:    make(map[string]int),
+		Withdrawn: make(map[string]decimal.Decimal),
+	}
+	for _, r := range results {
+		report.Counts[r.Status]++
+		if r.Status == BatchSubmitted || r.Status == BatchCompleted {
+			report.Withdrawn[r.Currency] = report.Withdrawn[r.Currency].Add(r.Amount)
+		}
+	}
+	return report
+}
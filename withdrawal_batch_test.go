@@ -0,0 +1,78 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestWithdrawBatch(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/wallet/fiat/"):
+			_, _ = w.Write([]byte(`{"id":"fiat1"}`))
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":"w1"}`))
+		case strings.HasSuffix(r.URL.Path, "/withdraw/w1"):
+			polls++
+			status := "Processing"
+			if polls > 1 {
+				status = "Complete"
+			}
+			_, _ = w.Write([]byte(`{"currency":"BTC","transactionHash":"0xabc","status":"` + status + `"}`))
+		default:
+			_, _ = w.Write([]byte(`{"currency":"BTC","minimumWithdrawAmount":"0.001","isActive":true,"withdrawCost":"0.0001"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	btc := func(amount string) *valr.PostNewCryptoWithdrawRequest {
+		return &valr.PostNewCryptoWithdrawRequest{
+			Asset:   "BTC",
+			Amount:  decimal.RequireFromString(amount),
+			Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		}
+	}
+
+	report, err := cl.WithdrawBatch(context.Background(), []valr.WithdrawalInstruction{
+		{Ref: "a", Crypto: btc("0.5")},
+		{Ref: "b", Crypto: btc("0.0001")},
+		{Ref: "c", Fiat: &valr.PostNewFiatWithdrawRequest{Asset: "ZAR", Amount: decimal.RequireFromString("100"), BankAccountID: "bank"}},
+		{Ref: "d"},
+	}, valr.WithBatchPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	want := []string{valr.BatchCompleted, valr.BatchRejected, valr.BatchSubmitted, valr.BatchRejected}
+	for i, r := range report.Results {
+		if r.Status != want[i] {
+			t.Errorf("%s: expected %s, got %s (%v)", r.Ref, want[i], r.Status, r.Err)
+		}
+	}
+	if !errors.Is(report.Results[1].Err, valr.ErrBelowWithdrawMinimum) {
+		t.Errorf("Expected %v, got %v", valr.ErrBelowWithdrawMinimum, report.Results[1].Err)
+	}
+	if report.Results[0].TransactionHash != "0xabc" {
+		t.Errorf("Expected %q, got %q", "0xabc", report.Results[0].TransactionHash)
+	}
+	if got := report.Withdrawn["BTC"]; !got.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("Expected 0.5 BTC withdrawn, got %s", got)
+	}
+	if report.Counts[valr.BatchRejected] != 2 {
+		t.Errorf("Expected 2 rejected, got %d", report.Counts[valr.BatchRejected])
+	}
+}
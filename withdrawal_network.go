@@ -0,0 +1,111 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoWithdrawalNetwork is returned when no network can carry a withdrawal.
+var ErrNoWithdrawalNetwork = errors.New("valr: no viable withdrawal network")
+
+// WithdrawalRoute is a network a withdrawal could be sent on.
+type WithdrawalRoute struct {
+	// Network is empty for currencies that only report a default network.
+	Network string
+	Fee     decimal.Decimal
+	Minimum decimal.Decimal
+	Active  bool
+	Viable  bool
+	// Reason explains why the route is not viable.
+	Reason string
+}
+
+// WithdrawalRoutes compares the networks currency can be withdrawn on for
+// the given amount, cheapest viable route first, followed by the routes
+// that are not viable. If networks is not empty only those networks are
+// considered, e.g. those the destination address belongs to.
+func (cl *Client) WithdrawalRoutes(ctx context.Context, currency string, amount decimal.Decimal, networks ...string) ([]WithdrawalRoute, error) {
+	info, err := cl.GetWithdrawInfoRequest(ctx, &GetWithdrawInfoRequest{Asset: currency})
+	if err != nil {
+		return nil, err
+	}
+	candidates := info.SupportedNetworks
+	if len(candidates) == 0 {
+		candidates = []WithdrawNetworkInfo{{
+			MinimumWithdrawAmount: info.MinimumWithdrawAmount,
+			Active:                info.Active,
+			WithdrawCost:          info.WithdrawCost,
+		}}
+	}
+
+	var routes []WithdrawalRoute
+	for _, n := range candidates {
+		if len(networks) > 0 && !slices.Contains(networks, n.NetworkType) {
+			continue
+		}
+		r := WithdrawalRoute{
+			Network: n.NetworkType,
+			Fee:     n.WithdrawCost,
+			Minimum: n.MinimumWithdrawAmount,
+			Active:  n.Active,
+		}
+		switch {
+		case !n.Active:
+			r.Reason = "network inactive"
+		case amount.LessThan(n.MinimumWithdrawAmount):
+			r.Reason = fmt.Sprintf("below minimum %s", n.MinimumWithdrawAmount)
+		case !amount.GreaterThan(n.WithdrawCost):
+			r.Reason = fmt.Sprintf("does not cover fee %s", n.WithdrawCost)
+		default:
+			r.Viable = true
+		}
+		routes = append(routes, r)
+	}
+	slices.SortStableFunc(routes, func(a, b WithdrawalRoute) int {
+		if a.Viable != b.Viable {
+			if a.Viable {
+				return -1
+			}
+			return 1
+		}
+		if c := a.Fee.Cmp(b.Fee); c != 0 {
+			return c
+		}
+		return a.Minimum.Cmp(b.Minimum)
+	})
+	return routes, nil
+}
+
+// CheapestWithdrawalRoute returns the viable route with the lowest fee, or
+// ErrNoWithdrawalNetwork.
+func (cl *Client) CheapestWithdrawalRoute(ctx context.Context, currency string, amount decimal.Decimal, networks ...string) (*WithdrawalRoute, error) {
+	routes, err := cl.WithdrawalRoutes(ctx, currency, amount, networks...)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 || !routes[0].Viable {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoWithdrawalNetwork, amount, currency)
+	}
+	return &routes[0], nil
+}
+
+// WithdrawCheapest sends a crypto withdrawal on the cheapest viable network.
+// If req.Network is set, only that network is considered; otherwise it is
+// set to the network chosen from networks, or from all networks if none are
+// given.
+func (cl *Client) WithdrawCheapest(ctx context.Context, req *PostNewCryptoWithdrawRequest, networks ...string) (*PostNewCryptoWithdrawResponse, error) {
+	if req.Network != "" {
+		networks = []string{req.Network}
+	}
+	route, err := cl.CheapestWithdrawalRoute(ctx, req.Asset, req.Amount, networks...)
+	if err != nil {
+		return nil, err
+	}
+	chosen := *req
+	chosen.Network = route.Network
+	return cl.PostNewCryptoWithdrawRequest(ctx, &chosen)
+}
@@ -0,0 +1,67 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestWithdrawCheapest(t *testing.T) {
+	var (
+		path    string
+		network string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"currency":"USDT","supportedNetworks":[
+				{"networkType":"Ethereum","minimumWithdrawAmount":"10","isActive":true,"withdrawCost":"5"},
+				{"networkType":"Tron","minimumWithdrawAmount":"50","isActive":true,"withdrawCost":"1"},
+				{"networkType":"Solana","minimumWithdrawAmount":"1","isActive":false,"withdrawCost":"0.1"}
+			]}`))
+			return
+		}
+		path = r.URL.Path
+		var body struct {
+			NetworkType string `json:"networkType"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		network = body.NetworkType
+		_, _ = w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	routes, err := cl.WithdrawalRoutes(ctx, "USDT", decimal.RequireFromString("20"))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(routes) != 3 || routes[0].Network != "Ethereum" || !routes[0].Viable || routes[1].Viable {
+		t.Errorf("Unexpected routes %+v", routes)
+	}
+
+	_, err = cl.WithdrawCheapest(ctx, &valr.PostNewCryptoWithdrawRequest{
+		Asset:   "USDT",
+		Address: "TAFH65xLeW1gcNMQdt24jBhtaedmJRsEeF",
+		Amount:  decimal.RequireFromString("100"),
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if network != "Tron" {
+		t.Errorf("Expected %q, got %q", "Tron", network)
+	}
+	if path != "/wallet/crypto/USDT/withdraw" {
+		t.Errorf("Expected %q, got %q", "/wallet/crypto/USDT/withdraw", path)
+	}
+}
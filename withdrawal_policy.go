@@ -0,0 +1,252 @@
+package valr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrWithdrawalNotAllowed is returned for withdrawals to a destination
+	// that is not on the withdrawal policy's allow-list.
+	ErrWithdrawalNotAllowed = errors.New("valr: withdrawal destination not allowed")
+	// ErrWithdrawalCapExceeded is returned for withdrawals that would exceed
+	// the policy's daily cap for the currency.
+	ErrWithdrawalCapExceeded = errors.New("valr: withdrawal exceeds daily cap")
+)
+
+// withdrawal is implemented by withdrawal requests.
+type withdrawal interface {
+	withdrawal() (currency, destination string, amount decimal.Decimal)
+}
+
+func (r *PostNewCryptoWithdrawRequest) withdrawal() (string, string, decimal.Decimal) {
+	return r.Asset, r.Address, r.Amount
+}
+
+func (r *PostNewFiatWithdrawRequest) withdrawal() (string, string, decimal.Decimal) {
+	return r.Asset, r.BankAccountID, r.Amount
+}
+
+func (r *PostPayRequest) withdrawal() (string, string, decimal.Decimal) {
+	return r.Currency, r.Recipient(), r.Amount
+}
+
+// WithdrawalPolicy restricts the withdrawals a client may make to allowed
+// destinations and daily caps. It is enforced locally before requests are
+// signed, as a defence against bugs and compromised keys, whether they are
+// made with the typed methods or with Call, Post or Delete. A policy with no
+// allowed destinations rejects every withdrawal.
+type WithdrawalPolicy struct {
+	mu           sync.Mutex
+	addresses    map[string]bool
+	bankAccounts map[string]bool
+	caps         map[string]decimal.Decimal
+	day          string
+	spent        map[string]decimal.Decimal
+	now          func() time.Time
+}
+
+// NewWithdrawalPolicy returns a policy that allows nothing.
+func NewWithdrawalPolicy() *WithdrawalPolicy {
+	return &WithdrawalPolicy{
+		addresses:    make(map[string]bool),
+		bankAccounts: make(map[string]bool),
+		caps:         make(map[string]decimal.Decimal),
+		spent:        make(map[string]decimal.Decimal),
+		now:          time.Now,
+	}
+}
+
+func addressKey(currency, address string) string {
+	return strings.ToUpper(currency) + "\x00" + address
+}
+
+// AllowAddress allows crypto withdrawals of currency to address.
+func (p *WithdrawalPolicy) AllowAddress(currency, address string) *WithdrawalPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addresses[addressKey(currency, address)] = true
+	return p
+}
+
+// AllowBankAccount allows fiat withdrawals to the linked bank account with
+// the given ID.
+func (p *WithdrawalPolicy) AllowBankAccount(id string) *WithdrawalPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bankAccounts[id] = true
+	return p
+}
+
+// SetDailyCap limits the total withdrawn in currency per UTC day.
+// Currencies without a cap are unlimited.
+func (p *WithdrawalPolicy) SetDailyCap(currency string, amount decimal.Decimal) *WithdrawalPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.caps[strings.ToUpper(currency)] = amount
+	return p
+}
+
+// Withdrawn returns the amount of currency withdrawn today.
+func (p *WithdrawalPolicy) Withdrawn(currency string) decimal.Decimal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rollover()
+	return p.spent[strings.ToUpper(currency)]
+}
+
+func (p *WithdrawalPolicy) rollover() {
+	if day := p.now().UTC().Format(time.DateOnly); day != p.day {
+		p.day = day
+		p.spent = make(map[string]decimal.Decimal)
+	}
+}
+
+// reserve checks a withdrawal against the policy and counts it towards the
+// daily cap. The returned function releases the reservation if the
+// withdrawal was not made.
+func (p *WithdrawalPolicy) reserve(w withdrawal) (func(), error) {
+	currency, destination, amount := w.withdrawal()
+	currency = strings.ToUpper(currency)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var allowed bool
+	switch w.(type) {
+	case *PostNewFiatWithdrawRequest:
+		allowed = p.bankAccounts[destination]
+	default:
+		allowed = p.addresses[addressKey(currency, destination)]
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s %s", ErrWithdrawalNotAllowed, currency, destination)
+	}
+
+	p.rollover()
+	total := p.spent[currency].Add(amount)
+	if limit, ok := p.caps[currency]; ok && total.GreaterThan(limit) {
+		return nil, fmt.Errorf("%w: %s %s of %s", ErrWithdrawalCapExceeded,
+			total, currency, limit)
+	}
+	p.spent[currency] = total
+	day := p.day
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.day == day {
+			p.spent[currency] = p.spent[currency].Sub(amount)
+		}
+	}, nil
+}
+
+// SetWithdrawalPolicy enforces p on all withdrawals made by the client.
+// Pass nil to remove the policy.
+func (cl *Client) SetWithdrawalPolicy(p *WithdrawalPolicy) {
+	cl.withdrawalPolicy = p
+}
+
+// guardedRequest decodes the body of a withdrawal, payment or subaccount
+// transfer, however it was made, from its method, expanded path and JSON
+// body. It returns nil for other calls.
+func guardedRequest(method, path string, body []byte) (any, error) {
+	if method != http.MethodPost {
+		return nil, nil
+	}
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	is := func(names ...string) bool {
+		if len(names) != len(segments) {
+			return false
+		}
+		for i, name := range names {
+			if name != "" && !strings.EqualFold(segments[i], name) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var req any
+	switch {
+	case is("wallet", "crypto", "", "withdraw"):
+		req = &PostNewCryptoWithdrawRequest{}
+	case is("wallet", "fiat", "", "withdraw"):
+		req = &PostNewFiatWithdrawRequest{}
+	case is("pay"):
+		req = &PostPayRequest{}
+	case is("account", "subaccounts", "transfer"):
+		req = &PostSubaccountTransferRequest{}
+	default:
+		return nil, nil
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, fmt.Errorf("valr: decoding %s request: %w", path, err)
+	}
+	// The currency sent is the one in the path, not the body.
+	switch r := req.(type) {
+	case *PostNewCryptoWithdrawRequest:
+		r.Asset = segments[2]
+	case *PostNewFiatWithdrawRequest:
+		r.Asset = segments[2]
+	}
+	return req, nil
+}
+
+// guard checks a call against the client's withdrawal policy and approval
+// gate, whichever method made it. Withdrawals and payments count towards
+// the daily cap and, like large transfers, wait for approval before they
+// are signed. The returned function must be called with the outcome of the
+// call; it releases the reservation if the call was definitely not carried
+// out.
+func (cl *Client) guard(ctx context.Context, method, path string, body []byte) (func(sent bool, err error), error) {
+	noop := func(bool, error) {}
+	p, g := cl.withdrawalPolicy, cl.approvals
+	if p == nil && g == nil {
+		return noop, nil
+	}
+	req, err := guardedRequest(method, path, body)
+	if err != nil {
+		return noop, err
+	}
+
+	switch r := req.(type) {
+	case *PostSubaccountTransferRequest:
+		if g != nil {
+			return noop, g.await(ctx, IntentTransfer, r.Currency, r.ToID, r.Amount)
+		}
+		return noop, nil
+	case withdrawal:
+		release := func() {}
+		if p != nil {
+			if release, err = p.reserve(r); err != nil {
+				return noop, err
+			}
+		}
+		if g != nil {
+			currency, destination, amount := r.withdrawal()
+			if err := g.await(ctx, IntentWithdrawal, currency, destination, amount); err != nil {
+				release()
+				return noop, err
+			}
+		}
+		return func(sent bool, err error) {
+			// After any other error VALR may have made the withdrawal, so
+			// it stays counted against the daily cap.
+			if !sent || IsRejected(err) {
+				release()
+			}
+		}, nil
+	default:
+		return noop, nil
+	}
+}
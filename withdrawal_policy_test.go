@@ -0,0 +1,189 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/donohutcheon/valr-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestWithdrawalPolicy(t *testing.T) {
+	sent := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		_, _ = w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	policy := valr.NewWithdrawalPolicy().
+		AllowAddress("BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq").
+		SetDailyCap("BTC", decimal.RequireFromString("1"))
+	cl.SetWithdrawalPolicy(policy)
+
+	ctx := context.Background()
+	withdraw := func(address, amount string) error {
+		_, err := cl.PostNewCryptoWithdrawRequest(ctx, &valr.PostNewCryptoWithdrawRequest{
+			Asset:   "BTC",
+			Address: address,
+			Amount:  decimal.RequireFromString(amount),
+		})
+		return err
+	}
+
+	if err := withdraw("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "0.1"); !errors.Is(err, valr.ErrWithdrawalNotAllowed) {
+		t.Errorf("Expected ErrWithdrawalNotAllowed, got %v", err)
+	}
+	if err := withdraw("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "0.6"); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if err := withdraw("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "0.6"); !errors.Is(err, valr.ErrWithdrawalCapExceeded) {
+		t.Errorf("Expected ErrWithdrawalCapExceeded, got %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("Expected 1 request sent, got %d", sent)
+	}
+	if w := policy.Withdrawn("btc"); !w.Equal(decimal.RequireFromString("0.6")) {
+		t.Errorf("Expected %s, got %s", "0.6", w)
+	}
+}
+
+func TestWithdrawalPolicyFailures(t *testing.T) {
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code":-1,"message":"failed"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	policy := valr.NewWithdrawalPolicy().
+		AllowAddress("BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq").
+		SetDailyCap("BTC", decimal.RequireFromString("1"))
+	cl.SetWithdrawalPolicy(policy)
+
+	withdraw := func(amount string) error {
+		_, err := cl.PostNewCryptoWithdrawRequest(context.Background(), &valr.PostNewCryptoWithdrawRequest{
+			Asset:   "BTC",
+			Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+			Amount:  decimal.RequireFromString(amount),
+		})
+		return err
+	}
+
+	// A rejected withdrawal was not made, so it doesn't count.
+	status = http.StatusBadRequest
+	if err := withdraw("0.6"); err == nil || !valr.IsRejected(err) {
+		t.Fatalf("Expected a rejection, got %v", err)
+	}
+	if w := policy.Withdrawn("BTC"); !w.IsZero() {
+		t.Errorf("Expected nothing withdrawn, got %s", w)
+	}
+
+	// A server error may hide a withdrawal that was made, so it counts.
+	status = http.StatusInternalServerError
+	if err := withdraw("0.6"); err == nil || valr.IsRejected(err) {
+		t.Fatalf("Expected a server error, got %v", err)
+	}
+	if w := policy.Withdrawn("BTC"); !w.Equal(decimal.RequireFromString("0.6")) {
+		t.Errorf("Expected %s, got %s", "0.6", w)
+	}
+	if err := withdraw("0.6"); !errors.Is(err, valr.ErrWithdrawalCapExceeded) {
+		t.Errorf("Expected ErrWithdrawalCapExceeded, got %v", err)
+	}
+}
+
+func TestWithdrawalPolicyGenericCalls(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer srv.Close()
+
+	cl := valr.NewClient()
+	cl.SetBaseURL(srv.URL)
+	cl.SetRetryPolicy(nil)
+	if err := cl.SetAuth("key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	policy := valr.NewWithdrawalPolicy().
+		AllowAddress("BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq").
+		AllowBankAccount("bank-1").
+		SetDailyCap("BTC", decimal.RequireFromString("1"))
+	cl.SetWithdrawalPolicy(policy)
+	ctx := context.Background()
+
+	err := cl.Call(ctx, http.MethodPost, "/wallet/crypto/BTC/withdraw", map[string]string{
+		"address": "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"amount":  "0.1",
+	}, nil, true)
+	if !errors.Is(err, valr.ErrWithdrawalNotAllowed) {
+		t.Errorf("Expected ErrWithdrawalNotAllowed, got %v", err)
+	}
+
+	// The currency is taken from the path, whatever the body says.
+	err = cl.Call(ctx, http.MethodPost, "/wallet/crypto/ETH/withdraw", map[string]string{
+		"currencyCode": "BTC",
+		"address":      "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		"amount":       "0.1",
+	}, nil, true)
+	if !errors.Is(err, valr.ErrWithdrawalNotAllowed) {
+		t.Errorf("Expected ErrWithdrawalNotAllowed, got %v", err)
+	}
+
+	_, err = valr.Post[map[string]any](ctx, cl, "/wallet/fiat/ZAR/withdraw", map[string]string{
+		"linkedBankAccountId": "bank-2",
+		"amount":              "100",
+	})
+	if !errors.Is(err, valr.ErrWithdrawalNotAllowed) {
+		t.Errorf("Expected ErrWithdrawalNotAllowed, got %v", err)
+	}
+
+	_, err = valr.Post[map[string]any](ctx, cl, "/pay", map[string]string{
+		"currency":       "BTC",
+		"amount":         "0.1",
+		"recipientPayId": "someone",
+	})
+	if !errors.Is(err, valr.ErrWithdrawalNotAllowed) {
+		t.Errorf("Expected ErrWithdrawalNotAllowed, got %v", err)
+	}
+
+	// A body that can't be checked is not sent.
+	if err := cl.Call(ctx, http.MethodPost, "/wallet/crypto/BTC/withdraw", nil, nil, true); err == nil {
+		t.Error("Expected an error withdrawing without a body")
+	}
+
+	// Allowed withdrawals count towards the same cap whichever way they
+	// are made.
+	err = cl.Call(ctx, http.MethodPost, "//wallet/crypto/btc/withdraw/", map[string]string{
+		"address": "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		"amount":  "0.6",
+	}, nil, true)
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if _, err := cl.PostNewCryptoWithdrawRequest(ctx, &valr.PostNewCryptoWithdrawRequest{
+		Asset:   "BTC",
+		Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		Amount:  decimal.RequireFromString("0.6"),
+	}); !errors.Is(err, valr.ErrWithdrawalCapExceeded) {
+		t.Errorf("Expected ErrWithdrawalCapExceeded, got %v", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("Expected 1 request sent, got %q", paths)
+	}
+}